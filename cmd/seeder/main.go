@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -18,6 +17,7 @@ import (
 
 	"github.com/foxxcyber/price-feed/internal/config"
 	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
 )
 
 const (
@@ -34,15 +34,37 @@ type CityData struct {
 }
 
 func main() {
+	// Load .env before reading flag defaults from the environment
+	godotenv.Load()
+
 	// Command line flags
 	dryRun := flag.Bool("dry-run", false, "Preview changes without writing to database")
 	minZips := flag.Int("min-zips", 1, "Minimum zip codes required for a city to be included")
 	stateFilter := flag.String("state", "", "Only import cities from this state (e.g., 'CO')")
 	localFile := flag.String("file", "", "Use local CSV file instead of downloading")
+	sourceName := flag.String("source", envOrDefault("SEEDER_ZIP_SOURCE", "us"), "Dataset to import: 'us' or 'geonames:<CC>' (env SEEDER_ZIP_SOURCE)")
+	columns := flag.String("columns", os.Getenv("SEEDER_ZIP_COLUMNS"), "Override column mapping, e.g. 'state=4,zip=1,city=2,county=5' (env SEEDER_ZIP_COLUMNS)")
+	incremental := flag.Bool("incremental", false, "Only import zip codes not already assigned to a region")
+	country := flag.String("country", "", "Country code for imported regions; sets their currency and locale (default: the source's country)")
 	flag.Parse()
 
-	// Load .env
-	godotenv.Load()
+	source, err := resolveSource(*sourceName)
+	if err != nil {
+		log.Fatalf("Invalid source: %v", err)
+	}
+	if err := applyColumnOverrides(source, *columns); err != nil {
+		log.Fatalf("Invalid column mapping: %v", err)
+	}
+	if *country != "" {
+		source.Country = strings.ToUpper(strings.TrimSpace(*country))
+	}
+	if len(source.Country) != 2 {
+		log.Fatalf("Invalid country %q, expected a two-letter code", source.Country)
+	}
+	locale, known := models.LocaleForCountry(source.Country)
+	if !known {
+		log.Printf("Warning: no currency/locale known for %s, using %s/%s", source.Country, locale.Currency, locale.Locale)
+	}
 
 	// Load config
 	cfg := config.Load()
@@ -54,7 +76,7 @@ func main() {
 	}
 	defer db.Close()
 
-	log.Println("Starting zip code data import...")
+	log.Printf("Starting zip code data import (source: %s)...", source.Name)
 
 	// Get CSV data
 	var reader io.Reader
@@ -67,21 +89,27 @@ func main() {
 		reader = file
 		log.Printf("Reading from local file: %s", *localFile)
 	} else {
-		log.Printf("Downloading zip code data from: %s", zipCodeDataURL)
-		resp, err := http.Get(zipCodeDataURL)
+		log.Printf("Downloading zip code data from: %s", source.URL)
+		body, err := openSource(source)
 		if err != nil {
 			log.Fatalf("Failed to download zip code data: %v", err)
 		}
-		defer resp.Body.Close()
+		defer body.Close()
+		reader = body
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			log.Fatalf("Failed to download: HTTP %d", resp.StatusCode)
+	// In incremental mode skip zip codes that already belong to a region
+	var skipZips map[string]bool
+	if *incremental {
+		skipZips, err = loadExistingZipCodes(db)
+		if err != nil {
+			log.Fatalf("Failed to load existing zip codes: %v", err)
 		}
-		reader = resp.Body
+		log.Printf("Incremental mode: skipping %d zip codes already imported", len(skipZips))
 	}
 
 	// Parse CSV and aggregate by city
-	cities, err := parseZipCodeData(reader, *stateFilter, *minZips)
+	cities, err := parseZipCodeData(reader, source, *stateFilter, *minZips, skipZips)
 	if err != nil {
 		log.Fatalf("Failed to parse zip code data: %v", err)
 	}
@@ -95,7 +123,7 @@ func main() {
 	}
 
 	// Import to database
-	imported, updated, err := importCities(db, cities)
+	imported, updated, err := importCities(db, cities, source.Country, locale)
 	if err != nil {
 		log.Fatalf("Failed to import cities: %v", err)
 	}
@@ -103,34 +131,39 @@ func main() {
 	log.Printf("Import complete: %d new cities, %d updated", imported, updated)
 }
 
-// parseZipCodeData reads CSV and aggregates zip codes by city
-func parseZipCodeData(reader io.Reader, stateFilter string, minZips int) ([]CityData, error) {
+// parseZipCodeData reads a delimited dataset using the source's column mapping and
+// aggregates zip codes by city. Zip codes in skipZips are ignored.
+func parseZipCodeData(reader io.Reader, source *ZipSource, stateFilter string, minZips int, skipZips map[string]bool) ([]CityData, error) {
 	csvReader := csv.NewReader(bufio.NewReader(reader))
+	csvReader.Comma = source.Delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
 
-	// Read header
-	header, err := csvReader.Read()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
-	}
-
-	// Find column indices
-	// Expected: state_fips,state,state_abbr,zipcode,county,city
+	// Map header names to column indices (sources without a header use numeric mappings)
 	colMap := make(map[string]int)
-	for i, col := range header {
-		colMap[strings.ToLower(strings.TrimSpace(col))] = i
+	if source.HasHeader {
+		header, err := csvReader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		for i, col := range header {
+			colMap[strings.ToLower(strings.TrimSpace(col))] = i
+		}
 	}
 
-	stateCol, ok := colMap["state_abbr"]
-	if !ok {
-		stateCol = colMap["state"]
+	stateCol := resolveColumn(source.Columns.State, colMap)
+	zipCol := resolveColumn(source.Columns.Zip, colMap)
+	cityCol := resolveColumn(source.Columns.City, colMap)
+	countyCol := resolveColumn(source.Columns.County, colMap)
+	if stateCol < 0 || zipCol < 0 || cityCol < 0 {
+		return nil, fmt.Errorf("dataset is missing a state, zip or city column for source %s", source.Name)
 	}
-	zipCol := colMap["zipcode"]
-	cityCol := colMap["city"]
-	countyCol := colMap["county"]
+	maxCol := max(stateCol, zipCol, cityCol)
 
 	// Aggregate zip codes by city+state
 	cityMap := make(map[string]*CityData)
 	rowCount := 0
+	skippedLongState := 0
 
 	for {
 		record, err := csvReader.Read()
@@ -143,13 +176,16 @@ func parseZipCodeData(reader io.Reader, stateFilter string, minZips int) ([]City
 		}
 
 		rowCount++
+		if len(record) <= maxCol {
+			continue
+		}
 
 		// Extract fields
 		state := strings.TrimSpace(record[stateCol])
 		zipCode := strings.TrimSpace(record[zipCol])
 		city := strings.TrimSpace(record[cityCol])
 		county := ""
-		if countyCol < len(record) {
+		if countyCol >= 0 && countyCol < len(record) {
 			county = strings.TrimSpace(record[countyCol])
 		}
 
@@ -163,6 +199,16 @@ func parseZipCodeData(reader io.Reader, stateFilter string, minZips int) ([]City
 			continue
 		}
 
+		if skipZips[zipCode] {
+			continue
+		}
+
+		// regions.state holds a two-character code
+		if len(state) > 2 {
+			skippedLongState++
+			continue
+		}
+
 		// Normalize state to uppercase
 		state = strings.ToUpper(state)
 
@@ -185,6 +231,9 @@ func parseZipCodeData(reader io.Reader, stateFilter string, minZips int) ([]City
 	}
 
 	log.Printf("Processed %d rows", rowCount)
+	if skippedLongState > 0 {
+		log.Printf("Warning: skipped %d rows whose state code is longer than 2 characters (adjust -columns)", skippedLongState)
+	}
 
 	// Convert map to slice and filter by min zips
 	var cities []CityData
@@ -207,8 +256,9 @@ func parseZipCodeData(reader io.Reader, stateFilter string, minZips int) ([]City
 	return cities, nil
 }
 
-// importCities imports city data to the regions table using batched transactions
-func importCities(db *database.DB, cities []CityData) (imported, updated int, err error) {
+// importCities imports city data to the regions table using batched transactions.
+// New regions get the country and its currency/locale; existing regions keep theirs.
+func importCities(db *database.DB, cities []CityData, country string, locale models.RegionLocale) (imported, updated int, err error) {
 	ctx := context.Background()
 	batchSize := 500 // Commit every 500 cities to avoid long transactions
 
@@ -219,7 +269,7 @@ func importCities(db *database.DB, cities []CityData) (imported, updated int, er
 		}
 		batch := cities[i:end]
 
-		batchImported, batchUpdated, err := importBatch(ctx, db, batch)
+		batchImported, batchUpdated, err := importBatch(ctx, db, batch, country, locale)
 		if err != nil {
 			return imported, updated, err
 		}
@@ -234,7 +284,7 @@ func importCities(db *database.DB, cities []CityData) (imported, updated int, er
 }
 
 // importBatch imports a batch of cities in a single transaction
-func importBatch(ctx context.Context, db *database.DB, cities []CityData) (imported, updated int, err error) {
+func importBatch(ctx context.Context, db *database.DB, cities []CityData, country string, locale models.RegionLocale) (imported, updated int, err error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
@@ -247,15 +297,15 @@ func importBatch(ctx context.Context, db *database.DB, cities []CityData) (impor
 		var existingZips []string
 		err := tx.QueryRow(ctx, `
 			SELECT id, zip_codes FROM regions
-			WHERE LOWER(name) = LOWER($1) AND state = $2
-		`, city.Name, city.State).Scan(&existingID, &existingZips)
+			WHERE LOWER(name) = LOWER($1) AND state = $2 AND country_code = $3
+		`, city.Name, city.State, country).Scan(&existingID, &existingZips)
 
 		if err == pgx.ErrNoRows {
 			// Insert new city
 			_, err = tx.Exec(ctx, `
-				INSERT INTO regions (name, state, zip_codes)
-				VALUES ($1, $2, $3)
-			`, city.Name, city.State, city.ZipCodes)
+				INSERT INTO regions (name, state, zip_codes, country_code, currency, locale)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, city.Name, city.State, city.ZipCodes, country, locale.Currency, locale.Locale)
 			if err != nil {
 				return imported, updated, fmt.Errorf("failed to insert %s, %s: %w", city.Name, city.State, err)
 			}
@@ -285,6 +335,34 @@ func importBatch(ctx context.Context, db *database.DB, cities []CityData) (impor
 	return imported, updated, nil
 }
 
+// loadExistingZipCodes returns every zip code already assigned to a region
+func loadExistingZipCodes(db *database.DB) (map[string]bool, error) {
+	rows, err := db.Pool.Query(context.Background(), `SELECT DISTINCT unnest(zip_codes) FROM regions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zips := make(map[string]bool)
+	for rows.Next() {
+		var zip string
+		if err := rows.Scan(&zip); err != nil {
+			return nil, err
+		}
+		zips[zip] = true
+	}
+
+	return zips, rows.Err()
+}
+
+// envOrDefault returns an environment variable or a default when it is unset
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 // mergeZipCodes combines two zip code slices, removing duplicates
 func mergeZipCodes(existing, new []string) []string {
 	zipSet := make(map[string]bool)
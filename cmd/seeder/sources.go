@@ -0,0 +1,177 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnMapping names the columns holding each field. Each entry is a list of
+// candidate header names (case-insensitive) or zero-based column indices; the first
+// one present wins. County is optional.
+type ColumnMapping struct {
+	State  []string
+	Zip    []string
+	City   []string
+	County []string
+}
+
+// ZipSource describes a downloadable zip/postal code dataset
+type ZipSource struct {
+	Name        string
+	Description string
+	URL         string
+	Delimiter   rune
+	HasHeader   bool
+	ArchiveFile string // File to read when the download is a .zip archive
+	Country     string // ISO country code assigned to imported regions
+	Columns     ColumnMapping
+}
+
+// geonamesColumns maps the GeoNames postal code export (tab separated, no header):
+// country, postal code, place name, admin1 name, admin1 code, admin2 name, ...
+var geonamesColumns = ColumnMapping{
+	State:  []string{"4", "3"},
+	Zip:    []string{"1"},
+	City:   []string{"2"},
+	County: []string{"5"},
+}
+
+// builtinSources lists the datasets selectable with -source
+var builtinSources = map[string]*ZipSource{
+	"us": {
+		Name:        "us",
+		Description: "US zip codes by state, county and city (scpike/us-state-county-zip)",
+		URL:         zipCodeDataURL,
+		Delimiter:   ',',
+		HasHeader:   true,
+		Country:     "US",
+		Columns: ColumnMapping{
+			State:  []string{"state_abbr", "state"},
+			Zip:    []string{"zipcode"},
+			City:   []string{"city"},
+			County: []string{"county"},
+		},
+	},
+}
+
+// resolveSource returns the dataset for a -source value. Besides the built-in names,
+// "geonames:<CC>" selects the GeoNames postal code export for a country (e.g. geonames:CA).
+func resolveSource(name string) (*ZipSource, error) {
+	name = strings.TrimSpace(name)
+	if src, ok := builtinSources[strings.ToLower(name)]; ok {
+		copied := *src
+		return &copied, nil
+	}
+
+	if country, ok := strings.CutPrefix(strings.ToLower(name), "geonames:"); ok {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 {
+			return nil, fmt.Errorf("geonames source needs a two-letter country code, got %q", country)
+		}
+		return &ZipSource{
+			Name:        "geonames:" + country,
+			Description: "GeoNames postal codes for " + country,
+			URL:         "https://download.geonames.org/export/zip/" + country + ".zip",
+			Delimiter:   '\t',
+			HasHeader:   false,
+			ArchiveFile: country + ".txt",
+			Country:     country,
+			Columns:     geonamesColumns,
+		}, nil
+	}
+
+	names := make([]string, 0, len(builtinSources))
+	for n := range builtinSources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown source %q (available: %s, geonames:<CC>)", name, strings.Join(names, ", "))
+}
+
+// applyColumnOverrides parses a -columns value such as "state=4,zip=1,city=2,county=5"
+// (names or indices, '|' separating alternatives) onto the source's mapping
+func applyColumnOverrides(src *ZipSource, overrides string) error {
+	if strings.TrimSpace(overrides) == "" {
+		return nil
+	}
+
+	for _, pair := range strings.Split(overrides, ",") {
+		field, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid column mapping %q, expected field=column", pair)
+		}
+		candidates := strings.Split(strings.TrimSpace(value), "|")
+
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "state":
+			src.Columns.State = candidates
+		case "zip":
+			src.Columns.Zip = candidates
+		case "city":
+			src.Columns.City = candidates
+		case "county":
+			src.Columns.County = candidates
+		default:
+			return fmt.Errorf("unknown column field %q (use state, zip, city or county)", field)
+		}
+	}
+
+	return nil
+}
+
+// resolveColumn finds the index of the first candidate present in the header.
+// Numeric candidates are used as indices directly. Returns -1 when none match.
+func resolveColumn(candidates []string, header map[string]int) int {
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if idx, err := strconv.Atoi(candidate); err == nil && idx >= 0 {
+			return idx
+		}
+		if idx, ok := header[candidate]; ok {
+			return idx
+		}
+	}
+	return -1
+}
+
+// openSource downloads a dataset, unpacking it when it is a .zip archive
+func openSource(src *ZipSource) (io.ReadCloser, error) {
+	resp, err := http.Get(src.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download: HTTP %d", resp.StatusCode)
+	}
+
+	if src.ArchiveFile == "" {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	for _, f := range archive.File {
+		if strings.EqualFold(f.Name, src.ArchiveFile) {
+			return f.Open()
+		}
+	}
+
+	return nil, fmt.Errorf("archive does not contain %s", src.ArchiveFile)
+}
@@ -39,6 +39,11 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Enforce case-insensitive usernames once existing collisions are resolved
+	if err := database.EnsureUsernameIndex(db); err != nil {
+		log.Printf("Warning: Could not ensure username index: %v", err)
+	}
+
 	// Create admin user if it doesn't exist
 	if err := database.EnsureAdminUser(db, cfg); err != nil {
 		log.Printf("Warning: Could not ensure admin user: %v", err)
@@ -47,6 +52,10 @@ func main() {
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: handlers.ErrorHandler,
+		// Once proxies are configured, only honor X-Forwarded-Proto and friends from them, so
+		// clients cannot claim HTTPS for share links over plain HTTP
+		EnableTrustedProxyCheck: len(cfg.TrustedProxies) > 0,
+		TrustedProxies:          cfg.TrustedProxies,
 	})
 
 	// Global middleware
@@ -59,17 +68,20 @@ func main() {
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
+	app.Use(middleware.SecurityHeaders(cfg))
 
 	// Create handler with dependencies
 	h := handlers.New(db, cfg)
 
 	// Initialize Google Maps service and handler
-	mapsService := services.NewGoogleMapsService(cfg.GoogleMapsAPIKey)
-	mapsHandler := handlers.NewMapsHandler(mapsService, cfg.GoogleMapsAPIKey)
+	mapsService := services.NewGoogleMapsService(cfg.GoogleMapsAPIKey, cfg.MapsDedupeMeters)
+	storeGeocoder := services.NewStoreGeocoder(db, mapsService, cfg)
+	mapsHandler := handlers.NewMapsHandler(db, mapsService, storeGeocoder, cfg.GoogleMapsAPIKey)
 
 	// Initialize Email service and settings handler
 	emailService := services.NewEmailService(db, cfg)
 	settingsHandler := handlers.NewSettingsHandler(db, cfg, emailService)
+	priceAlerter := services.NewPriceAlerter(db, emailService)
 
 	// Initialize Storage service for receipts (load from database settings)
 	var receiptHandler *handlers.ReceiptHandler
@@ -144,7 +156,7 @@ func main() {
 
 		// Create receipt handler
 		receiptHandler = handlers.NewReceiptHandler(
-			db, cfg, storageService, ocrService, receiptParser, itemMatcher,
+			db, cfg, storageService, ocrService, receiptParser, itemMatcher, priceAlerter,
 		)
 		log.Println("Receipt scanning service initialized")
 
@@ -176,6 +188,34 @@ func main() {
 	}
 	initReceiptService()
 
+	// Periodically verify items that many users have confirmed on receipts
+	itemVerifier := services.NewItemVerifier(db, cfg)
+	go itemVerifier.Start(context.Background(), 1*time.Hour)
+
+	// Verify stores once enough distinct users have priced them
+	storeVerifier := services.NewStoreVerifier(db, cfg)
+	go storeVerifier.Start(context.Background(), 1*time.Hour)
+
+	// Replan watched shopping lists when their prices change
+	listWatcher := services.NewListWatcher(db, cfg, emailService)
+	go listWatcher.Start(context.Background(), 30*time.Minute)
+
+	// Generate pantry expiry and low-stock notifications daily
+	inventoryNotifier := services.NewInventoryNotifier(db, cfg, emailService)
+	go inventoryNotifier.Start(context.Background(), 24*time.Hour)
+
+	// Email region price-drop digests; each subscription sets its own daily or weekly schedule
+	regionDigester := services.NewRegionDigester(db, cfg, emailService)
+	go regionDigester.Start(context.Background(), 1*time.Hour)
+
+	// Drop search events past the retention window
+	searchPruner := services.NewSearchPruner(db, cfg)
+	go searchPruner.Start(context.Background(), 24*time.Hour)
+
+	// Regenerate completed recurring shopping lists when their next occurrence comes due
+	listRecurrer := services.NewListRecurrer(db)
+	go listRecurrer.Start(context.Background(), 1*time.Hour)
+
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
@@ -186,6 +226,7 @@ func main() {
 
 	// Create email verification middleware for write operations
 	emailVerified := middleware.EmailVerifiedRequiredFunc(h.CreateEmailVerificationChecker())
+	requireHTTPS := middleware.RequireHTTPS(cfg)
 
 	// Rate limiter for auth endpoints - stricter limits to prevent brute force
 	authLimiter := limiter.New(limiter.Config{
@@ -220,6 +261,13 @@ func main() {
 	users.Put("/:id", emailVerified, h.UpdateUser)
 	users.Post("/:id/change-password", emailVerified, h.ChangePassword)
 	users.Get("/:id/stats", h.GetUserStats)
+	users.Get("/:id/verification-impact", h.GetVerificationImpact)
+	users.Get("/:id/reputation", h.GetUserReputation)
+	users.Get("/:id/price-gap", h.GetUserPriceGap)
+	users.Get("/:id/prices/export", h.ExportUserPrices)
+	users.Get("/:id/region-prefs", h.ListUserRegionPrefs)
+	users.Put("/:id/region-prefs/:region_id", emailVerified, h.SaveUserRegionPref)
+	users.Delete("/:id/region-prefs/:region_id", emailVerified, h.DeleteUserRegionPref)
 
 	// Region routes (public read, admin write)
 	regions := api.Group("/regions")
@@ -228,11 +276,17 @@ func main() {
 	regions.Get("/stats", h.GetRegionStats)
 	regions.Get("/search", h.SearchRegions)
 	regions.Get("/:id", h.GetRegion)
+	regions.Get("/:id/contributors", middleware.AuthOptional(cfg), h.GetRegionContributors)
+
+	// Leaderboard routes (public, names masked per privacy settings)
+	leaderboard := api.Group("/leaderboard", middleware.AuthOptional(cfg))
+	leaderboard.Get("/savings", h.GetSavingsLeaderboard)
 
 	// Admin routes (admin only)
 	admin := api.Group("/admin", middleware.AuthRequired(cfg), middleware.AdminRequired())
 	admin.Post("/users", h.AdminCreateUser)
 	admin.Get("/users", h.AdminListUsers)
+	admin.Get("/users/username-collisions", h.AdminListUsernameCollisions)
 	admin.Get("/users/:id", h.AdminGetUser)
 	admin.Put("/users/:id", h.AdminUpdateUser)
 	admin.Delete("/users/:id", h.AdminDeleteUser)
@@ -247,6 +301,7 @@ func main() {
 	admin.Get("/settings", settingsHandler.GetAllSettings)
 	admin.Get("/settings/:category", settingsHandler.GetSettingsByCategory)
 	admin.Put("/settings/:category", settingsHandler.UpdateSettings)
+	admin.Get("/config/effective", settingsHandler.GetEffectiveConfig)
 
 	// Admin email routes
 	admin.Get("/email/config", settingsHandler.GetEmailConfig)
@@ -266,24 +321,48 @@ func main() {
 	stores := api.Group("/stores")
 	stores.Get("/", h.ListStores)
 	stores.Get("/stats", h.GetStoreStats)
-	stores.Get("/search", h.SearchStores)
+	stores.Get("/search", middleware.AuthOptional(cfg), h.SearchStores)
+	stores.Get("/nearby", middleware.AuthOptional(cfg), h.GetNearbyStores)
+	stores.Post("/carrying", middleware.AuthOptional(cfg), h.FindStoresCarrying)
 	stores.Get("/:id", h.GetStore)
+	stores.Get("/:id/quality", middleware.AuthOptional(cfg), h.GetStoreQuality)
+	stores.Get("/:id/volatility", middleware.AuthOptional(cfg), h.GetStorePriceVolatility)
+	stores.Get("/:id/check-sheet", middleware.AuthRequired(cfg), h.GetStoreCheckSheet)
+	stores.Get("/:id/my-coverage", middleware.AuthRequired(cfg), h.GetMyStoreCoverage)
 	stores.Post("/", middleware.AuthRequired(cfg), emailVerified, h.UserCreateStore)
 	stores.Put("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserUpdateStore)
 	stores.Delete("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserDeleteStore)
+	stores.Post("/:id/prices/bulk-adjust", middleware.AuthRequired(cfg), emailVerified, h.BulkAdjustStorePrices)
+
+	// Chain analysis routes (public)
+	chains := api.Group("/chains")
+	chains.Get("/:name/parity", h.GetChainParity)
 
 	// Admin store routes
 	admin.Post("/stores", h.CreateStore)
 	admin.Put("/stores/:id", h.UpdateStore)
 	admin.Delete("/stores/:id", h.DeleteStore)
 	admin.Post("/stores/:id/verify", h.VerifyStore)
+	admin.Get("/stores/data-issues", h.AdminListStoreDataIssues)
+	admin.Post("/stores/geocode-missing", mapsHandler.GeocodeMissingStores)
+	admin.Post("/stores/import", mapsHandler.ImportStores)
+	admin.Post("/stores/assign-regions", h.AdminAssignStoreRegions)
 
 	// Item routes (public read with optional auth for visibility, authenticated write)
 	items := api.Group("/items", middleware.AuthOptional(cfg))
 	items.Get("/", h.ListItems)
 	items.Get("/stats", h.GetItemStats)
 	items.Get("/search", h.SearchItems)
+	items.Get("/suggest-tags", h.SuggestItemTags)
+	items.Get("/barcode/:code", h.GetItemByBarcode)
 	items.Get("/:id", h.GetItem)
+	items.Get("/:id/best-day", h.GetBestDayToBuy)
+	items.Get("/:id/price-histogram", h.GetPriceHistogram)
+	items.Get("/:id/region-prices", h.GetItemRegionPrices)
+	items.Get("/:id/lowest-ever", h.GetLowestPriceEver)
+	items.Get("/:id/size-comparison", h.GetItemSizeComparison)
+	items.Get("/:id/equivalents", h.GetItemEquivalents)
+	items.Get("/:id/frequently-bought-with", middleware.AuthOptional(cfg), h.GetFrequentlyBoughtWith)
 	items.Post("/", middleware.AuthRequired(cfg), emailVerified, h.UserCreateItem)
 	items.Put("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserUpdateItem)
 	items.Delete("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserDeleteItem)
@@ -296,22 +375,39 @@ func main() {
 	admin.Post("/items", h.CreateItem)
 	admin.Put("/items/:id", h.UpdateItem)
 	admin.Delete("/items/:id", h.DeleteItem)
+	admin.Post("/items/:id/restore", h.RestoreItem)
+	admin.Post("/items/refresh-best-prices", h.RefreshItemBestPrices)
+	admin.Put("/items/:id/product-group", h.SetItemProductGroup)
+	admin.Post("/product-groups", h.CreateProductGroup)
+	admin.Get("/item-name-rules", h.AdminListItemNameRules)
+	admin.Put("/item-name-rules", h.AdminUpsertItemNameRule)
+	admin.Delete("/item-name-rules/:id", h.AdminDeleteItemNameRule)
+
+	// Token-authorized price import (no login; the import token is the credential).
+	// Registered ahead of the import group so its auth middleware does not apply.
+	api.Post("/import/prices", h.ImportPricesWithToken)
 
 	// Import routes (authenticated, email verification required)
 	importRoutes := api.Group("/import", middleware.AuthRequired(cfg), emailVerified)
 	importRoutes.Post("/shopping-list", h.ParseShoppingList)
 	importRoutes.Post("/create-items", h.BulkCreateItems)
 
+	// Admin import token routes
+	admin.Post("/import-tokens", h.AdminCreateImportToken)
+	admin.Get("/import-tokens", h.AdminListImportTokens)
+
 	// Price routes (public read, authenticated write)
-	prices := api.Group("/prices")
+	prices := api.Group("/prices", middleware.AuthOptional(cfg))
 	prices.Get("/", h.ListPrices)
 	prices.Get("/stats", h.GetPriceStats)
+	prices.Get("/drops", h.GetPriceDrops)
 	prices.Get("/by-store/:store_id", h.GetPricesByStore)
 	prices.Get("/by-item/:item_id", h.GetPricesByItem)
 	prices.Get("/history/:item_id", h.GetPriceHistory)
 	prices.Get("/:id", h.GetPrice)
 	prices.Post("/", middleware.AuthRequired(cfg), emailVerified, h.CreatePrice)
 	prices.Post("/:id/verify", middleware.AuthRequired(cfg), emailVerified, h.VerifyPrice)
+	prices.Post("/:id/flag", middleware.AuthRequired(cfg), emailVerified, h.FlagPrice)
 	prices.Put("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserUpdatePrice)
 	prices.Delete("/:id", middleware.AuthRequired(cfg), emailVerified, h.UserDeletePrice)
 
@@ -330,11 +426,22 @@ func main() {
 	lists.Put("/:id/items/:item_id", emailVerified, h.UpdateListItem)
 	lists.Delete("/:id/items/:item_id", emailVerified, h.RemoveItemFromList)
 	lists.Post("/:id/build-plan", h.BuildShoppingPlan)
+	lists.Get("/:id/nearby-stores", h.GetListNearbyStores)
 	lists.Post("/:id/complete", emailVerified, h.CompleteShoppingList)
 	lists.Post("/:id/reopen", emailVerified, h.ReopenShoppingList)
+	lists.Post("/:id/reconcile", emailVerified, h.ReconcileShoppingList)
 	lists.Post("/:id/duplicate", emailVerified, h.DuplicateShoppingList)
-	lists.Post("/:id/share", emailVerified, h.GenerateShareLink)
-	lists.Post("/:id/email", emailVerified, h.EmailShoppingList)
+	lists.Post("/:id/merge", emailVerified, h.MergeShoppingList)
+	lists.Post("/:id/share", emailVerified, requireHTTPS, h.GenerateShareLink)
+	lists.Post("/:id/email", emailVerified, requireHTTPS, h.EmailShoppingList)
+	lists.Get("/:id/calendar.ics", h.GetListCalendar)
+	lists.Get("/:id/inflation", h.GetListInflation)
+	lists.Post("/:id/snapshots", emailVerified, h.CreateListSnapshot)
+	lists.Get("/:id/snapshots", h.ListListSnapshots)
+	lists.Get("/:id/compare-snapshots", h.CompareListSnapshots)
+	lists.Post("/:id/watch", emailVerified, h.WatchShoppingList)
+	lists.Delete("/:id/watch", h.UnwatchShoppingList)
+	lists.Put("/:id/recurrence", emailVerified, h.SetListRecurrence)
 
 	// Inventory routes (authenticated)
 	inventory := api.Group("/inventory", middleware.AuthRequired(cfg))
@@ -344,15 +451,19 @@ func main() {
 	inventory.Get("/expiring", h.GetExpiringItems)
 	inventory.Get("/locations", h.GetInventoryLocations)
 	inventory.Get("/active-lists", h.GetActiveShoppingListsForInventory)
+	inventory.Get("/notifications", h.GetInventoryNotifications)
+	inventory.Post("/notifications/:id/ack", h.AcknowledgeInventoryNotification)
 	inventory.Get("/:id", h.GetInventoryItem)
+	inventory.Get("/:id/consumption", h.GetInventoryConsumption)
 	inventory.Post("/", emailVerified, h.CreateInventoryItem)
+	inventory.Post("/restock-list", emailVerified, h.BuildRestockList)
 	inventory.Put("/:id", emailVerified, h.UpdateInventoryItem)
 	inventory.Delete("/:id", emailVerified, h.DeleteInventoryItem)
 	inventory.Post("/:id/adjust", emailVerified, h.AdjustInventoryQuantity)
 	inventory.Post("/:id/add-to-list", emailVerified, h.AddInventoryToShoppingList)
 
 	// Public share routes (no auth required)
-	share := api.Group("/share")
+	share := api.Group("/share", requireHTTPS)
 	share.Get("/:token", h.GetSharedList)
 	share.Post("/:token/items/:itemId/toggle", h.ToggleSharedListItem)
 
@@ -362,17 +473,48 @@ func main() {
 		receipts.Post("/upload", emailVerified, receiptHandler.UploadReceipt)
 		receipts.Post("/manual", emailVerified, receiptHandler.CreateManualReceipt)
 		receipts.Get("/", receiptHandler.ListReceipts)
-		receipts.Get("/spending-summary", receiptHandler.GetSpendingSummary)
+		receipts.Get("/spending-summary", h.GetSpendingSummary)
 		receipts.Get("/:id", receiptHandler.GetReceipt)
+		receipts.Get("/:id/conflicts", receiptHandler.GetReceiptConflicts)
 		receipts.Put("/:id/items/:itemId", emailVerified, receiptHandler.UpdateReceiptItem)
 		receipts.Post("/:id/confirm", emailVerified, receiptHandler.ConfirmReceipt)
 		receipts.Delete("/:id", emailVerified, receiptHandler.DeleteReceipt)
 		receipts.Get("/:id/image", receiptHandler.GetReceiptImage)
+		receipts.Post("/:id/rematch", emailVerified, receiptHandler.RematchReceipt)
+		receipts.Post("/:id/reprocess", emailVerified, receiptHandler.ReprocessReceipt)
+		admin.Post("/receipts/rematch", receiptHandler.RematchAllReceipts)
+		admin.Get("/receipts/ocr-preprocessing", receiptHandler.GetOCRPreprocessingStats)
+		admin.Get("/receipts/metrics", receiptHandler.GetReceiptMetrics)
+		admin.Post("/storage/reconcile", receiptHandler.ReconcileStorage)
 	}
 
+	// Trending searches (public, anonymized)
+	api.Get("/search/trending", h.GetTrendingSearches)
+
+	// Current user digest routes
+	me := api.Group("/me", middleware.AuthRequired(cfg))
+	me.Get("/whats-new", h.GetWhatsNew)
+	me.Get("/notifications", h.GetNotificationPreferences)
+	me.Put("/notifications", emailVerified, h.UpdateNotificationPreferences)
+	me.Get("/region-subscriptions", h.ListRegionSubscriptions)
+	me.Post("/region-subscriptions", emailVerified, h.CreateRegionSubscription)
+	me.Get("/region-subscriptions/:id", h.GetRegionSubscription)
+	me.Put("/region-subscriptions/:id", emailVerified, h.UpdateRegionSubscription)
+	me.Delete("/region-subscriptions/:id", h.DeleteRegionSubscription)
+
+	// Price alert routes
+	alerts := api.Group("/alerts", middleware.AuthRequired(cfg))
+	alerts.Get("/", h.ListPriceAlerts)
+	alerts.Post("/", emailVerified, h.CreatePriceAlert)
+	alerts.Post("/:id/test", h.TestPriceAlert)
+	alerts.Delete("/:id", emailVerified, h.DeletePriceAlert)
+
 	// Price comparison route (authenticated)
 	api.Get("/compare", middleware.AuthRequired(cfg), h.GetPriceComparison)
 
+	// Spending summary (receipts and completed lists, JSON or CSV)
+	api.Get("/spending-summary", middleware.AuthRequired(cfg), h.GetSpendingSummary)
+
 	// Maps config route (public - needed for registration)
 	api.Get("/maps/config", mapsHandler.GetConfig)
 
@@ -385,7 +527,7 @@ func main() {
 	maps.Get("/place/:place_id", mapsHandler.GetPlaceDetails)
 
 	// Shared list page route (serves the HTML page for shared lists)
-	app.Get("/share/:token", func(c *fiber.Ctx) error {
+	app.Get("/share/:token", requireHTTPS, func(c *fiber.Ctx) error {
 		return c.SendFile("./web/share/index.html")
 	})
 
@@ -30,8 +30,16 @@ type Config struct {
 	// Environment
 	Environment string
 
+	// Transport security
+	ForceHTTPS     bool     // share tokens are only issued and accepted over HTTPS
+	HSTSMaxAge     int      // seconds, 0 disables the Strict-Transport-Security header
+	TrustedProxies []string // IPs or CIDRs whose X-Forwarded-* headers are believed; required by ForceHTTPS
+
 	// Google Maps
 	GoogleMapsAPIKey string
+	// MAPS_DEDUPE_RADIUS_METERS: nearby places with similar names within this radius are collapsed
+	// into one result listing the others as duplicates. Off (0) unless set; 75 suits most areas.
+	MapsDedupeMeters int
 
 	// SMTP Email
 	SMTPHost     string
@@ -111,6 +119,14 @@ func Load() *Config {
 		log.Printf("Warning: Database SSL is disabled. Enable SSL for production: sslmode=require")
 	}
 
+	// The server only listens on plain HTTP, so HTTPS can only be detected through a trusted
+	// TLS-terminating proxy; enforcing it without one would reject every share request
+	forceHTTPS := getBoolEnv("FORCE_HTTPS", false)
+	trustedProxies := getListEnv("TRUSTED_PROXIES")
+	if forceHTTPS && len(trustedProxies) == 0 {
+		log.Fatal("FATAL: FORCE_HTTPS requires TRUSTED_PROXIES to list the TLS-terminating proxy")
+	}
+
 	return &Config{
 		Port:             getEnv("PORT", "8080"),
 		AllowedOrigins:   allowedOrigins,
@@ -121,7 +137,11 @@ func Load() *Config {
 		AdminEmail:       getEnv("ADMIN_EMAIL", "admin@pricefeed.local"),
 		AdminPassword:    getEnv("ADMIN_PASSWORD", ""),
 		Environment:      env,
+		ForceHTTPS:       forceHTTPS,
+		HSTSMaxAge:       getIntEnv("HSTS_MAX_AGE_SECONDS", 31536000),
+		TrustedProxies:   trustedProxies,
 		GoogleMapsAPIKey: getEnv("GOOGLE_API_KEY_MAPS", ""),
+		MapsDedupeMeters: getIntEnv("MAPS_DEDUPE_RADIUS_METERS", 0),
 		SMTPHost:         getEnv("SMTP_HOST", ""),
 		SMTPPort:         getIntEnv("SMTP_PORT", 587),
 		SMTPUser:         getEnv("SMTP_USER", ""),
@@ -138,6 +158,51 @@ func Load() *Config {
 	}
 }
 
+// Value is a resolved process setting, keyed by its environment variable
+type Value struct {
+	Key       string
+	Value     string
+	FromEnv   bool // false when the built-in default (or a generated secret) is in use
+	Sensitive bool
+}
+
+// Values lists the loaded configuration for diagnostics. Sensitive values are returned as is
+// and must be masked by the caller before being shown.
+func (c *Config) Values() []Value {
+	v := func(key, value string, sensitive bool) Value {
+		return Value{Key: key, Value: value, FromEnv: os.Getenv(key) != "", Sensitive: sensitive}
+	}
+	return []Value{
+		v("ENVIRONMENT", c.Environment, false),
+		v("PORT", c.Port, false),
+		v("ALLOWED_ORIGINS", c.AllowedOrigins, false),
+		v("FORCE_HTTPS", strconv.FormatBool(c.ForceHTTPS), false),
+		v("HSTS_MAX_AGE_SECONDS", strconv.Itoa(c.HSTSMaxAge), false),
+		v("TRUSTED_PROXIES", strings.Join(c.TrustedProxies, ","), false),
+		v("DATABASE_URL", c.DatabaseURL, true),
+		v("JWT_SECRET", c.JWTSecret, true),
+		v("JWT_EXPIRY_HOURS", strconv.Itoa(int(c.JWTExpiry/time.Hour)), false),
+		v("REFRESH_JWT_EXPIRY_DAYS", strconv.Itoa(int(c.RefreshJWTExpiry/(24*time.Hour))), false),
+		v("ADMIN_EMAIL", c.AdminEmail, false),
+		v("ADMIN_PASSWORD", c.AdminPassword, true),
+		v("GOOGLE_API_KEY_MAPS", c.GoogleMapsAPIKey, true),
+		v("MAPS_DEDUPE_RADIUS_METERS", strconv.Itoa(c.MapsDedupeMeters), false),
+		v("SMTP_ENABLED", strconv.FormatBool(c.SMTPEnabled), false),
+		v("SMTP_HOST", c.SMTPHost, false),
+		v("SMTP_PORT", strconv.Itoa(c.SMTPPort), false),
+		v("SMTP_USER", c.SMTPUser, false),
+		v("SMTP_PASSWORD", c.SMTPPassword, true),
+		v("SMTP_FROM_ADDR", c.SMTPFromAddr, false),
+		v("SMTP_FROM_NAME", c.SMTPFromName, false),
+		v("S3_ENDPOINT", c.S3Endpoint, false),
+		v("S3_ACCESS_KEY", c.S3AccessKey, true),
+		v("S3_SECRET_KEY", c.S3SecretKey, true),
+		v("S3_BUCKET", c.S3Bucket, false),
+		v("S3_USE_SSL", strconv.FormatBool(c.S3UseSSL), false),
+		v("S3_REGION", c.S3Region, false),
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -154,6 +219,17 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getListEnv splits a comma-separated variable, dropping blank entries
+func getListEnv(key string) []string {
+	var list []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrPriceAlertNotFound = errors.New("price alert not found")
+
+// CreatePriceAlert adds a price alert for the user
+func (db *DB) CreatePriceAlert(ctx context.Context, userID int, req *models.CreatePriceAlertRequest) (*models.PriceAlert, error) {
+	var id int
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO price_alerts (user_id, item_id, store_id, target_price)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, userID, req.ItemID, req.StoreID, req.TargetPrice).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := db.listPriceAlerts(ctx, userID, &id)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, ErrPriceAlertNotFound
+	}
+	return alerts[0], nil
+}
+
+// ListPriceAlerts returns the user's price alerts, newest first
+func (db *DB) ListPriceAlerts(ctx context.Context, userID int) ([]*models.PriceAlert, error) {
+	return db.listPriceAlerts(ctx, userID, nil)
+}
+
+// listPriceAlerts returns the user's alerts, or only the one with id when given, with the best
+// current price each alert can see. That is the same set of prices TriggerPriceAlerts fires on:
+// shared prices and the owner's own, at public stores and the owner's own.
+func (db *DB) listPriceAlerts(ctx context.Context, userID int, id *int) ([]*models.PriceAlert, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT a.id, a.user_id, a.item_id, i.name, a.store_id, s.name,
+		       a.target_price::float8, a.last_triggered_at, a.created_at,
+		       best.price, best.store_id, best.store_name
+		FROM price_alerts a
+		JOIN items i ON a.item_id = i.id
+		LEFT JOIN stores s ON a.store_id = s.id
+		LEFT JOIN LATERAL (
+			SELECT sp.price::float8 AS price, bs.id AS store_id, bs.name AS store_name
+			FROM store_prices sp
+			JOIN stores bs ON sp.store_id = bs.id
+			WHERE sp.item_id = a.item_id
+			  AND (a.store_id IS NULL OR sp.store_id = a.store_id)
+			  AND (sp.is_shared = true OR sp.user_id = a.user_id)
+			  AND (COALESCE(bs.is_private, false) = false OR bs.created_by = a.user_id)
+			ORDER BY sp.price ASC, sp.updated_at DESC
+			LIMIT 1
+		) best ON true
+		WHERE a.user_id = $1 AND ($2::int IS NULL OR a.id = $2)
+		ORDER BY a.created_at DESC
+	`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []*models.PriceAlert{}
+	for rows.Next() {
+		a := &models.PriceAlert{}
+		if err := rows.Scan(&a.ID, &a.UserID, &a.ItemID, &a.ItemName, &a.StoreID, &a.StoreName,
+			&a.TargetPrice, &a.LastTriggeredAt, &a.CreatedAt,
+			&a.BestPrice, &a.BestStoreID, &a.BestStoreName); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
+
+// TestPriceAlert evaluates one of the user's alerts against current prices. Nothing is recorded
+// and no one is notified.
+func (db *DB) TestPriceAlert(ctx context.Context, id, userID int) (*models.PriceAlertTestResult, error) {
+	alerts, err := db.listPriceAlerts(ctx, userID, &id)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, ErrPriceAlertNotFound
+	}
+
+	alert := alerts[0]
+	result := &models.PriceAlertTestResult{Alert: alert}
+	if alert.BestPrice != nil {
+		diff := math.Round((*alert.BestPrice-alert.TargetPrice)*100) / 100
+		result.Difference = &diff
+		result.WouldFire = *alert.BestPrice <= alert.TargetPrice
+	}
+	return result, nil
+}
+
+// DeletePriceAlert removes one of the user's price alerts
+func (db *DB) DeletePriceAlert(ctx context.Context, id, userID int) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM price_alerts WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrPriceAlertNotFound
+	}
+
+	return nil
+}
+
+// TriggerPriceAlerts fires the alerts a stored price satisfies and returns them. An alert
+// matches when the price is at or below its target for its item (and store, if set). Private
+// prices and prices at private stores only reach the alert owner's own submissions and stores.
+// Each alert fires once per store price and amount, so repeating a submission does not
+// notify twice. Alerts still fire for owners who muted alert emails; EmailEnabled says
+// whether to send one.
+func (db *DB) TriggerPriceAlerts(ctx context.Context, p models.PriceSubmission) ([]models.TriggeredPriceAlert, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH matched AS (
+			SELECT a.id
+			FROM price_alerts a
+			JOIN stores s ON s.id = $1
+			WHERE a.item_id = $2
+			  AND (a.store_id IS NULL OR a.store_id = $1)
+			  AND $3 <= a.target_price
+			  AND ($5 OR a.user_id = $4)
+			  AND (COALESCE(s.is_private, false) = false OR s.created_by = a.user_id)
+		),
+		fired AS (
+			INSERT INTO price_alert_events (alert_id, price_id, price)
+			SELECT id, $6, $3 FROM matched
+			ON CONFLICT (alert_id, price_id, price) DO NOTHING
+			RETURNING alert_id
+		),
+		touched AS (
+			UPDATE price_alerts a SET last_triggered_at = NOW()
+			FROM fired f
+			WHERE a.id = f.alert_id
+			RETURNING a.id, a.user_id, a.target_price
+		)
+		SELECT t.id, t.user_id, u.email, i.name, s.name, t.target_price::float8, COALESCE(np.price_alert_email, true)
+		FROM touched t
+		JOIN users u ON t.user_id = u.id
+		LEFT JOIN notification_preferences np ON np.user_id = t.user_id
+		JOIN items i ON i.id = $2
+		JOIN stores s ON s.id = $1
+	`, p.StoreID, p.ItemID, p.Price, p.UserID, p.IsShared, p.PriceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggered []models.TriggeredPriceAlert
+	for rows.Next() {
+		t := models.TriggeredPriceAlert{Price: p.Price}
+		if err := rows.Scan(&t.AlertID, &t.UserID, &t.Email, &t.ItemName, &t.StoreName, &t.TargetPrice, &t.EmailEnabled); err != nil {
+			return nil, err
+		}
+		triggered = append(triggered, t)
+	}
+
+	return triggered, rows.Err()
+}
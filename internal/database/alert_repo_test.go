@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestTestPriceAlert(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := testUser(t, db)
+	other := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+
+	alert, err := db.CreatePriceAlert(ctx, user.ID, &models.CreatePriceAlertRequest{ItemID: item.ID, TargetPrice: 2.00})
+	if err != nil {
+		t.Fatalf("CreatePriceAlert: %v", err)
+	}
+
+	result, err := db.TestPriceAlert(ctx, alert.ID, user.ID)
+	if err != nil {
+		t.Fatalf("TestPriceAlert: %v", err)
+	}
+	if result.WouldFire || result.Alert.BestPrice != nil {
+		t.Errorf("with no prices: would_fire %v, best price %v", result.WouldFire, result.Alert.BestPrice)
+	}
+
+	// Another user's private price is not visible to the alert
+	if _, err := db.CreatePrice(ctx, &models.CreatePriceRequest{StoreID: store.ID, ItemID: item.ID, Price: 1.50}, &other.ID); err != nil {
+		t.Fatalf("create price: %v", err)
+	}
+	testPrice(t, db, store.ID, item.ID, 2.25, &other.ID)
+
+	result, err = db.TestPriceAlert(ctx, alert.ID, user.ID)
+	if err != nil {
+		t.Fatalf("TestPriceAlert: %v", err)
+	}
+	if result.Alert.BestPrice == nil || *result.Alert.BestPrice != 2.25 {
+		t.Fatalf("best price = %v, want 2.25", result.Alert.BestPrice)
+	}
+	if result.WouldFire || result.Difference == nil || *result.Difference != 0.25 {
+		t.Errorf("above target: would_fire %v, difference %v", result.WouldFire, result.Difference)
+	}
+
+	testPrice(t, db, store.ID, item.ID, 1.99, &other.ID)
+	result, err = db.TestPriceAlert(ctx, alert.ID, user.ID)
+	if err != nil {
+		t.Fatalf("TestPriceAlert: %v", err)
+	}
+	if !result.WouldFire {
+		t.Errorf("below target: would_fire = false, best price %v", result.Alert.BestPrice)
+	}
+
+	alerts, err := db.ListPriceAlerts(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("ListPriceAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].LastTriggeredAt != nil {
+		t.Errorf("testing an alert must not trigger it: %+v", alerts)
+	}
+
+	if _, err := db.TestPriceAlert(ctx, alert.ID, other.ID); !errors.Is(err, ErrPriceAlertNotFound) {
+		t.Errorf("another user's alert: got %v, want ErrPriceAlertNotFound", err)
+	}
+}
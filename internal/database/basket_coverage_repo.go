@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"math"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// GetFrequentItems returns the items a user bought at least minPurchases times in the last
+// windowDays, most often bought first. A purchase is an appearance on one of the user's
+// shopping lists or confirmed receipts.
+func (db *DB) GetFrequentItems(ctx context.Context, userID, windowDays, minPurchases, limit int) ([]*models.FrequentItem, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH purchases AS (
+			SELECT sli.item_id, 'list:' || sl.id as basket, sli.quantity, sl.created_at as bought_at
+			FROM shopping_list_items sli
+			JOIN shopping_lists sl ON sli.list_id = sl.id
+			WHERE sl.user_id = $1
+			  AND sl.created_at >= NOW() - ($2 || ' days')::INTERVAL
+			UNION ALL
+			SELECT ri.confirmed_item_id, 'receipt:' || r.id, COALESCE(ri.extracted_quantity, 1), COALESCE(r.confirmed_at, r.created_at)
+			FROM receipt_items ri
+			JOIN receipts r ON ri.receipt_id = r.id
+			WHERE r.user_id = $1
+			  AND r.status = 'confirmed'
+			  AND ri.confirmed_item_id IS NOT NULL
+			  AND COALESCE(r.confirmed_at, r.created_at) >= NOW() - ($2 || ' days')::INTERVAL
+		)
+		SELECT p.item_id, i.name, COUNT(DISTINCT p.basket),
+		       GREATEST(ROUND(AVG(p.quantity)), 1)::int
+		FROM purchases p
+		JOIN items i ON p.item_id = i.id
+		GROUP BY p.item_id, i.name
+		HAVING COUNT(DISTINCT p.basket) >= $3
+		ORDER BY COUNT(DISTINCT p.basket) DESC, MAX(p.bought_at) DESC, p.item_id
+		LIMIT $4
+	`, userID, windowDays, minPurchases, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.FrequentItem
+	for rows.Next() {
+		f := &models.FrequentItem{}
+		if err := rows.Scan(&f.ItemID, &f.ItemName, &f.Purchases, &f.TypicalQuantity); err != nil {
+			return nil, err
+		}
+		items = append(items, f)
+	}
+
+	return items, rows.Err()
+}
+
+// GetStoreBasketCoverage prices a user's frequent items at one store with the shopping plan's
+// single-store costing, using the prices visible to the user
+func (db *DB) GetStoreBasketCoverage(ctx context.Context, storeID int, storeName string, userID int, basket []*models.FrequentItem) (*models.StoreBasketCoverage, error) {
+	coverage := &models.StoreBasketCoverage{
+		StoreID:    storeID,
+		StoreName:  storeName,
+		BasketSize: len(basket),
+		Covered:    []models.CoveredBasketItem{},
+		Missing:    []models.FrequentItem{},
+	}
+	if len(basket) == 0 {
+		return coverage, nil
+	}
+
+	itemIDs := make([]int, len(basket))
+	quantities := make(map[int]int, len(basket))
+	itemNames := make(map[int]string, len(basket))
+	for i, f := range basket {
+		itemIDs[i] = f.ItemID
+		quantities[f.ItemID] = f.TypicalQuantity
+		itemNames[f.ItemID] = f.ItemName
+	}
+
+	matrix, err := db.loadPriceMatrix(ctx, itemIDs, userID, &priceMatrixFilter{StoreIDs: []int{storeID}})
+	if err != nil {
+		return nil, err
+	}
+	prices := matrix.prices[storeID]
+
+	option := singleStoreOption(storeID, storeName, prices, itemIDs, quantities, itemNames)
+	coverage.CoveredCount = option.ItemsFound
+	coverage.EstimatedTotal = roundCents(option.TotalCost)
+	coverage.CoveragePercent = math.Round(float64(option.ItemsFound)/float64(len(basket))*1000) / 10
+
+	for _, f := range basket {
+		if price, ok := prices[f.ItemID]; ok {
+			coverage.Covered = append(coverage.Covered, models.CoveredBasketItem{
+				FrequentItem: *f,
+				Price:        price,
+				LineTotal:    roundCents(price * float64(f.TypicalQuantity)),
+			})
+		} else {
+			coverage.Missing = append(coverage.Missing, *f)
+		}
+	}
+
+	return coverage, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -67,8 +68,16 @@ func RunMigrations(db *DB) error {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
-	// Run each migration
-	for version, migration := range migrations {
+	// Run each migration in version order; later migrations rely on earlier ones
+	versions := make([]int, 0, len(migrations))
+	for version := range migrations {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	for _, version := range versions {
+		migration := migrations[version]
+
 		// Check if migration already applied
 		var exists bool
 		err := db.Pool.QueryRow(ctx,
@@ -105,6 +114,28 @@ func RunMigrations(db *DB) error {
 	return nil
 }
 
+// EnsureUsernameIndex creates the case-insensitive username index once no case-variant
+// collisions remain. Existing collisions are logged for admins instead of failing startup.
+func EnsureUsernameIndex(db *DB) error {
+	ctx := context.Background()
+
+	collisions, err := db.ListUsernameCollisions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(collisions) > 0 {
+		for _, c := range collisions {
+			log.Printf("Warning: usernames differing only by case: %v (user ids %v)", c.Usernames, c.UserIDs)
+		}
+		log.Printf("Case-insensitive username index not created: %d collision(s) need resolving", len(collisions))
+		return nil
+	}
+
+	_, err = db.Pool.Exec(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (lower(username))`)
+	return err
+}
+
 // EnsureAdminUser creates the admin user if it doesn't exist
 func EnsureAdminUser(db *DB, cfg *config.Config) error {
 	if cfg.AdminPassword == "" {
@@ -162,6 +193,73 @@ var migrations = map[int]string{
 	10: migration010,
 	11: migration011,
 	12: migration012,
+	13: migration013,
+	14: migration014,
+	15: migration015,
+	16: migration016,
+	17: migration017,
+	18: migration018,
+	19: migration019,
+	20: migration020,
+	21: migration021,
+	22: migration022,
+	23: migration023,
+	24: migration024,
+	25: migration025,
+	26: migration026,
+	27: migration027,
+	28: migration028,
+	29: migration029,
+	30: migration030,
+	31: migration031,
+	32: migration032,
+	33: migration033,
+	34: migration034,
+	35: migration035,
+	36: migration036,
+	37: migration037,
+	38: migration038,
+	39: migration039,
+	40: migration040,
+	41: migration041,
+	42: migration042,
+	43: migration043,
+	44: migration044,
+	45: migration045,
+	46: migration046,
+	47: migration047,
+	48: migration048,
+	49: migration049,
+	50: migration050,
+	51: migration051,
+	52: migration052,
+	53: migration053,
+	54: migration054,
+	55: migration055,
+	56: migration056,
+	57: migration057,
+	58: migration058,
+	59: migration059,
+	60: migration060,
+	61: migration061,
+	62: migration062,
+	63: migration063,
+	64: migration064,
+	65: migration065,
+	66: migration066,
+	67: migration067,
+	68: migration068,
+	69: migration069,
+	70: migration070,
+	71: migration071,
+	72: migration072,
+	73: migration073,
+	74: migration074,
+	75: migration075,
+	76: migration076,
+	77: migration077,
+	78: migration078,
+	79: migration079,
 }
 
 const migration001 = `
@@ -781,3 +879,1066 @@ CREATE INDEX IF NOT EXISTS idx_inventory_low_stock ON inventory_items(user_id, l
 -- Composite index for user's inventory with location filtering
 CREATE INDEX IF NOT EXISTS idx_inventory_user_location ON inventory_items(user_id, location);
 `
+
+const migration013 = `
+-- Migration 013: Per-list shopping budget
+
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS budget DECIMAL(10, 2);
+`
+
+const migration014 = `
+-- Migration 014: Contributor identity masking
+
+-- Per-user preference to appear as "community member" on shared prices
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_contributor_name BOOLEAN DEFAULT FALSE;
+
+-- Global switch to anonymize all contributors in public responses
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('anonymize_contributors', 'false', 'bool', 'general', 'Show all price submitters as "community member" to other users', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration015 = `
+-- Migration 015: Automatic item verification from receipt confirmations
+
+-- Distinct users who confirmed the item on a receipt (maintained by the verifier job)
+ALTER TABLE items ADD COLUMN IF NOT EXISTS receipt_user_count INT DEFAULT 0;
+
+-- How the item became verified: 'manual' or 'receipts'
+ALTER TABLE items ADD COLUMN IF NOT EXISTS verification_basis VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_items_confirmed_item ON receipt_items(confirmed_item_id)
+    WHERE is_confirmed = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('item_auto_verify_enabled', 'true', 'bool', 'general', 'Automatically verify items confirmed on receipts by multiple users', false),
+    ('item_auto_verify_min_users', '3', 'int', 'general', 'Distinct users whose receipts must confirm an item before it is verified', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration016 = `
+-- Migration 016: Price history tracking
+-- Previously only applied manually (migrations/009_price_history.sql)
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id SERIAL PRIMARY KEY,
+    store_id INT REFERENCES stores(id) ON DELETE CASCADE,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    previous_price DECIMAL(10, 2),
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_item_store ON price_history(item_id, store_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_item ON price_history(item_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_store ON price_history(store_id, recorded_at DESC);
+
+-- Lowest-ever lookups scan by item and price
+CREATE INDEX IF NOT EXISTS idx_price_history_item_price ON price_history(item_id, price);
+`
+
+const migration017 = `
+-- Migration 017: SMTP fallback server and per-category sender identity
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('smtp_reply_to', '', 'string', 'email', 'Default Reply-To address (blank for none)', false),
+    ('smtp_fallback_enabled', 'false', 'bool', 'email', 'Retry on a fallback SMTP server when the primary fails', false),
+    ('smtp_fallback_host', '', 'string', 'email', 'Fallback SMTP server hostname', false),
+    ('smtp_fallback_port', '587', 'int', 'email', 'Fallback SMTP server port', false),
+    ('smtp_fallback_user', '', 'string', 'email', 'Fallback SMTP authentication username', false),
+    ('smtp_fallback_password', '', 'encrypted', 'email', 'Fallback SMTP authentication password', true),
+    ('smtp_transactional_from_name', '', 'string', 'email', 'From name override for transactional emails', false),
+    ('smtp_transactional_from_addr', '', 'string', 'email', 'From address override for transactional emails', false),
+    ('smtp_transactional_reply_to', '', 'string', 'email', 'Reply-To override for transactional emails', false),
+    ('smtp_digest_from_name', '', 'string', 'email', 'From name override for digest emails', false),
+    ('smtp_digest_from_addr', '', 'string', 'email', 'From address override for digest emails', false),
+    ('smtp_digest_reply_to', '', 'string', 'email', 'Reply-To override for digest emails', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration018 = `
+-- Migration 018: Store coordinate backfill via geocoding
+
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS google_place_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_stores_missing_coords ON stores(id) WHERE latitude IS NULL OR longitude IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('geocode_backfill_batch_size', '50', 'int', 'api', 'Maximum stores geocoded per backfill run', false),
+    ('geocode_backfill_delay_ms', '200', 'int', 'api', 'Delay between geocoding requests during backfill (milliseconds)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration019 = `
+-- Migration 019: Receipt date inference
+
+-- Where receipt_date came from: 'ocr', 'exif', 'upload' or 'manual'
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS receipt_date_source VARCHAR(20);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_date_inference_enabled', 'true', 'bool', 'general', 'Infer missing receipt dates from photo EXIF data or upload time', false),
+    ('receipt_date_day_first', 'false', 'bool', 'general', 'Read ambiguous receipt dates as DD/MM instead of MM/DD', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration020 = `
+-- Migration 020: Configurable bcrypt cost
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('bcrypt_cost', '10', 'int', 'auth', 'bcrypt cost for password hashes (10-16); existing hashes are upgraded on next login', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration021 = `
+-- Migration 021: Shopping list watches for automatic replanning
+
+CREATE TABLE IF NOT EXISTS list_watches (
+    list_id INT PRIMARY KEY REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_plan_cost DECIMAL(10, 2),
+    last_checked_at TIMESTAMP DEFAULT NOW(),
+    last_notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_watches_user ON list_watches(user_id);
+CREATE INDEX IF NOT EXISTS idx_store_prices_item_updated ON store_prices(item_id, updated_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('list_watch_enabled', 'true', 'bool', 'general', 'Replan watched shopping lists when their prices change', false),
+    ('list_watch_min_savings', '1.00', 'float', 'general', 'Minimum plan cost drop (in dollars) before notifying the list owner', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration022 = `
+-- Migration 022: Preferred store types for nearby search defaults
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_store_types TEXT[] NOT NULL DEFAULT '{}';
+`
+
+const migration023 = `
+-- Migration 023: Persistent inventory expiry and low-stock notifications
+
+CREATE TABLE IF NOT EXISTS inventory_notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inventory_item_id INT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    acknowledged_at TIMESTAMP,
+    UNIQUE (inventory_item_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_notifications_pending ON inventory_notifications(user_id) WHERE acknowledged_at IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('inventory_notifications_enabled', 'true', 'bool', 'general', 'Generate daily pantry expiry and low-stock notifications', false),
+    ('inventory_expiry_window_days', '7', 'int', 'general', 'Notify about pantry items expiring within this many days', false),
+    ('inventory_notification_email_enabled', 'false', 'bool', 'email', 'Email users a daily summary of new pantry notifications', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration024 = `
+-- Migration 024: Email domain allow-list for user creation
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('email_domain_allowlist_enabled', 'false', 'bool', 'auth', 'Restrict admin-created accounts to approved email domains', false),
+    ('email_domain_allowlist', '', 'string', 'auth', 'Comma-separated allowed email domains; use *.example.com for subdomains', false),
+    ('email_domain_allowlist_register', 'false', 'bool', 'auth', 'Also enforce the email domain allow-list on self-registration', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration025 = `
+-- Migration 025: Optional region restriction for shared price submissions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_require_region_match', 'false', 'bool', 'general', 'Only allow shared prices for stores in the submitter''s region', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration026 = `
+-- Migration 026: Automatic store verification from distinct price contributors
+
+-- How the store became verified: 'manual' or 'contributors'
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS verification_basis VARCHAR(20);
+
+UPDATE stores SET verification_basis = 'manual' WHERE verified = true AND verification_basis IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_auto_verify_enabled', 'true', 'bool', 'general', 'Automatically verify public stores priced by many distinct users', false),
+    ('store_auto_verify_min_contributors', '5', 'int', 'general', 'Distinct price contributors required before a store is verified', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration027 = `
+-- Migration 027: Price staleness threshold for store data-quality metrics
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_stale_days', '30', 'int', 'general', 'Days without an update or verification before a price counts as stale', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration028 = `
+-- Migration 028: Award verification reputation only once per user and price
+
+-- Set when the verifier was rewarded; survives toggling is_accurate back and forth
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS rewarded_at TIMESTAMP;
+
+UPDATE price_verifications SET rewarded_at = created_at WHERE is_accurate = true AND rewarded_at IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('reputation_verify_points', '1', 'int', 'general', 'Reputation awarded for the first accurate verification of a price', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration029 = `
+-- Migration 029: Per-region store preferences for multi-region users
+
+CREATE TABLE IF NOT EXISTS user_region_prefs (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    region_id INT NOT NULL REFERENCES regions(id) ON DELETE CASCADE,
+    preferred_store_ids INT[] NOT NULL DEFAULT '{}',
+    compare_store_ids INT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, region_id)
+);
+`
+
+const migration030 = `
+-- Migration 030: Distance display preference (distances are still computed in km)
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unit_system VARCHAR(10) NOT NULL DEFAULT 'metric'
+    CHECK (unit_system IN ('metric', 'imperial'));
+`
+
+const migration031 = `
+-- Migration 031: Case-insensitive username uniqueness
+-- The unique index on lower(username) is created at startup by EnsureUsernameIndex once no
+-- case-variant collisions exist; admins resolve them via /api/admin/users/username-collisions.
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('username_case_insensitive', 'true', 'bool', 'auth', 'Treat usernames differing only by case as the same username', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration032 = `
+-- Migration 032: Bounds for bulk store price adjustments
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_bulk_max_change_percent', '50', 'float', 'general', 'Largest change (percent) a bulk price adjustment may apply to one price', false),
+    ('price_outlier_factor', '3', 'float', 'general', 'Bulk adjustments may not move a price beyond this multiple of the item''s median at other stores', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration033 = `
+-- Migration 033: Automatic tag suggestions for items
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('tag_auto_apply_enabled', 'false', 'bool', 'general', 'Automatically add high-confidence suggested tags to new items', false),
+    ('tag_auto_apply_min_confidence', '0.8', 'float', 'general', 'Minimum confidence (0-1) for a suggested tag to be applied automatically', false),
+    ('tag_suggestion_keywords', '', 'string', 'general', 'Extra keyword=tag-slug pairs (comma separated) used for tag suggestions', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration034 = `
+-- Migration 034: Region deletion with reassignment
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_delete_allow_unassign', 'false', 'bool', 'general', 'Allow force-deleting a referenced region without a reassignment target (clears region on its stores and users)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration035 = `
+-- Migration 035: Anonymized search events for trending searches
+-- Only the normalized query, kind and region are stored, never the user.
+
+CREATE TABLE IF NOT EXISTS search_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('item', 'store')),
+    query VARCHAR(100) NOT NULL,
+    region_id INT REFERENCES regions(id) ON DELETE SET NULL,
+    result_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_events_created ON search_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_search_events_region ON search_events(region_id, created_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('search_tracking_enabled', 'true', 'bool', 'general', 'Record anonymized item and store searches for trending searches', false),
+    ('search_events_retention_days', '30', 'int', 'general', 'Days to keep recorded search events', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration036 = `
+-- Migration 036: Quantity-aware outlier check on price submission
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_outlier_check_enabled', 'false', 'bool', 'general', 'Ask submitters to confirm prices more than price_outlier_factor away from the typical price', false),
+    ('price_outlier_use_unit_price', 'true', 'bool', 'general', 'Compare outliers by unit price across package sizes when item sizes are known', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration037 = `
+-- Migration 037: Country, currency and locale per region
+-- Existing regions were all seeded from US data, so they take the US defaults
+
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS country_code VARCHAR(2) NOT NULL DEFAULT 'US';
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en-US';
+
+CREATE INDEX IF NOT EXISTS idx_regions_country ON regions(country_code);
+`
+
+const migration038 = `
+-- Migration 038: Last-seen timestamp for the what's-new digest
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS whats_new_seen_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_stores_created_at ON stores(created_at);
+CREATE INDEX IF NOT EXISTS idx_price_history_recorded ON price_history(recorded_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('whats_new_first_visit_days', '7', 'int', 'general', 'Days of changes shown in the what''s-new digest before a user has opened it', false),
+    ('whats_new_store_radius_km', '15', 'int', 'general', 'Radius in km for new stores listed in the what''s-new digest', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration039 = `
+-- Migration 039: Keep prices from unverified accounts private until the email is verified
+
+ALTER TABLE store_prices ADD COLUMN IF NOT EXISTS share_on_verify BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_store_prices_share_on_verify ON store_prices(user_id) WHERE share_on_verify = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('private_prices_until_verified', 'false', 'bool', 'auth', 'Save prices from users with unverified email as private and share them once the email is verified', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration040 = `
+-- Migration 040: Grace period for storage reconciliation
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('storage_reconcile_grace_minutes', '60', 'int', 'storage', 'Minutes an uploaded object may exist without a receipt before reconciliation treats it as orphaned', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration041 = `
+-- Migration 041: Coordinate/address consistency checks for stores and user locations
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('address_validation_mode', 'warn', 'string', 'general', 'Reverse-geocode submitted coordinates and compare with the address: off, warn or block', false),
+    ('address_validation_zip_prefix', '3', 'int', 'general', 'Leading zip code digits that must match the geocoded zip (5 for exact, 0 to ignore zip codes)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration042 = `
+-- Migration 042: Single-use tokens for external price imports
+
+CREATE TABLE IF NOT EXISTS import_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    scope VARCHAR(30) NOT NULL,
+    store_ids INTEGER[] NOT NULL DEFAULT '{}',
+    max_rows INTEGER NOT NULL,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    used_ip VARCHAR(45),
+    rows_imported INTEGER,
+    rows_rejected INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_tokens_created_at ON import_tokens(created_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('import_token_default_ttl_minutes', '60', 'int', 'general', 'Minutes an import token stays valid when no TTL is requested', false),
+    ('import_token_max_ttl_minutes', '1440', 'int', 'general', 'Longest TTL in minutes an admin can give an import token', false),
+    ('import_token_max_rows', '5000', 'int', 'general', 'Most prices a single import token can upload', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration043 = `
+-- Migration 043: Image preprocessing before receipt OCR
+
+-- Steps applied before OCR, e.g. 'grayscale,contrast,deskew(1.50)', 'clean', 'holdout' or 'none'
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS ocr_preprocessing VARCHAR(100);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('ocr_preprocess_enabled', 'true', 'bool', 'general', 'Preprocess receipt images before OCR', false),
+    ('ocr_preprocess_grayscale', 'true', 'bool', 'general', 'Convert receipt images to grayscale before OCR (implied by contrast and deskew)', false),
+    ('ocr_preprocess_contrast', 'true', 'bool', 'general', 'Stretch receipt image contrast before OCR', false),
+    ('ocr_preprocess_deskew', 'false', 'bool', 'general', 'Straighten rotated receipt photos before OCR (slower)', false),
+    ('ocr_preprocess_max_skew_degrees', '5', 'float', 'general', 'Largest rotation in degrees that deskew corrects', false),
+    ('ocr_preprocess_clean_contrast', '0.8', 'float', 'general', 'Luminance spread (0-1) at which an image is clean enough to skip preprocessing', false),
+    ('ocr_preprocess_holdout_percent', '0', 'int', 'general', 'Percent of images needing preprocessing to leave untouched for match rate comparison', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration044 = `
+-- Migration 044: Option to rank the community best price without the user's own submissions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('compare_exclude_own_prices', 'false', 'bool', 'general', 'Compute the best price in comparisons without the requesting user''s own prices (shown separately); overridable per request with exclude_own', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration045 = `
+-- Migration 045: Personalized region price-drop digests
+
+CREATE TABLE IF NOT EXISTS region_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    region_id INT NOT NULL REFERENCES regions(id) ON DELETE CASCADE,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly' CHECK (frequency IN ('daily', 'weekly')),
+    categories TEXT[] NOT NULL DEFAULT '{}', -- Tag slugs; empty means every category
+    min_drop_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, region_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_region_subscriptions_active ON region_subscriptions(last_sent_at) WHERE is_active = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_digest_enabled', 'true', 'bool', 'email', 'Email region price-drop digests to subscribers', false),
+    ('region_digest_max_items', '20', 'int', 'email', 'Most price drops listed in one region digest email', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration046 = `
+-- Migration 046: Receipt confirmation updates store prices in place
+
+-- Lookup for the contributor's current price when a receipt line is confirmed
+CREATE INDEX IF NOT EXISTS idx_store_prices_store_item_user ON store_prices(store_id, item_id, user_id, updated_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_confirm_price_scope', 'contributor', 'string', 'general', 'Store price a confirmed receipt line updates: contributor (the user''s own price) or store_item (the latest price for the store and item)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration047 = `
+-- Migration 047: Inventory quantity change log for consumption tracking
+
+CREATE TABLE IF NOT EXISTS inventory_adjustments (
+    id SERIAL PRIMARY KEY,
+    inventory_item_id INT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delta DECIMAL(10, 3) NOT NULL,
+    quantity_after DECIMAL(10, 3) NOT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual', -- manual, edit
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_adjustments_item ON inventory_adjustments(inventory_item_id, created_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('inventory_restock_lead_days', '7', 'int', 'general', 'Suggest adding an inventory item to a list when its projected run-out is this many days away', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration048 = `
+-- Migration 048: Cost-of-living index for cross-region price comparison
+
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS cost_of_living_index DECIMAL(6, 2) CHECK (cost_of_living_index > 0); -- 100 = baseline
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_price_normalize', 'false', 'bool', 'general', 'Normalize region price comparisons to the baseline cost of living by default; overridable per request with normalize', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration049 = `
+-- Migration 049: Confidence at which a receipt match needs no review
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_auto_match_confidence', '0.9', 'float', 'general', 'Receipt matches at or above this confidence are treated as auto-matched in the conflict view', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration050 = `
+-- Migration 050: Price sharing and digest choices made at registration
+
+-- Existing users never chose to share by default, so they start opted out; new users get the
+-- column default (registration normally sets it explicitly)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS share_prices_by_default BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ALTER COLUMN share_prices_by_default SET DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Users who already subscribed to a region digest have opted in
+UPDATE users SET digest_opt_in = true
+WHERE id IN (SELECT user_id FROM region_subscriptions);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('onboarding_share_prices_default', 'true', 'bool', 'general', 'Price sharing default offered to new users at registration', false),
+    ('onboarding_digest_opt_in_default', 'false', 'bool', 'email', 'Region digest opt-in offered to new users at registration', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration051 = `
+-- Migration 051: Price alerts for items dropping below a target
+
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    store_id INT REFERENCES stores(id) ON DELETE CASCADE, -- NULL matches any store
+    target_price DECIMAL(10, 2) NOT NULL CHECK (target_price > 0),
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_item ON price_alerts(item_id);
+CREATE INDEX IF NOT EXISTS idx_price_alerts_user ON price_alerts(user_id);
+
+-- One row per alert fired by a store price at a given amount, so a submission never notifies twice
+CREATE TABLE IF NOT EXISTS price_alert_events (
+    alert_id INT NOT NULL REFERENCES price_alerts(id) ON DELETE CASCADE,
+    price_id INT NOT NULL REFERENCES store_prices(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (alert_id, price_id, price)
+);
+`
+
+const migration052 = `
+-- Migration 052: Whether store creators may change community stores they added
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_owner_edit_public', 'true', 'bool', 'general', 'Allow users to edit or delete public stores they created; when off only admins can change community stores', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration053 = `
+-- Migration 053: Savings leaderboard opt-out and benchmark window
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('savings_leaderboard_benchmark_days', '30', 'int', 'general', 'Days of community prices before a purchase averaged to estimate savings on the leaderboard', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration054 = `
+-- Migration 054: Receipt thumbnails for the receipt list
+
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS thumbnail_key VARCHAR(255);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_thumbnail_enabled', 'true', 'bool', 'general', 'Generate a small preview image when a receipt is uploaded', false),
+    ('receipt_thumbnail_max_width', '320', 'int', 'general', 'Maximum receipt thumbnail width in pixels', false),
+    ('receipt_thumbnail_max_height', '480', 'int', 'general', 'Maximum receipt thumbnail height in pixels', false),
+    ('receipt_thumbnail_format', 'jpeg', 'string', 'general', 'Image format receipt thumbnails are converted to: jpeg or png', false),
+    ('receipt_thumbnail_quality', '75', 'int', 'general', 'JPEG quality (1-100) for receipt thumbnails', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration055 = `
+-- Migration 055: Batch size for admin store imports
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_import_batch_size', '100', 'int', 'general', 'Imported stores inserted per transaction', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration056 = `
+-- Migration 056: Archive items instead of deleting them so their price history survives
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+`
+
+const migration057 = `
+-- Migration 057: Optionally limit public price statistics to verified prices
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('public_stats_verified_only', 'false', 'bool', 'general', 'Only count verified prices in public price statistics (histogram, region prices, price stats)', false),
+    ('public_stats_min_verifications', '1', 'int', 'general', 'Verifications a price needs before it counts toward public statistics', false),
+    ('public_stats_min_verified_prices', '3', 'int', 'general', 'Verified prices required before statistics stop falling back to all prices', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration058 = `
+-- Migration 058: Product groups link the same product sold under different brands
+
+CREATE TABLE IF NOT EXISTS product_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS product_group_id INT REFERENCES product_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_items_product_group ON items(product_group_id) WHERE product_group_id IS NOT NULL;
+`
+
+const migration059 = `
+-- Migration 059: Flag prices as wrong with a reason
+
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS flag_reason VARCHAR(20)
+    CHECK (flag_reason IN ('too_high', 'too_low', 'item_mismatch', 'out_of_stock', 'other'));
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS reason TEXT;
+
+-- Inaccurate verifications since the last accurate one; prices with enough flags drop out of
+-- comparisons and shopping plans until someone re-verifies them
+ALTER TABLE store_prices ADD COLUMN IF NOT EXISTS flag_count INT NOT NULL DEFAULT 0;
+
+UPDATE store_prices sp SET flag_count = (
+    SELECT COUNT(*) FROM price_verifications pv
+    WHERE pv.price_id = sp.id AND pv.is_accurate = false
+      AND pv.created_at > COALESCE((
+          SELECT MAX(a.created_at) FROM price_verifications a
+          WHERE a.price_id = sp.id AND a.is_accurate = true
+      ), '-infinity'::timestamp)
+)
+WHERE EXISTS (SELECT 1 FROM price_verifications pv WHERE pv.price_id = sp.id AND pv.is_accurate = false);
+`
+
+const migration060 = `
+-- Migration 060: Keep each item's lowest price on the item row
+-- Maintained by a trigger on store_prices so every write path stays consistent
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS best_price DECIMAL(10, 2);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS best_store_id INT REFERENCES stores(id) ON DELETE SET NULL;
+
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    UPDATE items SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        WHERE sp.item_id = p_item_id
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE id = p_item_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION store_prices_refresh_best_price() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_item_best_price(OLD.item_id);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.item_id IS DISTINCT FROM OLD.item_id) THEN
+        PERFORM refresh_item_best_price(NEW.item_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price
+    AFTER INSERT OR DELETE OR UPDATE OF price, store_id, item_id ON store_prices
+    FOR EACH ROW EXECUTE FUNCTION store_prices_refresh_best_price();
+
+-- Backfill
+SELECT refresh_item_best_price(id) FROM items WHERE EXISTS (SELECT 1 FROM store_prices sp WHERE sp.item_id = items.id);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('item_best_price_denormalized', 'true', 'bool', 'general', 'Read list estimates and spending from the stored best price instead of scanning every price', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration061 = `
+-- Migration 061: Flag or restrict price comparisons across regions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('compare_single_region_only', 'false', 'bool', 'general', 'Reject price comparisons whose stores are in different regions', false),
+    ('compare_max_store_spread_km', '80', 'float', 'general', 'Warn when compared stores are further apart than this many km (0 disables)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration062 = `
+-- Migration 062: One place for each user's notification choices
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    region_digest_email BOOLEAN NOT NULL DEFAULT FALSE, -- Only record of the region digest opt-in (migration 078)
+    price_alert_email BOOLEAN NOT NULL DEFAULT TRUE,
+    list_watch_email BOOLEAN NOT NULL DEFAULT TRUE,
+    inventory_alerts BOOLEAN NOT NULL DEFAULT TRUE,
+    inventory_email BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Migration 078 drops users.digest_opt_in; skip the copy if it already ran
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'users' AND column_name = 'digest_opt_in') THEN
+        INSERT INTO notification_preferences (user_id, region_digest_email)
+        SELECT id, digest_opt_in FROM users
+        ON CONFLICT (user_id) DO NOTHING;
+    END IF;
+END $$;
+`
+
+const migration063 = `
+-- Migration 063: Reputation ledger and configurable award values
+
+CREATE TABLE IF NOT EXISTS reputation_events (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INT NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    source_id INT, -- Price verification, store or receipt the points were awarded for
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reputation_events_user ON reputation_events(user_id, created_at DESC);
+
+-- Each contribution is rewarded at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reputation_events_source
+    ON reputation_events(user_id, reason, source_id) WHERE source_id IS NOT NULL;
+
+-- Carry existing totals into the ledger so it sums to reputation_points
+INSERT INTO reputation_events (user_id, points, reason)
+SELECT u.id, u.reputation_points, 'opening_balance'
+FROM users u
+WHERE COALESCE(u.reputation_points, 0) <> 0
+  AND NOT EXISTS (SELECT 1 FROM reputation_events e WHERE e.user_id = u.id);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('reputation_price_verified_points', '2', 'int', 'general', 'Reputation awarded to a price submitter each time another user verifies the price as accurate', false),
+    ('reputation_store_verified_points', '5', 'int', 'general', 'Reputation awarded to the creator of a store when it is verified', false),
+    ('reputation_receipt_confirmed_points', '3', 'int', 'general', 'Reputation awarded for confirming a receipt', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration064 = `
+-- Migration 064: Presigned receipt image URL caching and generation limits
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_url_validity_minutes', '60', 'int', 'storage', 'Minutes a presigned receipt image URL stays valid', false),
+    ('receipt_url_refresh_minutes', '10', 'int', 'storage', 'Regenerate a cached receipt image URL once fewer than this many minutes of validity remain', false),
+    ('receipt_url_max_per_minute', '30', 'int', 'storage', 'Fresh presigned receipt image URLs one user can generate per minute (0 for no limit)', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration065 = `
+-- Migration 065: Minimum drop for the price drops feed
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_drop_min_percent', '5', 'float', 'general', 'Smallest percent decrease listed in the price drops feed', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration066 = `
+-- Migration 066: Recurring shopping lists
+
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS recurrence VARCHAR(20) NOT NULL DEFAULT 'none'
+    CHECK (recurrence IN ('none', 'weekly', 'biweekly', 'monthly'));
+
+-- Date the next copy is due; only set while recurrence is not 'none'
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS next_occurrence DATE;
+
+CREATE INDEX IF NOT EXISTS idx_shopping_lists_recurring ON shopping_lists(next_occurrence)
+    WHERE recurrence <> 'none';
+`
+
+const migration067 = `
+-- Migration 067: Strict JSON request binding
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('strict_json_binding', 'false', 'bool', 'api', 'Reject write requests whose JSON body contains unknown fields', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration068 = `
+-- Migration 068: Restock inventory from completed shopping lists
+
+-- Set the first time a completed list restocks inventory so that reopening and completing
+-- the list again does not add the same purchases twice
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS inventory_restocked_at TIMESTAMP;
+`
+
+const migration069 = `
+-- Migration 069: Normalize names of items created from receipts
+
+-- Word rules applied to receipt text before it becomes a catalog item name. A receipt word
+-- equal to pattern (case-insensitive) is replaced; an empty replacement drops the word.
+CREATE TABLE IF NOT EXISTS item_name_rules (
+    id SERIAL PRIMARY KEY,
+    pattern VARCHAR(50) NOT NULL UNIQUE,
+    replacement VARCHAR(100) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO item_name_rules (pattern, replacement) VALUES
+    ('gv', 'Great Value'),
+    ('ks', 'Kirkland Signature'),
+    ('mm', 'Member''s Mark'),
+    ('org', 'Organic'),
+    ('whl', 'Whole'),
+    ('chkn', 'Chicken'),
+    ('brst', 'Breast'),
+    ('bnls', 'Boneless'),
+    ('sknls', 'Skinless'),
+    ('grnd', 'Ground'),
+    ('bf', 'Beef'),
+    ('mlk', 'Milk'),
+    ('chse', 'Cheese'),
+    ('shrd', 'Shredded'),
+    ('brd', 'Bread'),
+    ('wht', 'White'),
+    ('whe', 'Wheat'),
+    ('brn', 'Brown'),
+    ('grn', 'Green'),
+    ('frsh', 'Fresh'),
+    ('frzn', 'Frozen'),
+    ('veg', 'Vegetable'),
+    ('frt', 'Fruit'),
+    ('jce', 'Juice'),
+    ('flr', 'Flour'),
+    ('lrg', 'Large'),
+    ('med', 'Medium'),
+    ('sml', 'Small'),
+    ('gal', 'Gallon'),
+    ('pkg', 'Package'),
+    ('btl', 'Bottle'),
+    ('ea', ''),
+    ('@', '')
+ON CONFLICT (pattern) DO NOTHING;
+
+-- Receipt text an item was created from, kept for matching later receipts
+ALTER TABLE items ADD COLUMN IF NOT EXISTS raw_name TEXT;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_item_name_normalize', 'true', 'bool', 'general', 'Clean up names of items created from receipts using the item name rules', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration070 = `
+-- Migration 070: Item barcodes
+
+-- UPC/EAN barcode stored as a 14-digit GTIN (left-padded with zeros) so that UPC-A, EAN-13
+-- and the GTIN-14 printed on some receipts all find the same item
+ALTER TABLE items ADD COLUMN IF NOT EXISTS barcode VARCHAR(14) UNIQUE;
+`
+
+const migration071 = `
+-- Migration 071: Shopping list price snapshots
+
+-- A saved copy of a list's estimated cost, so later snapshots can be compared against it
+CREATE TABLE IF NOT EXISTS list_price_snapshots (
+    id SERIAL PRIMARY KEY,
+    list_id INT NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    estimated_total DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_price_snapshots_list ON list_price_snapshots(list_id, created_at DESC);
+
+-- Each list item's quantity and best known price when the snapshot was taken
+CREATE TABLE IF NOT EXISTS list_price_snapshot_items (
+    snapshot_id INT NOT NULL REFERENCES list_price_snapshots(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    item_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    price DECIMAL(10,2),
+    store_name VARCHAR(200),
+    PRIMARY KEY (snapshot_id, item_id)
+);
+`
+
+const migration072 = `
+-- Migration 072: Per-line OCR confidence on receipt items
+
+-- Tesseract confidence (0-100) of the text line an item was parsed from
+ALTER TABLE receipt_items ADD COLUMN IF NOT EXISTS ocr_confidence REAL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_ocr_min_confidence', '60', 'float', 'storage', 'OCR confidence (0-100) below which a receipt line is flagged for review', false)
+ON CONFLICT (key) DO NOTHING;
+`
+
+const migration073 = `
+-- Migration 073: Scope item barcode uniqueness
+
+-- A barcode identifies one public item, and at most one private item per owner, so a user's
+-- private copy of a product does not block the public catalog entry (or another user's copy)
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_barcode_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_barcode_public ON items(barcode)
+    WHERE barcode IS NOT NULL AND is_private = false;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_barcode_private ON items(created_by, barcode)
+    WHERE barcode IS NOT NULL AND is_private = true;
+`
+
+const migration074 = `
+-- Migration 074: Remember failed store geocoding attempts
+
+-- The backfill retries stores that have never been attempted first, then the longest-ago
+-- failures, so stores that cannot be geocoded do not block the rest of the queue
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS geocode_attempted_at TIMESTAMP;
+`
+
+const migration075 = `
+-- Migration 075: Indexes for item search and receipt matching
+
+-- Item search narrows candidates with trigram and ILIKE matches on name, brand and receipt
+-- text, exact brand words and matching tags before scoring them
+CREATE INDEX IF NOT EXISTS idx_items_brand_trgm ON items USING gin(brand gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_items_brand_lower ON items(LOWER(brand));
+CREATE INDEX IF NOT EXISTS idx_items_raw_name_trgm ON items USING gin(raw_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_item_tags_tag ON item_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_tags_name_lower ON tags(LOWER(name));
+`
+
+const migration076 = `
+-- Migration 076: Read receipt dates in the order of the receipt's region
+
+-- The store's or uploader's region locale now decides day-first dates; the setting is the
+-- fallback for receipts with no known region
+UPDATE system_settings
+SET description = 'Read ambiguous receipt dates as DD/MM when neither the store nor the uploader has a region'
+WHERE key = 'receipt_date_day_first';
+`
+
+const migration077 = `
+-- Migration 077: Serialize best price refreshes per item
+
+-- Two transactions writing prices for the same item each recomputed the best price from their
+-- own snapshot, so the later commit could store a value that missed the other's write. Locking
+-- the item row first makes the recompute wait, and it then reads the committed prices.
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    PERFORM 1 FROM items WHERE id = p_item_id FOR UPDATE;
+
+    UPDATE items SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        WHERE sp.item_id = p_item_id
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE id = p_item_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Repair any values that drifted before the lock was taken
+SELECT refresh_item_best_price(id) FROM items
+WHERE best_price IS DISTINCT FROM (SELECT MIN(sp.price) FROM store_prices sp WHERE sp.item_id = items.id);
+`
+
+const migration078 = `
+-- Migration 078: notification_preferences is the only record of the region digest choice
+-- Copy users.digest_opt_in for anyone still without a preferences row, then drop the column.
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'users' AND column_name = 'digest_opt_in') THEN
+        INSERT INTO notification_preferences (user_id, region_digest_email)
+        SELECT id, digest_opt_in FROM users
+        ON CONFLICT (user_id) DO NOTHING;
+    END IF;
+END $$;
+
+ALTER TABLE users DROP COLUMN IF EXISTS digest_opt_in;
+`
+
+const migration079 = `
+-- Migration 079: Best price from community-visible prices, refreshed once per statement
+
+-- Only prices anyone could see count toward an item's best price: shared, not hidden by flags
+-- (models.PriceFlagHideThreshold), at a public store, for an item that is not archived. The
+-- affected items are locked in id order so writers touching several items cannot deadlock.
+CREATE OR REPLACE FUNCTION refresh_item_best_prices(p_item_ids INT[]) RETURNS VOID AS $$
+BEGIN
+    PERFORM 1 FROM items WHERE id = ANY(p_item_ids) ORDER BY id FOR UPDATE;
+
+    UPDATE items i SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        JOIN stores s ON s.id = sp.store_id
+        WHERE sp.item_id = i.id
+          AND i.archived_at IS NULL
+          AND sp.is_shared = true
+          AND sp.flag_count < 3
+          AND COALESCE(s.is_private, false) = false
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE i.id = ANY(p_item_ids);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    PERFORM refresh_item_best_prices(ARRAY[p_item_id]);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Refresh every item a statement touched in one call instead of once per row
+CREATE OR REPLACE FUNCTION store_prices_refresh_best_prices() RETURNS TRIGGER AS $$
+DECLARE
+    changed INT[];
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        SELECT array_agg(DISTINCT item_id) INTO changed FROM new_prices;
+    ELSIF TG_OP = 'DELETE' THEN
+        SELECT array_agg(DISTINCT item_id) INTO changed FROM old_prices;
+    ELSE
+        SELECT array_agg(item_id) INTO changed
+        FROM (SELECT item_id FROM old_prices UNION SELECT item_id FROM new_prices) ids;
+    END IF;
+
+    IF changed IS NOT NULL THEN
+        PERFORM refresh_item_best_prices(changed);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sharing and flag changes matter now too, so updates fire on any column
+DROP TRIGGER IF EXISTS trg_store_prices_best_price ON store_prices;
+DROP FUNCTION IF EXISTS store_prices_refresh_best_price();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_insert ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_insert
+    AFTER INSERT ON store_prices
+    REFERENCING NEW TABLE AS new_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_update ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_update
+    AFTER UPDATE ON store_prices
+    REFERENCING OLD TABLE AS old_prices NEW TABLE AS new_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_delete ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_delete
+    AFTER DELETE ON store_prices
+    REFERENCING OLD TABLE AS old_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+-- Archiving or restoring an item clears or restores its best price
+CREATE OR REPLACE FUNCTION items_refresh_best_price_on_archive() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_item_best_prices(ARRAY[NEW.id]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_items_best_price_archive ON items;
+CREATE TRIGGER trg_items_best_price_archive
+    AFTER UPDATE OF archived_at ON items
+    FOR EACH ROW WHEN (OLD.archived_at IS DISTINCT FROM NEW.archived_at)
+    EXECUTE FUNCTION items_refresh_best_price_on_archive();
+
+-- Recompute every stored best price under the new rules
+SELECT refresh_item_best_prices(ARRAY(
+    SELECT id FROM items
+    WHERE best_price IS NOT NULL OR EXISTS (SELECT 1 FROM store_prices sp WHERE sp.item_id = items.id)
+));
+`
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var (
+	ErrImportTokenNotFound = errors.New("import token not found")
+	ErrImportTokenUsed     = errors.New("import token already used")
+	ErrImportTokenExpired  = errors.New("import token expired")
+)
+
+const importTokenColumns = `id, label, scope, store_ids, max_rows, created_by, expires_at, used_at, used_ip, rows_imported, rows_rejected, created_at`
+
+// scanImportToken scans a row selected with importTokenColumns
+func scanImportToken(row pgx.Row) (*models.ImportToken, error) {
+	t := &models.ImportToken{}
+	err := row.Scan(&t.ID, &t.Label, &t.Scope, &t.StoreIDs, &t.MaxRows, &t.CreatedBy, &t.ExpiresAt,
+		&t.UsedAt, &t.UsedIP, &t.RowsImported, &t.RowsRejected, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if t.StoreIDs == nil {
+		t.StoreIDs = []int{}
+	}
+	return t, nil
+}
+
+// CreateImportToken stores a new import token by the hash of its secret
+func (db *DB) CreateImportToken(ctx context.Context, tokenHash string, req *models.CreateImportTokenRequest, createdBy int, expiresAt time.Time) (*models.ImportToken, error) {
+	return scanImportToken(db.Pool.QueryRow(ctx, `
+		INSERT INTO import_tokens (token_hash, label, scope, store_ids, max_rows, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+importTokenColumns,
+		tokenHash, req.Label, req.Scope, req.StoreIDs, req.MaxRows, createdBy, expiresAt))
+}
+
+// ListImportTokens returns the most recent import tokens with their usage, newest first
+func (db *DB) ListImportTokens(ctx context.Context, limit, offset int) ([]*models.ImportToken, int, error) {
+	var total int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM import_tokens`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+importTokenColumns+`
+		FROM import_tokens
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.ImportToken{}
+	for rows.Next() {
+		t, err := scanImportToken(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, total, rows.Err()
+}
+
+// GetUsableImportToken returns an unused, unexpired token without consuming it, so the request
+// can be checked against the token's scope and limits first
+func (db *DB) GetUsableImportToken(ctx context.Context, tokenHash string) (*models.ImportToken, error) {
+	token, err := scanImportToken(db.Pool.QueryRow(ctx, `
+		SELECT `+importTokenColumns+`
+		FROM import_tokens
+		WHERE token_hash = $1
+	`, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportTokenNotFound
+		}
+		return nil, err
+	}
+	if token.UsedAt != nil {
+		return nil, ErrImportTokenUsed
+	}
+	if !token.ExpiresAt.After(time.Now()) {
+		return nil, ErrImportTokenExpired
+	}
+	return token, nil
+}
+
+// consumeImportToken marks an unused, unexpired token as used within tx. The update is atomic,
+// so concurrent requests with the same token cannot both succeed.
+func consumeImportToken(ctx context.Context, tx pgx.Tx, tokenID int, ip string) error {
+	var expired bool
+	err := tx.QueryRow(ctx, `
+		UPDATE import_tokens
+		SET used_at = NOW(), used_ip = $2
+		WHERE id = $1 AND used_at IS NULL
+		RETURNING expires_at <= NOW()
+	`, tokenID, ip).Scan(&expired)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrImportTokenUsed
+		}
+		return err
+	}
+	if expired {
+		return ErrImportTokenExpired
+	}
+	return nil
+}
+
+// ImportTokenPrices consumes a token and saves the prices pushed with it, attributed to the
+// token's creator, in one transaction, and records the outcome on the token. Only the creator's
+// own shared price for a store and item is updated. Entries already marked with a Rejection,
+// for unknown or private stores and items, or stores outside the token's scope, are rejected
+// individually.
+// The token stays unused if the import fails.
+func (db *DB) ImportTokenPrices(ctx context.Context, token *models.ImportToken, ip string, entries []models.ImportPriceEntry) (*models.ImportPricesResult, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := consumeImportToken(ctx, tx, token.ID, ip); err != nil {
+		return nil, err
+	}
+
+	var itemIDs []int
+	for _, e := range entries {
+		if e.Rejection == "" {
+			itemIDs = append(itemIDs, e.ItemID)
+		}
+	}
+	if err := lockItemsForPriceWrites(ctx, tx, itemIDs); err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[int]bool, len(token.StoreIDs))
+	for _, id := range token.StoreIDs {
+		allowed[id] = true
+	}
+
+	result := &models.ImportPricesResult{}
+	reject := func(i int, msg string) {
+		result.Rejected++
+		result.Errors = append(result.Errors, fmt.Sprintf("price %d: %s", i+1, msg))
+	}
+
+	for i, e := range entries {
+		if e.Price <= 0 {
+			reject(i, "price must be greater than 0")
+			continue
+		}
+		if e.Rejection != "" {
+			reject(i, e.Rejection)
+			continue
+		}
+		if len(allowed) > 0 && !allowed[e.StoreID] {
+			reject(i, "store is outside the token scope")
+			continue
+		}
+
+		var valid bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1 AND COALESCE(is_private, false) = false)
+			   AND EXISTS (SELECT 1 FROM items WHERE id = $2 AND COALESCE(is_private, false) = false)
+		`, e.StoreID, e.ItemID).Scan(&valid)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			reject(i, "unknown store or item")
+			continue
+		}
+
+		// Update the creator's own shared price for the store/item when there is one, otherwise
+		// add it; other contributors' prices are never taken over
+		var priceID int
+		var previous *float64
+		err = tx.QueryRow(ctx, `
+			SELECT id, price FROM store_prices
+			WHERE store_id = $1 AND item_id = $2 AND user_id = $3 AND is_shared = true
+			ORDER BY updated_at DESC
+			LIMIT 1
+			FOR UPDATE
+		`, e.StoreID, e.ItemID, token.CreatedBy).Scan(&priceID, &previous)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			_, err = tx.Exec(ctx, `
+				INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, true, NOW(), NOW())
+			`, e.StoreID, e.ItemID, e.Price, token.CreatedBy)
+		case err == nil:
+			_, err = tx.Exec(ctx, `
+				UPDATE store_prices SET price = $2, updated_at = NOW() WHERE id = $1
+			`, priceID, e.Price)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO price_history (store_id, item_id, price, previous_price, user_id, recorded_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+		`, e.StoreID, e.ItemID, e.Price, previous, token.CreatedBy)
+		if err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE import_tokens SET rows_imported = $2, rows_rejected = $3 WHERE id = $1
+	`, token.ID, result.Imported, result.Rejected)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
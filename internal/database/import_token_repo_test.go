@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestImportTokenPricesOwnRowsOnly(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	creator := testUser(t, db)
+	other := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+	otherPrice := testPrice(t, db, store.ID, item.ID, 3.00, &other.ID)
+
+	importPrices := func(price float64, rejection string) *models.ImportPricesResult {
+		t.Helper()
+		token, err := db.CreateImportToken(ctx, testName("hash"), &models.CreateImportTokenRequest{
+			Label: "test", Scope: models.ImportTokenScopePrices, MaxRows: 10,
+		}, creator.ID, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("CreateImportToken: %v", err)
+		}
+		result, err := db.ImportTokenPrices(ctx, token, "127.0.0.1", []models.ImportPriceEntry{
+			{StoreID: store.ID, ItemID: item.ID, Price: price, Rejection: rejection},
+		})
+		if err != nil {
+			t.Fatalf("ImportTokenPrices: %v", err)
+		}
+		return result
+	}
+
+	ownPriceIDs := func() []int {
+		t.Helper()
+		rows, err := db.Pool.Query(ctx, `
+			SELECT id FROM store_prices WHERE store_id = $1 AND item_id = $2 AND user_id = $3
+		`, store.ID, item.ID, creator.ID)
+		if err != nil {
+			t.Fatalf("read creator prices: %v", err)
+		}
+		defer rows.Close()
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	if r := importPrices(2.50, ""); r.Imported != 1 {
+		t.Fatalf("first import imported %d, want 1", r.Imported)
+	}
+	if r := importPrices(2.75, ""); r.Imported != 1 {
+		t.Fatalf("second import imported %d, want 1", r.Imported)
+	}
+	if r := importPrices(99.00, "outlier"); r.Imported != 0 || r.Rejected != 1 {
+		t.Fatalf("rejected entry imported %d, rejected %d", r.Imported, r.Rejected)
+	}
+
+	if ids := ownPriceIDs(); len(ids) != 1 {
+		t.Fatalf("creator has %d prices, want 1 updated in place", len(ids))
+	}
+
+	var price float64
+	var ownerID int
+	err := db.Pool.QueryRow(ctx, `SELECT price, user_id FROM store_prices WHERE id = $1`, otherPrice.ID).Scan(&price, &ownerID)
+	if err != nil {
+		t.Fatalf("read other price: %v", err)
+	}
+	if price != 3.00 || ownerID != other.ID {
+		t.Errorf("other contributor's price changed to %.2f by user %d", price, ownerID)
+	}
+}
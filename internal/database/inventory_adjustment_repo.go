@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// logInventoryAdjustment records a quantity change on an inventory item
+func logInventoryAdjustment(ctx context.Context, tx pgx.Tx, inventoryItemID, userID int, delta, quantityAfter float64, source string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO inventory_adjustments (inventory_item_id, user_id, delta, quantity_after, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, inventoryItemID, userID, delta, quantityAfter, source)
+	return err
+}
+
+// restockInventory adds quantity to the user's inventory row for a catalog item and records the
+// purchase date. With createMissing an item not yet in inventory gets a new row; otherwise it is
+// skipped and false is returned.
+func restockInventory(ctx context.Context, tx pgx.Tx, userID, itemID int, quantity float64, purchaseDate time.Time, createMissing bool, source string) (bool, error) {
+	var inventoryID int
+	var after float64
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM inventory_items
+		WHERE user_id = $1 AND item_id = $2
+		ORDER BY updated_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, userID, itemID).Scan(&inventoryID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		if !createMissing {
+			return false, nil
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO inventory_items (user_id, item_id, custom_size, custom_unit, quantity, purchase_date, created_at, updated_at)
+			VALUES ($1, $2, (SELECT size FROM items WHERE id = $2), (SELECT unit FROM items WHERE id = $2), $3, $4, NOW(), NOW())
+			RETURNING id, quantity
+		`, userID, itemID, quantity, purchaseDate).Scan(&inventoryID, &after)
+	case err == nil:
+		// An older purchase recorded late does not move the purchase date back
+		err = tx.QueryRow(ctx, `
+			UPDATE inventory_items
+			SET quantity = quantity + $2,
+			    purchase_date = GREATEST(COALESCE(purchase_date, $3), $3),
+			    updated_at = NOW()
+			WHERE id = $1
+			RETURNING quantity
+		`, inventoryID, quantity, purchaseDate).Scan(&after)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := logInventoryAdjustment(ctx, tx, inventoryID, userID, quantity, after, source); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetInventoryConsumption averages the decreases logged for an inventory item over the last
+// windowDays and projects when the current quantity runs out. The rate is spread over the
+// observed span (the window, or less when the item is newer), and suggestAheadDays sets how
+// close to running out an item must be before it is suggested for a shopping list.
+func (db *DB) GetInventoryConsumption(ctx context.Context, id, userID, windowDays, suggestAheadDays int) (*models.InventoryConsumption, error) {
+	item, err := db.GetInventoryItemByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -windowDays)
+	if item.CreatedAt.After(since) {
+		since = item.CreatedAt
+	}
+
+	c := &models.InventoryConsumption{
+		InventoryItemID: item.ID,
+		Quantity:        item.Quantity,
+		Unit:            item.Unit,
+		WindowDays:      windowDays,
+	}
+
+	err = db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(-delta), 0)
+		FROM inventory_adjustments
+		WHERE inventory_item_id = $1 AND delta < 0 AND created_at >= $2
+	`, id, since).Scan(&c.ConsumptionEvents, &c.TotalConsumed)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ObservedDays = math.Max(now.Sub(since).Hours()/24, 1)
+	c.DailyRate = c.TotalConsumed / c.ObservedDays
+
+	switch {
+	case c.ConsumptionEvents == 0:
+		c.Confidence = models.ConsumptionConfidenceNone
+	case c.ConsumptionEvents < 3 || c.ObservedDays < 7:
+		c.Confidence = models.ConsumptionConfidenceLow
+	case c.ConsumptionEvents < 6 || c.ObservedDays < 21:
+		c.Confidence = models.ConsumptionConfidenceMedium
+	default:
+		c.Confidence = models.ConsumptionConfidenceHigh
+	}
+
+	if c.DailyRate > 0 {
+		days := c.Quantity / c.DailyRate
+		runOut := now.Add(time.Duration(days * 24 * float64(time.Hour)))
+		c.DaysUntilEmpty = &days
+		c.ProjectedRunOut = &runOut
+	}
+
+	// With no usable history only an item that is already low is worth suggesting
+	c.SuggestAddToList = item.IsLowStock ||
+		(c.DaysUntilEmpty != nil && c.Confidence != models.ConsumptionConfidenceNone && *c.DaysUntilEmpty <= float64(suggestAheadDays))
+
+	return c, nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrInventoryNotificationNotFound = errors.New("inventory notification not found")
+
+// ListPendingInventoryNotifications returns a user's unacknowledged inventory notifications
+func (db *DB) ListPendingInventoryNotifications(ctx context.Context, userID int) ([]*models.InventoryNotification, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, inventory_item_id, kind, message, created_at, acknowledged_at
+		FROM inventory_notifications
+		WHERE user_id = $1 AND acknowledged_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.InventoryNotification{}
+	for rows.Next() {
+		n := &models.InventoryNotification{}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.InventoryItemID, &n.Kind, &n.Message, &n.CreatedAt, &n.AcknowledgedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// AcknowledgeInventoryNotification dismisses a notification owned by the user
+func (db *DB) AcknowledgeInventoryNotification(ctx context.Context, id, userID int) error {
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE inventory_notifications
+		SET acknowledged_at = COALESCE(acknowledged_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrInventoryNotificationNotFound
+	}
+
+	return nil
+}
+
+// CreateInventoryNotification records a notification unless one already exists for the item and kind.
+// Returns nil without error when the notification already exists (acknowledged or not).
+func (db *DB) CreateInventoryNotification(ctx context.Context, userID, inventoryItemID int, kind, message string) (*models.InventoryNotification, error) {
+	rows, err := db.Pool.Query(ctx, `
+		INSERT INTO inventory_notifications (user_id, inventory_item_id, kind, message)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (inventory_item_id, kind) DO NOTHING
+		RETURNING id, user_id, inventory_item_id, kind, message, created_at, acknowledged_at
+	`, userID, inventoryItemID, kind, message)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	n := &models.InventoryNotification{}
+	if err := rows.Scan(&n.ID, &n.UserID, &n.InventoryItemID, &n.Kind, &n.Message, &n.CreatedAt, &n.AcknowledgedAt); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// ClearResolvedInventoryNotifications removes a user's notifications of a kind whose condition no longer holds,
+// so the alert can fire again if the item runs low or nears expiry later
+func (db *DB) ClearResolvedInventoryNotifications(ctx context.Context, userID int, kind string, activeItemIDs []int) error {
+	if activeItemIDs == nil {
+		activeItemIDs = []int{}
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		DELETE FROM inventory_notifications
+		WHERE user_id = $1 AND kind = $2 AND NOT (inventory_item_id = ANY($3::int[]))
+	`, userID, kind, activeItemIDs)
+	return err
+}
+
+// ListUsersWithInventory returns the IDs of users that track at least one inventory item
+func (db *DB) ListUsersWithInventory(ctx context.Context) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT user_id FROM inventory_items ORDER BY user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return userIDs, rows.Err()
+}
@@ -15,6 +15,7 @@ import (
 var (
 	ErrInventoryItemNotFound = errors.New("inventory item not found")
 	ErrNotInventoryOwner     = errors.New("not the owner of this inventory item")
+	ErrNothingToRestock      = errors.New("no low-stock or expiring catalog items to restock")
 )
 
 // ListInventoryItems returns paginated inventory for a user
@@ -261,7 +262,11 @@ func (db *DB) CreateInventoryItem(ctx context.Context, req *models.CreateInvento
 			location, notes,
 			created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW()
+			$1, $2, $3, $4,
+			-- Catalog items default to the item's package size and unit
+			COALESCE($5, (SELECT size FROM items WHERE id = $2)),
+			COALESCE($6, (SELECT unit FROM items WHERE id = $2)),
+			$7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW()
 		)
 		RETURNING id, user_id, item_id,
 			custom_name, custom_brand, custom_size, custom_unit,
@@ -294,11 +299,18 @@ func (db *DB) CreateInventoryItem(ctx context.Context, req *models.CreateInvento
 	return item, nil
 }
 
-// UpdateInventoryItem updates an inventory item
+// UpdateInventoryItem updates an inventory item, logging any quantity change
 func (db *DB) UpdateInventoryItem(ctx context.Context, id int, userID int, req *models.UpdateInventoryItemRequest) (*models.InventoryItem, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	// First check ownership
 	var ownerID int
-	err := db.Pool.QueryRow(ctx, `SELECT user_id FROM inventory_items WHERE id = $1`, id).Scan(&ownerID)
+	var previous float64
+	err = tx.QueryRow(ctx, `SELECT user_id, quantity FROM inventory_items WHERE id = $1 FOR UPDATE`, id).Scan(&ownerID, &previous)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrInventoryItemNotFound
@@ -311,7 +323,7 @@ func (db *DB) UpdateInventoryItem(ctx context.Context, id int, userID int, req *
 
 	item := &models.InventoryItem{}
 
-	err = db.Pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		UPDATE inventory_items
 		SET
 			quantity = COALESCE($3, quantity),
@@ -358,6 +370,17 @@ func (db *DB) UpdateInventoryItem(ctx context.Context, id int, userID int, req *
 		return nil, err
 	}
 
+	// A new quantity entered directly is logged like an adjustment so consumption stays complete
+	if item.Quantity != previous {
+		if err := logInventoryAdjustment(ctx, tx, item.ID, userID, item.Quantity-previous, item.Quantity, models.InventoryAdjustmentEdit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return item, nil
 }
 
@@ -377,11 +400,18 @@ func (db *DB) DeleteInventoryItem(ctx context.Context, id int, userID int) error
 	return nil
 }
 
-// AdjustInventoryQuantity adds or subtracts from current quantity
+// AdjustInventoryQuantity adds or subtracts from current quantity and logs the change
 func (db *DB) AdjustInventoryQuantity(ctx context.Context, id int, userID int, adjustment float64) (*models.InventoryItem, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	// First check ownership
 	var ownerID int
-	err := db.Pool.QueryRow(ctx, `SELECT user_id FROM inventory_items WHERE id = $1`, id).Scan(&ownerID)
+	var previous float64
+	err = tx.QueryRow(ctx, `SELECT user_id, quantity FROM inventory_items WHERE id = $1 FOR UPDATE`, id).Scan(&ownerID, &previous)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrInventoryItemNotFound
@@ -394,7 +424,7 @@ func (db *DB) AdjustInventoryQuantity(ctx context.Context, id int, userID int, a
 
 	item := &models.InventoryItem{}
 
-	err = db.Pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		UPDATE inventory_items
 		SET quantity = GREATEST(0, quantity + $3), updated_at = NOW()
 		WHERE id = $1 AND user_id = $2
@@ -422,6 +452,14 @@ func (db *DB) AdjustInventoryQuantity(ctx context.Context, id int, userID int, a
 		return nil, err
 	}
 
+	if err := logInventoryAdjustment(ctx, tx, item.ID, userID, item.Quantity-previous, item.Quantity, models.InventoryAdjustmentManual); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return item, nil
 }
 
@@ -635,6 +673,81 @@ func (db *DB) AddInventoryItemToShoppingList(ctx context.Context, inventoryID in
 	return nil
 }
 
+// BuildRestockList creates a shopping list holding the catalog items behind the user's low-stock
+// inventory and the inventory expiring within expiringDays, one of each item. Custom inventory
+// items have no catalog item to shop for and are reported as skipped.
+func (db *DB) BuildRestockList(ctx context.Context, userID int, listName string, expiringDays int) (*models.RestockListResult, error) {
+	lowStock, err := db.GetLowStockItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	expiring, err := db.GetExpiringItems(ctx, userID, expiringDays)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RestockListResult{}
+	seenInventory := make(map[int]bool)
+	seenItems := make(map[int]bool)
+	var itemIDs []int
+	for _, inv := range append(lowStock, expiring...) {
+		if seenInventory[inv.ID] {
+			continue
+		}
+		seenInventory[inv.ID] = true
+
+		if inv.ItemID == nil {
+			result.Skipped = append(result.Skipped, models.RestockSkippedItem{
+				InventoryID: inv.ID,
+				Name:        inv.DisplayName,
+				Reason:      "custom item has no catalog item linked",
+			})
+			continue
+		}
+		if !seenItems[*inv.ItemID] {
+			seenItems[*inv.ItemID] = true
+			itemIDs = append(itemIDs, *inv.ItemID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		return result, ErrNothingToRestock
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var listID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shopping_lists (user_id, name, status, created_at, updated_at)
+		VALUES ($1, $2, 'active', NOW(), NOW())
+		RETURNING id
+	`, userID, listName).Scan(&listID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shopping_list_items (list_id, item_id, quantity, created_at)
+		SELECT $1, item_id, 1, NOW() FROM UNNEST($2::int[]) AS item_id
+	`, listID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	result.List, err = db.GetShoppingListByID(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetActiveShoppingLists returns user's active shopping lists (for quick-add dropdown)
 func (db *DB) GetActiveShoppingLists(ctx context.Context, userID int) ([]*models.ShoppingListSummary, error) {
 	rows, err := db.Pool.Query(ctx, `
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestCreateInventoryItemInheritsCatalogSize(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := testUser(t, db)
+	size, unit := 64.0, "fl oz"
+	item := testItem(t, db, &models.CreateItemRequest{Size: &size, Unit: &unit}, nil)
+
+	inherited, err := db.CreateInventoryItem(ctx, &models.CreateInventoryItemRequest{ItemID: &item.ID, Quantity: 1}, user.ID)
+	if err != nil {
+		t.Fatalf("CreateInventoryItem: %v", err)
+	}
+	if inherited.CustomSize == nil || *inherited.CustomSize != size || inherited.CustomUnit == nil || *inherited.CustomUnit != unit {
+		t.Errorf("inherited size = %v %v, want %v %q", inherited.CustomSize, inherited.CustomUnit, size, unit)
+	}
+
+	customSize, customUnit := 1.0, "gal"
+	overridden, err := db.CreateInventoryItem(ctx, &models.CreateInventoryItemRequest{
+		ItemID:     &item.ID,
+		CustomSize: &customSize,
+		CustomUnit: &customUnit,
+		Quantity:   1,
+	}, user.ID)
+	if err != nil {
+		t.Fatalf("CreateInventoryItem: %v", err)
+	}
+	if overridden.CustomSize == nil || *overridden.CustomSize != customSize || overridden.CustomUnit == nil || *overridden.CustomUnit != customUnit {
+		t.Errorf("overridden size = %v %v, want %v %q", overridden.CustomSize, overridden.CustomUnit, customSize, customUnit)
+	}
+
+	name := testName("custom")
+	custom, err := db.CreateInventoryItem(ctx, &models.CreateInventoryItemRequest{CustomName: &name, Quantity: 1}, user.ID)
+	if err != nil {
+		t.Fatalf("CreateInventoryItem: %v", err)
+	}
+	if custom.CustomSize != nil || custom.CustomUnit != nil {
+		t.Errorf("custom item size = %v %v, want none", custom.CustomSize, custom.CustomUnit)
+	}
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrItemNameRuleNotFound = errors.New("item name rule not found")
+
+// ListItemNameRules returns the item name rules ordered by pattern
+func (db *DB) ListItemNameRules(ctx context.Context, enabledOnly bool) ([]*models.ItemNameRule, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, pattern, replacement, enabled, created_at, updated_at
+		FROM item_name_rules
+		WHERE enabled OR NOT $1
+		ORDER BY pattern
+	`, enabledOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.ItemNameRule
+	for rows.Next() {
+		r := &models.ItemNameRule{}
+		if err := rows.Scan(&r.ID, &r.Pattern, &r.Replacement, &r.Enabled, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetItemNameReplacements returns the enabled rules as a lowercase pattern to replacement map
+func (db *DB) GetItemNameReplacements(ctx context.Context) (map[string]string, error) {
+	rules, err := db.ListItemNameRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := make(map[string]string, len(rules))
+	for _, r := range rules {
+		replacements[r.Pattern] = r.Replacement
+	}
+	return replacements, nil
+}
+
+// UpsertItemNameRule adds a rule for a pattern or updates the existing one
+func (db *DB) UpsertItemNameRule(ctx context.Context, pattern, replacement string, enabled bool) (*models.ItemNameRule, error) {
+	r := &models.ItemNameRule{}
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO item_name_rules (pattern, replacement, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (pattern) DO UPDATE SET replacement = $2, enabled = $3, updated_at = NOW()
+		RETURNING id, pattern, replacement, enabled, created_at, updated_at
+	`, strings.ToLower(pattern), replacement, enabled).Scan(
+		&r.ID, &r.Pattern, &r.Replacement, &r.Enabled, &r.CreatedAt, &r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DeleteItemNameRule removes an item name rule
+func (db *DB) DeleteItemNameRule(ctx context.Context, id int) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM item_name_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrItemNameRuleNotFound
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -12,9 +13,18 @@ import (
 )
 
 var (
-	ErrItemNotFound = errors.New("item not found")
+	ErrItemNotFound         = errors.New("item not found")
+	ErrProductGroupNotFound = errors.New("product group not found")
+	ErrDuplicateBarcode     = errors.New("another item already has this barcode")
 )
 
+// isBarcodeConflict reports whether err is a violation of the unique public or per-owner
+// private item barcode
+func isBarcodeConflict(err error) bool {
+	return err.Error() == `ERROR: duplicate key value violates unique constraint "idx_items_barcode_public" (SQLSTATE 23505)` ||
+		err.Error() == `ERROR: duplicate key value violates unique constraint "idx_items_barcode_private" (SQLSTATE 23505)`
+}
+
 // ListItems returns a paginated list of items with optional filtering
 // Users only see their own items + public items
 func (db *DB) ListItems(ctx context.Context, params *models.ItemListParams) ([]*models.ItemWithStats, int, error) {
@@ -54,6 +64,10 @@ func (db *DB) ListItems(ctx context.Context, params *models.ItemListParams) ([]*
 		argIndex++
 	}
 
+	if !params.IncludeArchived {
+		whereClauses = append(whereClauses, "i.archived_at IS NULL")
+	}
+
 	whereClause := ""
 	if len(whereClauses) > 0 {
 		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
@@ -70,25 +84,25 @@ func (db *DB) ListItems(ctx context.Context, params *models.ItemListParams) ([]*
 	// Get items with stats
 	query := fmt.Sprintf(`
 		SELECT
-			i.id, i.name, i.brand, i.size, i.unit, i.description,
+			i.id, i.name, i.brand, i.size, i.unit, i.description, i.barcode,
 			i.verified, i.verification_count, i.is_private, i.created_by, i.created_at, i.updated_at,
 			COALESCE((SELECT COUNT(*) FROM store_prices WHERE item_id = i.id), 0) as price_count,
-			(SELECT AVG(price) FROM store_prices WHERE item_id = i.id) as avg_price,
-			(SELECT MIN(price) FROM store_prices WHERE item_id = i.id) as min_price,
-			(SELECT MAX(price) FROM store_prices WHERE item_id = i.id) as max_price,
+			ps.avg_price, ps.min_price, ps.max_price,
 			COALESCE(
 				(SELECT array_agg(t.name ORDER BY t.name)
 				 FROM item_tags it JOIN tags t ON it.tag_id = t.id
 				 WHERE it.item_id = i.id),
 				ARRAY[]::TEXT[]
-			) as tags
+			) as tags,
+			i.archived_at
 		FROM items i
 		%s
+		%s
 		ORDER BY i.name ASC
 		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, itemPriceStatsJoin(argIndex, argIndex+1), whereClause, argIndex+2, argIndex+3)
 
-	args = append(args, params.Limit, params.Offset)
+	args = append(args, params.StatsFilter.Threshold(), params.StatsFilter.MinVerifiedPrices, params.Limit, params.Offset)
 
 	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
@@ -100,10 +114,10 @@ func (db *DB) ListItems(ctx context.Context, params *models.ItemListParams) ([]*
 	for rows.Next() {
 		item := &models.ItemWithStats{}
 		err := rows.Scan(
-			&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description,
+			&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description, &item.Barcode,
 			&item.Verified, &item.VerificationCount, &item.IsPrivate, &item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
 			&item.PriceCount, &item.AvgPrice, &item.MinPrice, &item.MaxPrice,
-			&item.Tags,
+			&item.Tags, &item.ArchivedAt,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -117,31 +131,50 @@ func (db *DB) ListItems(ctx context.Context, params *models.ItemListParams) ([]*
 	return items, total, nil
 }
 
-// GetItemByID retrieves an item by ID with stats
+// itemPriceStatsJoin joins the average, lowest and highest price of each item as ps. The
+// placeholders hold the verified_count a price needs and the number of such prices required
+// before the others are left out, following the public statistics verified-only mode.
+func itemPriceStatsJoin(thresholdArg, minVerifiedArg int) string {
+	return fmt.Sprintf(`LEFT JOIN LATERAL (
+			SELECT AVG(sp.price) as avg_price, MIN(sp.price) as min_price, MAX(sp.price) as max_price
+			FROM store_prices sp
+			WHERE sp.item_id = i.id
+			  AND (sp.verified_count >= $%[1]d
+			       OR (SELECT COUNT(*) FROM store_prices v WHERE v.item_id = i.id AND v.verified_count >= $%[1]d) < $%[2]d)
+		) ps ON true`, thresholdArg, minVerifiedArg)
+}
+
+// GetItemByID retrieves an item by ID with stats over every price
 func (db *DB) GetItemByID(ctx context.Context, id int) (*models.ItemWithStats, error) {
+	return db.GetItemWithStats(ctx, id, models.PublicStatsFilter{})
+}
+
+// GetItemWithStats retrieves an item by ID with price stats narrowed by the public statistics filter
+func (db *DB) GetItemWithStats(ctx context.Context, id int, filter models.PublicStatsFilter) (*models.ItemWithStats, error) {
 	item := &models.ItemWithStats{}
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT
-			i.id, i.name, i.brand, i.size, i.unit, i.description,
+			i.id, i.name, i.brand, i.size, i.unit, i.description, i.barcode,
 			i.verified, i.verification_count, i.is_private, i.created_by, i.created_at, i.updated_at,
 			COALESCE((SELECT COUNT(*) FROM store_prices WHERE item_id = i.id), 0) as price_count,
-			(SELECT AVG(price) FROM store_prices WHERE item_id = i.id) as avg_price,
-			(SELECT MIN(price) FROM store_prices WHERE item_id = i.id) as min_price,
-			(SELECT MAX(price) FROM store_prices WHERE item_id = i.id) as max_price,
+			ps.avg_price, ps.min_price, ps.max_price,
 			COALESCE(
 				(SELECT array_agg(t.name ORDER BY t.name)
 				 FROM item_tags it JOIN tags t ON it.tag_id = t.id
 				 WHERE it.item_id = i.id),
 				ARRAY[]::TEXT[]
-			) as tags
+			) as tags,
+			i.verification_basis, COALESCE(i.receipt_user_count, 0), i.archived_at
 		FROM items i
+		`+itemPriceStatsJoin(2, 3)+`
 		WHERE i.id = $1
-	`, id).Scan(
-		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description,
+	`, id, filter.Threshold(), filter.MinVerifiedPrices).Scan(
+		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description, &item.Barcode,
 		&item.Verified, &item.VerificationCount, &item.IsPrivate, &item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
 		&item.PriceCount, &item.AvgPrice, &item.MinPrice, &item.MaxPrice,
 		&item.Tags,
+		&item.VerificationBasis, &item.ReceiptUserCount, &item.ArchivedAt,
 	)
 
 	if err != nil {
@@ -169,15 +202,18 @@ func (db *DB) CreateItem(ctx context.Context, req *models.CreateItemRequest, cre
 	}
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO items (name, brand, size, unit, description, is_private, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
-		RETURNING id, name, brand, size, unit, description, verified, verification_count, is_private, created_by, created_at, updated_at
-	`, req.Name, req.Brand, req.Size, req.Unit, req.Description, isPrivate, createdBy).Scan(
-		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description,
+		INSERT INTO items (name, brand, size, unit, description, barcode, is_private, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NOW(), NOW())
+		RETURNING id, name, brand, size, unit, description, barcode, verified, verification_count, is_private, created_by, created_at, updated_at
+	`, req.Name, req.Brand, req.Size, req.Unit, req.Description, req.Barcode, isPrivate, createdBy).Scan(
+		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description, &item.Barcode,
 		&item.Verified, &item.VerificationCount, &item.IsPrivate, &item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
 	)
 
 	if err != nil {
+		if isBarcodeConflict(err) {
+			return nil, ErrDuplicateBarcode
+		}
 		return nil, err
 	}
 
@@ -214,6 +250,26 @@ func (db *DB) CreateItem(ctx context.Context, req *models.CreateItemRequest, cre
 	return item, nil
 }
 
+// GetItemByBarcode retrieves the item with a barcode in normalized GTIN-14 form. The user's own
+// private item wins over the public one; with a nil userID only public items are found.
+func (db *DB) GetItemByBarcode(ctx context.Context, barcode string, userID *int) (*models.ItemWithStats, error) {
+	var id int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id FROM items
+		WHERE barcode = $1 AND (is_private = false OR created_by = $2)
+		ORDER BY is_private DESC
+		LIMIT 1
+	`, barcode, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	return db.GetItemByID(ctx, id)
+}
+
 // UpdateItem updates an existing item
 func (db *DB) UpdateItem(ctx context.Context, id int, req *models.UpdateItemRequest) (*models.Item, error) {
 	item := &models.Item{}
@@ -226,11 +282,13 @@ func (db *DB) UpdateItem(ctx context.Context, id int, req *models.UpdateItemRequ
 		    unit = COALESCE($5, unit),
 		    description = COALESCE($6, description),
 		    verified = COALESCE($7, verified),
+		    verification_basis = CASE WHEN $7::boolean IS NULL THEN verification_basis ELSE 'manual' END,
+		    barcode = CASE WHEN $8::text IS NULL THEN barcode ELSE NULLIF($8, '') END,
 		    updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, brand, size, unit, description, verified, verification_count, is_private, created_by, created_at, updated_at
-	`, id, req.Name, req.Brand, req.Size, req.Unit, req.Description, req.Verified).Scan(
-		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description,
+		RETURNING id, name, brand, size, unit, description, barcode, verified, verification_count, is_private, created_by, created_at, updated_at
+	`, id, req.Name, req.Brand, req.Size, req.Unit, req.Description, req.Verified, req.Barcode).Scan(
+		&item.ID, &item.Name, &item.Brand, &item.Size, &item.Unit, &item.Description, &item.Barcode,
 		&item.Verified, &item.VerificationCount, &item.IsPrivate, &item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
 	)
 
@@ -238,6 +296,9 @@ func (db *DB) UpdateItem(ctx context.Context, id int, req *models.UpdateItemRequ
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrItemNotFound
 		}
+		if isBarcodeConflict(err) {
+			return nil, ErrDuplicateBarcode
+		}
 		return nil, err
 	}
 
@@ -276,9 +337,27 @@ func (db *DB) UpdateItem(ctx context.Context, id int, req *models.UpdateItemRequ
 	return item, nil
 }
 
-// DeleteItem deletes an item by ID
+// DeleteItem archives an item by ID. The row and its prices stay so community price history
+// survives; archived items are hidden from listings, search, comparisons and shopping plans.
+// Archiving an already archived item keeps the original timestamp.
 func (db *DB) DeleteItem(ctx context.Context, id int) error {
-	result, err := db.Pool.Exec(ctx, `DELETE FROM items WHERE id = $1`, id)
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE items SET archived_at = COALESCE(archived_at, NOW()), updated_at = NOW() WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+
+	return nil
+}
+
+// RestoreItem clears an item's archived timestamp
+func (db *DB) RestoreItem(ctx context.Context, id int) error {
+	result, err := db.Pool.Exec(ctx, `UPDATE items SET archived_at = NULL, updated_at = NOW() WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
@@ -315,54 +394,108 @@ func (db *DB) GetItemStats(ctx context.Context) (*models.ItemStats, error) {
 	}, nil
 }
 
-// SearchItems performs a fuzzy search on items
-// Only returns items visible to the user (public items OR user's own private items)
-func (db *DB) SearchItems(ctx context.Context, query string, limit int, userID *int) ([]*models.Item, error) {
-	var rows pgx.Rows
-	var err error
-
-	if userID != nil {
-		// User is logged in: show public items OR their own private items
-		rows, err = db.Pool.Query(ctx, `
-			SELECT id, name, brand, size, unit, description, verified, verification_count, is_private, created_by, created_at, updated_at
-			FROM items
-			WHERE (name ILIKE $1 OR brand ILIKE $1)
-			AND (is_private = false OR created_by = $4)
-			ORDER BY
-				CASE WHEN name ILIKE $2 || '%' THEN 0 ELSE 1 END,
-				name
-			LIMIT $3
-		`, "%"+query+"%", query, limit, *userID)
-	} else {
-		// No user: show only public items
-		rows, err = db.Pool.Query(ctx, `
-			SELECT id, name, brand, size, unit, description, verified, verification_count, is_private, created_by, created_at, updated_at
-			FROM items
-			WHERE (name ILIKE $1 OR brand ILIKE $1)
-			AND is_private = false
-			ORDER BY
-				CASE WHEN name ILIKE $2 || '%' THEN 0 ELSE 1 END,
-				name
-			LIMIT $3
-		`, "%"+query+"%", query, limit)
+// Search ranking weights added on top of the name's trigram similarity
+const (
+	searchBrandWeight = 0.3
+	searchTagWeight   = 0.25
+)
+
+// searchSimilarityFloor is the trigram similarity a name needs to match a search
+const searchSimilarityFloor = 0.2
+
+// beginTrigramSearch starts a read transaction in which the pg_trgm % operator matches at the
+// given similarity, so searches can narrow candidates with the trigram indexes before scoring
+func (db *DB) beginTrigramSearch(ctx context.Context, threshold float64) (pgx.Tx, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, fmt.Sprint(threshold)); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
 	}
+	return tx, nil
+}
+
+// SearchItems performs a fuzzy search on items, ranking by name similarity plus bonuses for
+// an exact brand match and for tags matching words in the query. Names must clear the 0.2
+// trigram floor or contain the query, but brand and tag matches alone are enough to qualify.
+// Candidates are gathered through indexed predicates first so only they are scored.
+// Only returns items visible to the user (public items OR user's own private items)
+func (db *DB) SearchItems(ctx context.Context, query string, limit int, userID *int) ([]*models.ItemSearchResult, error) {
+	words := strings.Fields(strings.ToLower(query))
 
+	tx, err := db.beginTrigramSearch(ctx, searchSimilarityFloor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		WITH candidates AS (
+			SELECT id FROM items
+			WHERE name % $1
+			   OR name ILIKE '%' || $1 || '%'
+			   OR brand ILIKE '%' || $1 || '%'
+			   OR LOWER(brand) = ANY($2::text[])
+			UNION
+			SELECT it.item_id
+			FROM tags t
+			JOIN item_tags it ON it.tag_id = t.id
+			WHERE LOWER(t.name) = LOWER($1) OR LOWER(t.name) = ANY($2::text[]) OR t.slug = ANY($2::text[])
+		),
+		scored AS (
+			SELECT i.id, i.name, i.brand, i.size, i.unit, i.description, i.barcode, i.verified, i.verification_count,
+				i.is_private, i.created_by, i.created_at, i.updated_at,
+				similarity(LOWER(i.name), LOWER($1)) AS name_score,
+				CASE WHEN LOWER(i.brand) = LOWER($1) OR LOWER(i.brand) = ANY($2::text[])
+					THEN $5::float8 ELSE 0 END AS brand_score,
+				COALESCE(tm.tags, '{}') AS matched_tags
+			FROM items i
+			LEFT JOIN LATERAL (
+				SELECT ARRAY_AGG(t.name ORDER BY t.name) AS tags
+				FROM item_tags it
+				JOIN tags t ON t.id = it.tag_id
+				WHERE it.item_id = i.id
+				  AND (LOWER(t.name) = LOWER($1) OR LOWER(t.name) = ANY($2::text[]) OR t.slug = ANY($2::text[]))
+			) tm ON true
+			WHERE i.id IN (SELECT id FROM candidates)
+			  AND i.archived_at IS NULL
+			  AND (i.is_private = false OR i.created_by = $4)
+		)
+		SELECT id, name, brand, size, unit, description, barcode, verified, verification_count, is_private, created_by, created_at, updated_at,
+			name_score, brand_score, CARDINALITY(matched_tags) * $6::float8 AS tag_score, matched_tags
+		FROM scored
+		WHERE name_score > $7
+		   OR name ILIKE '%' || $1 || '%'
+		   OR brand ILIKE '%' || $1 || '%'
+		   OR brand_score > 0
+		   OR CARDINALITY(matched_tags) > 0
+		ORDER BY
+			name_score + brand_score + CARDINALITY(matched_tags) * $6::float8 DESC,
+			CASE WHEN name ILIKE $1 || '%' THEN 0 ELSE 1 END,
+			name
+		LIMIT $3
+	`, query, words, limit, userID, searchBrandWeight, searchTagWeight, searchSimilarityFloor)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []*models.Item
+	var results []*models.ItemSearchResult
 	for rows.Next() {
-		i := &models.Item{}
-		if err := rows.Scan(&i.ID, &i.Name, &i.Brand, &i.Size, &i.Unit, &i.Description,
-			&i.Verified, &i.VerificationCount, &i.IsPrivate, &i.CreatedBy, &i.CreatedAt, &i.UpdatedAt); err != nil {
+		r := &models.ItemSearchResult{}
+		i := &r.Item
+		if err := rows.Scan(&i.ID, &i.Name, &i.Brand, &i.Size, &i.Unit, &i.Description, &i.Barcode,
+			&i.Verified, &i.VerificationCount, &i.IsPrivate, &i.CreatedBy, &i.CreatedAt, &i.UpdatedAt,
+			&r.Score.Name, &r.Score.Brand, &r.Score.Tags, &r.Score.MatchedTags); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		r.Score.Total = r.Score.Name + r.Score.Brand + r.Score.Tags
+		results = append(results, r)
 	}
 
-	return items, nil
+	return results, rows.Err()
 }
 
 // ListTags returns all tags
@@ -388,3 +521,375 @@ func (db *DB) ListTags(ctx context.Context) ([]*models.Tag, error) {
 
 	return tags, nil
 }
+
+// AutoVerifyItemsFromReceipts credits items with one verification per distinct user who
+// confirmed them on a receipt, then verifies items that reach minUsers verifications.
+// At least two distinct receipt users are required so nobody can self-verify an item.
+// Returns the number of items newly verified.
+func (db *DB) AutoVerifyItemsFromReceipts(ctx context.Context, minUsers int) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only new distinct users since the last run add to verification_count
+	_, err = tx.Exec(ctx, `
+		WITH receipt_counts AS (
+			SELECT ri.confirmed_item_id as item_id, COUNT(DISTINCT r.user_id) as user_count
+			FROM receipt_items ri
+			JOIN receipts r ON ri.receipt_id = r.id
+			WHERE r.status = 'confirmed'
+			  AND ri.is_confirmed = true
+			  AND ri.match_status <> 'skipped'
+			  AND ri.confirmed_item_id IS NOT NULL
+			GROUP BY ri.confirmed_item_id
+		)
+		UPDATE items i
+		SET verification_count = i.verification_count + (rc.user_count - COALESCE(i.receipt_user_count, 0)),
+		    receipt_user_count = rc.user_count,
+		    updated_at = NOW()
+		FROM receipt_counts rc
+		WHERE i.id = rc.item_id AND rc.user_count > COALESCE(i.receipt_user_count, 0)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx, `
+		UPDATE items
+		SET verified = true, verification_basis = 'receipts', updated_at = NOW()
+		WHERE verified = false
+		  AND receipt_user_count >= 2
+		  AND verification_count >= $1
+	`, minUsers)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+// GetItemVariantPrices returns items sharing the given item's name and brand (case-insensitive),
+// each with its lowest price visible to the user. The item itself is included.
+func (db *DB) GetItemVariantPrices(ctx context.Context, itemID int, userID *int) ([]*models.ItemVariantPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT v.id, v.name, v.brand, v.size, v.unit, best.price, best.store_id, best.store_name
+		FROM items base
+		JOIN items v ON LOWER(TRIM(v.name)) = LOWER(TRIM(base.name))
+			AND LOWER(COALESCE(v.brand, '')) = LOWER(COALESCE(base.brand, ''))
+		LEFT JOIN LATERAL (
+			SELECT sp.price, s.id as store_id, s.name as store_name
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			WHERE sp.item_id = v.id
+			  AND (sp.is_shared = true OR sp.user_id = $2)
+			  AND (s.is_private = false OR s.created_by = $2)
+			ORDER BY sp.price ASC
+			LIMIT 1
+		) best ON true
+		WHERE base.id = $1
+		  AND (v.is_private = false OR v.created_by = $2 OR v.id = base.id)
+		ORDER BY v.size NULLS LAST, v.id
+	`, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []*models.ItemVariantPrice
+	for rows.Next() {
+		v := &models.ItemVariantPrice{}
+		if err := rows.Scan(&v.ItemID, &v.Name, &v.Brand, &v.Size, &v.Unit, &v.BestPrice, &v.StoreID, &v.StoreName); err != nil {
+			return nil, err
+		}
+		v.IsCurrent = v.ItemID == itemID
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+// GetItemEquivalents returns other brands of the same product as itemID: members of its product
+// group, plus items that share a tag and whose names with the brand removed are at least
+// minSimilarity alike. Size variants of the item itself are left to the size comparison.
+func (db *DB) GetItemEquivalents(ctx context.Context, itemID int, userID *int, minSimilarity float64, limit int) (*models.ItemEquivalents, error) {
+	result := &models.ItemEquivalents{ItemID: itemID, Equivalents: []*models.ItemEquivalent{}}
+
+	err := db.Pool.QueryRow(ctx, `
+		SELECT i.product_group_id, (
+			SELECT MIN(sp.price)
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			WHERE sp.item_id = i.id
+			  AND (sp.is_shared = true OR sp.user_id = $2)
+			  AND (s.is_private = false OR s.created_by = $2)
+		)
+		FROM items i
+		WHERE i.id = $1
+	`, itemID, userID).Scan(&result.ProductGroupID, &result.BestPrice)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		WITH base AS (
+			SELECT id, name, brand, product_group_id,
+			       TRIM(REPLACE(LOWER(name), LOWER(COALESCE(brand, '')), '')) as generic_name
+			FROM items
+			WHERE id = $1
+		),
+		candidates AS (
+			SELECT v.id, v.name, v.brand, v.size, v.unit,
+			       COALESCE(v.product_group_id = b.product_group_id, false) as grouped,
+			       similarity(TRIM(REPLACE(LOWER(v.name), LOWER(COALESCE(v.brand, '')), '')), b.generic_name) as score
+			FROM items v, base b
+			WHERE v.id <> b.id
+			  AND v.archived_at IS NULL
+			  AND (v.is_private = false OR v.created_by = $2)
+			  AND NOT (LOWER(TRIM(v.name)) = LOWER(TRIM(b.name))
+			           AND LOWER(COALESCE(v.brand, '')) = LOWER(COALESCE(b.brand, '')))
+		)
+		SELECT c.id, c.name, c.brand, c.size, c.unit, c.grouped, c.score,
+		       best.price, best.store_id, best.store_name
+		FROM candidates c
+		LEFT JOIN LATERAL (
+			SELECT sp.price, s.id as store_id, s.name as store_name
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			WHERE sp.item_id = c.id
+			  AND (sp.is_shared = true OR sp.user_id = $2)
+			  AND (s.is_private = false OR s.created_by = $2)
+			ORDER BY sp.price ASC
+			LIMIT 1
+		) best ON true
+		WHERE c.grouped
+		   OR (c.score >= $3 AND EXISTS (
+				SELECT 1 FROM item_tags ct
+				JOIN item_tags bt ON bt.tag_id = ct.tag_id
+				WHERE ct.item_id = c.id AND bt.item_id = $1
+		   ))
+		ORDER BY c.grouped DESC, c.score DESC, c.id
+		LIMIT $4
+	`, itemID, userID, minSimilarity, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &models.ItemEquivalent{}
+		var grouped bool
+		if err := rows.Scan(&e.ItemID, &e.Name, &e.Brand, &e.Size, &e.Unit, &grouped, &e.Similarity,
+			&e.BestPrice, &e.StoreID, &e.StoreName); err != nil {
+			return nil, err
+		}
+		e.MatchType = models.EquivalentMatchSimilar
+		if grouped {
+			e.MatchType = models.EquivalentMatchProductGroup
+		}
+		result.Equivalents = append(result.Equivalents, e)
+	}
+
+	return result, rows.Err()
+}
+
+// CreateProductGroup creates a product group and moves the given items into it
+func (db *DB) CreateProductGroup(ctx context.Context, name string, itemIDs []int) (*models.ProductGroup, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	group := &models.ProductGroup{ItemIDs: []int{}}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO product_groups (name) VALUES ($1)
+		RETURNING id, name, created_at
+	`, name).Scan(&group.ID, &group.Name, &group.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE items SET product_group_id = $1, updated_at = NOW()
+		WHERE id = ANY($2)
+		RETURNING id
+	`, group.ID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		group.ItemIDs = append(group.ItemIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// SetItemProductGroup moves an item into a product group, or out of its group when groupID is nil
+func (db *DB) SetItemProductGroup(ctx context.Context, itemID int, groupID *int) error {
+	if groupID != nil {
+		var exists bool
+		if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM product_groups WHERE id = $1)`, *groupID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrProductGroupNotFound
+		}
+	}
+
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE items SET product_group_id = $2, updated_at = NOW() WHERE id = $1
+	`, itemID, groupID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// GetSimilarItemTags weighs the tags of public items whose names are similar to name.
+// Each tag's weight is the share of the total similarity carried by items with that tag.
+func (db *DB) GetSimilarItemTags(ctx context.Context, name string, limit int) ([]models.TagSuggestion, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH similar AS (
+			SELECT id, similarity(lower(name), lower($1)) as score
+			FROM items
+			WHERE COALESCE(is_private, false) = false
+			AND similarity(lower(name), lower($1)) >= 0.3
+			ORDER BY score DESC
+			LIMIT 10
+		)
+		SELECT t.slug, t.name, SUM(s.score) / NULLIF((SELECT SUM(score) FROM similar), 0) as weight
+		FROM similar s
+		JOIN item_tags it ON it.item_id = s.id
+		JOIN tags t ON it.tag_id = t.id
+		GROUP BY t.slug, t.name
+		ORDER BY weight DESC, t.name ASC
+		LIMIT $2
+	`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.TagSuggestion
+	for rows.Next() {
+		var s models.TagSuggestion
+		if err := rows.Scan(&s.Slug, &s.Name, &s.Confidence); err != nil {
+			return nil, err
+		}
+		s.Source = "similar_items"
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, rows.Err()
+}
+
+// GetItemCategories returns each item's primary tag, used as its aisle/category.
+// The most widely used tag wins so items group under broad categories. Untagged items are omitted.
+func (db *DB) GetItemCategories(ctx context.Context, itemIDs []int) (map[int]string, error) {
+	categories := make(map[int]string)
+	if len(itemIDs) == 0 {
+		return categories, nil
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT ON (it.item_id) it.item_id, t.name
+		FROM item_tags it
+		JOIN tags t ON it.tag_id = t.id
+		WHERE it.item_id = ANY($1)
+		ORDER BY it.item_id, t.usage_count DESC, t.name ASC
+	`, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID int
+		var name string
+		if err := rows.Scan(&itemID, &name); err != nil {
+			return nil, err
+		}
+		categories[itemID] = name
+	}
+
+	return categories, rows.Err()
+}
+
+// GetFrequentlyBoughtWith ranks public items by how many confirmed receipts and completed shopping
+// lists contain them together with itemID. Pairs seen in fewer than minBaskets baskets are dropped.
+// Returns one page of results and the total number of co-purchased items.
+func (db *DB) GetFrequentlyBoughtWith(ctx context.Context, itemID, minBaskets, limit, offset int) ([]*models.CoPurchasedItem, int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH baskets AS (
+			SELECT DISTINCT 'r' || ri.receipt_id AS basket, ri.confirmed_item_id AS item_id
+			FROM receipt_items ri
+			JOIN receipts r ON ri.receipt_id = r.id
+			WHERE r.status = 'confirmed' AND ri.confirmed_item_id IS NOT NULL
+			UNION
+			SELECT DISTINCT 'l' || sli.list_id, sli.item_id
+			FROM shopping_list_items sli
+			JOIN shopping_lists sl ON sli.list_id = sl.id
+			WHERE sl.status = 'completed' AND sli.item_id IS NOT NULL
+		),
+		target AS (
+			SELECT basket FROM baskets WHERE item_id = $1
+		),
+		pairs AS (
+			SELECT b.item_id, COUNT(*) AS together
+			FROM baskets b
+			JOIN target t ON b.basket = t.basket
+			WHERE b.item_id <> $1
+			GROUP BY b.item_id
+			HAVING COUNT(*) >= $2
+		)
+		SELECT i.id, i.name, i.brand, p.together,
+			p.together::float8 / NULLIF((SELECT COUNT(*) FROM target), 0) AS confidence,
+			COUNT(*) OVER () AS total
+		FROM pairs p
+		JOIN items i ON p.item_id = i.id
+		WHERE COALESCE(i.is_private, false) = false
+		ORDER BY p.together DESC, i.name ASC
+		LIMIT $3 OFFSET $4
+	`, itemID, minBaskets, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []*models.CoPurchasedItem{}
+	total := 0
+	for rows.Next() {
+		item := &models.CoPurchasedItem{}
+		if err := rows.Scan(&item.ItemID, &item.Name, &item.Brand, &item.BasketCount, &item.Confidence, &total); err != nil {
+			return nil, 0, err
+		}
+		item.Confidence = math.Round(item.Confidence*1000) / 1000
+		items = append(items, item)
+	}
+
+	return items, total, rows.Err()
+}
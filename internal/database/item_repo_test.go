@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestItemBarcodeScope(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	alice := testUser(t, db)
+	bob := testUser(t, db)
+	barcode := fmt.Sprintf("%014d", time.Now().UnixNano()%1e14)
+	code := func() *string { c := barcode; return &c }
+	private, public := true, false
+
+	pub := testItem(t, db, &models.CreateItemRequest{Barcode: code(), IsPrivate: &public}, nil)
+	own := testItem(t, db, &models.CreateItemRequest{Barcode: code(), IsPrivate: &private}, &alice.ID)
+	testItem(t, db, &models.CreateItemRequest{Barcode: code(), IsPrivate: &private}, &bob.ID)
+
+	if _, err := db.CreateItem(ctx, &models.CreateItemRequest{Name: testName("item"), Barcode: code(), IsPrivate: &public}, nil); !errors.Is(err, ErrDuplicateBarcode) {
+		t.Errorf("second public item: got %v, want ErrDuplicateBarcode", err)
+	}
+	if _, err := db.CreateItem(ctx, &models.CreateItemRequest{Name: testName("item"), Barcode: code(), IsPrivate: &private}, &alice.ID); !errors.Is(err, ErrDuplicateBarcode) {
+		t.Errorf("second private item for one owner: got %v, want ErrDuplicateBarcode", err)
+	}
+
+	got, err := db.GetItemByBarcode(ctx, barcode, &alice.ID)
+	if err != nil {
+		t.Fatalf("GetItemByBarcode(alice): %v", err)
+	}
+	if got.ID != own.ID {
+		t.Errorf("alice's lookup = item %d, want her own item %d", got.ID, own.ID)
+	}
+	got, err = db.GetItemByBarcode(ctx, barcode, nil)
+	if err != nil {
+		t.Fatalf("GetItemByBarcode(nil): %v", err)
+	}
+	if got.ID != pub.ID {
+		t.Errorf("anonymous lookup = item %d, want public item %d", got.ID, pub.ID)
+	}
+
+	// An empty barcode clears it, a missing one leaves it alone
+	name := "renamed"
+	updated, err := db.UpdateItem(ctx, pub.ID, &models.UpdateItemRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	if updated.Barcode == nil || *updated.Barcode != barcode {
+		t.Errorf("barcode after unrelated update = %v, want %s", updated.Barcode, barcode)
+	}
+	empty := ""
+	updated, err = db.UpdateItem(ctx, pub.ID, &models.UpdateItemRequest{Barcode: &empty})
+	if err != nil {
+		t.Fatalf("UpdateItem clear: %v", err)
+	}
+	if updated.Barcode != nil {
+		t.Errorf("barcode after clearing = %q, want nil", *updated.Barcode)
+	}
+}
+
+func TestItemStatsHonorVerifiedOnly(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	item := testItem(t, db, nil, nil)
+	verifier := testUser(t, db)
+	var verified *models.StorePrice
+	for _, amount := range []float64{1, 2, 6} {
+		submitter := testUser(t, db)
+		price := testPrice(t, db, testStore(t, db, nil).ID, item.ID, amount, &submitter.ID)
+		if amount == 2 {
+			verified = price
+		}
+	}
+	if _, err := db.VerifyPrice(ctx, verified.ID, verifier.ID, true, 0, 0); err != nil {
+		t.Fatalf("VerifyPrice: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		filter      models.PublicStatsFilter
+		avg, lo, hi float64
+	}{
+		{"mode off", models.PublicStatsFilter{}, 3, 1, 6},
+		{"verified only", models.PublicStatsFilter{VerifiedOnly: true, MinVerifications: 1, MinVerifiedPrices: 1}, 2, 2, 2},
+		{"too few verified falls back", models.PublicStatsFilter{VerifiedOnly: true, MinVerifications: 1, MinVerifiedPrices: 2}, 3, 1, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetItemWithStats(ctx, item.ID, tt.filter)
+			if err != nil {
+				t.Fatalf("GetItemWithStats: %v", err)
+			}
+			if got.AvgPrice == nil || got.MinPrice == nil || got.MaxPrice == nil {
+				t.Fatalf("missing stats: %+v", got)
+			}
+			if *got.AvgPrice != tt.avg || *got.MinPrice != tt.lo || *got.MaxPrice != tt.hi {
+				t.Errorf("stats = avg %v min %v max %v, want %v %v %v", *got.AvgPrice, *got.MinPrice, *got.MaxPrice, tt.avg, tt.lo, tt.hi)
+			}
+
+			listed, _, err := db.ListItems(ctx, &models.ItemListParams{Limit: 1, Search: item.Name, StatsFilter: tt.filter})
+			if err != nil {
+				t.Fatalf("ListItems: %v", err)
+			}
+			if len(listed) != 1 || listed[0].AvgPrice == nil || *listed[0].AvgPrice != tt.avg {
+				t.Errorf("listed stats differ from detail: %+v", listed)
+			}
+		})
+	}
+}
+
+func TestSearchItemsCandidates(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	brand := "brand" + suffix
+	tag := "tag" + suffix
+	byName := testItem(t, db, &models.CreateItemRequest{Name: "Crunchy Peanut Butter " + suffix}, nil)
+	byBrand := testItem(t, db, &models.CreateItemRequest{Brand: &brand}, nil)
+	byTag := testItem(t, db, &models.CreateItemRequest{Tags: []string{tag}}, nil)
+
+	found := func(results []*models.ItemSearchResult, id int) bool {
+		for _, r := range results {
+			if r.Item.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"misspelled name", "crunchy peanut buter " + suffix, byName.ID},
+		{"brand word", brand + " cereal", byBrand.ID},
+		{"tag", tag, byTag.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := db.SearchItems(ctx, tt.query, 50, nil)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if !found(results, tt.want) {
+				t.Errorf("SearchItems(%q) did not return item %d", tt.query, tt.want)
+			}
+		})
+	}
+
+	matches, err := db.FindSimilarItems(ctx, "crunchy peanut buter "+suffix, 50)
+	if err != nil {
+		t.Fatalf("FindSimilarItems: %v", err)
+	}
+	var matched bool
+	for _, m := range matches {
+		matched = matched || m.ItemID == byName.ID
+	}
+	if !matched {
+		t.Errorf("FindSimilarItems did not return item %d", byName.ID)
+	}
+}
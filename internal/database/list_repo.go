@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"math"
 	"sort"
 	"time"
 
@@ -44,10 +46,7 @@ func (db *DB) ListShoppingLists(ctx context.Context, params *models.ListListPara
 			COALESCE((SELECT COUNT(*) FROM shopping_list_items WHERE list_id = sl.id), 0) as item_count,
 			COALESCE((
 				SELECT SUM(
-					sli.quantity * COALESCE(
-						(SELECT MIN(sp.price) FROM store_prices sp WHERE sp.item_id = sli.item_id),
-						0
-					)
+					sli.quantity * COALESCE(`+bestPriceSQL("sli.item_id", params.DenormalizedBestPrice)+`, 0)
 				)
 				FROM shopping_list_items sli
 				WHERE sli.list_id = sl.id
@@ -78,17 +77,81 @@ func (db *DB) ListShoppingLists(ctx context.Context, params *models.ListListPara
 	return lists, total, nil
 }
 
+// communityBestPriceFilter limits store_prices (sp) joined to stores (s) and items (i) to the
+// prices that count toward an item's best price. It matches refresh_item_best_prices.
+var communityBestPriceFilter = fmt.Sprintf(`i.archived_at IS NULL
+			AND sp.is_shared = true
+			AND sp.flag_count < %d
+			AND COALESCE(s.is_private, false) = false`, models.PriceFlagHideThreshold)
+
+// bestPriceSQL is the lowest community price of the item in itemColumn: the items.best_price
+// column kept current by store_prices triggers, or a live MIN over store_prices when denormalized is off
+func bestPriceSQL(itemColumn string, denormalized bool) string {
+	if denormalized {
+		return "(SELECT bi.best_price FROM items bi WHERE bi.id = " + itemColumn + ")"
+	}
+	return `(SELECT MIN(sp.price) FROM store_prices sp
+			JOIN stores s ON s.id = sp.store_id
+			JOIN items i ON i.id = sp.item_id
+			WHERE sp.item_id = ` + itemColumn + ` AND ` + communityBestPriceFilter + `)`
+}
+
+// RefreshItemBestPrices recomputes the stored best price and store of every item that drifted
+// from its prices, repairing them through refresh_item_best_prices so the repair takes the same
+// item locks as the triggers. Returns the number of items whose values were refreshed.
+func (db *DB) RefreshItemBestPrices(ctx context.Context) (int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT i.id
+		FROM items i
+		LEFT JOIN LATERAL (
+			SELECT sp.price, sp.store_id
+			FROM store_prices sp
+			JOIN stores s ON s.id = sp.store_id
+			WHERE sp.item_id = i.id AND `+communityBestPriceFilter+`
+			ORDER BY sp.price ASC, sp.updated_at DESC
+			LIMIT 1
+		) b ON true
+		WHERE i.best_price IS DISTINCT FROM b.price OR i.best_store_id IS DISTINCT FROM b.store_id
+	`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if _, err := db.Pool.Exec(ctx, `SELECT refresh_item_best_prices($1)`, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
 // GetShoppingListByID retrieves a shopping list with all its items
 func (db *DB) GetShoppingListByID(ctx context.Context, id int, userID int) (*models.ShoppingListWithItems, error) {
 	// Get the list
 	list := &models.ShoppingListWithItems{}
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, user_id, name, status, target_date, completed_at, share_token, share_expires_at, share_created_at, created_at, updated_at
+		SELECT id, user_id, name, status, target_date, budget, completed_at, share_token, share_expires_at, share_created_at,
+			recurrence, next_occurrence, created_at, updated_at
 		FROM shopping_lists
 		WHERE id = $1
 	`, id).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt,
-		&list.ShareToken, &list.ShareExpiresAt, &list.ShareCreatedAt, &list.CreatedAt, &list.UpdatedAt,
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt,
+		&list.ShareToken, &list.ShareExpiresAt, &list.ShareCreatedAt,
+		&list.Recurrence, &list.NextOccurrence, &list.CreatedAt, &list.UpdatedAt,
 	)
 
 	if err != nil {
@@ -150,6 +213,7 @@ func (db *DB) GetShoppingListByID(ctx context.Context, id int, userID int) (*mod
 	list.ItemCount = len(list.Items)
 	list.CheckedCount = checkedCount
 	list.EstimatedTotal = estimatedTotal
+	list.OverBudget, list.BudgetOverage = checkBudget(list.Budget, estimatedTotal)
 
 	return list, nil
 }
@@ -159,11 +223,11 @@ func (db *DB) CreateShoppingList(ctx context.Context, req *models.CreateListRequ
 	list := &models.ShoppingList{}
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO shopping_lists (user_id, name, status, target_date, created_at, updated_at)
-		VALUES ($1, $2, 'active', $3, NOW(), NOW())
-		RETURNING id, user_id, name, status, target_date, completed_at, created_at, updated_at
-	`, userID, req.Name, req.TargetDate).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
+		INSERT INTO shopping_lists (user_id, name, status, target_date, budget, created_at, updated_at)
+		VALUES ($1, $2, 'active', $3, $4, NOW(), NOW())
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
+	`, userID, req.Name, req.TargetDate, req.Budget).Scan(
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
 	)
 
 	if err != nil {
@@ -173,7 +237,7 @@ func (db *DB) CreateShoppingList(ctx context.Context, req *models.CreateListRequ
 	return list, nil
 }
 
-// UpdateShoppingList updates a shopping list
+// UpdateShoppingList updates a shopping list. Nil fields are left unchanged; ClearBudget removes the budget.
 func (db *DB) UpdateShoppingList(ctx context.Context, id int, userID int, req *models.UpdateListRequest) (*models.ShoppingList, error) {
 	list := &models.ShoppingList{}
 
@@ -181,11 +245,12 @@ func (db *DB) UpdateShoppingList(ctx context.Context, id int, userID int, req *m
 		UPDATE shopping_lists
 		SET name = COALESCE($3, name),
 		    target_date = COALESCE($4, target_date),
+		    budget = CASE WHEN $6 THEN NULL ELSE COALESCE($5, budget) END,
 		    updated_at = NOW()
 		WHERE id = $1 AND user_id = $2
-		RETURNING id, user_id, name, status, target_date, completed_at, created_at, updated_at
-	`, id, userID, req.Name, req.TargetDate).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
+	`, id, userID, req.Name, req.TargetDate, req.Budget, req.ClearBudget).Scan(
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
 	)
 
 	if err != nil {
@@ -319,39 +384,71 @@ func (db *DB) RemoveItemFromList(ctx context.Context, listID int, itemID int, us
 	return nil
 }
 
-// BuildShoppingPlan generates an optimized shopping plan for a list
-func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, regionID *int) (*models.ShoppingPlanResult, error) {
-	// Verify list ownership and get items
-	list, err := db.GetShoppingListByID(ctx, listID, userID)
-	if err != nil {
-		return nil, err
-	}
+// priceMatrixFilter narrows the prices loaded into a price matrix
+type priceMatrixFilter struct {
+	RegionID   *int
+	MaxAgeDays *int     // Ignore prices not updated or verified within this many days
+	Latitude   *float64 // With Longitude, computes store distances
+	Longitude  *float64
+	StoreIDs   []int // Only these stores when non-empty
+}
 
-	if len(list.Items) == 0 {
-		return nil, errors.New("shopping list is empty")
+// priceMatrix holds the cheapest visible price per store and item
+type priceMatrix struct {
+	prices         map[int]map[int]float64 // storeID -> itemID -> price
+	storeNames     map[int]string
+	storeAddresses map[int]string
+	storeDistances map[int]float64 // km; only set for stores with coordinates when a location was given
+	itemNames      map[int]string
+	verified       map[int]map[int]int       // storeID -> itemID -> verified count of the kept price
+	refreshed      map[int]map[int]time.Time // storeID -> itemID -> last update or verification of the kept price
+}
+
+// preferPrice breaks a tie between two stores with the same price for an item: the price
+// verified more often wins, then the fresher one.
+func (m *priceMatrix) preferPrice(storeID, otherID, itemID int) bool {
+	if m.verified[storeID][itemID] != m.verified[otherID][itemID] {
+		return m.verified[storeID][itemID] > m.verified[otherID][itemID]
 	}
+	return m.refreshed[storeID][itemID].After(m.refreshed[otherID][itemID])
+}
 
-	// Get all item IDs from the list
-	itemIDs := make([]int, len(list.Items))
-	itemQuantities := make(map[int]int)
-	for i, item := range list.Items {
-		itemIDs[i] = item.ItemID
-		itemQuantities[item.ItemID] = item.Quantity
+// loadPriceMatrix loads prices for the given items visible to userID.
+// Includes shared prices, the user's own prices, and prices from stores the user created.
+// Prices flagged as wrong too often are skipped until re-verified.
+func (db *DB) loadPriceMatrix(ctx context.Context, itemIDs []int, userID int, filter *priceMatrixFilter) (*priceMatrix, error) {
+	if filter == nil {
+		filter = &priceMatrixFilter{}
+	}
+	storeIDs := filter.StoreIDs
+	if storeIDs == nil {
+		storeIDs = []int{}
 	}
 
-	// Build price matrix: map[storeID]map[itemID]price
-	priceMatrix := make(map[int]map[int]float64)
-	storeNames := make(map[int]string)
-	storeAddresses := make(map[int]string)
-	itemNames := make(map[int]string)
+	matrix := &priceMatrix{
+		prices:         make(map[int]map[int]float64),
+		storeNames:     make(map[int]string),
+		storeAddresses: make(map[int]string),
+		storeDistances: make(map[int]float64),
+		itemNames:      make(map[int]string),
+		verified:       make(map[int]map[int]int),
+		refreshed:      make(map[int]map[int]time.Time),
+	}
 
-	// Query all prices for the items in the list
-	// Include: shared prices, user's own prices, and prices from stores the user created
 	rows, err := db.Pool.Query(ctx, `
 		SELECT
-			sp.store_id, sp.item_id, sp.price,
+			sp.store_id, sp.item_id, sp.price, sp.verified_count,
+			GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) as refreshed_at,
 			s.name as store_name, i.name as item_name,
-			COALESCE(s.street_address, '') || ', ' || COALESCE(s.city, '') || ', ' || COALESCE(s.state, '') as store_address
+			COALESCE(s.street_address, '') || ', ' || COALESCE(s.city, '') || ', ' || COALESCE(s.state, '') as store_address,
+			CASE WHEN $5::float8 IS NULL OR $6::float8 IS NULL OR s.latitude IS NULL OR s.longitude IS NULL THEN NULL
+			ELSE 6371 * acos(
+				LEAST(1.0, GREATEST(-1.0,
+					cos(radians($5)) * cos(radians(s.latitude)) *
+					cos(radians(s.longitude) - radians($6)) +
+					sin(radians($5)) * sin(radians(s.latitude))
+				))
+			) END as distance_km
 		FROM store_prices sp
 		JOIN stores s ON sp.store_id = s.id
 		JOIN items i ON sp.item_id = i.id
@@ -364,51 +461,103 @@ func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, reg
 			-- Include prices from stores the user created (even if price wasn't marked as theirs)
 			OR s.created_by = $2
 		)
+		AND i.archived_at IS NULL
 		AND (s.is_private = false OR s.created_by = $2)
-		ORDER BY sp.price ASC, sp.updated_at DESC
-	`, itemIDs, userID)
+		AND ($3::int IS NULL OR s.region_id = $3)
+		AND ($4::int IS NULL OR GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - make_interval(days => $4))
+		AND (cardinality($7::int[]) = 0 OR sp.store_id = ANY($7::int[]))
+		AND sp.flag_count < $8
+		ORDER BY sp.price ASC, sp.verified_count DESC, refreshed_at DESC
+	`, itemIDs, userID, filter.RegionID, filter.MaxAgeDays, filter.Latitude, filter.Longitude, storeIDs, models.PriceFlagHideThreshold)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var storeID, itemID int
+		var storeID, itemID, verified int
 		var price float64
+		var refreshed time.Time
 		var storeName, itemName, storeAddress string
-		if err := rows.Scan(&storeID, &itemID, &price, &storeName, &itemName, &storeAddress); err != nil {
+		var distance *float64
+		if err := rows.Scan(&storeID, &itemID, &price, &verified, &refreshed, &storeName, &itemName, &storeAddress, &distance); err != nil {
 			return nil, err
 		}
 
-		if priceMatrix[storeID] == nil {
-			priceMatrix[storeID] = make(map[int]float64)
+		if matrix.prices[storeID] == nil {
+			matrix.prices[storeID] = make(map[int]float64)
+			matrix.verified[storeID] = make(map[int]int)
+			matrix.refreshed[storeID] = make(map[int]time.Time)
+		}
+		// Only keep the first (cheapest, then best verified and freshest) price per store/item
+		if _, exists := matrix.prices[storeID][itemID]; !exists {
+			matrix.prices[storeID][itemID] = price
+			matrix.verified[storeID][itemID] = verified
+			matrix.refreshed[storeID][itemID] = refreshed
 		}
-		// Only keep the first (cheapest) price per store/item
-		if _, exists := priceMatrix[storeID][itemID]; !exists {
-			priceMatrix[storeID][itemID] = price
+		matrix.storeNames[storeID] = storeName
+		matrix.storeAddresses[storeID] = storeAddress
+		matrix.itemNames[itemID] = itemName
+		if distance != nil {
+			matrix.storeDistances[storeID] = *distance
 		}
-		storeNames[storeID] = storeName
-		storeAddresses[storeID] = storeAddress
-		itemNames[itemID] = itemName
 	}
 
+	return matrix, rows.Err()
+}
+
+// BuildShoppingPlan generates an optimized shopping plan for a list
+// When suggestDrops is set and the plan exceeds the list budget, items to drop are suggested.
+// A nil constraints uses the default trip cap and savings threshold. A non-nil regionID limits
+// the plan to stores in that region.
+func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, regionID *int, suggestDrops bool, constraints *models.PlanConstraints) (*models.ShoppingPlanResult, error) {
+	return db.buildShoppingPlan(ctx, listID, userID, &priceMatrixFilter{RegionID: regionID}, suggestDrops, constraints)
+}
+
+// BuildShoppingPlanForStores generates a shopping plan limited to the given stores (all stores when empty)
+func (db *DB) BuildShoppingPlanForStores(ctx context.Context, listID int, userID int, storeIDs []int, suggestDrops bool, constraints *models.PlanConstraints) (*models.ShoppingPlanResult, error) {
+	return db.buildShoppingPlan(ctx, listID, userID, &priceMatrixFilter{StoreIDs: storeIDs}, suggestDrops, constraints)
+}
+
+// buildShoppingPlan generates a shopping plan from the prices filter selects
+func (db *DB) buildShoppingPlan(ctx context.Context, listID int, userID int, filter *priceMatrixFilter, suggestDrops bool, constraints *models.PlanConstraints) (*models.ShoppingPlanResult, error) {
+	if constraints == nil {
+		constraints = &models.PlanConstraints{MaxTrips: models.DefaultPlanMaxTrips, MinSavings: models.DefaultPlanMinSavings}
+	}
+
+	// Verify list ownership and get items
+	list, err := db.GetShoppingListByID(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list.Items) == 0 {
+		return nil, errors.New("shopping list is empty")
+	}
+
+	// Get all item IDs from the list
+	itemIDs := make([]int, len(list.Items))
+	itemQuantities := make(map[int]int)
+	for i, item := range list.Items {
+		itemIDs[i] = item.ItemID
+		itemQuantities[item.ItemID] = item.Quantity
+	}
+
+	// Build price matrix: map[storeID]map[itemID]price
+	filter.MaxAgeDays = constraints.MaxAgeDays
+	matrix, err := db.loadPriceMatrix(ctx, itemIDs, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	priceMatrix := matrix.prices
+	storeNames := matrix.storeNames
+	storeAddresses := matrix.storeAddresses
+	itemNames := matrix.itemNames
+
 	// Calculate single-store options
 	var singleStoreOptions []models.SingleStoreOption
 	for storeID, prices := range priceMatrix {
-		option := models.SingleStoreOption{
-			StoreID:   storeID,
-			StoreName: storeNames[storeID],
-		}
-
-		for _, itemID := range itemIDs {
-			if price, exists := prices[itemID]; exists {
-				option.TotalCost += price * float64(itemQuantities[itemID])
-				option.ItemsFound++
-			} else {
-				option.ItemsMissing = append(option.ItemsMissing, itemNames[itemID])
-			}
-		}
-		singleStoreOptions = append(singleStoreOptions, option)
+		singleStoreOptions = append(singleStoreOptions, singleStoreOption(storeID, storeNames[storeID], prices, itemIDs, itemQuantities, itemNames))
 	}
 
 	// Sort by total cost (stores with all items first, then by price)
@@ -426,44 +575,54 @@ func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, reg
 		bestSingleStore = &singleStoreOptions[0]
 	}
 
-	// Calculate multi-store option (best price per item)
+	// Calculate multi-store option (best price per item), merging stores away when the
+	// cheapest split needs more trips than allowed
 	multiStore := &models.MultiStoreOption{
 		Stores: []models.MultiStoreBreakdown{},
 	}
 
+	allStores := make(map[int]bool, len(priceMatrix))
+	for storeID := range priceMatrix {
+		allStores[storeID] = true
+	}
+	assignment := cheapestStoreAssignment(priceMatrix, itemIDs, allStores, matrix.preferPrice)
+	if used := assignedStores(assignment); len(used) > constraints.MaxTrips {
+		kept := mergeStoresToTripCap(priceMatrix, itemIDs, itemQuantities, used, constraints.MaxTrips)
+		capped := cheapestStoreAssignment(priceMatrix, itemIDs, kept, matrix.preferPrice)
+		for _, itemID := range itemIDs {
+			if _, priced := assignment[itemID]; priced {
+				if _, stillPriced := capped[itemID]; !stillPriced {
+					multiStore.ItemsMissing = append(multiStore.ItemsMissing, itemNames[itemID])
+				}
+			}
+		}
+		assignment = capped
+		multiStore.TripCapped = true
+	}
+
 	storeItems := make(map[int][]models.StorePlanItemWithDetails)
 	storeSubtotals := make(map[int]float64)
 
 	for _, itemID := range itemIDs {
-		var bestPrice float64 = -1
-		var bestStoreID int
-
-		// Find the best price across all stores
-		for storeID, prices := range priceMatrix {
-			if price, exists := prices[itemID]; exists {
-				if bestPrice < 0 || price < bestPrice {
-					bestPrice = price
-					bestStoreID = storeID
-				}
-			}
+		bestStoreID, ok := assignment[itemID]
+		if !ok {
+			continue
 		}
-
-		if bestPrice >= 0 {
-			quantity := itemQuantities[itemID]
-			item := models.StorePlanItemWithDetails{
-				StorePlanItem: models.StorePlanItem{
-					StoreID:  bestStoreID,
-					ItemID:   itemID,
-					Quantity: quantity,
-					Price:    bestPrice,
-				},
-				StoreName: storeNames[bestStoreID],
-				ItemName:  itemNames[itemID],
-			}
-			storeItems[bestStoreID] = append(storeItems[bestStoreID], item)
-			storeSubtotals[bestStoreID] += bestPrice * float64(quantity)
-			multiStore.TotalCost += bestPrice * float64(quantity)
+		bestPrice := priceMatrix[bestStoreID][itemID]
+		quantity := itemQuantities[itemID]
+		item := models.StorePlanItemWithDetails{
+			StorePlanItem: models.StorePlanItem{
+				StoreID:  bestStoreID,
+				ItemID:   itemID,
+				Quantity: quantity,
+				Price:    bestPrice,
+			},
+			StoreName: storeNames[bestStoreID],
+			ItemName:  itemNames[itemID],
 		}
+		storeItems[bestStoreID] = append(storeItems[bestStoreID], item)
+		storeSubtotals[bestStoreID] += bestPrice * float64(quantity)
+		multiStore.TotalCost += bestPrice * float64(quantity)
 	}
 
 	// Build store breakdowns
@@ -485,11 +644,9 @@ func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, reg
 		multiStore.TotalSavings = bestSingleStore.TotalCost - multiStore.TotalCost
 	}
 
-	// Determine recommendation
+	// Determine recommendation; a capped plan that lost items is never preferred
 	recommendation := "single_store"
-	savingsThreshold := 10.00 // Configurable threshold
-
-	if multiStore.TotalSavings >= savingsThreshold && multiStore.TripCount <= 3 {
+	if multiStore.TotalSavings >= constraints.MinSavings && len(multiStore.ItemsMissing) == 0 {
 		recommendation = "multi_store"
 	}
 
@@ -498,22 +655,217 @@ func (db *DB) BuildShoppingPlan(ctx context.Context, listID int, userID int, reg
 		SingleStore:    bestSingleStore,
 		MultiStore:     multiStore,
 		Recommendation: recommendation,
+		Constraints:    *constraints,
+		Budget:         list.Budget,
+	}
+
+	// Compare the recommended plan against the list budget
+	var planItems []models.StorePlanItemWithDetails
+	if recommendation == "multi_store" || bestSingleStore == nil {
+		result.PlanTotal = multiStore.TotalCost
+		for _, breakdown := range multiStore.Stores {
+			planItems = append(planItems, breakdown.Items...)
+		}
+	} else {
+		result.PlanTotal = bestSingleStore.TotalCost
+		for _, itemID := range itemIDs {
+			if price, exists := priceMatrix[bestSingleStore.StoreID][itemID]; exists {
+				planItems = append(planItems, models.StorePlanItemWithDetails{
+					StorePlanItem: models.StorePlanItem{
+						StoreID:  bestSingleStore.StoreID,
+						ItemID:   itemID,
+						Quantity: itemQuantities[itemID],
+						Price:    price,
+					},
+					StoreName: bestSingleStore.StoreName,
+					ItemName:  itemNames[itemID],
+				})
+			}
+		}
+	}
+	result.OverBudget, result.BudgetOverage = checkBudget(list.Budget, result.PlanTotal)
+
+	if result.OverBudget && suggestDrops {
+		result.DropSuggestions = suggestBudgetDrops(planItems, result.BudgetOverage)
 	}
 
 	return result, nil
 }
 
-// GetPriceComparison generates a price comparison grid
+// singleStoreOption totals the items a store has prices for and lists the ones it is missing
+func singleStoreOption(storeID int, storeName string, prices map[int]float64, itemIDs []int, quantities map[int]int, itemNames map[int]string) models.SingleStoreOption {
+	option := models.SingleStoreOption{
+		StoreID:   storeID,
+		StoreName: storeName,
+	}
+
+	for _, itemID := range itemIDs {
+		if price, exists := prices[itemID]; exists {
+			option.TotalCost += price * float64(quantities[itemID])
+			option.ItemsFound++
+		} else {
+			option.ItemsMissing = append(option.ItemsMissing, itemNames[itemID])
+		}
+	}
+
+	return option
+}
+
+// cheapestStoreAssignment maps each item to the store in stores with its lowest price. Items
+// without a price at any of the stores are left out; ties go to the store prefer(store, best, item)
+// favors, or else the lower store ID. A nil prefer only uses the store ID.
+func cheapestStoreAssignment(priceMatrix map[int]map[int]float64, itemIDs []int, stores map[int]bool, prefer func(storeID, otherID, itemID int) bool) map[int]int {
+	assignment := make(map[int]int, len(itemIDs))
+	for _, itemID := range itemIDs {
+		bestPrice := -1.0
+		bestStoreID := 0
+		for storeID := range stores {
+			price, exists := priceMatrix[storeID][itemID]
+			if !exists {
+				continue
+			}
+			if bestPrice < 0 || price < bestPrice || (price == bestPrice && preferStore(prefer, storeID, bestStoreID, itemID)) {
+				bestPrice = price
+				bestStoreID = storeID
+			}
+		}
+		if bestPrice >= 0 {
+			assignment[itemID] = bestStoreID
+		}
+	}
+	return assignment
+}
+
+// preferStore breaks a price tie between two stores, falling back to the lower store ID
+func preferStore(prefer func(storeID, otherID, itemID int) bool, storeID, otherID, itemID int) bool {
+	if prefer != nil {
+		if prefer(storeID, otherID, itemID) {
+			return true
+		}
+		if prefer(otherID, storeID, itemID) {
+			return false
+		}
+	}
+	return storeID < otherID
+}
+
+// assignedStores returns the set of stores an assignment visits
+func assignedStores(assignment map[int]int) map[int]bool {
+	stores := make(map[int]bool)
+	for _, storeID := range assignment {
+		stores[storeID] = true
+	}
+	return stores
+}
+
+// mergeStoresToTripCap greedily drops stores until at most maxTrips remain. Each step drops the
+// store whose items are cheapest to buy at the remaining stores instead, and only gives up an
+// item when every remaining store is the sole source of one.
+func mergeStoresToTripCap(priceMatrix map[int]map[int]float64, itemIDs []int, quantities map[int]int, stores map[int]bool, maxTrips int) map[int]bool {
+	kept := make(map[int]bool, len(stores))
+	for storeID := range stores {
+		kept[storeID] = true
+	}
+	if maxTrips < 1 {
+		maxTrips = 1
+	}
+
+	for len(kept) > maxTrips {
+		candidates := make([]int, 0, len(kept))
+		for storeID := range kept {
+			candidates = append(candidates, storeID)
+		}
+		sort.Ints(candidates)
+
+		dropID, dropMissing, dropCost := 0, -1, 0.0
+		for _, storeID := range candidates {
+			delete(kept, storeID)
+			assignment := cheapestStoreAssignment(priceMatrix, itemIDs, kept, nil)
+			kept[storeID] = true
+
+			missing := len(itemIDs) - len(assignment)
+			var cost float64
+			for itemID, assigned := range assignment {
+				cost += priceMatrix[assigned][itemID] * float64(quantities[itemID])
+			}
+			if dropMissing < 0 || missing < dropMissing || (missing == dropMissing && cost < dropCost) {
+				dropID, dropMissing, dropCost = storeID, missing, cost
+			}
+		}
+		delete(kept, dropID)
+	}
+
+	return kept
+}
+
+// checkBudget reports whether a total exceeds the budget and by how much
+func checkBudget(budget *float64, total float64) (bool, float64) {
+	if budget == nil || total <= *budget {
+		return false, 0
+	}
+	return true, total - *budget
+}
+
+// suggestBudgetDrops picks the cheapest items whose removal brings the plan under budget.
+// A single item that covers the overage on its own is preferred; otherwise the cheapest
+// items are dropped one at a time until the overage is covered.
+func suggestBudgetDrops(items []models.StorePlanItemWithDetails, overage float64) []models.BudgetDropSuggestion {
+	lines := make([]models.BudgetDropSuggestion, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, models.BudgetDropSuggestion{
+			ItemID:    item.ItemID,
+			ItemName:  item.ItemName,
+			Quantity:  item.Quantity,
+			LineTotal: item.Price * float64(item.Quantity),
+		})
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].LineTotal < lines[j].LineTotal
+	})
+
+	for _, line := range lines {
+		if line.LineTotal >= overage {
+			return []models.BudgetDropSuggestion{line}
+		}
+	}
+
+	var drops []models.BudgetDropSuggestion
+	var saved float64
+	for _, line := range lines {
+		if saved >= overage {
+			break
+		}
+		drops = append(drops, line)
+		saved += line.LineTotal
+	}
+
+	return drops
+}
+
+// GetPriceComparison generates a price comparison grid. Prices flagged as wrong too often are left out.
 func (db *DB) GetPriceComparison(ctx context.Context, params *models.CompareParams) (*models.PriceComparisonResult, error) {
 	result := &models.PriceComparisonResult{
 		Stores: []models.StoreBasic{},
 		Items:  []models.PriceComparisonRow{},
 	}
 
-	// Get store info
+	// Get store info, with Haversine distance (km) when the caller supplied coordinates
 	storeRows, err := db.Pool.Query(ctx, `
-		SELECT id, name FROM stores WHERE id = ANY($1) ORDER BY name
-	`, params.StoreIDs)
+		SELECT s.id, s.name,
+			CASE WHEN $2::float8 IS NULL OR $3::float8 IS NULL OR s.latitude IS NULL OR s.longitude IS NULL THEN NULL
+			ELSE 6371 * acos(
+				LEAST(1.0, GREATEST(-1.0,
+					cos(radians($2)) * cos(radians(s.latitude)) *
+					cos(radians(s.longitude) - radians($3)) +
+					sin(radians($2)) * sin(radians(s.latitude))
+				))
+			) END as distance_km,
+			s.region_id, r.name, s.latitude, s.longitude
+		FROM stores s
+		LEFT JOIN regions r ON s.region_id = r.id
+		WHERE s.id = ANY($1) ORDER BY s.name
+	`, params.StoreIDs, params.Latitude, params.Longitude)
 	if err != nil {
 		return nil, err
 	}
@@ -521,7 +873,7 @@ func (db *DB) GetPriceComparison(ctx context.Context, params *models.ComparePara
 
 	for storeRows.Next() {
 		var s models.StoreBasic
-		if err := storeRows.Scan(&s.ID, &s.Name); err != nil {
+		if err := storeRows.Scan(&s.ID, &s.Name, &s.DistanceKm, &s.RegionID, &s.RegionName, &s.Latitude, &s.Longitude); err != nil {
 			return nil, err
 		}
 		result.Stores = append(result.Stores, s)
@@ -536,29 +888,38 @@ func (db *DB) GetPriceComparison(ctx context.Context, params *models.ComparePara
 		priceQuery = `
 			SELECT
 				i.id, i.name, i.brand, i.size, i.unit,
-				sp.store_id, sp.price, sp.verified_count, u.username, sp.updated_at
+				sp.store_id, sp.price, sp.verified_count, u.username, sp.updated_at,
+				sp.user_id, COALESCE(u.hide_contributor_name, false),
+				GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at))
 			FROM items i
 			LEFT JOIN store_prices sp ON i.id = sp.item_id AND sp.store_id = ANY($1)
 				AND (sp.is_shared = true OR sp.user_id = $3)
+				AND sp.flag_count < $4
+				AND ($5::int IS NULL OR GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - make_interval(days => $5))
 			LEFT JOIN users u ON sp.user_id = u.id
-			WHERE i.id = ANY($2)
+			WHERE i.id = ANY($2) AND i.archived_at IS NULL
 			ORDER BY i.name, sp.store_id
 		`
-		args = []interface{}{params.StoreIDs, params.ItemIDs, params.UserID}
+		args = []interface{}{params.StoreIDs, params.ItemIDs, params.UserID, models.PriceFlagHideThreshold, params.MaxAgeDays}
 	} else {
 		// All items that have prices at any of the selected stores
 		priceQuery = `
 			SELECT
 				i.id, i.name, i.brand, i.size, i.unit,
-				sp.store_id, sp.price, sp.verified_count, u.username, sp.updated_at
+				sp.store_id, sp.price, sp.verified_count, u.username, sp.updated_at,
+				sp.user_id, COALESCE(u.hide_contributor_name, false),
+				GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at))
 			FROM items i
 			JOIN store_prices sp ON i.id = sp.item_id
 			LEFT JOIN users u ON sp.user_id = u.id
 			WHERE sp.store_id = ANY($1)
 				AND (sp.is_shared = true OR sp.user_id = $2)
+				AND sp.flag_count < $3
+				AND ($4::int IS NULL OR GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - make_interval(days => $4))
+				AND i.archived_at IS NULL
 			ORDER BY i.name, sp.store_id
 		`
-		args = []interface{}{params.StoreIDs, params.UserID}
+		args = []interface{}{params.StoreIDs, params.UserID, models.PriceFlagHideThreshold, params.MaxAgeDays}
 	}
 
 	rows, err := db.Pool.Query(ctx, priceQuery, args...)
@@ -569,6 +930,7 @@ func (db *DB) GetPriceComparison(ctx context.Context, params *models.ComparePara
 
 	// Build the grid
 	itemMap := make(map[int]*models.PriceComparisonRow)
+	now := time.Now()
 
 	for rows.Next() {
 		var itemID int
@@ -579,12 +941,21 @@ func (db *DB) GetPriceComparison(ctx context.Context, params *models.ComparePara
 		var price *float64
 		var verifiedCount *int
 		var updatedAt *string
+		var submitterID *int
+		var submitterHidden bool
+		var lastTouched *time.Time
 
 		if err := rows.Scan(&itemID, &itemName, &itemBrand, &itemSize, &itemUnit,
-			&storeID, &price, &verifiedCount, &username, &updatedAt); err != nil {
+			&storeID, &price, &verifiedCount, &username, &updatedAt,
+			&submitterID, &submitterHidden, &lastTouched); err != nil {
 			return nil, err
 		}
 
+		if username != nil && params.Visibility.ShouldMask(submitterID, submitterHidden) {
+			anonymous := models.AnonymousContributorName
+			username = &anonymous
+		}
+
 		row, exists := itemMap[itemID]
 		if !exists {
 			row = &models.PriceComparisonRow{
@@ -603,13 +974,29 @@ func (db *DB) GetPriceComparison(ctx context.Context, params *models.ComparePara
 			if verifiedCount != nil {
 				vc = *verifiedCount
 			}
-			row.Prices[*storeID] = models.PriceComparisonCell{
+			cell := models.PriceComparisonCell{
 				Price:         price,
 				VerifiedCount: vc,
 				SubmittedBy:   username,
 				UpdatedAt:     updatedAt,
+				IsOwn:         params.UserID != nil && submitterID != nil && *submitterID == *params.UserID,
+			}
+			if lastTouched != nil {
+				daysOld, stale := models.PriceAge(*lastTouched, now, params.StaleDays)
+				cell.DaysOld = &daysOld
+				cell.IsStale = stale
 			}
 
+			// Own prices are shown in their own cells and kept out of the community best
+			if cell.IsOwn && params.ExcludeOwnPrices {
+				if row.OwnPrices == nil {
+					row.OwnPrices = make(map[int]models.PriceComparisonCell)
+				}
+				row.OwnPrices[*storeID] = cell
+				continue
+			}
+			row.Prices[*storeID] = cell
+
 			// Track best price
 			if row.BestPrice == nil || *price < *row.BestPrice {
 				row.BestPrice = price
@@ -710,24 +1097,232 @@ func (db *DB) CompleteShoppingList(ctx context.Context, listID int, userID int,
 		}
 	}
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	restocked := false
+	if req != nil && req.RestockInventory {
+		if restocked, err = restockListInventory(ctx, tx, listID, userID, req); err != nil {
+			return nil, err
+		}
+	}
+
 	// Mark list as completed
 	list := &models.ShoppingList{}
-	err = db.Pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		UPDATE shopping_lists
-		SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		SET status = 'completed', completed_at = NOW(), updated_at = NOW(),
+		    inventory_restocked_at = CASE WHEN $3 THEN NOW() ELSE inventory_restocked_at END
 		WHERE id = $1 AND user_id = $2
-		RETURNING id, user_id, name, status, target_date, completed_at, created_at, updated_at
-	`, listID, userID).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
+	`, listID, userID, restocked).Scan(
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return list, nil
 }
 
+// restockListInventory adds the checked-off items of a list being completed to the user's
+// inventory, using the request's per-item quantities where given. Lists that restocked on an
+// earlier completion are skipped so reopening a list never double-counts. It reports whether
+// this completion restocked the list.
+func restockListInventory(ctx context.Context, tx pgx.Tx, listID, userID int, req *models.CompleteListRequest) (bool, error) {
+	var restockedAt *time.Time
+	err := tx.QueryRow(ctx, `
+		SELECT inventory_restocked_at FROM shopping_lists WHERE id = $1 FOR UPDATE
+	`, listID).Scan(&restockedAt)
+	if err != nil {
+		return false, err
+	}
+	if restockedAt != nil {
+		return false, nil
+	}
+
+	overrides := make(map[int]float64, len(req.RestockQuantities))
+	for _, q := range req.RestockQuantities {
+		overrides[q.ItemID] = q.Quantity
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT item_id, SUM(quantity)::float8
+		FROM shopping_list_items
+		WHERE list_id = $1 AND COALESCE(is_checked, false)
+		GROUP BY item_id
+	`, listID)
+	if err != nil {
+		return false, err
+	}
+	purchased := make(map[int]float64)
+	for rows.Next() {
+		var itemID int
+		var quantity float64
+		if err := rows.Scan(&itemID, &quantity); err != nil {
+			rows.Close()
+			return false, err
+		}
+		purchased[itemID] = quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	today := time.Now()
+	for itemID, quantity := range purchased {
+		if q, ok := overrides[itemID]; ok {
+			quantity = q
+		}
+		if quantity <= 0 {
+			continue
+		}
+		if _, err := restockInventory(ctx, tx, userID, itemID, quantity, today, req.CreateInventoryItems, models.InventoryAdjustmentList); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ReconcileShoppingList records the prices actually paid on a trip, like the corrected prices of
+// CompleteShoppingList, and reports them against the list estimate (each item's best known price
+// before the trip). Each price updates the user's own price for the store and item, shared when
+// shared is set. Every item must be on the list. The list itself is left as it is.
+func (db *DB) ReconcileShoppingList(ctx context.Context, listID int, userID int, shared bool, req *models.ReconcileListRequest) (*models.ListReconciliation, error) {
+	var ownerID int
+	err := db.Pool.QueryRow(ctx, `SELECT user_id FROM shopping_lists WHERE id = $1`, listID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrListNotFound
+		}
+		return nil, err
+	}
+	if ownerID != userID {
+		return nil, ErrNotListOwner
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Estimates are read before any actual price is recorded
+	type listLine struct {
+		name     string
+		quantity int
+		estimate *float64
+	}
+	lines := make(map[int]*listLine)
+	rows, err := tx.Query(ctx, `
+		SELECT sli.item_id, i.name, sli.quantity, `+bestPriceSQL("sli.item_id", false)+`::float8
+		FROM shopping_list_items sli
+		JOIN items i ON sli.item_id = i.id
+		WHERE sli.list_id = $1
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var itemID int
+		l := &listLine{}
+		if err := rows.Scan(&itemID, &l.name, &l.quantity, &l.estimate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines[itemID] = l
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]int, 0, len(req.Items))
+	for _, entry := range req.Items {
+		itemIDs = append(itemIDs, entry.ItemID)
+	}
+	if err := lockItemsForPriceWrites(ctx, tx, itemIDs); err != nil {
+		return nil, err
+	}
+
+	result := &models.ListReconciliation{ListID: listID, Items: []models.ReconciledListItem{}}
+	reconciled := make(map[int]bool)
+	for _, entry := range req.Items {
+		line, ok := lines[entry.ItemID]
+		if !ok {
+			return nil, ErrListItemNotFound
+		}
+
+		var visible bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1 AND (is_private = false OR created_by = $2))
+		`, entry.StoreID, userID).Scan(&visible)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			return nil, ErrStoreNotFound
+		}
+
+		priceID, err := upsertReceiptPrice(ctx, tx, entry.StoreID, entry.ItemID, userID, entry.ActualPrice, shared, models.ReceiptPriceScopeContributor)
+		if err != nil {
+			return nil, err
+		}
+
+		quantity := line.quantity
+		if entry.Quantity != nil {
+			quantity = *entry.Quantity
+		}
+		item := models.ReconciledListItem{
+			ItemID:         entry.ItemID,
+			ItemName:       line.name,
+			StoreID:        entry.StoreID,
+			Quantity:       quantity,
+			EstimatedPrice: line.estimate,
+			ActualPrice:    entry.ActualPrice,
+			ActualTotal:    roundCents(entry.ActualPrice * float64(quantity)),
+			PriceID:        priceID,
+		}
+		result.ActualTotal += item.ActualTotal
+		if line.estimate != nil {
+			estimated := *line.estimate * float64(quantity)
+			variance := roundCents(item.ActualTotal - estimated)
+			item.Variance = &variance
+			result.EstimatedTotal += estimated
+			result.Variance += variance
+		} else {
+			result.UnestimatedCount++
+		}
+		result.Items = append(result.Items, item)
+		reconciled[entry.ItemID] = true
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	result.EstimatedTotal = roundCents(result.EstimatedTotal)
+	result.ActualTotal = roundCents(result.ActualTotal)
+	result.Variance = roundCents(result.Variance)
+	if result.EstimatedTotal > 0 {
+		pct := math.Round(result.Variance/result.EstimatedTotal*1000) / 10
+		result.VariancePercent = &pct
+	}
+	result.UnreconciledCount = len(lines) - len(reconciled)
+
+	return result, nil
+}
+
 // DuplicateShoppingList creates a copy of an existing list with all its items
 func (db *DB) DuplicateShoppingList(ctx context.Context, listID int, userID int, newName string) (*models.ShoppingListWithItems, error) {
 	// Get the source list with items
@@ -739,11 +1334,11 @@ func (db *DB) DuplicateShoppingList(ctx context.Context, listID int, userID int,
 	// Create the new list
 	newList := &models.ShoppingList{}
 	err = db.Pool.QueryRow(ctx, `
-		INSERT INTO shopping_lists (user_id, name, status, target_date, created_at, updated_at)
-		VALUES ($1, $2, 'active', NULL, NOW(), NOW())
-		RETURNING id, user_id, name, status, target_date, completed_at, created_at, updated_at
-	`, userID, newName).Scan(
-		&newList.ID, &newList.UserID, &newList.Name, &newList.Status, &newList.TargetDate, &newList.CompletedAt, &newList.CreatedAt, &newList.UpdatedAt,
+		INSERT INTO shopping_lists (user_id, name, status, target_date, budget, created_at, updated_at)
+		VALUES ($1, $2, 'active', NULL, $3, NOW(), NOW())
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
+	`, userID, newName, sourceList.Budget).Scan(
+		&newList.ID, &newList.UserID, &newList.Name, &newList.Status, &newList.TargetDate, &newList.Budget, &newList.CompletedAt, &newList.CreatedAt, &newList.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -764,7 +1359,203 @@ func (db *DB) DuplicateShoppingList(ctx context.Context, listID int, userID int,
 	return db.GetShoppingListByID(ctx, newList.ID, userID)
 }
 
-// ReopenShoppingList marks a completed list as active again
+// SetListRecurrence sets how often a list repeats and when its next copy is due. Setting
+// the recurrence to none clears the next occurrence.
+func (db *DB) SetListRecurrence(ctx context.Context, listID, userID int, recurrence string, nextOccurrence *time.Time) (*models.ShoppingListWithItems, error) {
+	if recurrence == models.ListRecurrenceNone {
+		nextOccurrence = nil
+	}
+
+	// Verify ownership
+	if _, err := db.GetShoppingListByID(ctx, listID, userID); err != nil {
+		return nil, err
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE shopping_lists
+		SET recurrence = $2, next_occurrence = $3, updated_at = NOW()
+		WHERE id = $1
+	`, listID, recurrence, nextOccurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetShoppingListByID(ctx, listID, userID)
+}
+
+// ListDueRecurringLists returns completed recurring lists whose next occurrence is on or before day
+func (db *DB) ListDueRecurringLists(ctx context.Context, day time.Time) ([]*models.ShoppingList, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, name, recurrence, next_occurrence
+		FROM shopping_lists
+		WHERE status = 'completed'
+		  AND recurrence <> 'none'
+		  AND next_occurrence <= $1::date
+		ORDER BY next_occurrence, id
+	`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []*models.ShoppingList
+	for rows.Next() {
+		l := &models.ShoppingList{}
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Name, &l.Recurrence, &l.NextOccurrence); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+
+	return lists, rows.Err()
+}
+
+// RegenerateRecurringList makes an active copy of a completed recurring list that is due on or
+// before day and hands it the schedule: the copy targets the due occurrence and recurs from the
+// next occurrence after day, and the source stops recurring. The list row is locked for the
+// whole transaction and locked rows are skipped, so concurrent runs cannot copy a list twice.
+// Returns nil when the list is no longer due or another run holds it.
+func (db *DB) RegenerateRecurringList(ctx context.Context, listID int, day time.Time) (*models.ShoppingList, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	source := &models.ShoppingList{}
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id, name, budget, recurrence, next_occurrence
+		FROM shopping_lists
+		WHERE id = $1
+		  AND status = 'completed'
+		  AND recurrence <> 'none'
+		  AND next_occurrence <= $2::date
+		FOR UPDATE SKIP LOCKED
+	`, listID, day).Scan(&source.ID, &source.UserID, &source.Name, &source.Budget, &source.Recurrence, &source.NextOccurrence)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	occurrence := *source.NextOccurrence
+
+	// Skip occurrences missed while the list stayed open
+	next := models.NextListOccurrence(occurrence, source.Recurrence)
+	for !next.After(day) {
+		next = models.NextListOccurrence(next, source.Recurrence)
+	}
+
+	copied := &models.ShoppingList{}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shopping_lists (user_id, name, status, target_date, budget, recurrence, next_occurrence, created_at, updated_at)
+		VALUES ($1, $2, 'active', $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
+	`, source.UserID, source.Name, occurrence, source.Budget, source.Recurrence, next).Scan(
+		&copied.ID, &copied.UserID, &copied.Name, &copied.Status, &copied.TargetDate, &copied.Budget, &copied.CompletedAt, &copied.CreatedAt, &copied.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO shopping_list_items (list_id, item_id, quantity, created_at)
+		SELECT $2, item_id, quantity, NOW() FROM shopping_list_items WHERE list_id = $1
+	`, source.ID, copied.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE shopping_lists SET recurrence = 'none', next_occurrence = NULL, updated_at = NOW() WHERE id = $1
+	`, source.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	copied.Recurrence = source.Recurrence
+	copied.NextOccurrence = &next
+	return copied, nil
+}
+
+// MergeShoppingLists moves all items of the source list into the target list, summing quantities
+// for items on both lists. The target keeps its own name, budget and checked state.
+// When deleteSource is set the emptied source list is removed.
+func (db *DB) MergeShoppingLists(ctx context.Context, targetID, sourceID, userID int, deleteSource bool) (*models.ShoppingListWithItems, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock both lists and verify ownership
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id FROM shopping_lists WHERE id = ANY($1::int[]) FOR UPDATE
+	`, []int{targetID, sourceID})
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[int]int, 2)
+	for rows.Next() {
+		var id, ownerID int
+		if err := rows.Scan(&id, &ownerID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		owners[id] = ownerID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range []int{targetID, sourceID} {
+		ownerID, ok := owners[id]
+		if !ok {
+			return nil, ErrListNotFound
+		}
+		if ownerID != userID {
+			return nil, ErrNotListOwner
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shopping_list_items (list_id, item_id, quantity, created_at)
+		SELECT $1, item_id, quantity, NOW()
+		FROM shopping_list_items
+		WHERE list_id = $2
+		ON CONFLICT (list_id, item_id) DO UPDATE SET quantity = shopping_list_items.quantity + EXCLUDED.quantity
+	`, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteSource {
+		_, err = tx.Exec(ctx, `DELETE FROM shopping_lists WHERE id = $1`, sourceID)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM shopping_list_items WHERE list_id = $1`, sourceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE shopping_lists SET updated_at = NOW() WHERE id = ANY($1::int[])`, []int{targetID, sourceID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return db.GetShoppingListByID(ctx, targetID, userID)
+}
+
+// ReopenShoppingList marks a completed list as active again. Inventory restocked when the list
+// was completed is left in place and not restocked again on the next completion.
 func (db *DB) ReopenShoppingList(ctx context.Context, listID int, userID int) (*models.ShoppingList, error) {
 	list := &models.ShoppingList{}
 
@@ -772,9 +1563,9 @@ func (db *DB) ReopenShoppingList(ctx context.Context, listID int, userID int) (*
 		UPDATE shopping_lists
 		SET status = 'active', completed_at = NULL, updated_at = NOW()
 		WHERE id = $1 AND user_id = $2
-		RETURNING id, user_id, name, status, target_date, completed_at, created_at, updated_at
+		RETURNING id, user_id, name, status, target_date, budget, completed_at, created_at, updated_at
 	`, listID, userID).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt, &list.CreatedAt, &list.UpdatedAt,
 	)
 
 	if err != nil {
@@ -855,11 +1646,11 @@ func (db *DB) GetShoppingListByShareToken(ctx context.Context, token string) (*m
 	var shareExpiresAt *time.Time
 
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, user_id, name, status, target_date, completed_at, share_token, share_expires_at, share_created_at, created_at, updated_at
+		SELECT id, user_id, name, status, target_date, budget, completed_at, share_token, share_expires_at, share_created_at, created_at, updated_at
 		FROM shopping_lists
 		WHERE share_token = $1
 	`, token).Scan(
-		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.CompletedAt,
+		&list.ID, &list.UserID, &list.Name, &list.Status, &list.TargetDate, &list.Budget, &list.CompletedAt,
 		&list.ShareToken, &shareExpiresAt, &list.ShareCreatedAt, &list.CreatedAt, &list.UpdatedAt,
 	)
 
@@ -923,6 +1714,7 @@ func (db *DB) GetShoppingListByShareToken(ctx context.Context, token string) (*m
 	list.ItemCount = len(list.Items)
 	list.CheckedCount = checkedCount
 	list.EstimatedTotal = estimatedTotal
+	list.OverBudget, list.BudgetOverage = checkBudget(list.Budget, estimatedTotal)
 
 	return list, nil
 }
@@ -1031,3 +1823,131 @@ func (db *DB) GetUserEmailForList(ctx context.Context, listID int) (string, erro
 	}
 	return email, nil
 }
+
+// GetListInflation computes how the cost of a list's basket changed month over month using
+// price history. Each month uses the best recorded price per item; month-over-month changes
+// only compare items priced in both months so gaps in history do not read as inflation.
+func (db *DB) GetListInflation(ctx context.Context, listID, userID, months int) (*models.ListInflation, error) {
+	list, err := db.GetShoppingListByID(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int]int)
+	var itemIDs []int
+	for _, item := range list.Items {
+		if _, seen := quantities[item.ItemID]; !seen {
+			itemIDs = append(itemIDs, item.ItemID)
+		}
+		quantities[item.ItemID] += item.Quantity
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(months - 1), 0)
+
+	result := &models.ListInflation{
+		ListID:              listID,
+		ItemsTotal:          len(itemIDs),
+		Months:              []models.ListInflationMonth{},
+		ItemsWithoutHistory: []int{},
+	}
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+
+	// Best price per item per month from shared prices at public stores, plus the user's own history
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ph.item_id, to_char(date_trunc('month', ph.recorded_at), 'YYYY-MM') as month, MIN(ph.price)
+		FROM price_history ph
+		JOIN stores s ON ph.store_id = s.id
+		JOIN items i ON ph.item_id = i.id
+		LEFT JOIN store_prices sp ON sp.store_id = ph.store_id AND sp.item_id = ph.item_id
+		WHERE ph.item_id = ANY($1)
+		  AND ph.recorded_at >= $3
+		  AND (
+		      ph.user_id = $2
+		      OR (COALESCE(s.is_private, false) = false
+		          AND COALESCE(i.is_private, false) = false
+		          AND COALESCE(sp.is_shared, true) = true)
+		  )
+		GROUP BY ph.item_id, month
+	`, itemIDs, userID, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	best := make(map[string]map[int]float64)
+	hasHistory := make(map[int]bool)
+	for rows.Next() {
+		var itemID int
+		var month string
+		var price float64
+		if err := rows.Scan(&itemID, &month, &price); err != nil {
+			return nil, err
+		}
+		if best[month] == nil {
+			best[month] = make(map[int]float64)
+		}
+		best[month][itemID] = price
+		hasHistory[itemID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, itemID := range itemIDs {
+		if !hasHistory[itemID] {
+			result.ItemsWithoutHistory = append(result.ItemsWithoutHistory, itemID)
+		}
+	}
+
+	index := 100.0
+	var previous map[int]float64
+	for m := start; !m.After(now); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		prices := best[key]
+
+		point := models.ListInflationMonth{Month: key, ItemsPriced: len(prices)}
+		var prevCost, curCost float64
+		for itemID, price := range prices {
+			qty := float64(quantities[itemID])
+			point.BasketCost += price * qty
+			if prevPrice, ok := previous[itemID]; ok {
+				prevCost += prevPrice * qty
+				curCost += price * qty
+			}
+		}
+		point.BasketCost = roundCents(point.BasketCost)
+
+		if prevCost > 0 {
+			change := (curCost - prevCost) / prevCost * 100
+			index *= 1 + change/100
+			rounded := roundCents(change)
+			point.ChangePercent = &rounded
+		}
+		point.Index = roundCents(index)
+		result.Months = append(result.Months, point)
+
+		// Carry prices forward so a month without history compares against the last known price
+		if len(prices) > 0 {
+			merged := make(map[int]float64, len(previous)+len(prices))
+			for itemID, price := range previous {
+				merged[itemID] = price
+			}
+			for itemID, price := range prices {
+				merged[itemID] = price
+			}
+			previous = merged
+		}
+	}
+
+	result.TotalChangePercent = roundCents(index - 100)
+
+	return result, nil
+}
+
+// roundCents rounds a value to two decimal places
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestRegenerateRecurringListOnce(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := testUser(t, db)
+	item := testItem(t, db, nil, nil)
+	list, err := db.CreateShoppingList(ctx, &models.CreateListRequest{Name: testName("list")}, user.ID)
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	if _, err := db.Pool.Exec(ctx, `
+		INSERT INTO shopping_list_items (list_id, item_id, quantity, created_at) VALUES ($1, $2, 3, NOW())
+	`, list.ID, item.ID); err != nil {
+		t.Fatalf("add list item: %v", err)
+	}
+
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	due := day.AddDate(0, 0, -8)
+	if _, err := db.Pool.Exec(ctx, `
+		UPDATE shopping_lists SET status = 'completed', recurrence = 'weekly', next_occurrence = $2 WHERE id = $1
+	`, list.ID, due); err != nil {
+		t.Fatalf("complete list: %v", err)
+	}
+
+	copied, err := db.RegenerateRecurringList(ctx, list.ID, day)
+	if err != nil {
+		t.Fatalf("RegenerateRecurringList: %v", err)
+	}
+	if copied == nil {
+		t.Fatal("RegenerateRecurringList returned nil for a due list")
+	}
+	if want := due.AddDate(0, 0, 14); !copied.NextOccurrence.Equal(want) {
+		t.Errorf("next occurrence = %v, want %v", copied.NextOccurrence, want)
+	}
+
+	again, err := db.RegenerateRecurringList(ctx, list.ID, day)
+	if err != nil {
+		t.Fatalf("second RegenerateRecurringList: %v", err)
+	}
+	if again != nil {
+		t.Errorf("second run copied the list again as %d", again.ID)
+	}
+
+	withItems, err := db.GetShoppingListByID(ctx, copied.ID, user.ID)
+	if err != nil {
+		t.Fatalf("GetShoppingListByID: %v", err)
+	}
+	if len(withItems.Items) != 1 || withItems.Items[0].Quantity != 3 {
+		t.Errorf("copied items = %+v, want one item with quantity 3", withItems.Items)
+	}
+	if withItems.Recurrence != models.ListRecurrenceWeekly {
+		t.Errorf("copy recurrence = %q, want weekly", withItems.Recurrence)
+	}
+}
+
+func itemBestPrice(t *testing.T, db *DB, itemID int) (*float64, *int) {
+	t.Helper()
+	var price *float64
+	var storeID *int
+	if err := db.Pool.QueryRow(context.Background(), `
+		SELECT best_price::float8, best_store_id FROM items WHERE id = $1
+	`, itemID).Scan(&price, &storeID); err != nil {
+		t.Fatalf("read best price: %v", err)
+	}
+	return price, storeID
+}
+
+func TestItemBestPriceTrigger(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	item := testItem(t, db, nil, nil)
+	other := testItem(t, db, nil, nil)
+	cheapStore := testStore(t, db, nil)
+	dearStore := testStore(t, db, nil)
+
+	check := func(step string, itemID int, wantPrice float64, wantStore int) {
+		t.Helper()
+		price, storeID := itemBestPrice(t, db, itemID)
+		if wantStore == 0 {
+			if price != nil || storeID != nil {
+				t.Errorf("%s: best price = %v at %v, want none", step, price, storeID)
+			}
+			return
+		}
+		if price == nil || *price != wantPrice || storeID == nil || *storeID != wantStore {
+			t.Errorf("%s: best price = %v at %v, want %v at %d", step, price, storeID, wantPrice, wantStore)
+		}
+	}
+
+	dear := testPrice(t, db, dearStore.ID, item.ID, 3.49, nil)
+	check("first price", item.ID, 3.49, dearStore.ID)
+
+	cheap := testPrice(t, db, cheapStore.ID, item.ID, 2.99, nil)
+	check("cheaper price", item.ID, 2.99, cheapStore.ID)
+
+	if _, err := db.Pool.Exec(ctx, `UPDATE store_prices SET price = 3.99 WHERE id = $1`, cheap.ID); err != nil {
+		t.Fatalf("raise price: %v", err)
+	}
+	check("cheapest raised", item.ID, 3.49, dearStore.ID)
+
+	if _, err := db.Pool.Exec(ctx, `UPDATE store_prices SET item_id = $2 WHERE id = $1`, dear.ID, other.ID); err != nil {
+		t.Fatalf("move price: %v", err)
+	}
+	check("price moved away", item.ID, 3.99, cheapStore.ID)
+	check("price moved in", other.ID, 3.49, dearStore.ID)
+
+	if err := db.DeletePrice(ctx, cheap.ID); err != nil {
+		t.Fatalf("DeletePrice: %v", err)
+	}
+	check("last price deleted", item.ID, 0, 0)
+}
+
+func TestItemBestPriceCountsOnlyCommunityPrices(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	owner := testUser(t, db)
+	item := testItem(t, db, nil, nil)
+	store := testStore(t, db, nil)
+	privateStore := testStore(t, db, nil)
+	if _, err := db.Pool.Exec(ctx, `UPDATE stores SET is_private = true WHERE id = $1`, privateStore.ID); err != nil {
+		t.Fatalf("make store private: %v", err)
+	}
+
+	testPrice(t, db, store.ID, item.ID, 3.49, nil)
+	flagged := testPrice(t, db, store.ID, item.ID, 2.49, nil)
+	testPrice(t, db, privateStore.ID, item.ID, 0.99, nil)
+	if _, err := db.CreatePrice(ctx, &models.CreatePriceRequest{
+		StoreID: store.ID, ItemID: item.ID, Price: 1.49, IsShared: false,
+	}, &owner.ID); err != nil {
+		t.Fatalf("create private price: %v", err)
+	}
+
+	price, storeID := itemBestPrice(t, db, item.ID)
+	if price == nil || *price != 2.49 || storeID == nil || *storeID != store.ID {
+		t.Errorf("best price = %v at %v, want the shared 2.49 at %d", price, storeID, store.ID)
+	}
+
+	// refresh_item_best_prices hard-codes the threshold; these steps fail if it drifts from the constant
+	setFlags := func(count int) {
+		t.Helper()
+		if _, err := db.Pool.Exec(ctx, `UPDATE store_prices SET flag_count = $2 WHERE id = $1`, flagged.ID, count); err != nil {
+			t.Fatalf("flag price: %v", err)
+		}
+	}
+	setFlags(models.PriceFlagHideThreshold - 1)
+	if price, _ := itemBestPrice(t, db, item.ID); price == nil || *price != 2.49 {
+		t.Errorf("best price one flag below the threshold = %v, want 2.49", price)
+	}
+	setFlags(models.PriceFlagHideThreshold)
+	if price, _ := itemBestPrice(t, db, item.ID); price == nil || *price != 3.49 {
+		t.Errorf("best price at the flag threshold = %v, want 3.49", price)
+	}
+
+	if err := db.DeleteItem(ctx, item.ID); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	if price, storeID := itemBestPrice(t, db, item.ID); price != nil || storeID != nil {
+		t.Errorf("archived item best price = %v at %v, want none", price, storeID)
+	}
+}
+
+func TestItemBestPriceConcurrentWrites(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	item := testItem(t, db, nil, nil)
+	cheapStore := testStore(t, db, nil)
+	dearStore := testStore(t, db, nil)
+
+	first, err := db.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer first.Rollback(ctx)
+	if _, err := first.Exec(ctx, `INSERT INTO store_prices (store_id, item_id, price) VALUES ($1, $2, 1.99)`, cheapStore.ID, item.ID); err != nil {
+		t.Fatalf("insert cheap price: %v", err)
+	}
+
+	// The second write waits on the item lock held by the first and must see its price once it commits
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Pool.Exec(ctx, `INSERT INTO store_prices (store_id, item_id, price) VALUES ($1, $2, 4.99)`, dearStore.ID, item.ID)
+		done <- err
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if err := first.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("insert dear price: %v", err)
+	}
+
+	price, storeID := itemBestPrice(t, db, item.ID)
+	if price == nil || *price != 1.99 || storeID == nil || *storeID != cheapStore.ID {
+		t.Errorf("best price = %v at %v, want 1.99 at %d", price, storeID, cheapStore.ID)
+	}
+}
+
+func TestRefreshItemBestPricesRepairsDrift(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	item := testItem(t, db, nil, nil)
+	store := testStore(t, db, nil)
+	testPrice(t, db, store.ID, item.ID, 2.49, nil)
+
+	if _, err := db.Pool.Exec(ctx, `UPDATE items SET best_price = 9.99, best_store_id = NULL WHERE id = $1`, item.ID); err != nil {
+		t.Fatalf("corrupt best price: %v", err)
+	}
+
+	refreshed, err := db.RefreshItemBestPrices(ctx)
+	if err != nil {
+		t.Fatalf("RefreshItemBestPrices: %v", err)
+	}
+	if refreshed < 1 {
+		t.Errorf("refreshed %d items, want at least the drifted one", refreshed)
+	}
+	price, storeID := itemBestPrice(t, db, item.ID)
+	if price == nil || *price != 2.49 || storeID == nil || *storeID != store.ID {
+		t.Errorf("best price = %v at %v, want 2.49 at %d", price, storeID, store.ID)
+	}
+
+	again, err := db.RefreshItemBestPrices(ctx)
+	if err != nil {
+		t.Fatalf("RefreshItemBestPrices: %v", err)
+	}
+	if again != 0 {
+		t.Errorf("second refresh changed %d items, want 0", again)
+	}
+}
+
+func TestUpdateShoppingListBudget(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := testUser(t, db)
+	budget := 50.0
+	list, err := db.CreateShoppingList(ctx, &models.CreateListRequest{Name: testName("list"), Budget: &budget}, user.ID)
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+
+	// Leaving the budget out keeps it
+	name := testName("renamed")
+	updated, err := db.UpdateShoppingList(ctx, list.ID, user.ID, &models.UpdateListRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateShoppingList: %v", err)
+	}
+	if updated.Budget == nil || *updated.Budget != budget {
+		t.Fatalf("budget after rename = %v, want %v", updated.Budget, budget)
+	}
+
+	updated, err = db.UpdateShoppingList(ctx, list.ID, user.ID, &models.UpdateListRequest{ClearBudget: true})
+	if err != nil {
+		t.Fatalf("UpdateShoppingList: %v", err)
+	}
+	if updated.Budget != nil {
+		t.Fatalf("budget after clear = %v, want nil", *updated.Budget)
+	}
+
+	newBudget := 75.0
+	updated, err = db.UpdateShoppingList(ctx, list.ID, user.ID, &models.UpdateListRequest{Budget: &newBudget})
+	if err != nil {
+		t.Fatalf("UpdateShoppingList: %v", err)
+	}
+	if updated.Budget == nil || *updated.Budget != newBudget {
+		t.Fatalf("budget after set = %v, want %v", updated.Budget, newBudget)
+	}
+}
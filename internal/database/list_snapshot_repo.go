@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrListSnapshotNotFound = errors.New("list snapshot not found")
+
+// maxSnapshotMovers is how many items a snapshot comparison highlights as biggest movers
+const maxSnapshotMovers = 5
+
+// CreateListSnapshot saves each list item's quantity and current best price so the list's
+// cost can later be compared against other snapshots
+func (db *DB) CreateListSnapshot(ctx context.Context, listID, userID int) (*models.ListSnapshot, error) {
+	list, err := db.GetShoppingListByID(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	snapshot := &models.ListSnapshot{ListID: listID, EstimatedTotal: roundCents(list.EstimatedTotal)}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO list_price_snapshots (list_id, user_id, estimated_total, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at
+	`, listID, userID, snapshot.EstimatedTotal).Scan(&snapshot.ID, &snapshot.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range list.Items {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO list_price_snapshot_items (snapshot_id, item_id, item_name, quantity, price, store_name)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (snapshot_id, item_id) DO UPDATE
+			SET quantity = list_price_snapshot_items.quantity + EXCLUDED.quantity
+		`, snapshot.ID, item.ItemID, item.ItemName, item.Quantity, item.BestPrice, item.BestStore)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return db.getListSnapshot(ctx, listID, snapshot.ID)
+}
+
+// ListListSnapshots returns a list's snapshots, newest first, without their items
+func (db *DB) ListListSnapshots(ctx context.Context, listID, userID int) ([]*models.ListSnapshot, error) {
+	if _, err := db.GetShoppingListByID(ctx, listID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.id, s.list_id, s.estimated_total::float8,
+			(SELECT COUNT(*) FROM list_price_snapshot_items si WHERE si.snapshot_id = s.id),
+			s.created_at
+		FROM list_price_snapshots s
+		WHERE s.list_id = $1
+		ORDER BY s.created_at DESC, s.id DESC
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []*models.ListSnapshot{}
+	for rows.Next() {
+		s := &models.ListSnapshot{}
+		if err := rows.Scan(&s.ID, &s.ListID, &s.EstimatedTotal, &s.ItemCount, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// getListSnapshot loads one snapshot of a list with its items
+func (db *DB) getListSnapshot(ctx context.Context, listID, snapshotID int) (*models.ListSnapshot, error) {
+	s := &models.ListSnapshot{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, list_id, estimated_total::float8, created_at
+		FROM list_price_snapshots
+		WHERE id = $1 AND list_id = $2
+	`, snapshotID, listID).Scan(&s.ID, &s.ListID, &s.EstimatedTotal, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrListSnapshotNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT item_id, item_name, quantity, price::float8, store_name
+		FROM list_price_snapshot_items
+		WHERE snapshot_id = $1
+		ORDER BY item_name
+	`, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.ListSnapshotItem
+		if err := rows.Scan(&item.ItemID, &item.ItemName, &item.Quantity, &item.Price, &item.StoreName); err != nil {
+			return nil, err
+		}
+		s.Items = append(s.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.ItemCount = len(s.Items)
+
+	return s, nil
+}
+
+// CompareListSnapshots computes per-item and total cost differences between two snapshots of
+// a list and picks out the items whose line cost moved the most
+func (db *DB) CompareListSnapshots(ctx context.Context, listID, userID, fromID, toID int) (*models.ListSnapshotComparison, error) {
+	if _, err := db.GetShoppingListByID(ctx, listID, userID); err != nil {
+		return nil, err
+	}
+
+	from, err := db.getListSnapshot(ctx, listID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := db.getListSnapshot(ctx, listID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make(map[int]*models.ListSnapshotItemDelta)
+	var order []int
+	for _, item := range from.Items {
+		d := &models.ListSnapshotItemDelta{
+			ItemID: item.ItemID, ItemName: item.ItemName, Status: models.SnapshotItemRemoved,
+			FromQuantity: item.Quantity, FromPrice: item.Price,
+		}
+		if item.Price != nil {
+			d.FromTotal = roundCents(*item.Price * float64(item.Quantity))
+		}
+		deltas[item.ItemID] = d
+		order = append(order, item.ItemID)
+	}
+	for _, item := range to.Items {
+		d, ok := deltas[item.ItemID]
+		if !ok {
+			d = &models.ListSnapshotItemDelta{ItemID: item.ItemID, ItemName: item.ItemName, Status: models.SnapshotItemAdded}
+			deltas[item.ItemID] = d
+			order = append(order, item.ItemID)
+		} else {
+			d.Status = models.SnapshotItemChanged
+		}
+		d.ToQuantity = item.Quantity
+		d.ToPrice = item.Price
+		if item.Price != nil {
+			d.ToTotal = roundCents(*item.Price * float64(item.Quantity))
+		}
+	}
+
+	result := &models.ListSnapshotComparison{
+		ListID:        listID,
+		From:          *from,
+		To:            *to,
+		TotalDelta:    roundCents(to.EstimatedTotal - from.EstimatedTotal),
+		Items:         make([]models.ListSnapshotItemDelta, 0, len(order)),
+		BiggestMovers: []models.ListSnapshotItemDelta{},
+	}
+	if from.EstimatedTotal > 0 {
+		pct := math.Round(result.TotalDelta/from.EstimatedTotal*1000) / 10
+		result.TotalChangePercent = &pct
+	}
+
+	for _, id := range order {
+		d := deltas[id]
+		d.Delta = roundCents(d.ToTotal - d.FromTotal)
+		if d.FromPrice != nil && d.ToPrice != nil && *d.FromPrice > 0 {
+			pct := math.Round((*d.ToPrice-*d.FromPrice) / *d.FromPrice * 1000) / 10
+			d.ChangePercent = &pct
+		}
+		if d.Status == models.SnapshotItemChanged && d.Delta == 0 {
+			d.Status = models.SnapshotItemUnchanged
+		}
+		result.Items = append(result.Items, *d)
+	}
+
+	for _, d := range result.Items {
+		if d.Delta != 0 {
+			result.BiggestMovers = append(result.BiggestMovers, d)
+		}
+	}
+	sort.SliceStable(result.BiggestMovers, func(i, j int) bool {
+		return math.Abs(result.BiggestMovers[i].Delta) > math.Abs(result.BiggestMovers[j].Delta)
+	})
+	if len(result.BiggestMovers) > maxSnapshotMovers {
+		result.BiggestMovers = result.BiggestMovers[:maxSnapshotMovers]
+	}
+
+	// The full item breakdowns are already in Items
+	result.From.Items = nil
+	result.To.Items = nil
+
+	return result, nil
+}
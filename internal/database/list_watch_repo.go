@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrListWatchNotFound = errors.New("list is not being watched")
+
+// WatchList subscribes a list to automatic replanning, recording the current plan cost
+func (db *DB) WatchList(ctx context.Context, listID, userID int, planCost *float64) (*models.ListWatch, error) {
+	watch := &models.ListWatch{}
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO list_watches (list_id, user_id, last_plan_cost, last_checked_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (list_id) DO UPDATE
+		SET last_plan_cost = EXCLUDED.last_plan_cost, last_checked_at = NOW()
+		RETURNING list_id, user_id, last_plan_cost, last_checked_at, last_notified_at, created_at
+	`, listID, userID, planCost).Scan(
+		&watch.ListID, &watch.UserID, &watch.LastPlanCost, &watch.LastCheckedAt, &watch.LastNotifiedAt, &watch.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+// UnwatchList removes a list's auto-replan subscription
+func (db *DB) UnwatchList(ctx context.Context, listID, userID int) error {
+	result, err := db.Pool.Exec(ctx, `
+		DELETE FROM list_watches WHERE list_id = $1 AND user_id = $2
+	`, listID, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrListWatchNotFound
+	}
+
+	return nil
+}
+
+// GetListWatch returns the watch for a list, if any
+func (db *DB) GetListWatch(ctx context.Context, listID int) (*models.ListWatch, error) {
+	watch := &models.ListWatch{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT list_id, user_id, last_plan_cost, last_checked_at, last_notified_at, created_at
+		FROM list_watches WHERE list_id = $1
+	`, listID).Scan(
+		&watch.ListID, &watch.UserID, &watch.LastPlanCost, &watch.LastCheckedAt, &watch.LastNotifiedAt, &watch.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrListWatchNotFound
+		}
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+// GetListWatchesWithPriceChanges returns watches on active lists where a price for any
+// list item has changed since the watch was last checked
+func (db *DB) GetListWatchesWithPriceChanges(ctx context.Context) ([]*models.ListWatch, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT w.list_id, w.user_id, w.last_plan_cost, w.last_checked_at, w.last_notified_at, w.created_at
+		FROM list_watches w
+		JOIN shopping_lists sl ON w.list_id = sl.id
+		WHERE sl.status = 'active'
+		  AND EXISTS (
+			SELECT 1
+			FROM shopping_list_items sli
+			JOIN store_prices sp ON sp.item_id = sli.item_id
+			WHERE sli.list_id = w.list_id AND sp.updated_at > w.last_checked_at
+		  )
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watches []*models.ListWatch
+	for rows.Next() {
+		watch := &models.ListWatch{}
+		if err := rows.Scan(
+			&watch.ListID, &watch.UserID, &watch.LastPlanCost, &watch.LastCheckedAt, &watch.LastNotifiedAt, &watch.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		watches = append(watches, watch)
+	}
+
+	return watches, rows.Err()
+}
+
+// UpdateListWatchCost records the latest plan cost and marks the watch as checked
+func (db *DB) UpdateListWatchCost(ctx context.Context, listID int, planCost *float64, notified bool) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE list_watches
+		SET last_plan_cost = $2,
+		    last_checked_at = NOW(),
+		    last_notified_at = CASE WHEN $3 THEN NOW() ELSE last_notified_at END
+		WHERE list_id = $1
+	`, listID, planCost, notified)
+	return err
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// userDigestOptInColumn selects the region digest choice of the users row being read or
+// returned, for queries that do not alias the users table
+const userDigestOptInColumn = `COALESCE((SELECT np.region_digest_email FROM notification_preferences np WHERE np.user_id = users.id), false)`
+
+// notificationPreferenceColumns selects a user's preferences joined as np on users u, falling
+// back to the defaults for users without a saved row
+const notificationPreferenceColumns = `
+	COALESCE(np.region_digest_email, false),
+	COALESCE(np.price_alert_email, true),
+	COALESCE(np.list_watch_email, true),
+	COALESCE(np.inventory_alerts, true),
+	COALESCE(np.inventory_email, true),
+	np.updated_at`
+
+// GetNotificationPreferences returns a user's notification preferences with their region
+// digest subscriptions. Users without a saved row get the defaults.
+func (db *DB) GetNotificationPreferences(ctx context.Context, userID int) (*models.NotificationPreferences, error) {
+	prefs := &models.NotificationPreferences{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = $1
+	`, userID).Scan(&prefs.RegionDigestEmail, &prefs.PriceAlertEmail, &prefs.ListWatchEmail,
+		&prefs.InventoryAlerts, &prefs.InventoryEmail, &prefs.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	prefs.RegionSubscriptions, err = db.ListRegionSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// ListNotificationPreferences loads the preferences of many users in one query for background
+// jobs, keyed by user id. Region subscriptions are not included and users that no longer
+// exist are left out.
+func (db *DB) ListNotificationPreferences(ctx context.Context, userIDs []int) (map[int]*models.NotificationPreferences, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT u.id, `+notificationPreferenceColumns+`
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = ANY($1)
+	`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := make(map[int]*models.NotificationPreferences, len(userIDs))
+	for rows.Next() {
+		var userID int
+		p := &models.NotificationPreferences{}
+		if err := rows.Scan(&userID, &p.RegionDigestEmail, &p.PriceAlertEmail, &p.ListWatchEmail,
+			&p.InventoryAlerts, &p.InventoryEmail, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prefs[userID] = p
+	}
+
+	return prefs, rows.Err()
+}
+
+// UpdateNotificationPreferences saves the preferences set in req and pauses or resumes the
+// listed region subscriptions, all in one transaction
+func (db *DB) UpdateNotificationPreferences(ctx context.Context, userID int, req *models.UpdateNotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO notification_preferences (user_id, region_digest_email, price_alert_email, list_watch_email, inventory_alerts, inventory_email)
+		SELECT u.id, COALESCE($2, false), COALESCE($3, true), COALESCE($4, true), COALESCE($5, true), COALESCE($6, true)
+		FROM users u WHERE u.id = $1
+		ON CONFLICT (user_id) DO UPDATE SET
+		    region_digest_email = COALESCE($2, notification_preferences.region_digest_email),
+		    price_alert_email = COALESCE($3, notification_preferences.price_alert_email),
+		    list_watch_email = COALESCE($4, notification_preferences.list_watch_email),
+		    inventory_alerts = COALESCE($5, notification_preferences.inventory_alerts),
+		    inventory_email = COALESCE($6, notification_preferences.inventory_email),
+		    updated_at = NOW()
+	`, userID, req.RegionDigestEmail, req.PriceAlertEmail, req.ListWatchEmail, req.InventoryAlerts, req.InventoryEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, toggle := range req.RegionSubscriptions {
+		result, err := tx.Exec(ctx, `
+			UPDATE region_subscriptions SET is_active = $3, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`, toggle.ID, userID, toggle.IsActive)
+		if err != nil {
+			return nil, err
+		}
+		if result.RowsAffected() == 0 {
+			return nil, ErrRegionSubscriptionNotFound
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return db.GetNotificationPreferences(ctx, userID)
+}
+
+// setRegionDigestPreference records a region digest choice made outside the notification
+// preferences: at registration, in the profile, or by subscribing to a region
+func (db *DB) setRegionDigestPreference(ctx context.Context, userID int, optIn bool) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO notification_preferences (user_id, region_digest_email)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET region_digest_email = $2, updated_at = NOW()
+	`, userID, optIn)
+	return err
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestDigestOptInStoredInNotificationPreferences(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	optIn := true
+	name := testName("user")
+	user, err := db.CreateUser(ctx, name+"@example.com", "x", &name, nil, &models.RegisterRequest{DigestOptIn: &optIn})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if !user.DigestOptIn {
+		t.Fatal("CreateUser: digest_opt_in = false, want true")
+	}
+
+	prefs, err := db.GetNotificationPreferences(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetNotificationPreferences: %v", err)
+	}
+	if !prefs.RegionDigestEmail {
+		t.Fatal("region_digest_email = false after registering opted in")
+	}
+
+	optOut := false
+	updated, err := db.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{DigestOptIn: &optOut})
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if updated.DigestOptIn {
+		t.Fatal("UpdateUser: digest_opt_in = true, want false")
+	}
+
+	// The profile reflects a change made through the notification preferences
+	if _, err := db.UpdateNotificationPreferences(ctx, user.ID, &models.UpdateNotificationPreferencesRequest{RegionDigestEmail: &optIn}); err != nil {
+		t.Fatalf("UpdateNotificationPreferences: %v", err)
+	}
+	fetched, err := db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if !fetched.DigestOptIn {
+		t.Fatal("GetUserByID: digest_opt_in = false after opting in via preferences")
+	}
+	byEmail, err := db.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if !byEmail.DigestOptIn {
+		t.Fatal("GetUserByEmail: digest_opt_in = false after opting in via preferences")
+	}
+}
+
+func TestListNotificationPreferences(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	muted := testUser(t, db)
+	defaults := testUser(t, db)
+
+	off := false
+	if _, err := db.UpdateNotificationPreferences(ctx, muted.ID, &models.UpdateNotificationPreferencesRequest{
+		ListWatchEmail:  &off,
+		InventoryAlerts: &off,
+	}); err != nil {
+		t.Fatalf("UpdateNotificationPreferences: %v", err)
+	}
+	// Users registered before preferences existed have no row and get the defaults
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, defaults.ID); err != nil {
+		t.Fatalf("delete preferences: %v", err)
+	}
+
+	prefs, err := db.ListNotificationPreferences(ctx, []int{muted.ID, defaults.ID, -1})
+	if err != nil {
+		t.Fatalf("ListNotificationPreferences: %v", err)
+	}
+	if len(prefs) != 2 {
+		t.Fatalf("got preferences for %d users, want 2", len(prefs))
+	}
+
+	m := prefs[muted.ID]
+	if m.ListWatchEmail || m.InventoryAlerts || !m.PriceAlertEmail || !m.InventoryEmail {
+		t.Errorf("muted user preferences = %+v", m)
+	}
+	d := prefs[defaults.ID]
+	if d.RegionDigestEmail || !d.ListWatchEmail || !d.InventoryAlerts || !d.PriceAlertEmail || !d.InventoryEmail {
+		t.Errorf("default preferences = %+v", d)
+	}
+}
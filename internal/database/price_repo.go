@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -12,7 +14,8 @@ import (
 )
 
 var (
-	ErrPriceNotFound = errors.New("price not found")
+	ErrPriceNotFound  = errors.New("price not found")
+	ErrVerifyOwnPrice = errors.New("cannot verify your own price")
 )
 
 // ListPrices returns a paginated list of prices with optional filtering
@@ -83,6 +86,19 @@ func (db *DB) ListPrices(ctx context.Context, params *models.PriceListParams) ([
 		argIndex++
 	}
 
+	if params.SubmitterID != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("sp.user_id = $%d", argIndex))
+		args = append(args, *params.SubmitterID)
+		argIndex++
+	}
+
+	if params.MaxAgeDays != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf(
+			"GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - make_interval(days => $%d)", argIndex))
+		args = append(args, *params.MaxAgeDays)
+		argIndex++
+	}
+
 	whereClause := ""
 	if len(whereClauses) > 0 {
 		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
@@ -110,14 +126,15 @@ func (db *DB) ListPrices(ctx context.Context, params *models.PriceListParams) ([
 			i.name as item_name, i.brand as item_brand,
 			s.name as store_name, s.street_address, s.city, s.state, s.zip_code,
 			s.region_id, r.name as region_name,
-			u.username as user_name, u.email as user_email
+			u.username as user_name, u.email as user_email,
+			COALESCE(u.hide_contributor_name, false) as contributor_hidden
 		FROM store_prices sp
 		JOIN items i ON sp.item_id = i.id
 		JOIN stores s ON sp.store_id = s.id
 		LEFT JOIN regions r ON s.region_id = r.id
 		LEFT JOIN users u ON sp.user_id = u.id
 		%s
-		ORDER BY sp.updated_at DESC
+		ORDER BY sp.updated_at DESC, sp.id DESC
 		LIMIT $%d OFFSET $%d
 	`, whereClause, argIndex, argIndex+1)
 
@@ -138,7 +155,7 @@ func (db *DB) ListPrices(ctx context.Context, params *models.PriceListParams) ([
 			&p.ItemName, &p.ItemBrand,
 			&p.StoreName, &p.StoreAddress, &p.StoreCity, &p.StoreState, &p.StoreZipCode,
 			&p.RegionID, &p.RegionName,
-			&p.UserName, &p.UserEmail,
+			&p.UserName, &p.UserEmail, &p.ContributorHidden,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -160,7 +177,9 @@ func (db *DB) GetPriceByID(ctx context.Context, id int) (*models.StorePriceWithD
 			i.name as item_name, i.brand as item_brand,
 			s.name as store_name, s.street_address, s.city, s.state, s.zip_code,
 			s.region_id, r.name as region_name,
-			u.username as user_name, u.email as user_email
+			u.username as user_name, u.email as user_email,
+			COALESCE(u.hide_contributor_name, false) as contributor_hidden,
+			sp.flag_count
 		FROM store_prices sp
 		JOIN items i ON sp.item_id = i.id
 		JOIN stores s ON sp.store_id = s.id
@@ -173,7 +192,8 @@ func (db *DB) GetPriceByID(ctx context.Context, id int) (*models.StorePriceWithD
 		&p.ItemName, &p.ItemBrand,
 		&p.StoreName, &p.StoreAddress, &p.StoreCity, &p.StoreState, &p.StoreZipCode,
 		&p.RegionID, &p.RegionName,
-		&p.UserName, &p.UserEmail,
+		&p.UserName, &p.UserEmail, &p.ContributorHidden,
+		&p.FlagCount,
 	)
 
 	if err != nil {
@@ -191,10 +211,10 @@ func (db *DB) CreatePrice(ctx context.Context, req *models.CreatePriceRequest, u
 	price := &models.StorePrice{}
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, share_on_verify, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
 		RETURNING id, store_id, item_id, price, user_id, is_shared, verified_count, last_verified, created_at, updated_at
-	`, req.StoreID, req.ItemID, req.Price, userID, req.IsShared).Scan(
+	`, req.StoreID, req.ItemID, req.Price, userID, req.IsShared, req.ShareOnVerify).Scan(
 		&price.ID, &price.StoreID, &price.ItemID, &price.Price, &price.UserID, &price.IsShared,
 		&price.VerifiedCount, &price.LastVerified, &price.CreatedAt, &price.UpdatedAt,
 	)
@@ -206,6 +226,20 @@ func (db *DB) CreatePrice(ctx context.Context, req *models.CreatePriceRequest, u
 	return price, nil
 }
 
+// ShareHeldPrices shares the prices a user submitted as shared while their email was unverified.
+// Returns the number of prices made public.
+func (db *DB) ShareHeldPrices(ctx context.Context, userID int) (int64, error) {
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE store_prices
+		SET is_shared = true, share_on_verify = false, updated_at = NOW()
+		WHERE user_id = $1 AND share_on_verify = true
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 // UpdatePrice updates an existing price
 func (db *DB) UpdatePrice(ctx context.Context, id int, req *models.UpdatePriceRequest) (*models.StorePrice, error) {
 	price := &models.StorePrice{}
@@ -245,33 +279,191 @@ func (db *DB) DeletePrice(ctx context.Context, id int) error {
 	return nil
 }
 
-// VerifyPrice adds a verification for a price
-func (db *DB) VerifyPrice(ctx context.Context, priceID int, userID int, isAccurate bool) error {
+// lockItemsForPriceWrites locks the items a transaction is about to write prices for, in id
+// order. The best price triggers lock every item a statement touches, so a transaction writing
+// several items in separate statements takes all of those locks up front instead of one at a
+// time in whatever order it processes them, which could deadlock with another such writer.
+func lockItemsForPriceWrites(ctx context.Context, tx pgx.Tx, itemIDs []int) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `SELECT 1 FROM items WHERE id = ANY($1) ORDER BY id FOR UPDATE`, itemIDs)
+	return err
+}
+
+// lockPriceItem locks the item of a price before the price row itself, in the same order as
+// lockItemsForPriceWrites. Returns ErrPriceNotFound when the price does not exist.
+func lockPriceItem(ctx context.Context, tx pgx.Tx, priceID int) error {
+	var itemID int
+	err := tx.QueryRow(ctx, `
+		SELECT i.id FROM items i JOIN store_prices sp ON sp.item_id = i.id WHERE sp.id = $1 FOR UPDATE OF i
+	`, priceID).Scan(&itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPriceNotFound
+	}
+	return err
+}
+
+// VerifyPrice adds a verification for a price. The first accurate verification by a user
+// rewards them with rewardPoints and the price's submitter with submitterPoints. Submitters
+// cannot verify their own prices.
+func (db *DB) VerifyPrice(ctx context.Context, priceID int, userID int, isAccurate bool, rewardPoints, submitterPoints int) (*models.PriceVerificationResult, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockPriceItem(ctx, tx, priceID); err != nil {
+		return nil, err
+	}
+
+	var submitterID *int
+	err = tx.QueryRow(ctx, `SELECT user_id FROM store_prices WHERE id = $1`, priceID).Scan(&submitterID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPriceNotFound
+		}
+		return nil, err
+	}
+	if submitterID != nil && *submitterID == userID {
+		return nil, ErrVerifyOwnPrice
+	}
+
+	// Look up any earlier verification so toggling does not count as a new one
+	result := &models.PriceVerificationResult{}
+	var wasAccurate, rewarded bool
+	err = tx.QueryRow(ctx, `
+		SELECT is_accurate, rewarded_at IS NOT NULL
+		FROM price_verifications
+		WHERE price_id = $1 AND user_id = $2
+		FOR UPDATE
+	`, priceID, userID).Scan(&wasAccurate, &rewarded)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		result.IsNew = true
+	} else {
+		result.Changed = wasAccurate != isAccurate
+	}
+
 	// Insert verification
-	_, err := db.Pool.Exec(ctx, `
+	var verificationID int
+	err = tx.QueryRow(ctx, `
 		INSERT INTO price_verifications (price_id, user_id, is_accurate, created_at)
 		VALUES ($1, $2, $3, NOW())
-		ON CONFLICT (price_id, user_id) DO UPDATE SET is_accurate = $3, created_at = NOW()
-	`, priceID, userID, isAccurate)
+		ON CONFLICT (price_id, user_id) DO UPDATE SET is_accurate = $3, flag_reason = NULL, reason = NULL, created_at = NOW()
+		RETURNING id
+	`, priceID, userID, isAccurate).Scan(&verificationID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Reputation is only ever awarded once per user and price
+	if isAccurate && !rewarded {
+		_, err = tx.Exec(ctx, `
+			UPDATE price_verifications SET rewarded_at = NOW() WHERE price_id = $1 AND user_id = $2
+		`, priceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := awardReputation(ctx, tx, userID, rewardPoints, models.ReputationReasonVerification, &priceID); err != nil {
+			return nil, err
+		}
+		if submitterID != nil {
+			if _, err := awardReputation(ctx, tx, *submitterID, submitterPoints, models.ReputationReasonPriceVerified, &verificationID); err != nil {
+				return nil, err
+			}
+		}
+		result.Rewarded = true
 	}
 
 	// Update price verified count
-	_, err = db.Pool.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 		UPDATE store_prices
 		SET verified_count = (SELECT COUNT(*) FROM price_verifications WHERE price_id = $1 AND is_accurate = true),
+		    flag_count = `+priceFlagCountSQL+`,
 		    last_verified = NOW(),
 		    updated_at = NOW()
 		WHERE id = $1
 	`, priceID)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// priceFlagCountSQL counts a price's ($1) inaccurate verifications since its last accurate one
+const priceFlagCountSQL = `(
+			SELECT COUNT(*) FROM price_verifications pv
+			WHERE pv.price_id = $1 AND pv.is_accurate = false
+			  AND pv.created_at > COALESCE((
+				SELECT MAX(a.created_at) FROM price_verifications a
+				WHERE a.price_id = $1 AND a.is_accurate = true
+			  ), '-infinity'::timestamp)
+		)`
+
+// FlagPrice records a user's report that a price is wrong. It replaces any earlier verification
+// by the same user. Enough flags since the last accurate verification hide the price from
+// comparisons and shopping plans.
+func (db *DB) FlagPrice(ctx context.Context, priceID, userID int, req *models.FlagPriceRequest) (*models.PriceFlagResult, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockPriceItem(ctx, tx, priceID); err != nil {
+		return nil, err
+	}
+
+	result := &models.PriceFlagResult{}
+	err = tx.QueryRow(ctx, `
+		SELECT NOT EXISTS(SELECT 1 FROM price_verifications WHERE price_id = $1 AND user_id = $2)
+	`, priceID, userID).Scan(&result.IsNew)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO price_verifications (price_id, user_id, is_accurate, flag_reason, reason, created_at)
+		VALUES ($1, $2, false, $3, $4, NOW())
+		ON CONFLICT (price_id, user_id) DO UPDATE
+		SET is_accurate = false, flag_reason = $3, reason = $4, created_at = NOW()
+	`, priceID, userID, req.FlagReason, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE store_prices
+		SET verified_count = (SELECT COUNT(*) FROM price_verifications WHERE price_id = $1 AND is_accurate = true),
+		    flag_count = `+priceFlagCountSQL+`
+		WHERE id = $1
+		RETURNING flag_count
+	`, priceID).Scan(&result.FlagCount)
+	if err != nil {
+		return nil, err
+	}
+	result.Hidden = result.FlagCount >= models.PriceFlagHideThreshold
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-// GetPriceStats returns aggregate statistics for prices
-func (db *DB) GetPriceStats(ctx context.Context) (*models.PriceStats, error) {
-	var totalPrices, todayCount, weekCount, verifiedCount, flaggedCount int
+// GetPriceStats returns aggregate statistics for prices. Under a verified-only filter it also
+// counts the prices verified often enough to feed public statistics.
+func (db *DB) GetPriceStats(ctx context.Context, filter models.PublicStatsFilter) (*models.PriceStats, error) {
+	var totalPrices, todayCount, weekCount, verifiedCount, flaggedCount, eligibleCount int
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT
@@ -279,47 +471,82 @@ func (db *DB) GetPriceStats(ctx context.Context) (*models.PriceStats, error) {
 			COUNT(*) FILTER (WHERE created_at >= CURRENT_DATE) as today_count,
 			COUNT(*) FILTER (WHERE created_at >= CURRENT_DATE - INTERVAL '7 days') as week_count,
 			COUNT(*) FILTER (WHERE verified_count > 0) as verified_count,
-			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM price_verifications pv WHERE pv.price_id = store_prices.id AND pv.is_accurate = false)) as flagged_count
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM price_verifications pv WHERE pv.price_id = store_prices.id AND pv.is_accurate = false)) as flagged_count,
+			COUNT(*) FILTER (WHERE verified_count >= $1) as eligible_count
 		FROM store_prices
-	`).Scan(&totalPrices, &todayCount, &weekCount, &verifiedCount, &flaggedCount)
+	`, filter.Threshold()).Scan(&totalPrices, &todayCount, &weekCount, &verifiedCount, &flaggedCount, &eligibleCount)
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.PriceStats{
-		TotalPrices:   totalPrices,
-		TodayCount:    todayCount,
-		WeekCount:     weekCount,
-		VerifiedCount: verifiedCount,
-		FlaggedCount:  flaggedCount,
+		TotalPrices:        totalPrices,
+		TodayCount:         todayCount,
+		WeekCount:          weekCount,
+		VerifiedCount:      verifiedCount,
+		FlaggedCount:       flaggedCount,
+		StatsVerifiedOnly:  filter.VerifiedOnly,
+		StatsEligibleCount: eligibleCount,
 	}, nil
 }
 
-// GetPricesByStore returns all prices for a store
-func (db *DB) GetPricesByStore(ctx context.Context, storeID int) ([]*models.StorePriceWithDetails, error) {
-	rows, err := db.Pool.Query(ctx, `
+// GetPricesByStore returns a page of a store's prices ordered by item name, with the total count.
+// A zero limit returns every matching price.
+func (db *DB) GetPricesByStore(ctx context.Context, storeID int, params *models.ScopedPriceParams) ([]*models.StorePriceWithDetails, int, error) {
+	return db.listScopedPrices(ctx, "sp.store_id", storeID, "i.name ASC, sp.id ASC", params)
+}
+
+// GetPricesByItem returns a page of an item's prices ordered from cheapest, with the total count.
+// A zero limit returns every matching price.
+func (db *DB) GetPricesByItem(ctx context.Context, itemID int, params *models.ScopedPriceParams) ([]*models.StorePriceWithDetails, int, error) {
+	return db.listScopedPrices(ctx, "sp.item_id", itemID, "sp.price ASC, sp.id ASC", params)
+}
+
+// listScopedPrices lists the prices whose scope column (store or item) equals id
+func (db *DB) listScopedPrices(ctx context.Context, column string, id int, orderBy string, params *models.ScopedPriceParams) ([]*models.StorePriceWithDetails, int, error) {
+	if params == nil {
+		params = &models.ScopedPriceParams{}
+	}
+
+	where := fmt.Sprintf("%s = $1 AND ($2::boolean = false OR sp.is_shared = true) AND sp.verified_count >= $3", column)
+	args := []interface{}{id, params.SharedOnly, params.MinVerified}
+
+	var total int
+	err := db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM store_prices sp WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var limit *int
+	if params.Limit > 0 {
+		limit = &params.Limit
+	}
+
+	rows, err := db.Pool.Query(ctx, fmt.Sprintf(`
 		SELECT
 			sp.id, sp.store_id, sp.item_id, sp.price, sp.user_id, sp.is_shared,
 			sp.verified_count, sp.last_verified, sp.created_at, sp.updated_at,
 			i.name as item_name, i.brand as item_brand,
 			s.name as store_name, s.street_address, s.city, s.state, s.zip_code,
 			s.region_id, r.name as region_name,
-			u.username as user_name, u.email as user_email
+			u.username as user_name, u.email as user_email,
+			COALESCE(u.hide_contributor_name, false) as contributor_hidden
 		FROM store_prices sp
 		JOIN items i ON sp.item_id = i.id
 		JOIN stores s ON sp.store_id = s.id
 		LEFT JOIN regions r ON s.region_id = r.id
 		LEFT JOIN users u ON sp.user_id = u.id
-		WHERE sp.store_id = $1
-		ORDER BY i.name ASC
-	`, storeID)
+		WHERE %s
+		ORDER BY %s
+		LIMIT $4 OFFSET $5
+	`, where, orderBy), append(args, limit, params.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var prices []*models.StorePriceWithDetails
+	prices := []*models.StorePriceWithDetails{}
 	for rows.Next() {
 		p := &models.StorePriceWithDetails{}
 		err := rows.Scan(
@@ -328,58 +555,238 @@ func (db *DB) GetPricesByStore(ctx context.Context, storeID int) ([]*models.Stor
 			&p.ItemName, &p.ItemBrand,
 			&p.StoreName, &p.StoreAddress, &p.StoreCity, &p.StoreState, &p.StoreZipCode,
 			&p.RegionID, &p.RegionName,
-			&p.UserName, &p.UserEmail,
+			&p.UserName, &p.UserEmail, &p.ContributorHidden,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		prices = append(prices, p)
 	}
 
-	return prices, nil
+	return prices, total, rows.Err()
 }
 
-// GetPricesByItem returns all prices for an item
-func (db *DB) GetPricesByItem(ctx context.Context, itemID int) ([]*models.StorePriceWithDetails, error) {
-	rows, err := db.Pool.Query(ctx, `
-		SELECT
-			sp.id, sp.store_id, sp.item_id, sp.price, sp.user_id, sp.is_shared,
-			sp.verified_count, sp.last_verified, sp.created_at, sp.updated_at,
-			i.name as item_name, i.brand as item_brand,
-			s.name as store_name, s.street_address, s.city, s.state, s.zip_code,
-			s.region_id, r.name as region_name,
-			u.username as user_name, u.email as user_email
+// BulkAdjustStorePrices applies a percentage or flat change to a store's prices matching the
+// request filters, in one transaction, recording each change in price history. Only shared
+// prices and the operator's own prices are touched. Changes producing a non-positive price,
+// exceeding bounds.MaxChangePercent or straying from the item's median price at other stores
+// by more than bounds.OutlierFactor are skipped. A dry run reports the changes and rolls back.
+func (db *DB) BulkAdjustStorePrices(ctx context.Context, storeID, userID int, req *models.BulkPriceAdjustRequest, bounds models.BulkPriceBounds) (*models.BulkPriceAdjustResult, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	itemIDs := req.ItemIDs
+	if itemIDs == nil {
+		itemIDs = []int{}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT sp.id, sp.item_id, i.name, sp.price,
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY o.price)
+			 FROM store_prices o
+			 WHERE o.item_id = sp.item_id AND o.store_id <> sp.store_id AND o.is_shared = true) as median_elsewhere
 		FROM store_prices sp
 		JOIN items i ON sp.item_id = i.id
-		JOIN stores s ON sp.store_id = s.id
-		LEFT JOIN regions r ON s.region_id = r.id
-		LEFT JOIN users u ON sp.user_id = u.id
-		WHERE sp.item_id = $1
-		ORDER BY sp.price ASC
-	`, itemID)
+		WHERE sp.store_id = $1
+		  AND (sp.is_shared = true OR sp.user_id = $2)
+		  AND (cardinality($3::int[]) = 0 OR sp.item_id = ANY($3::int[]))
+		  AND ($4::text IS NULL OR EXISTS (
+		      SELECT 1 FROM item_tags it JOIN tags t ON it.tag_id = t.id
+		      WHERE it.item_id = sp.item_id AND t.slug = $4))
+		  AND ($5::text IS NULL OR lower(i.brand) = lower($5))
+		ORDER BY sp.item_id, sp.id
+		FOR UPDATE OF i, sp
+	`, storeID, userID, itemIDs, req.Tag, req.Brand)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkPriceAdjustResult{
+		StoreID: storeID,
+		DryRun:  req.DryRun,
+		Changes: []*models.BulkPriceChange{},
+	}
+	medians := make(map[int]*float64)
+	for rows.Next() {
+		change := &models.BulkPriceChange{}
+		var median *float64
+		if err := rows.Scan(&change.PriceID, &change.ItemID, &change.ItemName, &change.OldPrice, &median); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		medians[change.PriceID] = median
+		result.Changes = append(result.Changes, change)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Matched = len(result.Changes)
+
+	// Rows were locked item by item in id order, matching lockItemsForPriceWrites; report by name
+	sort.SliceStable(result.Changes, func(a, b int) bool {
+		return result.Changes[a].ItemName < result.Changes[b].ItemName
+	})
+
+	for _, change := range result.Changes {
+		newPrice := change.OldPrice
+		if req.Percent != nil {
+			newPrice *= 1 + *req.Percent/100
+		} else if req.Amount != nil {
+			newPrice += *req.Amount
+		}
+		change.NewPrice = math.Round(newPrice*100) / 100
+
+		median := medians[change.PriceID]
+		switch {
+		case change.NewPrice <= 0:
+			change.Skipped = "resulting price is not positive"
+		case bounds.MaxChangePercent > 0 && math.Abs(change.NewPrice-change.OldPrice)/change.OldPrice*100 > bounds.MaxChangePercent:
+			change.Skipped = fmt.Sprintf("change exceeds %.0f%%", bounds.MaxChangePercent)
+		case bounds.OutlierFactor > 1 && median != nil && *median > 0 &&
+			(change.NewPrice > *median*bounds.OutlierFactor || change.NewPrice < *median/bounds.OutlierFactor):
+			change.Skipped = fmt.Sprintf("outlier compared to the median of %.2f at other stores", *median)
+		case change.NewPrice == change.OldPrice:
+			change.Skipped = "no change"
+		}
+
+		if change.Skipped != "" {
+			result.Skipped++
+			continue
+		}
+		result.Updated++
+
+		if req.DryRun {
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE store_prices SET price = $2, updated_at = NOW() WHERE id = $1
+		`, change.PriceID, change.NewPrice); err != nil {
+			return nil, err
+		}
+
+		oldPrice := change.OldPrice
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO price_history (store_id, item_id, price, previous_price, user_id, recorded_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+		`, storeID, change.ItemID, change.NewPrice, &oldPrice, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetUserPriceGap pairs each of a user's private prices with the most recent shared price from
+// other users for the same store and item. Whichever side was updated more recently is treated
+// as current; pairs within a cent of each other are in sync.
+func (db *DB) GetUserPriceGap(ctx context.Context, userID int) (*models.PriceGapReport, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.store_id, s.name, p.item_id, i.name, p.price, p.updated_at,
+		       c.price, c.updated_at, c.total
+		FROM store_prices p
+		JOIN stores s ON p.store_id = s.id
+		JOIN items i ON p.item_id = i.id
+		JOIN LATERAL (
+			SELECT sp.price, sp.updated_at, COUNT(*) OVER () as total
+			FROM store_prices sp
+			WHERE sp.store_id = p.store_id AND sp.item_id = p.item_id
+			AND sp.is_shared = true
+			AND (sp.user_id IS NULL OR sp.user_id <> $1)
+			ORDER BY sp.updated_at DESC
+			LIMIT 1
+		) c ON true
+		WHERE p.user_id = $1 AND p.is_shared = false
+		ORDER BY s.name, i.name
+	`, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prices []*models.StorePriceWithDetails
+	report := &models.PriceGapReport{
+		UserID:  userID,
+		Entries: []*models.PriceGapEntry{},
+	}
 	for rows.Next() {
-		p := &models.StorePriceWithDetails{}
+		e := &models.PriceGapEntry{}
 		err := rows.Scan(
-			&p.ID, &p.StoreID, &p.ItemID, &p.Price, &p.UserID, &p.IsShared,
-			&p.VerifiedCount, &p.LastVerified, &p.CreatedAt, &p.UpdatedAt,
-			&p.ItemName, &p.ItemBrand,
-			&p.StoreName, &p.StoreAddress, &p.StoreCity, &p.StoreState, &p.StoreZipCode,
-			&p.RegionID, &p.RegionName,
-			&p.UserName, &p.UserEmail,
+			&e.StoreID, &e.StoreName, &e.ItemID, &e.ItemName, &e.PrivatePrice, &e.PrivateUpdatedAt,
+			&e.CommunityPrice, &e.CommunityUpdatedAt, &e.CommunityCount,
 		)
 		if err != nil {
 			return nil, err
 		}
-		prices = append(prices, p)
+
+		e.Difference = math.Round((e.PrivatePrice-e.CommunityPrice)*100) / 100
+		if e.CommunityPrice > 0 {
+			e.DifferencePercent = math.Round(e.Difference/e.CommunityPrice*10000) / 100
+		}
+
+		switch {
+		case math.Abs(e.Difference) < 0.01:
+			e.Status = models.PriceGapInSync
+			report.InSync++
+		case e.CommunityUpdatedAt.After(e.PrivateUpdatedAt):
+			e.Status = models.PriceGapPrivateStale
+			report.PrivateStale++
+		default:
+			e.Status = models.PriceGapCommunityStale
+			report.CommunityStale++
+		}
+
+		report.Entries = append(report.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.Compared = len(report.Entries)
+
+	return report, nil
+}
+
+// GetOutlierReferencePrices returns recent shared prices at public stores for an item and its
+// package-size variants (items with the same name and brand), with each item's size and unit
+func (db *DB) GetOutlierReferencePrices(ctx context.Context, itemID int) ([]models.ReferencePrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT v.id, sp.price, v.size, v.unit
+		FROM items base
+		JOIN items v ON LOWER(TRIM(v.name)) = LOWER(TRIM(base.name))
+			AND LOWER(COALESCE(v.brand, '')) = LOWER(COALESCE(base.brand, ''))
+		JOIN store_prices sp ON sp.item_id = v.id AND sp.is_shared = true
+		JOIN stores s ON sp.store_id = s.id AND COALESCE(s.is_private, false) = false
+		WHERE base.id = $1
+		  AND (COALESCE(v.is_private, false) = false OR v.id = base.id)
+		ORDER BY sp.updated_at DESC
+		LIMIT 200
+	`, itemID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return prices, nil
+	var refs []models.ReferencePrice
+	for rows.Next() {
+		var r models.ReferencePrice
+		if err := rows.Scan(&r.ItemID, &r.Price, &r.Size, &r.Unit); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+
+	return refs, rows.Err()
 }
 
 // RecordPriceHistory records a price change in the history table
@@ -527,6 +934,246 @@ func (db *DB) GetPriceHistory(ctx context.Context, params *models.PriceHistoryPa
 	}, nil
 }
 
+// GetLowestPriceEver returns the lowest price recorded in price history for an item,
+// optionally scoped to a store or region. Private stores and items are excluded, as is history
+// from contributors without a shared price for that store and item. Returns nil when no history exists.
+func (db *DB) GetLowestPriceEver(ctx context.Context, params *models.LowestPriceParams) (*models.LowestPriceRecord, error) {
+	query := `
+		SELECT ph.item_id, ph.price, ph.store_id, s.name, s.region_id, ph.recorded_at
+		FROM price_history ph
+		JOIN stores s ON ph.store_id = s.id
+		JOIN items i ON ph.item_id = i.id
+		WHERE ph.item_id = $1
+		  AND COALESCE(s.is_private, false) = false
+		  AND COALESCE(i.is_private, false) = false
+		  AND EXISTS (
+		      SELECT 1 FROM store_prices sp
+		      WHERE sp.store_id = ph.store_id AND sp.item_id = ph.item_id
+		        AND sp.user_id IS NOT DISTINCT FROM ph.user_id AND sp.is_shared = true)
+	`
+	args := []interface{}{params.ItemID}
+	argIndex := 2
+
+	if params.StoreID != nil {
+		query += fmt.Sprintf(" AND ph.store_id = $%d", argIndex)
+		args = append(args, *params.StoreID)
+		argIndex++
+	}
+
+	if params.RegionID != nil {
+		query += fmt.Sprintf(" AND s.region_id = $%d", argIndex)
+		args = append(args, *params.RegionID)
+	}
+
+	// Earliest occurrence wins ties so "first seen at this price" is reported
+	query += " ORDER BY ph.price ASC, ph.recorded_at ASC LIMIT 1"
+
+	record := &models.LowestPriceRecord{}
+	err := db.Pool.QueryRow(ctx, query, args...).Scan(
+		&record.ItemID, &record.Price, &record.StoreID, &record.StoreName, &record.RegionID, &record.RecordedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetBestDayBuckets groups an item's public price history by day of week or week of month.
+// History only counts when its own contributor has a shared price for that store and item. Each price is compared to its store's average so stores with different price levels can be combined.
+func (db *DB) GetBestDayBuckets(ctx context.Context, params *models.BestDayParams) ([]*models.BestDayBucket, error) {
+	bucketExpr := "EXTRACT(DOW FROM recorded_at)::int"
+	if params.GroupBy == models.BestDayByWeekOfMonth {
+		bucketExpr = "((EXTRACT(DAY FROM recorded_at)::int - 1) / 7) + 1"
+	}
+
+	filters := ""
+	args := []interface{}{params.ItemID, params.Days}
+	argIndex := 3
+
+	if params.StoreID != nil {
+		filters += fmt.Sprintf(" AND ph.store_id = $%d", argIndex)
+		args = append(args, *params.StoreID)
+		argIndex++
+	}
+
+	if params.RegionID != nil {
+		filters += fmt.Sprintf(" AND s.region_id = $%d", argIndex)
+		args = append(args, *params.RegionID)
+	}
+
+	query := fmt.Sprintf(`
+		WITH history AS (
+			SELECT ph.price, ph.recorded_at,
+				AVG(ph.price) OVER (PARTITION BY ph.store_id) as store_avg
+			FROM price_history ph
+			JOIN stores s ON ph.store_id = s.id
+			JOIN items i ON ph.item_id = i.id
+			WHERE ph.item_id = $1
+			  AND ph.recorded_at >= NOW() - ($2 || ' days')::INTERVAL
+			  AND COALESCE(s.is_private, false) = false
+			  AND COALESCE(i.is_private, false) = false
+			  AND EXISTS (
+			      SELECT 1 FROM store_prices sp
+			      WHERE sp.store_id = ph.store_id AND sp.item_id = ph.item_id
+			        AND sp.user_id IS NOT DISTINCT FROM ph.user_id AND sp.is_shared = true)
+			  %s
+		)
+		SELECT %s as bucket, COUNT(*), AVG(price)::float8, AVG(price / NULLIF(store_avg, 0))::float8
+		FROM history
+		GROUP BY bucket
+		ORDER BY bucket
+	`, filters, bucketExpr)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []*models.BestDayBucket{}
+	for rows.Next() {
+		b := &models.BestDayBucket{}
+		var relative *float64
+		if err := rows.Scan(&b.Bucket, &b.SampleCount, &b.AvgPrice, &relative); err != nil {
+			return nil, err
+		}
+		if relative != nil {
+			b.RelativePrice = *relative
+		} else {
+			b.RelativePrice = 1
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetPriceHistogram buckets an item's current shared prices into equal-width ranges between
+// the lowest and highest price. Prices at private stores or not refreshed within staleDays are excluded.
+// A verified-only filter narrows the prices to verified ones unless too few of them qualify.
+func (db *DB) GetPriceHistogram(ctx context.Context, itemID int, regionID *int, bucketCount, staleDays int, filter models.PublicStatsFilter) (*models.PriceHistogram, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH candidates AS (
+			SELECT sp.price, sp.verified_count >= $5 as verified
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			JOIN items i ON sp.item_id = i.id
+			WHERE sp.item_id = $1
+			  AND sp.is_shared = true
+			  AND COALESCE(s.is_private, false) = false
+			  AND COALESCE(i.is_private, false) = false
+			  AND GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - ($2 || ' days')::INTERVAL
+			  AND ($3::int IS NULL OR s.region_id = $3)
+		),
+		tally AS (
+			SELECT COUNT(*) FILTER (WHERE verified) >= $6 as enough FROM candidates
+		),
+		prices AS (
+			SELECT c.price FROM candidates c, tally t WHERE c.verified OR NOT t.enough
+		),
+		bounds AS (
+			SELECT MIN(price) as lo, MAX(price) as hi FROM prices
+		)
+		SELECT
+			CASE WHEN b.hi = b.lo THEN 1 ELSE LEAST(width_bucket(p.price, b.lo, b.hi, $4), $4) END as bucket,
+			COUNT(*), b.lo::float8, b.hi::float8, t.enough
+		FROM prices p, bounds b, tally t
+		GROUP BY bucket, b.lo, b.hi, t.enough
+		ORDER BY bucket
+	`, itemID, staleDays, regionID, bucketCount, filter.Threshold(), filter.MinVerifiedPrices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histogram := &models.PriceHistogram{ItemID: itemID, RegionID: regionID, Buckets: []*models.PriceHistogramBucket{}}
+	counts := make(map[int]int)
+	for rows.Next() {
+		var bucket, count int
+		var verified bool
+		if err := rows.Scan(&bucket, &count, &histogram.Min, &histogram.Max, &verified); err != nil {
+			return nil, err
+		}
+		histogram.VerifiedOnly = filter.VerifiedOnly && verified
+		counts[bucket] = count
+		histogram.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if histogram.Total == 0 {
+		return histogram, nil
+	}
+
+	// A single distinct price collapses into one bucket
+	if histogram.Max == histogram.Min {
+		bucketCount = 1
+	}
+	histogram.BucketWidth = (histogram.Max - histogram.Min) / float64(bucketCount)
+
+	for i := 1; i <= bucketCount; i++ {
+		histogram.Buckets = append(histogram.Buckets, &models.PriceHistogramBucket{
+			From:  histogram.Min + float64(i-1)*histogram.BucketWidth,
+			To:    histogram.Min + float64(i)*histogram.BucketWidth,
+			Count: counts[i],
+		})
+	}
+
+	return histogram, nil
+}
+
+// GetItemRegionPrices summarizes an item's current shared prices per region, using the same
+// freshness, privacy and verified-only rules as the price histogram; the verified fallback is
+// decided per region. Stores without a region are left out.
+func (db *DB) GetItemRegionPrices(ctx context.Context, itemID, staleDays int, filter models.PublicStatsFilter) ([]*models.RegionItemPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH candidates AS (
+			SELECT s.region_id, sp.store_id, sp.price, sp.verified_count >= $3 as verified,
+			       COUNT(*) FILTER (WHERE sp.verified_count >= $3) OVER (PARTITION BY s.region_id) >= $4 as enough
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			JOIN items i ON sp.item_id = i.id
+			WHERE sp.item_id = $1
+			  AND sp.is_shared = true
+			  AND COALESCE(s.is_private, false) = false
+			  AND COALESCE(i.is_private, false) = false
+			  AND GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - ($2 || ' days')::INTERVAL
+		)
+		SELECT r.id, r.name, r.state, r.currency, r.cost_of_living_index,
+		       COUNT(DISTINCT c.store_id),
+		       MIN(c.price)::float8, ROUND(AVG(c.price), 2)::float8, MAX(c.price)::float8,
+		       bool_and(c.enough)
+		FROM candidates c
+		JOIN regions r ON c.region_id = r.id
+		WHERE c.verified OR NOT c.enough
+		GROUP BY r.id, r.name, r.state, r.currency, r.cost_of_living_index
+		ORDER BY AVG(c.price) ASC
+	`, itemID, staleDays, filter.Threshold(), filter.MinVerifiedPrices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regions := []*models.RegionItemPrice{}
+	for rows.Next() {
+		p := &models.RegionItemPrice{}
+		var verified bool
+		if err := rows.Scan(&p.RegionID, &p.RegionName, &p.State, &p.Currency, &p.CostOfLivingIndex,
+			&p.StoreCount, &p.MinPrice, &p.AvgPrice, &p.MaxPrice, &verified); err != nil {
+			return nil, err
+		}
+		p.VerifiedOnly = filter.VerifiedOnly && verified
+		regions = append(regions, p)
+	}
+
+	return regions, rows.Err()
+}
+
 // GetPriceForItemStore returns the current price for an item at a specific store
 func (db *DB) GetPriceForItemStore(ctx context.Context, itemID, storeID int) (*models.StorePrice, error) {
 	price := &models.StorePrice{}
@@ -546,3 +1193,205 @@ func (db *DB) GetPriceForItemStore(ctx context.Context, itemID, storeID int) (*m
 	}
 	return price, nil
 }
+
+// GetChainParity returns the current shared price of an item at every public location of a chain
+// (matched case-insensitively) together with its spread. Prices not refreshed within staleDays are excluded.
+func (db *DB) GetChainParity(ctx context.Context, chain string, itemID, staleDays int) (*models.ChainParity, error) {
+	parity := &models.ChainParity{Chain: chain, ItemID: itemID, StaleAfterDays: staleDays, Locations: []*models.ChainParityLocation{}}
+
+	err := db.Pool.QueryRow(ctx, `SELECT name FROM items WHERE id = $1 AND COALESCE(is_private, false) = false`, itemID).Scan(&parity.ItemName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	// Latest price per location so a store with several submissions counts once
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT ON (s.id) s.id, s.name, s.city, s.state, sp.price, sp.updated_at
+		FROM store_prices sp
+		JOIN stores s ON sp.store_id = s.id
+		WHERE sp.item_id = $1
+		  AND LOWER(s.chain) = LOWER($2)
+		  AND sp.is_shared = true
+		  AND COALESCE(s.is_private, false) = false
+		  AND GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - ($3 || ' days')::INTERVAL
+		ORDER BY s.id, sp.updated_at DESC
+	`, itemID, chain, staleDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := &models.ChainParityLocation{}
+		if err := rows.Scan(&l.StoreID, &l.StoreName, &l.City, &l.State, &l.Price, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		parity.Locations = append(parity.Locations, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	parity.LocationCount = len(parity.Locations)
+	if parity.LocationCount == 0 {
+		return parity, nil
+	}
+
+	sort.Slice(parity.Locations, func(i, j int) bool {
+		return parity.Locations[i].Price < parity.Locations[j].Price
+	})
+	parity.MinPrice = parity.Locations[0].Price
+	parity.MaxPrice = parity.Locations[parity.LocationCount-1].Price
+
+	var sum float64
+	for _, l := range parity.Locations {
+		sum += l.Price
+	}
+	avg := sum / float64(parity.LocationCount)
+
+	var variance float64
+	for _, l := range parity.Locations {
+		variance += (l.Price - avg) * (l.Price - avg)
+	}
+	stddev := math.Sqrt(variance / float64(parity.LocationCount))
+
+	parity.AvgPrice = roundCents(avg)
+	parity.StdDev = roundCents(stddev)
+	if avg > 0 {
+		parity.VariationPercent = math.Round(stddev/avg*1000) / 10
+		parity.Uniform = math.Max(avg-parity.MinPrice, parity.MaxPrice-avg)/avg*100 <= models.ChainParityUniformPercent
+	}
+
+	return parity, nil
+}
+
+// GetPriceDrops returns each item's largest price decrease recorded at a public store within the
+// last params.Days by at least params.MinPercent, largest relative drop first, with the total
+// number of items. A drop only
+// counts while it is still the store's current shared price, that price is fresh and not
+// hidden by flags, and neither side of the drop is an outlier against the item's median.
+func (db *DB) GetPriceDrops(ctx context.Context, params *models.PriceDropParams) ([]*models.PriceDrop, int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH candidates AS (
+			SELECT ph.item_id, ph.store_id, ph.price, ph.previous_price, ph.recorded_at,
+			       (ph.previous_price - ph.price) / ph.previous_price * 100 as drop_pct
+			FROM price_history ph
+			JOIN stores s ON ph.store_id = s.id AND COALESCE(s.is_private, false) = false
+			JOIN items i ON ph.item_id = i.id AND COALESCE(i.is_private, false) = false
+			WHERE ph.recorded_at >= NOW() - ($1 || ' days')::INTERVAL
+			  AND ph.previous_price IS NOT NULL
+			  AND ph.previous_price > 0
+			  AND ph.price < ph.previous_price
+			  AND (ph.previous_price - ph.price) / ph.previous_price * 100 >= $8
+			  AND ($2::int IS NULL OR s.region_id = $2)
+			  AND EXISTS (
+				SELECT 1 FROM store_prices sp
+				WHERE sp.store_id = ph.store_id
+				  AND sp.item_id = ph.item_id
+				  AND sp.is_shared = true
+				  AND sp.price = ph.price
+				  AND sp.flag_count < $4
+				  AND GREATEST(sp.updated_at, COALESCE(sp.last_verified, sp.updated_at)) >= NOW() - ($3 || ' days')::INTERVAL
+			  )
+		),
+		medians AS (
+			SELECT sp.item_id, percentile_cont(0.5) WITHIN GROUP (ORDER BY sp.price) as median
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id AND COALESCE(s.is_private, false) = false
+			WHERE sp.is_shared = true
+			  AND sp.item_id IN (SELECT item_id FROM candidates)
+			GROUP BY sp.item_id
+		),
+		best AS (
+			SELECT DISTINCT ON (c.item_id) c.*
+			FROM candidates c
+			LEFT JOIN medians m ON m.item_id = c.item_id
+			WHERE $5::float8 <= 1 OR m.median IS NULL OR m.median <= 0
+			   OR (c.price >= m.median / $5 AND c.previous_price <= m.median * $5)
+			ORDER BY c.item_id, c.drop_pct DESC, c.recorded_at DESC
+		)
+		SELECT b.item_id, i.name, i.brand, b.store_id, s.name, s.region_id,
+		       b.price::float8, b.previous_price::float8, b.drop_pct::float8, b.recorded_at,
+		       COUNT(*) OVER () as total
+		FROM best b
+		JOIN items i ON b.item_id = i.id
+		JOIN stores s ON b.store_id = s.id
+		ORDER BY b.drop_pct DESC, b.recorded_at DESC, b.item_id
+		LIMIT $6 OFFSET $7
+	`, params.Days, params.RegionID, params.StaleDays, models.PriceFlagHideThreshold, params.OutlierFactor, params.Limit, params.Offset, params.MinPercent)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	drops := []*models.PriceDrop{}
+	total := 0
+	for rows.Next() {
+		d := &models.PriceDrop{}
+		if err := rows.Scan(&d.ItemID, &d.ItemName, &d.ItemBrand, &d.StoreID, &d.StoreName, &d.RegionID,
+			&d.Price, &d.PreviousPrice, &d.DropPercent, &d.DroppedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		d.DropAmount = math.Round((d.PreviousPrice-d.Price)*100) / 100
+		d.DropPercent = math.Round(d.DropPercent*10) / 10
+		drops = append(drops, d)
+	}
+
+	return drops, total, rows.Err()
+}
+
+// GetRecentPriceDrops returns store prices whose latest price_history entry is a decrease of at
+// least minPercent, at public stores and optionally in one region, largest relative drop first.
+// Only entries that are still the store's current shared price are included.
+func (db *DB) GetRecentPriceDrops(ctx context.Context, regionID *int, minPercent float64, limit int) ([]*models.PriceDrop, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (ph.store_id, ph.item_id)
+			       ph.store_id, ph.item_id, ph.price, ph.previous_price, ph.recorded_at
+			FROM price_history ph
+			JOIN stores s ON ph.store_id = s.id AND COALESCE(s.is_private, false) = false
+			WHERE ($1::int IS NULL OR s.region_id = $1)
+			ORDER BY ph.store_id, ph.item_id, ph.recorded_at DESC, ph.id DESC
+		)
+		SELECT l.item_id, i.name, i.brand, l.store_id, s.name, s.region_id,
+		       l.price::float8, l.previous_price::float8,
+		       ((l.previous_price - l.price) / l.previous_price * 100)::float8 as drop_pct, l.recorded_at
+		FROM latest l
+		JOIN items i ON l.item_id = i.id AND COALESCE(i.is_private, false) = false
+		JOIN stores s ON l.store_id = s.id
+		WHERE l.previous_price > 0
+		  AND l.price < l.previous_price
+		  AND (l.previous_price - l.price) / l.previous_price * 100 >= $2
+		  AND EXISTS (
+			SELECT 1 FROM store_prices sp
+			WHERE sp.store_id = l.store_id
+			  AND sp.item_id = l.item_id
+			  AND sp.is_shared = true
+			  AND sp.price = l.price
+			  AND sp.flag_count < $3
+		  )
+		ORDER BY drop_pct DESC, l.recorded_at DESC
+		LIMIT $4
+	`, regionID, minPercent, models.PriceFlagHideThreshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drops := []*models.PriceDrop{}
+	for rows.Next() {
+		d := &models.PriceDrop{}
+		if err := rows.Scan(&d.ItemID, &d.ItemName, &d.ItemBrand, &d.StoreID, &d.StoreName, &d.RegionID,
+			&d.Price, &d.PreviousPrice, &d.DropPercent, &d.DroppedAt); err != nil {
+			return nil, err
+		}
+		d.DropAmount = math.Round((d.PreviousPrice-d.Price)*100) / 100
+		d.DropPercent = math.Round(d.DropPercent*10) / 10
+		drops = append(drops, d)
+	}
+
+	return drops, rows.Err()
+}
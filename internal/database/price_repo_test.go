@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func userReputation(t *testing.T, db *DB, userID int) int {
+	t.Helper()
+	var points int
+	if err := db.Pool.QueryRow(context.Background(),
+		`SELECT reputation_points FROM users WHERE id = $1`, userID).Scan(&points); err != nil {
+		t.Fatalf("read reputation: %v", err)
+	}
+	return points
+}
+
+func TestVerifyPriceRejectsSubmitter(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	submitter := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+	price := testPrice(t, db, store.ID, item.ID, 2.49, &submitter.ID)
+
+	before := userReputation(t, db, submitter.ID)
+	_, err := db.VerifyPrice(ctx, price.ID, submitter.ID, true, 1, 2)
+	if !errors.Is(err, ErrVerifyOwnPrice) {
+		t.Fatalf("VerifyPrice by submitter: got %v, want ErrVerifyOwnPrice", err)
+	}
+	if after := userReputation(t, db, submitter.ID); after != before {
+		t.Errorf("submitter reputation changed from %d to %d", before, after)
+	}
+
+	got, err := db.GetPriceByID(ctx, price.ID)
+	if err != nil {
+		t.Fatalf("GetPriceByID: %v", err)
+	}
+	if got.VerifiedCount != 0 {
+		t.Errorf("verified_count = %d, want 0", got.VerifiedCount)
+	}
+}
+
+func TestVerifyPriceRewardsOnce(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	submitter := testUser(t, db)
+	verifier := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+	price := testPrice(t, db, store.ID, item.ID, 2.49, &submitter.ID)
+
+	submitterBefore := userReputation(t, db, submitter.ID)
+	verifierBefore := userReputation(t, db, verifier.ID)
+
+	first, err := db.VerifyPrice(ctx, price.ID, verifier.ID, true, 1, 2)
+	if err != nil {
+		t.Fatalf("VerifyPrice: %v", err)
+	}
+	if !first.IsNew || !first.Rewarded {
+		t.Errorf("first verification = %+v, want new and rewarded", first)
+	}
+
+	if _, err := db.VerifyPrice(ctx, price.ID, verifier.ID, false, 1, 2); err != nil {
+		t.Fatalf("VerifyPrice toggle: %v", err)
+	}
+	again, err := db.VerifyPrice(ctx, price.ID, verifier.ID, true, 1, 2)
+	if err != nil {
+		t.Fatalf("VerifyPrice again: %v", err)
+	}
+	if again.IsNew || again.Rewarded {
+		t.Errorf("repeat verification = %+v, want neither new nor rewarded", again)
+	}
+
+	if got := userReputation(t, db, verifier.ID) - verifierBefore; got != 1 {
+		t.Errorf("verifier earned %d points, want 1", got)
+	}
+	if got := userReputation(t, db, submitter.ID) - submitterBefore; got != 2 {
+		t.Errorf("submitter earned %d points, want 2", got)
+	}
+}
+
+func TestGetOutlierReferencePrices(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	name := testName("item")
+	small, large := 16.0, 64.0
+	oz := "oz"
+	item := testItem(t, db, &models.CreateItemRequest{Name: name, Size: &small, Unit: &oz}, nil)
+	variant := testItem(t, db, &models.CreateItemRequest{Name: " " + strings.ToUpper(name) + " ", Size: &large, Unit: &oz}, nil)
+	unrelated := testItem(t, db, nil, nil)
+
+	store := testStore(t, db, nil)
+	privateStore, err := db.CreateStore(ctx, &models.CreateStoreRequest{
+		Name:          testName("store"),
+		StreetAddress: testName("street"),
+		City:          "Testville",
+		State:         "TX",
+		ZipCode:       "75001",
+		IsPrivate:     true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("create private store: %v", err)
+	}
+
+	testPrice(t, db, store.ID, item.ID, 1.00, nil)
+	testPrice(t, db, store.ID, variant.ID, 3.50, nil)
+	testPrice(t, db, store.ID, unrelated.ID, 9.99, nil)
+	testPrice(t, db, privateStore.ID, item.ID, 7.77, nil)
+	if _, err := db.CreatePrice(ctx, &models.CreatePriceRequest{StoreID: testStore(t, db, nil).ID, ItemID: item.ID, Price: 8.88}, nil); err != nil {
+		t.Fatalf("create unshared price: %v", err)
+	}
+
+	refs, err := db.GetOutlierReferencePrices(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("GetOutlierReferencePrices: %v", err)
+	}
+
+	got := make(map[float64]int)
+	for _, r := range refs {
+		got[r.Price] = r.ItemID
+	}
+	if len(refs) != 2 || got[1.00] != item.ID || got[3.50] != variant.ID {
+		t.Errorf("references = %+v, want the shared public prices of the item and its variant", refs)
+	}
+}
+
+func TestGetLowestPriceEverSkipsPrivateContributors(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	sharer := testUser(t, db)
+	private := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+
+	testPrice(t, db, store.ID, item.ID, 3.00, &sharer.ID)
+	if _, err := db.CreatePrice(ctx, &models.CreatePriceRequest{
+		StoreID: store.ID, ItemID: item.ID, Price: 1.00, IsShared: false,
+	}, &private.ID); err != nil {
+		t.Fatalf("create private price: %v", err)
+	}
+	for _, h := range []struct {
+		price  float64
+		userID int
+	}{{3.00, sharer.ID}, {1.00, private.ID}} {
+		if err := db.RecordPriceHistory(ctx, store.ID, item.ID, h.price, nil, &h.userID); err != nil {
+			t.Fatalf("RecordPriceHistory: %v", err)
+		}
+	}
+
+	record, err := db.GetLowestPriceEver(ctx, &models.LowestPriceParams{ItemID: item.ID})
+	if err != nil {
+		t.Fatalf("GetLowestPriceEver: %v", err)
+	}
+	if record == nil || record.Price != 3.00 {
+		t.Errorf("lowest price = %+v, want the shared 3.00", record)
+	}
+}
+
+func TestGetBestDayBucketsSkipsPrivateContributors(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	sharer := testUser(t, db)
+	private := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+
+	testPrice(t, db, store.ID, item.ID, 4.00, &sharer.ID)
+	if _, err := db.CreatePrice(ctx, &models.CreatePriceRequest{
+		StoreID: store.ID, ItemID: item.ID, Price: 1.00, IsShared: false,
+	}, &private.ID); err != nil {
+		t.Fatalf("create private price: %v", err)
+	}
+	for _, h := range []struct {
+		price  float64
+		userID int
+	}{{2.00, sharer.ID}, {4.00, sharer.ID}, {1.00, private.ID}} {
+		if err := db.RecordPriceHistory(ctx, store.ID, item.ID, h.price, nil, &h.userID); err != nil {
+			t.Fatalf("RecordPriceHistory: %v", err)
+		}
+	}
+
+	buckets, err := db.GetBestDayBuckets(ctx, &models.BestDayParams{
+		ItemID: item.ID, GroupBy: models.BestDayByDayOfWeek, Days: 30,
+	})
+	if err != nil {
+		t.Fatalf("GetBestDayBuckets: %v", err)
+	}
+	if len(buckets) != 1 {
+		t.Fatalf("got %d buckets, want 1", len(buckets))
+	}
+	if b := buckets[0]; b.SampleCount != 2 || b.AvgPrice != 3.00 || b.RelativePrice < 0.999 || b.RelativePrice > 1.001 {
+		t.Errorf("bucket = %d samples averaging %.2f (relative %.2f), want 2 averaging 3.00 (relative 1.00)",
+			b.SampleCount, b.AvgPrice, b.RelativePrice)
+	}
+}
@@ -13,6 +13,7 @@ import (
 var (
 	ErrReceiptNotFound     = errors.New("receipt not found")
 	ErrReceiptItemNotFound = errors.New("receipt item not found")
+	ErrReceiptConfirmed    = errors.New("receipt already confirmed")
 )
 
 // CreateReceipt creates a new receipt record
@@ -23,12 +24,12 @@ func (db *DB) CreateReceipt(ctx context.Context, req *models.CreateReceiptReques
 		INSERT INTO receipts (user_id, store_id, s3_bucket, s3_key, original_filename, content_type, file_size_bytes, status)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending')
 		RETURNING id, user_id, store_id, s3_bucket, s3_key, original_filename, content_type, file_size_bytes,
-		          status, ocr_text, error_message, receipt_date, receipt_total,
+		          status, ocr_text, error_message, receipt_date, receipt_total, receipt_date_source, ocr_preprocessing, thumbnail_key,
 		          uploaded_at, processed_at, confirmed_at, expires_at, created_at, updated_at
 	`, req.UserID, req.StoreID, req.S3Bucket, req.S3Key, req.OriginalFilename, req.ContentType, req.FileSizeBytes).Scan(
 		&receipt.ID, &receipt.UserID, &receipt.StoreID, &receipt.S3Bucket, &receipt.S3Key,
 		&receipt.OriginalFilename, &receipt.ContentType, &receipt.FileSizeBytes,
-		&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal,
+		&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal, &receipt.DateSource, &receipt.OCRPreprocessing, &receipt.ThumbnailKey,
 		&receipt.UploadedAt, &receipt.ProcessedAt, &receipt.ConfirmedAt, &receipt.ExpiresAt, &receipt.CreatedAt, &receipt.UpdatedAt,
 	)
 
@@ -45,7 +46,7 @@ func (db *DB) GetReceiptByID(ctx context.Context, id int) (*models.ReceiptWithIt
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT r.id, r.user_id, r.store_id, r.s3_bucket, r.s3_key, r.original_filename, r.content_type, r.file_size_bytes,
-		       r.status, r.ocr_text, r.error_message, r.receipt_date, r.receipt_total,
+		       r.status, r.ocr_text, r.error_message, r.receipt_date, r.receipt_total, r.receipt_date_source, r.ocr_preprocessing, r.thumbnail_key,
 		       r.uploaded_at, r.processed_at, r.confirmed_at, r.expires_at, r.created_at, r.updated_at,
 		       s.name as store_name
 		FROM receipts r
@@ -54,7 +55,7 @@ func (db *DB) GetReceiptByID(ctx context.Context, id int) (*models.ReceiptWithIt
 	`, id).Scan(
 		&receipt.ID, &receipt.UserID, &receipt.StoreID, &receipt.S3Bucket, &receipt.S3Key,
 		&receipt.OriginalFilename, &receipt.ContentType, &receipt.FileSizeBytes,
-		&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal,
+		&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal, &receipt.DateSource, &receipt.OCRPreprocessing, &receipt.ThumbnailKey,
 		&receipt.UploadedAt, &receipt.ProcessedAt, &receipt.ConfirmedAt, &receipt.ExpiresAt, &receipt.CreatedAt, &receipt.UpdatedAt,
 		&receipt.StoreName,
 	)
@@ -82,7 +83,7 @@ func (db *DB) GetReceiptItems(ctx context.Context, receiptID int) ([]models.Rece
 		SELECT ri.id, ri.receipt_id, ri.raw_text, ri.extracted_name, ri.extracted_price, ri.extracted_quantity,
 		       ri.matched_item_id, ri.match_confidence, ri.match_status,
 		       ri.confirmed_item_id, ri.confirmed_price, ri.is_confirmed, ri.created_item_id,
-		       ri.line_number, ri.created_at, ri.updated_at,
+		       ri.line_number, ri.ocr_confidence, ri.created_at, ri.updated_at,
 		       i.name as matched_item_name
 		FROM receipt_items ri
 		LEFT JOIN items i ON ri.matched_item_id = i.id
@@ -101,7 +102,7 @@ func (db *DB) GetReceiptItems(ctx context.Context, receiptID int) ([]models.Rece
 			&item.ID, &item.ReceiptID, &item.RawText, &item.ExtractedName, &item.ExtractedPrice, &item.ExtractedQuantity,
 			&item.MatchedItemID, &item.MatchConfidence, &item.MatchStatus,
 			&item.ConfirmedItemID, &item.ConfirmedPrice, &item.IsConfirmed, &item.CreatedItemID,
-			&item.LineNumber, &item.CreatedAt, &item.UpdatedAt,
+			&item.LineNumber, &item.OCRConfidence, &item.CreatedAt, &item.UpdatedAt,
 			&item.MatchedItemName,
 		)
 		if err != nil {
@@ -143,7 +144,7 @@ func (db *DB) ListReceipts(ctx context.Context, params *models.ReceiptListParams
 	// Get receipts
 	query := `
 		SELECT r.id, r.user_id, r.store_id, r.s3_bucket, r.s3_key, r.original_filename, r.content_type, r.file_size_bytes,
-		       r.status, r.ocr_text, r.error_message, r.receipt_date, r.receipt_total,
+		       r.status, r.ocr_text, r.error_message, r.receipt_date, r.receipt_total, r.receipt_date_source, r.ocr_preprocessing, r.thumbnail_key,
 		       r.uploaded_at, r.processed_at, r.confirmed_at, r.expires_at, r.created_at, r.updated_at,
 		       s.name as store_name
 		FROM receipts r
@@ -166,7 +167,7 @@ func (db *DB) ListReceipts(ctx context.Context, params *models.ReceiptListParams
 		err := rows.Scan(
 			&receipt.ID, &receipt.UserID, &receipt.StoreID, &receipt.S3Bucket, &receipt.S3Key,
 			&receipt.OriginalFilename, &receipt.ContentType, &receipt.FileSizeBytes,
-			&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal,
+			&receipt.Status, &receipt.OCRText, &receipt.ErrorMessage, &receipt.ReceiptDate, &receipt.ReceiptTotal, &receipt.DateSource, &receipt.OCRPreprocessing, &receipt.ThumbnailKey,
 			&receipt.UploadedAt, &receipt.ProcessedAt, &receipt.ConfirmedAt, &receipt.ExpiresAt, &receipt.CreatedAt, &receipt.UpdatedAt,
 			&receipt.StoreName,
 		)
@@ -196,13 +197,146 @@ func (db *DB) UpdateReceiptStatus(ctx context.Context, id int, status models.Rec
 	return err
 }
 
-// UpdateReceiptMetadata updates extracted metadata
-func (db *DB) UpdateReceiptMetadata(ctx context.Context, id int, receiptDate *time.Time, total *float64) error {
+// SetReceiptThumbnail records the storage key of a receipt's thumbnail
+func (db *DB) SetReceiptThumbnail(ctx context.Context, id int, key string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE receipts SET thumbnail_key = $2, updated_at = NOW() WHERE id = $1`, id, key)
+	return err
+}
+
+// SetReceiptOCRPreprocessing records which preprocessing steps ran before OCR
+func (db *DB) SetReceiptOCRPreprocessing(ctx context.Context, id int, preprocessing string) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE receipts SET ocr_preprocessing = $2, updated_at = NOW() WHERE id = $1
+	`, id, preprocessing)
+	return err
+}
+
+// GetOCRPreprocessingStats compares how many parsed items were matched to catalog items for
+// receipts uploaded since the given time, grouped by preprocessing. Deskew angles are folded
+// into a single "deskew" step so the groups stay comparable.
+func (db *DB) GetOCRPreprocessingStats(ctx context.Context, since time.Time) ([]models.OCRPreprocessingStat, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT regexp_replace(r.ocr_preprocessing, 'deskew\([^)]*\)', 'deskew') AS preprocessing,
+		       COUNT(DISTINCT r.id),
+		       COUNT(ri.id),
+		       COUNT(ri.id) FILTER (WHERE ri.matched_item_id IS NOT NULL OR ri.confirmed_item_id IS NOT NULL)
+		FROM receipts r
+		LEFT JOIN receipt_items ri ON ri.receipt_id = r.id
+		WHERE r.ocr_preprocessing IS NOT NULL AND r.uploaded_at >= $1
+		GROUP BY 1
+		ORDER BY 2 DESC
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.OCRPreprocessingStat{}
+	for rows.Next() {
+		var s models.OCRPreprocessingStat
+		if err := rows.Scan(&s.Preprocessing, &s.Receipts, &s.Items, &s.MatchedItems); err != nil {
+			return nil, err
+		}
+		if s.Items > 0 {
+			s.MatchRate = float64(s.MatchedItems) / float64(s.Items)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetReceiptOCRMetrics aggregates scanning quality for receipts uploaded since the given time.
+// Manual receipts are left out. A parsed receipt's total is reconciled against the sum of its
+// kept line prices; a relative difference above tolerance counts as a mismatch. Lines read
+// below minConfidence count as low confidence.
+func (db *DB) GetReceiptOCRMetrics(ctx context.Context, since time.Time, tolerance, minConfidence float64) (*models.ReceiptOCRMetrics, error) {
+	m := &models.ReceiptOCRMetrics{Since: since, TotalTolerance: tolerance}
+
+	var processed, withText, parsed, extracted, confirmedExtracted, kept, autoMatched, autoKept, scored int
+	var confidenceSum float64
+	err := db.Pool.QueryRow(ctx, `
+		WITH per_receipt AS (
+			SELECT r.id, r.status, r.ocr_text IS NOT NULL AS has_text, r.receipt_total,
+			       COUNT(ri.id) AS extracted,
+			       COUNT(ri.id) FILTER (WHERE ri.match_status <> 'skipped') AS kept,
+			       COUNT(ri.id) FILTER (WHERE ri.match_status <> 'skipped' AND ri.matched_item_id IS NOT NULL) AS auto_matched,
+			       COUNT(ri.id) FILTER (WHERE ri.match_status <> 'skipped' AND ri.confirmed_item_id = ri.matched_item_id) AS auto_kept,
+			       COALESCE(SUM(ri.extracted_price) FILTER (WHERE ri.match_status <> 'skipped'), 0) AS line_sum,
+			       COUNT(ri.ocr_confidence) AS scored,
+			       COALESCE(SUM(ri.ocr_confidence), 0) AS confidence_sum,
+			       COUNT(ri.id) FILTER (WHERE ri.ocr_confidence < $3) AS low_confidence
+			FROM receipts r
+			LEFT JOIN receipt_items ri ON ri.receipt_id = r.id
+			WHERE r.s3_key <> '' AND r.uploaded_at >= $1
+			GROUP BY r.id
+		)
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status IN ('pending', 'processing')),
+		       COUNT(*) FILTER (WHERE status = 'failed'),
+		       COUNT(*) FILTER (WHERE status = 'confirmed'),
+		       COUNT(*) FILTER (WHERE status NOT IN ('pending', 'processing')),
+		       COUNT(*) FILTER (WHERE has_text AND status NOT IN ('pending', 'processing')),
+		       COUNT(*) FILTER (WHERE status IN ('completed', 'confirmed')),
+		       COALESCE(SUM(extracted) FILTER (WHERE status IN ('completed', 'confirmed')), 0),
+		       COALESCE(SUM(extracted) FILTER (WHERE status = 'confirmed'), 0),
+		       COALESCE(SUM(kept) FILTER (WHERE status = 'confirmed'), 0),
+		       COALESCE(SUM(auto_matched) FILTER (WHERE status = 'confirmed'), 0),
+		       COALESCE(SUM(auto_kept) FILTER (WHERE status = 'confirmed'), 0),
+		       COALESCE(SUM(scored), 0),
+		       COALESCE(SUM(confidence_sum), 0),
+		       COALESCE(SUM(low_confidence), 0),
+		       COUNT(*) FILTER (WHERE status IN ('completed', 'confirmed') AND receipt_total > 0),
+		       COUNT(*) FILTER (WHERE status IN ('completed', 'confirmed') AND receipt_total > 0
+		                        AND ABS(line_sum - receipt_total) > receipt_total * $2)
+		FROM per_receipt
+	`, since, tolerance, minConfidence).Scan(
+		&m.Receipts, &m.Processing, &m.Failed, &m.Confirmed,
+		&processed, &withText, &parsed,
+		&extracted, &confirmedExtracted, &kept, &autoMatched, &autoKept,
+		&scored, &confidenceSum, &m.LowConfidenceItems,
+		&m.ReconciledReceipts, &m.TotalMismatches,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if processed > 0 {
+		m.OCRSuccessRate = float64(withText) / float64(processed)
+	}
+	if withText > 0 {
+		m.ParseSuccessRate = float64(parsed) / float64(withText)
+	}
+	if parsed > 0 {
+		m.AvgItemsExtracted = float64(extracted) / float64(parsed)
+	}
+	if m.Confirmed > 0 {
+		m.AvgItemsConfirmed = float64(kept) / float64(m.Confirmed)
+	}
+	if confirmedExtracted > 0 {
+		m.ItemConfirmRate = float64(kept) / float64(confirmedExtracted)
+	}
+	if autoMatched > 0 {
+		m.AutoMatchAccuracy = float64(autoKept) / float64(autoMatched)
+	}
+	if scored > 0 {
+		avg := confidenceSum / float64(scored)
+		m.AvgOCRConfidence = &avg
+	}
+	if m.ReconciledReceipts > 0 {
+		m.TotalMismatchRate = float64(m.TotalMismatches) / float64(m.ReconciledReceipts)
+	}
+
+	return m, nil
+}
+
+// UpdateReceiptMetadata updates extracted metadata and records where the date came from
+func (db *DB) UpdateReceiptMetadata(ctx context.Context, id int, receiptDate *time.Time, dateSource *string, total *float64) error {
 	_, err := db.Pool.Exec(ctx, `
 		UPDATE receipts
-		SET receipt_date = $2, receipt_total = $3, updated_at = NOW()
+		SET receipt_date = $2, receipt_date_source = $3, receipt_total = $4, updated_at = NOW()
 		WHERE id = $1
-	`, id, receiptDate, total)
+	`, id, receiptDate, dateSource, total)
 
 	return err
 }
@@ -213,18 +347,18 @@ func (db *DB) CreateReceiptItem(ctx context.Context, req *models.CreateReceiptIt
 
 	err := db.Pool.QueryRow(ctx, `
 		INSERT INTO receipt_items (receipt_id, raw_text, extracted_name, extracted_price, extracted_quantity,
-		                          matched_item_id, match_confidence, match_status, line_number)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		                          matched_item_id, match_confidence, match_status, line_number, ocr_confidence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, receipt_id, raw_text, extracted_name, extracted_price, extracted_quantity,
 		          matched_item_id, match_confidence, match_status,
 		          confirmed_item_id, confirmed_price, is_confirmed, created_item_id,
-		          line_number, created_at, updated_at
+		          line_number, ocr_confidence, created_at, updated_at
 	`, req.ReceiptID, req.RawText, req.ExtractedName, req.ExtractedPrice, req.ExtractedQuantity,
-		req.MatchedItemID, req.MatchConfidence, req.MatchStatus, req.LineNumber).Scan(
+		req.MatchedItemID, req.MatchConfidence, req.MatchStatus, req.LineNumber, req.OCRConfidence).Scan(
 		&item.ID, &item.ReceiptID, &item.RawText, &item.ExtractedName, &item.ExtractedPrice, &item.ExtractedQuantity,
 		&item.MatchedItemID, &item.MatchConfidence, &item.MatchStatus,
 		&item.ConfirmedItemID, &item.ConfirmedPrice, &item.IsConfirmed, &item.CreatedItemID,
-		&item.LineNumber, &item.CreatedAt, &item.UpdatedAt,
+		&item.LineNumber, &item.OCRConfidence, &item.CreatedAt, &item.UpdatedAt,
 	)
 
 	if err != nil {
@@ -234,6 +368,12 @@ func (db *DB) CreateReceiptItem(ctx context.Context, req *models.CreateReceiptIt
 	return item, nil
 }
 
+// DeleteReceiptItems removes all parsed items of a receipt before it is processed again
+func (db *DB) DeleteReceiptItems(ctx context.Context, receiptID int) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM receipt_items WHERE receipt_id = $1`, receiptID)
+	return err
+}
+
 // UpdateReceiptItem updates a receipt item with user confirmation
 func (db *DB) UpdateReceiptItem(ctx context.Context, id int, req *models.UpdateReceiptItemRequest) (*models.ReceiptItem, error) {
 	item := &models.ReceiptItem{}
@@ -249,12 +389,12 @@ func (db *DB) UpdateReceiptItem(ctx context.Context, id int, req *models.UpdateR
 		RETURNING id, receipt_id, raw_text, extracted_name, extracted_price, extracted_quantity,
 		          matched_item_id, match_confidence, match_status,
 		          confirmed_item_id, confirmed_price, is_confirmed, created_item_id,
-		          line_number, created_at, updated_at
+		          line_number, ocr_confidence, created_at, updated_at
 	`, id, req.ConfirmedItemID, req.ConfirmedPrice, req.MatchStatus, req.IsConfirmed).Scan(
 		&item.ID, &item.ReceiptID, &item.RawText, &item.ExtractedName, &item.ExtractedPrice, &item.ExtractedQuantity,
 		&item.MatchedItemID, &item.MatchConfidence, &item.MatchStatus,
 		&item.ConfirmedItemID, &item.ConfirmedPrice, &item.IsConfirmed, &item.CreatedItemID,
-		&item.LineNumber, &item.CreatedAt, &item.UpdatedAt,
+		&item.LineNumber, &item.OCRConfidence, &item.CreatedAt, &item.UpdatedAt,
 	)
 
 	if err != nil {
@@ -267,22 +407,49 @@ func (db *DB) UpdateReceiptItem(ctx context.Context, id int, req *models.UpdateR
 	return item, nil
 }
 
-// ConfirmReceipt confirms all items and creates prices
-func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, userID int, items []models.ConfirmReceiptItemData) error {
+// ConfirmReceipt confirms all items and creates or updates their prices, returning the stored prices.
+// A receipt can only be confirmed once; confirming it rewards the user with rewardPoints. With
+// addToInventory each confirmed catalog item is also added to the user's inventory as bought on
+// the receipt date.
+func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, userID int, shared bool, scope models.ReceiptPriceScope, items []models.ConfirmReceiptItemData, rewardPoints int, addToInventory bool) ([]models.PriceSubmission, error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	// Update receipt store and status
-	_, err = tx.Exec(ctx, `
+	var stored []models.PriceSubmission
+
+	var purchaseDate time.Time
+	if addToInventory {
+		err = tx.QueryRow(ctx, `SELECT COALESCE(receipt_date, CURRENT_DATE) FROM receipts WHERE id = $1`, receiptID).Scan(&purchaseDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Update receipt store and status. The row lock makes a concurrent confirmation wait and
+	// then find the receipt confirmed, so prices and inventory are only recorded once.
+	result, err := tx.Exec(ctx, `
 		UPDATE receipts
 		SET store_id = $2, status = 'confirmed', confirmed_at = NOW(), updated_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND status <> 'confirmed'
 	`, receiptID, storeID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrReceiptConfirmed
+	}
+
+	var priceItemIDs []int
+	for _, item := range items {
+		if !item.Skip && item.ItemID != nil && item.Price != nil {
+			priceItemIDs = append(priceItemIDs, *item.ItemID)
+		}
+	}
+	if err := lockItemsForPriceWrites(ctx, tx, priceItemIDs); err != nil {
+		return nil, err
 	}
 
 	// Process each item
@@ -294,7 +461,7 @@ func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, us
 				WHERE id = $1
 			`, item.ReceiptItemID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			continue
 		}
@@ -305,12 +472,12 @@ func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, us
 		if item.CreateNewItem && item.NewItemName != nil {
 			// Create new item
 			err = tx.QueryRow(ctx, `
-				INSERT INTO items (name, created_by, created_at, updated_at)
-				VALUES ($1, $2, NOW(), NOW())
+				INSERT INTO items (name, raw_name, created_by, created_at, updated_at)
+				VALUES ($1, (SELECT raw_text FROM receipt_items WHERE id = $3), $2, NOW(), NOW())
 				RETURNING id
-			`, *item.NewItemName, userID).Scan(&itemID)
+			`, *item.NewItemName, userID, item.ReceiptItemID).Scan(&itemID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// Update receipt item with created item ID
@@ -318,7 +485,7 @@ func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, us
 				UPDATE receipt_items SET created_item_id = $2, match_status = 'new_item' WHERE id = $1
 			`, item.ReceiptItemID, itemID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		} else if item.ItemID != nil {
 			itemID = *item.ItemID
@@ -327,29 +494,25 @@ func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, us
 			continue
 		}
 
+		if addToInventory {
+			if err := addReceiptItemToInventory(ctx, tx, userID, itemID, item.ReceiptItemID, purchaseDate); err != nil {
+				return nil, err
+			}
+		}
+
 		if item.Price != nil {
 			price = *item.Price
 		} else {
 			continue
 		}
 
-		// Create or update store price
-		_, err = tx.Exec(ctx, `
-			INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, true, NOW(), NOW())
-			ON CONFLICT (store_id, item_id) WHERE store_id = $1 AND item_id = $2
-			DO UPDATE SET price = $3, user_id = $4, updated_at = NOW()
-		`, storeID, itemID, price, userID)
+		priceID, err := upsertReceiptPrice(ctx, tx, storeID, itemID, userID, price, shared, scope)
 		if err != nil {
-			// If conflict handling fails, try simple insert/update
-			_, err = tx.Exec(ctx, `
-				INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, true, NOW(), NOW())
-			`, storeID, itemID, price, userID)
-			if err != nil {
-				return err
-			}
+			return nil, err
 		}
+		stored = append(stored, models.PriceSubmission{
+			PriceID: priceID, StoreID: storeID, ItemID: itemID, Price: price, UserID: userID, IsShared: shared,
+		})
 
 		// Update receipt item as confirmed
 		_, err = tx.Exec(ctx, `
@@ -358,11 +521,147 @@ func (db *DB) ConfirmReceipt(ctx context.Context, receiptID int, storeID int, us
 			WHERE id = $1
 		`, item.ReceiptItemID, itemID, price)
 		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := awardReputation(ctx, tx, userID, rewardPoints, models.ReputationReasonReceiptConfirmed, &receiptID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// addReceiptItemToInventory adds a receipt line's quantity to the user's inventory row for the
+// catalog item, creating one when there is none, and records the purchase date
+func addReceiptItemToInventory(ctx context.Context, tx pgx.Tx, userID, itemID, receiptItemID int, purchaseDate time.Time) error {
+	var quantity float64
+	err := tx.QueryRow(ctx, `
+		SELECT GREATEST(COALESCE(extracted_quantity, 1), 1) FROM receipt_items WHERE id = $1
+	`, receiptItemID).Scan(&quantity)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
 			return err
 		}
+		quantity = 1
+	}
+
+	_, err = restockInventory(ctx, tx, userID, itemID, quantity, purchaseDate, true, models.InventoryAdjustmentReceipt)
+	return err
+}
+
+// upsertReceiptPrice updates the store price a receipt line replaces, or adds one when there is
+// none, and records the change in price_history. store_prices has no unique key to upsert
+// against: CreatePrice adds a row per submission, so a contributor can already hold several
+// rows for the same store and item and a constraint could not be added without rewriting
+// their history. Writers for the same store and item are instead serialized with a
+// transaction-scoped advisory lock so repeated or concurrent confirmations update in place
+// rather than adding duplicates.
+func upsertReceiptPrice(ctx context.Context, tx pgx.Tx, storeID, itemID, userID int, price float64, shared bool, scope models.ReceiptPriceScope) (int, error) {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1::int, $2::int)`, storeID, itemID); err != nil {
+		return 0, err
+	}
+
+	// The store_item scope may also refresh the latest shared price, but never another
+	// contributor's private one, and only a shared submission may replace a shared price
+	anyShared := scope == models.ReceiptPriceScopeStoreItem && shared
+
+	var priceID, ownerID int
+	var previous *float64
+	err := tx.QueryRow(ctx, `
+		SELECT id, price, COALESCE(user_id, 0) FROM store_prices
+		WHERE store_id = $1 AND item_id = $2 AND (user_id = $3 OR ($4 AND is_shared = true))
+		ORDER BY updated_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, storeID, itemID, userID, anyShared).Scan(&priceID, &previous, &ownerID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		err = tx.QueryRow(ctx, `
+			INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, share_on_verify, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, NOT $5, NOW(), NOW())
+			RETURNING id
+		`, storeID, itemID, price, userID, shared).Scan(&priceID)
+	case err == nil && ownerID == userID:
+		_, err = tx.Exec(ctx, `
+			UPDATE store_prices
+			SET price = $2, is_shared = $3, share_on_verify = NOT $3, updated_at = NOW()
+			WHERE id = $1
+		`, priceID, price, shared)
+	case err == nil:
+		// Another contributor's shared price keeps its author and sharing; only the amount moves
+		_, err = tx.Exec(ctx, `
+			UPDATE store_prices SET price = $2, updated_at = NOW() WHERE id = $1
+		`, priceID, price)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO price_history (store_id, item_id, price, previous_price, user_id, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, storeID, itemID, price, previous, userID)
+	return priceID, err
+}
+
+// ListRematchCandidates returns unconfirmed receipt items whose match can still change:
+// pending or auto-matched lines on receipts that are not confirmed. A nil receiptID
+// returns candidates across all receipts, oldest first, up to limit.
+func (db *DB) ListRematchCandidates(ctx context.Context, receiptID *int, limit int) ([]models.ReceiptItem, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ri.id, ri.receipt_id, ri.raw_text, ri.extracted_name, ri.extracted_price, ri.extracted_quantity,
+		       ri.matched_item_id, ri.match_confidence, ri.match_status,
+		       ri.confirmed_item_id, ri.confirmed_price, ri.is_confirmed, ri.created_item_id,
+		       ri.line_number, ri.ocr_confidence, ri.created_at, ri.updated_at
+		FROM receipt_items ri
+		JOIN receipts r ON ri.receipt_id = r.id
+		WHERE ri.is_confirmed = false
+		AND ri.match_status IN ('pending', 'matched')
+		AND r.status <> 'confirmed'
+		AND ($1::int IS NULL OR ri.receipt_id = $1)
+		ORDER BY ri.receipt_id ASC, ri.line_number ASC, ri.id ASC
+		LIMIT $2
+	`, receiptID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.ReceiptItem
+	for rows.Next() {
+		var item models.ReceiptItem
+		err := rows.Scan(
+			&item.ID, &item.ReceiptID, &item.RawText, &item.ExtractedName, &item.ExtractedPrice, &item.ExtractedQuantity,
+			&item.MatchedItemID, &item.MatchConfidence, &item.MatchStatus,
+			&item.ConfirmedItemID, &item.ConfirmedPrice, &item.IsConfirmed, &item.CreatedItemID,
+			&item.LineNumber, &item.OCRConfidence, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdateReceiptItemMatch stores a new automatic match for a receipt item.
+// Items confirmed in the meantime are left untouched; returns whether a row was updated.
+func (db *DB) UpdateReceiptItemMatch(ctx context.Context, id int, matchedItemID int, confidence float64) (bool, error) {
+	result, err := db.Pool.Exec(ctx, `
+		UPDATE receipt_items
+		SET matched_item_id = $2, match_confidence = $3, match_status = 'matched', updated_at = NOW()
+		WHERE id = $1 AND is_confirmed = false AND match_status IN ('pending', 'matched')
+	`, id, matchedItemID, confidence)
+	if err != nil {
+		return false, err
 	}
 
-	return tx.Commit(ctx)
+	return result.RowsAffected() > 0, nil
 }
 
 // DeleteReceipt deletes a receipt and its items
@@ -379,8 +678,9 @@ func (db *DB) DeleteReceipt(ctx context.Context, id int) error {
 	return nil
 }
 
-// CreateManualReceipt creates a receipt with manually entered items (no image)
-func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.CreateManualReceiptRequest) (*models.ReceiptWithItems, error) {
+// CreateManualReceipt creates a receipt with manually entered items (no image). Prices for
+// matched items are shared when shared is set.
+func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.CreateManualReceiptRequest, shared bool, scope models.ReceiptPriceScope) (*models.ReceiptWithItems, error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -389,10 +689,13 @@ func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.C
 
 	// Parse the receipt date if provided
 	var receiptDate *time.Time
+	var dateSource *string
 	if req.ReceiptDate != nil && *req.ReceiptDate != "" {
 		parsed, err := time.Parse("2006-01-02", *req.ReceiptDate)
 		if err == nil {
 			receiptDate = &parsed
+			source := models.ReceiptDateSourceManual
+			dateSource = &source
 		}
 	}
 
@@ -413,14 +716,24 @@ func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.C
 	// Create receipt record (manual entry - no S3 bucket/key, immediately confirmed)
 	var receiptID int
 	err = tx.QueryRow(ctx, `
-		INSERT INTO receipts (user_id, store_id, s3_bucket, s3_key, status, receipt_date, receipt_total, confirmed_at)
-		VALUES ($1, $2, '', '', 'confirmed', $3, $4, NOW())
+		INSERT INTO receipts (user_id, store_id, s3_bucket, s3_key, status, receipt_date, receipt_date_source, receipt_total, confirmed_at)
+		VALUES ($1, $2, '', '', 'confirmed', $3, $4, $5, NOW())
 		RETURNING id
-	`, userID, req.StoreID, receiptDate, total).Scan(&receiptID)
+	`, userID, req.StoreID, receiptDate, dateSource, total).Scan(&receiptID)
 	if err != nil {
 		return nil, err
 	}
 
+	var priceItemIDs []int
+	for _, item := range req.Items {
+		if item.ItemID != nil {
+			priceItemIDs = append(priceItemIDs, *item.ItemID)
+		}
+	}
+	if err := lockItemsForPriceWrites(ctx, tx, priceItemIDs); err != nil {
+		return nil, err
+	}
+
 	// Create receipt items and prices
 	for i, item := range req.Items {
 		qty := item.Quantity
@@ -445,11 +758,9 @@ func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.C
 
 		// Create store price if we have an item ID
 		if itemID != nil {
-			_, _ = tx.Exec(ctx, `
-				INSERT INTO store_prices (store_id, item_id, price, user_id, is_shared, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, true, NOW(), NOW())
-				ON CONFLICT (store_id, item_id) DO UPDATE SET price = $3, user_id = $4, updated_at = NOW()
-			`, req.StoreID, *itemID, item.Price, userID)
+			if _, err := upsertReceiptPrice(ctx, tx, req.StoreID, *itemID, userID, item.Price, shared, scope); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -462,9 +773,11 @@ func (db *DB) CreateManualReceipt(ctx context.Context, userID int, req *models.C
 
 // CleanupExpiredReceipts deletes receipts past their expiration date and returns S3 keys to delete
 func (db *DB) CleanupExpiredReceipts(ctx context.Context) ([]string, error) {
-	// Get S3 keys of expired receipts
+	// Get S3 keys of expired receipts and their thumbnails
 	rows, err := db.Pool.Query(ctx, `
 		SELECT s3_key FROM receipts WHERE expires_at < NOW()
+		UNION ALL
+		SELECT thumbnail_key FROM receipts WHERE expires_at < NOW() AND thumbnail_key IS NOT NULL
 	`)
 	if err != nil {
 		return nil, err
@@ -489,15 +802,28 @@ func (db *DB) CleanupExpiredReceipts(ctx context.Context) ([]string, error) {
 	return keys, nil
 }
 
-// FindSimilarItems finds items similar to the given name using trigram similarity
+// FindSimilarItems finds items similar to the given name using trigram similarity. Items created
+// from a receipt also match on the receipt text they were created from. The trigram indexes
+// narrow the candidates before they are scored.
 func (db *DB) FindSimilarItems(ctx context.Context, name string, limit int) ([]models.MatchResult, error) {
-	rows, err := db.Pool.Query(ctx, `
-		SELECT id, name, brand, similarity(LOWER(name), LOWER($1)) as confidence
-		FROM items
-		WHERE similarity(LOWER(name), LOWER($1)) > 0.2
+	tx, err := db.beginTrigramSearch(ctx, searchSimilarityFloor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, name, brand, confidence
+		FROM (
+			SELECT id, name, brand,
+				GREATEST(similarity(LOWER(name), LOWER($1)), COALESCE(similarity(LOWER(raw_name), LOWER($1)), 0)) as confidence
+			FROM items
+			WHERE name % $1 OR raw_name % $1
+		) s
+		WHERE confidence > $3
 		ORDER BY confidence DESC
 		LIMIT $2
-	`, name, limit)
+	`, name, limit, searchSimilarityFloor)
 	if err != nil {
 		return nil, err
 	}
@@ -518,8 +844,8 @@ func (db *DB) FindSimilarItems(ctx context.Context, name string, limit int) ([]m
 }
 
 // GetSpendingSummary returns monthly spending summary for a user
-// Includes both receipts and completed shopping lists
-func (db *DB) GetSpendingSummary(ctx context.Context, userID int, months int) (*models.SpendingSummary, error) {
+// Includes both receipts and completed shopping lists, priced at each item's best price
+func (db *DB) GetSpendingSummary(ctx context.Context, userID int, months int, denormalized bool) (*models.SpendingSummary, error) {
 	// Query combines receipts and completed shopping lists
 	rows, err := db.Pool.Query(ctx, `
 		WITH spending_data AS (
@@ -544,10 +870,7 @@ func (db *DB) GetSpendingSummary(ctx context.Context, userID int, months int) (*
 				0 as store_id,
 				'Shopping Lists' as store_name,
 				COALESCE((
-					SELECT SUM(sli.quantity * COALESCE(
-						(SELECT MIN(sp.price) FROM store_prices sp WHERE sp.item_id = sli.item_id),
-						0
-					))
+					SELECT SUM(sli.quantity * COALESCE(`+bestPriceSQL("sli.item_id", denormalized)+`, 0))
 					FROM shopping_list_items sli
 					WHERE sli.list_id = sl.id
 				), 0) as total,
@@ -635,3 +958,54 @@ func (db *DB) GetSpendingSummary(ctx context.Context, userID int, months int) (*
 
 	return &result, nil
 }
+
+// FindUnreferencedReceiptKeys returns the keys that no receipt row points at, as image or thumbnail
+func (db *DB) FindUnreferencedReceiptKeys(ctx context.Context, keys []string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT k
+		FROM unnest($1::text[]) AS k
+		WHERE NOT EXISTS (SELECT 1 FROM receipts r WHERE r.s3_key = k OR r.thumbnail_key = k)
+	`, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unreferenced []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		unreferenced = append(unreferenced, key)
+	}
+
+	return unreferenced, rows.Err()
+}
+
+// ListReceiptObjectRefs returns up to limit receipts with a stored image, ordered by id after afterID.
+// Only receipts created before createdBefore are included. Manual receipts have no object and are skipped.
+func (db *DB) ListReceiptObjectRefs(ctx context.Context, afterID, limit int, createdBefore time.Time) ([]models.ReceiptObjectRef, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, s3_key
+		FROM receipts
+		WHERE id > $1 AND s3_key <> '' AND created_at < $3
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []models.ReceiptObjectRef
+	for rows.Next() {
+		var ref models.ReceiptObjectRef
+		if err := rows.Scan(&ref.ReceiptID, &ref.UserID, &ref.S3Key); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
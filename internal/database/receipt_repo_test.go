@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestUpsertReceiptPriceScope(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		existingShare bool
+		scope         models.ReceiptPriceScope
+		shared        bool
+		wantReplace   bool
+	}{
+		{"store item scope keeps other private price", false, models.ReceiptPriceScopeStoreItem, true, false},
+		{"store item scope refreshes shared price keeping its author", true, models.ReceiptPriceScopeStoreItem, true, true},
+		{"private submission keeps shared price", true, models.ReceiptPriceScopeStoreItem, false, false},
+		{"contributor scope keeps shared price", true, models.ReceiptPriceScopeContributor, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := testUser(t, db)
+			user := testUser(t, db)
+			store := testStore(t, db, nil)
+			item := testItem(t, db, nil, nil)
+
+			existing, err := db.CreatePrice(ctx, &models.CreatePriceRequest{
+				StoreID: store.ID, ItemID: item.ID, Price: 3.00, IsShared: tt.existingShare,
+			}, &other.ID)
+			if err != nil {
+				t.Fatalf("create price: %v", err)
+			}
+
+			tx, err := db.Pool.Begin(ctx)
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			defer tx.Rollback(ctx)
+			priceID, err := upsertReceiptPrice(ctx, tx, store.ID, item.ID, user.ID, 2.50, tt.shared, tt.scope)
+			if err != nil {
+				t.Fatalf("upsertReceiptPrice: %v", err)
+			}
+			if err := tx.Commit(ctx); err != nil {
+				t.Fatalf("commit: %v", err)
+			}
+
+			if got := priceID == existing.ID; got != tt.wantReplace {
+				t.Fatalf("replaced existing price = %v, want %v", got, tt.wantReplace)
+			}
+
+			var price float64
+			var ownerID int
+			var isShared bool
+			err = db.Pool.QueryRow(ctx, `SELECT price, user_id, is_shared FROM store_prices WHERE id = $1`, existing.ID).
+				Scan(&price, &ownerID, &isShared)
+			if err != nil {
+				t.Fatalf("read existing price: %v", err)
+			}
+			if tt.wantReplace {
+				if price != 2.50 || ownerID != other.ID || !isShared {
+					t.Errorf("existing price = %.2f by user %d (shared %v), want 2.50 by user %d (shared)", price, ownerID, isShared, other.ID)
+				}
+				return
+			}
+			if price != 3.00 || ownerID != other.ID || isShared != tt.existingShare {
+				t.Errorf("existing price changed to %.2f by user %d (shared %v)", price, ownerID, isShared)
+			}
+		})
+	}
+
+	t.Run("updates own price in place", func(t *testing.T) {
+		user := testUser(t, db)
+		store := testStore(t, db, nil)
+		item := testItem(t, db, nil, nil)
+
+		var ids []int
+		for _, price := range []float64{2.00, 2.25} {
+			tx, err := db.Pool.Begin(ctx)
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			id, err := upsertReceiptPrice(ctx, tx, store.ID, item.ID, user.ID, price, false, models.ReceiptPriceScopeStoreItem)
+			if err != nil {
+				tx.Rollback(ctx)
+				t.Fatalf("upsertReceiptPrice: %v", err)
+			}
+			if err := tx.Commit(ctx); err != nil {
+				t.Fatalf("commit: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		if ids[0] != ids[1] {
+			t.Errorf("second upsert created price %d, want update of %d", ids[1], ids[0])
+		}
+	})
+}
+
+func TestConfirmReceiptOnlyOnce(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := testUser(t, db)
+	store := testStore(t, db, nil)
+	item := testItem(t, db, nil, nil)
+
+	receipt, err := db.CreateReceipt(ctx, &models.CreateReceiptRequest{UserID: user.ID, S3Bucket: "receipts", S3Key: testName("receipt")})
+	if err != nil {
+		t.Fatalf("CreateReceipt: %v", err)
+	}
+	line, err := db.CreateReceiptItem(ctx, &models.CreateReceiptItemRequest{ReceiptID: receipt.ID, RawText: "MILK 2.49", MatchStatus: models.MatchStatusPending, LineNumber: 1})
+	if err != nil {
+		t.Fatalf("CreateReceiptItem: %v", err)
+	}
+
+	price := 2.49
+	items := []models.ConfirmReceiptItemData{{ReceiptItemID: line.ID, ItemID: &item.ID, Price: &price}}
+	if _, err := db.ConfirmReceipt(ctx, receipt.ID, store.ID, user.ID, true, models.ReceiptPriceScopeContributor, items, 0, true); err != nil {
+		t.Fatalf("first ConfirmReceipt: %v", err)
+	}
+	if _, err := db.ConfirmReceipt(ctx, receipt.ID, store.ID, user.ID, true, models.ReceiptPriceScopeContributor, items, 0, true); !errors.Is(err, ErrReceiptConfirmed) {
+		t.Fatalf("second ConfirmReceipt: got %v, want ErrReceiptConfirmed", err)
+	}
+
+	var quantity float64
+	err = db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM(quantity), 0)::float8 FROM inventory_items WHERE user_id = $1 AND item_id = $2
+	`, user.ID, item.ID).Scan(&quantity)
+	if err != nil {
+		t.Fatalf("read inventory: %v", err)
+	}
+	if quantity != 1 {
+		t.Errorf("inventory quantity = %v, want 1", quantity)
+	}
+}
@@ -14,6 +14,7 @@ import (
 var (
 	ErrRegionNotFound = errors.New("region not found")
 	ErrRegionExists   = errors.New("region already exists")
+	ErrReassignTarget = errors.New("reassignment region not found")
 )
 
 // ListRegions returns a paginated list of regions with optional filtering
@@ -54,7 +55,7 @@ func (db *DB) ListRegions(ctx context.Context, params *models.RegionListParams)
 	// Get regions with stats
 	query := fmt.Sprintf(`
 		SELECT
-			r.id, r.name, r.state, r.zip_codes, r.created_at, r.updated_at,
+			r.id, r.name, r.state, r.zip_codes, r.country_code, r.currency, r.locale, r.cost_of_living_index, r.created_at, r.updated_at,
 			COALESCE((SELECT COUNT(*) FROM stores WHERE region_id = r.id), 0) as store_count,
 			COALESCE((SELECT COUNT(*) FROM users WHERE region_id = r.id), 0) as user_count,
 			COALESCE((SELECT COUNT(*) FROM store_prices sp
@@ -82,6 +83,10 @@ func (db *DB) ListRegions(ctx context.Context, params *models.RegionListParams)
 			&r.Name,
 			&r.State,
 			&r.ZipCodes,
+			&r.Country,
+			&r.Currency,
+			&r.Locale,
+			&r.CostOfLivingIndex,
 			&r.CreatedAt,
 			&r.UpdatedAt,
 			&r.StoreCount,
@@ -103,7 +108,7 @@ func (db *DB) GetRegionByID(ctx context.Context, id int) (*models.RegionWithStat
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT
-			r.id, r.name, r.state, r.zip_codes, r.created_at, r.updated_at,
+			r.id, r.name, r.state, r.zip_codes, r.country_code, r.currency, r.locale, r.cost_of_living_index, r.created_at, r.updated_at,
 			COALESCE((SELECT COUNT(*) FROM stores WHERE region_id = r.id), 0) as store_count,
 			COALESCE((SELECT COUNT(*) FROM users WHERE region_id = r.id), 0) as user_count,
 			COALESCE((SELECT COUNT(*) FROM store_prices sp
@@ -116,6 +121,10 @@ func (db *DB) GetRegionByID(ctx context.Context, id int) (*models.RegionWithStat
 		&r.Name,
 		&r.State,
 		&r.ZipCodes,
+		&r.Country,
+		&r.Currency,
+		&r.Locale,
+		&r.CostOfLivingIndex,
 		&r.CreatedAt,
 		&r.UpdatedAt,
 		&r.StoreCount,
@@ -133,6 +142,21 @@ func (db *DB) GetRegionByID(ctx context.Context, id int) (*models.RegionWithStat
 	return r, nil
 }
 
+// GetReceiptLocale returns the locale of the region a receipt was most likely printed in: the
+// store's region when the store is known, otherwise the uploader's region. Returns "" when
+// neither has a region.
+func (db *DB) GetReceiptLocale(ctx context.Context, storeID *int, userID int) (string, error) {
+	var locale string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(
+			(SELECT r.locale FROM stores s JOIN regions r ON r.id = s.region_id WHERE s.id = $1),
+			(SELECT r.locale FROM users u JOIN regions r ON r.id = u.region_id WHERE u.id = $2),
+			''
+		)
+	`, storeID, userID).Scan(&locale)
+	return locale, err
+}
+
 // CreateRegion creates a new region
 func (db *DB) CreateRegion(ctx context.Context, req *models.CreateRegionRequest) (*models.Region, error) {
 	region := &models.Region{}
@@ -141,14 +165,18 @@ func (db *DB) CreateRegion(ctx context.Context, req *models.CreateRegionRequest)
 	state := strings.ToUpper(req.State)
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO regions (name, state, zip_codes, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, name, state, zip_codes, created_at, updated_at
-	`, req.Name, state, req.ZipCodes).Scan(
+		INSERT INTO regions (name, state, zip_codes, country_code, currency, locale, cost_of_living_index, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, name, state, zip_codes, country_code, currency, locale, cost_of_living_index, created_at, updated_at
+	`, req.Name, state, req.ZipCodes, req.Country, req.Currency, req.Locale, req.CostOfLivingIndex).Scan(
 		&region.ID,
 		&region.Name,
 		&region.State,
 		&region.ZipCodes,
+		&region.Country,
+		&region.Currency,
+		&region.Locale,
+		&region.CostOfLivingIndex,
 		&region.CreatedAt,
 		&region.UpdatedAt,
 	)
@@ -176,14 +204,22 @@ func (db *DB) UpdateRegion(ctx context.Context, id int, req *models.UpdateRegion
 		SET name = COALESCE($2, name),
 		    state = COALESCE($3, state),
 		    zip_codes = COALESCE($4, zip_codes),
+		    country_code = COALESCE($5, country_code),
+		    currency = COALESCE($6, currency),
+		    locale = COALESCE($7, locale),
+		    cost_of_living_index = COALESCE($8, cost_of_living_index),
 		    updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, state, zip_codes, created_at, updated_at
-	`, id, req.Name, state, req.ZipCodes).Scan(
+		RETURNING id, name, state, zip_codes, country_code, currency, locale, cost_of_living_index, created_at, updated_at
+	`, id, req.Name, state, req.ZipCodes, req.Country, req.Currency, req.Locale, req.CostOfLivingIndex).Scan(
 		&region.ID,
 		&region.Name,
 		&region.State,
 		&region.ZipCodes,
+		&region.Country,
+		&region.Currency,
+		&region.Locale,
+		&region.CostOfLivingIndex,
 		&region.CreatedAt,
 		&region.UpdatedAt,
 	)
@@ -198,18 +234,74 @@ func (db *DB) UpdateRegion(ctx context.Context, id int, req *models.UpdateRegion
 	return region, nil
 }
 
-// DeleteRegion deletes a region by ID
-func (db *DB) DeleteRegion(ctx context.Context, id int) error {
-	result, err := db.Pool.Exec(ctx, `DELETE FROM regions WHERE id = $1`, id)
+// GetRegionReferences counts the stores, users and feed entries assigned to a region
+func (db *DB) GetRegionReferences(ctx context.Context, id int) (*models.RegionReferences, error) {
+	refs := &models.RegionReferences{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM stores WHERE region_id = $1),
+			(SELECT COUNT(*) FROM users WHERE region_id = $1),
+			(SELECT COUNT(*) FROM price_feed WHERE region_id = $1)
+	`, id).Scan(&refs.Stores, &refs.Users, &refs.FeedItems)
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// DeleteRegion deletes a region, first moving its stores, users and feed entries to reassignTo
+// in the same transaction. A nil reassignTo clears those references instead; callers decide
+// whether that is allowed. Returns how many rows were moved.
+func (db *DB) DeleteRegion(ctx context.Context, id int, reassignTo *int) (*models.DeleteRegionResult, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if reassignTo != nil {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM regions WHERE id = $1)`, *reassignTo).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrReassignTarget
+		}
+	}
+
+	result := &models.DeleteRegionResult{RegionID: id, ReassignedTo: reassignTo}
+
+	tag, err := tx.Exec(ctx, `UPDATE stores SET region_id = $2, updated_at = NOW() WHERE region_id = $1`, id, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+	result.Reassigned.Stores = int(tag.RowsAffected())
+
+	tag, err = tx.Exec(ctx, `UPDATE users SET region_id = $2, updated_at = NOW() WHERE region_id = $1`, id, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+	result.Reassigned.Users = int(tag.RowsAffected())
+
+	tag, err = tx.Exec(ctx, `UPDATE price_feed SET region_id = $2 WHERE region_id = $1`, id, reassignTo)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result.Reassigned.FeedItems = int(tag.RowsAffected())
+
+	tag, err = tx.Exec(ctx, `DELETE FROM regions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrRegionNotFound
 	}
 
-	if result.RowsAffected() == 0 {
-		return ErrRegionNotFound
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return result, nil
 }
 
 // GetDistinctStates returns all unique states that have regions
@@ -260,7 +352,7 @@ func (db *DB) GetRegionStats(ctx context.Context) (map[string]int, error) {
 // SearchRegions performs a fuzzy search on regions
 func (db *DB) SearchRegions(ctx context.Context, query string, limit int) ([]*models.Region, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, name, state, zip_codes, created_at, updated_at
+		SELECT id, name, state, zip_codes, country_code, currency, locale, cost_of_living_index, created_at, updated_at
 		FROM regions
 		WHERE name ILIKE $1 OR state ILIKE $1 OR $2 = ANY(zip_codes)
 		ORDER BY
@@ -277,7 +369,7 @@ func (db *DB) SearchRegions(ctx context.Context, query string, limit int) ([]*mo
 	var regions []*models.Region
 	for rows.Next() {
 		r := &models.Region{}
-		if err := rows.Scan(&r.ID, &r.Name, &r.State, &r.ZipCodes, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.Name, &r.State, &r.ZipCodes, &r.Country, &r.Currency, &r.Locale, &r.CostOfLivingIndex, &r.CreatedAt, &r.UpdatedAt); err != nil {
 			return nil, err
 		}
 		regions = append(regions, r)
@@ -285,3 +377,78 @@ func (db *DB) SearchRegions(ctx context.Context, query string, limit int) ([]*mo
 
 	return regions, nil
 }
+
+// FindRegionForLocation picks the region for a new store: the region listing its zip code, or
+// failing that the region of the nearest store in the same state. Returns nil when neither applies.
+func (db *DB) FindRegionForLocation(ctx context.Context, state, zipCode string, lat, lng *float64) (*int, error) {
+	var regionID *int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(
+			(SELECT id FROM regions WHERE LEFT($2, 5) = ANY(zip_codes) ORDER BY id LIMIT 1),
+			(SELECT o.region_id
+			 FROM stores o
+			 WHERE $3::float8 IS NOT NULL AND $4::float8 IS NOT NULL
+			   AND o.region_id IS NOT NULL AND o.state = UPPER($1)
+			   AND o.latitude IS NOT NULL AND o.longitude IS NOT NULL
+			 ORDER BY (o.latitude - $3::float8) ^ 2
+			        + ((o.longitude - $4::float8) * cos(radians($3::float8))) ^ 2
+			 LIMIT 1)
+		)
+	`, state, zipCode, lat, lng).Scan(&regionID)
+	return regionID, err
+}
+
+// GetRegionContributors ranks users by shared prices and public stores they added in a region
+func (db *DB) GetRegionContributors(ctx context.Context, regionID, limit, offset int) ([]*models.RegionContributor, int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH price_counts AS (
+			SELECT sp.user_id, COUNT(*) AS cnt
+			FROM store_prices sp
+			JOIN stores s ON sp.store_id = s.id
+			WHERE s.region_id = $1 AND s.is_private = false
+				AND sp.is_shared = true AND sp.user_id IS NOT NULL
+			GROUP BY sp.user_id
+		),
+		store_counts AS (
+			SELECT created_by AS user_id, COUNT(*) AS cnt
+			FROM stores
+			WHERE region_id = $1 AND is_private = false AND created_by IS NOT NULL
+			GROUP BY created_by
+		),
+		combined AS (
+			SELECT COALESCE(p.user_id, s.user_id) AS user_id,
+				COALESCE(p.cnt, 0) AS price_count,
+				COALESCE(s.cnt, 0) AS store_count
+			FROM price_counts p
+			FULL OUTER JOIN store_counts s ON p.user_id = s.user_id
+		)
+		SELECT u.id, u.username, u.reputation_points, COALESCE(u.hide_contributor_name, false),
+			c.price_count, c.store_count, COUNT(*) OVER() AS total
+		FROM combined c
+		JOIN users u ON c.user_id = u.id
+		ORDER BY c.price_count + c.store_count DESC, u.reputation_points DESC, u.id
+		LIMIT $2 OFFSET $3
+	`, regionID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	contributors := []*models.RegionContributor{}
+	total := 0
+	for rows.Next() {
+		var userID int
+		rc := &models.RegionContributor{}
+		if err := rows.Scan(
+			&userID, &rc.Username, &rc.ReputationPoints, &rc.ContributorHidden,
+			&rc.SharedPriceCount, &rc.StoresAdded, &total,
+		); err != nil {
+			return nil, 0, err
+		}
+		rc.UserID = &userID
+		rc.Rank = offset + len(contributors) + 1
+		contributors = append(contributors, rc)
+	}
+
+	return contributors, total, rows.Err()
+}
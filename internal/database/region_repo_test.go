@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func testRegion(t *testing.T, db *DB, locale string) *models.Region {
+	t.Helper()
+	region, err := db.CreateRegion(context.Background(), &models.CreateRegionRequest{
+		Name:     testName("region"),
+		State:    "TX",
+		ZipCodes: []string{},
+		Locale:   locale,
+	})
+	if err != nil {
+		t.Fatalf("create region: %v", err)
+	}
+	return region
+}
+
+func TestGetReceiptLocale(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	storeRegion := testRegion(t, db, "en-GB")
+	userRegion := testRegion(t, db, "de-DE")
+
+	user := testUser(t, db)
+	if _, err := db.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{RegionID: &userRegion.ID}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	regionless := testUser(t, db)
+
+	store := testStore(t, db, nil)
+	if _, err := db.UpdateStore(ctx, store.ID, nil, &models.UpdateStoreRequest{RegionID: &storeRegion.ID}); err != nil {
+		t.Fatalf("UpdateStore: %v", err)
+	}
+	storeWithoutRegion := testStore(t, db, nil)
+
+	tests := []struct {
+		name    string
+		storeID *int
+		userID  int
+		want    string
+	}{
+		{"store region wins", &store.ID, user.ID, "en-GB"},
+		{"uploader region without store", nil, user.ID, "de-DE"},
+		{"uploader region when store has none", &storeWithoutRegion.ID, user.ID, "de-DE"},
+		{"no region", nil, regionless.ID, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetReceiptLocale(ctx, tt.storeID, tt.userID)
+			if err != nil {
+				t.Fatalf("GetReceiptLocale: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetReceiptLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
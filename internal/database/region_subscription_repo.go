@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var (
+	ErrRegionSubscriptionNotFound = errors.New("region subscription not found")
+	ErrRegionSubscriptionExists   = errors.New("already subscribed to this region")
+)
+
+const regionSubscriptionColumns = `
+	s.id, s.user_id, s.region_id, r.name, s.frequency, s.categories, s.min_drop_percent::float8,
+	s.is_active, s.last_sent_at, s.created_at, s.updated_at`
+
+// scanRegionSubscription scans a row selected with regionSubscriptionColumns
+func scanRegionSubscription(row pgx.Row) (*models.RegionSubscription, error) {
+	s := &models.RegionSubscription{}
+	err := row.Scan(&s.ID, &s.UserID, &s.RegionID, &s.RegionName, &s.Frequency, &s.Categories, &s.MinDropPercent,
+		&s.IsActive, &s.LastSentAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRegionSubscriptionNotFound
+		}
+		return nil, err
+	}
+	if s.Categories == nil {
+		s.Categories = []string{}
+	}
+	return s, nil
+}
+
+// ListRegionSubscriptions returns a user's region digest subscriptions
+func (db *DB) ListRegionSubscriptions(ctx context.Context, userID int) ([]*models.RegionSubscription, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+regionSubscriptionColumns+`
+		FROM region_subscriptions s
+		JOIN regions r ON s.region_id = r.id
+		WHERE s.user_id = $1
+		ORDER BY r.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.RegionSubscription{}
+	for rows.Next() {
+		s, err := scanRegionSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+
+	return subs, rows.Err()
+}
+
+// GetRegionSubscription returns one of a user's region digest subscriptions
+func (db *DB) GetRegionSubscription(ctx context.Context, id, userID int) (*models.RegionSubscription, error) {
+	return scanRegionSubscription(db.Pool.QueryRow(ctx, `
+		SELECT `+regionSubscriptionColumns+`
+		FROM region_subscriptions s
+		JOIN regions r ON s.region_id = r.id
+		WHERE s.id = $1 AND s.user_id = $2
+	`, id, userID))
+}
+
+// CreateRegionSubscription subscribes a user to a region digest; a user has at most one per region
+func (db *DB) CreateRegionSubscription(ctx context.Context, userID int, req *models.CreateRegionSubscriptionRequest) (*models.RegionSubscription, error) {
+	var id int
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO region_subscriptions (user_id, region_id, frequency, categories, min_drop_percent)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, region_id) DO NOTHING
+		RETURNING id
+	`, userID, req.RegionID, req.Frequency, req.Categories, req.MinDropPercent).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRegionSubscriptionExists
+		}
+		return nil, err
+	}
+
+	// Subscribing is an explicit opt-in to digest emails
+	if err := db.setRegionDigestPreference(ctx, userID, true); err != nil {
+		return nil, err
+	}
+
+	return db.GetRegionSubscription(ctx, id, userID)
+}
+
+// UpdateRegionSubscription changes the fields set in req
+func (db *DB) UpdateRegionSubscription(ctx context.Context, id, userID int, req *models.UpdateRegionSubscriptionRequest) (*models.RegionSubscription, error) {
+	var categories []string
+	if req.Categories != nil {
+		categories = *req.Categories
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE region_subscriptions
+		SET frequency = COALESCE($3, frequency),
+		    categories = CASE WHEN $4 THEN $5 ELSE categories END,
+		    min_drop_percent = COALESCE($6, min_drop_percent),
+		    is_active = COALESCE($7, is_active),
+		    updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, id, userID, req.Frequency, req.Categories != nil, categories, req.MinDropPercent, req.IsActive)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrRegionSubscriptionNotFound
+	}
+
+	return db.GetRegionSubscription(ctx, id, userID)
+}
+
+// DeleteRegionSubscription unsubscribes a user from a region digest
+func (db *DB) DeleteRegionSubscription(ctx context.Context, id, userID int) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM region_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRegionSubscriptionNotFound
+	}
+	return nil
+}
+
+// FindUnknownTagSlugs returns the slugs that do not name an existing tag
+func (db *DB) FindUnknownTagSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT u.slug FROM unnest($1::text[]) AS u(slug)
+		WHERE NOT EXISTS (SELECT 1 FROM tags t WHERE t.slug = u.slug)
+	`, slugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unknown := []string{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		unknown = append(unknown, slug)
+	}
+
+	return unknown, rows.Err()
+}
+
+// ListDueRegionSubscriptions returns active subscriptions of opted-in users whose next daily or
+// weekly digest is due
+func (db *DB) ListDueRegionSubscriptions(ctx context.Context, now time.Time) ([]*models.RegionSubscription, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+regionSubscriptionColumns+`
+		FROM region_subscriptions s
+		JOIN regions r ON s.region_id = r.id
+		JOIN notification_preferences np ON np.user_id = s.user_id
+		WHERE s.is_active = true AND np.region_digest_email = true
+		AND (
+			s.last_sent_at IS NULL
+			OR (s.frequency = 'daily' AND s.last_sent_at <= $1 - INTERVAL '1 day')
+			OR (s.frequency = 'weekly' AND s.last_sent_at <= $1 - INTERVAL '7 days')
+		)
+		ORDER BY s.id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.RegionSubscription{}
+	for rows.Next() {
+		s, err := scanRegionSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+
+	return subs, rows.Err()
+}
+
+// MarkRegionSubscriptionSent records when the subscription's digest period was last closed
+func (db *DB) MarkRegionSubscriptionSent(ctx context.Context, id int, sentAt time.Time) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE region_subscriptions SET last_sent_at = $2 WHERE id = $1`, id, sentAt)
+	return err
+}
+
+// GetRegionDigestDrops returns price decreases recorded after since at public stores in a region
+// that drop by at least minDropPercent, limited to items tagged with one of the category slugs
+// when any are given, largest relative drop first
+func (db *DB) GetRegionDigestDrops(ctx context.Context, regionID int, since time.Time, categories []string, minDropPercent float64, limit int) ([]models.WhatsNewPrice, error) {
+	if categories == nil {
+		categories = []string{}
+	}
+	for i := range categories {
+		categories[i] = strings.ToLower(categories[i])
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ph.store_id, s.name, ph.item_id, i.name, ph.price, ph.previous_price, ph.recorded_at
+		FROM price_history ph
+		JOIN stores s ON ph.store_id = s.id
+		JOIN items i ON ph.item_id = i.id
+		WHERE s.region_id = $1
+		AND s.is_private = false
+		AND ph.recorded_at > $2
+		AND ph.previous_price IS NOT NULL
+		AND ph.previous_price > 0
+		AND ph.price < ph.previous_price
+		AND (ph.previous_price - ph.price) / ph.previous_price * 100 >= $4
+		AND (cardinality($3::text[]) = 0 OR EXISTS (
+			SELECT 1 FROM item_tags it
+			JOIN tags t ON it.tag_id = t.id
+			WHERE it.item_id = ph.item_id AND t.slug = ANY($3)
+		))
+		ORDER BY (ph.previous_price - ph.price) / ph.previous_price DESC, ph.recorded_at DESC
+		LIMIT $5
+	`, regionID, since, categories, minDropPercent, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drops := []models.WhatsNewPrice{}
+	for rows.Next() {
+		var p models.WhatsNewPrice
+		if err := rows.Scan(&p.StoreID, &p.StoreName, &p.ItemID, &p.ItemName, &p.Price, &p.PreviousPrice, &p.ChangedAt); err != nil {
+			return nil, err
+		}
+		drops = append(drops, p)
+	}
+
+	return drops, rows.Err()
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// AwardReputation adds points to a user's reputation and records the reason in their ledger
+func (db *DB) AwardReputation(ctx context.Context, userID, points int, reason string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := awardReputation(ctx, tx, userID, points, reason, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// awardReputation records a ledger entry and adds its points to the user's total. With a
+// sourceID the award is made at most once per user, reason and source; it reports whether
+// points were added.
+func awardReputation(ctx context.Context, tx pgx.Tx, userID, points int, reason string, sourceID *int) (bool, error) {
+	if points == 0 {
+		return false, nil
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO reputation_events (user_id, points, reason, source_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, reason, source_id) WHERE source_id IS NOT NULL DO NOTHING
+	`, userID, points, reason, sourceID)
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET reputation_points = COALESCE(reputation_points, 0) + $2 WHERE id = $1
+	`, userID, points)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetUserReputation returns a user's reputation total and a page of their ledger, newest
+// first, along with the number of ledger entries
+func (db *DB) GetUserReputation(ctx context.Context, userID, limit, offset int) (*models.UserReputation, int, error) {
+	rep := &models.UserReputation{UserID: userID, Events: []*models.ReputationEvent{}}
+	var total int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(reputation_points, 0), (SELECT COUNT(*) FROM reputation_events WHERE user_id = $1)
+		FROM users WHERE id = $1
+	`, userID).Scan(&rep.Total, &total)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, ErrUserNotFound
+		}
+		return nil, 0, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, points, reason, source_id, created_at
+		FROM reputation_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &models.ReputationEvent{}
+		if err := rows.Scan(&e.ID, &e.Points, &e.Reason, &e.SourceID, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		rep.Events = append(rep.Events, e)
+	}
+
+	return rep, total, rows.Err()
+}
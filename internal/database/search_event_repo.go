@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// maxSearchQueryLength is the longest query text, in characters, kept in search_events
+const maxSearchQueryLength = 100
+
+// RecordSearchEvent stores an anonymized search: the normalized query text, kind, region and
+// result count. No user identity is kept.
+func (db *DB) RecordSearchEvent(ctx context.Context, kind, query string, regionID *int, resultCount int) error {
+	query = normalizeSearchQuery(query)
+	if query == "" {
+		return nil
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO search_events (kind, query, region_id, result_count)
+		VALUES ($1, $2, $3, $4)
+	`, kind, query, regionID, resultCount)
+	return err
+}
+
+// normalizeSearchQuery lowercases the query, collapses whitespace and truncates it to
+// maxSearchQueryLength characters without splitting a multi-byte character
+func normalizeSearchQuery(query string) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if runes := []rune(query); len(runes) > maxSearchQueryLength {
+		query = strings.TrimSpace(string(runes[:maxSearchQueryLength]))
+	}
+	return query
+}
+
+// GetTrendingSearches returns the most frequent recent queries, optionally for one region and kind
+func (db *DB) GetTrendingSearches(ctx context.Context, params *models.TrendingSearchParams) ([]*models.TrendingSearch, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT query, kind, COUNT(*) as searches,
+		       COALESCE(AVG(result_count), 0),
+		       COUNT(*) FILTER (WHERE result_count = 0),
+		       to_char(MAX(created_at), 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+		FROM search_events
+		WHERE created_at >= NOW() - make_interval(days => $1)
+		AND ($2::int IS NULL OR region_id = $2)
+		AND ($3 = '' OR kind = $3)
+		GROUP BY query, kind
+		HAVING COUNT(*) >= $4
+		ORDER BY searches DESC, query ASC
+		LIMIT $5
+	`, params.Days, params.RegionID, params.Kind, params.MinSearches, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trending := []*models.TrendingSearch{}
+	for rows.Next() {
+		t := &models.TrendingSearch{}
+		if err := rows.Scan(&t.Query, &t.Kind, &t.Searches, &t.AvgResults, &t.ZeroResults, &t.LastSearched); err != nil {
+			return nil, err
+		}
+		trending = append(trending, t)
+	}
+
+	return trending, rows.Err()
+}
+
+// PruneSearchEvents deletes search events older than retentionDays and returns how many were removed
+func (db *DB) PruneSearchEvents(ctx context.Context, retentionDays int) (int, error) {
+	result, err := db.Pool.Exec(ctx, `
+		DELETE FROM search_events WHERE created_at < NOW() - make_interval(days => $1)
+	`, retentionDays)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
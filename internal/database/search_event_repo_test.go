@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"collapses whitespace", "  Whole   Milk\t", "whole milk"},
+		{"empty", "   ", ""},
+		{"ascii truncated", strings.Repeat("a", 150), strings.Repeat("a", maxSearchQueryLength)},
+		{"multibyte truncated by character", strings.Repeat("é", 150), strings.Repeat("é", maxSearchQueryLength)},
+		{"no trailing space after truncation", strings.Repeat("a", maxSearchQueryLength-1) + " bread", strings.Repeat("a", maxSearchQueryLength-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeSearchQuery(tt.query)
+			if got != tt.want {
+				t.Errorf("normalizeSearchQuery() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("normalizeSearchQuery() returned invalid UTF-8 %q", got)
+			}
+		})
+	}
+}
@@ -34,6 +34,17 @@ type SettingsMap map[string]interface{}
 
 var ErrSettingNotFound = errors.New("setting not found")
 
+// MaskedSettingValue replaces sensitive values in output; submitting it back leaves the value unchanged
+const MaskedSettingValue = "••••••••"
+
+// MaskSettingValue hides a non-empty sensitive value behind MaskedSettingValue
+func MaskSettingValue(value string, sensitive bool) string {
+	if sensitive && value != "" {
+		return MaskedSettingValue
+	}
+	return value
+}
+
 // Salt for PBKDF2 key derivation - this is fixed but combined with the secret
 // In a production system, you might want to store this separately
 var encryptionSalt = []byte("pricefeed-settings-v1")
@@ -161,6 +172,19 @@ func (db *DB) GetSettingInt(ctx context.Context, key string, defaultValue int, e
 	return val
 }
 
+// GetSettingFloat retrieves a setting as a float
+func (db *DB) GetSettingFloat(ctx context.Context, key string, defaultValue float64, encryptionKey []byte) float64 {
+	setting, err := db.GetSetting(ctx, key, encryptionKey)
+	if err != nil {
+		return defaultValue
+	}
+	val, err := strconv.ParseFloat(setting.Value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return val
+}
+
 // GetSettingBool retrieves a setting as a boolean
 func (db *DB) GetSettingBool(ctx context.Context, key string, defaultValue bool, encryptionKey []byte) bool {
 	setting, err := db.GetSetting(ctx, key, encryptionKey)
@@ -203,9 +227,7 @@ func (db *DB) GetSettingsByCategory(ctx context.Context, category string, encryp
 		}
 
 		// Mask sensitive values for output (show only if explicitly requested)
-		if s.IsSensitive && s.Value != "" {
-			s.Value = "••••••••"
-		}
+		s.Value = MaskSettingValue(s.Value, s.IsSensitive)
 
 		settings = append(settings, s)
 	}
@@ -243,7 +265,7 @@ func (db *DB) GetSettingsByCategoryAsMap(ctx context.Context, category string, e
 
 		// Mask sensitive values unless explicitly requested
 		if isSensitive && !includeSensitive && value != "" {
-			result[key] = "••••••••"
+			result[key] = MaskedSettingValue
 		} else {
 			result[key] = convertSettingValue(value, valueType)
 		}
@@ -272,9 +294,7 @@ func (db *DB) GetAllSettings(ctx context.Context, encryptionKey []byte) (map[str
 		}
 
 		// Mask sensitive values
-		if s.IsSensitive && s.Value != "" {
-			s.Value = "••••••••"
-		}
+		s.Value = MaskSettingValue(s.Value, s.IsSensitive)
 
 		result[s.Category] = append(result[s.Category], s)
 	}
@@ -282,6 +302,62 @@ func (db *DB) GetAllSettings(ctx context.Context, encryptionKey []byte) (map[str
 	return result, nil
 }
 
+// EffectiveSetting is a resolved configuration value and where it came from
+type EffectiveSetting struct {
+	Key         string      `json:"key"`
+	Value       interface{} `json:"value"`
+	Category    string      `json:"category"`
+	Source      string      `json:"source"` // env, default or db-setting
+	IsSensitive bool        `json:"is_sensitive"`
+}
+
+// Effective setting sources
+const (
+	SettingSourceEnv     = "env"
+	SettingSourceDefault = "default"
+	SettingSourceDB      = "db-setting"
+)
+
+// GetEffectiveSettings returns every database setting decrypted and typed the way the
+// GetSetting* readers resolve it, with sensitive values masked
+func (db *DB) GetEffectiveSettings(ctx context.Context, encryptionKey []byte) ([]EffectiveSetting, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT key, value, value_type, category, is_sensitive
+		FROM system_settings
+		ORDER BY category, key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []EffectiveSetting
+	for rows.Next() {
+		var key, value, valueType, category string
+		var isSensitive bool
+		if err := rows.Scan(&key, &value, &valueType, &category, &isSensitive); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+
+		// Decrypt if encrypted
+		if valueType == "encrypted" && value != "" && encryptionKey != nil {
+			if decrypted, err := decrypt(value, encryptionKey); err == nil {
+				value = decrypted
+			}
+		}
+
+		s := EffectiveSetting{Key: key, Category: category, Source: SettingSourceDB, IsSensitive: isSensitive}
+		if isSensitive && value != "" {
+			s.Value = MaskedSettingValue
+		} else {
+			s.Value = convertSettingValue(value, valueType)
+		}
+		settings = append(settings, s)
+	}
+
+	return settings, rows.Err()
+}
+
 // SetSetting updates or creates a setting
 func (db *DB) SetSetting(ctx context.Context, key, value string, encryptionKey []byte) error {
 	// First, get the existing setting to check if it should be encrypted
@@ -299,7 +375,7 @@ func (db *DB) SetSetting(ctx context.Context, key, value string, encryptionKey [
 
 	// Encrypt if needed
 	finalValue := value
-	if valueType == "encrypted" && value != "" && value != "••••••••" && encryptionKey != nil {
+	if valueType == "encrypted" && value != "" && value != MaskedSettingValue && encryptionKey != nil {
 		encrypted, err := encrypt(value, encryptionKey)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt value: %w", err)
@@ -308,7 +384,7 @@ func (db *DB) SetSetting(ctx context.Context, key, value string, encryptionKey [
 	}
 
 	// Don't update if masked value is submitted
-	if value == "••••••••" {
+	if value == MaskedSettingValue {
 		return nil
 	}
 
@@ -370,6 +446,11 @@ func convertSettingValue(value, valueType string) interface{} {
 			return v
 		}
 		return 0
+	case "float":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+		return 0.0
 	case "bool":
 		if v, err := strconv.ParseBool(value); err == nil {
 			return v
@@ -395,6 +476,10 @@ type SMTPConfig struct {
 	Password string
 	FromAddr string
 	FromName string
+	ReplyTo  string
+
+	// Fallback is a secondary server tried when the primary fails (nil when disabled)
+	Fallback *SMTPConfig
 }
 
 // GetSMTPConfig retrieves all SMTP settings as a config struct
@@ -412,11 +497,43 @@ func (db *DB) GetSMTPConfig(ctx context.Context, encryptionKey []byte) (*SMTPCon
 	config.FromAddr = db.GetSettingString(ctx, "smtp_from_addr", "noreply@pricefeed.app", encryptionKey)
 	config.FromName = db.GetSettingString(ctx, "smtp_from_name", "PriceFeed", encryptionKey)
 
+	config.ReplyTo = db.GetSettingString(ctx, "smtp_reply_to", "", encryptionKey)
+
 	// Get password (need to decrypt)
 	setting, err := db.GetSetting(ctx, "smtp_password", encryptionKey)
 	if err == nil && setting.Value != "" {
 		config.Password = setting.Value
 	}
 
+	// Fallback server shares the sender identity of the primary
+	if db.GetSettingBool(ctx, "smtp_fallback_enabled", false, encryptionKey) {
+		fallback := &SMTPConfig{
+			Enabled:  true,
+			Host:     db.GetSettingString(ctx, "smtp_fallback_host", "", encryptionKey),
+			Port:     db.GetSettingInt(ctx, "smtp_fallback_port", 587, encryptionKey),
+			User:     db.GetSettingString(ctx, "smtp_fallback_user", "", encryptionKey),
+			FromAddr: config.FromAddr,
+			FromName: config.FromName,
+			ReplyTo:  config.ReplyTo,
+		}
+		setting, err := db.GetSetting(ctx, "smtp_fallback_password", encryptionKey)
+		if err == nil && setting.Value != "" {
+			fallback.Password = setting.Value
+		}
+		if fallback.Host != "" {
+			config.Fallback = fallback
+		}
+	}
+
 	return config, nil
 }
+
+// GetEmailCategorySender returns the from-name, from-address and reply-to overrides
+// configured for an email category (e.g. "digest"). Empty values mean no override.
+func (db *DB) GetEmailCategorySender(ctx context.Context, category string, encryptionKey []byte) (fromName, fromAddr, replyTo string) {
+	prefix := "smtp_" + category + "_"
+	fromName = db.GetSettingString(ctx, prefix+"from_name", "", encryptionKey)
+	fromAddr = db.GetSettingString(ctx, prefix+"from_addr", "", encryptionKey)
+	replyTo = db.GetSettingString(ctx, prefix+"reply_to", "", encryptionKey)
+	return fromName, fromAddr, replyTo
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+var testEncryptionKey = getEncryptionKey("test-secret")
+
+// setTestSetting changes a setting for the duration of the test and restores it afterwards
+func setTestSetting(t *testing.T, db *DB, key, value string) {
+	t.Helper()
+	ctx := context.Background()
+
+	previous, err := db.GetSetting(ctx, key, testEncryptionKey)
+	if err != nil && err != ErrSettingNotFound {
+		t.Fatalf("get setting %s: %v", key, err)
+	}
+	t.Cleanup(func() {
+		if previous == nil {
+			db.DeleteSetting(ctx, key)
+			return
+		}
+		db.SetSetting(ctx, key, previous.Value, testEncryptionKey)
+	})
+
+	if err := db.SetSetting(ctx, key, value, testEncryptionKey); err != nil {
+		t.Fatalf("set setting %s: %v", key, err)
+	}
+}
+
+func TestGetSMTPConfigFallback(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	setTestSetting(t, db, "smtp_from_addr", "noreply@example.com")
+	setTestSetting(t, db, "smtp_from_name", "PriceFeed")
+	setTestSetting(t, db, "smtp_reply_to", "help@example.com")
+	setTestSetting(t, db, "smtp_fallback_host", "fallback.example.com")
+	setTestSetting(t, db, "smtp_fallback_port", "2525")
+	setTestSetting(t, db, "smtp_fallback_password", "secret")
+
+	setTestSetting(t, db, "smtp_fallback_enabled", "false")
+	cfg, err := db.GetSMTPConfig(ctx, testEncryptionKey)
+	if err != nil {
+		t.Fatalf("GetSMTPConfig: %v", err)
+	}
+	if cfg.Fallback != nil {
+		t.Errorf("disabled fallback = %+v, want nil", cfg.Fallback)
+	}
+
+	setTestSetting(t, db, "smtp_fallback_enabled", "true")
+	cfg, err = db.GetSMTPConfig(ctx, testEncryptionKey)
+	if err != nil {
+		t.Fatalf("GetSMTPConfig: %v", err)
+	}
+	fb := cfg.Fallback
+	if fb == nil {
+		t.Fatal("enabled fallback is nil")
+	}
+	if fb.Host != "fallback.example.com" || fb.Port != 2525 || fb.Password != "secret" {
+		t.Errorf("fallback server = %s:%d password %q", fb.Host, fb.Port, fb.Password)
+	}
+	if fb.FromAddr != "noreply@example.com" || fb.FromName != "PriceFeed" || fb.ReplyTo != "help@example.com" {
+		t.Errorf("fallback sender = %q <%s> reply-to %q, want the primary's", fb.FromName, fb.FromAddr, fb.ReplyTo)
+	}
+
+	setTestSetting(t, db, "smtp_fallback_host", "")
+	cfg, err = db.GetSMTPConfig(ctx, testEncryptionKey)
+	if err != nil {
+		t.Fatalf("GetSMTPConfig: %v", err)
+	}
+	if cfg.Fallback != nil {
+		t.Error("fallback without a host was enabled")
+	}
+}
+
+func TestGetEmailCategorySender(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	setTestSetting(t, db, "smtp_digest_from_name", "PriceFeed Digest")
+	setTestSetting(t, db, "smtp_digest_from_addr", "digest@example.com")
+	setTestSetting(t, db, "smtp_digest_reply_to", "")
+
+	name, addr, replyTo := db.GetEmailCategorySender(ctx, "digest", testEncryptionKey)
+	if name != "PriceFeed Digest" || addr != "digest@example.com" || replyTo != "" {
+		t.Errorf("digest sender = %q <%s> reply-to %q", name, addr, replyTo)
+	}
+
+	name, addr, replyTo = db.GetEmailCategorySender(ctx, testName("category"), testEncryptionKey)
+	if name != "" || addr != "" || replyTo != "" {
+		t.Errorf("unconfigured category sender = %q <%s> reply-to %q, want no overrides", name, addr, replyTo)
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// storeDataIssuesQuery finds public stores missing a region, missing coordinates or whose
+// region does not cover their address, one row per issue, busiest stores first within each
+// priority. A region with no zip codes covers its whole state. A suggested region comes from
+// the region listing the store's zip code, falling back to the region of the nearest store in
+// the same state.
+const storeDataIssuesQuery = `
+	WITH candidates AS (
+		SELECT s.id, s.name, s.street_address, s.city, s.state, s.zip_code, s.region_id,
+		       s.latitude::float8 AS latitude, s.longitude::float8 AS longitude,
+		       r.state AS region_state, r.zip_codes AS region_zips, zr.id AS zip_region_id,
+		       (SELECT COUNT(*) FROM store_prices sp WHERE sp.store_id = s.id) AS price_count
+		FROM stores s
+		LEFT JOIN regions r ON s.region_id = r.id
+		LEFT JOIN LATERAL (
+			SELECT id FROM regions WHERE LEFT(s.zip_code, 5) = ANY(zip_codes) ORDER BY id LIMIT 1
+		) zr ON true
+		WHERE s.is_private = false
+	),
+	issues AS (
+		SELECT c.*, 'missing_region' AS issue, 1 AS priority FROM candidates c
+		WHERE c.region_id IS NULL
+		UNION ALL
+		SELECT c.*, 'missing_coordinates', 2 FROM candidates c
+		WHERE c.latitude IS NULL OR c.longitude IS NULL
+		UNION ALL
+		SELECT c.*, 'region_mismatch', 3 FROM candidates c
+		WHERE c.region_id IS NOT NULL
+		AND (c.region_state <> c.state
+		     OR (cardinality(c.region_zips) > 0 AND NOT (LEFT(c.zip_code, 5) = ANY(c.region_zips))))
+	)
+	SELECT i.id, i.name, i.street_address, i.city, i.state, i.zip_code, i.region_id,
+	       i.latitude, i.longitude, i.price_count, i.issue, i.priority,
+	       i.zip_region_id, nearest.region_id, sr.name,
+	       COUNT(*) OVER() AS total
+	FROM issues i
+	LEFT JOIN LATERAL (
+		SELECT o.region_id
+		FROM stores o
+		WHERE i.issue <> 'missing_coordinates'
+		AND i.zip_region_id IS NULL
+		AND i.latitude IS NOT NULL AND i.longitude IS NOT NULL
+		AND o.id <> i.id
+		AND o.region_id IS NOT NULL
+		AND o.state = i.state
+		AND o.latitude IS NOT NULL AND o.longitude IS NOT NULL
+		ORDER BY (o.latitude - i.latitude) ^ 2
+		       + ((o.longitude - i.longitude) * cos(radians(i.latitude))) ^ 2
+		LIMIT 1
+	) nearest ON true
+	LEFT JOIN regions sr ON sr.id = COALESCE(i.zip_region_id, nearest.region_id)
+	WHERE ($1 = '' OR i.issue = $1)
+	ORDER BY i.priority, i.price_count DESC, i.id
+	LIMIT $2 OFFSET $3
+`
+
+// ListStoreDataIssues returns the prioritized store cleanup queue, optionally limited to one
+// issue type, with a suggested fix per row
+func (db *DB) ListStoreDataIssues(ctx context.Context, issue string, limit, offset int) ([]*models.StoreDataIssue, int, error) {
+	rows, err := db.Pool.Query(ctx, storeDataIssuesQuery, issue, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	issues := []*models.StoreDataIssue{}
+	for rows.Next() {
+		var i models.StoreDataIssue
+		var zipRegionID, nearestRegionID *int
+		err := rows.Scan(
+			&i.StoreID, &i.StoreName, &i.StreetAddress, &i.City, &i.State, &i.ZipCode, &i.RegionID,
+			&i.Latitude, &i.Longitude, &i.PriceCount, &i.Issue, &i.Priority,
+			&zipRegionID, &nearestRegionID, &i.SuggestedRegionName, &total,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case zipRegionID != nil:
+			source := models.RegionSuggestionZip
+			i.SuggestedRegionID, i.SuggestionSource = zipRegionID, &source
+		case nearestRegionID != nil:
+			source := models.RegionSuggestionNearestStore
+			i.SuggestedRegionID, i.SuggestionSource = nearestRegionID, &source
+		}
+
+		switch {
+		case i.Issue == models.StoreIssueMissingCoordinates:
+			i.SuggestedFix = models.StoreFixGeocode
+			i.SuggestedRegionID, i.SuggestedRegionName, i.SuggestionSource = nil, nil, nil
+		case i.SuggestedRegionID != nil && (i.RegionID == nil || *i.RegionID != *i.SuggestedRegionID):
+			i.SuggestedFix = models.StoreFixAssignRegion
+		default:
+			i.SuggestedFix = models.StoreFixReview
+			i.SuggestedRegionID, i.SuggestedRegionName, i.SuggestionSource = nil, nil, nil
+		}
+
+		issues = append(issues, &i)
+	}
+
+	return issues, total, rows.Err()
+}
+
+// AssignStoreRegions moves each store to its new region in one transaction
+func (db *DB) AssignStoreRegions(ctx context.Context, assignments []models.StoreRegionAssignment) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, a := range assignments {
+		_, err := tx.Exec(ctx, `UPDATE stores SET region_id = $2, updated_at = NOW() WHERE id = $1`, a.StoreID, a.ToRegionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestRegionWithoutZipCodesCoversState(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	statewide := testRegion(t, db, "en-US")
+	zipped, err := db.CreateRegion(ctx, &models.CreateRegionRequest{
+		Name:     testName("region"),
+		State:    "TX",
+		ZipCodes: []string{"79999"},
+		Locale:   "en-US",
+	})
+	if err != nil {
+		t.Fatalf("create region: %v", err)
+	}
+
+	// testStore is in TX with zip 75001
+	inStatewide := testStore(t, db, nil)
+	outsideZips := testStore(t, db, nil)
+	if err := db.AssignStoreRegions(ctx, []models.StoreRegionAssignment{
+		{StoreID: inStatewide.ID, ToRegionID: statewide.ID},
+		{StoreID: outsideZips.ID, ToRegionID: zipped.ID},
+	}); err != nil {
+		t.Fatalf("AssignStoreRegions: %v", err)
+	}
+
+	issues, _, err := db.ListStoreDataIssues(ctx, models.StoreIssueRegionMismatch, 100000, 0)
+	if err != nil {
+		t.Fatalf("ListStoreDataIssues: %v", err)
+	}
+
+	mismatched := map[int]bool{}
+	for _, i := range issues {
+		mismatched[i.StoreID] = true
+	}
+	if mismatched[inStatewide.ID] {
+		t.Error("store in a region without zip codes reported as region_mismatch")
+	}
+	if !mismatched[outsideZips.ID] {
+		t.Error("store outside its region's zip codes not reported as region_mismatch")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -14,6 +16,7 @@ import (
 var (
 	ErrStoreNotFound = errors.New("store not found")
 	ErrStoreExists   = errors.New("store already exists at this address")
+	ErrNotStoreOwner = errors.New("store belongs to another user")
 )
 
 // ListStores returns a paginated list of stores with optional filtering
@@ -85,7 +88,8 @@ func (db *DB) ListStores(ctx context.Context, params *models.StoreListParams) ([
 			s.verified, s.verification_count, s.is_private, s.created_by, s.created_at, s.updated_at,
 			r.name as region_name,
 			COALESCE((SELECT COUNT(*) FROM store_prices WHERE store_id = s.id), 0) as price_count,
-			COALESCE((SELECT COUNT(DISTINCT user_id) FROM store_prices WHERE store_id = s.id AND user_id IS NOT NULL), 0) as contributor_count
+			COALESCE((SELECT COUNT(DISTINCT user_id) FROM store_prices WHERE store_id = s.id AND user_id IS NOT NULL), 0) as contributor_count,
+			s.verification_basis
 		FROM stores s
 		LEFT JOIN regions r ON s.region_id = r.id
 		%s
@@ -111,6 +115,7 @@ func (db *DB) ListStores(ctx context.Context, params *models.StoreListParams) ([
 			&s.RegionName,
 			&s.PriceCount,
 			&s.ContributorCount,
+			&s.VerifyBasis,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -132,7 +137,8 @@ func (db *DB) GetStoreByID(ctx context.Context, id int) (*models.StoreWithStats,
 			s.verified, s.verification_count, s.is_private, s.created_by, s.created_at, s.updated_at,
 			r.name as region_name,
 			COALESCE((SELECT COUNT(*) FROM store_prices WHERE store_id = s.id), 0) as price_count,
-			COALESCE((SELECT COUNT(DISTINCT user_id) FROM store_prices WHERE store_id = s.id AND user_id IS NOT NULL), 0) as contributor_count
+			COALESCE((SELECT COUNT(DISTINCT user_id) FROM store_prices WHERE store_id = s.id AND user_id IS NOT NULL), 0) as contributor_count,
+			s.verification_basis
 		FROM stores s
 		LEFT JOIN regions r ON s.region_id = r.id
 		WHERE s.id = $1
@@ -143,6 +149,7 @@ func (db *DB) GetStoreByID(ctx context.Context, id int) (*models.StoreWithStats,
 		&s.RegionName,
 		&s.PriceCount,
 		&s.ContributorCount,
+		&s.VerifyBasis,
 	)
 
 	if err != nil {
@@ -163,8 +170,8 @@ func (db *DB) CreateStore(ctx context.Context, req *models.CreateStoreRequest, c
 	state := strings.ToUpper(req.State)
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO stores (name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, is_private, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
+		INSERT INTO stores (name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, verification_basis, is_private, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $11 THEN 'manual' END, $12, $13, NOW(), NOW())
 		RETURNING id, name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, verification_count, is_private, created_by, created_at, updated_at
 	`, req.Name, req.StreetAddress, req.City, state, req.ZipCode, req.RegionID, req.StoreType, req.Chain, req.Latitude, req.Longitude, req.Verified, req.IsPrivate, createdBy).Scan(
 		&store.ID, &store.Name, &store.StreetAddress, &store.City, &store.State, &store.ZipCode,
@@ -179,8 +186,84 @@ func (db *DB) CreateStore(ctx context.Context, req *models.CreateStoreRequest, c
 	return store, nil
 }
 
-// UpdateStore updates an existing store
-func (db *DB) UpdateStore(ctx context.Context, id int, req *models.UpdateStoreRequest) (*models.Store, error) {
+// ImportStores inserts a batch of stores in one transaction. A row whose normalized street
+// address, state and zip code match an existing community store (or an earlier row) is not
+// inserted; its result carries the existing store's ID and Duplicate set. Each row runs in its
+// own savepoint, so a row that fails is rolled back and reported in its result's Err while the
+// rest of the batch is still saved.
+func (db *DB) ImportStores(ctx context.Context, reqs []*models.CreateStoreRequest, createdBy *int) ([]models.StoreImportInsert, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]models.StoreImportInsert, len(reqs))
+	for i, req := range reqs {
+		row, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results[i], err = importStore(ctx, row, req, createdBy)
+		if err != nil {
+			if rbErr := row.Rollback(ctx); rbErr != nil {
+				return nil, rbErr
+			}
+			results[i] = models.StoreImportInsert{Err: err}
+			continue
+		}
+		if err := row.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// importStore inserts one imported store unless it duplicates a community store
+func importStore(ctx context.Context, tx pgx.Tx, req *models.CreateStoreRequest, createdBy *int) (models.StoreImportInsert, error) {
+	state := strings.ToUpper(req.State)
+
+	var existingID int
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM stores
+		WHERE is_private = false
+		  AND normalize_address(street_address) = normalize_address($1)
+		  AND state = $2 AND LEFT(zip_code, 5) = LEFT($3, 5)
+		ORDER BY id
+		LIMIT 1
+	`, req.StreetAddress, state, req.ZipCode).Scan(&existingID)
+	if err == nil {
+		return models.StoreImportInsert{StoreID: existingID, Duplicate: true}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.StoreImportInsert{}, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO stores (name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, verification_basis, is_private, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $11 THEN 'manual' END, false, $12, NOW(), NOW())
+		ON CONFLICT ON CONSTRAINT unique_store_address DO NOTHING
+		RETURNING id
+	`, req.Name, req.StreetAddress, req.City, state, req.ZipCode, req.RegionID, req.StoreType, req.Chain, req.Latitude, req.Longitude, req.Verified, createdBy).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Conflicted with a private store at the same address
+		return models.StoreImportInsert{Duplicate: true}, nil
+	}
+	if err != nil {
+		return models.StoreImportInsert{}, err
+	}
+	return models.StoreImportInsert{StoreID: id}, nil
+}
+
+// UpdateStore updates an existing store. A non-nil ownerID limits the update to a store created
+// by that user, returning ErrNotStoreOwner for anyone else's store.
+func (db *DB) UpdateStore(ctx context.Context, id int, ownerID *int, req *models.UpdateStoreRequest) (*models.Store, error) {
 	store := &models.Store{}
 
 	// Normalize state to uppercase if provided
@@ -203,10 +286,11 @@ func (db *DB) UpdateStore(ctx context.Context, id int, req *models.UpdateStoreRe
 		    latitude = COALESCE($10, latitude),
 		    longitude = COALESCE($11, longitude),
 		    verified = COALESCE($12, verified),
+		    verification_basis = CASE WHEN $12::boolean IS NOT NULL THEN 'manual' ELSE verification_basis END,
 		    updated_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND ($13::int IS NULL OR created_by = $13)
 		RETURNING id, name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, verification_count, is_private, created_by, created_at, updated_at
-	`, id, req.Name, req.StreetAddress, req.City, state, req.ZipCode, req.RegionID, req.StoreType, req.Chain, req.Latitude, req.Longitude, req.Verified).Scan(
+	`, id, req.Name, req.StreetAddress, req.City, state, req.ZipCode, req.RegionID, req.StoreType, req.Chain, req.Latitude, req.Longitude, req.Verified, ownerID).Scan(
 		&store.ID, &store.Name, &store.StreetAddress, &store.City, &store.State, &store.ZipCode,
 		&store.RegionID, &store.StoreType, &store.Chain, &store.Latitude, &store.Longitude,
 		&store.Verified, &store.VerificationCount, &store.IsPrivate, &store.CreatedBy, &store.CreatedAt, &store.UpdatedAt,
@@ -214,7 +298,7 @@ func (db *DB) UpdateStore(ctx context.Context, id int, req *models.UpdateStoreRe
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrStoreNotFound
+			return nil, db.storeMissingOrNotOwned(ctx, id, ownerID)
 		}
 		return nil, err
 	}
@@ -222,27 +306,282 @@ func (db *DB) UpdateStore(ctx context.Context, id int, req *models.UpdateStoreRe
 	return store, nil
 }
 
-// DeleteStore deletes a store by ID
-func (db *DB) DeleteStore(ctx context.Context, id int) error {
-	result, err := db.Pool.Exec(ctx, `DELETE FROM stores WHERE id = $1`, id)
+// DeleteStore deletes a store by ID. A non-nil ownerID limits the delete to a store created by
+// that user, returning ErrNotStoreOwner for anyone else's store.
+func (db *DB) DeleteStore(ctx context.Context, id int, ownerID *int) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM stores WHERE id = $1 AND ($2::int IS NULL OR created_by = $2)`, id, ownerID)
 	if err != nil {
 		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return ErrStoreNotFound
+		return db.storeMissingOrNotOwned(ctx, id, ownerID)
 	}
 
 	return nil
 }
 
-// VerifyStore marks a store as verified
-func (db *DB) VerifyStore(ctx context.Context, id int) error {
+// storeMissingOrNotOwned explains why an owner-limited write to a store matched no row
+func (db *DB) storeMissingOrNotOwned(ctx context.Context, id int, ownerID *int) error {
+	if ownerID == nil {
+		return ErrStoreNotFound
+	}
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM stores WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrNotStoreOwner
+	}
+	return ErrStoreNotFound
+}
+
+// CountOtherContributorPrices counts prices at a store submitted by users other than userID
+func (db *DB) CountOtherContributorPrices(ctx context.Context, storeID, userID int) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM store_prices
+		WHERE store_id = $1 AND (user_id IS NULL OR user_id <> $2)
+	`, storeID, userID).Scan(&count)
+	return count, err
+}
+
+// VerifyStore marks a store as verified and rewards its creator with creatorPoints the first
+// time it is verified
+func (db *DB) VerifyStore(ctx context.Context, id int, creatorPoints int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var createdBy *int
+	err = tx.QueryRow(ctx, `
+		UPDATE stores
+		SET verified = true, verification_count = verification_count + 1, verification_basis = 'manual', updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_by
+	`, id).Scan(&createdBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrStoreNotFound
+		}
+		return err
+	}
+
+	if createdBy != nil {
+		if _, err := awardReputation(ctx, tx, *createdBy, creatorPoints, models.ReputationReasonStoreVerified, &id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AutoVerifyStoresFromContributors verifies public stores that have prices from at least
+// minContributors distinct users and rewards each store's creator with creatorPoints. Stores
+// an admin has verified or unverified by hand are left alone. Returns the number of stores
+// newly verified.
+func (db *DB) AutoVerifyStoresFromContributors(ctx context.Context, minContributors, creatorPoints int) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		WITH contributor_counts AS (
+			SELECT store_id, COUNT(DISTINCT user_id) as contributor_count
+			FROM store_prices
+			WHERE user_id IS NOT NULL
+			GROUP BY store_id
+		)
+		UPDATE stores s
+		SET verified = true, verification_basis = 'contributors', updated_at = NOW()
+		FROM contributor_counts cc
+		WHERE s.id = cc.store_id
+		  AND s.verified = false
+		  AND s.verification_basis IS DISTINCT FROM 'manual'
+		  AND s.is_private = false
+		  AND cc.contributor_count >= $1
+		RETURNING s.id, s.created_by
+	`, minContributors)
+	if err != nil {
+		return 0, err
+	}
+
+	type verifiedStore struct {
+		id        int
+		createdBy *int
+	}
+	var verified []verifiedStore
+	for rows.Next() {
+		var v verifiedStore
+		if err := rows.Scan(&v.id, &v.createdBy); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		verified = append(verified, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, v := range verified {
+		if v.createdBy == nil {
+			continue
+		}
+		if _, err := awardReputation(ctx, tx, *v.createdBy, creatorPoints, models.ReputationReasonStoreVerified, &v.id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(verified), nil
+}
+
+// GetStoreQuality aggregates verification, freshness and contributor metrics over a store's shared prices.
+// A price is stale when it has not been updated or verified within staleDays.
+func (db *DB) GetStoreQuality(ctx context.Context, storeID, staleDays int) (*models.StoreQuality, error) {
+	q := &models.StoreQuality{StoreID: storeID, StaleAfterDays: staleDays}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*),
+			COALESCE(100.0 * COUNT(*) FILTER (WHERE verified_count > 0) / NULLIF(COUNT(*), 0), 0)::float8,
+			COALESCE(100.0 * COUNT(*) FILTER (
+				WHERE GREATEST(updated_at, COALESCE(last_verified, updated_at)) < NOW() - ($2 || ' days')::INTERVAL
+			) / NULLIF(COUNT(*), 0), 0)::float8,
+			COALESCE(AVG(EXTRACT(EPOCH FROM (NOW() - GREATEST(updated_at, COALESCE(last_verified, updated_at)))) / 86400), 0)::float8,
+			COUNT(DISTINCT user_id)
+		FROM store_prices
+		WHERE store_id = $1 AND is_shared = true
+	`, storeID, staleDays).Scan(&q.TotalPrices, &q.VerifiedPercent, &q.StalePercent, &q.AvgAgeDays, &q.ContributorCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// GetStorePriceVolatility ranks items at a store by how often and how much their price changed
+// in price_history over the last days, most volatile first. Only recorded changes to a
+// different price count; items with none in the window are left out.
+func (db *DB) GetStorePriceVolatility(ctx context.Context, storeID, days, limit, offset int) ([]*models.ItemPriceVolatility, int, error) {
+	weeks := float64(days) / 7
+	rows, err := db.Pool.Query(ctx, `
+		WITH changes AS (
+			SELECT ph.item_id, ph.price, ph.previous_price, ph.recorded_at,
+			       ABS(ph.price - ph.previous_price) as delta,
+			       ABS(ph.price - ph.previous_price) / NULLIF(ph.previous_price, 0) * 100 as delta_pct
+			FROM price_history ph
+			JOIN items i ON ph.item_id = i.id
+			WHERE ph.store_id = $1
+			  AND ph.previous_price IS NOT NULL
+			  AND ph.price <> ph.previous_price
+			  AND ph.recorded_at >= NOW() - ($2 || ' days')::INTERVAL
+			  AND COALESCE(i.is_private, false) = false
+		),
+		per_item AS (
+			SELECT item_id,
+			       COUNT(*) as change_count,
+			       AVG(delta) as avg_change,
+			       COALESCE(AVG(delta_pct), 0) as avg_pct,
+			       COALESCE(MAX(delta_pct), 0) as max_pct,
+			       LEAST(MIN(price), MIN(previous_price)) as min_price,
+			       GREATEST(MAX(price), MAX(previous_price)) as max_price,
+			       (ARRAY_AGG(price ORDER BY recorded_at DESC))[1] as last_price,
+			       MAX(recorded_at) as last_changed_at
+			FROM changes
+			GROUP BY item_id
+		)
+		SELECT p.item_id, i.name, i.brand, p.change_count,
+		       p.avg_change::float8, p.avg_pct::float8, p.max_pct::float8,
+		       p.min_price::float8, p.max_price::float8, p.last_price::float8, p.last_changed_at,
+		       COUNT(*) OVER () as total
+		FROM per_item p
+		JOIN items i ON p.item_id = i.id
+		ORDER BY p.change_count / $3::float8 * p.avg_pct DESC, p.change_count DESC, i.name ASC
+		LIMIT $4 OFFSET $5
+	`, storeID, days, weeks, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []*models.ItemPriceVolatility{}
+	total := 0
+	for rows.Next() {
+		v := &models.ItemPriceVolatility{}
+		if err := rows.Scan(&v.ItemID, &v.ItemName, &v.ItemBrand, &v.ChangeCount,
+			&v.AvgChange, &v.AvgChangePercent, &v.MaxChangePercent,
+			&v.MinPrice, &v.MaxPrice, &v.LastPrice, &v.LastChangedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		v.ChangesPerWeek = math.Round(float64(v.ChangeCount)/weeks*100) / 100
+		v.Score = math.Round(float64(v.ChangeCount)/weeks*v.AvgChangePercent*100) / 100
+		v.AvgChange = math.Round(v.AvgChange*100) / 100
+		v.AvgChangePercent = math.Round(v.AvgChangePercent*10) / 10
+		v.MaxChangePercent = math.Round(v.MaxChangePercent*10) / 10
+		items = append(items, v)
+	}
+
+	return items, total, rows.Err()
+}
+
+// ListStoresMissingCoordinates returns stores that have an address but no coordinates, starting
+// with stores that were never attempted and then those whose last failed attempt is oldest
+func (db *DB) ListStoresMissingCoordinates(ctx context.Context, limit int) ([]*models.Store, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, name, street_address, city, state, zip_code, region_id, store_type, chain, latitude, longitude, verified, verification_count, is_private, created_by, created_at, updated_at
+		FROM stores
+		WHERE (latitude IS NULL OR longitude IS NULL)
+		  AND street_address <> ''
+		ORDER BY geocode_attempted_at NULLS FIRST, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stores []*models.Store
+	for rows.Next() {
+		store := &models.Store{}
+		err := rows.Scan(
+			&store.ID, &store.Name, &store.StreetAddress, &store.City, &store.State, &store.ZipCode,
+			&store.RegionID, &store.StoreType, &store.Chain, &store.Latitude, &store.Longitude,
+			&store.Verified, &store.VerificationCount, &store.IsPrivate, &store.CreatedBy, &store.CreatedAt, &store.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+
+	return stores, rows.Err()
+}
+
+// CountStoresMissingCoordinates returns how many stores still need geocoding
+func (db *DB) CountStoresMissingCoordinates(ctx context.Context) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM stores
+		WHERE (latitude IS NULL OR longitude IS NULL) AND street_address <> ''
+	`).Scan(&count)
+	return count, err
+}
+
+// UpdateStoreCoordinates sets a store's coordinates and Google place ID
+func (db *DB) UpdateStoreCoordinates(ctx context.Context, id int, lat, lng float64, placeID string) error {
 	result, err := db.Pool.Exec(ctx, `
 		UPDATE stores
-		SET verified = true, verification_count = verification_count + 1, updated_at = NOW()
+		SET latitude = $2, longitude = $3, google_place_id = NULLIF($4, ''), geocode_attempted_at = NULL, updated_at = NOW()
 		WHERE id = $1
-	`, id)
+	`, id, lat, lng, placeID)
 	if err != nil {
 		return err
 	}
@@ -254,6 +593,15 @@ func (db *DB) VerifyStore(ctx context.Context, id int) error {
 	return nil
 }
 
+// MarkStoreGeocodeAttempted records a failed geocoding attempt so the store moves to the back
+// of the backfill queue
+func (db *DB) MarkStoreGeocodeAttempted(ctx context.Context, id int) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE stores SET geocode_attempted_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
 // GetStoreStats returns aggregate statistics for stores
 func (db *DB) GetStoreStats(ctx context.Context) (*models.StoreStats, error) {
 	var totalStores, verifiedCount, pendingCount, totalPrices int
@@ -333,13 +681,20 @@ func (db *DB) SearchStores(ctx context.Context, query string, limit int, userID
 // StoreWithDistance represents a store with its distance from a reference point
 type StoreWithDistance struct {
 	models.StoreWithStats
-	DistanceKm float64 `json:"distance_km"`
+	DistanceKm   float64             `json:"distance_km"`
+	Distance     float64             `json:"distance"` // DistanceKm in DistanceUnit, rounded
+	DistanceUnit models.DistanceUnit `json:"distance_unit"`
 }
 
 // FindNearbyStores finds public stores within a given radius of a location
 // Uses the Haversine formula to calculate distance
 // Only returns public stores (is_private = false) that have coordinates set
-func (db *DB) FindNearbyStores(ctx context.Context, lat, lng float64, radiusKm float64, limit int) ([]*StoreWithDistance, error) {
+// An empty storeTypes matches stores of any type
+func (db *DB) FindNearbyStores(ctx context.Context, lat, lng float64, radiusKm float64, limit int, storeTypes []string) ([]*StoreWithDistance, error) {
+	if storeTypes == nil {
+		storeTypes = []string{}
+	}
+
 	if limit <= 0 {
 		limit = 20
 	}
@@ -380,9 +735,10 @@ func (db *DB) FindNearbyStores(ctx context.Context, lat, lng float64, radiusKm f
 					))
 				)
 			) <= $3
+			AND (cardinality($5::text[]) = 0 OR s.store_type = ANY($5::text[]))
 		ORDER BY distance_km ASC
 		LIMIT $4
-	`, lat, lng, radiusKm, limit)
+	`, lat, lng, radiusKm, limit, storeTypes)
 	if err != nil {
 		return nil, err
 	}
@@ -408,3 +764,156 @@ func (db *DB) FindNearbyStores(ctx context.Context, lat, lng float64, radiusKm f
 
 	return stores, nil
 }
+
+// NearbyListStore is a nearby store with how much of a shopping list it prices
+type NearbyListStore struct {
+	StoreWithDistance
+	ItemsCovered      int     `json:"items_covered"`
+	ItemsTotal        int     `json:"items_total"`
+	EstimatedSubtotal float64 `json:"estimated_subtotal"` // Price x quantity over the covered items
+	MissingItemIDs    []int   `json:"missing_item_ids"`
+}
+
+// FindNearbyStoresForList returns the public stores within radiusKm with how many of the list's
+// items have a price visible to userID there and what those items would cost. Stores are sorted
+// by item coverage, then distance.
+func (db *DB) FindNearbyStoresForList(ctx context.Context, lat, lng, radiusKm float64, listID, userID int) ([]*NearbyListStore, error) {
+	var ownerID int
+	err := db.Pool.QueryRow(ctx, `SELECT user_id FROM shopping_lists WHERE id = $1`, listID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrListNotFound
+		}
+		return nil, err
+	}
+	if ownerID != userID {
+		return nil, ErrNotListOwner
+	}
+
+	quantities := make(map[int]int)
+	var itemIDs []int
+	rows, err := db.Pool.Query(ctx, `SELECT item_id, quantity FROM shopping_list_items WHERE list_id = $1`, listID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var itemID, quantity int
+		if err := rows.Scan(&itemID, &quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if _, seen := quantities[itemID]; !seen {
+			itemIDs = append(itemIDs, itemID)
+		}
+		quantities[itemID] += quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	nearby, err := db.FindNearbyStores(ctx, lat, lng, radiusKm, 100, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*NearbyListStore, 0, len(nearby))
+	if len(nearby) == 0 {
+		return results, nil
+	}
+
+	storeIDs := make([]int, len(nearby))
+	for i, s := range nearby {
+		storeIDs[i] = s.ID
+	}
+	matrix, err := db.loadPriceMatrix(ctx, itemIDs, userID, &priceMatrixFilter{StoreIDs: storeIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range nearby {
+		store := &NearbyListStore{StoreWithDistance: *s, ItemsTotal: len(itemIDs), MissingItemIDs: []int{}}
+		for _, itemID := range itemIDs {
+			if price, ok := matrix.prices[s.ID][itemID]; ok {
+				store.ItemsCovered++
+				store.EstimatedSubtotal += price * float64(quantities[itemID])
+			} else {
+				store.MissingItemIDs = append(store.MissingItemIDs, itemID)
+			}
+		}
+		store.EstimatedSubtotal = roundCents(store.EstimatedSubtotal)
+		results = append(results, store)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].ItemsCovered != results[j].ItemsCovered {
+			return results[i].ItemsCovered > results[j].ItemsCovered
+		}
+		return results[i].DistanceKm < results[j].DistanceKm
+	})
+
+	return results, nil
+}
+
+// FindStoresCarrying ranks stores by how many of the requested items they price, then by the
+// basket total of the covered items. Returns one page of results and the total store count.
+func (db *DB) FindStoresCarrying(ctx context.Context, req *models.StoresCarryingRequest, userID int) ([]*models.StoreCoverage, int, error) {
+	matrix, err := db.loadPriceMatrix(ctx, req.ItemIDs, userID, &priceMatrixFilter{
+		RegionID:   req.RegionID,
+		MaxAgeDays: req.MaxAgeDays,
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var stores []*models.StoreCoverage
+	for storeID, prices := range matrix.prices {
+		coverage := &models.StoreCoverage{
+			StoreID:        storeID,
+			StoreName:      matrix.storeNames[storeID],
+			StoreAddress:   matrix.storeAddresses[storeID],
+			ItemsRequested: len(req.ItemIDs),
+		}
+
+		if distance, ok := matrix.storeDistances[storeID]; ok {
+			coverage.DistanceKm = &distance
+		}
+		if req.RadiusKm != nil && (coverage.DistanceKm == nil || *coverage.DistanceKm > *req.RadiusKm) {
+			continue
+		}
+
+		for _, itemID := range req.ItemIDs {
+			if price, exists := prices[itemID]; exists {
+				coverage.BasketTotal += price
+				coverage.ItemsCovered++
+			} else {
+				coverage.MissingItemIDs = append(coverage.MissingItemIDs, itemID)
+			}
+		}
+		coverage.Coverage = float64(coverage.ItemsCovered) / float64(len(req.ItemIDs))
+
+		stores = append(stores, coverage)
+	}
+
+	sort.Slice(stores, func(i, j int) bool {
+		if stores[i].ItemsCovered != stores[j].ItemsCovered {
+			return stores[i].ItemsCovered > stores[j].ItemsCovered
+		}
+		if stores[i].BasketTotal != stores[j].BasketTotal {
+			return stores[i].BasketTotal < stores[j].BasketTotal
+		}
+		return stores[i].StoreID < stores[j].StoreID
+	})
+
+	total := len(stores)
+	if req.Offset >= total {
+		return []*models.StoreCoverage{}, total, nil
+	}
+	end := req.Offset + req.Limit
+	if end > total {
+		end = total
+	}
+
+	return stores[req.Offset:end], total, nil
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestAutoVerifySkipsManuallyUnverifiedStores(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	auto := testStore(t, db, nil)
+	manual := testStore(t, db, nil)
+	unverified := false
+	if _, err := db.UpdateStore(ctx, manual.ID, nil, &models.UpdateStoreRequest{Verified: &unverified}); err != nil {
+		t.Fatalf("UpdateStore: %v", err)
+	}
+
+	for _, store := range []*models.Store{auto, manual} {
+		item := testItem(t, db, nil, nil)
+		for i := 0; i < 2; i++ {
+			user := testUser(t, db)
+			testPrice(t, db, store.ID, item.ID, 1.99, &user.ID)
+		}
+	}
+
+	if _, err := db.AutoVerifyStoresFromContributors(ctx, 2, 0); err != nil {
+		t.Fatalf("AutoVerifyStoresFromContributors: %v", err)
+	}
+
+	got, err := db.GetStoreByID(ctx, auto.ID)
+	if err != nil {
+		t.Fatalf("GetStoreByID: %v", err)
+	}
+	if !got.Verified || got.VerifyBasis == nil || *got.VerifyBasis != "contributors" {
+		t.Errorf("auto store verified %v with basis %v, want verified by contributors", got.Verified, got.VerifyBasis)
+	}
+
+	got, err = db.GetStoreByID(ctx, manual.ID)
+	if err != nil {
+		t.Fatalf("GetStoreByID: %v", err)
+	}
+	if got.Verified {
+		t.Error("store an admin unverified was verified from contributors")
+	}
+}
+
+func TestImportStoresReportsFailedRows(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	missingRegion := -1
+	row := func(regionID *int) *models.CreateStoreRequest {
+		return &models.CreateStoreRequest{
+			Name:          testName("store"),
+			StreetAddress: testName("street"),
+			City:          "Testville",
+			State:         "tx",
+			ZipCode:       "75001",
+			RegionID:      regionID,
+		}
+	}
+
+	results, err := db.ImportStores(ctx, []*models.CreateStoreRequest{row(nil), row(&missingRegion), row(nil)}, nil)
+	if err != nil {
+		t.Fatalf("ImportStores: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[1].Err == nil {
+		t.Error("row with a missing region did not report an error")
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Err != nil || results[i].StoreID == 0 {
+			t.Errorf("row %d = %+v, want a created store", i, results[i])
+			continue
+		}
+		if _, err := db.GetStoreByID(ctx, results[i].StoreID); err != nil {
+			t.Errorf("row %d store was not saved: %v", i, err)
+		}
+	}
+}
+
+func TestListStoresMissingCoordinatesQueuesFailedAttemptsLast(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	failed := testStore(t, db, nil)
+	fresh := testStore(t, db, nil)
+	if err := db.MarkStoreGeocodeAttempted(ctx, failed.ID); err != nil {
+		t.Fatalf("MarkStoreGeocodeAttempted: %v", err)
+	}
+
+	count, err := db.CountStoresMissingCoordinates(ctx)
+	if err != nil {
+		t.Fatalf("CountStoresMissingCoordinates: %v", err)
+	}
+	stores, err := db.ListStoresMissingCoordinates(ctx, count)
+	if err != nil {
+		t.Fatalf("ListStoresMissingCoordinates: %v", err)
+	}
+
+	position := make(map[int]int)
+	for i, store := range stores {
+		position[store.ID] = i
+	}
+	failedAt, okFailed := position[failed.ID]
+	freshAt, okFresh := position[fresh.ID]
+	if !okFailed || !okFresh {
+		t.Fatalf("stores missing from backfill queue: failed %v, fresh %v", okFailed, okFresh)
+	}
+	if freshAt > failedAt {
+		t.Errorf("never-attempted store at %d is queued after failed store at %d", freshAt, failedAt)
+	}
+
+	if err := db.UpdateStoreCoordinates(ctx, failed.ID, 32.9, -96.8, ""); err != nil {
+		t.Fatalf("UpdateStoreCoordinates: %v", err)
+	}
+	stores, err = db.ListStoresMissingCoordinates(ctx, count)
+	if err != nil {
+		t.Fatalf("ListStoresMissingCoordinates: %v", err)
+	}
+	for _, store := range stores {
+		if store.ID == failed.ID {
+			t.Error("geocoded store is still in the backfill queue")
+		}
+	}
+}
+
+func TestStoreWritesLimitedToOwner(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	owner := testUser(t, db)
+	other := testUser(t, db)
+	store := testStore(t, db, &owner.ID)
+	name := testName("renamed")
+
+	if _, err := db.UpdateStore(ctx, store.ID, &other.ID, &models.UpdateStoreRequest{Name: &name}); !errors.Is(err, ErrNotStoreOwner) {
+		t.Errorf("UpdateStore by another user = %v, want ErrNotStoreOwner", err)
+	}
+	if err := db.DeleteStore(ctx, store.ID, &other.ID); !errors.Is(err, ErrNotStoreOwner) {
+		t.Errorf("DeleteStore by another user = %v, want ErrNotStoreOwner", err)
+	}
+	if _, err := db.UpdateStore(ctx, -1, &owner.ID, &models.UpdateStoreRequest{Name: &name}); !errors.Is(err, ErrStoreNotFound) {
+		t.Errorf("UpdateStore of a missing store = %v, want ErrStoreNotFound", err)
+	}
+
+	updated, err := db.UpdateStore(ctx, store.ID, &owner.ID, &models.UpdateStoreRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateStore by owner: %v", err)
+	}
+	if updated.Name != name {
+		t.Errorf("name = %q, want %q", updated.Name, name)
+	}
+
+	if err := db.DeleteStore(ctx, store.ID, &owner.ID); err != nil {
+		t.Fatalf("DeleteStore by owner: %v", err)
+	}
+	if _, err := db.GetStoreByID(ctx, store.ID); !errors.Is(err, ErrStoreNotFound) {
+		t.Errorf("deleted store lookup = %v, want ErrStoreNotFound", err)
+	}
+}
+
+func TestCountOtherContributorPrices(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	owner := testUser(t, db)
+	other := testUser(t, db)
+	store := testStore(t, db, &owner.ID)
+
+	testPrice(t, db, store.ID, testItem(t, db, nil, nil).ID, 1.00, &owner.ID)
+	count, err := db.CountOtherContributorPrices(ctx, store.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("CountOtherContributorPrices: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("count with only the owner's prices = %d, want 0", count)
+	}
+
+	testPrice(t, db, store.ID, testItem(t, db, nil, nil).ID, 2.00, &other.ID)
+	testPrice(t, db, store.ID, testItem(t, db, nil, nil).ID, 3.00, nil)
+	count, err = db.CountOtherContributorPrices(ctx, store.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("CountOtherContributorPrices: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2 (another user's and an anonymous price)", count)
+	}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// testDB connects to the database named by TEST_DATABASE_URL and applies all migrations in
+// version order. Tests that need Postgres are skipped when the variable is unset.
+func testDB(t *testing.T) *DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := Connect(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	ctx := context.Background()
+	if _, err := db.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT NOW()
+		)
+	`); err != nil {
+		t.Fatalf("create migrations table: %v", err)
+	}
+
+	versions := make([]int, 0, len(migrations))
+	for version := range migrations {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	for _, version := range versions {
+		tag, err := db.Pool.Exec(ctx, `
+			INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING
+		`, version)
+		if err != nil {
+			t.Fatalf("record migration %d: %v", version, err)
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		if _, err := db.Pool.Exec(ctx, migrations[version]); err != nil {
+			t.Fatalf("apply migration %d: %v", version, err)
+		}
+	}
+
+	return db
+}
+
+var testSeq atomic.Int64
+
+// testName returns a name that is unique across test runs against the same database.
+func testName(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), testSeq.Add(1))
+}
+
+func testUser(t *testing.T, db *DB) *models.User {
+	t.Helper()
+	name := testName("user")
+	user, err := db.CreateUser(context.Background(), name+"@example.com", "x", &name, nil, nil)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func testStore(t *testing.T, db *DB, createdBy *int) *models.Store {
+	t.Helper()
+	store, err := db.CreateStore(context.Background(), &models.CreateStoreRequest{
+		Name:          testName("store"),
+		StreetAddress: testName("street"),
+		City:          "Testville",
+		State:         "TX",
+		ZipCode:       "75001",
+	}, createdBy)
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	return store
+}
+
+func testItem(t *testing.T, db *DB, req *models.CreateItemRequest, createdBy *int) *models.Item {
+	t.Helper()
+	if req == nil {
+		req = &models.CreateItemRequest{}
+	}
+	if req.Name == "" {
+		req.Name = testName("item")
+	}
+	if req.IsPrivate == nil {
+		public := false
+		req.IsPrivate = &public
+	}
+	item, err := db.CreateItem(context.Background(), req, createdBy)
+	if err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+	return item
+}
+
+func testPrice(t *testing.T, db *DB, storeID, itemID int, price float64, userID *int) *models.StorePrice {
+	t.Helper()
+	sp, err := db.CreatePrice(context.Background(), &models.CreatePriceRequest{
+		StoreID:  storeID,
+		ItemID:   itemID,
+		Price:    price,
+		IsShared: true,
+	}, userID)
+	if err != nil {
+		t.Fatalf("create price: %v", err)
+	}
+	return sp
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+var ErrRegionPrefNotFound = errors.New("region preferences not found")
+
+// ListUserRegionPrefs returns all of a user's per-region preferences
+func (db *DB) ListUserRegionPrefs(ctx context.Context, userID int) ([]*models.UserRegionPref, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.user_id, p.region_id, r.name, p.preferred_store_ids, p.compare_store_ids, p.updated_at
+		FROM user_region_prefs p
+		JOIN regions r ON p.region_id = r.id
+		WHERE p.user_id = $1
+		ORDER BY r.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := []*models.UserRegionPref{}
+	for rows.Next() {
+		p := &models.UserRegionPref{}
+		if err := rows.Scan(&p.UserID, &p.RegionID, &p.RegionName, &p.PreferredStoreIDs, &p.CompareStoreIDs, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+
+	return prefs, rows.Err()
+}
+
+// GetUserRegionPref returns a user's preferences for one region
+func (db *DB) GetUserRegionPref(ctx context.Context, userID, regionID int) (*models.UserRegionPref, error) {
+	p := &models.UserRegionPref{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT p.user_id, p.region_id, r.name, p.preferred_store_ids, p.compare_store_ids, p.updated_at
+		FROM user_region_prefs p
+		JOIN regions r ON p.region_id = r.id
+		WHERE p.user_id = $1 AND p.region_id = $2
+	`, userID, regionID).Scan(&p.UserID, &p.RegionID, &p.RegionName, &p.PreferredStoreIDs, &p.CompareStoreIDs, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRegionPrefNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// SaveUserRegionPref creates or replaces a user's preferences for a region
+func (db *DB) SaveUserRegionPref(ctx context.Context, userID, regionID int, req *models.UpdateUserRegionPrefRequest) (*models.UserRegionPref, error) {
+	preferred, compare := req.PreferredStoreIDs, req.CompareStoreIDs
+	if preferred == nil {
+		preferred = []int{}
+	}
+	if compare == nil {
+		compare = []int{}
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO user_region_prefs (user_id, region_id, preferred_store_ids, compare_store_ids, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id, region_id) DO UPDATE
+		SET preferred_store_ids = EXCLUDED.preferred_store_ids,
+		    compare_store_ids = EXCLUDED.compare_store_ids,
+		    updated_at = NOW()
+	`, userID, regionID, preferred, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetUserRegionPref(ctx, userID, regionID)
+}
+
+// DeleteUserRegionPref removes a user's preferences for a region
+func (db *DB) DeleteUserRegionPref(ctx context.Context, userID, regionID int) error {
+	result, err := db.Pool.Exec(ctx, `
+		DELETE FROM user_region_prefs WHERE user_id = $1 AND region_id = $2
+	`, userID, regionID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRegionPrefNotFound
+	}
+
+	return nil
+}
+
+// CountStoresInRegion counts how many of the given stores belong to a region
+func (db *DB) CountStoresInRegion(ctx context.Context, storeIDs []int, regionID int) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM stores WHERE id = ANY($1::int[]) AND region_id = $2
+	`, storeIDs, regionID).Scan(&count)
+	return count, err
+}
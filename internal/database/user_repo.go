@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -24,6 +25,7 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, userna
 	// Extract location fields from request (if provided)
 	var streetAddress, city, state, zipCode, googlePlaceID *string
 	var latitude, longitude *float64
+	sharePrices, digestOptIn := true, false
 	if req != nil {
 		streetAddress = req.StreetAddress
 		city = req.City
@@ -32,14 +34,20 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, userna
 		latitude = req.Latitude
 		longitude = req.Longitude
 		googlePlaceID = req.GooglePlaceID
+		if req.SharePricesByDefault != nil {
+			sharePrices = *req.SharePricesByDefault
+		}
+		if req.DigestOptIn != nil {
+			digestOptIn = *req.DigestOptIn
+		}
 	}
 
 	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO users (email, password_hash, username, region_id, street_address, city, state, zip_code, latitude, longitude, google_place_id, role, email_verified, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'user', false, NOW(), NOW())
+		INSERT INTO users (email, password_hash, username, region_id, street_address, city, state, zip_code, latitude, longitude, google_place_id, share_prices_by_default, role, email_verified, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 'user', false, NOW(), NOW())
 		RETURNING id, email, password_hash, username, region_id, reputation_points, role, email_verified, created_at, updated_at, last_login_at,
-			street_address, city, state, zip_code, latitude, longitude, google_place_id
-	`, email, passwordHash, username, regionID, streetAddress, city, state, zipCode, latitude, longitude, googlePlaceID).Scan(
+			street_address, city, state, zip_code, latitude, longitude, google_place_id, hide_contributor_name, preferred_store_types, unit_system, share_prices_by_default, leaderboard_opt_out
+	`, email, passwordHash, username, regionID, streetAddress, city, state, zipCode, latitude, longitude, googlePlaceID, sharePrices).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -58,6 +66,11 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, userna
 		&user.Latitude,
 		&user.Longitude,
 		&user.GooglePlaceID,
+		&user.HideContributorName,
+		&user.PreferredStoreTypes,
+		&user.UnitSystem,
+		&user.SharePricesByDefault,
+		&user.LeaderboardOptOut,
 	)
 
 	if err != nil {
@@ -65,24 +78,72 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, userna
 		if err.Error() == `ERROR: duplicate key value violates unique constraint "users_email_key" (SQLSTATE 23505)` {
 			return nil, ErrEmailExists
 		}
-		if err.Error() == `ERROR: duplicate key value violates unique constraint "users_username_key" (SQLSTATE 23505)` {
+		if isUsernameConflict(err) {
 			return nil, ErrUsernameExists
 		}
 		return nil, err
 	}
 
+	if err := db.setRegionDigestPreference(ctx, user.ID, digestOptIn); err != nil {
+		return nil, err
+	}
+	user.DigestOptIn = digestOptIn
+
 	return user, nil
 }
 
+// isUsernameConflict reports whether err violates the exact or case-insensitive username constraint
+func isUsernameConflict(err error) bool {
+	return err.Error() == `ERROR: duplicate key value violates unique constraint "users_username_key" (SQLSTATE 23505)` ||
+		err.Error() == `ERROR: duplicate key value violates unique constraint "idx_users_username_lower" (SQLSTATE 23505)`
+}
+
+// UsernameTaken reports whether another user already has this username, ignoring case
+func (db *DB) UsernameTaken(ctx context.Context, username string, excludeUserID int) (bool, error) {
+	var taken bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users WHERE lower(username) = lower($1) AND id <> $2)
+	`, username, excludeUserID).Scan(&taken)
+	return taken, err
+}
+
+// ListUsernameCollisions returns groups of usernames that differ only by case
+func (db *DB) ListUsernameCollisions(ctx context.Context) ([]models.UsernameCollision, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT lower(username), array_agg(id ORDER BY id), array_agg(username ORDER BY id)
+		FROM users
+		WHERE username IS NOT NULL
+		GROUP BY lower(username)
+		HAVING COUNT(*) > 1
+		ORDER BY lower(username)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collisions := []models.UsernameCollision{}
+	for rows.Next() {
+		var c models.UsernameCollision
+		if err := rows.Scan(&c.Normalized, &c.UserIDs, &c.Usernames); err != nil {
+			return nil, err
+		}
+		collisions = append(collisions, c)
+	}
+
+	return collisions, rows.Err()
+}
+
 // GetUserByID retrieves a user by their ID
 func (db *DB) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	user := &models.User{}
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT u.id, u.email, u.password_hash, u.username, u.region_id, r.name as region_name, u.reputation_points, u.role, u.email_verified, u.created_at, u.updated_at, u.last_login_at,
-			u.street_address, u.city, u.state, u.zip_code, u.latitude, u.longitude, u.google_place_id
+			u.street_address, u.city, u.state, u.zip_code, u.latitude, u.longitude, u.google_place_id, u.hide_contributor_name, u.preferred_store_types, u.unit_system, u.share_prices_by_default, COALESCE(np.region_digest_email, false), u.leaderboard_opt_out
 		FROM users u
 		LEFT JOIN regions r ON u.region_id = r.id
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
 		WHERE u.id = $1
 	`, id).Scan(
 		&user.ID,
@@ -104,6 +165,12 @@ func (db *DB) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 		&user.Latitude,
 		&user.Longitude,
 		&user.GooglePlaceID,
+		&user.HideContributorName,
+		&user.PreferredStoreTypes,
+		&user.UnitSystem,
+		&user.SharePricesByDefault,
+		&user.DigestOptIn,
+		&user.LeaderboardOptOut,
 	)
 
 	if err != nil {
@@ -122,7 +189,7 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT id, email, password_hash, username, region_id, reputation_points, role, email_verified, created_at, updated_at, last_login_at,
-			street_address, city, state, zip_code, latitude, longitude, google_place_id
+			street_address, city, state, zip_code, latitude, longitude, google_place_id, hide_contributor_name, preferred_store_types, unit_system, share_prices_by_default, `+userDigestOptInColumn+`, leaderboard_opt_out
 		FROM users
 		WHERE email = $1
 	`, email).Scan(
@@ -144,6 +211,12 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 		&user.Latitude,
 		&user.Longitude,
 		&user.GooglePlaceID,
+		&user.HideContributorName,
+		&user.PreferredStoreTypes,
+		&user.UnitSystem,
+		&user.SharePricesByDefault,
+		&user.DigestOptIn,
+		&user.LeaderboardOptOut,
 	)
 
 	if err != nil {
@@ -171,11 +244,16 @@ func (db *DB) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequ
 		    latitude = COALESCE($8, latitude),
 		    longitude = COALESCE($9, longitude),
 		    google_place_id = COALESCE($10, google_place_id),
+		    hide_contributor_name = COALESCE($11, hide_contributor_name),
+		    preferred_store_types = COALESCE($12::text[], preferred_store_types),
+		    unit_system = COALESCE($13, unit_system),
+		    share_prices_by_default = COALESCE($14, share_prices_by_default),
+		    leaderboard_opt_out = COALESCE($15, leaderboard_opt_out),
 		    updated_at = NOW()
 		WHERE id = $1
 		RETURNING id, email, password_hash, username, region_id, reputation_points, role, email_verified, created_at, updated_at, last_login_at,
-			street_address, city, state, zip_code, latitude, longitude, google_place_id
-	`, id, req.Username, req.RegionID, req.StreetAddress, req.City, req.State, req.ZipCode, req.Latitude, req.Longitude, req.GooglePlaceID).Scan(
+			street_address, city, state, zip_code, latitude, longitude, google_place_id, hide_contributor_name, preferred_store_types, unit_system, share_prices_by_default, `+userDigestOptInColumn+`, leaderboard_opt_out
+	`, id, req.Username, req.RegionID, req.StreetAddress, req.City, req.State, req.ZipCode, req.Latitude, req.Longitude, req.GooglePlaceID, req.HideContributorName, req.PreferredStoreTypes, req.UnitSystem, req.SharePricesByDefault, req.LeaderboardOptOut).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -194,15 +272,31 @@ func (db *DB) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequ
 		&user.Latitude,
 		&user.Longitude,
 		&user.GooglePlaceID,
+		&user.HideContributorName,
+		&user.PreferredStoreTypes,
+		&user.UnitSystem,
+		&user.SharePricesByDefault,
+		&user.DigestOptIn,
+		&user.LeaderboardOptOut,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
+		if isUsernameConflict(err) {
+			return nil, ErrUsernameExists
+		}
 		return nil, err
 	}
 
+	if req.DigestOptIn != nil {
+		if err := db.setRegionDigestPreference(ctx, id, *req.DigestOptIn); err != nil {
+			return nil, err
+		}
+		user.DigestOptIn = *req.DigestOptIn
+	}
+
 	return user, nil
 }
 
@@ -242,7 +336,7 @@ func (db *DB) AdminUpdateUser(ctx context.Context, id int, req *models.AdminUpda
 		    updated_at = NOW()
 		WHERE id = $1
 		RETURNING id, email, password_hash, username, region_id, reputation_points, role, email_verified, created_at, updated_at, last_login_at,
-			street_address, city, state, zip_code, latitude, longitude, google_place_id
+			street_address, city, state, zip_code, latitude, longitude, google_place_id, hide_contributor_name, preferred_store_types, unit_system, share_prices_by_default, `+userDigestOptInColumn+`, leaderboard_opt_out
 	`, id, req.Email, req.Username, req.Role, req.EmailVerified, req.RegionID).Scan(
 		&user.ID,
 		&user.Email,
@@ -262,12 +356,24 @@ func (db *DB) AdminUpdateUser(ctx context.Context, id int, req *models.AdminUpda
 		&user.Latitude,
 		&user.Longitude,
 		&user.GooglePlaceID,
+		&user.HideContributorName,
+		&user.PreferredStoreTypes,
+		&user.UnitSystem,
+		&user.SharePricesByDefault,
+		&user.DigestOptIn,
+		&user.LeaderboardOptOut,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
+		if err.Error() == `ERROR: duplicate key value violates unique constraint "users_email_key" (SQLSTATE 23505)` {
+			return nil, ErrEmailExists
+		}
+		if isUsernameConflict(err) {
+			return nil, ErrUsernameExists
+		}
 		return nil, err
 	}
 
@@ -300,7 +406,7 @@ func (db *DB) ListUsers(ctx context.Context, limit, offset int) ([]*models.User,
 	// Get users
 	rows, err := db.Pool.Query(ctx, `
 		SELECT id, email, password_hash, username, region_id, reputation_points, role, email_verified, created_at, updated_at, last_login_at,
-			street_address, city, state, zip_code, latitude, longitude, google_place_id
+			street_address, city, state, zip_code, latitude, longitude, google_place_id, hide_contributor_name, preferred_store_types, unit_system, share_prices_by_default, `+userDigestOptInColumn+`, leaderboard_opt_out
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -332,6 +438,12 @@ func (db *DB) ListUsers(ctx context.Context, limit, offset int) ([]*models.User,
 			&user.Latitude,
 			&user.Longitude,
 			&user.GooglePlaceID,
+			&user.HideContributorName,
+			&user.PreferredStoreTypes,
+			&user.UnitSystem,
+			&user.SharePricesByDefault,
+			&user.DigestOptIn,
+			&user.LeaderboardOptOut,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -368,6 +480,133 @@ func (db *DB) GetUserStats(ctx context.Context, userID int) (*models.UserStats,
 	return stats, nil
 }
 
+// GetVerificationImpact judges each of a user's verifications against price history recorded
+// within windowDays afterwards. An "accurate" vote is upheld when the price did not change in
+// that window; an "inaccurate" vote is upheld when it did. Votes younger than the window with
+// no change yet are pending.
+func (db *DB) GetVerificationImpact(ctx context.Context, userID, windowDays int) (*models.VerificationImpact, error) {
+	impact := &models.VerificationImpact{
+		UserID:     userID,
+		WindowDays: windowDays,
+		ComputedAt: time.Now(),
+	}
+
+	err := db.Pool.QueryRow(ctx, `
+		WITH votes AS (
+			SELECT pv.is_accurate, pv.created_at,
+			       pv.created_at <= NOW() - make_interval(days => $2) as matured,
+			       EXISTS (
+			           SELECT 1 FROM price_history ph
+			           WHERE ph.store_id = sp.store_id AND ph.item_id = sp.item_id
+			             AND ph.recorded_at > pv.created_at
+			             AND ph.recorded_at <= pv.created_at + make_interval(days => $2)
+			       ) as changed
+			FROM price_verifications pv
+			JOIN store_prices sp ON pv.price_id = sp.id
+			WHERE pv.user_id = $1
+		)
+		SELECT
+			(SELECT reputation_points FROM users WHERE id = $1),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE is_accurate),
+			COUNT(*) FILTER (WHERE NOT is_accurate),
+			COUNT(*) FILTER (WHERE (is_accurate AND NOT changed AND matured) OR (NOT is_accurate AND changed)),
+			COUNT(*) FILTER (WHERE (is_accurate AND changed) OR (NOT is_accurate AND NOT changed AND matured))
+		FROM votes
+	`, userID, windowDays).Scan(
+		&impact.ReputationPoints,
+		&impact.Verifications,
+		&impact.Confirmations,
+		&impact.Flags,
+		&impact.Upheld,
+		&impact.Corrected,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	impact.Pending = impact.Verifications - impact.Upheld - impact.Corrected
+	if judged := impact.Upheld + impact.Corrected; judged > 0 {
+		rate := float64(impact.Upheld) / float64(judged)
+		impact.AccuracyRate = &rate
+	}
+
+	return impact, nil
+}
+
+// GetSavingsLeaderboard ranks users by estimated savings on confirmed receipt items bought on or
+// after since (nil for all time). Each item is compared with the average shared community price
+// at public stores, excluding the buyer's own prices, over the benchmarkDays leading up to the
+// purchase. Users who opted out are left out and only positive totals are ranked.
+func (db *DB) GetSavingsLeaderboard(ctx context.Context, since *time.Time, benchmarkDays, limit int) ([]*models.SavingsLeaderboardEntry, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH purchases AS (
+			SELECT r.user_id, ri.confirmed_item_id AS item_id, ri.confirmed_price AS paid,
+			       COALESCE(r.receipt_date, r.uploaded_at::date) AS bought_on
+			FROM receipt_items ri
+			JOIN receipts r ON ri.receipt_id = r.id
+			WHERE r.status = 'confirmed' AND ri.is_confirmed = true
+			  AND ri.confirmed_item_id IS NOT NULL AND ri.confirmed_price IS NOT NULL
+			  AND r.user_id IS NOT NULL
+			  AND ($1::date IS NULL OR COALESCE(r.receipt_date, r.uploaded_at::date) >= $1::date)
+		),
+		compared AS (
+			SELECT p.user_id, p.paid, bench.avg_price
+			FROM purchases p
+			CROSS JOIN LATERAL (
+				SELECT AVG(ph.price) AS avg_price
+				FROM price_history ph
+				JOIN stores s ON ph.store_id = s.id
+				WHERE ph.item_id = p.item_id
+				  AND s.is_private = false
+				  AND ph.user_id IS DISTINCT FROM p.user_id
+				  AND ph.recorded_at >= p.bought_on - $2::int
+				  AND ph.recorded_at < p.bought_on + 1
+				  AND EXISTS (
+				      SELECT 1 FROM store_prices sp
+				      WHERE sp.store_id = ph.store_id AND sp.item_id = ph.item_id
+				        AND sp.user_id IS NOT DISTINCT FROM ph.user_id AND sp.is_shared = true
+				  )
+			) bench
+			WHERE bench.avg_price IS NOT NULL
+		)
+		SELECT u.id, u.username, COALESCE(u.hide_contributor_name, false),
+		       SUM(c.avg_price - c.paid)::float8 AS savings,
+		       SUM(c.avg_price)::float8 AS community_total,
+		       COUNT(*) AS items
+		FROM compared c
+		JOIN users u ON c.user_id = u.id
+		WHERE u.leaderboard_opt_out = false
+		GROUP BY u.id, u.username, u.hide_contributor_name
+		HAVING SUM(c.avg_price - c.paid) > 0
+		ORDER BY savings DESC, u.id
+		LIMIT $3
+	`, since, benchmarkDays, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.SavingsLeaderboardEntry{}
+	for rows.Next() {
+		var userID int
+		var communityTotal float64
+		e := &models.SavingsLeaderboardEntry{}
+		if err := rows.Scan(&userID, &e.Username, &e.ContributorHidden, &e.EstimatedSavings, &communityTotal, &e.ItemsCompared); err != nil {
+			return nil, err
+		}
+		e.UserID = &userID
+		e.Rank = len(entries) + 1
+		e.EstimatedSavings = math.Round(e.EstimatedSavings*100) / 100
+		if communityTotal > 0 {
+			e.SavingsPercent = math.Round(e.EstimatedSavings/communityTotal*1000) / 10
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
 // GetAdminStats retrieves system-wide statistics
 func (db *DB) GetAdminStats(ctx context.Context) (*models.AdminStats, error) {
 	stats := &models.AdminStats{}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEnsureUsernameIndexRejectsCaseVariants(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	if err := EnsureUsernameIndex(db); err != nil {
+		t.Fatalf("EnsureUsernameIndex: %v", err)
+	}
+	// Running it again on every startup must be harmless
+	if err := EnsureUsernameIndex(db); err != nil {
+		t.Fatalf("EnsureUsernameIndex again: %v", err)
+	}
+
+	var indexes int
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM pg_indexes WHERE tablename = 'users' AND indexdef ILIKE '%lower(username)%'
+	`).Scan(&indexes); err != nil {
+		t.Fatalf("count indexes: %v", err)
+	}
+	if indexes != 1 {
+		t.Fatalf("case-insensitive username indexes = %d, want 1", indexes)
+	}
+
+	name := testName("Casey")
+	if _, err := db.CreateUser(ctx, testName("a")+"@example.com", "x", &name, nil, nil); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	upper := strings.ToUpper(name)
+	_, err := db.CreateUser(ctx, testName("b")+"@example.com", "x", &upper, nil, nil)
+	if !errors.Is(err, ErrUsernameExists) {
+		t.Fatalf("create case variant: err = %v, want ErrUsernameExists", err)
+	}
+}
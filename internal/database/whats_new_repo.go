@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// GetWhatsNewSeenAt returns when the user last fetched their what's-new digest, or nil if never
+func (db *DB) GetWhatsNewSeenAt(ctx context.Context, userID int) (*time.Time, error) {
+	var seenAt *time.Time
+	err := db.Pool.QueryRow(ctx, `SELECT whats_new_seen_at FROM users WHERE id = $1`, userID).Scan(&seenAt)
+	if err != nil {
+		return nil, err
+	}
+	return seenAt, nil
+}
+
+// MarkWhatsNewSeen records that the user has seen everything up to seenAt.
+// The timestamp never moves backwards if two fetches overlap.
+func (db *DB) MarkWhatsNewSeen(ctx context.Context, userID int, seenAt time.Time) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE users
+		SET whats_new_seen_at = GREATEST(COALESCE(whats_new_seen_at, $2), $2)
+		WHERE id = $1
+	`, userID, seenAt)
+	return err
+}
+
+// GetFollowedItemPrices returns visible prices set after since on items from the user's active
+// shopping lists, excluding the user's own submissions
+func (db *DB) GetFollowedItemPrices(ctx context.Context, userID int, since time.Time, limit int) ([]models.WhatsNewPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT sp.store_id, s.name, sp.item_id, i.name, sp.price, sp.updated_at
+		FROM store_prices sp
+		JOIN stores s ON sp.store_id = s.id
+		JOIN items i ON sp.item_id = i.id
+		WHERE sp.item_id IN (
+			SELECT sli.item_id
+			FROM shopping_list_items sli
+			JOIN shopping_lists sl ON sli.list_id = sl.id
+			WHERE sl.user_id = $1 AND COALESCE(sl.status, 'active') = 'active'
+		)
+		AND sp.updated_at > $2
+		AND sp.is_shared = true
+		AND s.is_private = false
+		AND sp.user_id IS DISTINCT FROM $1
+		ORDER BY sp.updated_at DESC
+		LIMIT $3
+	`, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []models.WhatsNewPrice{}
+	for rows.Next() {
+		var p models.WhatsNewPrice
+		if err := rows.Scan(&p.StoreID, &p.StoreName, &p.ItemID, &p.ItemName, &p.Price, &p.ChangedAt); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
+}
+
+// GetRegionPriceDrops returns price decreases recorded after since at public stores in a region,
+// largest relative drop first
+func (db *DB) GetRegionPriceDrops(ctx context.Context, regionID int, since time.Time, limit int) ([]models.WhatsNewPrice, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ph.store_id, s.name, ph.item_id, i.name, ph.price, ph.previous_price, ph.recorded_at
+		FROM price_history ph
+		JOIN stores s ON ph.store_id = s.id
+		JOIN items i ON ph.item_id = i.id
+		WHERE s.region_id = $1
+		AND s.is_private = false
+		AND ph.recorded_at > $2
+		AND ph.previous_price IS NOT NULL
+		AND ph.previous_price > 0
+		AND ph.price < ph.previous_price
+		ORDER BY (ph.previous_price - ph.price) / ph.previous_price DESC, ph.recorded_at DESC
+		LIMIT $3
+	`, regionID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drops := []models.WhatsNewPrice{}
+	for rows.Next() {
+		var p models.WhatsNewPrice
+		if err := rows.Scan(&p.StoreID, &p.StoreName, &p.ItemID, &p.ItemName, &p.Price, &p.PreviousPrice, &p.ChangedAt); err != nil {
+			return nil, err
+		}
+		drops = append(drops, p)
+	}
+
+	return drops, rows.Err()
+}
+
+// GetNewStoresNear returns public stores created after since within radiusKm of a location, nearest first.
+// The distance is reported in kilometers.
+func (db *DB) GetNewStoresNear(ctx context.Context, lat, lng, radiusKm float64, since time.Time, limit int) ([]models.WhatsNewStore, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, name, city, state, created_at, distance_km
+		FROM (
+			SELECT s.id, s.name, s.city, s.state, s.created_at,
+				6371 * acos(
+					LEAST(1.0, GREATEST(-1.0,
+						cos(radians($1)) * cos(radians(s.latitude)) *
+						cos(radians(s.longitude) - radians($2)) +
+						sin(radians($1)) * sin(radians(s.latitude))
+					))
+				) as distance_km
+			FROM stores s
+			WHERE s.is_private = false
+			AND s.latitude IS NOT NULL
+			AND s.longitude IS NOT NULL
+			AND s.created_at > $4
+		) nearby
+		WHERE distance_km <= $3
+		ORDER BY distance_km ASC
+		LIMIT $5
+	`, lat, lng, radiusKm, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []models.WhatsNewStore{}
+	for rows.Next() {
+		var s models.WhatsNewStore
+		var distance float64
+		if err := rows.Scan(&s.ID, &s.Name, &s.City, &s.State, &s.CreatedAt, &distance); err != nil {
+			return nil, err
+		}
+		s.Distance = &distance
+		stores = append(stores, s)
+	}
+
+	return stores, rows.Err()
+}
+
+// GetNewStoresInRegion returns public stores created after since in a region, newest first.
+// Used when the user has no location for a distance search.
+func (db *DB) GetNewStoresInRegion(ctx context.Context, regionID int, since time.Time, limit int) ([]models.WhatsNewStore, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, name, city, state, created_at
+		FROM stores
+		WHERE region_id = $1 AND is_private = false AND created_at > $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, regionID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []models.WhatsNewStore{}
+	for rows.Next() {
+		var s models.WhatsNewStore
+		if err := rows.Scan(&s.ID, &s.Name, &s.City, &s.State, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		stores = append(stores, s)
+	}
+
+	return stores, rows.Err()
+}
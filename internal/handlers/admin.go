@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,6 +23,9 @@ func (h *Handler) AdminCreateUser(c *fiber.Ctx) error {
 	if req.Email == "" {
 		return Error(c, fiber.StatusBadRequest, "email is required")
 	}
+	if !h.emailDomainAllowed(c.Context(), req.Email, false) {
+		return Error(c, fiber.StatusBadRequest, "email domain is not on the allow-list")
+	}
 	if req.Password == "" {
 		return Error(c, fiber.StatusBadRequest, "password is required")
 	}
@@ -42,8 +46,12 @@ func (h *Handler) AdminCreateUser(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid role")
 	}
 
+	if e := h.validateUsername(c.Context(), req.Username, 0); e != nil {
+		return Error(c, e.Code, e.Message)
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.bcryptCost(c.Context()))
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to hash password")
 	}
@@ -145,6 +153,10 @@ func (h *Handler) AdminUpdateUser(c *fiber.Ctx) error {
 		}
 	}
 
+	if e := h.validateUsername(c.Context(), req.Username, id); e != nil {
+		return Error(c, e.Code, e.Message)
+	}
+
 	user, err := h.db.AdminUpdateUser(c.Context(), id, &req)
 	if err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
@@ -159,9 +171,28 @@ func (h *Handler) AdminUpdateUser(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to update user")
 	}
 
+	// Verifying a user by hand also shares the prices held while they were unverified
+	if req.EmailVerified != nil && *req.EmailVerified {
+		if _, err := h.db.ShareHeldPrices(c.Context(), id); err != nil {
+			log.Printf("Warning: Failed to share held prices for user %d: %v", id, err)
+		}
+	}
+
 	return Success(c, user)
 }
 
+// AdminListUsernameCollisions returns existing usernames that differ only by case. These block
+// the case-insensitive username index until an admin renames one user in each group.
+// GET /api/admin/users/username-collisions
+func (h *Handler) AdminListUsernameCollisions(c *fiber.Ctx) error {
+	collisions, err := h.db.ListUsernameCollisions(c.Context())
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list username collisions")
+	}
+
+	return Success(c, collisions)
+}
+
 // AdminDeleteUser deletes a user
 func (h *Handler) AdminDeleteUser(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
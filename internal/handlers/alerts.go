@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// ListPriceAlerts returns the current user's price alerts with when each last fired and the
+// best current price against its target
+// GET /api/alerts
+func (h *Handler) ListPriceAlerts(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	alerts, err := h.db.ListPriceAlerts(c.Context(), userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list price alerts")
+	}
+
+	return Success(c, alerts)
+}
+
+// CreatePriceAlert asks to be emailed when an item is priced at or below a target,
+// optionally only at one store
+// POST /api/alerts
+func (h *Handler) CreatePriceAlert(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	var req models.CreatePriceAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if req.TargetPrice <= 0 {
+		return Error(c, fiber.StatusBadRequest, "target_price must be greater than 0")
+	}
+
+	item, err := h.db.GetItemByID(c.Context(), req.ItemID)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+	if item.IsPrivate && (item.CreatedBy == nil || *item.CreatedBy != userID) {
+		return Error(c, fiber.StatusNotFound, "item not found")
+	}
+
+	if req.StoreID != nil {
+		store, err := h.db.GetStoreByID(c.Context(), *req.StoreID)
+		if err != nil {
+			if errors.Is(err, database.ErrStoreNotFound) {
+				return Error(c, fiber.StatusNotFound, "store not found")
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get store")
+		}
+		if store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != userID) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+	}
+
+	alert, err := h.db.CreatePriceAlert(c.Context(), userID, &req)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to create price alert")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    alert,
+	})
+}
+
+// TestPriceAlert evaluates one of the current user's alerts against current prices and reports
+// whether it would fire, without sending anything
+// POST /api/alerts/:id/test
+func (h *Handler) TestPriceAlert(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid alert id")
+	}
+
+	result, err := h.db.TestPriceAlert(c.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrPriceAlertNotFound) {
+			return Error(c, fiber.StatusNotFound, "price alert not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to test price alert")
+	}
+
+	return Success(c, result)
+}
+
+// DeletePriceAlert removes one of the current user's price alerts
+// DELETE /api/alerts/:id
+func (h *Handler) DeletePriceAlert(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid alert id")
+	}
+
+	if err := h.db.DeletePriceAlert(c.Context(), id, userID); err != nil {
+		if errors.Is(err, database.ErrPriceAlertNotFound) {
+			return Error(c, fiber.StatusNotFound, "price alert not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to delete price alert")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "price alert deleted",
+	})
+}
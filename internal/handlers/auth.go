@@ -8,6 +8,7 @@ import (
 	"errors"
 	"log"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -45,6 +46,117 @@ func (h *Handler) getEncryptionKey() []byte {
 	return DeriveEncryptionKey(h.cfg.JWTSecret)
 }
 
+// Bounds for the configurable bcrypt cost; higher costs make logins noticeably slow
+const (
+	minBcryptCost = 10
+	maxBcryptCost = 16
+)
+
+// bcryptCost returns the configured bcrypt cost, clamped to a safe range
+func (h *Handler) bcryptCost(ctx context.Context) int {
+	cost := h.db.GetSettingInt(ctx, "bcrypt_cost", bcrypt.DefaultCost, h.getEncryptionKey())
+	if cost < minBcryptCost {
+		return minBcryptCost
+	}
+	if cost > maxBcryptCost {
+		return maxBcryptCost
+	}
+	return cost
+}
+
+// rehashPasswordIfNeeded re-stores a user's password hash when its cost differs from the configured cost
+func (h *Handler) rehashPasswordIfNeeded(ctx context.Context, user *models.User, password string) {
+	currentCost, err := bcrypt.Cost([]byte(user.PasswordHash))
+	if err != nil {
+		return
+	}
+
+	targetCost := h.bcryptCost(ctx)
+	if currentCost == targetCost {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), targetCost)
+	if err != nil {
+		log.Printf("Warning: Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+
+	if err := h.db.UpdateUserPassword(ctx, user.ID, string(hashed)); err != nil {
+		log.Printf("Warning: Failed to store rehashed password for user %d: %v", user.ID, err)
+		return
+	}
+
+	user.PasswordHash = string(hashed)
+}
+
+// validateUsername trims a requested username in place and checks its length. When
+// username_case_insensitive is enabled, a case variant of another user's name is rejected.
+// excludeUserID is the user being updated (0 for new users).
+func (h *Handler) validateUsername(ctx context.Context, username *string, excludeUserID int) *fiber.Error {
+	if username == nil {
+		return nil
+	}
+
+	*username = strings.TrimSpace(*username)
+	if len(*username) < 3 || len(*username) > 50 {
+		return fiber.NewError(fiber.StatusBadRequest, "username must be between 3 and 50 characters")
+	}
+
+	encryptionKey := h.getEncryptionKey()
+	if !h.db.GetSettingBool(ctx, "username_case_insensitive", true, encryptionKey) {
+		return nil
+	}
+
+	taken, err := h.db.UsernameTaken(ctx, *username, excludeUserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check username")
+	}
+	if taken {
+		return fiber.NewError(fiber.StatusConflict, "username already taken")
+	}
+
+	return nil
+}
+
+// emailDomainAllowed checks an email against the configured domain allow-list.
+// selfRegistration selects whether the list also applies to public sign-ups.
+func (h *Handler) emailDomainAllowed(ctx context.Context, email string, selfRegistration bool) bool {
+	key := h.getEncryptionKey()
+	if !h.db.GetSettingBool(ctx, "email_domain_allowlist_enabled", false, key) {
+		return true
+	}
+	if selfRegistration && !h.db.GetSettingBool(ctx, "email_domain_allowlist_register", false, key) {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	allowList := h.db.GetSettingString(ctx, "email_domain_allowlist", "", key)
+	for _, pattern := range strings.Split(allowList, ",") {
+		if matchEmailDomain(domain, strings.ToLower(strings.TrimSpace(pattern))) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEmailDomain matches a domain against an exact pattern or a "*.example.com" wildcard,
+// which covers any subdomain of example.com but not example.com itself
+func matchEmailDomain(domain, pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(domain, "."+suffix)
+	}
+	return domain == pattern
+}
+
 // isEmailVerificationRequired checks if email verification is enabled
 func (h *Handler) isEmailVerificationRequired(c *fiber.Ctx) bool {
 	return h.db.GetSettingBool(c.Context(), "require_email_verify", false, h.getEncryptionKey())
@@ -72,6 +184,9 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 	if !emailRegex.MatchString(req.Email) {
 		return Error(c, fiber.StatusBadRequest, "invalid email format")
 	}
+	if !h.emailDomainAllowed(c.Context(), req.Email, true) {
+		return Error(c, fiber.StatusForbidden, "registration is not allowed for this email domain")
+	}
 
 	// Validate password
 	if len(req.Password) < 8 {
@@ -79,19 +194,28 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 	}
 
 	// Validate username if provided
-	if req.Username != nil {
-		if len(*req.Username) < 3 || len(*req.Username) > 50 {
-			return Error(c, fiber.StatusBadRequest, "username must be between 3 and 50 characters")
-		}
+	if e := h.validateUsername(c.Context(), req.Username, 0); e != nil {
+		return Error(c, e.Code, e.Message)
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.bcryptCost(c.Context()))
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to process password")
 	}
 
-	// Create user (pass full request to include location fields)
+	// Contribution choices not made on the form take the configured onboarding defaults
+	key := h.getEncryptionKey()
+	if req.SharePricesByDefault == nil {
+		share := h.db.GetSettingBool(c.Context(), "onboarding_share_prices_default", true, key)
+		req.SharePricesByDefault = &share
+	}
+	if req.DigestOptIn == nil {
+		optIn := h.db.GetSettingBool(c.Context(), "onboarding_digest_opt_in_default", false, key)
+		req.DigestOptIn = &optIn
+	}
+
+	// Create user (pass full request to include location and onboarding fields)
 	user, err := h.db.CreateUser(c.Context(), req.Email, string(hashedPassword), req.Username, req.RegionID, &req)
 	if err != nil {
 		if errors.Is(err, database.ErrEmailExists) {
@@ -103,6 +227,21 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to create user")
 	}
 
+	// Opting in to digests subscribes the user to a weekly digest of their home region
+	var digestRegionID *int
+	if user.DigestOptIn && user.RegionID != nil {
+		_, err := h.db.CreateRegionSubscription(c.Context(), user.ID, &models.CreateRegionSubscriptionRequest{
+			RegionID:   *user.RegionID,
+			Frequency:  models.DigestFrequencyWeekly,
+			Categories: []string{},
+		})
+		if err != nil {
+			log.Printf("Warning: failed to subscribe user %d to region %d digest: %v", user.ID, *user.RegionID, err)
+		} else {
+			digestRegionID = user.RegionID
+		}
+	}
+
 	// Check if email verification is required
 	requireVerification := h.isEmailVerificationRequired(c)
 
@@ -157,6 +296,11 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 		"user":                     user,
 		"email_verification_sent":  requireVerification && h.emailService.IsConfiguredWithContext(c.Context()),
 		"email_verification_required": requireVerification,
+		"onboarding": fiber.Map{
+			"share_prices_by_default": user.SharePricesByDefault,
+			"digest_opt_in":           user.DigestOptIn,
+			"digest_region_id":        digestRegionID,
+		},
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(response)
@@ -193,6 +337,9 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusUnauthorized, "invalid credentials")
 	}
 
+	// Upgrade the stored hash if the configured cost has changed
+	h.rehashPasswordIfNeeded(c.Context(), user, req.Password)
+
 	// Update last login
 	h.db.UpdateUserLastLogin(c.Context(), user.ID)
 
@@ -310,6 +457,11 @@ func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to verify email")
 	}
 
+	// Share prices that were held private while the account was unverified
+	if _, err := h.db.ShareHeldPrices(c.Context(), evt.UserID); err != nil {
+		log.Printf("Warning: Failed to share held prices for user %d: %v", evt.UserID, err)
+	}
+
 	return Success(c, fiber.Map{
 		"message": "Email verified successfully",
 	})
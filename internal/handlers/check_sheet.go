@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
+	"github.com/foxxcyber/price-feed/internal/services"
+)
+
+// checkSheetCSVHeader is the column layout of the CSV check sheet; new_price and notes are left blank for the contributor
+var checkSheetCSVHeader = []string{"stale", "category", "item_id", "item_name", "brand", "last_price", "last_verified", "new_price", "notes"}
+
+// uncategorizedLabel groups items without tags at the end of the sheet
+const uncategorizedLabel = "Uncategorized"
+
+// GetStoreCheckSheet returns a printable sheet of the store's priced items for verifying
+// prices in person, stale prices first and then by category
+// GET /api/stores/:id/check-sheet?format=csv|pdf
+func (h *Handler) GetStoreCheckSheet(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid store id")
+	}
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "pdf" {
+		return Error(c, fiber.StatusBadRequest, "format must be csv or pdf")
+	}
+
+	store, err := h.db.GetStoreByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get store")
+	}
+	userID := middleware.GetUserID(c)
+	if store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != userID) {
+		return Error(c, fiber.StatusNotFound, "store not found")
+	}
+
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, h.getEncryptionKey())
+	if staleDays < 1 {
+		staleDays = 30
+	}
+
+	prices, _, err := h.db.GetPricesByStore(c.Context(), id, nil)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get prices")
+	}
+
+	sheet, err := h.buildCheckSheet(c, store, prices, userID, staleDays)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get item categories")
+	}
+
+	if format == "pdf" {
+		return writeCheckSheetPDF(c, sheet)
+	}
+	return writeCheckSheetCSV(c, sheet)
+}
+
+// buildCheckSheet keeps the most recent visible price per item and orders the rows
+// stale first, then by category and item name
+func (h *Handler) buildCheckSheet(c *fiber.Ctx, store *models.StoreWithStats, prices []*models.StorePriceWithDetails, userID, staleDays int) (*models.CheckSheet, error) {
+	now := time.Now()
+	staleBefore := now.AddDate(0, 0, -staleDays)
+
+	latest := make(map[int]*models.StorePriceWithDetails)
+	for _, p := range prices {
+		// Private prices only show up on their owner's sheet
+		if !p.IsShared && (p.UserID == nil || *p.UserID != userID) {
+			continue
+		}
+		if cur, ok := latest[p.ItemID]; !ok || lastTouched(p).After(lastTouched(cur)) {
+			latest[p.ItemID] = p
+		}
+	}
+
+	itemIDs := make([]int, 0, len(latest))
+	for itemID := range latest {
+		itemIDs = append(itemIDs, itemID)
+	}
+	categories, err := h.db.GetItemCategories(c.Context(), itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &models.CheckSheet{
+		StoreID:        store.ID,
+		StoreName:      store.Name,
+		StaleAfterDays: staleDays,
+		GeneratedAt:    now,
+		Rows:           make([]models.CheckSheetRow, 0, len(latest)),
+	}
+	for itemID, p := range latest {
+		row := models.CheckSheetRow{
+			ItemID:       itemID,
+			ItemName:     p.ItemName,
+			ItemBrand:    p.ItemBrand,
+			Category:     categories[itemID],
+			Price:        p.Price,
+			LastVerified: p.LastVerified,
+			UpdatedAt:    p.UpdatedAt,
+			IsStale:      lastTouched(p).Before(staleBefore),
+		}
+		if row.Category == "" {
+			row.Category = uncategorizedLabel
+		}
+		if row.IsStale {
+			sheet.StaleCount++
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	sort.Slice(sheet.Rows, func(i, j int) bool {
+		a, b := sheet.Rows[i], sheet.Rows[j]
+		if a.IsStale != b.IsStale {
+			return a.IsStale
+		}
+		if a.Category != b.Category {
+			// Untagged items go last within their staleness group
+			if a.Category == uncategorizedLabel || b.Category == uncategorizedLabel {
+				return b.Category == uncategorizedLabel
+			}
+			return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+		}
+		if !strings.EqualFold(a.ItemName, b.ItemName) {
+			return strings.ToLower(a.ItemName) < strings.ToLower(b.ItemName)
+		}
+		return a.ItemID < b.ItemID
+	})
+
+	return sheet, nil
+}
+
+// lastTouched returns when a price was last confirmed, by an update or a verification
+func lastTouched(p *models.StorePriceWithDetails) time.Time {
+	if p.LastVerified != nil && p.LastVerified.After(p.UpdatedAt) {
+		return *p.LastVerified
+	}
+	return p.UpdatedAt
+}
+
+// checkSheetVerifiedDate formats the last-verified date, or "never" for unverified prices
+func checkSheetVerifiedDate(row models.CheckSheetRow) string {
+	if row.LastVerified == nil {
+		return "never"
+	}
+	return row.LastVerified.Format("2006-01-02")
+}
+
+// writeCheckSheetCSV streams one row per item to the response
+func writeCheckSheetCSV(c *fiber.Ctx, sheet *models.CheckSheet) error {
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="store-%d-check-sheet.csv"`, sheet.StoreID))
+
+	w := csv.NewWriter(c)
+	if err := w.Write(checkSheetCSVHeader); err != nil {
+		return err
+	}
+
+	for _, row := range sheet.Rows {
+		brand := ""
+		if row.ItemBrand != nil {
+			brand = *row.ItemBrand
+		}
+		record := []string{
+			strconv.FormatBool(row.IsStale),
+			row.Category,
+			strconv.Itoa(row.ItemID),
+			row.ItemName,
+			brand,
+			fmt.Sprintf("%.2f", row.Price),
+			checkSheetVerifiedDate(row),
+			"",
+			"",
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// writeCheckSheetPDF renders the sheet as a printable table with a blank column for the new price
+func writeCheckSheetPDF(c *fiber.Ctx, sheet *models.CheckSheet) error {
+	const rowFormat = "%-1s %-18.18s %-42.42s %9s  %-10s  %s"
+
+	header := []string{
+		fmt.Sprintf("Price check sheet: %s", sheet.StoreName),
+		fmt.Sprintf("Generated %s - %d items, %d stale (* = not confirmed in %d days)",
+			sheet.GeneratedAt.Format("2006-01-02"), len(sheet.Rows), sheet.StaleCount, sheet.StaleAfterDays),
+		"",
+		fmt.Sprintf(rowFormat, "", "Category", "Item", "Last", "Verified", "New price"),
+	}
+
+	lines := make([]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		stale := ""
+		if row.IsStale {
+			stale = "*"
+		}
+		name := row.ItemName
+		if row.ItemBrand != nil && *row.ItemBrand != "" {
+			name = *row.ItemBrand + " " + name
+		}
+		lines = append(lines, fmt.Sprintf(rowFormat, stale, row.Category, name,
+			fmt.Sprintf("%.2f", row.Price), checkSheetVerifiedDate(row), "________"))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No prices recorded for this store yet.")
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="store-%d-check-sheet.pdf"`, sheet.StoreID))
+	return c.Send(services.RenderTextPDF(header, lines))
+}
@@ -1,6 +1,14 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/foxxcyber/price-feed/internal/config"
@@ -15,18 +23,39 @@ type Handler struct {
 	cfg            *config.Config
 	captchaService *services.CaptchaService
 	emailService   *services.EmailService
+	priceAlerter   *services.PriceAlerter
+	tagSuggester   *services.TagSuggester
+	addressCheck   *services.AddressValidator
+
+	impactMu    sync.Mutex
+	impactCache map[int]*models.VerificationImpact
+
+	savingsMu    sync.Mutex
+	savingsCache map[string]*savingsLeaderboard
 }
 
 // New creates a new Handler instance
 func New(db *database.DB, cfg *config.Config) *Handler {
+	emailService := services.NewEmailService(db, cfg)
 	return &Handler{
 		db:             db,
 		cfg:            cfg,
 		captchaService: services.NewCaptchaService(db, cfg),
-		emailService:   services.NewEmailService(db, cfg),
+		emailService:   emailService,
+		priceAlerter:   services.NewPriceAlerter(db, emailService),
+		tagSuggester:   services.NewTagSuggester(db, cfg),
+		addressCheck:   services.NewAddressValidator(db, cfg),
+		impactCache:    make(map[int]*models.VerificationImpact),
+		savingsCache:   make(map[string]*savingsLeaderboard),
 	}
 }
 
+// verificationImpactTTL is how long a computed verification impact is reused
+const verificationImpactTTL = 15 * time.Minute
+
+// verificationImpactCacheSize caps how many users' verification impacts are kept
+const verificationImpactCacheSize = 1000
+
 // ErrorHandler is a custom error handler for Fiber
 func ErrorHandler(c *fiber.Ctx, err error) error {
 	// Default to 500
@@ -50,6 +79,8 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Meta    *Meta       `json:"meta,omitempty"`
+	// Non-fatal issues with the request, e.g. address discrepancies
+	Warnings interface{} `json:"warnings,omitempty"`
 }
 
 // Meta contains pagination metadata
@@ -88,6 +119,39 @@ func Error(c *fiber.Ctx, status int, message string) error {
 	})
 }
 
+// errInvalidBody is returned by parseBody for bodies that cannot be decoded
+var errInvalidBody = errors.New("invalid request body")
+
+// parseBody decodes the request body into out. When the strict_json_binding setting is on,
+// JSON bodies with fields the request type does not declare are rejected so that typos
+// such as "quantiy" fail loudly instead of being silently dropped. The returned error is
+// safe to show to the client.
+func (h *Handler) parseBody(c *fiber.Ctx, out interface{}) error {
+	return bindBody(c, out, h.db.GetSettingBool(c.Context(), "strict_json_binding", false, h.getEncryptionKey()))
+}
+
+// bindBody decodes the request body into out, rejecting unknown JSON fields when strict is set
+func bindBody(c *fiber.Ctx, out interface{}, strict bool) error {
+	if !strict || !strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEApplicationJSON) {
+		if err := c.BodyParser(out); err != nil {
+			return errInvalidBody
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		// encoding/json reports these as: json: unknown field "name"
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("unknown field %s in request body", field)
+		}
+		return errInvalidBody
+	}
+
+	return nil
+}
+
 // CreateEmailVerificationChecker creates a function for checking email verification status
 // This can be used with the EmailVerifiedRequiredFunc middleware
 func (h *Handler) CreateEmailVerificationChecker() func(c *fiber.Ctx) (required bool, verified bool, isAdmin bool, err error) {
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBindBody(t *testing.T) {
+	type request struct {
+		Name     string `json:"name" form:"name"`
+		Quantity int    `json:"quantity" form:"quantity"`
+	}
+
+	tests := []struct {
+		name        string
+		strict      bool
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{"lenient ignores unknown field", false, fiber.MIMEApplicationJSON, `{"name":"milk","quantiy":2}`, fiber.StatusOK, "milk 0"},
+		{"strict accepts known fields", true, fiber.MIMEApplicationJSON, `{"name":"milk","quantity":2}`, fiber.StatusOK, "milk 2"},
+		{"strict rejects unknown field", true, fiber.MIMEApplicationJSON, `{"name":"milk","quantiy":2}`, fiber.StatusBadRequest, `unknown field "quantiy" in request body`},
+		{"strict with charset rejects unknown field", true, fiber.MIMEApplicationJSONCharsetUTF8, `{"quantiy":2}`, fiber.StatusBadRequest, `unknown field "quantiy" in request body`},
+		{"strict rejects malformed body", true, fiber.MIMEApplicationJSON, `{"name":`, fiber.StatusBadRequest, errInvalidBody.Error()},
+		{"lenient rejects malformed body", false, fiber.MIMEApplicationJSON, `{"name":`, fiber.StatusBadRequest, errInvalidBody.Error()},
+		{"strict leaves form bodies lenient", true, fiber.MIMEApplicationForm, `name=milk&quantiy=2`, fiber.StatusOK, "milk 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				var req request
+				if err := bindBody(c, &req, tt.strict); err != nil {
+					return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+				}
+				return c.SendString(fmt.Sprintf("%s %d", req.Name, req.Quantity))
+			})
+
+			r := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set(fiber.HeaderContentType, tt.contentType)
+			resp, err := app.Test(r)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// importTokenHeader carries the token on price import requests
+const importTokenHeader = "X-Import-Token"
+
+// hashImportToken returns the stored form of an import token
+func hashImportToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AdminCreateImportToken generates a single-use token for uploading prices without a login.
+// The plaintext token is only returned here; the database keeps its hash.
+// POST /api/admin/import-tokens
+func (h *Handler) AdminCreateImportToken(c *fiber.Ctx) error {
+	var req models.CreateImportTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	ctx := c.Context()
+
+	req.Label = strings.TrimSpace(req.Label)
+	if len(req.Label) > 100 {
+		return Error(c, fiber.StatusBadRequest, "label must be 100 characters or fewer")
+	}
+	if req.Scope == "" {
+		req.Scope = models.ImportTokenScopePrices
+	}
+	if req.Scope != models.ImportTokenScopePrices {
+		return Error(c, fiber.StatusBadRequest, "scope must be prices")
+	}
+
+	maxRows := h.db.GetSettingInt(ctx, "import_token_max_rows", 5000, h.getEncryptionKey())
+	if req.MaxRows == 0 {
+		req.MaxRows = maxRows
+	}
+	if req.MaxRows < 1 || req.MaxRows > maxRows {
+		return Error(c, fiber.StatusBadRequest, "max_rows must be between 1 and the import_token_max_rows setting")
+	}
+
+	maxTTL := h.db.GetSettingInt(ctx, "import_token_max_ttl_minutes", 1440, h.getEncryptionKey())
+	if req.TTLMinutes == 0 {
+		req.TTLMinutes = h.db.GetSettingInt(ctx, "import_token_default_ttl_minutes", 60, h.getEncryptionKey())
+	}
+	if req.TTLMinutes < 1 || req.TTLMinutes > maxTTL {
+		return Error(c, fiber.StatusBadRequest, "ttl_minutes must be between 1 and the import_token_max_ttl_minutes setting")
+	}
+
+	if req.StoreIDs == nil {
+		req.StoreIDs = []int{}
+	}
+	for _, storeID := range req.StoreIDs {
+		store, err := h.db.GetStoreByID(ctx, storeID)
+		if err != nil {
+			if errors.Is(err, database.ErrStoreNotFound) {
+				return Error(c, fiber.StatusBadRequest, "store not found")
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get store")
+		}
+		if store.IsPrivate {
+			return Error(c, fiber.StatusBadRequest, "import tokens can only target public stores")
+		}
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to generate token")
+	}
+
+	adminID := middleware.GetUserID(c)
+	expiresAt := time.Now().Add(time.Duration(req.TTLMinutes) * time.Minute)
+	info, err := h.db.CreateImportToken(ctx, hashImportToken(token), &req, adminID, expiresAt)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to create import token")
+	}
+
+	log.Printf("Import token %d created by user %d (scope %s, expires %s)", info.ID, adminID, info.Scope, info.ExpiresAt.Format(time.RFC3339))
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    &models.CreateImportTokenResponse{Token: token, Info: info},
+	})
+}
+
+// AdminListImportTokens returns import tokens with when, from where and how much they imported
+// GET /api/admin/import-tokens
+func (h *Handler) AdminListImportTokens(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	tokens, total, err := h.db.ListImportTokens(c.Context(), limit, offset)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list import tokens")
+	}
+
+	return SuccessWithMeta(c, tokens, total, limit, offset)
+}
+
+// ImportPricesWithToken uploads a batch of prices authorized by an import token instead of
+// a login. The token is consumed by the first request within its scope and row limit, even if
+// every row is rejected, and the prices are attributed to the admin who created it. Prices
+// beyond price_outlier_factor of the item's typical price are rejected.
+// POST /api/import/prices
+func (h *Handler) ImportPricesWithToken(c *fiber.Ctx) error {
+	raw := strings.TrimSpace(c.Get(importTokenHeader))
+	if raw == "" {
+		return Error(c, fiber.StatusUnauthorized, "missing import token")
+	}
+
+	var req models.ImportPricesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Prices) == 0 {
+		return Error(c, fiber.StatusBadRequest, "at least one price is required")
+	}
+	ctx := c.Context()
+
+	token, err := h.db.GetUsableImportToken(ctx, hashImportToken(raw))
+	if err != nil {
+		return importTokenError(c, err)
+	}
+
+	if token.Scope != models.ImportTokenScopePrices {
+		return Error(c, fiber.StatusForbidden, "import token does not allow price imports")
+	}
+	if len(req.Prices) > token.MaxRows {
+		return Error(c, fiber.StatusRequestEntityTooLarge, "too many prices for this import token")
+	}
+
+	// Nobody is around to confirm an unusual price, so outliers are always rejected
+	for i, e := range req.Prices {
+		if e.Price <= 0 {
+			continue
+		}
+		if outlier := h.detectPriceOutlier(c, e.ItemID, e.Price); outlier != nil {
+			req.Prices[i].Rejection = fmt.Sprintf("outlier compared to the typical price of %.2f", outlier.Median)
+		}
+	}
+
+	result, err := h.db.ImportTokenPrices(ctx, token, c.IP(), req.Prices)
+	if err != nil {
+		if errors.Is(err, database.ErrImportTokenUsed) || errors.Is(err, database.ErrImportTokenExpired) {
+			return importTokenError(c, err)
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to import prices")
+	}
+
+	log.Printf("Import token %d consumed from %s: %d prices imported, %d rejected", token.ID, c.IP(), result.Imported, result.Rejected)
+
+	return Success(c, result)
+}
+
+// importTokenError responds to a token that cannot be used
+func importTokenError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, database.ErrImportTokenNotFound):
+		return Error(c, fiber.StatusUnauthorized, "invalid import token")
+	case errors.Is(err, database.ErrImportTokenUsed):
+		log.Printf("Rejected reuse of import token from %s", c.IP())
+		return Error(c, fiber.StatusUnauthorized, "import token has already been used")
+	case errors.Is(err, database.ErrImportTokenExpired):
+		return Error(c, fiber.StatusUnauthorized, "import token has expired")
+	}
+	return Error(c, fiber.StatusInternalServerError, "failed to validate import token")
+}
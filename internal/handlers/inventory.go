@@ -3,11 +3,13 @@ package handlers
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/foxxcyber/price-feed/internal/database"
 	"github.com/foxxcyber/price-feed/internal/models"
+	"github.com/foxxcyber/price-feed/internal/services"
 )
 
 // ListInventoryItems returns all inventory items for the current user
@@ -95,8 +97,13 @@ func (h *Handler) CreateInventoryItem(c *fiber.Ctx) error {
 	}
 
 	var req models.CreateInventoryItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if req.CustomName != nil {
+		name := sanitizeName(*req.CustomName)
+		req.CustomName = &name
 	}
 
 	// Validate: must have either item_id OR custom_name
@@ -133,8 +140,13 @@ func (h *Handler) UpdateInventoryItem(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateInventoryItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if req.CustomName != nil {
+		name := sanitizeName(*req.CustomName)
+		req.CustomName = &name
 	}
 
 	// Validate quantity if provided
@@ -194,8 +206,8 @@ func (h *Handler) AdjustInventoryQuantity(c *fiber.Ctx) error {
 	}
 
 	var req models.AdjustInventoryQuantityRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	item, err := h.db.AdjustInventoryQuantity(c.Context(), id, userID, req.Adjustment)
@@ -212,6 +224,42 @@ func (h *Handler) AdjustInventoryQuantity(c *fiber.Ctx) error {
 	return Success(c, item)
 }
 
+// GetInventoryConsumption returns an inventory item's average consumption rate and projected run-out date
+func (h *Handler) GetInventoryConsumption(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid inventory item id")
+	}
+
+	days := c.QueryInt("days", 90)
+	if days < 7 {
+		days = 7
+	}
+	if days > 365 {
+		days = 365
+	}
+
+	leadDays := h.db.GetSettingInt(c.Context(), "inventory_restock_lead_days", 7, h.getEncryptionKey())
+
+	consumption, err := h.db.GetInventoryConsumption(c.Context(), id, userID, days, leadDays)
+	if err != nil {
+		if errors.Is(err, database.ErrInventoryItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "inventory item not found")
+		}
+		if errors.Is(err, database.ErrNotInventoryOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this inventory item")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get inventory consumption")
+	}
+
+	return Success(c, consumption)
+}
+
 // GetInventorySummary returns aggregate stats for user's inventory
 func (h *Handler) GetInventorySummary(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
@@ -305,6 +353,24 @@ func (h *Handler) AddInventoryToShoppingList(c *fiber.Ctx) error {
 		req.Quantity = 1
 	}
 
+	// An amount with a unit is bought as whole packages of the item's size
+	if req.Amount != nil {
+		item, err := h.db.GetInventoryItemByID(c.Context(), inventoryID, userID)
+		if err != nil {
+			if errors.Is(err, database.ErrInventoryItemNotFound) {
+				return Error(c, fiber.StatusNotFound, "inventory item not found")
+			}
+			if errors.Is(err, database.ErrNotInventoryOwner) {
+				return Error(c, fiber.StatusForbidden, "you do not own this inventory item")
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get inventory item")
+		}
+		req.Quantity, err = inventoryPackages(item, *req.Amount, req.Unit)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
 	err = h.db.AddInventoryItemToShoppingList(c.Context(), inventoryID, userID, req.ListID, req.Quantity)
 	if err != nil {
 		if errors.Is(err, database.ErrInventoryItemNotFound) {
@@ -327,8 +393,75 @@ func (h *Handler) AddInventoryToShoppingList(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "item added to shopping list successfully",
+		"success":  true,
+		"message":  "item added to shopping list successfully",
+		"quantity": req.Quantity,
+	})
+}
+
+// inventoryPackages converts an amount into whole packages of an inventory item. The unit defaults
+// to the inventory item's unit and the package size to its own size, then the catalog item's.
+func inventoryPackages(item *models.InventoryItemWithDetails, amount float64, unit *string) (int, error) {
+	if unit == nil || *unit == "" {
+		unit = item.Unit
+	}
+	if unit == nil || *unit == "" {
+		return 0, errors.New("unit is required with amount")
+	}
+
+	size, sizeUnit := item.CustomSize, item.CustomUnit
+	if size == nil || sizeUnit == nil {
+		size, sizeUnit = item.ItemSize, item.ItemUnit
+	}
+	if size == nil || sizeUnit == nil {
+		return 0, services.ErrNoPackageSize
+	}
+
+	return services.PackagesFor(amount, *unit, *size, *sizeUnit)
+}
+
+// BuildRestockList creates a shopping list from low-stock and soon-expiring inventory
+// POST /api/inventory/restock-list
+func (h *Handler) BuildRestockList(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Body is optional
+	var req models.RestockListRequest
+	if len(c.Body()) > 0 {
+		if err := h.parseBody(c, &req); err != nil {
+			return Error(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	req.Name = sanitizeName(req.Name)
+	if req.Name == "" {
+		req.Name = "Restock " + time.Now().Format("Jan 2")
+	}
+	if req.ExpiringDays < 1 {
+		req.ExpiringDays = 7
+	}
+	if req.ExpiringDays > 365 {
+		req.ExpiringDays = 365
+	}
+
+	result, err := h.db.BuildRestockList(c.Context(), userID, req.Name, req.ExpiringDays)
+	if err != nil {
+		if errors.Is(err, database.ErrNothingToRestock) {
+			return c.Status(fiber.StatusBadRequest).JSON(APIResponse{
+				Success:  false,
+				Error:    err.Error(),
+				Warnings: result.Skipped,
+			})
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to build restock list")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    result,
 	})
 }
 
@@ -346,3 +479,42 @@ func (h *Handler) GetActiveShoppingListsForInventory(c *fiber.Ctx) error {
 
 	return Success(c, lists)
 }
+
+// GetInventoryNotifications returns the user's pending expiry and low-stock notifications
+// GET /api/inventory/notifications
+func (h *Handler) GetInventoryNotifications(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	notifications, err := h.db.ListPendingInventoryNotifications(c.Context(), userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get inventory notifications")
+	}
+
+	return Success(c, notifications)
+}
+
+// AcknowledgeInventoryNotification dismisses an inventory notification
+// POST /api/inventory/notifications/:id/ack
+func (h *Handler) AcknowledgeInventoryNotification(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid notification id")
+	}
+
+	if err := h.db.AcknowledgeInventoryNotification(c.Context(), id, userID); err != nil {
+		if errors.Is(err, database.ErrInventoryNotificationNotFound) {
+			return Error(c, fiber.StatusNotFound, "notification not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to acknowledge notification")
+	}
+
+	return Success(c, fiber.Map{"acknowledged": true})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// AdminListItemNameRules returns all rules used to clean up names of items created from receipts
+// GET /api/admin/item-name-rules
+func (h *Handler) AdminListItemNameRules(c *fiber.Ctx) error {
+	rules, err := h.db.ListItemNameRules(c.Context(), false)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list item name rules")
+	}
+
+	return Success(c, rules)
+}
+
+// AdminUpsertItemNameRule adds an item name rule or updates the rule for the same pattern
+// PUT /api/admin/item-name-rules
+func (h *Handler) AdminUpsertItemNameRule(c *fiber.Ctx) error {
+	var req models.UpsertItemNameRuleRequest
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	req.Pattern = strings.TrimSpace(req.Pattern)
+	req.Replacement = strings.TrimSpace(req.Replacement)
+	if len(strings.Fields(req.Pattern)) != 1 {
+		return Error(c, fiber.StatusBadRequest, "pattern must be a single word")
+	}
+	if len(req.Pattern) > 50 {
+		return Error(c, fiber.StatusBadRequest, "pattern must be 50 characters or less")
+	}
+	if len(req.Replacement) > 100 {
+		return Error(c, fiber.StatusBadRequest, "replacement must be 100 characters or less")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	rule, err := h.db.UpsertItemNameRule(c.Context(), req.Pattern, req.Replacement, enabled)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to save item name rule")
+	}
+
+	return Success(c, rule)
+}
+
+// AdminDeleteItemNameRule removes an item name rule
+// DELETE /api/admin/item-name-rules/:id
+func (h *Handler) AdminDeleteItemNameRule(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid rule id")
+	}
+
+	if err := h.db.DeleteItemNameRule(c.Context(), id); err != nil {
+		if errors.Is(err, database.ErrItemNameRuleNotFound) {
+			return Error(c, fiber.StatusNotFound, "item name rule not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to delete item name rule")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "item name rule deleted",
+	})
+}
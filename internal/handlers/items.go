@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"errors"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -10,15 +12,17 @@ import (
 	"github.com/foxxcyber/price-feed/internal/database"
 	"github.com/foxxcyber/price-feed/internal/middleware"
 	"github.com/foxxcyber/price-feed/internal/models"
+	"github.com/foxxcyber/price-feed/internal/services"
 )
 
 // ListItems returns a paginated list of items
 func (h *Handler) ListItems(c *fiber.Ctx) error {
 	params := &models.ItemListParams{
-		Limit:  c.QueryInt("limit", 50),
-		Offset: c.QueryInt("offset", 0),
-		Search: c.Query("search"),
-		Tag:    c.Query("tag"),
+		Limit:       c.QueryInt("limit", 50),
+		Offset:      c.QueryInt("offset", 0),
+		Search:      c.Query("search"),
+		Tag:         c.Query("tag"),
+		StatsFilter: h.publicStatsFilter(c),
 	}
 
 	// Filter by user visibility - users only see their own items + public items
@@ -26,6 +30,11 @@ func (h *Handler) ListItems(c *fiber.Ctx) error {
 		params.UserID = &userID
 	}
 
+	// Admins can list archived items to restore them
+	if c.QueryBool("include_archived") && middleware.GetUserRole(c) == models.RoleAdmin {
+		params.IncludeArchived = true
+	}
+
 	// Validate limits
 	if params.Limit < 1 || params.Limit > 100 {
 		params.Limit = 50
@@ -49,7 +58,7 @@ func (h *Handler) GetItem(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid item id")
 	}
 
-	item, err := h.db.GetItemByID(c.Context(), id)
+	item, err := h.db.GetItemWithStats(c.Context(), id, h.publicStatsFilter(c))
 	if err != nil {
 		if errors.Is(err, database.ErrItemNotFound) {
 			return Error(c, fiber.StatusNotFound, "item not found")
@@ -60,17 +69,292 @@ func (h *Handler) GetItem(c *fiber.Ctx) error {
 	return Success(c, item)
 }
 
+// GetItemByBarcode looks up an item by its scanned UPC/EAN barcode. A 404 means no visible
+// item has the barcode yet, so the client can offer to create one.
+// GET /api/items/barcode/:code
+func (h *Handler) GetItemByBarcode(c *fiber.Ctx) error {
+	code, ok := models.NormalizeBarcode(c.Params("code"))
+	if !ok {
+		return Error(c, fiber.StatusBadRequest, "barcode must be an 8, 12, 13 or 14 digit UPC/EAN code")
+	}
+
+	// Only public items and the caller's own private ones are visible
+	var userID *int
+	if uid := middleware.GetUserID(c); uid != 0 {
+		userID = &uid
+	}
+
+	item, err := h.db.GetItemByBarcode(c.Context(), code, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "no item found with barcode "+code)
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	return Success(c, item)
+}
+
+// normalizeRequestBarcode rewrites an optional request barcode to GTIN-14, reporting false
+// when it is not a valid UPC/EAN code. An empty barcode is kept so updates can clear it.
+func normalizeRequestBarcode(barcode **string) bool {
+	if *barcode == nil || strings.TrimSpace(**barcode) == "" {
+		if *barcode != nil {
+			empty := ""
+			*barcode = &empty
+		}
+		return true
+	}
+	code, ok := models.NormalizeBarcode(**barcode)
+	if !ok {
+		return false
+	}
+	*barcode = &code
+	return true
+}
+
+// GetItemSizeComparison ranks package sizes of the same product by best unit price
+// GET /api/items/:id/size-comparison
+func (h *Handler) GetItemSizeComparison(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	item, err := h.db.GetItemByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	var userID *int
+	if uid := middleware.GetUserID(c); uid != 0 {
+		userID = &uid
+	}
+
+	variants, err := h.db.GetItemVariantPrices(c.Context(), id, userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get item variants")
+	}
+
+	result := &models.ItemSizeComparison{
+		ItemID:   id,
+		Variants: variants,
+	}
+
+	// Only sizes in the same dimension as this item (weight, volume or count) are comparable
+	var dimension string
+	if item.Size != nil && item.Unit != nil {
+		if _, dim, ok := services.ConvertToBaseUnit(*item.Size, *item.Unit); ok {
+			dimension = dim
+		}
+	}
+	if dimension == "" {
+		return Success(c, result)
+	}
+	result.BaseUnit = services.BaseUnitName(dimension)
+
+	var ranked []*models.ItemVariantPrice
+	for _, v := range variants {
+		if v.BestPrice == nil || v.Size == nil || v.Unit == nil {
+			continue
+		}
+		perUnit, dim, ok := services.UnitPrice(*v.BestPrice, *v.Size, *v.Unit)
+		if !ok || dim != dimension {
+			continue
+		}
+		v.UnitPrice = &perUnit
+		ranked = append(ranked, v)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return *ranked[i].UnitPrice < *ranked[j].UnitPrice
+	})
+	for i, v := range ranked {
+		v.Rank = i + 1
+	}
+	if len(ranked) > 0 {
+		result.CheapestItemID = &ranked[0].ItemID
+	}
+
+	// Ranked variants first, then those without a comparable unit price
+	ordered := make([]*models.ItemVariantPrice, 0, len(variants))
+	ordered = append(ordered, ranked...)
+	for _, v := range variants {
+		if v.UnitPrice == nil {
+			ordered = append(ordered, v)
+		}
+	}
+	result.Variants = ordered
+
+	return Success(c, result)
+}
+
+// equivalentMinSimilarity is how alike brand-free names must be for tagged items to count as equivalents
+const equivalentMinSimilarity = 0.5
+
+// GetItemEquivalents lists other brands of the same product (store brand vs name brand), ranked
+// by unit price, so shoppers can see what switching would save. Equivalents come from the item's
+// product group or from items sharing a tag with a similar name once the brand is removed.
+// GET /api/items/:id/equivalents
+func (h *Handler) GetItemEquivalents(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	item, err := h.db.GetItemByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	var userID *int
+	if uid := middleware.GetUserID(c); uid != 0 {
+		userID = &uid
+	}
+
+	result, err := h.db.GetItemEquivalents(c.Context(), id, userID, equivalentMinSimilarity, limit)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get item equivalents")
+	}
+
+	// Unit prices are only comparable within the item's dimension (weight, volume or count)
+	var dimension string
+	if item.Size != nil && item.Unit != nil {
+		if _, dim, ok := services.ConvertToBaseUnit(*item.Size, *item.Unit); ok {
+			dimension = dim
+		}
+	}
+	if dimension == "" {
+		return Success(c, result)
+	}
+	result.BaseUnit = services.BaseUnitName(dimension)
+	if result.BestPrice != nil {
+		if perUnit, _, ok := services.UnitPrice(*result.BestPrice, *item.Size, *item.Unit); ok {
+			result.UnitPrice = &perUnit
+		}
+	}
+
+	var ranked []*models.ItemEquivalent
+	for _, e := range result.Equivalents {
+		if e.BestPrice == nil || e.Size == nil || e.Unit == nil {
+			continue
+		}
+		perUnit, dim, ok := services.UnitPrice(*e.BestPrice, *e.Size, *e.Unit)
+		if !ok || dim != dimension {
+			continue
+		}
+		e.UnitPrice = &perUnit
+		if result.UnitPrice != nil && *result.UnitPrice > 0 && perUnit < *result.UnitPrice {
+			savings := math.Round((*result.UnitPrice-perUnit) / *result.UnitPrice * 1000) / 10
+			e.SavingsPercent = &savings
+		}
+		ranked = append(ranked, e)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return *ranked[i].UnitPrice < *ranked[j].UnitPrice
+	})
+	for i, e := range ranked {
+		e.Rank = i + 1
+	}
+	if len(ranked) > 0 && ranked[0].SavingsPercent != nil {
+		result.CheapestItemID = &ranked[0].ItemID
+	}
+
+	// Ranked equivalents first, then those without a comparable unit price
+	ordered := make([]*models.ItemEquivalent, 0, len(result.Equivalents))
+	ordered = append(ordered, ranked...)
+	for _, e := range result.Equivalents {
+		if e.UnitPrice == nil {
+			ordered = append(ordered, e)
+		}
+	}
+	result.Equivalents = ordered
+
+	return Success(c, result)
+}
+
+// CreateProductGroup links items that are the same product under different brands (admin only)
+// POST /api/admin/product-groups
+func (h *Handler) CreateProductGroup(c *fiber.Ctx) error {
+	var req models.CreateProductGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return Error(c, fiber.StatusBadRequest, "name is required")
+	}
+	if len(req.ItemIDs) < 2 {
+		return Error(c, fiber.StatusBadRequest, "a product group needs at least two items")
+	}
+
+	group, err := h.db.CreateProductGroup(c.Context(), req.Name, req.ItemIDs)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to create product group")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+// SetItemProductGroup moves an item into a product group, or out of it with a null group (admin only)
+// PUT /api/admin/items/:id/product-group
+func (h *Handler) SetItemProductGroup(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	var req models.SetItemProductGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if err := h.db.SetItemProductGroup(c.Context(), id, req.ProductGroupID); err != nil {
+		switch {
+		case errors.Is(err, database.ErrItemNotFound):
+			return Error(c, fiber.StatusNotFound, "item not found")
+		case errors.Is(err, database.ErrProductGroupNotFound):
+			return Error(c, fiber.StatusNotFound, "product group not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to update product group")
+	}
+
+	return Success(c, fiber.Map{
+		"item_id":          id,
+		"product_group_id": req.ProductGroupID,
+	})
+}
+
 // CreateItem creates a new item (admin only)
 func (h *Handler) CreateItem(c *fiber.Ctx) error {
 	var req models.CreateItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
 	if req.Name == "" {
 		return Error(c, fiber.StatusBadRequest, "name is required")
 	}
+	if !normalizeRequestBarcode(&req.Barcode) {
+		return Error(c, fiber.StatusBadRequest, "barcode must be an 8, 12, 13 or 14 digit UPC/EAN code")
+	}
 
 	// Parse tags from comma-separated string if needed
 	if len(req.Tags) == 1 && strings.Contains(req.Tags[0], ",") {
@@ -83,6 +367,8 @@ func (h *Handler) CreateItem(c *fiber.Ctx) error {
 		}
 	}
 
+	h.autoApplyTags(c, &req)
+
 	// Get user ID from context if available
 	var createdBy *int
 	if user := c.Locals("user"); user != nil {
@@ -93,6 +379,9 @@ func (h *Handler) CreateItem(c *fiber.Ctx) error {
 
 	item, err := h.db.CreateItem(c.Context(), &req, createdBy)
 	if err != nil {
+		if errors.Is(err, database.ErrDuplicateBarcode) {
+			return Error(c, fiber.StatusConflict, err.Error())
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to create item")
 	}
 
@@ -110,8 +399,11 @@ func (h *Handler) UpdateItem(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	if !normalizeRequestBarcode(&req.Barcode) {
+		return Error(c, fiber.StatusBadRequest, "barcode must be an 8, 12, 13 or 14 digit UPC/EAN code")
 	}
 
 	// Parse tags from comma-separated string if needed
@@ -130,13 +422,16 @@ func (h *Handler) UpdateItem(c *fiber.Ctx) error {
 		if errors.Is(err, database.ErrItemNotFound) {
 			return Error(c, fiber.StatusNotFound, "item not found")
 		}
+		if errors.Is(err, database.ErrDuplicateBarcode) {
+			return Error(c, fiber.StatusConflict, err.Error())
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to update item")
 	}
 
 	return Success(c, item)
 }
 
-// DeleteItem deletes an item (admin only)
+// DeleteItem archives an item (admin only)
 func (h *Handler) DeleteItem(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -152,10 +447,44 @@ func (h *Handler) DeleteItem(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "item deleted successfully",
+		"message": "item archived successfully",
 	})
 }
 
+// RestoreItem brings an archived item back into listings, comparisons and plans (admin only)
+// POST /api/admin/items/:id/restore
+func (h *Handler) RestoreItem(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	if err := h.db.RestoreItem(c.Context(), id); err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to restore item")
+	}
+
+	item, err := h.db.GetItemByID(c.Context(), id)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	return Success(c, item)
+}
+
+// RefreshItemBestPrices recomputes the stored best price of every item (admin only)
+// POST /api/admin/items/refresh-best-prices
+func (h *Handler) RefreshItemBestPrices(c *fiber.Ctx) error {
+	updated, err := h.db.RefreshItemBestPrices(c.Context())
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to refresh best prices")
+	}
+
+	return Success(c, fiber.Map{"updated": updated})
+}
+
 // GetItemStats returns aggregate item statistics
 func (h *Handler) GetItemStats(c *fiber.Ctx) error {
 	stats, err := h.db.GetItemStats(c.Context())
@@ -189,6 +518,8 @@ func (h *Handler) SearchItems(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to search items")
 	}
 
+	h.recordSearch(c, models.SearchKindItem, query, len(items))
+
 	return Success(c, items)
 }
 
@@ -205,14 +536,17 @@ func (h *Handler) ListTags(c *fiber.Ctx) error {
 // UserCreateItem allows authenticated users to create items
 func (h *Handler) UserCreateItem(c *fiber.Ctx) error {
 	var req models.CreateItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
 	if req.Name == "" {
 		return Error(c, fiber.StatusBadRequest, "name is required")
 	}
+	if !normalizeRequestBarcode(&req.Barcode) {
+		return Error(c, fiber.StatusBadRequest, "barcode must be an 8, 12, 13 or 14 digit UPC/EAN code")
+	}
 
 	// Parse tags from comma-separated string if needed
 	if len(req.Tags) == 1 && strings.Contains(req.Tags[0], ",") {
@@ -231,8 +565,13 @@ func (h *Handler) UserCreateItem(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusUnauthorized, "unauthorized")
 	}
 
+	h.autoApplyTags(c, &req)
+
 	item, err := h.db.CreateItem(c.Context(), &req, &userID)
 	if err != nil {
+		if errors.Is(err, database.ErrDuplicateBarcode) {
+			return Error(c, fiber.StatusConflict, err.Error())
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to create item")
 	}
 
@@ -270,8 +609,11 @@ func (h *Handler) UserUpdateItem(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	if !normalizeRequestBarcode(&req.Barcode) {
+		return Error(c, fiber.StatusBadRequest, "barcode must be an 8, 12, 13 or 14 digit UPC/EAN code")
 	}
 
 	// Parse tags from comma-separated string if needed
@@ -290,13 +632,16 @@ func (h *Handler) UserUpdateItem(c *fiber.Ctx) error {
 		if errors.Is(err, database.ErrItemNotFound) {
 			return Error(c, fiber.StatusNotFound, "item not found")
 		}
+		if errors.Is(err, database.ErrDuplicateBarcode) {
+			return Error(c, fiber.StatusConflict, err.Error())
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to update item")
 	}
 
 	return Success(c, updatedItem)
 }
 
-// UserDeleteItem allows users to delete their own items
+// UserDeleteItem allows users to delete (archive) their own items
 func (h *Handler) UserDeleteItem(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -332,6 +677,99 @@ func (h *Handler) UserDeleteItem(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "item deleted successfully",
+		"message": "item archived successfully",
 	})
 }
+
+// SuggestItemTags returns tag slugs suggested for an item name
+// GET /api/items/suggest-tags?name=
+func (h *Handler) SuggestItemTags(c *fiber.Ctx) error {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		return Error(c, fiber.StatusBadRequest, "name is required")
+	}
+	if len(name) > 255 {
+		return Error(c, fiber.StatusBadRequest, "name is too long")
+	}
+
+	limit := c.QueryInt("limit", 5)
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	suggestions, err := h.tagSuggester.Suggest(c.Context(), name, limit)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to suggest tags")
+	}
+
+	return Success(c, suggestions)
+}
+
+// autoApplyTags adds high-confidence suggested tags to a new item when tag_auto_apply_enabled is set.
+// Tags the request already names are kept; suggestion failures never block item creation.
+func (h *Handler) autoApplyTags(c *fiber.Ctx, req *models.CreateItemRequest) {
+	encryptionKey := h.getEncryptionKey()
+	if !h.db.GetSettingBool(c.Context(), "tag_auto_apply_enabled", false, encryptionKey) {
+		return
+	}
+	minConfidence := h.db.GetSettingFloat(c.Context(), "tag_auto_apply_min_confidence", 0.8, encryptionKey)
+
+	suggestions, err := h.tagSuggester.Suggest(c.Context(), req.Name, 5)
+	if err != nil {
+		return
+	}
+
+	present := make(map[string]bool, len(req.Tags))
+	for _, tag := range req.Tags {
+		present[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), " ", "-"))] = true
+	}
+	for _, s := range suggestions {
+		if s.Confidence >= minConfidence && !present[s.Slug] {
+			req.Tags = append(req.Tags, s.Slug)
+			present[s.Slug] = true
+		}
+	}
+}
+
+// Limits for co-purchase recommendations; pairs seen only once are noise
+const (
+	maxFrequentlyBoughtWith = 50
+	minCoPurchaseBaskets    = 2
+)
+
+// GetFrequentlyBoughtWith returns items often bought together with an item, mined from confirmed
+// receipts and completed shopping lists
+// GET /api/items/:id/frequently-bought-with?limit=10&offset=0
+func (h *Handler) GetFrequentlyBoughtWith(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > maxFrequentlyBoughtWith {
+		limit = maxFrequentlyBoughtWith
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	item, err := h.db.GetItemByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+	if item.IsPrivate && (item.CreatedBy == nil || *item.CreatedBy != middleware.GetUserID(c)) {
+		return Error(c, fiber.StatusNotFound, "item not found")
+	}
+
+	related, total, err := h.db.GetFrequentlyBoughtWith(c.Context(), id, minCoPurchaseBaskets, limit, offset)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get co-purchased items")
+	}
+
+	return SuccessWithMeta(c, related, total, limit, offset)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// savingsLeaderboardTTL is how long a computed savings ranking is reused
+const savingsLeaderboardTTL = 30 * time.Minute
+
+// savingsLeaderboardSize caps how many users a cached ranking holds
+const savingsLeaderboardSize = 100
+
+// savingsLeaderboardPeriods maps the period query value to its length in days (0 is all time)
+var savingsLeaderboardPeriods = map[string]int{
+	"week":  7,
+	"month": 30,
+	"year":  365,
+	"all":   0,
+}
+
+// savingsLeaderboard is a cached ranking for one period
+type savingsLeaderboard struct {
+	entries    []*models.SavingsLeaderboardEntry
+	computedAt time.Time
+}
+
+// GetSavingsLeaderboard ranks users by estimated savings on their confirmed receipts
+// GET /api/leaderboard/savings?period=week|month|year|all
+func (h *Handler) GetSavingsLeaderboard(c *fiber.Ctx) error {
+	period := c.Query("period", "month")
+	days, ok := savingsLeaderboardPeriods[period]
+	if !ok {
+		return Error(c, fiber.StatusBadRequest, "period must be week, month, year or all")
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > savingsLeaderboardSize {
+		limit = 20
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	h.savingsMu.Lock()
+	board, cached := h.savingsCache[period]
+	h.savingsMu.Unlock()
+
+	if !cached || time.Since(board.computedAt) >= savingsLeaderboardTTL {
+		var since *time.Time
+		if days > 0 {
+			t := time.Now().AddDate(0, 0, -days)
+			since = &t
+		}
+		benchmarkDays := h.db.GetSettingInt(c.Context(), "savings_leaderboard_benchmark_days", 30, h.getEncryptionKey())
+
+		entries, err := h.db.GetSavingsLeaderboard(c.Context(), since, benchmarkDays, savingsLeaderboardSize)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to get savings leaderboard")
+		}
+
+		board = &savingsLeaderboard{entries: entries, computedAt: time.Now()}
+		h.savingsMu.Lock()
+		h.savingsCache[period] = board
+		h.savingsMu.Unlock()
+	}
+
+	// Mask copies so the cached ranking keeps real identities for other viewers
+	page := []*models.SavingsLeaderboardEntry{}
+	visibility := h.contributorVisibility(c)
+	for i := offset; i < len(board.entries) && len(page) < limit; i++ {
+		e := *board.entries[i]
+		if visibility.ShouldMask(e.UserID, e.ContributorHidden) {
+			anonymous := models.AnonymousContributorName
+			e.Username = &anonymous
+			e.UserID = nil
+		}
+		page = append(page, &e)
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Data: fiber.Map{
+			"period":      period,
+			"computed_at": board.computedAt,
+			"entries":     page,
+		},
+		Meta: &Meta{
+			Total:  len(board.entries),
+			Limit:  limit,
+			Offset: offset,
+		},
+	})
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"html"
+	"math"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -13,6 +17,17 @@ import (
 	"github.com/foxxcyber/price-feed/internal/services"
 )
 
+// sanitizeName trims a user-supplied name and drops control characters such as
+// newlines, which have no place in names and could be abused in email headers
+func sanitizeName(name string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+}
+
 // getUserID extracts user ID from context using the middleware helper
 func getUserID(c *fiber.Ctx) (int, error) {
 	userID, ok := c.Locals("user_id").(int)
@@ -34,6 +49,8 @@ func (h *Handler) ListShoppingLists(c *fiber.Ctx) error {
 		Offset: c.QueryInt("offset", 0),
 		UserID: userID,
 		Status: models.ListStatus(c.Query("status")), // Optional: "active" or "completed"
+
+		DenormalizedBestPrice: h.db.GetSettingBool(c.Context(), "item_best_price_denormalized", true, h.getEncryptionKey()),
 	}
 
 	// Validate limits
@@ -86,14 +103,18 @@ func (h *Handler) CreateShoppingList(c *fiber.Ctx) error {
 	}
 
 	var req models.CreateListRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
+	req.Name = sanitizeName(req.Name)
 	if req.Name == "" {
 		return Error(c, fiber.StatusBadRequest, "name is required")
 	}
+	if req.Budget != nil && *req.Budget < 0 {
+		return Error(c, fiber.StatusBadRequest, "budget cannot be negative")
+	}
 
 	list, err := h.db.CreateShoppingList(c.Context(), &req, userID)
 	if err != nil {
@@ -106,7 +127,7 @@ func (h *Handler) CreateShoppingList(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateShoppingList updates a shopping list
+// UpdateShoppingList updates a shopping list. clear_budget removes its budget.
 func (h *Handler) UpdateShoppingList(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
@@ -119,8 +140,23 @@ func (h *Handler) UpdateShoppingList(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateListRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if req.Name != nil {
+		name := sanitizeName(*req.Name)
+		if name == "" {
+			return Error(c, fiber.StatusBadRequest, "name cannot be empty")
+		}
+		req.Name = &name
+	}
+
+	if req.Budget != nil && *req.Budget < 0 {
+		return Error(c, fiber.StatusBadRequest, "budget cannot be negative")
+	}
+	if req.Budget != nil && req.ClearBudget {
+		return Error(c, fiber.StatusBadRequest, "budget and clear_budget cannot both be set")
 	}
 
 	list, err := h.db.UpdateShoppingList(c.Context(), id, userID, &req)
@@ -172,8 +208,8 @@ func (h *Handler) AddItemToList(c *fiber.Ctx) error {
 	}
 
 	var req models.AddListItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
@@ -219,8 +255,8 @@ func (h *Handler) UpdateListItem(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateListItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	if req.Quantity < 1 {
@@ -280,7 +316,13 @@ func (h *Handler) RemoveItemFromList(c *fiber.Ctx) error {
 	})
 }
 
-// BuildShoppingPlan generates an optimized shopping plan for a list
+// maxPlanTrips caps the max_trips a shopping plan request may ask for
+const maxPlanTrips = 10
+
+// BuildShoppingPlan generates an optimized shopping plan for a list. max_trips caps how many
+// stores the multi-store plan visits and min_savings is how much it must save over the best
+// single store to be recommended. max_age_days leaves out prices that are too old.
+// POST /api/lists/:id/build-plan
 func (h *Handler) BuildShoppingPlan(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
@@ -292,13 +334,35 @@ func (h *Handler) BuildShoppingPlan(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid list id")
 	}
 
-	// Get user's region if available
-	var regionID *int
-	if user, err := h.db.GetUserByID(c.Context(), userID); err == nil && user.RegionID != nil {
-		regionID = user.RegionID
+	// Use the requested region (or the user's primary one) and its saved preferred stores
+	regionID, pref, err := h.resolveRegionPrefs(c, userID)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Optionally suggest items to drop when the plan is over budget
+	suggestDrops := c.QueryBool("suggest_drops", false)
+
+	constraints := &models.PlanConstraints{
+		MaxTrips:   c.QueryInt("max_trips", models.DefaultPlanMaxTrips),
+		MinSavings: c.QueryFloat("min_savings", models.DefaultPlanMinSavings),
+	}
+	if constraints.MaxTrips < 1 || constraints.MaxTrips > maxPlanTrips {
+		return Error(c, fiber.StatusBadRequest, fmt.Sprintf("max_trips must be between 1 and %d", maxPlanTrips))
+	}
+	if constraints.MinSavings < 0 {
+		return Error(c, fiber.StatusBadRequest, "min_savings cannot be negative")
+	}
+	if constraints.MaxAgeDays, err = maxAgeDaysQuery(c); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	plan, err := h.db.BuildShoppingPlan(c.Context(), listID, userID, regionID)
+	var plan *models.ShoppingPlanResult
+	if pref != nil && len(pref.PreferredStoreIDs) > 0 {
+		plan, err = h.db.BuildShoppingPlanForStores(c.Context(), listID, userID, pref.PreferredStoreIDs, suggestDrops, constraints)
+	} else {
+		plan, err = h.db.BuildShoppingPlan(c.Context(), listID, userID, regionID, suggestDrops, constraints)
+	}
 	if err != nil {
 		if errors.Is(err, database.ErrListNotFound) {
 			return Error(c, fiber.StatusNotFound, "shopping list not found")
@@ -315,26 +379,55 @@ func (h *Handler) BuildShoppingPlan(c *fiber.Ctx) error {
 	return Success(c, plan)
 }
 
-// GetPriceComparison returns a price comparison grid
+// maxAgeDaysQuery parses the optional max_age_days filter that leaves out stale prices
+func maxAgeDaysQuery(c *fiber.Ctx) (*int, error) {
+	v := c.Query("max_age_days")
+	if v == "" {
+		return nil, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		return nil, errors.New("max_age_days must be a positive number")
+	}
+	return &days, nil
+}
+
+// GetPriceComparison returns a price comparison grid. With exclude_own (default from the
+// compare_exclude_own_prices setting) the best price ignores the user's own submissions,
+// which are returned in each row's own_prices instead. max_age_days leaves out old prices
+// and cells older than price_stale_days are marked stale.
+// GET /api/compare
 func (h *Handler) GetPriceComparison(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
 		return Error(c, fiber.StatusUnauthorized, err.Error())
 	}
 
-	// Parse store IDs (required)
-	storeIDsParam := c.Query("store_ids")
-	if storeIDsParam == "" {
-		return Error(c, fiber.StatusBadRequest, "store_ids is required")
+	// Use the requested region (or the user's primary one) and its saved comparison set
+	regionID, pref, err := h.resolveRegionPrefs(c, userID)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
+	// Parse store IDs, defaulting to the region's saved comparison stores
 	var storeIDs []int
-	for _, idStr := range strings.Split(storeIDsParam, ",") {
-		id, err := strconv.Atoi(strings.TrimSpace(idStr))
-		if err != nil {
-			return Error(c, fiber.StatusBadRequest, "invalid store_ids format")
+	if storeIDsParam := c.Query("store_ids"); storeIDsParam != "" {
+		for _, idStr := range strings.Split(storeIDsParam, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return Error(c, fiber.StatusBadRequest, "invalid store_ids format")
+			}
+			storeIDs = append(storeIDs, id)
+		}
+	} else if pref != nil {
+		storeIDs = pref.CompareStoreIDs
+		if len(storeIDs) == 0 {
+			storeIDs = pref.PreferredStoreIDs
 		}
-		storeIDs = append(storeIDs, id)
+	}
+
+	if len(storeIDs) == 0 {
+		return Error(c, fiber.StatusBadRequest, "store_ids is required")
 	}
 
 	if len(storeIDs) < 1 || len(storeIDs) > 5 {
@@ -353,17 +446,47 @@ func (h *Handler) GetPriceComparison(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get user's region if available
-	var regionID *int
-	if user, err := h.db.GetUserByID(c.Context(), userID); err == nil && user.RegionID != nil {
-		regionID = user.RegionID
-	}
-
 	params := &models.CompareParams{
 		StoreIDs: storeIDs,
 		ItemIDs:  itemIDs,
 		RegionID: regionID,
 		UserID:   &userID,
+
+		Visibility: h.contributorVisibility(c),
+		StaleDays:  h.priceStaleDays(c),
+	}
+
+	maxAge, err := maxAgeDaysQuery(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	params.MaxAgeDays = maxAge
+
+	// Optionally rank the community best without the user's own submissions
+	excludeOwn := h.db.GetSettingBool(c.Context(), "compare_exclude_own_prices", false, h.getEncryptionKey())
+	if v := c.Query("exclude_own"); v != "" {
+		excludeOwn, err = strconv.ParseBool(v)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "exclude_own must be true or false")
+		}
+	}
+	params.ExcludeOwnPrices = excludeOwn
+
+	// Optional coordinates add a distance column per store
+	latParam, lngParam := c.Query("lat"), c.Query("lng")
+	if latParam != "" || lngParam != "" {
+		lat, latErr := strconv.ParseFloat(latParam, 64)
+		lng, lngErr := strconv.ParseFloat(lngParam, 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return Error(c, fiber.StatusBadRequest, "lat and lng must both be valid coordinates")
+		}
+		params.Latitude = &lat
+		params.Longitude = &lng
+	}
+
+	unit, err := h.distanceUnit(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	comparison, err := h.db.GetPriceComparison(c.Context(), params)
@@ -371,6 +494,275 @@ func (h *Handler) GetPriceComparison(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to get price comparison")
 	}
 
+	for i := range comparison.Stores {
+		if km := comparison.Stores[i].DistanceKm; km != nil {
+			distance := unit.FromKm(*km)
+			comparison.Stores[i].Distance = &distance
+			comparison.Stores[i].DistanceUnit = unit
+		}
+	}
+
+	applyUnitPrices(comparison)
+
+	// Cross-region comparisons are allowed unless restricted, but always flagged
+	key := h.getEncryptionKey()
+	maxSpreadKm := h.db.GetSettingFloat(c.Context(), "compare_max_store_spread_km", 80, key)
+	spread := comparisonSpread(comparison.Stores, maxSpreadKm)
+	if spread != nil && spread.MultipleRegions && h.db.GetSettingBool(c.Context(), "compare_single_region_only", false, key) {
+		return Error(c, fiber.StatusBadRequest, "compared stores must all be in the same region")
+	}
+	if spread == nil {
+		return Success(c, comparison)
+	}
+
+	return c.JSON(APIResponse{
+		Success:  true,
+		Data:     comparison,
+		Warnings: fiber.Map{"region_spread": spread},
+	})
+}
+
+// comparisonSpread reports when compared stores are in more than one region or, with a positive
+// maxSpreadKm, further apart than that. Stores without a region or coordinates are ignored for
+// the respective check. Returns nil when neither applies.
+func comparisonSpread(stores []models.StoreBasic, maxSpreadKm float64) *models.ComparisonSpread {
+	spread := &models.ComparisonSpread{RegionIDs: []int{}}
+	seen := make(map[int]bool)
+	for _, s := range stores {
+		if s.RegionID != nil && !seen[*s.RegionID] {
+			seen[*s.RegionID] = true
+			spread.RegionIDs = append(spread.RegionIDs, *s.RegionID)
+		}
+	}
+	spread.MultipleRegions = len(spread.RegionIDs) > 1
+
+	for i := range stores {
+		for j := i + 1; j < len(stores); j++ {
+			a, b := stores[i], stores[j]
+			if a.Latitude == nil || a.Longitude == nil || b.Latitude == nil || b.Longitude == nil {
+				continue
+			}
+			km := services.DistanceKm(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude)
+			if spread.MaxDistanceKm == nil || km > *spread.MaxDistanceKm {
+				rounded := math.Round(km*10) / 10
+				spread.MaxDistanceKm = &rounded
+			}
+		}
+	}
+	spread.TooFarApart = maxSpreadKm > 0 && spread.MaxDistanceKm != nil && *spread.MaxDistanceKm > maxSpreadKm
+
+	switch {
+	case spread.MultipleRegions && spread.TooFarApart:
+		spread.Message = fmt.Sprintf("selected stores span %d regions and are up to %.0f km apart", len(spread.RegionIDs), *spread.MaxDistanceKm)
+	case spread.MultipleRegions:
+		spread.Message = fmt.Sprintf("selected stores span %d regions", len(spread.RegionIDs))
+	case spread.TooFarApart:
+		spread.Message = fmt.Sprintf("selected stores are up to %.0f km apart", *spread.MaxDistanceKm)
+	default:
+		return nil
+	}
+	return spread
+}
+
+// applyUnitPrices fills in each cell's price per normalized unit from the item's size. Rows
+// for the same product (name and brand) in different sizes form a group, and the cell with
+// the lowest unit price across the group is marked best by unit price. Items without a
+// convertible size keep raw price comparison only.
+func applyUnitPrices(comparison *models.PriceComparisonResult) {
+	type group struct {
+		sizes map[float64]bool
+		best  *float64
+		rows  []int
+	}
+	groups := make(map[string]*group)
+
+	for i := range comparison.Items {
+		row := &comparison.Items[i]
+		if row.ItemSize == nil || row.ItemUnit == nil {
+			continue
+		}
+		baseSize, dimension, ok := services.ConvertToBaseUnit(*row.ItemSize, *row.ItemUnit)
+		if !ok || baseSize <= 0 {
+			continue
+		}
+		row.NormalizedUnit = services.BaseUnitName(dimension)
+
+		for _, cells := range []map[int]models.PriceComparisonCell{row.Prices, row.OwnPrices} {
+			for storeID, cell := range cells {
+				if cell.Price == nil {
+					continue
+				}
+				perUnit := math.Round(*cell.Price/baseSize*10000) / 10000
+				cell.UnitPrice = &perUnit
+				cells[storeID] = cell
+			}
+		}
+
+		brand := ""
+		if row.ItemBrand != nil {
+			brand = *row.ItemBrand
+		}
+		key := strings.ToLower(strings.TrimSpace(row.ItemName)) + "|" + strings.ToLower(strings.TrimSpace(brand)) + "|" + dimension
+		g := groups[key]
+		if g == nil {
+			g = &group{sizes: make(map[float64]bool)}
+			groups[key] = g
+		}
+		g.sizes[baseSize] = true
+		g.rows = append(g.rows, i)
+
+		for _, cell := range row.Prices {
+			if cell.UnitPrice == nil {
+				continue
+			}
+			if row.BestUnitPrice == nil || *cell.UnitPrice < *row.BestUnitPrice {
+				row.BestUnitPrice = cell.UnitPrice
+			}
+		}
+		if row.BestUnitPrice != nil && (g.best == nil || *row.BestUnitPrice < *g.best) {
+			g.best = row.BestUnitPrice
+		}
+	}
+
+	// Only sizes that differ need a unit-price winner; otherwise the raw best already says it
+	for _, g := range groups {
+		if len(g.sizes) < 2 || g.best == nil {
+			continue
+		}
+		for _, i := range g.rows {
+			row := &comparison.Items[i]
+			for storeID, cell := range row.Prices {
+				if cell.UnitPrice != nil && *cell.UnitPrice == *g.best {
+					cell.IsBestUnitPrice = true
+					row.Prices[storeID] = cell
+				}
+			}
+		}
+	}
+}
+
+// GetListInflation returns a month-over-month basket cost series for a list's items
+// GET /api/lists/:id/inflation?months=12
+func (h *Handler) GetListInflation(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	months := c.QueryInt("months", 12)
+	if months < 2 || months > 36 {
+		return Error(c, fiber.StatusBadRequest, "months must be between 2 and 36")
+	}
+
+	inflation, err := h.db.GetListInflation(c.Context(), listID, userID, months)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to compute list inflation")
+	}
+
+	return Success(c, inflation)
+}
+
+// CreateListSnapshot saves the list's current estimated cost for later comparison
+// POST /api/lists/:id/snapshots
+func (h *Handler) CreateListSnapshot(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	snapshot, err := h.db.CreateListSnapshot(c.Context(), listID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to save list snapshot")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    snapshot,
+	})
+}
+
+// ListListSnapshots returns the saved cost snapshots of a list, newest first
+// GET /api/lists/:id/snapshots
+func (h *Handler) ListListSnapshots(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	snapshots, err := h.db.ListListSnapshots(c.Context(), listID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to list snapshots")
+	}
+
+	return Success(c, snapshots)
+}
+
+// CompareListSnapshots returns per-item and total cost differences between two snapshots
+// GET /api/lists/:id/compare-snapshots?from=&to=
+func (h *Handler) CompareListSnapshots(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	fromID := c.QueryInt("from", 0)
+	toID := c.QueryInt("to", 0)
+	if fromID < 1 || toID < 1 {
+		return Error(c, fiber.StatusBadRequest, "from and to snapshot ids are required")
+	}
+
+	comparison, err := h.db.CompareListSnapshots(c.Context(), listID, userID, fromID, toID)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		if errors.Is(err, database.ErrListSnapshotNotFound) {
+			return Error(c, fiber.StatusNotFound, "snapshot not found for this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to compare snapshots")
+	}
+
 	return Success(c, comparison)
 }
 
@@ -389,8 +781,8 @@ func (h *Handler) DuplicateShoppingList(c *fiber.Ctx) error {
 	var req struct {
 		Name string `json:"name"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	if req.Name == "" {
@@ -411,7 +803,47 @@ func (h *Handler) DuplicateShoppingList(c *fiber.Ctx) error {
 	return Success(c, newList)
 }
 
-// CompleteShoppingList marks a shopping list as completed with optional price confirmations
+// MergeShoppingList moves another list's items into this list, summing quantities of shared items
+// POST /api/lists/:id/merge
+func (h *Handler) MergeShoppingList(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	var req models.MergeListRequest
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if req.SourceListID <= 0 {
+		return Error(c, fiber.StatusBadRequest, "source_list_id is required")
+	}
+	if req.SourceListID == listID {
+		return Error(c, fiber.StatusBadRequest, "cannot merge a list into itself")
+	}
+
+	list, err := h.db.MergeShoppingLists(c.Context(), listID, req.SourceListID, userID, req.DeleteSource)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to merge shopping lists")
+	}
+
+	return Success(c, list)
+}
+
+// CompleteShoppingList marks a shopping list as completed with optional price confirmations.
+// With restock_inventory the checked-off items are added to the user's inventory, once per list.
 func (h *Handler) CompleteShoppingList(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
@@ -423,10 +855,12 @@ func (h *Handler) CompleteShoppingList(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid list id")
 	}
 
+	// Body is optional; when one is sent it is bound like any other
 	var req models.CompleteListRequest
-	if err := c.BodyParser(&req); err != nil {
-		// Body is optional, so just use empty request
-		req = models.CompleteListRequest{}
+	if len(c.Body()) > 0 {
+		if err := h.parseBody(c, &req); err != nil {
+			return Error(c, fiber.StatusBadRequest, err.Error())
+		}
 	}
 
 	list, err := h.db.CompleteShoppingList(c.Context(), listID, userID, &req)
@@ -446,6 +880,121 @@ func (h *Handler) CompleteShoppingList(c *fiber.Ctx) error {
 	return Success(c, list)
 }
 
+// ReconcileShoppingList records the prices actually paid for list items and returns the estimated
+// vs actual totals with per-item variances. Unlike completion it leaves the list status alone.
+// POST /api/lists/:id/reconcile
+func (h *Handler) ReconcileShoppingList(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	var req models.ReconcileListRequest
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if len(req.Items) == 0 {
+		return Error(c, fiber.StatusBadRequest, "items are required")
+	}
+	seen := make(map[int]bool, len(req.Items))
+	for _, item := range req.Items {
+		if item.ActualPrice <= 0 {
+			return Error(c, fiber.StatusBadRequest, "actual_price must be greater than 0")
+		}
+		if item.Quantity != nil && *item.Quantity < 1 {
+			return Error(c, fiber.StatusBadRequest, "quantity must be at least 1")
+		}
+		if seen[item.ItemID] {
+			return Error(c, fiber.StatusBadRequest, "each item can only be reconciled once")
+		}
+		seen[item.ItemID] = true
+	}
+
+	// Unverified accounts may be limited to private prices, as with any other submission
+	shared := !sharingHeldForVerification(c, h.db, h.getEncryptionKey(), userID)
+
+	result, err := h.db.ReconcileShoppingList(c.Context(), listID, userID, shared, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrListNotFound):
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		case errors.Is(err, database.ErrNotListOwner):
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		case errors.Is(err, database.ErrListItemNotFound):
+			return Error(c, fiber.StatusBadRequest, "every item must be on the list")
+		case errors.Is(err, database.ErrStoreNotFound):
+			return Error(c, fiber.StatusBadRequest, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to reconcile shopping list")
+	}
+
+	return Success(c, result)
+}
+
+// GetListNearbyStores returns public stores near a location with how many of the list's items
+// each prices and their estimated subtotal, best coverage first, then nearest
+// GET /api/lists/:id/nearby-stores?lat=&lng=&radius=
+func (h *Handler) GetListNearbyStores(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return Error(c, fiber.StatusBadRequest, "lat and lng are required")
+	}
+	if lat < -90 || lat > 90 {
+		return Error(c, fiber.StatusBadRequest, "latitude must be between -90 and 90")
+	}
+	if lng < -180 || lng > 180 {
+		return Error(c, fiber.StatusBadRequest, "longitude must be between -180 and 180")
+	}
+
+	radiusKm, err := strconv.ParseFloat(c.Query("radius", "10"), 64)
+	if err != nil || radiusKm <= 0 {
+		return Error(c, fiber.StatusBadRequest, "radius must be a positive number")
+	}
+	if radiusKm > 50 {
+		radiusKm = 50
+	}
+
+	unit, err := h.distanceUnit(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	stores, err := h.db.FindNearbyStoresForList(c.Context(), lat, lng, radiusKm, listID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to find nearby stores")
+	}
+
+	for _, s := range stores {
+		s.Distance = unit.FromKm(s.DistanceKm)
+		s.DistanceUnit = unit
+	}
+
+	return Success(c, stores)
+}
+
 // ReopenShoppingList marks a completed list as active again
 func (h *Handler) ReopenShoppingList(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
@@ -504,9 +1053,7 @@ func (h *Handler) GenerateShareLink(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to generate share link")
 	}
 
-	// Build full share URL
-	baseURL := c.Protocol() + "://" + c.Hostname()
-	shareURL := baseURL + "/share/" + token
+	shareURL := h.shareURL(c, token)
 
 	return Success(c, fiber.Map{
 		"token":      token,
@@ -515,6 +1062,15 @@ func (h *Handler) GenerateShareLink(c *fiber.Ctx) error {
 	})
 }
 
+// shareURL builds the public link for a share token, always on HTTPS when it is enforced
+func (h *Handler) shareURL(c *fiber.Ctx, token string) string {
+	scheme := c.Protocol()
+	if h.cfg.ForceHTTPS {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Hostname() + "/share/" + token
+}
+
 // GetSharedList retrieves a shopping list by share token (public endpoint)
 func (h *Handler) GetSharedList(c *fiber.Ctx) error {
 	token := c.Params("token")
@@ -605,9 +1161,7 @@ func (h *Handler) EmailShoppingList(c *fiber.Ctx) error {
 		}
 	}
 
-	// Build share URL
-	baseURL := c.Protocol() + "://" + c.Hostname()
-	shareURL := baseURL + "/share/" + token
+	shareURL := h.shareURL(c, token)
 
 	// Create email service and send
 	emailService := services.NewEmailService(h.db, h.cfg)
@@ -630,6 +1184,286 @@ func (h *Handler) EmailShoppingList(c *fiber.Ctx) error {
 	})
 }
 
+// WatchShoppingList subscribes a list to automatic replanning when its prices change
+// POST /api/lists/:id/watch
+func (h *Handler) WatchShoppingList(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	// Baseline cost for detecting later improvements (also verifies ownership)
+	var planCost *float64
+	plan, err := h.db.BuildShoppingPlan(c.Context(), listID, userID, nil, false, nil)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		if err.Error() != "shopping list is empty" {
+			return Error(c, fiber.StatusInternalServerError, "failed to build shopping plan")
+		}
+	} else if plan.PlanTotal > 0 {
+		planCost = &plan.PlanTotal
+	}
+
+	watch, err := h.db.WatchList(c.Context(), listID, userID, planCost)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to watch list")
+	}
+
+	return Success(c, watch)
+}
+
+// UnwatchShoppingList removes a list's auto-replan subscription
+// DELETE /api/lists/:id/watch
+func (h *Handler) UnwatchShoppingList(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	if err := h.db.UnwatchList(c.Context(), listID, userID); err != nil {
+		if errors.Is(err, database.ErrListWatchNotFound) {
+			return Error(c, fiber.StatusNotFound, "list is not being watched")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to unwatch list")
+	}
+
+	return Success(c, fiber.Map{"watching": false})
+}
+
+// SetListRecurrence makes a list repeat weekly, biweekly or monthly. Once a recurring list is
+// completed and its next occurrence arrives, a fresh active copy is created automatically.
+// PUT /api/lists/:id/recurrence
+func (h *Handler) SetListRecurrence(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	var req models.SetListRecurrenceRequest
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	req.Recurrence = strings.ToLower(strings.TrimSpace(req.Recurrence))
+	if !models.IsValidListRecurrence(req.Recurrence) {
+		return Error(c, fiber.StatusBadRequest, "recurrence must be none, weekly, biweekly, or monthly")
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if req.Recurrence != models.ListRecurrenceNone {
+		if req.NextOccurrence == nil {
+			next := models.NextListOccurrence(today, req.Recurrence)
+			req.NextOccurrence = &next
+		} else if req.NextOccurrence.Before(today) {
+			return Error(c, fiber.StatusBadRequest, "next_occurrence cannot be in the past")
+		}
+	}
+
+	list, err := h.db.SetListRecurrence(c.Context(), listID, userID, req.Recurrence, req.NextOccurrence)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to update list recurrence")
+	}
+
+	return Success(c, list)
+}
+
+// maxCalendarLists caps how many lists the all-lists calendar feed exports
+const maxCalendarLists = 100
+
+// GetListCalendar exports the shopping trip on the list's target date as an iCalendar event.
+// With ?all=true it exports every active list that has a target date, one event per list,
+// and the :id in the path is ignored.
+// GET /api/lists/:id/calendar.ics
+func (h *Handler) GetListCalendar(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	if c.QueryBool("all") {
+		return h.getAllListsCalendar(c, userID)
+	}
+
+	listID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid list id")
+	}
+
+	list, err := h.db.GetShoppingListByID(c.Context(), listID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrListNotFound) {
+			return Error(c, fiber.StatusNotFound, "shopping list not found")
+		}
+		if errors.Is(err, database.ErrNotListOwner) {
+			return Error(c, fiber.StatusForbidden, "you do not own this list")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get shopping list")
+	}
+
+	if list.TargetDate == nil {
+		return Error(c, fiber.StatusBadRequest, "shopping list has no target date")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="shopping-list-`+strconv.Itoa(list.ID)+`.ics"`)
+	return c.SendString(h.buildListCalendar(c, []*models.ShoppingListWithItems{list}))
+}
+
+// getAllListsCalendar exports every active list of the user that has a target date
+func (h *Handler) getAllListsCalendar(c *fiber.Ctx, userID int) error {
+	summaries, _, err := h.db.ListShoppingLists(c.Context(), &models.ListListParams{
+		Limit:  maxCalendarLists,
+		UserID: userID,
+		Status: models.ListStatusActive,
+
+		DenormalizedBestPrice: h.db.GetSettingBool(c.Context(), "item_best_price_denormalized", true, h.getEncryptionKey()),
+	})
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list shopping lists")
+	}
+
+	var lists []*models.ShoppingListWithItems
+	for _, summary := range summaries {
+		if summary.TargetDate == nil {
+			continue
+		}
+		list, err := h.db.GetShoppingListByID(c.Context(), summary.ID, userID)
+		if err != nil {
+			if errors.Is(err, database.ErrListNotFound) {
+				continue // Deleted since it was listed
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get shopping list")
+		}
+		lists = append(lists, list)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="shopping-lists.ics"`)
+	return c.SendString(h.buildListCalendar(c, lists))
+}
+
+// buildListCalendar renders a VCALENDAR with an all-day VEVENT for each list's target date
+func (h *Handler) buildListCalendar(c *fiber.Ctx, lists []*models.ShoppingListWithItems) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//PriceFeed//Shopping List//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	for _, list := range lists {
+		// Only link an active share; never create one as a side effect
+		var shareURL string
+		if list.ShareToken != nil && list.ShareExpiresAt != nil && list.ShareExpiresAt.After(time.Now()) {
+			shareURL = h.shareURL(c, *list.ShareToken)
+		}
+		lines = append(lines, listCalendarEvent(list, c.Hostname(), shareURL)...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(foldICSLine(line))
+		out.WriteString("\r\n")
+	}
+	return out.String()
+}
+
+// listCalendarEvent renders the VEVENT lines for the list's target date. The UID depends only
+// on the list ID and host so re-importing updates the existing event.
+func listCalendarEvent(list *models.ShoppingListWithItems, host, shareURL string) []string {
+	start := list.TargetDate.Format("20060102")
+	end := list.TargetDate.AddDate(0, 0, 1).Format("20060102")
+
+	var description strings.Builder
+	description.WriteString(strconv.Itoa(list.ItemCount) + " item(s)")
+	if list.EstimatedTotal > 0 {
+		description.WriteString(", estimated total $" + strconv.FormatFloat(list.EstimatedTotal, 'f', 2, 64))
+	}
+	description.WriteString("\n")
+	for _, item := range list.Items {
+		description.WriteString("\n- " + item.ItemName)
+		if item.Quantity > 1 {
+			description.WriteString(" (x" + strconv.Itoa(item.Quantity) + ")")
+		}
+	}
+	if shareURL != "" {
+		description.WriteString("\n\nOpen list: " + shareURL)
+	}
+
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:shopping-list-" + strconv.Itoa(list.ID) + "@" + host,
+		"DTSTAMP:" + list.UpdatedAt.UTC().Format("20060102T150405Z"),
+		"DTSTART;VALUE=DATE:" + start,
+		"DTEND;VALUE=DATE:" + end,
+		"SUMMARY:" + escapeICSText("Shopping trip: "+list.Name),
+		"DESCRIPTION:" + escapeICSText(description.String()),
+	}
+	if shareURL != "" {
+		lines = append(lines, "URL:"+shareURL)
+	}
+	return append(lines, "END:VEVENT")
+}
+
+// escapeICSText escapes TEXT values per RFC 5545
+func escapeICSText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// foldICSLine splits content lines longer than 75 octets, without breaking UTF-8 sequences
+func foldICSLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var out strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			out.WriteString("\r\n ")
+			width = 1
+		}
+		out.WriteRune(r)
+		width += size
+	}
+	return out.String()
+}
+
 // buildShoppingListEmailText creates the plain text email body for a shopping list
 func buildShoppingListEmailText(list *models.ShoppingListWithItems, shareURL string) string {
 	var items string
@@ -661,7 +1495,7 @@ func buildShoppingListEmail(list *models.ShoppingListWithItems, shareURL string)
 		if item.IsChecked {
 			checked = "✓ "
 		}
-		itemsList += "<li>" + checked + item.ItemName
+		itemsList += "<li>" + checked + html.EscapeString(item.ItemName)
 		if item.Quantity > 1 {
 			itemsList += " (x" + strconv.Itoa(item.Quantity) + ")"
 		}
@@ -678,7 +1512,7 @@ func buildShoppingListEmail(list *models.ShoppingListWithItems, shareURL string)
 </head>
 <body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Helvetica, Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f5f5f5;">
     <div style="background-color: white; border-radius: 8px; padding: 30px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
-        <h1 style="color: #333; margin-bottom: 20px;">` + list.Name + `</h1>
+        <h1 style="color: #333; margin-bottom: 20px;">` + html.EscapeString(list.Name) + `</h1>
         
         <p style="color: #666; margin-bottom: 20px;">Here's your shopping list. Click the button below to view and interact with your list on your phone!</p>
         
@@ -689,7 +1523,7 @@ func buildShoppingListEmail(list *models.ShoppingListWithItems, shareURL string)
             </ul>
         </div>
         
-        <a href="` + shareURL + `" style="display: inline-block; background-color: #007bff; color: white; text-decoration: none; padding: 12px 24px; border-radius: 6px; font-weight: 500;">Open Interactive List</a>
+        <a href="` + html.EscapeString(shareURL) + `" style="display: inline-block; background-color: #007bff; color: white; text-decoration: none; padding: 12px 24px; border-radius: 6px; font-weight: 500;">Open Interactive List</a>
         
         <p style="color: #999; font-size: 12px; margin-top: 30px;">This link expires in 7 days. You can mark items as checked directly from your phone!</p>
     </div>
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Weekly groceries", "Weekly groceries"},
+		{"trimmed", "  Weekly  ", "Weekly"},
+		{"newlines dropped", "Weekly\r\nBcc: victim@example.com", "WeeklyBcc: victim@example.com"},
+		{"tabs and nulls dropped", "Week\tly\x00", "Weekly"},
+		{"unicode kept", "Épicerie 🛒", "Épicerie 🛒"},
+		{"only control characters", "\n\t", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeName(tt.in); got != tt.want {
+				t.Errorf("sanitizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildShoppingListEmailEscapesUserText(t *testing.T) {
+	list := &models.ShoppingListWithItems{
+		ShoppingList: models.ShoppingList{Name: `<script>alert("list")</script>`},
+		Items: []models.ShoppingListItemWithDetails{
+			{ItemName: `<img src=x onerror=alert(1)>`},
+		},
+	}
+
+	body := buildShoppingListEmail(list, `https://example.com/s/abc"><script>`)
+
+	for _, raw := range []string{"<script>", "<img", `"><`} {
+		if strings.Contains(body, raw) {
+			t.Errorf("email body contains unescaped %q", raw)
+		}
+	}
+	for _, escaped := range []string{"&lt;script&gt;", "&lt;img src=x onerror=alert(1)&gt;"} {
+		if !strings.Contains(body, escaped) {
+			t.Errorf("email body is missing escaped text %q", escaped)
+		}
+	}
+}
@@ -1,24 +1,38 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
 	"github.com/foxxcyber/price-feed/internal/services"
 )
 
 // MapsHandler handles Google Maps related endpoints
 type MapsHandler struct {
-	mapsService *services.GoogleMapsService
-	frontendKey string
+	db            *database.DB
+	mapsService   *services.GoogleMapsService
+	storeGeocoder *services.StoreGeocoder
+	frontendKey   string
 }
 
 // NewMapsHandler creates a new MapsHandler instance
-func NewMapsHandler(mapsService *services.GoogleMapsService, frontendKey string) *MapsHandler {
+func NewMapsHandler(db *database.DB, mapsService *services.GoogleMapsService, storeGeocoder *services.StoreGeocoder, frontendKey string) *MapsHandler {
 	return &MapsHandler{
-		mapsService: mapsService,
-		frontendKey: frontendKey,
+		db:            db,
+		mapsService:   mapsService,
+		storeGeocoder: storeGeocoder,
+		frontendKey:   frontendKey,
 	}
 }
 
@@ -38,6 +52,8 @@ type NearbyStoresRequest struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 	Radius    int     `json:"radius"` // in meters, optional
+	// StoreTypes overrides the user's preferred store types, optional
+	StoreTypes []string `json:"store_types,omitempty"`
 }
 
 // TextSearchRequest is the request body for text-based store search
@@ -123,8 +139,18 @@ func (h *MapsHandler) NearbyStores(c *fiber.Ctx) error {
 		radius = 50000
 	}
 
-	// Search for supermarkets/grocery stores (empty type triggers multi-type search)
-	results, err := h.mapsService.NearbySearch(c.Context(), req.Latitude, req.Longitude, radius, "")
+	// Explicit store types win; otherwise use the user's preferences (supermarkets if none)
+	storeTypes, invalid := models.NormalizeStoreTypes(req.StoreTypes)
+	if invalid != "" {
+		return Error(c, fiber.StatusBadRequest, "invalid store type: "+invalid)
+	}
+	if len(storeTypes) == 0 {
+		if user, err := h.db.GetUserByID(c.Context(), middleware.GetUserID(c)); err == nil {
+			storeTypes = user.PreferredStoreTypes
+		}
+	}
+
+	results, err := h.mapsService.NearbySearchStoreTypes(c.Context(), req.Latitude, req.Longitude, radius, storeTypes)
 	if err != nil {
 		return handleMapsError(c, err)
 	}
@@ -196,6 +222,183 @@ func (h *MapsHandler) GetConfig(c *fiber.Ctx) error {
 	})
 }
 
+// GeocodeMissingStores backfills coordinates for stores that lack them (admin only)
+// POST /api/admin/stores/geocode-missing
+func (h *MapsHandler) GeocodeMissingStores(c *fiber.Ctx) error {
+	result, err := h.storeGeocoder.GeocodeMissing(c.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrGeocodeInProgress) {
+			return Error(c, fiber.StatusConflict, "geocode backfill already in progress")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to geocode stores")
+	}
+
+	return Success(c, result)
+}
+
+// maxStoreImportRows caps the number of stores in one import file
+const maxStoreImportRows = 1000
+
+// storeImportColumns lists the columns a store import file may contain; the first five are required
+var storeImportColumns = []string{"name", "street_address", "city", "state", "zip_code", "store_type", "chain", "latitude", "longitude", "region_id"}
+
+// ImportStores creates community stores from a CSV file, geocoding rows that lack
+// coordinates and assigning regions by zip code or location (admin only).
+// The file is sent as the "file" form field or as a text/csv body.
+// POST /api/admin/stores/import
+func (h *MapsHandler) ImportStores(c *fiber.Ctx) error {
+	var data []byte
+	if file, err := c.FormFile("file"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "failed to read file")
+		}
+		defer src.Close()
+		if data, err = io.ReadAll(src); err != nil {
+			return Error(c, fiber.StatusBadRequest, "failed to read file")
+		}
+	} else {
+		data = c.Body()
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return Error(c, fiber.StatusBadRequest, "a CSV file is required")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid CSV: "+err.Error())
+	}
+	if len(records) < 2 {
+		return Error(c, fiber.StatusBadRequest, "CSV must have a header row and at least one store")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range storeImportColumns[:5] {
+		if _, ok := columns[required]; !ok {
+			return Error(c, fiber.StatusBadRequest, "missing column: "+required)
+		}
+	}
+
+	if len(records)-1 > maxStoreImportRows {
+		return Error(c, fiber.StatusBadRequest, fmt.Sprintf("too many rows; at most %d stores per import", maxStoreImportRows))
+	}
+
+	var rows []models.StoreImportRow
+	var invalid []models.StoreImportRowResult
+	knownRegions := make(map[int]bool)
+	for i, record := range records[1:] {
+		row, problem := parseStoreImportRecord(record, columns)
+		row.Row = i + 1
+		if problem == "" && row.Request.RegionID != nil {
+			problem = h.checkImportRegion(c, *row.Request.RegionID, knownRegions)
+		}
+		if problem != "" {
+			invalid = append(invalid, models.StoreImportRowResult{
+				Row: row.Row, Name: row.Request.Name, Status: models.StoreImportInvalid, Error: problem,
+			})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	result, err := h.storeGeocoder.ImportStores(c.Context(), rows, middleware.GetUserID(c))
+	if err != nil {
+		if errors.Is(err, services.ErrGeocodeInProgress) {
+			return Error(c, fiber.StatusConflict, "a geocode backfill or store import is already in progress")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to import stores")
+	}
+
+	result.Invalid = len(invalid)
+	result.Rows = append(result.Rows, invalid...)
+	sort.Slice(result.Rows, func(a, b int) bool { return result.Rows[a].Row < result.Rows[b].Row })
+
+	return Success(c, result)
+}
+
+// checkImportRegion reports a problem when an imported row names a region that doesn't exist
+func (h *MapsHandler) checkImportRegion(c *fiber.Ctx, regionID int, known map[int]bool) string {
+	exists, seen := known[regionID]
+	if !seen {
+		_, err := h.db.GetRegionByID(c.Context(), regionID)
+		exists = err == nil
+		known[regionID] = exists
+	}
+	if !exists {
+		return "region_id does not exist"
+	}
+	return ""
+}
+
+// parseStoreImportRecord reads one CSV record into a store request, returning a description
+// of the first problem found
+func parseStoreImportRecord(record []string, columns map[string]int) (models.StoreImportRow, string) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	optional := func(name string) *string {
+		if v := field(name); v != "" {
+			return &v
+		}
+		return nil
+	}
+
+	row := models.StoreImportRow{Request: models.CreateStoreRequest{
+		Name:          field("name"),
+		StreetAddress: field("street_address"),
+		City:          field("city"),
+		State:         strings.ToUpper(field("state")),
+		ZipCode:       field("zip_code"),
+		StoreType:     optional("store_type"),
+		Chain:         optional("chain"),
+	}}
+	req := &row.Request
+
+	for _, required := range storeImportColumns[:5] {
+		if field(required) == "" {
+			return row, required + " is required"
+		}
+	}
+	if len(req.State) != 2 {
+		return row, "state must be a 2-letter code"
+	}
+
+	lat, lng := field("latitude"), field("longitude")
+	if (lat == "") != (lng == "") {
+		return row, "latitude and longitude must be given together"
+	}
+	if lat != "" {
+		latitude, err := strconv.ParseFloat(lat, 64)
+		if err != nil || latitude < -90 || latitude > 90 {
+			return row, "invalid latitude"
+		}
+		longitude, err := strconv.ParseFloat(lng, 64)
+		if err != nil || longitude < -180 || longitude > 180 {
+			return row, "invalid longitude"
+		}
+		req.Latitude, req.Longitude = &latitude, &longitude
+	}
+
+	if v := field("region_id"); v != "" {
+		regionID, err := strconv.Atoi(v)
+		if err != nil || regionID < 1 {
+			return row, "invalid region_id"
+		}
+		req.RegionID = &regionID
+	}
+
+	return row, ""
+}
+
 // handleMapsError converts Google Maps service errors to HTTP responses
 func handleMapsError(c *fiber.Ctx, err error) error {
 	switch {
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// GetNotificationPreferences returns every notification toggle for the user, including their
+// region digest subscriptions
+// GET /api/me/notifications
+func (h *Handler) GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	prefs, err := h.db.GetNotificationPreferences(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return Error(c, fiber.StatusNotFound, "user not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get notification preferences")
+	}
+
+	return Success(c, prefs)
+}
+
+// UpdateNotificationPreferences changes the toggles that are set and pauses or resumes the
+// listed region subscriptions
+// PUT /api/me/notifications
+func (h *Handler) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	prefs, err := h.db.UpdateNotificationPreferences(c.Context(), userID, &req)
+	if err != nil {
+		return regionSubscriptionError(c, err, "failed to update notification preferences")
+	}
+
+	return Success(c, prefs)
+}
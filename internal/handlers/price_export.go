@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// priceExportCSVHeader is the column layout of the price export; keep stable for downstream parsers
+var priceExportCSVHeader = []string{"store_name", "item_name", "brand", "price", "is_shared", "verified_count", "created_at"}
+
+// priceExportPageSize is how many prices are read per query while exporting
+const priceExportPageSize = 500
+
+// unsafeFilenameChars matches anything that should not appear in a download filename
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ExportUserPrices downloads every price a user submitted as CSV
+// GET /api/users/:id/prices/export
+func (h *Handler) ExportUserPrices(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	// Private prices are only visible to their owner and admins
+	if middleware.GetUserID(c) != id && middleware.GetUserRole(c) != models.RoleAdmin {
+		return Error(c, fiber.StatusForbidden, "cannot export another user's prices")
+	}
+
+	user, err := h.db.GetUserByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return Error(c, fiber.StatusNotFound, "user not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get user")
+	}
+
+	name := "user-" + strconv.Itoa(id)
+	if user.Username != nil {
+		if cleaned := unsafeFilenameChars.ReplaceAllString(*user.Username, "-"); cleaned != "" {
+			name = cleaned
+		}
+	}
+	filename := fmt.Sprintf("prices-%s-%s.csv", name, time.Now().Format("2006-01-02"))
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	w := csv.NewWriter(c)
+	if err := w.Write(priceExportCSVHeader); err != nil {
+		return err
+	}
+
+	params := &models.PriceListParams{
+		Limit:       priceExportPageSize,
+		SubmitterID: &id,
+	}
+	for {
+		prices, _, err := h.db.ListPrices(c.Context(), params)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range prices {
+			brand := ""
+			if p.ItemBrand != nil {
+				brand = *p.ItemBrand
+			}
+			record := []string{
+				p.StoreName,
+				p.ItemName,
+				brand,
+				fmt.Sprintf("%.2f", p.Price),
+				strconv.FormatBool(p.IsShared),
+				strconv.Itoa(p.VerifiedCount),
+				p.CreatedAt.Format(time.RFC3339),
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+
+		if len(prices) < priceExportPageSize {
+			break
+		}
+		params.Offset += priceExportPageSize
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/foxxcyber/price-feed/internal/database"
 	"github.com/foxxcyber/price-feed/internal/middleware"
 	"github.com/foxxcyber/price-feed/internal/models"
+	"github.com/foxxcyber/price-feed/internal/services"
 )
 
 // ListPrices returns a paginated list of prices
@@ -59,6 +64,13 @@ func (h *Handler) ListPrices(c *fiber.Ctx) error {
 		}
 	}
 
+	// Only prices updated or verified recently
+	maxAge, err := maxAgeDaysQuery(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	params.MaxAgeDays = maxAge
+
 	// Validate limits
 	if params.Limit < 1 || params.Limit > 100 {
 		params.Limit = 50
@@ -72,6 +84,10 @@ func (h *Handler) ListPrices(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to list prices")
 	}
 
+	maskPriceContributors(prices, h.contributorVisibility(c))
+	markUnverifiedPrices(prices, h.publicStatsFilter(c))
+	markStalePrices(prices, h.priceStaleDays(c))
+
 	return SuccessWithMeta(c, prices, total, params.Limit, params.Offset)
 }
 
@@ -90,14 +106,24 @@ func (h *Handler) GetPrice(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to get price")
 	}
 
+	maskPriceContributors([]*models.StorePriceWithDetails{price}, h.contributorVisibility(c))
+	markUnverifiedPrices([]*models.StorePriceWithDetails{price}, h.publicStatsFilter(c))
+	markStalePrices([]*models.StorePriceWithDetails{price}, h.priceStaleDays(c))
+
 	return Success(c, price)
 }
 
 // CreatePrice creates a new price
 func (h *Handler) CreatePrice(c *fiber.Ctx) error {
-	var req models.CreatePriceRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	// A price that omits is_shared follows the submitter's sharing default
+	req := models.CreatePriceRequest{IsShared: true}
+	if uid := middleware.GetUserID(c); uid != 0 {
+		if user, err := h.db.GetUserByID(c.Context(), uid); err == nil {
+			req.IsShared = user.SharePricesByDefault
+		}
+	}
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
@@ -113,9 +139,31 @@ func (h *Handler) CreatePrice(c *fiber.Ctx) error {
 
 	// Get user ID from context if available
 	var userID *int
-	if user := c.Locals("user"); user != nil {
-		if u, ok := user.(*models.User); ok {
-			userID = &u.ID
+	if uid := middleware.GetUserID(c); uid != 0 {
+		userID = &uid
+	}
+
+	// The store must be visible to the submitter (and in their region, if configured)
+	if userID != nil && middleware.GetUserRole(c) != models.RoleAdmin {
+		if status, msg := h.checkPriceStoreAccess(c, *userID, req.StoreID, req.IsShared); status != 0 {
+			return Error(c, status, msg)
+		}
+	}
+
+	// Unverified accounts may be limited to private prices; they are shared once the email is verified
+	if userID != nil && req.IsShared && sharingHeldForVerification(c, h.db, h.getEncryptionKey(), *userID) {
+		req.IsShared = false
+		req.ShareOnVerify = true
+	}
+
+	// Optionally hold back prices far from what others pay, unless the submitter confirms them
+	if !req.ConfirmOutlier {
+		if outlier := h.checkPriceOutlier(c, req.ItemID, req.Price); outlier != nil {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"success": false,
+				"error":   "price looks unusual for this item; resubmit with confirm_outlier to save it",
+				"outlier": outlier,
+			})
 		}
 	}
 
@@ -137,12 +185,109 @@ func (h *Handler) CreatePrice(c *fiber.Ctx) error {
 		// The price was created successfully
 	}
 
+	if userID != nil {
+		h.priceAlerter.Notify(models.PriceSubmission{
+			PriceID: price.ID, StoreID: req.StoreID, ItemID: req.ItemID, Price: req.Price, UserID: *userID, IsShared: req.IsShared,
+		})
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(APIResponse{
 		Success: true,
 		Data:    price,
 	})
 }
 
+// sharingHeldForVerification reports whether the user's prices must stay private until they verify
+// their email (private_prices_until_verified). Admins are exempt.
+func sharingHeldForVerification(c *fiber.Ctx, db *database.DB, encryptionKey []byte, userID int) bool {
+	if middleware.GetUserRole(c) == models.RoleAdmin {
+		return false
+	}
+	if !db.GetSettingBool(c.Context(), "private_prices_until_verified", false, encryptionKey) {
+		return false
+	}
+
+	user, err := db.GetUserByID(c.Context(), userID)
+	if err != nil {
+		// Fail closed: an unknown account is treated as unverified
+		return true
+	}
+	return !user.EmailVerified
+}
+
+// checkPriceOutlier returns outlier details when price_outlier_check_enabled is set and the price
+// is more than price_outlier_factor away from the typical price for the item's package size
+func (h *Handler) checkPriceOutlier(c *fiber.Ctx, itemID int, price float64) *models.PriceOutlier {
+	if !h.db.GetSettingBool(c.Context(), "price_outlier_check_enabled", false, h.getEncryptionKey()) {
+		return nil
+	}
+	return h.detectPriceOutlier(c, itemID, price)
+}
+
+// detectPriceOutlier returns outlier details when the price is more than price_outlier_factor
+// away from the typical price for the item's package size, whether or not submissions are checked
+func (h *Handler) detectPriceOutlier(c *fiber.Ctx, itemID int, price float64) *models.PriceOutlier {
+	encryptionKey := h.getEncryptionKey()
+	factor := h.db.GetSettingFloat(c.Context(), "price_outlier_factor", 3, encryptionKey)
+	useUnitPrice := h.db.GetSettingBool(c.Context(), "price_outlier_use_unit_price", true, encryptionKey)
+
+	item, err := h.db.GetItemByID(c.Context(), itemID)
+	if err != nil {
+		return nil
+	}
+	refs, err := h.db.GetOutlierReferencePrices(c.Context(), itemID)
+	if err != nil {
+		return nil
+	}
+
+	return services.DetectPriceOutlier(itemID, price, item.Size, item.Unit, refs, factor, useUnitPrice)
+}
+
+// checkPriceStoreAccess verifies a user may submit a price for a store. Private stores are only
+// open to their creator; shared prices may optionally be limited to stores in the user's region.
+// Returns a zero status when the submission is allowed.
+func (h *Handler) checkPriceStoreAccess(c *fiber.Ctx, userID, storeID int, shared bool) (int, string) {
+	store, err := h.db.GetStoreByID(c.Context(), storeID)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return fiber.StatusNotFound, "store not found"
+		}
+		return fiber.StatusInternalServerError, "failed to get store"
+	}
+
+	if privateStoreBlocked(&store.Store, userID) {
+		return fiber.StatusForbidden, "you cannot submit prices for another user's private store"
+	}
+
+	if !shared || store.RegionID == nil {
+		return 0, ""
+	}
+	if !h.db.GetSettingBool(c.Context(), "price_require_region_match", false, h.getEncryptionKey()) {
+		return 0, ""
+	}
+
+	user, err := h.db.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return fiber.StatusInternalServerError, "failed to get user"
+	}
+	if outsideUserRegion(&store.Store, user.RegionID) {
+		return fiber.StatusForbidden, "shared prices can only be submitted for stores in your region"
+	}
+
+	return 0, ""
+}
+
+// privateStoreBlocked reports whether a store is private to a user other than userID
+func privateStoreBlocked(store *models.Store, userID int) bool {
+	return store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != userID)
+}
+
+// outsideUserRegion reports whether a store with a region lies outside the user's region; a
+// user without a region is outside every region
+func outsideUserRegion(store *models.Store, userRegionID *int) bool {
+	return store.RegionID != nil && (userRegionID == nil || *userRegionID != *store.RegionID)
+}
+
 // UpdatePrice updates an existing price (admin only)
 func (h *Handler) UpdatePrice(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -151,8 +296,8 @@ func (h *Handler) UpdatePrice(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdatePriceRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate price if provided
@@ -195,8 +340,8 @@ func (h *Handler) UserUpdatePrice(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdatePriceRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate price if provided
@@ -285,28 +430,95 @@ func (h *Handler) VerifyPrice(c *fiber.Ctx) error {
 	}
 
 	// Get user ID from context
-	user := c.Locals("user")
-	if user == nil {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
 		return Error(c, fiber.StatusUnauthorized, "authentication required")
 	}
-	u, ok := user.(*models.User)
-	if !ok {
-		return Error(c, fiber.StatusInternalServerError, "invalid user context")
-	}
 
-	if err := h.db.VerifyPrice(c.Context(), id, u.ID, req.IsAccurate); err != nil {
+	points := h.db.GetSettingInt(c.Context(), "reputation_verify_points", 1, h.getEncryptionKey())
+	submitterPoints := h.db.GetSettingInt(c.Context(), "reputation_price_verified_points", 2, h.getEncryptionKey())
+
+	result, err := h.db.VerifyPrice(c.Context(), id, userID, req.IsAccurate, points, submitterPoints)
+	if err != nil {
+		if errors.Is(err, database.ErrPriceNotFound) {
+			return Error(c, fiber.StatusNotFound, "price not found")
+		}
+		if errors.Is(err, database.ErrVerifyOwnPrice) {
+			return Error(c, fiber.StatusForbidden, "you cannot verify your own price")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to verify price")
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "price verification recorded",
+		"data":    result,
 	})
 }
 
-// GetPriceStats returns aggregate price statistics
+// FlagPrice reports a price as wrong with a reason. Enough flags hide the price from comparisons
+// and shopping plans until it is verified as accurate again.
+// POST /api/prices/:id/flag
+func (h *Handler) FlagPrice(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid price id")
+	}
+
+	var req models.FlagPriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if !models.IsValidPriceFlagReason(req.FlagReason) {
+		return Error(c, fiber.StatusBadRequest, "flag_reason must be one of: too_high, too_low, item_mismatch, out_of_stock, other")
+	}
+	reason, ok := normalizeFlagReason(req.Reason)
+	if !ok {
+		return Error(c, fiber.StatusBadRequest, fmt.Sprintf("reason must be %d characters or fewer", maxFlagReasonLength))
+	}
+	req.Reason = reason
+
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "authentication required")
+	}
+
+	result, err := h.db.FlagPrice(c.Context(), id, userID, &req)
+	if err != nil {
+		if errors.Is(err, database.ErrPriceNotFound) {
+			return Error(c, fiber.StatusNotFound, "price not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to flag price")
+	}
+
+	return Success(c, result)
+}
+
+// maxFlagReasonLength is the longest free-text flag reason, in characters
+const maxFlagReasonLength = 500
+
+// normalizeFlagReason trims a flag's free-text reason, dropping it when blank. ok is false when
+// the reason is longer than maxFlagReasonLength characters.
+func normalizeFlagReason(reason *string) (*string, bool) {
+	if reason == nil {
+		return nil, true
+	}
+	trimmed := strings.TrimSpace(*reason)
+	if utf8.RuneCountInString(trimmed) > maxFlagReasonLength {
+		return nil, false
+	}
+	if trimmed == "" {
+		return nil, true
+	}
+	return &trimmed, true
+}
+
+// GetPriceStats returns aggregate price statistics, including how many prices count toward
+// public statistics when verified-only mode is on
+// GET /api/prices/stats
 func (h *Handler) GetPriceStats(c *fiber.Ctx) error {
-	stats, err := h.db.GetPriceStats(c.Context())
+	stats, err := h.db.GetPriceStats(c.Context(), h.publicStatsFilter(c))
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to get price stats")
 	}
@@ -314,34 +526,76 @@ func (h *Handler) GetPriceStats(c *fiber.Ctx) error {
 	return Success(c, stats)
 }
 
-// GetPricesByStore returns all prices for a store
+// GetPricesByStore returns a page of a store's prices
+// GET /api/prices/by-store/:store_id
 func (h *Handler) GetPricesByStore(c *fiber.Ctx) error {
 	storeID, err := strconv.Atoi(c.Params("store_id"))
 	if err != nil {
 		return Error(c, fiber.StatusBadRequest, "invalid store id")
 	}
 
-	prices, err := h.db.GetPricesByStore(c.Context(), storeID)
+	params, err := scopedPriceParams(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	prices, total, err := h.db.GetPricesByStore(c.Context(), storeID, params)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to get prices")
 	}
 
-	return Success(c, prices)
+	maskPriceContributors(prices, h.contributorVisibility(c))
+	markUnverifiedPrices(prices, h.publicStatsFilter(c))
+	markStalePrices(prices, h.priceStaleDays(c))
+
+	return SuccessWithMeta(c, prices, total, params.Limit, params.Offset)
 }
 
-// GetPricesByItem returns all prices for an item
+// GetPricesByItem returns a page of an item's prices, cheapest first
+// GET /api/prices/by-item/:item_id
 func (h *Handler) GetPricesByItem(c *fiber.Ctx) error {
 	itemID, err := strconv.Atoi(c.Params("item_id"))
 	if err != nil {
 		return Error(c, fiber.StatusBadRequest, "invalid item id")
 	}
 
-	prices, err := h.db.GetPricesByItem(c.Context(), itemID)
+	params, err := scopedPriceParams(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	prices, total, err := h.db.GetPricesByItem(c.Context(), itemID, params)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to get prices")
 	}
 
-	return Success(c, prices)
+	maskPriceContributors(prices, h.contributorVisibility(c))
+	markUnverifiedPrices(prices, h.publicStatsFilter(c))
+	markStalePrices(prices, h.priceStaleDays(c))
+
+	return SuccessWithMeta(c, prices, total, params.Limit, params.Offset)
+}
+
+// scopedPriceParams reads limit/offset and the shared_only and min_verified filters
+func scopedPriceParams(c *fiber.Ctx) (*models.ScopedPriceParams, error) {
+	params := &models.ScopedPriceParams{
+		Limit:       c.QueryInt("limit", 50),
+		Offset:      c.QueryInt("offset", 0),
+		SharedOnly:  c.QueryBool("shared_only"),
+		MinVerified: c.QueryInt("min_verified", 0),
+	}
+
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 50
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+	if params.MinVerified < 0 {
+		return nil, errors.New("min_verified must not be negative")
+	}
+
+	return params, nil
 }
 
 // GetPriceHistory returns the price history for an item
@@ -378,3 +632,360 @@ func (h *Handler) GetPriceHistory(c *fiber.Ctx) error {
 
 	return Success(c, history)
 }
+
+// GetLowestPriceEver returns the lowest price ever recorded for an item
+// GET /api/items/:id/lowest-ever?store_id=&region_id=
+func (h *Handler) GetLowestPriceEver(c *fiber.Ctx) error {
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	if _, err := h.db.GetItemByID(c.Context(), itemID); err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	params := &models.LowestPriceParams{ItemID: itemID}
+
+	if storeID := c.Query("store_id"); storeID != "" {
+		id, err := strconv.Atoi(storeID)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid store_id")
+		}
+		params.StoreID = &id
+	}
+
+	if regionID := c.Query("region_id"); regionID != "" {
+		id, err := strconv.Atoi(regionID)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid region_id")
+		}
+		params.RegionID = &id
+	}
+
+	record, err := h.db.GetLowestPriceEver(c.Context(), params)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get lowest price")
+	}
+
+	return Success(c, record)
+}
+
+// Minimum history needed before the best-day analysis reports a result
+const (
+	bestDayMinSamples = 10
+	bestDayMinBuckets = 3
+)
+
+var weekdayLabels = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// GetBestDayToBuy reports the day of week (or week of month) when an item's price tends to be lowest
+// GET /api/items/:id/best-day
+func (h *Handler) GetBestDayToBuy(c *fiber.Ctx) error {
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	if _, err := h.db.GetItemByID(c.Context(), itemID); err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	params := &models.BestDayParams{
+		ItemID:  itemID,
+		GroupBy: c.Query("by", models.BestDayByDayOfWeek),
+		Days:    c.QueryInt("days", 365),
+	}
+	if params.GroupBy != models.BestDayByDayOfWeek && params.GroupBy != models.BestDayByWeekOfMonth {
+		return Error(c, fiber.StatusBadRequest, "by must be day_of_week or week_of_month")
+	}
+	if params.Days < 30 || params.Days > 730 {
+		params.Days = 365
+	}
+
+	if storeID := c.Query("store_id"); storeID != "" {
+		id, err := strconv.Atoi(storeID)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid store_id")
+		}
+		params.StoreID = &id
+	}
+
+	if regionID := c.Query("region_id"); regionID != "" {
+		id, err := strconv.Atoi(regionID)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid region_id")
+		}
+		params.RegionID = &id
+	}
+
+	buckets, err := h.db.GetBestDayBuckets(c.Context(), params)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to analyze price history")
+	}
+
+	return Success(c, summarizeBestDay(params, buckets))
+}
+
+// summarizeBestDay labels the buckets, picks the cheapest one and rates confidence by sample size
+func summarizeBestDay(params *models.BestDayParams, buckets []*models.BestDayBucket) *models.BestDayInsight {
+	insight := &models.BestDayInsight{
+		ItemID:     params.ItemID,
+		GroupBy:    params.GroupBy,
+		Days:       params.Days,
+		Confidence: models.BestDayConfidenceInsufficient,
+		Buckets:    buckets,
+	}
+
+	for _, b := range buckets {
+		if params.GroupBy == models.BestDayByDayOfWeek && b.Bucket >= 0 && b.Bucket < len(weekdayLabels) {
+			b.Label = weekdayLabels[b.Bucket]
+		} else {
+			b.Label = fmt.Sprintf("Week %d", b.Bucket)
+		}
+		b.AvgPrice = math.Round(b.AvgPrice*100) / 100
+		b.RelativePrice = math.Round(b.RelativePrice*1000) / 1000
+
+		insight.SampleCount += b.SampleCount
+		if insight.Best == nil || b.RelativePrice < insight.Best.RelativePrice {
+			insight.Best = b
+		}
+	}
+
+	if insight.SampleCount < bestDayMinSamples || len(buckets) < bestDayMinBuckets {
+		insight.Best = nil
+		return insight
+	}
+
+	insight.SavingsPercent = math.Round((1-insight.Best.RelativePrice)*1000) / 10
+	switch {
+	case insight.SampleCount >= 100:
+		insight.Confidence = models.BestDayConfidenceHigh
+	case insight.SampleCount >= 30:
+		insight.Confidence = models.BestDayConfidenceMedium
+	default:
+		insight.Confidence = models.BestDayConfidenceLow
+	}
+
+	return insight
+}
+
+// GetPriceHistogram returns the distribution of an item's current shared prices.
+// Honors verified-only mode (public_stats_verified_only).
+// GET /api/items/:id/price-histogram
+func (h *Handler) GetPriceHistogram(c *fiber.Ctx) error {
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	if _, err := h.db.GetItemByID(c.Context(), itemID); err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	buckets := c.QueryInt("buckets", 10)
+	if buckets < 1 || buckets > 50 {
+		return Error(c, fiber.StatusBadRequest, "buckets must be between 1 and 50")
+	}
+
+	var regionID *int
+	if rid := c.Query("region_id"); rid != "" {
+		id, err := strconv.Atoi(rid)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid region_id")
+		}
+		regionID = &id
+	}
+
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, h.getEncryptionKey())
+	if staleDays < 1 {
+		staleDays = 30
+	}
+
+	histogram, err := h.db.GetPriceHistogram(c.Context(), itemID, regionID, buckets, staleDays, h.publicStatsFilter(c))
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get price histogram")
+	}
+
+	return Success(c, histogram)
+}
+
+// GetPriceDrops is a deals feed of the items whose prices dropped the most, in percent, within
+// the last days (default 7, at most 90), optionally in one region. Each item appears once,
+// with the store offering its dropped price. With ?latest=true it instead lists store prices
+// whose most recent change was a drop, for the deals widget, unpaginated.
+// GET /api/prices/drops
+func (h *Handler) GetPriceDrops(c *fiber.Ctx) error {
+	key := h.getEncryptionKey()
+	params := &models.PriceDropParams{
+		Days:          c.QueryInt("days", 7),
+		StaleDays:     h.db.GetSettingInt(c.Context(), "price_stale_days", 30, key),
+		OutlierFactor: h.db.GetSettingFloat(c.Context(), "price_outlier_factor", 3, key),
+		MinPercent:    h.db.GetSettingFloat(c.Context(), "price_drop_min_percent", 5, key),
+		Limit:         c.QueryInt("limit", 20),
+		Offset:        c.QueryInt("offset", 0),
+	}
+	if params.Days < 1 || params.Days > 90 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 1 and 90")
+	}
+	if rid := c.Query("region_id"); rid != "" {
+		id, err := strconv.Atoi(rid)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid region_id")
+		}
+		params.RegionID = &id
+	}
+	if params.StaleDays < 1 {
+		params.StaleDays = 30
+	}
+	if params.MinPercent < 0 {
+		params.MinPercent = 0
+	}
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 20
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+
+	if c.QueryBool("latest") {
+		drops, err := h.db.GetRecentPriceDrops(c.Context(), params.RegionID, params.MinPercent, params.Limit)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to get price drops")
+		}
+		return Success(c, drops)
+	}
+
+	drops, total, err := h.db.GetPriceDrops(c.Context(), params)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get price drops")
+	}
+
+	return SuccessWithMeta(c, drops, total, params.Limit, params.Offset)
+}
+
+// GetItemRegionPrices compares an item's current shared prices across regions. With normalize
+// (default from the region_price_normalize setting) prices are also restated at the baseline
+// cost of living for regions that have an index; each row flags whether it was normalized.
+// Honors verified-only mode per region.
+// GET /api/items/:id/region-prices
+func (h *Handler) GetItemRegionPrices(c *fiber.Ctx) error {
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid item id")
+	}
+
+	if _, err := h.db.GetItemByID(c.Context(), itemID); err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get item")
+	}
+
+	key := h.getEncryptionKey()
+	normalize := h.db.GetSettingBool(c.Context(), "region_price_normalize", false, key)
+	if v := c.Query("normalize"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "normalize must be true or false")
+		}
+		normalize = parsed
+	}
+
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, key)
+	if staleDays < 1 {
+		staleDays = 30
+	}
+
+	regions, err := h.db.GetItemRegionPrices(c.Context(), itemID, staleDays, h.publicStatsFilter(c))
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get region prices")
+	}
+
+	if normalize {
+		for _, r := range regions {
+			r.Normalize()
+		}
+	}
+
+	return Success(c, fiber.Map{
+		"item_id":        itemID,
+		"normalize":      normalize,
+		"baseline_index": models.CostOfLivingBaseline,
+		"regions":        regions,
+	})
+}
+
+// contributorVisibility builds the username masking rules for the current viewer
+func (h *Handler) contributorVisibility(c *fiber.Ctx) *models.ContributorVisibility {
+	return &models.ContributorVisibility{
+		ViewerID:      middleware.GetUserID(c),
+		ViewerIsAdmin: middleware.GetUserRole(c) == models.RoleAdmin,
+		AnonymizeAll:  h.db.GetSettingBool(c.Context(), "anonymize_contributors", false, h.getEncryptionKey()),
+	}
+}
+
+// publicStatsFilter reads the verified-only mode for public price statistics. It is honored by
+// the price histogram, region price comparison, price stats and item list/detail price averages; raw price listings
+// still return every price and flag the ones that don't count yet.
+func (h *Handler) publicStatsFilter(c *fiber.Ctx) models.PublicStatsFilter {
+	key := h.getEncryptionKey()
+	filter := models.PublicStatsFilter{
+		VerifiedOnly:      h.db.GetSettingBool(c.Context(), "public_stats_verified_only", false, key),
+		MinVerifications:  h.db.GetSettingInt(c.Context(), "public_stats_min_verifications", 1, key),
+		MinVerifiedPrices: h.db.GetSettingInt(c.Context(), "public_stats_min_verified_prices", 3, key),
+	}
+	if filter.MinVerifications < 1 {
+		filter.MinVerifications = 1
+	}
+	if filter.MinVerifiedPrices < 1 {
+		filter.MinVerifiedPrices = 1
+	}
+	return filter
+}
+
+// markUnverifiedPrices flags prices that verified-only mode keeps out of public statistics
+func markUnverifiedPrices(prices []*models.StorePriceWithDetails, filter models.PublicStatsFilter) {
+	for _, p := range prices {
+		p.Unverified = filter.IsUnverified(p.VerifiedCount)
+	}
+}
+
+// priceStaleDays reads how many days without an update or verification make a price stale
+func (h *Handler) priceStaleDays(c *fiber.Ctx) int {
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, h.getEncryptionKey())
+	if staleDays < 1 {
+		staleDays = 30
+	}
+	return staleDays
+}
+
+// markStalePrices sets each price's age and flags those not updated or verified within staleDays
+func markStalePrices(prices []*models.StorePriceWithDetails, staleDays int) {
+	now := time.Now()
+	for _, p := range prices {
+		p.DaysOld, p.IsStale = models.PriceAge(lastTouched(p), now, staleDays)
+	}
+}
+
+// maskPriceContributors replaces submitter identity with a generic label where required.
+// The user ID is dropped too, since it resolves back to the username through the users API.
+func maskPriceContributors(prices []*models.StorePriceWithDetails, v *models.ContributorVisibility) {
+	for _, p := range prices {
+		if p.UserID != nil && v.ShouldMask(p.UserID, p.ContributorHidden) {
+			anonymous := models.AnonymousContributorName
+			p.UserName = &anonymous
+			p.UserEmail = nil
+			p.UserID = nil
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestPrivateStoreBlocked(t *testing.T) {
+	owner, other := 1, 2
+
+	tests := []struct {
+		name  string
+		store models.Store
+		user  int
+		want  bool
+	}{
+		{"public store", models.Store{CreatedBy: &owner}, other, false},
+		{"own private store", models.Store{IsPrivate: true, CreatedBy: &owner}, owner, false},
+		{"another user's private store", models.Store{IsPrivate: true, CreatedBy: &owner}, other, true},
+		{"private store without creator", models.Store{IsPrivate: true}, owner, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := privateStoreBlocked(&tt.store, tt.user); got != tt.want {
+				t.Errorf("privateStoreBlocked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutsideUserRegion(t *testing.T) {
+	north, south := 1, 2
+
+	tests := []struct {
+		name        string
+		storeRegion *int
+		userRegion  *int
+		want        bool
+	}{
+		{"store without region", nil, &north, false},
+		{"same region", &north, &north, false},
+		{"different region", &north, &south, true},
+		{"user without region", &north, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &models.Store{RegionID: tt.storeRegion}
+			if got := outsideUserRegion(store, tt.userRegion); got != tt.want {
+				t.Errorf("outsideUserRegion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFlagReason(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		reason *string
+		want   *string
+		ok     bool
+	}{
+		{"nil", nil, nil, true},
+		{"blank", str("   "), nil, true},
+		{"trimmed", str("  wrong price "), str("wrong price"), true},
+		{"ascii at limit", str(strings.Repeat("a", maxFlagReasonLength)), str(strings.Repeat("a", maxFlagReasonLength)), true},
+		{"ascii over limit", str(strings.Repeat("a", maxFlagReasonLength+1)), nil, false},
+		{"multibyte at limit", str(strings.Repeat("é", maxFlagReasonLength)), str(strings.Repeat("é", maxFlagReasonLength)), true},
+		{"multibyte over limit", str(strings.Repeat("é", maxFlagReasonLength+1)), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normalizeFlagReason(tt.reason)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("normalizeFlagReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskPriceContributors(t *testing.T) {
+	viewer, submitter := 1, 2
+	name := "alice"
+
+	tests := []struct {
+		name       string
+		visibility *models.ContributorVisibility
+		userID     int
+		hidden     bool
+		wantMasked bool
+	}{
+		{"anonymize all", &models.ContributorVisibility{ViewerID: viewer, AnonymizeAll: true}, submitter, false, true},
+		{"submitter hides name", &models.ContributorVisibility{ViewerID: viewer}, submitter, true, true},
+		{"visible submitter", &models.ContributorVisibility{ViewerID: viewer}, submitter, false, false},
+		{"own price", &models.ContributorVisibility{ViewerID: viewer, AnonymizeAll: true}, viewer, true, false},
+		{"admin viewer", &models.ContributorVisibility{ViewerID: viewer, ViewerIsAdmin: true, AnonymizeAll: true}, submitter, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := tt.userID
+			p := &models.StorePriceWithDetails{UserName: &name, ContributorHidden: tt.hidden}
+			p.UserID = &userID
+
+			maskPriceContributors([]*models.StorePriceWithDetails{p}, tt.visibility)
+
+			if tt.wantMasked {
+				if p.UserID != nil {
+					t.Errorf("masked price kept user_id %d", *p.UserID)
+				}
+				if p.UserName == nil || *p.UserName != models.AnonymousContributorName {
+					t.Errorf("masked price user_name = %v, want %q", p.UserName, models.AnonymousContributorName)
+				}
+				return
+			}
+			if p.UserID == nil || *p.UserID != tt.userID {
+				t.Errorf("unmasked price user_id = %v, want %d", p.UserID, tt.userID)
+			}
+			if p.UserName == nil || *p.UserName != name {
+				t.Errorf("unmasked price user_name = %v, want %q", p.UserName, name)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +28,8 @@ type ReceiptHandler struct {
 	ocr     *services.OCRService
 	parser  *services.ReceiptParser
 	matcher *services.ItemMatcher
+	alerts  *services.PriceAlerter
+	urls    *services.PresignedURLCache
 }
 
 // NewReceiptHandler creates a new receipt handler
@@ -35,6 +40,7 @@ func NewReceiptHandler(
 	ocr *services.OCRService,
 	parser *services.ReceiptParser,
 	matcher *services.ItemMatcher,
+	alerts *services.PriceAlerter,
 ) *ReceiptHandler {
 	return &ReceiptHandler{
 		db:      db,
@@ -43,6 +49,8 @@ func NewReceiptHandler(
 		ocr:     ocr,
 		parser:  parser,
 		matcher: matcher,
+		alerts:  alerts,
+		urls:    services.NewPresignedURLCache(storage),
 	}
 }
 
@@ -93,6 +101,9 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to read file")
 	}
+	if err := services.CheckImageDimensions(imageBytes); err != nil {
+		return Error(c, fiber.StatusBadRequest, fmt.Sprintf("image is too large. Maximum is %d megapixels", services.MaxImagePixels/1_000_000))
+	}
 
 	// Upload to S3
 	uploadResult, err := h.storage.Upload(c.Context(), s3Key, strings.NewReader(string(imageBytes)), file.Size, contentType)
@@ -118,19 +129,44 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to create receipt record")
 	}
 
+	// Store a small preview for the receipt list
+	h.storeThumbnail(c, receipt.ID, s3Key, imageBytes)
+
+	if err := h.processReceipt(c, receipt, imageBytes); err != nil {
+		return Error(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return h.receiptResponse(c, receipt.ID)
+}
+
+// Client-facing failures of the receipt OCR pipeline
+var (
+	errReceiptOCRFailed   = errors.New("OCR processing failed")
+	errReceiptParseFailed = errors.New("failed to parse receipt")
+)
+
+// processReceipt runs OCR and parsing on a receipt image, stores the receipt metadata and
+// creates its matched items. The receipt moves through processing to completed or failed.
+func (h *ReceiptHandler) processReceipt(c *fiber.Ctx, receipt *models.Receipt, imageBytes []byte) error {
 	// Update status to processing
 	if err := h.db.UpdateReceiptStatus(c.Context(), receipt.ID, models.ReceiptStatusProcessing, nil, nil); err != nil {
 		log.Printf("Warning: Failed to update receipt %d status to processing: %v", receipt.ID, err)
 	}
 
+	// Clean up the photo for OCR and record what was done for debugging
+	ocrImage, preprocessing := h.preprocessForOCR(c, imageBytes)
+	if err := h.db.SetReceiptOCRPreprocessing(c.Context(), receipt.ID, preprocessing); err != nil {
+		log.Printf("Warning: Failed to record OCR preprocessing for receipt %d: %v", receipt.ID, err)
+	}
+
 	// Process with OCR
-	ocrResult, err := h.ocr.ProcessImage(imageBytes)
+	ocrResult, err := h.ocr.ProcessImage(ocrImage)
 	if err != nil {
 		errMsg := err.Error()
 		if statusErr := h.db.UpdateReceiptStatus(c.Context(), receipt.ID, models.ReceiptStatusFailed, nil, &errMsg); statusErr != nil {
 			log.Printf("Warning: Failed to update receipt %d status to failed: %v", receipt.ID, statusErr)
 		}
-		return Error(c, fiber.StatusInternalServerError, "OCR processing failed")
+		return errReceiptOCRFailed
 	}
 
 	// Parse the OCR text
@@ -140,14 +176,15 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 		if statusErr := h.db.UpdateReceiptStatus(c.Context(), receipt.ID, models.ReceiptStatusFailed, &ocrResult.Text, &errMsg); statusErr != nil {
 			log.Printf("Warning: Failed to update receipt %d status to failed: %v", receipt.ID, statusErr)
 		}
-		return Error(c, fiber.StatusInternalServerError, "failed to parse receipt")
+		return errReceiptParseFailed
 	}
 
 	// Update receipt with OCR text and metadata
 	if err := h.db.UpdateReceiptStatus(c.Context(), receipt.ID, models.ReceiptStatusCompleted, &ocrResult.Text, nil); err != nil {
 		log.Printf("Warning: Failed to update receipt %d status to completed: %v", receipt.ID, err)
 	}
-	if err := h.db.UpdateReceiptMetadata(c.Context(), receipt.ID, parsed.Date, parsed.Total); err != nil {
+	receiptDate, dateSource := h.inferReceiptDate(c, receipt, ocrResult.Text, parsed.Date, imageBytes)
+	if err := h.db.UpdateReceiptMetadata(c.Context(), receipt.ID, receiptDate, dateSource, parsed.Total); err != nil {
 		log.Printf("Warning: Failed to update receipt %d metadata: %v", receipt.ID, err)
 	}
 
@@ -159,6 +196,7 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 	}
 
 	// Create receipt items
+	minOCRConfidence := h.minOCRConfidence(c)
 	for _, item := range matched {
 		var matchedItemID *int
 		var matchConfidence *float64
@@ -170,6 +208,12 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 			matchStatus = models.MatchStatusMatched
 		}
 
+		// Lines that were hard to read need a second look whatever they matched
+		ocrConfidence := ocrResult.LineConfidence(item.ParsedItem.RawText)
+		if ocrConfidence != nil && *ocrConfidence < minOCRConfidence {
+			matchStatus = models.MatchStatusNeedsReview
+		}
+
 		_, err := h.db.CreateReceiptItem(c.Context(), &models.CreateReceiptItemRequest{
 			ReceiptID:         receipt.ID,
 			RawText:           item.ParsedItem.RawText,
@@ -180,6 +224,7 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 			MatchConfidence:   matchConfidence,
 			MatchStatus:       matchStatus,
 			LineNumber:        item.ParsedItem.LineNumber,
+			OCRConfidence:     ocrConfidence,
 		})
 		if err != nil {
 			// Continue even if individual item creation fails
@@ -187,22 +232,31 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get the complete receipt with items
-	fullReceipt, err := h.db.GetReceiptByID(c.Context(), receipt.ID)
+	return nil
+}
+
+// receiptResponse returns a receipt with image URLs, review flags and item suggestions
+func (h *ReceiptHandler) receiptResponse(c *fiber.Ctx, id int) error {
+	receipt, err := h.db.GetReceiptByID(c.Context(), id)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to retrieve receipt")
 	}
 
-	// Generate presigned URL for the image
-	imageURL, _ := h.storage.GetPresignedURL(c.Context(), s3Key, 1*time.Hour)
-	fullReceipt.ImageURL = &imageURL
+	h.setImageURL(c, receipt)
+	h.setThumbnailURL(c, receipt, true)
+	flagLowConfidence(receipt, h.minOCRConfidence(c))
+	h.addItemSuggestions(c, receipt)
+
+	return Success(c, receipt)
+}
 
-	// Add suggestions to items
-	for i := range fullReceipt.Items {
-		if fullReceipt.Items[i].ExtractedName != nil {
-			suggestions, _ := h.matcher.FindMatches(c.Context(), *fullReceipt.Items[i].ExtractedName, 5)
+// addItemSuggestions attaches the top catalog matches to each extracted receipt line
+func (h *ReceiptHandler) addItemSuggestions(c *fiber.Ctx, receipt *models.ReceiptWithItems) {
+	for i := range receipt.Items {
+		if receipt.Items[i].ExtractedName != nil {
+			suggestions, _ := h.matcher.FindMatches(c.Context(), *receipt.Items[i].ExtractedName, 5)
 			for _, s := range suggestions {
-				fullReceipt.Items[i].Suggestions = append(fullReceipt.Items[i].Suggestions, models.ItemSuggestion{
+				receipt.Items[i].Suggestions = append(receipt.Items[i].Suggestions, models.ItemSuggestion{
 					ItemID:     s.ItemID,
 					Name:       s.Name,
 					Brand:      s.Brand,
@@ -212,8 +266,199 @@ func (h *ReceiptHandler) UploadReceipt(c *fiber.Ctx) error {
 			}
 		}
 	}
+}
+
+// storeThumbnail generates a preview of an uploaded image and uploads it next to the original.
+// Thumbnails are optional, so failures are logged and the receipt is processed without one.
+func (h *ReceiptHandler) storeThumbnail(c *fiber.Ctx, receiptID int, s3Key string, imageBytes []byte) {
+	key := DeriveEncryptionKey(h.cfg.JWTSecret)
+	ctx := c.Context()
+
+	if !h.db.GetSettingBool(ctx, "receipt_thumbnail_enabled", true, key) {
+		return
+	}
+
+	thumb, err := services.GenerateThumbnail(imageBytes, services.ThumbnailOptions{
+		MaxWidth:  h.db.GetSettingInt(ctx, "receipt_thumbnail_max_width", 320, key),
+		MaxHeight: h.db.GetSettingInt(ctx, "receipt_thumbnail_max_height", 480, key),
+		Format:    h.db.GetSettingString(ctx, "receipt_thumbnail_format", services.ThumbnailFormatJPEG, key),
+		Quality:   h.db.GetSettingInt(ctx, "receipt_thumbnail_quality", 75, key),
+	})
+	if err != nil {
+		log.Printf("Warning: Thumbnail skipped for receipt %d: %v", receiptID, err)
+		return
+	}
+
+	thumbKey := thumbnailS3Key(s3Key, thumb.Extension)
+	if _, err := h.storage.Upload(ctx, thumbKey, bytes.NewReader(thumb.Image), int64(len(thumb.Image)), thumb.ContentType); err != nil {
+		log.Printf("Warning: Failed to upload thumbnail for receipt %d: %v", receiptID, err)
+		return
+	}
+	if err := h.db.SetReceiptThumbnail(ctx, receiptID, thumbKey); err != nil {
+		log.Printf("Warning: Failed to record thumbnail for receipt %d: %v", receiptID, err)
+		if deleteErr := h.storage.Delete(ctx, thumbKey); deleteErr != nil {
+			log.Printf("Warning: Failed to clean up S3 object %s: %v", thumbKey, deleteErr)
+		}
+	}
+}
+
+// setImageURL sets the receipt image's presigned URL; it is left unset if none can be issued
+func (h *ReceiptHandler) setImageURL(c *fiber.Ctx, receipt *models.ReceiptWithItems) {
+	if url, err := h.presignedURL(c, receipt.S3Key, true); err == nil {
+		expiresIn := url.ExpiresIn()
+		receipt.ImageURL = &url.URL
+		receipt.ImageURLExpiresIn = &expiresIn
+	}
+}
+
+// setThumbnailURL presigns the receipt's thumbnail, if it has one. List pages presign a
+// thumbnail per receipt, so they pass rateLimited false rather than spend the user's limit.
+func (h *ReceiptHandler) setThumbnailURL(c *fiber.Ctx, receipt *models.ReceiptWithItems, rateLimited bool) {
+	if receipt.ThumbnailKey == nil {
+		return
+	}
+	if url, err := h.presignedURL(c, *receipt.ThumbnailKey, rateLimited); err == nil {
+		expiresIn := url.ExpiresIn()
+		receipt.ThumbnailURL = &url.URL
+		receipt.ThumbnailURLExpiresIn = &expiresIn
+	}
+}
+
+// presignedURL returns a cached presigned URL for key, generating a fresh one when the cached
+// one is near expiry. Fresh URLs count against the requesting user's per-minute limit when
+// rateLimited is set.
+func (h *ReceiptHandler) presignedURL(c *fiber.Ctx, key string, rateLimited bool) (*services.PresignedURL, error) {
+	encKey := DeriveEncryptionKey(h.cfg.JWTSecret)
+	ctx := c.Context()
+
+	validity := h.db.GetSettingInt(ctx, "receipt_url_validity_minutes", 60, encKey)
+	if validity < 1 {
+		validity = 1
+	}
+	refresh := h.db.GetSettingInt(ctx, "receipt_url_refresh_minutes", 10, encKey)
+	if refresh < 0 || refresh >= validity {
+		// Reuse a URL for at least part of its life
+		refresh = validity / 2
+	}
+
+	opts := services.PresignOptions{
+		Validity:      time.Duration(validity) * time.Minute,
+		RefreshBefore: time.Duration(refresh) * time.Minute,
+	}
+	if rateLimited {
+		opts.MaxPerMinute = h.db.GetSettingInt(ctx, "receipt_url_max_per_minute", 30, encKey)
+	}
+
+	return h.urls.Get(ctx, middleware.GetUserID(c), key, opts)
+}
+
+// minOCRConfidence reads the OCR confidence (0-100) below which receipt lines are flagged for review
+func (h *ReceiptHandler) minOCRConfidence(c *fiber.Ctx) float64 {
+	return h.db.GetSettingFloat(c.Context(), "receipt_ocr_min_confidence", 60, DeriveEncryptionKey(h.cfg.JWTSecret))
+}
+
+// flagLowConfidence marks the receipt lines whose OCR confidence is below minConfidence
+func flagLowConfidence(receipt *models.ReceiptWithItems, minConfidence float64) {
+	for i := range receipt.Items {
+		conf := receipt.Items[i].OCRConfidence
+		receipt.Items[i].LowConfidence = conf != nil && *conf < minConfidence
+	}
+}
+
+// preprocessForOCR runs the configured preprocessing steps on an uploaded image and returns
+// the image to OCR with a label of the steps applied. Any failure falls back to the original.
+func (h *ReceiptHandler) preprocessForOCR(c *fiber.Ctx, imageBytes []byte) ([]byte, string) {
+	key := DeriveEncryptionKey(h.cfg.JWTSecret)
+	ctx := c.Context()
+
+	if !h.db.GetSettingBool(ctx, "ocr_preprocess_enabled", true, key) {
+		return imageBytes, services.OCRPreprocessNone
+	}
+
+	opts := services.OCRPreprocessOptions{
+		Grayscale:      h.db.GetSettingBool(ctx, "ocr_preprocess_grayscale", true, key),
+		Contrast:       h.db.GetSettingBool(ctx, "ocr_preprocess_contrast", true, key),
+		Deskew:         h.db.GetSettingBool(ctx, "ocr_preprocess_deskew", false, key),
+		MaxSkewDegrees: h.db.GetSettingFloat(ctx, "ocr_preprocess_max_skew_degrees", 5, key),
+		CleanContrast:  h.db.GetSettingFloat(ctx, "ocr_preprocess_clean_contrast", 0.8, key),
+		Holdout:        rand.Intn(100) < h.db.GetSettingInt(ctx, "ocr_preprocess_holdout_percent", 0, key),
+	}
+
+	result, err := services.PreprocessReceiptImage(imageBytes, opts)
+	if err != nil {
+		log.Printf("Warning: OCR preprocessing skipped: %v", err)
+		return imageBytes, services.OCRPreprocessNone
+	}
+	return result.Image, result.Label()
+}
+
+// GetOCRPreprocessingStats reports the receipt item match rate per preprocessing variant so
+// the effect of each step (and of the holdout group) can be compared. (admin only)
+// GET /api/admin/receipts/ocr-preprocessing?days=30
+func (h *ReceiptHandler) GetOCRPreprocessingStats(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > 365 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 1 and 365")
+	}
 
-	return Success(c, fullReceipt)
+	stats, err := h.db.GetOCRPreprocessingStats(c.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get OCR preprocessing stats")
+	}
+
+	return Success(c, stats)
+}
+
+// GetReceiptMetrics reports aggregate OCR and parse success rates, extracted versus confirmed
+// lines and how often parsed totals disagree with the extracted lines. (admin only)
+// GET /api/admin/receipts/metrics?days=30&tolerance=0.1
+func (h *ReceiptHandler) GetReceiptMetrics(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > 365 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 1 and 365")
+	}
+
+	// Totals include tax, so allow some difference by default
+	tolerance := c.QueryFloat("tolerance", 0.1)
+	if tolerance < 0 || tolerance > 1 {
+		return Error(c, fiber.StatusBadRequest, "tolerance must be between 0 and 1")
+	}
+
+	metrics, err := h.db.GetReceiptOCRMetrics(c.Context(), time.Now().AddDate(0, 0, -days), tolerance, h.minOCRConfidence(c))
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get receipt metrics")
+	}
+
+	return Success(c, metrics)
+}
+
+// inferReceiptDate resolves the receipt date from OCR text, EXIF capture time or upload time
+func (h *ReceiptHandler) inferReceiptDate(c *fiber.Ctx, receipt *models.Receipt, ocrText string, ocrDate *time.Time, imageBytes []byte) (*time.Time, *string) {
+	key := DeriveEncryptionKey(h.cfg.JWTSecret)
+
+	if !h.db.GetSettingBool(c.Context(), "receipt_date_inference_enabled", true, key) {
+		if ocrDate == nil {
+			return nil, nil
+		}
+		source := models.ReceiptDateSourceOCR
+		return ocrDate, &source
+	}
+
+	// Re-read ambiguous dates (e.g. 03/04) in day-first order when the receipt's region uses
+	// them; the setting covers receipts with no known region
+	dayFirst := h.db.GetSettingBool(c.Context(), "receipt_date_day_first", false, key)
+	locale, err := h.db.GetReceiptLocale(c.Context(), receipt.StoreID, receipt.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to look up locale for receipt %d: %v", receipt.ID, err)
+	} else if regionDayFirst, ok := services.LocaleDayFirst(locale); ok {
+		dayFirst = regionDayFirst
+	}
+	if dayFirst {
+		ocrDate = h.parser.ExtractDate(ocrText, true)
+	}
+
+	date, source := services.InferReceiptDate(ocrDate, services.ExtractEXIFDate(imageBytes), receipt.UploadedAt)
+	return &date, &source
 }
 
 // ListReceipts returns a paginated list of user's receipts
@@ -246,6 +491,11 @@ func (h *ReceiptHandler) ListReceipts(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to list receipts")
 	}
 
+	// Thumbnails keep the list view from fetching full images
+	for _, receipt := range receipts {
+		h.setThumbnailURL(c, receipt, false)
+	}
+
 	return SuccessWithMeta(c, receipts, total, params.Limit, params.Offset)
 }
 
@@ -274,27 +524,78 @@ func (h *ReceiptHandler) GetReceipt(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusForbidden, "access denied")
 	}
 
-	// Generate presigned URL
-	imageURL, _ := h.storage.GetPresignedURL(c.Context(), receipt.S3Key, 1*time.Hour)
-	receipt.ImageURL = &imageURL
+	h.setImageURL(c, receipt)
+	h.setThumbnailURL(c, receipt, true)
+	flagLowConfidence(receipt, h.minOCRConfidence(c))
+	h.addItemSuggestions(c, receipt)
 
-	// Add suggestions to items
-	for i := range receipt.Items {
-		if receipt.Items[i].ExtractedName != nil {
-			suggestions, _ := h.matcher.FindMatches(c.Context(), *receipt.Items[i].ExtractedName, 5)
-			for _, s := range suggestions {
-				receipt.Items[i].Suggestions = append(receipt.Items[i].Suggestions, models.ItemSuggestion{
-					ItemID:     s.ItemID,
-					Name:       s.Name,
-					Brand:      s.Brand,
-					Confidence: s.Confidence,
-					MatchType:  s.MatchType,
-				})
-			}
+	return Success(c, receipt)
+}
+
+// GetReceiptConflicts returns only the receipt lines that need review before confirmation:
+// unmatched lines and matches below receipt_auto_match_confidence, each with its top suggestions.
+// GET /api/receipts/:id/conflicts
+func (h *ReceiptHandler) GetReceiptConflicts(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid receipt ID")
+	}
+
+	receipt, err := h.db.GetReceiptByID(c.Context(), id)
+	if err != nil {
+		if err == database.ErrReceiptNotFound {
+			return Error(c, fiber.StatusNotFound, "receipt not found")
 		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get receipt")
 	}
 
-	return Success(c, receipt)
+	if receipt.UserID != userID {
+		return Error(c, fiber.StatusForbidden, "access denied")
+	}
+
+	minConfidence := h.db.GetSettingFloat(c.Context(), "receipt_auto_match_confidence", 0.9, DeriveEncryptionKey(h.cfg.JWTSecret))
+	flagLowConfidence(receipt, h.minOCRConfidence(c))
+
+	result := &models.ReceiptConflicts{
+		ReceiptID:     id,
+		MinConfidence: minConfidence,
+		Items:         []models.ReceiptItemWithSuggestions{},
+	}
+	for _, item := range receipt.Items {
+		switch {
+		case item.IsConfirmed || item.MatchStatus == models.MatchStatusSkipped:
+			result.Resolved++
+			continue
+		case item.MatchStatus == models.MatchStatusMatched && item.MatchedItemID != nil &&
+			item.MatchConfidence != nil && *item.MatchConfidence >= minConfidence:
+			result.AutoMatchable++
+			continue
+		}
+
+		result.NeedsReview++
+		name := item.RawText
+		if item.ExtractedName != nil && *item.ExtractedName != "" {
+			name = *item.ExtractedName
+		}
+		suggestions, _ := h.matcher.FindMatches(c.Context(), name, 5)
+		for _, s := range suggestions {
+			item.Suggestions = append(item.Suggestions, models.ItemSuggestion{
+				ItemID:     s.ItemID,
+				Name:       s.Name,
+				Brand:      s.Brand,
+				Confidence: s.Confidence,
+				MatchType:  s.MatchType,
+			})
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	return Success(c, result)
 }
 
 // UpdateReceiptItem updates a single receipt item
@@ -381,11 +682,42 @@ func (h *ReceiptHandler) ConfirmReceipt(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "store_id is required")
 	}
 
+	// Receipt prices are shared unless the account must verify its email first
+	shared := !sharingHeldForVerification(c, h.db, DeriveEncryptionKey(h.cfg.JWTSecret), userID)
+
+	rewardPoints := h.db.GetSettingInt(c.Context(), "reputation_receipt_confirmed_points", 3, DeriveEncryptionKey(h.cfg.JWTSecret))
+
+	// Clean up names of new items that still match the receipt text; names the user edited are
+	// kept as typed. The receipt text is kept on the item for later matching.
+	if h.db.GetSettingBool(c.Context(), "receipt_item_name_normalize", true, DeriveEncryptionKey(h.cfg.JWTSecret)) {
+		replacements, err := h.db.GetItemNameReplacements(c.Context())
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to load item name rules")
+		}
+		rawText := make(map[int]string, len(receipt.Items))
+		for _, item := range receipt.Items {
+			rawText[item.ID] = item.RawText
+		}
+		for i, item := range req.Items {
+			if item.CreateNewItem && item.NewItemName != nil {
+				name := services.NormalizeNewItemName(*item.NewItemName, rawText[item.ReceiptItemID], replacements)
+				req.Items[i].NewItemName = &name
+			}
+		}
+	}
+
 	// Confirm receipt and create prices
-	err = h.db.ConfirmReceipt(c.Context(), id, req.StoreID, userID, req.Items)
+	// Opt in with add_to_inventory in the body or the query string
+	addToInventory := req.AddToInventory || c.QueryBool("add_to_inventory", false)
+
+	stored, err := h.db.ConfirmReceipt(c.Context(), id, req.StoreID, userID, shared, h.receiptPriceScope(c), req.Items, rewardPoints, addToInventory)
 	if err != nil {
+		if errors.Is(err, database.ErrReceiptConfirmed) {
+			return Error(c, fiber.StatusBadRequest, "receipt already confirmed")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to confirm receipt")
 	}
+	h.alerts.Notify(stored...)
 
 	// Get updated receipt
 	updatedReceipt, err := h.db.GetReceiptByID(c.Context(), id)
@@ -396,6 +728,15 @@ func (h *ReceiptHandler) ConfirmReceipt(c *fiber.Ctx) error {
 	return Success(c, updatedReceipt)
 }
 
+// receiptPriceScope reads which existing store price a confirmed receipt line replaces
+func (h *ReceiptHandler) receiptPriceScope(c *fiber.Ctx) models.ReceiptPriceScope {
+	scope := h.db.GetSettingString(c.Context(), "receipt_confirm_price_scope", string(models.ReceiptPriceScopeContributor), DeriveEncryptionKey(h.cfg.JWTSecret))
+	if models.ReceiptPriceScope(scope) == models.ReceiptPriceScopeStoreItem {
+		return models.ReceiptPriceScopeStoreItem
+	}
+	return models.ReceiptPriceScopeContributor
+}
+
 // DeleteReceipt deletes a receipt and its image
 func (h *ReceiptHandler) DeleteReceipt(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -425,6 +766,13 @@ func (h *ReceiptHandler) DeleteReceipt(c *fiber.Ctx) error {
 	if err := h.storage.Delete(c.Context(), receipt.S3Key); err != nil {
 		log.Printf("Warning: Failed to delete S3 object %s for receipt %d: %v", receipt.S3Key, id, err)
 	}
+	h.urls.Forget(receipt.S3Key)
+	if receipt.ThumbnailKey != nil {
+		if err := h.storage.Delete(c.Context(), *receipt.ThumbnailKey); err != nil {
+			log.Printf("Warning: Failed to delete S3 object %s for receipt %d: %v", *receipt.ThumbnailKey, id, err)
+		}
+		h.urls.Forget(*receipt.ThumbnailKey)
+	}
 
 	// Delete from database
 	err = h.db.DeleteReceipt(c.Context(), id)
@@ -435,7 +783,7 @@ func (h *ReceiptHandler) DeleteReceipt(c *fiber.Ctx) error {
 	return Success(c, fiber.Map{"deleted": true})
 }
 
-// GetReceiptImage returns a presigned URL for the receipt image
+// GetReceiptImage returns a presigned URL for the receipt image and the seconds it remains valid
 func (h *ReceiptHandler) GetReceiptImage(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
@@ -449,7 +797,7 @@ func (h *ReceiptHandler) GetReceiptImage(c *fiber.Ctx) error {
 
 	receipt, err := h.db.GetReceiptByID(c.Context(), id)
 	if err != nil {
-		if err == database.ErrReceiptNotFound {
+		if errors.Is(err, database.ErrReceiptNotFound) {
 			return Error(c, fiber.StatusNotFound, "receipt not found")
 		}
 		return Error(c, fiber.StatusInternalServerError, "failed to get receipt")
@@ -459,13 +807,236 @@ func (h *ReceiptHandler) GetReceiptImage(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusForbidden, "access denied")
 	}
 
-	// Generate presigned URL (valid for 1 hour)
-	url, err := h.storage.GetPresignedURL(c.Context(), receipt.S3Key, 1*time.Hour)
+	url, err := h.presignedURL(c, receipt.S3Key, true)
 	if err != nil {
+		if errors.Is(err, services.ErrPresignRateLimited) {
+			return Error(c, fiber.StatusTooManyRequests, "too many image requests, try again shortly")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to generate image URL")
 	}
 
-	return Success(c, fiber.Map{"url": url})
+	return Success(c, fiber.Map{"url": url.URL, "expires_in": url.ExpiresIn()})
+}
+
+// ReprocessReceipt runs OCR and parsing again on a receipt's stored image and replaces its items,
+// so a failed or poorly read receipt can be retried without uploading it again
+// POST /api/receipts/:id/reprocess
+func (h *ReceiptHandler) ReprocessReceipt(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid receipt ID")
+	}
+
+	receipt, err := h.db.GetReceiptByID(c.Context(), id)
+	if err != nil {
+		if err == database.ErrReceiptNotFound {
+			return Error(c, fiber.StatusNotFound, "receipt not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get receipt")
+	}
+
+	if receipt.UserID != userID {
+		return Error(c, fiber.StatusForbidden, "access denied")
+	}
+
+	if receipt.Status == models.ReceiptStatusConfirmed {
+		return Error(c, fiber.StatusBadRequest, "receipt already confirmed")
+	}
+	if receipt.S3Key == "" {
+		return Error(c, fiber.StatusBadRequest, "receipt has no stored image")
+	}
+
+	obj, err := h.storage.Download(c.Context(), receipt.S3Key)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to download receipt image")
+	}
+	defer obj.Close()
+
+	imageBytes, err := io.ReadAll(io.LimitReader(obj, 10*1024*1024))
+	if err != nil || len(imageBytes) == 0 {
+		return Error(c, fiber.StatusInternalServerError, "failed to download receipt image")
+	}
+
+	if err := h.db.DeleteReceiptItems(c.Context(), id); err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to clear receipt items")
+	}
+
+	if err := h.processReceipt(c, &receipt.Receipt, imageBytes); err != nil {
+		return Error(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return h.receiptResponse(c, id)
+}
+
+// maxRematchItems caps how many receipt items one rematch request re-runs
+const maxRematchItems = 1000
+
+// RematchReceipt re-runs item matching for a receipt's unconfirmed items against the current catalog
+// POST /api/receipts/:id/rematch
+func (h *ReceiptHandler) RematchReceipt(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid receipt ID")
+	}
+
+	receipt, err := h.db.GetReceiptByID(c.Context(), id)
+	if err != nil {
+		if err == database.ErrReceiptNotFound {
+			return Error(c, fiber.StatusNotFound, "receipt not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get receipt")
+	}
+
+	// Owners and admins may rematch a receipt
+	if receipt.UserID != userID && middleware.GetUserRole(c) != models.RoleAdmin {
+		return Error(c, fiber.StatusForbidden, "access denied")
+	}
+
+	if receipt.Status == models.ReceiptStatusConfirmed {
+		return Error(c, fiber.StatusBadRequest, "receipt already confirmed")
+	}
+
+	result, err := h.matcher.RematchReceiptItems(c.Context(), &id, maxRematchItems)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to rematch receipt items")
+	}
+
+	return Success(c, result)
+}
+
+// RematchAllReceipts re-runs item matching for unconfirmed items across all unconfirmed receipts
+// POST /api/admin/receipts/rematch?limit=500
+func (h *ReceiptHandler) RematchAllReceipts(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", maxRematchItems)
+	if limit < 1 || limit > maxRematchItems {
+		limit = maxRematchItems
+	}
+
+	result, err := h.matcher.RematchReceiptItems(c.Context(), nil, limit)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to rematch receipt items")
+	}
+
+	return Success(c, result)
+}
+
+// Bounds for storage reconciliation batches and the number of keys echoed back
+const (
+	defaultReconcileBatch = 500
+	maxReconcileBatch     = 1000
+	maxReconcileSample    = 200
+)
+
+// ReconcileStorage cross-checks receipt images in storage against the receipts table. Objects
+// without a receipt are reported and, with delete_orphans, removed; receipts whose object is
+// gone are reported only. Objects newer than storage_reconcile_grace_minutes are skipped so
+// in-flight uploads are not mistaken for orphans. (admin only)
+// POST /api/admin/storage/reconcile
+func (h *ReceiptHandler) ReconcileStorage(c *fiber.Ctx) error {
+	var req models.StorageReconcileRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid request body")
+		}
+	}
+	if req.BatchSize < 1 {
+		req.BatchSize = defaultReconcileBatch
+	}
+	if req.BatchSize > maxReconcileBatch {
+		req.BatchSize = maxReconcileBatch
+	}
+
+	ctx := c.Context()
+	grace := h.db.GetSettingInt(ctx, "storage_reconcile_grace_minutes", 60, DeriveEncryptionKey(h.cfg.JWTSecret))
+	if grace < 0 {
+		grace = 0
+	}
+	startedAt := time.Now()
+	cutoff := startedAt.Add(-time.Duration(grace) * time.Minute)
+
+	result := &models.StorageReconcileResult{OrphanKeys: []string{}, MissingReceipts: []models.ReceiptObjectRef{}}
+	stored := make(map[string]bool)
+
+	// Objects without a receipt row
+	err := h.storage.WalkObjects(ctx, "receipts/", req.BatchSize, func(batch []services.StoredObject) error {
+		result.ObjectsScanned += len(batch)
+
+		keys := make([]string, 0, len(batch))
+		sizes := make(map[string]int64, len(batch))
+		for _, obj := range batch {
+			stored[obj.Key] = true
+			if obj.LastModified.Before(cutoff) {
+				keys = append(keys, obj.Key)
+				sizes[obj.Key] = obj.Size
+			}
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		orphans, err := h.db.FindUnreferencedReceiptKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		result.OrphanedObjects += len(orphans)
+		for _, key := range orphans {
+			result.OrphanedBytes += sizes[key]
+			if len(result.OrphanKeys) < maxReconcileSample {
+				result.OrphanKeys = append(result.OrphanKeys, key)
+			}
+		}
+
+		if req.DeleteOrphans && len(orphans) > 0 {
+			if err := h.storage.DeleteMultiple(ctx, orphans); err != nil {
+				return err
+			}
+			result.DeletedObjects += len(orphans)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Warning: Storage reconciliation failed after %d objects: %v", result.ObjectsScanned, err)
+		return Error(c, fiber.StatusInternalServerError, "failed to reconcile storage objects")
+	}
+
+	// Receipt rows whose object is gone; receipts created after the listing began may not be in it
+	afterID := 0
+	for {
+		refs, err := h.db.ListReceiptObjectRefs(ctx, afterID, req.BatchSize, startedAt)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to list receipts")
+		}
+		if len(refs) == 0 {
+			break
+		}
+
+		result.ReceiptsScanned += len(refs)
+		for _, ref := range refs {
+			if stored[ref.S3Key] {
+				continue
+			}
+			result.MissingObjects++
+			if len(result.MissingReceipts) < maxReconcileSample {
+				result.MissingReceipts = append(result.MissingReceipts, ref)
+			}
+		}
+		afterID = refs[len(refs)-1].ReceiptID
+	}
+
+	log.Printf("Storage reconciliation: %d objects, %d orphaned (%d deleted), %d receipts missing objects",
+		result.ObjectsScanned, result.OrphanedObjects, result.DeletedObjects, result.MissingObjects)
+
+	return Success(c, result)
 }
 
 // isValidImageType checks if the content type is a valid image
@@ -498,6 +1069,15 @@ func generateS3Key(userID int, filename string) string {
 	return fmt.Sprintf("receipts/%d/%d%s", userID, timestamp, ext)
 }
 
+// thumbnailS3Key derives the key of a receipt's thumbnail from its image key,
+// e.g. receipts/7/1700000000.png -> receipts/7/1700000000.thumb.jpg
+func thumbnailS3Key(s3Key, ext string) string {
+	if idx := strings.LastIndex(s3Key, "."); idx > strings.LastIndex(s3Key, "/") {
+		s3Key = s3Key[:idx]
+	}
+	return s3Key + ".thumb" + ext
+}
+
 // CreateManualReceipt creates a receipt without image upload (manual entry)
 func (h *ReceiptHandler) CreateManualReceipt(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -518,8 +1098,11 @@ func (h *ReceiptHandler) CreateManualReceipt(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "at least one item is required")
 	}
 
+	// Receipt prices are shared unless the account must verify its email first
+	shared := !sharingHeldForVerification(c, h.db, DeriveEncryptionKey(h.cfg.JWTSecret), userID)
+
 	// Create the receipt
-	receipt, err := h.db.CreateManualReceipt(c.Context(), userID, &req)
+	receipt, err := h.db.CreateManualReceipt(c.Context(), userID, &req, shared, h.receiptPriceScope(c))
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to create receipt")
 	}
@@ -529,27 +1112,3 @@ func (h *ReceiptHandler) CreateManualReceipt(c *fiber.Ctx) error {
 		Data:    receipt,
 	})
 }
-
-// GetSpendingSummary returns monthly spending summary for the user
-func (h *ReceiptHandler) GetSpendingSummary(c *fiber.Ctx) error {
-	userID := middleware.GetUserID(c)
-	if userID == 0 {
-		return Error(c, fiber.StatusUnauthorized, "unauthorized")
-	}
-
-	// Get number of months (default 6)
-	months := c.QueryInt("months", 6)
-	if months < 1 {
-		months = 1
-	}
-	if months > 24 {
-		months = 24
-	}
-
-	summary, err := h.db.GetSpendingSummary(c.Context(), userID, months)
-	if err != nil {
-		return Error(c, fiber.StatusInternalServerError, "failed to get spending summary")
-	}
-
-	return Success(c, summary)
-}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// ListUserRegionPrefs returns the user's saved preferences for every region
+// GET /api/users/:id/region-prefs
+func (h *Handler) ListUserRegionPrefs(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	if middleware.GetUserID(c) != id {
+		return Error(c, fiber.StatusForbidden, "cannot view another user's region preferences")
+	}
+
+	prefs, err := h.db.ListUserRegionPrefs(c.Context(), id)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get region preferences")
+	}
+
+	return Success(c, prefs)
+}
+
+// SaveUserRegionPref stores the user's preferred and comparison stores for a region
+// PUT /api/users/:id/region-prefs/:region_id
+func (h *Handler) SaveUserRegionPref(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	regionID, err := strconv.Atoi(c.Params("region_id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid region id")
+	}
+
+	if middleware.GetUserID(c) != id {
+		return Error(c, fiber.StatusForbidden, "cannot update another user's region preferences")
+	}
+
+	var req models.UpdateUserRegionPrefRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if _, err := h.db.GetRegionByID(c.Context(), regionID); err != nil {
+		if errors.Is(err, database.ErrRegionNotFound) {
+			return Error(c, fiber.StatusNotFound, "region not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get region")
+	}
+
+	req.PreferredStoreIDs = uniqueIDs(req.PreferredStoreIDs)
+	req.CompareStoreIDs = uniqueIDs(req.CompareStoreIDs)
+
+	if len(req.CompareStoreIDs) > 5 {
+		return Error(c, fiber.StatusBadRequest, "select at most 5 stores to compare")
+	}
+
+	// Every store must belong to the region the preferences are saved for
+	for _, ids := range [][]int{req.PreferredStoreIDs, req.CompareStoreIDs} {
+		if len(ids) == 0 {
+			continue
+		}
+		count, err := h.db.CountStoresInRegion(c.Context(), ids, regionID)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to validate stores")
+		}
+		if count != len(ids) {
+			return Error(c, fiber.StatusBadRequest, "all stores must be in the selected region")
+		}
+	}
+
+	pref, err := h.db.SaveUserRegionPref(c.Context(), id, regionID, &req)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to save region preferences")
+	}
+
+	return Success(c, pref)
+}
+
+// DeleteUserRegionPref removes the user's saved preferences for a region
+// DELETE /api/users/:id/region-prefs/:region_id
+func (h *Handler) DeleteUserRegionPref(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	regionID, err := strconv.Atoi(c.Params("region_id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid region id")
+	}
+
+	if middleware.GetUserID(c) != id {
+		return Error(c, fiber.StatusForbidden, "cannot update another user's region preferences")
+	}
+
+	if err := h.db.DeleteUserRegionPref(c.Context(), id, regionID); err != nil {
+		if errors.Is(err, database.ErrRegionPrefNotFound) {
+			return Error(c, fiber.StatusNotFound, "region preferences not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to delete region preferences")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "region preferences deleted successfully",
+	})
+}
+
+// resolveRegionPrefs picks the region for a comparison or plan request: the region_id query
+// parameter when given, otherwise the user's primary region. The user's saved preferences
+// for that region are returned when they exist.
+func (h *Handler) resolveRegionPrefs(c *fiber.Ctx, userID int) (*int, *models.UserRegionPref, error) {
+	var regionID *int
+	if param := c.Query("region_id"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			return nil, nil, errors.New("invalid region_id")
+		}
+		regionID = &id
+	} else if user, err := h.db.GetUserByID(c.Context(), userID); err == nil && user.RegionID != nil {
+		regionID = user.RegionID
+	}
+
+	if regionID == nil {
+		return nil, nil, nil
+	}
+
+	pref, err := h.db.GetUserRegionPref(c.Context(), userID, *regionID)
+	if err != nil {
+		// Missing or unreadable preferences fall back to the defaults
+		return regionID, nil, nil
+	}
+
+	return regionID, pref, nil
+}
+
+// uniqueIDs drops duplicate and non-positive IDs, keeping the original order
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := []int{}
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
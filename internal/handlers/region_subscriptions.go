@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// maxDigestCategories caps how many categories one subscription can filter on
+const maxDigestCategories = 20
+
+// ListRegionSubscriptions returns the user's region digest subscriptions
+// GET /api/me/region-subscriptions
+func (h *Handler) ListRegionSubscriptions(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	subs, err := h.db.ListRegionSubscriptions(c.Context(), userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list region subscriptions")
+	}
+
+	return Success(c, subs)
+}
+
+// GetRegionSubscription returns one of the user's region digest subscriptions
+// GET /api/me/region-subscriptions/:id
+func (h *Handler) GetRegionSubscription(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid subscription id")
+	}
+
+	sub, err := h.db.GetRegionSubscription(c.Context(), id, userID)
+	if err != nil {
+		return regionSubscriptionError(c, err, "failed to get region subscription")
+	}
+
+	return Success(c, sub)
+}
+
+// CreateRegionSubscription subscribes the user to a daily or weekly digest of price drops in a
+// region, optionally limited to some categories (tag slugs) and a minimum drop percentage
+// POST /api/me/region-subscriptions
+func (h *Handler) CreateRegionSubscription(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	var req models.CreateRegionSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if _, err := h.db.GetRegionByID(c.Context(), req.RegionID); err != nil {
+		if errors.Is(err, database.ErrRegionNotFound) {
+			return Error(c, fiber.StatusNotFound, "region not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get region")
+	}
+
+	if req.Frequency == "" {
+		req.Frequency = models.DigestFrequencyWeekly
+	}
+	req.Categories = normalizeDigestCategories(req.Categories)
+	if msg := h.validateRegionSubscription(c, req.Frequency, req.Categories, req.MinDropPercent); msg != "" {
+		return Error(c, fiber.StatusBadRequest, msg)
+	}
+
+	sub, err := h.db.CreateRegionSubscription(c.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, database.ErrRegionSubscriptionExists) {
+			return Error(c, fiber.StatusConflict, err.Error())
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to create region subscription")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+		Success: true,
+		Data:    sub,
+	})
+}
+
+// UpdateRegionSubscription changes a subscription's frequency, categories, minimum drop or active flag
+// PUT /api/me/region-subscriptions/:id
+func (h *Handler) UpdateRegionSubscription(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid subscription id")
+	}
+
+	var req models.UpdateRegionSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	current, err := h.db.GetRegionSubscription(c.Context(), id, userID)
+	if err != nil {
+		return regionSubscriptionError(c, err, "failed to get region subscription")
+	}
+
+	// Validate the subscription as it will be after the update
+	frequency, categories, minDrop := current.Frequency, current.Categories, current.MinDropPercent
+	if req.Frequency != nil {
+		frequency = *req.Frequency
+	}
+	if req.Categories != nil {
+		normalized := normalizeDigestCategories(*req.Categories)
+		req.Categories = &normalized
+		categories = normalized
+	}
+	if req.MinDropPercent != nil {
+		minDrop = *req.MinDropPercent
+	}
+	if msg := h.validateRegionSubscription(c, frequency, categories, minDrop); msg != "" {
+		return Error(c, fiber.StatusBadRequest, msg)
+	}
+
+	sub, err := h.db.UpdateRegionSubscription(c.Context(), id, userID, &req)
+	if err != nil {
+		return regionSubscriptionError(c, err, "failed to update region subscription")
+	}
+
+	return Success(c, sub)
+}
+
+// DeleteRegionSubscription unsubscribes the user from a region digest
+// DELETE /api/me/region-subscriptions/:id
+func (h *Handler) DeleteRegionSubscription(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid subscription id")
+	}
+
+	if err := h.db.DeleteRegionSubscription(c.Context(), id, userID); err != nil {
+		return regionSubscriptionError(c, err, "failed to delete region subscription")
+	}
+
+	return Success(c, fiber.Map{"message": "region subscription deleted"})
+}
+
+// validateRegionSubscription returns a message describing the first invalid preference, or ""
+func (h *Handler) validateRegionSubscription(c *fiber.Ctx, frequency string, categories []string, minDropPercent float64) string {
+	if frequency != models.DigestFrequencyDaily && frequency != models.DigestFrequencyWeekly {
+		return "frequency must be daily or weekly"
+	}
+	if minDropPercent < 0 || minDropPercent >= 100 {
+		return "min_drop_percent must be between 0 and 100"
+	}
+	if len(categories) > maxDigestCategories {
+		return "at most 20 categories can be selected"
+	}
+	if len(categories) > 0 {
+		unknown, err := h.db.FindUnknownTagSlugs(c.Context(), categories)
+		if err != nil {
+			return "failed to validate categories"
+		}
+		if len(unknown) > 0 {
+			return "unknown categories: " + strings.Join(unknown, ", ")
+		}
+	}
+	return ""
+}
+
+// normalizeDigestCategories lowercases and de-duplicates category slugs
+func normalizeDigestCategories(categories []string) []string {
+	seen := make(map[string]bool, len(categories))
+	normalized := []string{}
+	for _, category := range categories {
+		slug := strings.ToLower(strings.TrimSpace(category))
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		normalized = append(normalized, slug)
+	}
+	return normalized
+}
+
+// regionSubscriptionError maps repository errors to responses
+func regionSubscriptionError(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrRegionSubscriptionNotFound) {
+		return Error(c, fiber.StatusNotFound, "region subscription not found")
+	}
+	return Error(c, fiber.StatusInternalServerError, fallback)
+}
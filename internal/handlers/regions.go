@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -53,6 +54,46 @@ func (h *Handler) GetRegion(c *fiber.Ctx) error {
 	return Success(c, region)
 }
 
+// GetRegionContributors returns the most active contributors in a region
+// GET /api/regions/:id/contributors
+func (h *Handler) GetRegionContributors(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid region id")
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	if _, err := h.db.GetRegionByID(c.Context(), id); err != nil {
+		if errors.Is(err, database.ErrRegionNotFound) {
+			return Error(c, fiber.StatusNotFound, "region not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get region")
+	}
+
+	contributors, total, err := h.db.GetRegionContributors(c.Context(), id, limit, offset)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get region contributors")
+	}
+
+	visibility := h.contributorVisibility(c)
+	for _, rc := range contributors {
+		if visibility.ShouldMask(rc.UserID, rc.ContributorHidden) {
+			anonymous := models.AnonymousContributorName
+			rc.Username = &anonymous
+		}
+	}
+
+	return SuccessWithMeta(c, contributors, total, limit, offset)
+}
+
 // CreateRegion creates a new region (admin only)
 func (h *Handler) CreateRegion(c *fiber.Ctx) error {
 	var req models.CreateRegionRequest
@@ -76,6 +117,26 @@ func (h *Handler) CreateRegion(c *fiber.Ctx) error {
 		req.ZipCodes = []string{}
 	}
 
+	// Currency and locale follow the country unless the admin sets them explicitly
+	if req.Country == "" {
+		req.Country = models.DefaultCountry
+	}
+	req.Country = strings.ToUpper(req.Country)
+	locale, _ := models.LocaleForCountry(req.Country)
+	if req.Currency == "" {
+		req.Currency = locale.Currency
+	}
+	if req.Locale == "" {
+		req.Locale = locale.Locale
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+	if msg := validateRegionLocale(&req.Country, &req.Currency, &req.Locale); msg != "" {
+		return Error(c, fiber.StatusBadRequest, msg)
+	}
+	if req.CostOfLivingIndex != nil && *req.CostOfLivingIndex <= 0 {
+		return Error(c, fiber.StatusBadRequest, "cost_of_living_index must be positive")
+	}
+
 	region, err := h.db.CreateRegion(c.Context(), &req)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to create region")
@@ -104,6 +165,29 @@ func (h *Handler) UpdateRegion(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "state must be a 2-letter code")
 	}
 
+	// A new country brings its default currency and locale unless they are overridden too
+	if req.Country != nil {
+		country := strings.ToUpper(*req.Country)
+		req.Country = &country
+		locale, _ := models.LocaleForCountry(country)
+		if req.Currency == nil {
+			req.Currency = &locale.Currency
+		}
+		if req.Locale == nil {
+			req.Locale = &locale.Locale
+		}
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		req.Currency = &currency
+	}
+	if msg := validateRegionLocale(req.Country, req.Currency, req.Locale); msg != "" {
+		return Error(c, fiber.StatusBadRequest, msg)
+	}
+	if req.CostOfLivingIndex != nil && *req.CostOfLivingIndex <= 0 {
+		return Error(c, fiber.StatusBadRequest, "cost_of_living_index must be positive")
+	}
+
 	region, err := h.db.UpdateRegion(c.Context(), id, &req)
 	if err != nil {
 		if errors.Is(err, database.ErrRegionNotFound) {
@@ -115,6 +199,31 @@ func (h *Handler) UpdateRegion(c *fiber.Ctx) error {
 	return Success(c, region)
 }
 
+// validateRegionLocale checks the provided country, currency and locale codes of a region,
+// returning an error message or "" when they are valid. Nil values are skipped.
+func validateRegionLocale(country, currency, locale *string) string {
+	if country != nil && (len(*country) != 2 || !isASCIILetters(*country)) {
+		return "country must be a 2-letter code"
+	}
+	if currency != nil && (len(*currency) != 3 || !isASCIILetters(*currency)) {
+		return "currency must be a 3-letter code"
+	}
+	if locale != nil && (*locale == "" || len(*locale) > 10) {
+		return "locale must be a language tag such as en-US"
+	}
+	return ""
+}
+
+// isASCIILetters reports whether s consists only of ASCII letters
+func isASCIILetters(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
 // DeleteRegion deletes a region (admin only)
 func (h *Handler) DeleteRegion(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -122,17 +231,49 @@ func (h *Handler) DeleteRegion(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid region id")
 	}
 
-	if err := h.db.DeleteRegion(c.Context(), id); err != nil {
+	// Optional region to move the deleted region's stores and users to
+	var reassignTo *int
+	if raw := c.Query("reassign_to"); raw != "" {
+		target, err := strconv.Atoi(raw)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid reassign_to region id")
+		}
+		if target == id {
+			return Error(c, fiber.StatusBadRequest, "cannot reassign to the region being deleted")
+		}
+		reassignTo = &target
+	}
+	force := c.QueryBool("force", false)
+
+	refs, err := h.db.GetRegionReferences(c.Context(), id)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to check region references")
+	}
+
+	// Referenced regions need force plus a target, unless clearing references is allowed
+	if refs.Total() > 0 {
+		allowUnassign := h.db.GetSettingBool(c.Context(), "region_delete_allow_unassign", false, h.getEncryptionKey())
+		if !force || (reassignTo == nil && !allowUnassign) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success":    false,
+				"error":      "region is still in use; pass force=true and reassign_to=<region id> to move its stores and users",
+				"references": refs,
+			})
+		}
+	}
+
+	result, err := h.db.DeleteRegion(c.Context(), id, reassignTo)
+	if err != nil {
 		if errors.Is(err, database.ErrRegionNotFound) {
 			return Error(c, fiber.StatusNotFound, "region not found")
 		}
+		if errors.Is(err, database.ErrReassignTarget) {
+			return Error(c, fiber.StatusBadRequest, "reassign_to region not found")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to delete region")
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "region deleted successfully",
-	})
+	return Success(c, result)
 }
 
 // GetRegionStates returns list of distinct states
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/middleware"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// recordSearch logs an anonymized search event when search tracking is enabled.
+// The signed-in user's region is kept, never the user. Failures are ignored.
+func (h *Handler) recordSearch(c *fiber.Ctx, kind, query string, resultCount int) {
+	if !h.db.GetSettingBool(c.Context(), "search_tracking_enabled", true, h.getEncryptionKey()) {
+		return
+	}
+
+	var regionID *int
+	if uid := middleware.GetUserID(c); uid != 0 {
+		if user, err := h.db.GetUserByID(c.Context(), uid); err == nil {
+			regionID = user.RegionID
+		}
+	}
+
+	_ = h.db.RecordSearchEvent(c.Context(), kind, query, regionID, resultCount)
+}
+
+// GetTrendingSearches returns the most frequent recent item and store searches
+// GET /api/search/trending?region_id=&kind=item|store&days=7&limit=10
+func (h *Handler) GetTrendingSearches(c *fiber.Ctx) error {
+	params := &models.TrendingSearchParams{
+		Kind:  c.Query("kind"),
+		Days:  c.QueryInt("days", 7),
+		Limit: c.QueryInt("limit", 10),
+		// Queries searched only once could identify a single user
+		MinSearches: 2,
+	}
+
+	if params.Kind != "" && params.Kind != models.SearchKindItem && params.Kind != models.SearchKindStore {
+		return Error(c, fiber.StatusBadRequest, "kind must be item or store")
+	}
+	if params.Days < 1 || params.Days > 90 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 1 and 90")
+	}
+	if params.Limit < 1 || params.Limit > 50 {
+		params.Limit = 10
+	}
+
+	if raw := c.Query("region_id"); raw != "" {
+		regionID, err := strconv.Atoi(raw)
+		if err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid region_id")
+		}
+		params.RegionID = &regionID
+	}
+
+	trending, err := h.db.GetTrendingSearches(c.Context(), params)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get trending searches")
+	}
+
+	return Success(c, trending)
+}
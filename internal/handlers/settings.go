@@ -106,6 +106,35 @@ func (h *SettingsHandler) UpdateSettings(c *fiber.Ctx) error {
 	})
 }
 
+// GetEffectiveConfig returns the resolved configuration from the environment and the database
+// settings, with the source of each value and sensitive values masked. Email and storage are
+// configured through database settings, so their environment variables are informational.
+// GET /api/admin/config/effective
+func (h *SettingsHandler) GetEffectiveConfig(c *fiber.Ctx) error {
+	var entries []database.EffectiveSetting
+	for _, v := range h.cfg.Values() {
+		source := database.SettingSourceDefault
+		if v.FromEnv {
+			source = database.SettingSourceEnv
+		}
+		entries = append(entries, database.EffectiveSetting{
+			Key:         v.Key,
+			Value:       database.MaskSettingValue(v.Value, v.Sensitive),
+			Category:    "environment",
+			Source:      source,
+			IsSensitive: v.Sensitive,
+		})
+	}
+
+	settings, err := h.db.GetEffectiveSettings(c.Context(), h.encryptionKey)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get settings: "+err.Error())
+	}
+	entries = append(entries, settings...)
+
+	return Success(c, entries)
+}
+
 // GetEmailConfig returns the current email configuration
 func (h *SettingsHandler) GetEmailConfig(c *fiber.Ctx) error {
 	config := h.emailService.GetConfig()
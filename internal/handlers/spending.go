@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// spendingCSVHeader is the column layout of the CSV export; keep stable for downstream parsers
+var spendingCSVHeader = []string{"month", "store_id", "store_name", "source", "total", "transaction_count"}
+
+// GetSpendingSummary returns monthly spending for the user as JSON or CSV (format=csv)
+// GET /api/spending-summary?months=6&format=csv
+func (h *Handler) GetSpendingSummary(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Number of months to include (default 6), clamped to 1-24
+	months := c.QueryInt("months", 6)
+	if months < 1 {
+		months = 1
+	}
+	if months > 24 {
+		months = 24
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return Error(c, fiber.StatusBadRequest, "format must be json or csv")
+	}
+
+	denormalized := h.db.GetSettingBool(c.Context(), "item_best_price_denormalized", true, h.getEncryptionKey())
+	summary, err := h.db.GetSpendingSummary(c.Context(), userID, months, denormalized)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get spending summary")
+	}
+
+	if format == "csv" {
+		return writeSpendingCSV(c, summary)
+	}
+
+	return Success(c, summary)
+}
+
+// writeSpendingCSV streams one row per month/store/source to the response
+func writeSpendingCSV(c *fiber.Ctx, summary *models.SpendingSummary) error {
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="spending-summary.csv"`)
+
+	w := csv.NewWriter(c)
+	if err := w.Write(spendingCSVHeader); err != nil {
+		return err
+	}
+
+	for _, month := range summary.Months {
+		for _, store := range month.Stores {
+			record := []string{
+				month.Month,
+				strconv.Itoa(store.StoreID),
+				store.StoreName,
+				store.Source,
+				fmt.Sprintf("%.2f", store.Total),
+				strconv.Itoa(store.TransactionCount),
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// assignRegionsPageSize is how many issues AdminAssignStoreRegions reads per query
+const assignRegionsPageSize = 500
+
+// AdminListStoreDataIssues returns stores missing coordinates or a region, or whose region
+// does not match their address, as a prioritized cleanup queue with a suggested fix per row
+// GET /api/admin/stores/data-issues?issue=missing_region|missing_coordinates|region_mismatch
+func (h *Handler) AdminListStoreDataIssues(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	offset := c.QueryInt("offset", 0)
+
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	issue := c.Query("issue")
+	switch issue {
+	case "", models.StoreIssueMissingRegion, models.StoreIssueMissingCoordinates, models.StoreIssueRegionMismatch:
+	default:
+		return Error(c, fiber.StatusBadRequest, "issue must be missing_region, missing_coordinates or region_mismatch")
+	}
+
+	issues, total, err := h.db.ListStoreDataIssues(c.Context(), issue, limit, offset)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to list store data issues")
+	}
+
+	return SuccessWithMeta(c, issues, total, limit, offset)
+}
+
+// AdminAssignStoreRegions applies the suggested region to stores that are missing one or
+// whose region does not match their address. Stores without a suggestion are left for review.
+// POST /api/admin/stores/assign-regions
+func (h *Handler) AdminAssignStoreRegions(c *fiber.Ctx) error {
+	var req models.AssignStoreRegionsRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return Error(c, fiber.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	only := make(map[int]bool, len(req.StoreIDs))
+	for _, id := range req.StoreIDs {
+		only[id] = true
+	}
+
+	result := &models.AssignStoreRegionsResult{DryRun: req.DryRun, Assignments: []models.StoreRegionAssignment{}}
+	for _, issue := range []string{models.StoreIssueMissingRegion, models.StoreIssueRegionMismatch} {
+		for offset := 0; ; offset += assignRegionsPageSize {
+			page, total, err := h.db.ListStoreDataIssues(c.Context(), issue, assignRegionsPageSize, offset)
+			if err != nil {
+				return Error(c, fiber.StatusInternalServerError, "failed to list store data issues")
+			}
+			for _, i := range page {
+				if i.SuggestedFix != models.StoreFixAssignRegion || (len(only) > 0 && !only[i.StoreID]) {
+					continue
+				}
+				result.Assignments = append(result.Assignments, models.StoreRegionAssignment{
+					StoreID:      i.StoreID,
+					FromRegionID: i.RegionID,
+					ToRegionID:   *i.SuggestedRegionID,
+					Source:       *i.SuggestionSource,
+				})
+			}
+			if offset+assignRegionsPageSize >= total {
+				break
+			}
+		}
+	}
+
+	if !req.DryRun && len(result.Assignments) > 0 {
+		if err := h.db.AssignStoreRegions(c.Context(), result.Assignments); err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to assign store regions")
+		}
+		result.Assigned = len(result.Assignments)
+	}
+
+	return Success(c, result)
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"errors"
+	"math"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -73,8 +76,8 @@ func (h *Handler) GetStore(c *fiber.Ctx) error {
 // CreateStore creates a new store (admin only)
 func (h *Handler) CreateStore(c *fiber.Ctx) error {
 	var req models.CreateStoreRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
@@ -105,14 +108,20 @@ func (h *Handler) CreateStore(c *fiber.Ctx) error {
 		}
 	}
 
+	check, blocked := h.checkAddress(c, req.Latitude, req.Longitude, req.State, req.ZipCode)
+	if blocked {
+		return addressMismatch(c, check)
+	}
+
 	store, err := h.db.CreateStore(c.Context(), &req, createdBy)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to create store")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(APIResponse{
-		Success: true,
-		Data:    store,
+		Success:  true,
+		Data:     store,
+		Warnings: addressWarnings(check),
 	})
 }
 
@@ -124,8 +133,8 @@ func (h *Handler) UpdateStore(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateStoreRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate state if provided
@@ -133,7 +142,22 @@ func (h *Handler) UpdateStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "state must be a 2-letter code")
 	}
 
-	store, err := h.db.UpdateStore(c.Context(), id, &req)
+	var check *models.AddressCheck
+	if storeLocationChanged(&req) {
+		existing, err := h.db.GetStoreByID(c.Context(), id)
+		if err != nil {
+			if errors.Is(err, database.ErrStoreNotFound) {
+				return Error(c, fiber.StatusNotFound, "store not found")
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get store")
+		}
+		var blocked bool
+		if check, blocked = h.checkStoreUpdateAddress(c, &existing.Store, &req); blocked {
+			return addressMismatch(c, check)
+		}
+	}
+
+	store, err := h.db.UpdateStore(c.Context(), id, nil, &req)
 	if err != nil {
 		if errors.Is(err, database.ErrStoreNotFound) {
 			return Error(c, fiber.StatusNotFound, "store not found")
@@ -141,7 +165,11 @@ func (h *Handler) UpdateStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to update store")
 	}
 
-	return Success(c, store)
+	return c.JSON(APIResponse{
+		Success:  true,
+		Data:     store,
+		Warnings: addressWarnings(check),
+	})
 }
 
 // DeleteStore deletes a store (admin only)
@@ -151,7 +179,7 @@ func (h *Handler) DeleteStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid store id")
 	}
 
-	if err := h.db.DeleteStore(c.Context(), id); err != nil {
+	if err := h.db.DeleteStore(c.Context(), id, nil); err != nil {
 		if errors.Is(err, database.ErrStoreNotFound) {
 			return Error(c, fiber.StatusNotFound, "store not found")
 		}
@@ -171,7 +199,9 @@ func (h *Handler) VerifyStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "invalid store id")
 	}
 
-	if err := h.db.VerifyStore(c.Context(), id); err != nil {
+	creatorPoints := h.db.GetSettingInt(c.Context(), "reputation_store_verified_points", 5, h.getEncryptionKey())
+
+	if err := h.db.VerifyStore(c.Context(), id, creatorPoints); err != nil {
 		if errors.Is(err, database.ErrStoreNotFound) {
 			return Error(c, fiber.StatusNotFound, "store not found")
 		}
@@ -187,8 +217,8 @@ func (h *Handler) VerifyStore(c *fiber.Ctx) error {
 // UserCreateStore allows authenticated users to add stores they discover
 func (h *Handler) UserCreateStore(c *fiber.Ctx) error {
 	var req models.CreateStoreRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Validate required fields
@@ -217,14 +247,20 @@ func (h *Handler) UserCreateStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusUnauthorized, "unauthorized")
 	}
 
+	check, blocked := h.checkAddress(c, req.Latitude, req.Longitude, req.State, req.ZipCode)
+	if blocked {
+		return addressMismatch(c, check)
+	}
+
 	store, err := h.db.CreateStore(c.Context(), &req, &userID)
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to create store")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(APIResponse{
-		Success: true,
-		Data:    store,
+		Success:  true,
+		Data:     store,
+		Warnings: addressWarnings(check),
 	})
 }
 
@@ -250,14 +286,21 @@ func (h *Handler) UserUpdateStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to get store")
 	}
 
-	// Verify user owns this store
-	if store.CreatedBy == nil || *store.CreatedBy != userID {
-		return Error(c, fiber.StatusForbidden, "cannot update others' stores")
+	isAdmin := middleware.GetUserRole(c) == models.RoleAdmin
+	if !isAdmin {
+		if code, msg := h.authorizeStoreChange(c, &store.Store, userID, "update"); code != 0 {
+			return Error(c, code, msg)
+		}
 	}
 
 	var req models.UpdateStoreRequest
-	if err := c.BodyParser(&req); err != nil {
-		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	if err := h.parseBody(c, &req); err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Verification is an admin decision, not something owners can grant themselves
+	if !isAdmin {
+		req.Verified = nil
 	}
 
 	// Validate state if provided
@@ -265,15 +308,27 @@ func (h *Handler) UserUpdateStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusBadRequest, "state must be a 2-letter code")
 	}
 
-	updatedStore, err := h.db.UpdateStore(c.Context(), id, &req)
+	check, blocked := h.checkStoreUpdateAddress(c, &store.Store, &req)
+	if blocked {
+		return addressMismatch(c, check)
+	}
+
+	updatedStore, err := h.db.UpdateStore(c.Context(), id, storeOwnerFilter(isAdmin, userID), &req)
 	if err != nil {
 		if errors.Is(err, database.ErrStoreNotFound) {
 			return Error(c, fiber.StatusNotFound, "store not found")
 		}
+		if errors.Is(err, database.ErrNotStoreOwner) {
+			return Error(c, fiber.StatusForbidden, "cannot update others' stores")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to update store")
 	}
 
-	return Success(c, updatedStore)
+	return c.JSON(APIResponse{
+		Success:  true,
+		Data:     updatedStore,
+		Warnings: addressWarnings(check),
+	})
 }
 
 // UserDeleteStore allows users to delete their own stores
@@ -298,15 +353,31 @@ func (h *Handler) UserDeleteStore(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to get store")
 	}
 
-	// Verify user owns this store
-	if store.CreatedBy == nil || *store.CreatedBy != userID {
-		return Error(c, fiber.StatusForbidden, "cannot delete others' stores")
+	isAdmin := middleware.GetUserRole(c) == models.RoleAdmin
+	if !isAdmin {
+		if code, msg := h.authorizeStoreChange(c, &store.Store, userID, "delete"); code != 0 {
+			return Error(c, code, msg)
+		}
+
+		// A community store other people have priced stays put; ask an admin to remove it
+		if !store.IsPrivate {
+			others, err := h.db.CountOtherContributorPrices(c.Context(), id, userID)
+			if err != nil {
+				return Error(c, fiber.StatusInternalServerError, "failed to check store prices")
+			}
+			if others > 0 {
+				return Error(c, fiber.StatusConflict, "store has prices from other users; ask an admin to remove it")
+			}
+		}
 	}
 
-	if err := h.db.DeleteStore(c.Context(), id); err != nil {
+	if err := h.db.DeleteStore(c.Context(), id, storeOwnerFilter(isAdmin, userID)); err != nil {
 		if errors.Is(err, database.ErrStoreNotFound) {
 			return Error(c, fiber.StatusNotFound, "store not found")
 		}
+		if errors.Is(err, database.ErrNotStoreOwner) {
+			return Error(c, fiber.StatusForbidden, "cannot delete others' stores")
+		}
 		return Error(c, fiber.StatusInternalServerError, "failed to delete store")
 	}
 
@@ -316,6 +387,39 @@ func (h *Handler) UserDeleteStore(c *fiber.Ctx) error {
 	})
 }
 
+// authorizeStoreChange checks whether a regular user may update or delete a store. Another
+// user's private store is reported as missing, a community store they didn't create is
+// forbidden, and their own community stores are editable only while store_owner_edit_public
+// is enabled. A zero code means the change is allowed.
+func (h *Handler) authorizeStoreChange(c *fiber.Ctx, store *models.Store, userID int, action string) (int, string) {
+	ownerEditPublic := store.IsPrivate || h.db.GetSettingBool(c.Context(), "store_owner_edit_public", true, h.getEncryptionKey())
+	return storeChangeAccess(store, userID, action, ownerEditPublic)
+}
+
+// storeChangeAccess applies the authorizeStoreChange rules, with ownerEditPublic holding the
+// store_owner_edit_public setting
+func storeChangeAccess(store *models.Store, userID int, action string, ownerEditPublic bool) (int, string) {
+	owned := store.CreatedBy != nil && *store.CreatedBy == userID
+	if !owned {
+		if store.IsPrivate {
+			return fiber.StatusNotFound, "store not found"
+		}
+		return fiber.StatusForbidden, "cannot " + action + " others' stores"
+	}
+	if !store.IsPrivate && !ownerEditPublic {
+		return fiber.StatusForbidden, "community stores can only be changed by an admin"
+	}
+	return 0, ""
+}
+
+// storeOwnerFilter limits a store write to the user's own stores unless they are an admin
+func storeOwnerFilter(isAdmin bool, userID int) *int {
+	if isAdmin {
+		return nil
+	}
+	return &userID
+}
+
 // GetStoreStats returns aggregate store statistics
 func (h *Handler) GetStoreStats(c *fiber.Ctx) error {
 	stats, err := h.db.GetStoreStats(c.Context())
@@ -349,5 +453,449 @@ func (h *Handler) SearchStores(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to search stores")
 	}
 
+	h.recordSearch(c, models.SearchKindStore, query, len(stores))
+
+	return Success(c, stores)
+}
+
+// GetNearbyStores returns public stores near a location, filtered by store type.
+// Without a store_type param the signed-in user's preferred store types apply.
+// GET /api/stores/nearby
+func (h *Handler) GetNearbyStores(c *fiber.Ctx) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return Error(c, fiber.StatusBadRequest, "lat and lng are required")
+	}
+	if lat < -90 || lat > 90 {
+		return Error(c, fiber.StatusBadRequest, "latitude must be between -90 and 90")
+	}
+	if lng < -180 || lng > 180 {
+		return Error(c, fiber.StatusBadRequest, "longitude must be between -180 and 180")
+	}
+
+	radiusKm, err := strconv.ParseFloat(c.Query("radius_km", "10"), 64)
+	if err != nil || radiusKm <= 0 {
+		return Error(c, fiber.StatusBadRequest, "radius_km must be a positive number")
+	}
+	if radiusKm > 50 {
+		radiusKm = 50
+	}
+
+	var storeTypes []string
+	if raw := c.Query("store_type"); raw != "" {
+		var invalid string
+		storeTypes, invalid = models.NormalizeStoreTypes(strings.Split(raw, ","))
+		if invalid != "" {
+			return Error(c, fiber.StatusBadRequest, "invalid store type: "+invalid)
+		}
+	} else if uid := middleware.GetUserID(c); uid != 0 {
+		if user, err := h.db.GetUserByID(c.Context(), uid); err == nil {
+			storeTypes = user.PreferredStoreTypes
+		}
+	}
+
+	unit, err := h.distanceUnit(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	stores, err := h.db.FindNearbyStores(c.Context(), lat, lng, radiusKm, c.QueryInt("limit", 20), storeTypes)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to find nearby stores")
+	}
+
+	for _, s := range stores {
+		s.Distance = unit.FromKm(s.DistanceKm)
+		s.DistanceUnit = unit
+	}
+
 	return Success(c, stores)
 }
+
+// BulkAdjustStorePrices raises or lowers many of a store's prices at once, optionally as a preview
+// POST /api/stores/:id/prices/bulk-adjust
+func (h *Handler) BulkAdjustStorePrices(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid store id")
+	}
+
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	store, err := h.db.GetStoreByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get store")
+	}
+
+	// Only the store's creator or an admin may adjust its prices in bulk
+	if (store.CreatedBy == nil || *store.CreatedBy != userID) && middleware.GetUserRole(c) != models.RoleAdmin {
+		return Error(c, fiber.StatusForbidden, "cannot adjust prices for others' stores")
+	}
+
+	var req models.BulkPriceAdjustRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if (req.Percent == nil) == (req.Amount == nil) {
+		return Error(c, fiber.StatusBadRequest, "provide either percent or amount")
+	}
+	if req.Percent != nil && (*req.Percent <= -100 || *req.Percent == 0) {
+		return Error(c, fiber.StatusBadRequest, "percent must be non-zero and greater than -100")
+	}
+	if req.Amount != nil && *req.Amount == 0 {
+		return Error(c, fiber.StatusBadRequest, "amount must be non-zero")
+	}
+	if len(req.ItemIDs) > 1000 {
+		return Error(c, fiber.StatusBadRequest, "at most 1000 items can be adjusted at once")
+	}
+
+	encryptionKey := h.getEncryptionKey()
+	bounds := models.BulkPriceBounds{
+		MaxChangePercent: h.db.GetSettingFloat(c.Context(), "price_bulk_max_change_percent", 50, encryptionKey),
+		OutlierFactor:    h.db.GetSettingFloat(c.Context(), "price_outlier_factor", 3, encryptionKey),
+	}
+
+	result, err := h.db.BulkAdjustStorePrices(c.Context(), id, userID, &req, bounds)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to adjust prices")
+	}
+
+	return Success(c, result)
+}
+
+// distanceUnit returns the unit to report distances in: the unit query parameter ("km" or "mi"),
+// otherwise the signed-in user's unit_system preference, otherwise km
+func (h *Handler) distanceUnit(c *fiber.Ctx) (models.DistanceUnit, error) {
+	if raw := c.Query("unit"); raw != "" {
+		unit, ok := models.ParseDistanceUnit(strings.ToLower(raw))
+		if !ok {
+			return "", errors.New("unit must be km or mi")
+		}
+		return unit, nil
+	}
+
+	if uid := middleware.GetUserID(c); uid != 0 {
+		if user, err := h.db.GetUserByID(c.Context(), uid); err == nil {
+			return models.DistanceUnitFor(user.UnitSystem), nil
+		}
+	}
+
+	return models.DistanceUnitKm, nil
+}
+
+// storeQualityFullContributors is the contributor count that earns the full diversity score
+const storeQualityFullContributors = 5
+
+// GetStoreQuality returns data-quality metrics and a grade for a store's shared prices
+// GET /api/stores/:id/quality
+func (h *Handler) GetStoreQuality(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid store id")
+	}
+
+	store, err := h.db.GetStoreByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get store")
+	}
+	if store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != middleware.GetUserID(c)) {
+		return Error(c, fiber.StatusNotFound, "store not found")
+	}
+
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, h.getEncryptionKey())
+	if staleDays < 1 {
+		staleDays = 30
+	}
+
+	quality, err := h.db.GetStoreQuality(c.Context(), id, staleDays)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get store quality")
+	}
+
+	gradeStoreQuality(quality)
+	return Success(c, quality)
+}
+
+// GetStorePriceVolatility lists the items whose prices at a store changed most often and by
+// the most over the last days (default 28), paginated from most to least volatile
+// GET /api/stores/:id/volatility?days=28
+func (h *Handler) GetStorePriceVolatility(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid store id")
+	}
+
+	store, err := h.db.GetStoreByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get store")
+	}
+	if store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != middleware.GetUserID(c)) {
+		return Error(c, fiber.StatusNotFound, "store not found")
+	}
+
+	days := c.QueryInt("days", 28)
+	if days < 7 || days > 365 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 7 and 365")
+	}
+
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := h.db.GetStorePriceVolatility(c.Context(), id, days, limit, offset)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get price volatility")
+	}
+
+	return SuccessWithMeta(c, items, total, limit, offset)
+}
+
+// Typical basket used for store coverage
+const (
+	basketMinPurchases = 2  // Times an item must have been bought to count as typical
+	basketMaxItems     = 50 // Most frequent items that make up the basket
+)
+
+// GetMyStoreCoverage reports how much of the user's typical basket, the items they bought most
+// often over the last days (default 180), a store carries and what it would cost there
+// GET /api/stores/:id/my-coverage
+func (h *Handler) GetMyStoreCoverage(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return Error(c, fiber.StatusUnauthorized, "authentication required")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid store id")
+	}
+
+	store, err := h.db.GetStoreByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrStoreNotFound) {
+			return Error(c, fiber.StatusNotFound, "store not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get store")
+	}
+	if store.IsPrivate && (store.CreatedBy == nil || *store.CreatedBy != userID) {
+		return Error(c, fiber.StatusNotFound, "store not found")
+	}
+
+	days := c.QueryInt("days", 180)
+	if days < 30 || days > 730 {
+		return Error(c, fiber.StatusBadRequest, "days must be between 30 and 730")
+	}
+
+	basket, err := h.db.GetFrequentItems(c.Context(), userID, days, basketMinPurchases, basketMaxItems)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get frequent items")
+	}
+
+	coverage, err := h.db.GetStoreBasketCoverage(c.Context(), store.ID, store.Name, userID, basket)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get store coverage")
+	}
+	coverage.WindowDays = days
+
+	return Success(c, coverage)
+}
+
+// gradeStoreQuality scores a store from its verified share, fresh share and contributor diversity
+func gradeStoreQuality(q *models.StoreQuality) {
+	q.VerifiedPercent = math.Round(q.VerifiedPercent*10) / 10
+	q.StalePercent = math.Round(q.StalePercent*10) / 10
+	q.AvgAgeDays = math.Round(q.AvgAgeDays*10) / 10
+
+	if q.TotalPrices == 0 {
+		q.Grade = "N/A"
+		return
+	}
+
+	diversity := math.Min(float64(q.ContributorCount)/storeQualityFullContributors, 1) * 100
+	q.Score = math.Round(0.4*q.VerifiedPercent + 0.4*(100-q.StalePercent) + 0.2*diversity)
+
+	switch {
+	case q.Score >= 80:
+		q.Grade = "A"
+	case q.Score >= 65:
+		q.Grade = "B"
+	case q.Score >= 50:
+		q.Grade = "C"
+	case q.Score >= 35:
+		q.Grade = "D"
+	default:
+		q.Grade = "F"
+	}
+}
+
+// FindStoresCarrying ranks stores by how many of the given items they carry and their basket total
+// POST /api/stores/carrying
+func (h *Handler) FindStoresCarrying(c *fiber.Ctx) error {
+	var req models.StoresCarryingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	// Dedupe item IDs so coverage counts each item once
+	seen := make(map[int]bool, len(req.ItemIDs))
+	itemIDs := make([]int, 0, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		if !seen[id] {
+			seen[id] = true
+			itemIDs = append(itemIDs, id)
+		}
+	}
+	req.ItemIDs = itemIDs
+
+	if len(req.ItemIDs) == 0 {
+		return Error(c, fiber.StatusBadRequest, "item_ids is required")
+	}
+	if len(req.ItemIDs) > 100 {
+		return Error(c, fiber.StatusBadRequest, "at most 100 items can be searched at once")
+	}
+
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return Error(c, fiber.StatusBadRequest, "latitude and longitude must be provided together")
+	}
+	if req.Latitude != nil && (*req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180) {
+		return Error(c, fiber.StatusBadRequest, "invalid coordinates")
+	}
+	if req.RadiusKm != nil && (req.Latitude == nil || *req.RadiusKm <= 0) {
+		return Error(c, fiber.StatusBadRequest, "radius_km requires coordinates and must be positive")
+	}
+	if req.MaxAgeDays != nil && *req.MaxAgeDays < 1 {
+		return Error(c, fiber.StatusBadRequest, "max_age_days must be at least 1")
+	}
+
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	unit, err := h.distanceUnit(c)
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	stores, total, err := h.db.FindStoresCarrying(c.Context(), &req, middleware.GetUserID(c))
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to find stores")
+	}
+
+	for _, s := range stores {
+		if s.DistanceKm != nil {
+			distance := unit.FromKm(*s.DistanceKm)
+			s.Distance = &distance
+			s.DistanceUnit = unit
+		}
+	}
+
+	return SuccessWithMeta(c, stores, total, req.Limit, req.Offset)
+}
+
+// GetChainParity shows how much an item's price varies across a chain's locations
+// GET /api/chains/:name/parity?item_id=
+func (h *Handler) GetChainParity(c *fiber.Ctx) error {
+	chain, err := url.PathUnescape(c.Params("name"))
+	if err != nil || strings.TrimSpace(chain) == "" {
+		return Error(c, fiber.StatusBadRequest, "invalid chain name")
+	}
+
+	itemID, err := strconv.Atoi(c.Query("item_id"))
+	if err != nil || itemID <= 0 {
+		return Error(c, fiber.StatusBadRequest, "item_id is required")
+	}
+
+	staleDays := h.db.GetSettingInt(c.Context(), "price_stale_days", 30, h.getEncryptionKey())
+	if staleDays < 1 {
+		staleDays = 30
+	}
+
+	parity, err := h.db.GetChainParity(c.Context(), strings.TrimSpace(chain), itemID, staleDays)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return Error(c, fiber.StatusNotFound, "item not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get chain parity")
+	}
+
+	return Success(c, parity)
+}
+
+// checkAddress compares coordinates with the supplied state and zip code (address_validation_mode).
+// blocked is true when the mode is "block" and they disagree.
+func (h *Handler) checkAddress(c *fiber.Ctx, lat, lng *float64, state, zipCode string) (*models.AddressCheck, bool) {
+	check := h.addressCheck.Check(c.Context(), lat, lng, state, zipCode)
+	if check == nil || len(check.Discrepancies) == 0 {
+		return check, false
+	}
+	return check, check.Mode == models.AddressValidationBlock
+}
+
+// storeLocationChanged reports whether an update touches the store's coordinates or address
+func storeLocationChanged(req *models.UpdateStoreRequest) bool {
+	return req.Latitude != nil || req.Longitude != nil || req.State != nil || req.ZipCode != nil
+}
+
+// checkStoreUpdateAddress validates a store update against the store's current location,
+// so changing only the coordinates or only the address is checked too
+func (h *Handler) checkStoreUpdateAddress(c *fiber.Ctx, existing *models.Store, req *models.UpdateStoreRequest) (*models.AddressCheck, bool) {
+	if !storeLocationChanged(req) {
+		return nil, false
+	}
+
+	lat, lng := existing.Latitude, existing.Longitude
+	if req.Latitude != nil {
+		lat = req.Latitude
+	}
+	if req.Longitude != nil {
+		lng = req.Longitude
+	}
+	state, zipCode := existing.State, existing.ZipCode
+	if req.State != nil {
+		state = *req.State
+	}
+	if req.ZipCode != nil {
+		zipCode = *req.ZipCode
+	}
+
+	return h.checkAddress(c, lat, lng, state, zipCode)
+}
+
+// addressMismatch rejects a request whose coordinates disagree with its address
+func addressMismatch(c *fiber.Ctx, check *models.AddressCheck) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"success":       false,
+		"error":         "coordinates do not match the address",
+		"address_check": check,
+	})
+}
+
+// addressWarnings returns the address check for the response warnings, or nil when it found nothing
+func addressWarnings(check *models.AddressCheck) interface{} {
+	if check == nil || len(check.Discrepancies) == 0 {
+		return nil
+	}
+	return fiber.Map{"address_check": check}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestStoreChangeAccess(t *testing.T) {
+	owner, other := 1, 2
+
+	tests := []struct {
+		name            string
+		store           models.Store
+		user            int
+		ownerEditPublic bool
+		want            int
+	}{
+		{"own private store", models.Store{IsPrivate: true, CreatedBy: &owner}, owner, false, 0},
+		{"own community store", models.Store{CreatedBy: &owner}, owner, true, 0},
+		{"own community store with owner edits off", models.Store{CreatedBy: &owner}, owner, false, fiber.StatusForbidden},
+		{"another user's private store", models.Store{IsPrivate: true, CreatedBy: &owner}, other, true, fiber.StatusNotFound},
+		{"another user's community store", models.Store{CreatedBy: &owner}, other, true, fiber.StatusForbidden},
+		{"community store without creator", models.Store{}, other, true, fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, msg := storeChangeAccess(&tt.store, tt.user, "update", tt.ownerEditPublic); got != tt.want {
+				t.Errorf("storeChangeAccess() = %d %q, want %d", got, msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreOwnerFilter(t *testing.T) {
+	if got := storeOwnerFilter(true, 7); got != nil {
+		t.Errorf("admin filter = %v, want nil", *got)
+	}
+	if got := storeOwnerFilter(false, 7); got == nil || *got != 7 {
+		t.Errorf("user filter = %v, want 7", got)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -58,9 +59,39 @@ func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// Validate username if provided
-	if req.Username != nil {
-		if len(*req.Username) < 3 || len(*req.Username) > 50 {
-			return Error(c, fiber.StatusBadRequest, "username must be between 3 and 50 characters")
+	if e := h.validateUsername(c.Context(), req.Username, id); e != nil {
+		return Error(c, e.Code, e.Message)
+	}
+
+	// Validate preferred store types against the taxonomy
+	if req.PreferredStoreTypes != nil {
+		storeTypes, invalid := models.NormalizeStoreTypes(*req.PreferredStoreTypes)
+		if invalid != "" {
+			return Error(c, fiber.StatusBadRequest, "invalid store type: "+invalid)
+		}
+		req.PreferredStoreTypes = &storeTypes
+	}
+
+	if req.UnitSystem != nil && !models.IsValidUnitSystem(*req.UnitSystem) {
+		return Error(c, fiber.StatusBadRequest, "unit_system must be metric or imperial")
+	}
+
+	// Check the home location against its address, filling unchanged fields from the profile
+	var check *models.AddressCheck
+	if req.Latitude != nil || req.Longitude != nil || req.State != nil || req.ZipCode != nil {
+		existing, err := h.db.GetUserByID(c.Context(), id)
+		if err != nil {
+			if errors.Is(err, database.ErrUserNotFound) {
+				return Error(c, fiber.StatusNotFound, "user not found")
+			}
+			return Error(c, fiber.StatusInternalServerError, "failed to get user")
+		}
+		lat, lng := coalesceFloat(req.Latitude, existing.Latitude), coalesceFloat(req.Longitude, existing.Longitude)
+		state, zipCode := coalesceString(req.State, existing.State), coalesceString(req.ZipCode, existing.ZipCode)
+
+		var blocked bool
+		if check, blocked = h.checkAddress(c, lat, lng, state, zipCode); blocked {
+			return addressMismatch(c, check)
 		}
 	}
 
@@ -75,7 +106,31 @@ func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 		return Error(c, fiber.StatusInternalServerError, "failed to update user")
 	}
 
-	return Success(c, user)
+	return c.JSON(APIResponse{
+		Success:  true,
+		Data:     user,
+		Warnings: addressWarnings(check),
+	})
+}
+
+// coalesceFloat returns the first non-nil value
+func coalesceFloat(values ...*float64) *float64 {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// coalesceString returns the first non-nil value, or "" when all are nil
+func coalesceString(values ...*string) string {
+	for _, v := range values {
+		if v != nil {
+			return *v
+		}
+	}
+	return ""
 }
 
 // GetUserStats returns statistics for a user
@@ -93,6 +148,95 @@ func (h *Handler) GetUserStats(c *fiber.Ctx) error {
 	return Success(c, stats)
 }
 
+// verificationImpactWindowDays is how long after a vote later price changes count against it
+const verificationImpactWindowDays = 14
+
+// GetVerificationImpact returns how a user's price verifications held up over time
+// GET /api/users/:id/verification-impact
+func (h *Handler) GetVerificationImpact(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	if _, err := h.db.GetUserByID(c.Context(), id); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return Error(c, fiber.StatusNotFound, "user not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get user")
+	}
+
+	h.impactMu.Lock()
+	cached, ok := h.impactCache[id]
+	h.impactMu.Unlock()
+	if ok && time.Since(cached.ComputedAt) < verificationImpactTTL {
+		return Success(c, cached)
+	}
+
+	impact, err := h.db.GetVerificationImpact(c.Context(), id, verificationImpactWindowDays)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get verification impact")
+	}
+
+	h.cacheVerificationImpact(id, impact, time.Now())
+
+	return Success(c, impact)
+}
+
+// cacheVerificationImpact stores a computed impact. Expired entries are swept on each write,
+// and when the cache is still full the oldest entry makes room, so it stays bounded however
+// many users are looked up.
+func (h *Handler) cacheVerificationImpact(id int, impact *models.VerificationImpact, now time.Time) {
+	h.impactMu.Lock()
+	defer h.impactMu.Unlock()
+
+	for key, cached := range h.impactCache {
+		if now.Sub(cached.ComputedAt) >= verificationImpactTTL {
+			delete(h.impactCache, key)
+		}
+	}
+
+	if _, ok := h.impactCache[id]; !ok && len(h.impactCache) >= verificationImpactCacheSize {
+		oldest := -1
+		for key, cached := range h.impactCache {
+			if oldest == -1 || cached.ComputedAt.Before(h.impactCache[oldest].ComputedAt) {
+				oldest = key
+			}
+		}
+		delete(h.impactCache, oldest)
+	}
+
+	h.impactCache[id] = impact
+}
+
+// GetUserReputation returns a user's reputation total and a page of how it was earned
+// GET /api/users/:id/reputation
+func (h *Handler) GetUserReputation(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rep, total, err := h.db.GetUserReputation(c.Context(), id, limit, offset)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return Error(c, fiber.StatusNotFound, "user not found")
+		}
+		return Error(c, fiber.StatusInternalServerError, "failed to get reputation")
+	}
+
+	return SuccessWithMeta(c, rep, total, limit, offset)
+}
+
 // ChangePassword allows users to change their password
 func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -137,7 +281,7 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), h.bcryptCost(c.Context()))
 	if err != nil {
 		return Error(c, fiber.StatusInternalServerError, "failed to process password")
 	}
@@ -152,3 +296,35 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 		"message": "password changed successfully",
 	})
 }
+
+// GetUserPriceGap compares the user's private prices with community prices at the same stores
+// GET /api/users/:id/price-gap
+func (h *Handler) GetUserPriceGap(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return Error(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	// Private prices are only visible to their owner and admins
+	if middleware.GetUserID(c) != id && middleware.GetUserRole(c) != models.RoleAdmin {
+		return Error(c, fiber.StatusForbidden, "cannot view another user's private prices")
+	}
+
+	report, err := h.db.GetUserPriceGap(c.Context(), id)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to compare prices")
+	}
+
+	// Optionally narrow to one status, e.g. ?status=private_stale
+	if status := c.Query("status"); status != "" {
+		filtered := []*models.PriceGapEntry{}
+		for _, e := range report.Entries {
+			if e.Status == status {
+				filtered = append(filtered, e)
+			}
+		}
+		report.Entries = filtered
+	}
+
+	return Success(c, report)
+}
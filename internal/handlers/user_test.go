@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestCacheVerificationImpact(t *testing.T) {
+	now := time.Now()
+	h := &Handler{impactCache: make(map[int]*models.VerificationImpact)}
+
+	h.cacheVerificationImpact(1, &models.VerificationImpact{UserID: 1, ComputedAt: now.Add(-verificationImpactTTL)}, now)
+	h.cacheVerificationImpact(2, &models.VerificationImpact{UserID: 2, ComputedAt: now}, now)
+	if _, ok := h.impactCache[1]; ok {
+		t.Error("expired entry was not swept")
+	}
+
+	for id := 3; id < verificationImpactCacheSize+10; id++ {
+		h.cacheVerificationImpact(id, &models.VerificationImpact{UserID: id, ComputedAt: now.Add(time.Duration(id) * time.Millisecond)}, now)
+	}
+	if len(h.impactCache) != verificationImpactCacheSize {
+		t.Errorf("cache holds %d entries, want %d", len(h.impactCache), verificationImpactCacheSize)
+	}
+	if _, ok := h.impactCache[2]; ok {
+		t.Error("oldest entry was kept when the cache was full")
+	}
+	if _, ok := h.impactCache[verificationImpactCacheSize+9]; !ok {
+		t.Error("newest entry is missing")
+	}
+}
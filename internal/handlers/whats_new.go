@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// whatsNewSectionLimit caps how many entries each digest section returns
+const whatsNewSectionLimit = 20
+
+// GetWhatsNew returns everything that changed since the user last fetched the digest:
+// new prices on items from their active lists, price drops in their region, new stores
+// near them and pending inventory alerts. Fetching marks the digest as seen.
+// GET /api/me/whats-new
+func (h *Handler) GetWhatsNew(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	}
+	ctx := c.Context()
+
+	user, err := h.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get user")
+	}
+
+	seenAt, err := h.db.GetWhatsNewSeenAt(ctx, userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get last visit")
+	}
+
+	// First visit looks back a fixed window instead of the whole history
+	now := time.Now()
+	since := now.AddDate(0, 0, -h.db.GetSettingInt(ctx, "whats_new_first_visit_days", 7, h.getEncryptionKey()))
+	if seenAt != nil {
+		since = *seenAt
+	}
+
+	digest := &models.WhatsNew{
+		Since:           since,
+		SeenAt:          now,
+		RegionDrops:     []models.WhatsNewPrice{},
+		NewStores:       []models.WhatsNewStore{},
+		InventoryAlerts: []*models.InventoryNotification{},
+	}
+
+	digest.FollowedPrices, err = h.db.GetFollowedItemPrices(ctx, userID, since, whatsNewSectionLimit)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get followed item prices")
+	}
+
+	if user.RegionID != nil {
+		digest.RegionDrops, err = h.db.GetRegionPriceDrops(ctx, *user.RegionID, since, whatsNewSectionLimit)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to get price drops")
+		}
+	}
+
+	// Near the user's saved location when set, otherwise anywhere in their region
+	if user.Latitude != nil && user.Longitude != nil {
+		radiusKm := float64(h.db.GetSettingInt(ctx, "whats_new_store_radius_km", 15, h.getEncryptionKey()))
+		digest.NewStores, err = h.db.GetNewStoresNear(ctx, *user.Latitude, *user.Longitude, radiusKm, since, whatsNewSectionLimit)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to get new stores")
+		}
+		unit := models.DistanceUnitFor(user.UnitSystem)
+		for i := range digest.NewStores {
+			d := unit.FromKm(*digest.NewStores[i].Distance)
+			digest.NewStores[i].Distance = &d
+			digest.NewStores[i].DistanceUnit = unit
+		}
+	} else if user.RegionID != nil {
+		digest.NewStores, err = h.db.GetNewStoresInRegion(ctx, *user.RegionID, since, whatsNewSectionLimit)
+		if err != nil {
+			return Error(c, fiber.StatusInternalServerError, "failed to get new stores")
+		}
+	}
+
+	notifications, err := h.db.ListPendingInventoryNotifications(ctx, userID)
+	if err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to get inventory alerts")
+	}
+	for _, n := range notifications {
+		if n.CreatedAt.After(since) {
+			digest.InventoryAlerts = append(digest.InventoryAlerts, n)
+		}
+	}
+
+	// Mark seen only once the digest was built so a failed fetch does not drop changes
+	if err := h.db.MarkWhatsNewSeen(ctx, userID, now); err != nil {
+		return Error(c, fiber.StatusInternalServerError, "failed to update last visit")
+	}
+
+	return Success(c, digest)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+)
+
+// SecurityHeaders sets browser hardening headers on every response. HSTS is only sent on
+// HTTPS requests and only when HTTPS is enforced, so local development over HTTP is unaffected.
+func SecurityHeaders(cfg *config.Config) fiber.Handler {
+	hsts := "max-age=" + strconv.Itoa(cfg.HSTSMaxAge) + "; includeSubDomains"
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderReferrerPolicy, "strict-origin-when-cross-origin")
+		if cfg.ForceHTTPS && cfg.HSTSMaxAge > 0 && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		return c.Next()
+	}
+}
+
+// RequireHTTPS rejects plain HTTP requests when HTTPS is enforced. It guards routes that
+// issue or accept share tokens, and stops share pages from leaking their token as a referrer.
+// X-Forwarded-Proto is only honored from the app's trusted proxies (TRUSTED_PROXIES).
+func RequireHTTPS(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+		if cfg.ForceHTTPS && c.Protocol() != "https" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "HTTPS is required",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+)
+
+func TestRequireHTTPSForwardedProto(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		header  string
+		want    int
+	}{
+		{"untrusted client claiming https", nil, "https", fiber.StatusForbidden},
+		{"plain http", nil, "", fiber.StatusForbidden},
+		{"trusted proxy forwarding https", []string{"0.0.0.0"}, "https", fiber.StatusOK},
+		{"trusted proxy forwarding http", []string{"0.0.0.0"}, "http", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ForceHTTPS: true}
+			app := fiber.New(fiber.Config{EnableTrustedProxyCheck: true, TrustedProxies: tt.proxies})
+			app.Get("/share", RequireHTTPS(cfg), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/share", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderXForwardedProto, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if got := resp.Header.Get(fiber.HeaderReferrerPolicy); got != "no-referrer" {
+				t.Errorf("Referrer-Policy = %q, want no-referrer", got)
+			}
+		})
+	}
+}
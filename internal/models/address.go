@@ -0,0 +1,22 @@
+package models
+
+// Address validation modes (address_validation_mode setting)
+const (
+	AddressValidationOff   = "off"
+	AddressValidationWarn  = "warn"
+	AddressValidationBlock = "block"
+)
+
+// AddressDiscrepancy is a field where the supplied address disagrees with the reverse-geocoded coordinates
+type AddressDiscrepancy struct {
+	Field    string `json:"field"` // "state" or "zip_code"
+	Supplied string `json:"supplied"`
+	Geocoded string `json:"geocoded"`
+}
+
+// AddressCheck is the outcome of comparing coordinates with a supplied address
+type AddressCheck struct {
+	Mode            string               `json:"mode"`
+	GeocodedAddress string               `json:"geocoded_address"`
+	Discrepancies   []AddressDiscrepancy `json:"discrepancies"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// FrequentItem is an item a user buys often, counted from their shopping lists and confirmed receipts
+type FrequentItem struct {
+	ItemID          int    `json:"item_id"`
+	ItemName        string `json:"item_name"`
+	Purchases       int    `json:"purchases"`        // Lists and receipts the item appeared on
+	TypicalQuantity int    `json:"typical_quantity"` // Rounded average quantity per purchase
+}
+
+// CoveredBasketItem is a frequent item a store has a price for
+type CoveredBasketItem struct {
+	FrequentItem
+	Price     float64 `json:"price"`
+	LineTotal float64 `json:"line_total"` // Price times the typical quantity
+}
+
+// StoreBasketCoverage reports how much of a user's typical basket a store carries
+type StoreBasketCoverage struct {
+	StoreID         int                 `json:"store_id"`
+	StoreName       string              `json:"store_name"`
+	WindowDays      int                 `json:"window_days"`
+	BasketSize      int                 `json:"basket_size"`
+	CoveredCount    int                 `json:"covered_count"`
+	CoveragePercent float64             `json:"coverage_percent"`
+	EstimatedTotal  float64             `json:"estimated_total"` // Covered items at their typical quantity
+	Covered         []CoveredBasketItem `json:"covered"`
+	Missing         []FrequentItem      `json:"missing"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CheckSheetRow is one item on a store's printable price check sheet
+type CheckSheetRow struct {
+	ItemID       int        `json:"item_id"`
+	ItemName     string     `json:"item_name"`
+	ItemBrand    *string    `json:"item_brand,omitempty"`
+	Category     string     `json:"category"`
+	Price        float64    `json:"price"`
+	LastVerified *time.Time `json:"last_verified,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	IsStale      bool       `json:"is_stale"`
+}
+
+// CheckSheet lists a store's priced items for a contributor to verify in person,
+// stale prices first and then grouped by category
+type CheckSheet struct {
+	StoreID        int             `json:"store_id"`
+	StoreName      string          `json:"store_name"`
+	StaleAfterDays int             `json:"stale_after_days"`
+	StaleCount     int             `json:"stale_count"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+	Rows           []CheckSheetRow `json:"rows"`
+}
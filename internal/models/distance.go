@@ -0,0 +1,49 @@
+package models
+
+import "math"
+
+// UnitSystem values for a user's display preference
+const (
+	UnitSystemMetric   = "metric"
+	UnitSystemImperial = "imperial"
+)
+
+// IsValidUnitSystem checks a unit_system preference value
+func IsValidUnitSystem(s string) bool {
+	return s == UnitSystemMetric || s == UnitSystemImperial
+}
+
+// DistanceUnit is the unit distances are reported in. Distances are always computed in km.
+type DistanceUnit string
+
+const (
+	DistanceUnitKm DistanceUnit = "km"
+	DistanceUnitMi DistanceUnit = "mi"
+)
+
+const kmPerMile = 1.609344
+
+// ParseDistanceUnit parses a unit query value ("km" or "mi")
+func ParseDistanceUnit(s string) (DistanceUnit, bool) {
+	switch DistanceUnit(s) {
+	case DistanceUnitKm, DistanceUnitMi:
+		return DistanceUnit(s), true
+	}
+	return "", false
+}
+
+// DistanceUnitFor returns the default distance unit for a unit system
+func DistanceUnitFor(unitSystem string) DistanceUnit {
+	if unitSystem == UnitSystemImperial {
+		return DistanceUnitMi
+	}
+	return DistanceUnitKm
+}
+
+// FromKm converts a distance in km to this unit, rounded to one decimal
+func (u DistanceUnit) FromKm(km float64) float64 {
+	if u == DistanceUnitMi {
+		km /= kmPerMile
+	}
+	return math.Round(km*10) / 10
+}
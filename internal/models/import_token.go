@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// ImportTokenScopePrices allows a token to push store prices
+const ImportTokenScopePrices = "prices"
+
+// ImportToken is a single-use credential for an external price import
+type ImportToken struct {
+	ID           int        `json:"id"`
+	Label        string     `json:"label"`
+	Scope        string     `json:"scope"`
+	StoreIDs     []int      `json:"store_ids"` // Empty allows any public store
+	MaxRows      int        `json:"max_rows"`
+	CreatedBy    int        `json:"created_by"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	UsedIP       *string    `json:"used_ip,omitempty"`
+	RowsImported *int       `json:"rows_imported,omitempty"`
+	RowsRejected *int       `json:"rows_rejected,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreateImportTokenRequest is the request body for generating an import token
+type CreateImportTokenRequest struct {
+	Label      string `json:"label"`
+	Scope      string `json:"scope"`
+	StoreIDs   []int  `json:"store_ids"`
+	MaxRows    int    `json:"max_rows"`
+	TTLMinutes int    `json:"ttl_minutes"`
+}
+
+// CreateImportTokenResponse returns the plaintext token; only its hash is stored
+type CreateImportTokenResponse struct {
+	Token string       `json:"token"`
+	Info  *ImportToken `json:"info"`
+}
+
+// ImportPriceEntry is one price pushed through a token import
+type ImportPriceEntry struct {
+	StoreID int     `json:"store_id"`
+	ItemID  int     `json:"item_id"`
+	Price   float64 `json:"price"`
+	// Set by the server when the entry must be rejected before it reaches the database
+	Rejection string `json:"-"`
+}
+
+// ImportPricesRequest is the request body for a token price import
+type ImportPricesRequest struct {
+	Prices []ImportPriceEntry `json:"prices"`
+}
+
+// ImportPricesResult reports how many prices a token import saved
+type ImportPricesResult struct {
+	Imported int      `json:"imported"`
+	Rejected int      `json:"rejected"`
+	Errors   []string `json:"errors,omitempty"`
+}
@@ -12,7 +12,8 @@ type InventoryItem struct {
 	// Reference to catalog item (optional)
 	ItemID *int `json:"item_id,omitempty"`
 
-	// Custom item fields (used when ItemID is nil)
+	// Custom item fields (used when ItemID is nil). For catalog items the size and unit
+	// default to the item's and may be overridden.
 	CustomName  *string  `json:"custom_name,omitempty"`
 	CustomBrand *string  `json:"custom_brand,omitempty"`
 	CustomSize  *float64 `json:"custom_size,omitempty"`
@@ -74,7 +75,8 @@ type CreateInventoryItemRequest struct {
 	// Option 1: Reference existing catalog item
 	ItemID *int `json:"item_id,omitempty"`
 
-	// Option 2: Create custom inventory-only item
+	// Option 2: Create custom inventory-only item. With item_id, custom_size and custom_unit
+	// override the catalog item's size and unit, which are used when omitted.
 	CustomName  *string  `json:"custom_name,omitempty"`
 	CustomBrand *string  `json:"custom_brand,omitempty"`
 	CustomSize  *float64 `json:"custom_size,omitempty"`
@@ -132,4 +134,76 @@ type AdjustInventoryQuantityRequest struct {
 type AddInventoryToListRequest struct {
 	ListID   int `json:"list_id"`
 	Quantity int `json:"quantity"`
+	// Optional amount in Unit (default the inventory item's unit), converted to whole packages
+	// of the item's size; replaces Quantity when set
+	Amount *float64 `json:"amount,omitempty"`
+	Unit   *string  `json:"unit,omitempty"`
+}
+
+// RestockListRequest builds a shopping list from low-stock and expiring inventory
+type RestockListRequest struct {
+	Name         string `json:"name,omitempty"`          // Defaults to "Restock" and today's date
+	ExpiringDays int    `json:"expiring_days,omitempty"` // Include items expiring within this many days (default 7)
+}
+
+// RestockSkippedItem is an inventory item left off a restock list
+type RestockSkippedItem struct {
+	InventoryID int    `json:"inventory_id"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+}
+
+// RestockListResult is the shopping list built from inventory and the items that were skipped
+type RestockListResult struct {
+	List    *ShoppingListWithItems `json:"list"`
+	Skipped []RestockSkippedItem   `json:"skipped,omitempty"`
+}
+
+// Inventory notification kinds
+const (
+	InventoryNotificationExpiring = "expiring"
+	InventoryNotificationLowStock = "low_stock"
+)
+
+// InventoryNotification is a persisted expiry or low-stock alert for an inventory item
+type InventoryNotification struct {
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	InventoryItemID int        `json:"inventory_item_id"`
+	Kind            string     `json:"kind"`
+	Message         string     `json:"message"`
+	CreatedAt       time.Time  `json:"created_at"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// Inventory adjustment sources
+const (
+	InventoryAdjustmentManual  = "manual"  // +/- adjustment
+	InventoryAdjustmentEdit    = "edit"    // quantity set directly on update
+	InventoryAdjustmentReceipt = "receipt" // bought on a confirmed receipt
+	InventoryAdjustmentList    = "list"    // bought on a completed shopping list
+)
+
+// Consumption confidence levels, from too little history to a steady record
+const (
+	ConsumptionConfidenceNone   = "none"
+	ConsumptionConfidenceLow    = "low"
+	ConsumptionConfidenceMedium = "medium"
+	ConsumptionConfidenceHigh   = "high"
+)
+
+// InventoryConsumption is an item's average usage over a recent window and when it should run out
+type InventoryConsumption struct {
+	InventoryItemID   int        `json:"inventory_item_id"`
+	Quantity          float64    `json:"quantity"`
+	Unit              *string    `json:"unit,omitempty"`
+	WindowDays        int        `json:"window_days"`
+	ObservedDays      float64    `json:"observed_days"`
+	ConsumptionEvents int        `json:"consumption_events"`
+	TotalConsumed     float64    `json:"total_consumed"`
+	DailyRate         float64    `json:"daily_rate"`
+	DaysUntilEmpty    *float64   `json:"days_until_empty,omitempty"`
+	ProjectedRunOut   *time.Time `json:"projected_run_out,omitempty"`
+	Confidence        string     `json:"confidence"`
+	SuggestAddToList  bool       `json:"suggest_add_to_list"`
 }
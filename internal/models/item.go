@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,7 @@ type Item struct {
 	Size              *float64   `json:"size,omitempty"`
 	Unit              *string    `json:"unit,omitempty"`
 	Description       *string    `json:"description,omitempty"`
+	Barcode           *string    `json:"barcode,omitempty"` // GTIN-14
 	Verified          bool       `json:"verified"`
 	VerificationCount int        `json:"verification_count"`
 	IsPrivate         bool       `json:"is_private"`
@@ -28,6 +30,28 @@ type ItemWithStats struct {
 	MinPrice   *float64 `json:"min_price,omitempty"`
 	MaxPrice   *float64 `json:"max_price,omitempty"`
 	Tags       []string `json:"tags"`
+
+	// Verification basis: "manual" (admin) or "receipts" (confirmed by distinct users' receipts)
+	VerificationBasis *string `json:"verification_basis,omitempty"`
+	ReceiptUserCount  int     `json:"receipt_user_count"`
+
+	// Set when the item was deleted; its prices are kept but left out of comparisons and plans
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// ItemSearchScore breaks down how an item was ranked for a search query
+type ItemSearchScore struct {
+	Name        float64  `json:"name"`  // Trigram similarity of the item name to the query
+	Brand       float64  `json:"brand"` // Bonus for an exact brand match
+	Tags        float64  `json:"tags"`  // Bonus for each tag matching a query word
+	Total       float64  `json:"total"`
+	MatchedTags []string `json:"matched_tags,omitempty"`
+}
+
+// ItemSearchResult is an item returned from search with its ranking score
+type ItemSearchResult struct {
+	Item
+	Score ItemSearchScore `json:"score"`
 }
 
 // CreateItemRequest is the request body for creating an item
@@ -37,6 +61,7 @@ type CreateItemRequest struct {
 	Size        *float64 `json:"size,omitempty"`
 	Unit        *string  `json:"unit,omitempty"`
 	Description *string  `json:"description,omitempty"`
+	Barcode     *string  `json:"barcode,omitempty"` // UPC-A, UPC-E (8 digits), EAN-13 or GTIN-14
 	Tags        []string `json:"tags,omitempty"`
 	IsPrivate   *bool    `json:"is_private,omitempty"` // Defaults to true if not specified
 }
@@ -48,10 +73,29 @@ type UpdateItemRequest struct {
 	Size        *float64 `json:"size,omitempty"`
 	Unit        *string  `json:"unit,omitempty"`
 	Description *string  `json:"description,omitempty"`
+	Barcode     *string  `json:"barcode,omitempty"` // Empty string clears the barcode
 	Verified    *bool    `json:"verified,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
 }
 
+// NormalizeBarcode strips spaces and dashes from a scanned UPC/EAN code and pads it to the
+// 14-digit GTIN form items are stored under. It reports false for anything that is not an
+// 8, 12, 13 or 14 digit code.
+func NormalizeBarcode(code string) (string, bool) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return strings.Repeat("0", 14-len(code)) + code, true
+}
+
 // ItemListParams contains parameters for listing items
 type ItemListParams struct {
 	Limit     int
@@ -60,6 +104,10 @@ type ItemListParams struct {
 	Tag       string
 	UserID    *int  // Filter by creator (for visibility)
 	IsPrivate *bool // Filter by private/public items
+	// Include archived items (admin only)
+	IncludeArchived bool
+	// Narrows the average, lowest and highest price under verified-only mode
+	StatsFilter PublicStatsFilter
 }
 
 // ItemStats contains aggregate statistics for items
@@ -78,3 +126,100 @@ type Tag struct {
 	UsageCount int       `json:"usage_count"`
 	CreatedAt  time.Time `json:"created_at"`
 }
+
+// ItemVariantPrice is a package-size variant of a product with its lowest visible price
+type ItemVariantPrice struct {
+	ItemID    int      `json:"item_id"`
+	Name      string   `json:"name"`
+	Brand     *string  `json:"brand,omitempty"`
+	Size      *float64 `json:"size,omitempty"`
+	Unit      *string  `json:"unit,omitempty"`
+	BestPrice *float64 `json:"best_price,omitempty"`
+	StoreID   *int     `json:"store_id,omitempty"`
+	StoreName *string  `json:"store_name,omitempty"`
+
+	// Computed by the size comparison
+	UnitPrice *float64 `json:"unit_price,omitempty"`
+	Rank      int      `json:"rank,omitempty"`
+	IsCurrent bool     `json:"is_current"`
+}
+
+// ItemSizeComparison ranks package sizes of a product by their best unit price
+type ItemSizeComparison struct {
+	ItemID         int                 `json:"item_id"`
+	BaseUnit       string              `json:"base_unit,omitempty"`
+	CheapestItemID *int                `json:"cheapest_item_id,omitempty"`
+	Variants       []*ItemVariantPrice `json:"variants"`
+}
+
+// Equivalent match types
+const (
+	EquivalentMatchProductGroup = "product_group" // Linked by an admin
+	EquivalentMatchSimilar      = "similar"       // Shares a tag and a similar brand-free name
+)
+
+// ProductGroup links items that are the same product under different brands
+type ProductGroup struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	ItemIDs   []int     `json:"item_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateProductGroupRequest creates a product group from existing items
+type CreateProductGroupRequest struct {
+	Name    string `json:"name"`
+	ItemIDs []int  `json:"item_ids"`
+}
+
+// SetItemProductGroupRequest moves an item into a product group; null removes it from its group
+type SetItemProductGroupRequest struct {
+	ProductGroupID *int `json:"product_group_id"`
+}
+
+// ItemEquivalent is another brand of the same product with its best visible price
+type ItemEquivalent struct {
+	ItemID     int      `json:"item_id"`
+	Name       string   `json:"name"`
+	Brand      *string  `json:"brand,omitempty"`
+	Size       *float64 `json:"size,omitempty"`
+	Unit       *string  `json:"unit,omitempty"`
+	BestPrice  *float64 `json:"best_price,omitempty"`
+	StoreID    *int     `json:"store_id,omitempty"`
+	StoreName  *string  `json:"store_name,omitempty"`
+	MatchType  string   `json:"match_type"`
+	Similarity float64  `json:"similarity"`
+
+	// Computed by the equivalents ranking
+	UnitPrice      *float64 `json:"unit_price,omitempty"`
+	SavingsPercent *float64 `json:"savings_percent,omitempty"` // Unit price saving over the item itself
+	Rank           int      `json:"rank,omitempty"`
+}
+
+// ItemEquivalents ranks an item's cross-brand alternatives by unit price
+type ItemEquivalents struct {
+	ItemID         int               `json:"item_id"`
+	ProductGroupID *int              `json:"product_group_id,omitempty"`
+	BestPrice      *float64          `json:"best_price,omitempty"`
+	BaseUnit       string            `json:"base_unit,omitempty"`
+	UnitPrice      *float64          `json:"unit_price,omitempty"`
+	CheapestItemID *int              `json:"cheapest_item_id,omitempty"` // Set when an equivalent beats the item's unit price
+	Equivalents    []*ItemEquivalent `json:"equivalents"`
+}
+
+// TagSuggestion is a tag proposed for an item name
+type TagSuggestion struct {
+	Slug       string  `json:"slug"`
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"` // 0-1
+	Source     string  `json:"source"`     // "keyword" or "similar_items"
+}
+
+// CoPurchasedItem is an item often bought in the same receipt or completed list as another item
+type CoPurchasedItem struct {
+	ItemID      int     `json:"item_id"`
+	Name        string  `json:"name"`
+	Brand       *string `json:"brand,omitempty"`
+	BasketCount int     `json:"basket_count"` // Baskets containing both items
+	Confidence  float64 `json:"confidence"`   // Share of the item's baskets that also contain this one
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ItemNameRule rewrites one word of receipt text when an item is created from a receipt
+type ItemNameRule struct {
+	ID          int       `json:"id"`
+	Pattern     string    `json:"pattern"`     // Receipt word to match, case-insensitive
+	Replacement string    `json:"replacement"` // Empty drops the word
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UpsertItemNameRuleRequest is the request body for adding or changing an item name rule
+type UpsertItemNameRuleRequest struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Enabled     *bool  `json:"enabled,omitempty"` // Defaults to true
+}
@@ -19,14 +19,47 @@ type ShoppingList struct {
 	Name           string     `json:"name"`
 	Status         ListStatus `json:"status"`
 	TargetDate     *time.Time `json:"target_date,omitempty"`
+	Budget         *float64   `json:"budget,omitempty"`
 	CompletedAt    *time.Time `json:"completed_at,omitempty"`
 	ShareToken     *string    `json:"share_token,omitempty"`
 	ShareExpiresAt *time.Time `json:"share_expires_at,omitempty"`
 	ShareCreatedAt *time.Time `json:"share_created_at,omitempty"`
+	Recurrence     string     `json:"recurrence,omitempty"`      // none, weekly, biweekly or monthly
+	NextOccurrence *time.Time `json:"next_occurrence,omitempty"` // When the next copy is created
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+// Shopping list recurrence intervals
+const (
+	ListRecurrenceNone     = "none"
+	ListRecurrenceWeekly   = "weekly"
+	ListRecurrenceBiweekly = "biweekly"
+	ListRecurrenceMonthly  = "monthly"
+)
+
+// IsValidListRecurrence reports whether r is a supported recurrence
+func IsValidListRecurrence(r string) bool {
+	switch r {
+	case ListRecurrenceNone, ListRecurrenceWeekly, ListRecurrenceBiweekly, ListRecurrenceMonthly:
+		return true
+	}
+	return false
+}
+
+// NextListOccurrence returns the occurrence after from for a recurring list
+func NextListOccurrence(from time.Time, recurrence string) time.Time {
+	switch recurrence {
+	case ListRecurrenceWeekly:
+		return from.AddDate(0, 0, 7)
+	case ListRecurrenceBiweekly:
+		return from.AddDate(0, 0, 14)
+	case ListRecurrenceMonthly:
+		return from.AddDate(0, 1, 0)
+	}
+	return from
+}
+
 // ShoppingListItem represents an item in a shopping list
 type ShoppingListItem struct {
 	ID        int        `json:"id"`
@@ -56,6 +89,18 @@ type ShoppingListWithItems struct {
 	ItemCount      int                           `json:"item_count"`
 	CheckedCount   int                           `json:"checked_count"`   // Number of checked items
 	EstimatedTotal float64                       `json:"estimated_total"` // Sum of best prices * quantities
+	OverBudget     bool                          `json:"over_budget"`
+	BudgetOverage  float64                       `json:"budget_overage,omitempty"` // Amount the estimate exceeds the budget
+}
+
+// ListWatch marks a shopping list for automatic replanning when its prices change
+type ListWatch struct {
+	ListID         int        `json:"list_id"`
+	UserID         int        `json:"user_id"`
+	LastPlanCost   *float64   `json:"last_plan_cost,omitempty"`
+	LastCheckedAt  time.Time  `json:"last_checked_at"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // ShoppingListSummary is a compact representation for list views
@@ -123,6 +168,23 @@ type MultiStoreOption struct {
 	TotalCost    float64               `json:"total_cost"`
 	TotalSavings float64               `json:"total_savings"` // Savings vs best single store
 	TripCount    int                   `json:"trip_count"`
+	// Set when the cheapest split needed more stores than max_trips and stores were merged away
+	TripCapped bool `json:"trip_capped,omitempty"`
+	// Items only priced at stores that were merged away
+	ItemsMissing []string `json:"items_missing,omitempty"`
+}
+
+// Shopping plan defaults when the request does not set its own constraints
+const (
+	DefaultPlanMaxTrips   = 3
+	DefaultPlanMinSavings = 10.00
+)
+
+// PlanConstraints limits the multi-store plan and decides when it is recommended
+type PlanConstraints struct {
+	MaxTrips   int     `json:"max_trips"`              // Most stores the multi-store plan may visit
+	MinSavings float64 `json:"min_savings"`            // Savings over the best single store needed to recommend it
+	MaxAgeDays *int    `json:"max_age_days,omitempty"` // Leave out prices not updated or verified within this many days
 }
 
 // ShoppingPlanResult is the complete optimization result
@@ -132,6 +194,22 @@ type ShoppingPlanResult struct {
 	MultiStore     *MultiStoreOption  `json:"multi_store,omitempty"`
 	Recommendation string             `json:"recommendation"` // "single_store" or "multi_store"
 	GeneratedAt    time.Time          `json:"generated_at"`
+	Constraints    PlanConstraints    `json:"constraints"`
+
+	// Budget tracking for the recommended option
+	Budget          *float64               `json:"budget,omitempty"`
+	PlanTotal       float64                `json:"plan_total"`
+	OverBudget      bool                   `json:"over_budget"`
+	BudgetOverage   float64                `json:"budget_overage,omitempty"`
+	DropSuggestions []BudgetDropSuggestion `json:"drop_suggestions,omitempty"`
+}
+
+// BudgetDropSuggestion is an item that could be dropped to bring a plan under budget
+type BudgetDropSuggestion struct {
+	ItemID    int     `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Quantity  int     `json:"quantity"`
+	LineTotal float64 `json:"line_total"`
 }
 
 // PriceComparisonCell represents a single cell in the comparison grid
@@ -140,7 +218,15 @@ type PriceComparisonCell struct {
 	VerifiedCount int      `json:"verified_count"`
 	SubmittedBy   *string  `json:"submitted_by,omitempty"`
 	UpdatedAt     *string  `json:"updated_at,omitempty"`
-	IsBest        bool     `json:"is_best"` // True if this is the lowest price for the item
+	IsBest        bool     `json:"is_best"`            // True if this is the lowest price for the item
+	IsOwn         bool     `json:"is_own"`             // True if the requesting user submitted this price
+	DaysOld       *int     `json:"days_old,omitempty"` // Days since the price was last updated or verified
+	IsStale       bool     `json:"is_stale"`
+
+	// Price per the row's normalized unit; nil when the item has no comparable size
+	UnitPrice *float64 `json:"unit_price,omitempty"`
+	// Lowest unit price among same-name items of different sizes in the grid
+	IsBestUnitPrice bool `json:"is_best_unit_price,omitempty"`
 }
 
 // PriceComparisonRow represents a row (item) in the comparison grid
@@ -153,6 +239,13 @@ type PriceComparisonRow struct {
 	Prices    map[int]PriceComparisonCell `json:"prices"` // Key is store_id
 	BestPrice *float64                    `json:"best_price,omitempty"`
 	BestStore *int                        `json:"best_store,omitempty"`
+
+	// The requesting user's own prices, keyed by store_id, when they are excluded from Prices
+	OwnPrices map[int]PriceComparisonCell `json:"own_prices,omitempty"`
+
+	// Unit the cells' unit prices are expressed in ("oz", "fl oz" or "each"); empty without a size
+	NormalizedUnit string   `json:"normalized_unit,omitempty"`
+	BestUnitPrice  *float64 `json:"best_unit_price,omitempty"`
 }
 
 // PriceComparisonResult is the full comparison grid
@@ -165,6 +258,25 @@ type PriceComparisonResult struct {
 type StoreBasic struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+
+	// Distance from the caller's coordinates; omitted when not requested or the store has none
+	DistanceKm   *float64     `json:"distance_km,omitempty"`
+	Distance     *float64     `json:"distance,omitempty"` // DistanceKm in DistanceUnit, rounded
+	DistanceUnit DistanceUnit `json:"distance_unit,omitempty"`
+
+	RegionID   *int     `json:"region_id,omitempty"`
+	RegionName *string  `json:"region_name,omitempty"`
+	Latitude   *float64 `json:"-"`
+	Longitude  *float64 `json:"-"`
+}
+
+// ComparisonSpread flags a comparison whose stores span several regions or lie far apart
+type ComparisonSpread struct {
+	RegionIDs       []int    `json:"region_ids"`
+	MultipleRegions bool     `json:"multiple_regions"`
+	MaxDistanceKm   *float64 `json:"max_distance_km,omitempty"` // Largest distance between two selected stores
+	TooFarApart     bool     `json:"too_far_apart"`
+	Message         string   `json:"message"`
 }
 
 // Request types
@@ -173,12 +285,21 @@ type StoreBasic struct {
 type CreateListRequest struct {
 	Name       string     `json:"name"`
 	TargetDate *time.Time `json:"target_date,omitempty"`
+	Budget     *float64   `json:"budget,omitempty"`
 }
 
 // UpdateListRequest is the request body for updating a shopping list
 type UpdateListRequest struct {
-	Name       *string    `json:"name,omitempty"`
-	TargetDate *time.Time `json:"target_date,omitempty"`
+	Name        *string    `json:"name,omitempty"`
+	TargetDate  *time.Time `json:"target_date,omitempty"`
+	Budget      *float64   `json:"budget,omitempty"`
+	ClearBudget bool       `json:"clear_budget,omitempty"` // Remove the budget; cannot be combined with budget
+}
+
+// SetListRecurrenceRequest is the request body for making a list recurring
+type SetListRecurrenceRequest struct {
+	Recurrence     string     `json:"recurrence"`
+	NextOccurrence *time.Time `json:"next_occurrence,omitempty"` // Defaults to one interval from today
 }
 
 // AddListItemRequest is the request body for adding an item to a list
@@ -187,6 +308,12 @@ type AddListItemRequest struct {
 	Quantity int `json:"quantity"`
 }
 
+// MergeListRequest is the request body for merging another list into a list
+type MergeListRequest struct {
+	SourceListID int  `json:"source_list_id"`
+	DeleteSource bool `json:"delete_source"`
+}
+
 // UpdateListItemRequest is the request body for updating a list item
 type UpdateListItemRequest struct {
 	Quantity int `json:"quantity"`
@@ -198,6 +325,8 @@ type ListListParams struct {
 	Offset int
 	UserID int        // Required - lists are always scoped to a user
 	Status ListStatus // Optional - filter by status (active, completed)
+	// Estimate from the stored items.best_price instead of scanning store_prices
+	DenormalizedBestPrice bool
 }
 
 // CompareParams contains parameters for price comparison
@@ -206,6 +335,18 @@ type CompareParams struct {
 	ItemIDs  []int // Items to compare (optional, if empty compare all items with prices)
 	RegionID *int  // Filter by region
 	UserID   *int  // Include user's private prices
+
+	// Compute the community best without the user's own prices, which are returned separately
+	ExcludeOwnPrices bool
+
+	Visibility *ContributorVisibility // Controls masking of SubmittedBy
+
+	// Optional caller location for per-store distances
+	Latitude  *float64
+	Longitude *float64
+
+	MaxAgeDays *int // Leave out prices not updated or verified within this many days
+	StaleDays  int  // Cells older than this are marked stale; 0 marks none
 }
 
 // PriceConfirmation represents a price confirmation during checkout
@@ -219,4 +360,131 @@ type PriceConfirmation struct {
 // CompleteListRequest is the request body for completing a shopping list
 type CompleteListRequest struct {
 	PriceConfirmations []PriceConfirmation `json:"price_confirmations,omitempty"`
+
+	// Add the checked-off items to the user's inventory. A list restocks at most once, so
+	// reopening and completing it again does not count the same purchases twice.
+	RestockInventory bool `json:"restock_inventory,omitempty"`
+	// Create inventory rows for purchased items the user does not track yet
+	CreateInventoryItems bool `json:"create_inventory_items,omitempty"`
+	// Per-item quantities to restock, overriding the list quantity; 0 skips the item
+	RestockQuantities []RestockQuantity `json:"restock_quantities,omitempty"`
+}
+
+// RestockQuantity is how much of a list item was actually bought
+type RestockQuantity struct {
+	ItemID   int     `json:"item_id"`
+	Quantity float64 `json:"quantity"`
+}
+
+// ReconcileListItem is what one list item actually cost on a trip
+type ReconcileListItem struct {
+	ItemID      int     `json:"item_id"`
+	StoreID     int     `json:"store_id"`
+	ActualPrice float64 `json:"actual_price"`       // Unit price paid
+	Quantity    *int    `json:"quantity,omitempty"` // Defaults to the list quantity
+}
+
+// ReconcileListRequest is the request body for reconciling a list against a trip
+type ReconcileListRequest struct {
+	Items []ReconcileListItem `json:"items"`
+}
+
+// ReconciledListItem compares the estimated and actual cost of one list item
+type ReconciledListItem struct {
+	ItemID         int      `json:"item_id"`
+	ItemName       string   `json:"item_name"`
+	StoreID        int      `json:"store_id"`
+	Quantity       int      `json:"quantity"`
+	EstimatedPrice *float64 `json:"estimated_price,omitempty"` // Best known price before the trip
+	ActualPrice    float64  `json:"actual_price"`
+	ActualTotal    float64  `json:"actual_total"`
+	Variance       *float64 `json:"variance,omitempty"` // (actual - estimated) x quantity
+	PriceID        int      `json:"price_id"`           // Store price the actual was recorded to
+}
+
+// ListReconciliation is the variance report for a shopping trip
+type ListReconciliation struct {
+	ListID int `json:"list_id"`
+	// Totals over the reconciled items; the estimate only covers items that had a known price
+	EstimatedTotal    float64              `json:"estimated_total"`
+	ActualTotal       float64              `json:"actual_total"`
+	Variance          float64              `json:"variance"` // Over items with an estimate
+	VariancePercent   *float64             `json:"variance_percent,omitempty"`
+	Items             []ReconciledListItem `json:"items"`
+	UnestimatedCount  int                  `json:"unestimated_count"`  // Reconciled items that had no price before
+	UnreconciledCount int                  `json:"unreconciled_count"` // List items left out of the request
+}
+
+// ListInflationMonth is one month of a list's basket cost built from monthly best prices
+type ListInflationMonth struct {
+	Month       string  `json:"month"`       // YYYY-MM
+	BasketCost  float64 `json:"basket_cost"` // Sum of best price x quantity for items priced this month
+	ItemsPriced int     `json:"items_priced"`
+	// Change from the previous month over items priced in both months; nil when none overlap
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+	// Chained index of the monthly changes, starting at 100
+	Index float64 `json:"index"`
+}
+
+// ListInflation is a personal inflation time series for a shopping list's items
+type ListInflation struct {
+	ListID              int                  `json:"list_id"`
+	ItemsTotal          int                  `json:"items_total"`
+	Months              []ListInflationMonth `json:"months"`
+	TotalChangePercent  float64              `json:"total_change_percent"` // Index change over the period
+	ItemsWithoutHistory []int                `json:"items_without_history"`
+}
+
+// ListSnapshot is a saved copy of a shopping list's estimated cost
+type ListSnapshot struct {
+	ID             int                `json:"id"`
+	ListID         int                `json:"list_id"`
+	EstimatedTotal float64            `json:"estimated_total"`
+	ItemCount      int                `json:"item_count"`
+	CreatedAt      time.Time          `json:"created_at"`
+	Items          []ListSnapshotItem `json:"items,omitempty"`
+}
+
+// ListSnapshotItem is one list item's quantity and best known price in a snapshot
+type ListSnapshotItem struct {
+	ItemID    int      `json:"item_id"`
+	ItemName  string   `json:"item_name"`
+	Quantity  int      `json:"quantity"`
+	Price     *float64 `json:"price,omitempty"` // nil when the item had no price
+	StoreName *string  `json:"store_name,omitempty"`
+}
+
+// Snapshot item delta statuses
+const (
+	SnapshotItemChanged   = "changed"
+	SnapshotItemUnchanged = "unchanged"
+	SnapshotItemAdded     = "added"
+	SnapshotItemRemoved   = "removed"
+)
+
+// ListSnapshotItemDelta is how one item's line cost moved between two snapshots. Items
+// missing or unpriced in a snapshot count as costing nothing there.
+type ListSnapshotItemDelta struct {
+	ItemID        int      `json:"item_id"`
+	ItemName      string   `json:"item_name"`
+	Status        string   `json:"status"`
+	FromQuantity  int      `json:"from_quantity"`
+	ToQuantity    int      `json:"to_quantity"`
+	FromPrice     *float64 `json:"from_price,omitempty"`
+	ToPrice       *float64 `json:"to_price,omitempty"`
+	FromTotal     float64  `json:"from_total"`
+	ToTotal       float64  `json:"to_total"`
+	Delta         float64  `json:"delta"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // Unit price change when priced in both
+}
+
+// ListSnapshotComparison is the change in a list's cost between two snapshots
+type ListSnapshotComparison struct {
+	ListID             int                     `json:"list_id"`
+	From               ListSnapshot            `json:"from"`
+	To                 ListSnapshot            `json:"to"`
+	TotalDelta         float64                 `json:"total_delta"`
+	TotalChangePercent *float64                `json:"total_change_percent,omitempty"`
+	Items              []ListSnapshotItemDelta `json:"items"`
+	BiggestMovers      []ListSnapshotItemDelta `json:"biggest_movers"` // Largest absolute deltas first
 }
@@ -0,0 +1,54 @@
+package models
+
+import "strings"
+
+// DefaultCountry is assumed for regions created without a country
+const DefaultCountry = "US"
+
+// RegionLocale is the currency and locale a region uses to display prices
+type RegionLocale struct {
+	Currency string
+	Locale   string
+}
+
+// countryLocales maps ISO 3166-1 alpha-2 country codes to their default currency and locale
+var countryLocales = map[string]RegionLocale{
+	"US": {"USD", "en-US"},
+	"CA": {"CAD", "en-CA"},
+	"MX": {"MXN", "es-MX"},
+	"GB": {"GBP", "en-GB"},
+	"IE": {"EUR", "en-IE"},
+	"DE": {"EUR", "de-DE"},
+	"AT": {"EUR", "de-AT"},
+	"CH": {"CHF", "de-CH"},
+	"FR": {"EUR", "fr-FR"},
+	"BE": {"EUR", "nl-BE"},
+	"NL": {"EUR", "nl-NL"},
+	"LU": {"EUR", "fr-LU"},
+	"ES": {"EUR", "es-ES"},
+	"PT": {"EUR", "pt-PT"},
+	"IT": {"EUR", "it-IT"},
+	"FI": {"EUR", "fi-FI"},
+	"SE": {"SEK", "sv-SE"},
+	"NO": {"NOK", "nb-NO"},
+	"DK": {"DKK", "da-DK"},
+	"PL": {"PLN", "pl-PL"},
+	"CZ": {"CZK", "cs-CZ"},
+	"AU": {"AUD", "en-AU"},
+	"NZ": {"NZD", "en-NZ"},
+	"JP": {"JPY", "ja-JP"},
+	"IN": {"INR", "en-IN"},
+	"BR": {"BRL", "pt-BR"},
+	"ZA": {"ZAR", "en-ZA"},
+	"PR": {"USD", "es-PR"},
+}
+
+// LocaleForCountry returns the default currency and locale for a country code.
+// Unknown countries fall back to the US defaults; ok reports whether the country was known.
+func LocaleForCountry(country string) (locale RegionLocale, ok bool) {
+	locale, ok = countryLocales[strings.ToUpper(strings.TrimSpace(country))]
+	if !ok {
+		return countryLocales[DefaultCountry], false
+	}
+	return locale, true
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// NotificationPreferences is the canonical record of what a user wants to be notified about.
+// Every notifying subsystem reads its toggle from here; admin settings can still switch a
+// whole channel off.
+type NotificationPreferences struct {
+	RegionDigestEmail bool `json:"region_digest_email"` // Region price-drop digests for active subscriptions
+	PriceAlertEmail   bool `json:"price_alert_email"`   // Emails when a price alert's target is met
+	ListWatchEmail    bool `json:"list_watch_email"`    // Emails when a watched list gets cheaper
+	InventoryAlerts   bool `json:"inventory_alerts"`    // In-app pantry expiry and low-stock notifications
+	InventoryEmail    bool `json:"inventory_email"`     // Daily summary email of new pantry notifications
+
+	RegionSubscriptions []*RegionSubscription `json:"region_subscriptions"`
+	UpdatedAt           *time.Time            `json:"updated_at,omitempty"`
+}
+
+// RegionSubscriptionToggle pauses or resumes one region digest subscription
+type RegionSubscriptionToggle struct {
+	ID       int  `json:"id"`
+	IsActive bool `json:"is_active"`
+}
+
+// UpdateNotificationPreferencesRequest changes the preferences that are set
+type UpdateNotificationPreferencesRequest struct {
+	RegionDigestEmail *bool `json:"region_digest_email,omitempty"`
+	PriceAlertEmail   *bool `json:"price_alert_email,omitempty"`
+	ListWatchEmail    *bool `json:"list_watch_email,omitempty"`
+	InventoryAlerts   *bool `json:"inventory_alerts,omitempty"`
+	InventoryEmail    *bool `json:"inventory_email,omitempty"`
+
+	RegionSubscriptions []RegionSubscriptionToggle `json:"region_subscriptions,omitempty"`
+}
@@ -32,6 +32,18 @@ type StorePriceWithDetails struct {
 	RegionName    *string `json:"region_name,omitempty"`
 	UserName      *string `json:"user_name,omitempty"`
 	UserEmail     *string `json:"user_email,omitempty"`
+
+	ContributorHidden bool `json:"-"` // Submitter asked to be shown anonymously
+
+	// Set in verified-only mode when the price doesn't yet count toward public statistics
+	Unverified bool `json:"unverified,omitempty"`
+
+	// Flags since the last accurate verification (price detail only)
+	FlagCount *int `json:"flag_count,omitempty"`
+
+	// Whole days since the price was last updated or verified, and whether that exceeds price_stale_days
+	DaysOld int  `json:"days_old"`
+	IsStale bool `json:"is_stale"`
 }
 
 // CreatePriceRequest is the request body for creating a price
@@ -39,7 +51,11 @@ type CreatePriceRequest struct {
 	StoreID  int     `json:"store_id"`
 	ItemID   int     `json:"item_id"`
 	Price    float64 `json:"price"`
-	IsShared bool    `json:"is_shared"` // If true, price is shared with community (default true)
+	IsShared bool    `json:"is_shared"` // If true, price is shared with community (defaults to the user's share_prices_by_default)
+	// Submit the price even when it looks like an outlier
+	ConfirmOutlier bool `json:"confirm_outlier,omitempty"`
+	// Set by the server when sharing is held until the submitter verifies their email
+	ShareOnVerify bool `json:"-"`
 }
 
 // UpdatePriceRequest is the request body for updating a price
@@ -60,6 +76,18 @@ type PriceListParams struct {
 	DateTo   *time.Time
 	IsShared *bool // Filter by shared/private prices
 	UserID   *int  // Filter by submitter (for private prices)
+	// Only prices submitted by this user, shared or not
+	SubmitterID *int
+	// Only prices updated or verified within this many days
+	MaxAgeDays *int
+}
+
+// ScopedPriceParams pages and filters the prices of a single store or item
+type ScopedPriceParams struct {
+	Limit       int // 0 returns every price
+	Offset      int
+	SharedOnly  bool
+	MinVerified int
 }
 
 // PriceStats contains aggregate statistics for prices
@@ -69,6 +97,40 @@ type PriceStats struct {
 	WeekCount     int `json:"week_count"`
 	VerifiedCount int `json:"verified_count"`
 	FlaggedCount  int `json:"flagged_count"`
+
+	// Verified-only mode: prices verified often enough to count toward public statistics
+	StatsVerifiedOnly  bool `json:"stats_verified_only"`
+	StatsEligibleCount int  `json:"stats_eligible_count"`
+}
+
+// PublicStatsFilter limits public price statistics to prices verified at least MinVerifications
+// times. When fewer than MinVerifiedPrices prices qualify the statistics use every price instead.
+type PublicStatsFilter struct {
+	VerifiedOnly      bool
+	MinVerifications  int
+	MinVerifiedPrices int
+}
+
+// Threshold returns the verified_count a price needs to be counted, 0 when the mode is off
+func (f PublicStatsFilter) Threshold() int {
+	if !f.VerifiedOnly {
+		return 0
+	}
+	return f.MinVerifications
+}
+
+// IsUnverified reports whether a price is left out of public statistics under this filter
+func (f PublicStatsFilter) IsUnverified(verifiedCount int) bool {
+	return f.VerifiedOnly && verifiedCount < f.MinVerifications
+}
+
+// PriceAge returns the whole days since a price was last updated or verified and whether it is
+// stale, that is not touched within staleDays. Like the max_age_days and price drop queries it
+// compares exact timestamps rather than whole days. A staleDays of 0 marks nothing stale.
+func PriceAge(lastTouched, now time.Time, staleDays int) (daysOld int, stale bool) {
+	daysOld = int(now.Sub(lastTouched).Hours() / 24)
+	stale = staleDays > 0 && lastTouched.Before(now.AddDate(0, 0, -staleDays))
+	return daysOld, stale
 }
 
 // PriceVerification represents a user's verification of a price
@@ -124,3 +186,244 @@ type PriceHistoryParams struct {
 	StoreID *int
 	Limit   int
 }
+
+// LowestPriceRecord is the lowest price ever recorded for an item and where it occurred
+type LowestPriceRecord struct {
+	ItemID     int       `json:"item_id"`
+	Price      float64   `json:"price"`
+	StoreID    int       `json:"store_id"`
+	StoreName  string    `json:"store_name"`
+	RegionID   *int      `json:"region_id,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Groupings supported by the best-day-to-buy analysis
+const (
+	BestDayByDayOfWeek   = "day_of_week"
+	BestDayByWeekOfMonth = "week_of_month"
+)
+
+// Confidence levels for the best-day-to-buy analysis
+const (
+	BestDayConfidenceInsufficient = "insufficient_data"
+	BestDayConfidenceLow          = "low"
+	BestDayConfidenceMedium       = "medium"
+	BestDayConfidenceHigh         = "high"
+)
+
+// BestDayParams contains parameters for the best-day-to-buy analysis
+type BestDayParams struct {
+	ItemID   int
+	StoreID  *int
+	RegionID *int
+	GroupBy  string
+	Days     int
+}
+
+// BestDayBucket aggregates historical prices for one day of the week or week of the month.
+// RelativePrice compares prices to each store's own average, so 0.95 means 5% below typical.
+type BestDayBucket struct {
+	Bucket        int     `json:"bucket"`
+	Label         string  `json:"label"`
+	SampleCount   int     `json:"sample_count"`
+	AvgPrice      float64 `json:"avg_price"`
+	RelativePrice float64 `json:"relative_price"`
+}
+
+// BestDayInsight reports when an item's price tends to be lowest
+type BestDayInsight struct {
+	ItemID         int              `json:"item_id"`
+	GroupBy        string           `json:"group_by"`
+	Days           int              `json:"days"`
+	SampleCount    int              `json:"sample_count"`
+	Confidence     string           `json:"confidence"`
+	Best           *BestDayBucket   `json:"best,omitempty"`
+	SavingsPercent float64          `json:"savings_percent"` // Best bucket vs typical price
+	Buckets        []*BestDayBucket `json:"buckets"`
+}
+
+// LowestPriceParams contains parameters for the lowest historical price lookup
+type LowestPriceParams struct {
+	ItemID   int
+	StoreID  *int
+	RegionID *int
+}
+
+// PriceVerificationResult describes what recording a verification changed
+type PriceVerificationResult struct {
+	IsNew    bool `json:"is_new"`   // First verification of this price by the user
+	Changed  bool `json:"changed"`  // An existing verification flipped between accurate and inaccurate
+	Rewarded bool `json:"rewarded"` // Reputation was awarded (only on the first accurate verification)
+}
+
+// Price flag reasons
+const (
+	PriceFlagTooHigh      = "too_high"
+	PriceFlagTooLow       = "too_low"
+	PriceFlagItemMismatch = "item_mismatch"
+	PriceFlagOutOfStock   = "out_of_stock"
+	PriceFlagOther        = "other"
+)
+
+// PriceFlagHideThreshold is how many flags hide a price from comparisons and shopping plans
+// until it is verified as accurate again. refresh_item_best_prices (migration 079) repeats the
+// value in SQL; TestItemBestPriceCountsOnlyCommunityPrices fails if the two drift apart.
+const PriceFlagHideThreshold = 3
+
+// IsValidPriceFlagReason reports whether reason is a known flag reason
+func IsValidPriceFlagReason(reason string) bool {
+	switch reason {
+	case PriceFlagTooHigh, PriceFlagTooLow, PriceFlagItemMismatch, PriceFlagOutOfStock, PriceFlagOther:
+		return true
+	}
+	return false
+}
+
+// FlagPriceRequest reports a price as wrong
+type FlagPriceRequest struct {
+	FlagReason string  `json:"flag_reason"`
+	Reason     *string `json:"reason,omitempty"` // Optional free-text detail
+}
+
+// PriceFlagResult is the state of a price after it was flagged
+type PriceFlagResult struct {
+	IsNew     bool `json:"is_new"` // First flag or verification of this price by the user
+	FlagCount int  `json:"flag_count"`
+	Hidden    bool `json:"hidden"` // Left out of comparisons and shopping plans
+}
+
+// PriceHistogramBucket counts current prices falling in [From, To)
+type PriceHistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// PriceHistogram is the distribution of an item's current shared prices
+type PriceHistogram struct {
+	ItemID      int                     `json:"item_id"`
+	RegionID    *int                    `json:"region_id,omitempty"`
+	Total       int                     `json:"total"`
+	Min         float64                 `json:"min"`
+	Max         float64                 `json:"max"`
+	BucketWidth float64                 `json:"bucket_width"`
+	Buckets     []*PriceHistogramBucket `json:"buckets"`
+	// True when only verified prices were counted; false when the mode is off or too few were verified
+	VerifiedOnly bool `json:"verified_only"`
+}
+
+// BulkPriceAdjustRequest adjusts many of a store's prices at once. Exactly one of Percent
+// or Amount is required; the item filters are combined and all are optional.
+type BulkPriceAdjustRequest struct {
+	Percent *float64 `json:"percent,omitempty"` // e.g. 5 raises prices 5%, -10 lowers them 10%
+	Amount  *float64 `json:"amount,omitempty"`  // Flat change added to each price
+	ItemIDs []int    `json:"item_ids,omitempty"`
+	Tag     *string  `json:"tag,omitempty"` // Tag slug
+	Brand   *string  `json:"brand,omitempty"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// BulkPriceChange is one price affected by a bulk adjustment
+type BulkPriceChange struct {
+	PriceID  int     `json:"price_id"`
+	ItemID   int     `json:"item_id"`
+	ItemName string  `json:"item_name"`
+	OldPrice float64 `json:"old_price"`
+	NewPrice float64 `json:"new_price"`
+	Skipped  string  `json:"skipped,omitempty"` // Why the change was not applied
+}
+
+// BulkPriceAdjustResult summarizes a bulk adjustment or its preview
+type BulkPriceAdjustResult struct {
+	StoreID int                `json:"store_id"`
+	DryRun  bool               `json:"dry_run"`
+	Matched int                `json:"matched"`
+	Updated int                `json:"updated"` // Would be updated when DryRun
+	Skipped int                `json:"skipped"`
+	Changes []*BulkPriceChange `json:"changes"`
+}
+
+// BulkPriceBounds limits the prices a bulk adjustment may produce
+type BulkPriceBounds struct {
+	MaxChangePercent float64 // Largest allowed change per price
+	OutlierFactor    float64 // New price may not exceed this multiple (or fall below its inverse) of the item's median elsewhere
+}
+
+// Price gap statuses
+const (
+	PriceGapInSync         = "in_sync"
+	PriceGapPrivateStale   = "private_stale"   // Community price is newer and differs
+	PriceGapCommunityStale = "community_stale" // The user's private price is newer and differs
+)
+
+// PriceGapEntry compares a user's private price with the community price at the same store
+type PriceGapEntry struct {
+	StoreID            int       `json:"store_id"`
+	StoreName          string    `json:"store_name"`
+	ItemID             int       `json:"item_id"`
+	ItemName           string    `json:"item_name"`
+	PrivatePrice       float64   `json:"private_price"`
+	PrivateUpdatedAt   time.Time `json:"private_updated_at"`
+	CommunityPrice     float64   `json:"community_price"` // Most recent shared price from other users
+	CommunityUpdatedAt time.Time `json:"community_updated_at"`
+	CommunityCount     int       `json:"community_count"`
+	Difference         float64   `json:"difference"` // Private minus community
+	DifferencePercent  float64   `json:"difference_percent"`
+	Status             string    `json:"status"`
+}
+
+// PriceGapReport summarizes how a user's private prices compare with community prices
+type PriceGapReport struct {
+	UserID         int              `json:"user_id"`
+	Compared       int              `json:"compared"`
+	InSync         int              `json:"in_sync"`
+	PrivateStale   int              `json:"private_stale"`
+	CommunityStale int              `json:"community_stale"`
+	Entries        []*PriceGapEntry `json:"entries"`
+}
+
+// ReferencePrice is a current shared price used to judge whether a new price is an outlier
+type ReferencePrice struct {
+	ItemID int
+	Price  float64
+	Size   *float64
+	Unit   *string
+}
+
+// PriceOutlier describes why a submitted price was judged an outlier
+type PriceOutlier struct {
+	Price    float64 `json:"price"`
+	Median   float64 `json:"median"` // Typical price for this item's package size
+	Factor   float64 `json:"factor"`
+	Samples  int     `json:"samples"`
+	UnitBase bool    `json:"unit_based"` // Median derived from unit prices across package sizes
+}
+
+// ChainParityLocation is the current price of an item at one location of a chain
+type ChainParityLocation struct {
+	StoreID   int       `json:"store_id"`
+	StoreName string    `json:"store_name"`
+	City      string    `json:"city"`
+	State     string    `json:"state"`
+	Price     float64   `json:"price"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChainParity describes how much an item's price varies across a chain's locations
+type ChainParity struct {
+	Chain            string                 `json:"chain"`
+	ItemID           int                    `json:"item_id"`
+	ItemName         string                 `json:"item_name"`
+	StaleAfterDays   int                    `json:"stale_after_days"`
+	LocationCount    int                    `json:"location_count"`
+	MinPrice         float64                `json:"min_price"`
+	MaxPrice         float64                `json:"max_price"`
+	AvgPrice         float64                `json:"avg_price"`
+	StdDev           float64                `json:"stddev"`
+	VariationPercent float64                `json:"variation_percent"` // Standard deviation relative to the average
+	Uniform          bool                   `json:"uniform"`           // Every location is within ChainParityUniformPercent of the average
+	Locations        []*ChainParityLocation `json:"locations"`
+}
+
+// ChainParityUniformPercent is the largest spread from the average at which a chain counts as pricing uniformly
+const ChainParityUniformPercent = 2.0
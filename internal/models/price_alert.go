@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// PriceAlert notifies a user when an item is priced below a target, optionally at one store
+type PriceAlert struct {
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	ItemID          int        `json:"item_id"`
+	ItemName        string     `json:"item_name"`
+	StoreID         *int       `json:"store_id,omitempty"` // Nil matches any store
+	StoreName       *string    `json:"store_name,omitempty"`
+	TargetPrice     float64    `json:"target_price"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// Lowest current price the alert can see, if any
+	BestPrice     *float64 `json:"best_price,omitempty"`
+	BestStoreID   *int     `json:"best_store_id,omitempty"`
+	BestStoreName *string  `json:"best_store_name,omitempty"`
+}
+
+// PriceAlertTestResult is an alert evaluated against current prices without notifying anyone
+type PriceAlertTestResult struct {
+	Alert      *PriceAlert `json:"alert"`
+	WouldFire  bool        `json:"would_fire"`
+	Difference *float64    `json:"difference,omitempty"` // Best price minus target; at or below zero fires
+}
+
+// CreatePriceAlertRequest is the request body for creating a price alert
+type CreatePriceAlertRequest struct {
+	ItemID      int     `json:"item_id"`
+	StoreID     *int    `json:"store_id,omitempty"`
+	TargetPrice float64 `json:"target_price"`
+}
+
+// PriceSubmission is a store price that was just created or updated
+type PriceSubmission struct {
+	PriceID  int
+	StoreID  int
+	ItemID   int
+	Price    float64
+	UserID   int
+	IsShared bool
+}
+
+// TriggeredPriceAlert is an alert fired by a price submission, with what the email needs
+type TriggeredPriceAlert struct {
+	AlertID      int
+	UserID       int
+	Email        string
+	ItemName     string
+	StoreName    string
+	TargetPrice  float64
+	Price        float64
+	EmailEnabled bool
+}
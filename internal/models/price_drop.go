@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// PriceDrop is an item's largest recent price decrease at a public store, for the deals feed
+type PriceDrop struct {
+	ItemID        int       `json:"item_id"`
+	ItemName      string    `json:"item_name"`
+	ItemBrand     *string   `json:"item_brand,omitempty"`
+	StoreID       int       `json:"store_id"`
+	StoreName     string    `json:"store_name"`
+	RegionID      *int      `json:"region_id,omitempty"`
+	Price         float64   `json:"price"`
+	PreviousPrice float64   `json:"previous_price"`
+	DropAmount    float64   `json:"drop_amount"`
+	DropPercent   float64   `json:"drop_percent"`
+	DroppedAt     time.Time `json:"dropped_at"`
+}
+
+// PriceDropParams filters the price drops feed
+type PriceDropParams struct {
+	RegionID      *int
+	Days          int     // Only drops recorded within the last days
+	StaleDays     int     // Drops whose current price has not been updated or verified within this are left out
+	OutlierFactor float64 // Drops to or from a price this far off the item's median are left out; <= 1 disables
+	MinPercent    float64 // Smallest percent decrease to list
+	Limit         int
+	Offset        int
+}
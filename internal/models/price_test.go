@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceAge(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		touched   time.Time
+		staleDays int
+		wantDays  int
+		wantStale bool
+	}{
+		{"fresh", now.Add(-time.Hour), 30, 0, false},
+		{"exactly at the limit", now.AddDate(0, 0, -30), 30, 30, false},
+		{"just past the limit", now.AddDate(0, 0, -30).Add(-time.Minute), 30, 30, true},
+		{"part day past the limit", now.AddDate(0, 0, -30).Add(-23 * time.Hour), 30, 30, true},
+		{"well past the limit", now.AddDate(0, 0, -45), 30, 45, true},
+		{"staleness disabled", now.AddDate(0, 0, -400), 0, 400, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, stale := PriceAge(tt.touched, now, tt.staleDays)
+			if days != tt.wantDays || stale != tt.wantStale {
+				t.Errorf("PriceAge() = (%d, %v), want (%d, %v)", days, stale, tt.wantDays, tt.wantStale)
+			}
+		})
+	}
+}
@@ -23,6 +23,24 @@ const (
 	MatchStatusMatched MatchStatus = "matched"
 	MatchStatusNewItem MatchStatus = "new_item"
 	MatchStatusSkipped MatchStatus = "skipped"
+	// The line was read with low OCR confidence and should be checked by the user
+	MatchStatusNeedsReview MatchStatus = "needs_review"
+)
+
+// Receipt date sources, in order of precedence when inferring the date of a scanned receipt
+const (
+	ReceiptDateSourceOCR    = "ocr"
+	ReceiptDateSourceEXIF   = "exif"
+	ReceiptDateSourceUpload = "upload"
+	ReceiptDateSourceManual = "manual"
+)
+
+// ReceiptPriceScope selects which existing store price a confirmed receipt line updates
+type ReceiptPriceScope string
+
+const (
+	ReceiptPriceScopeContributor ReceiptPriceScope = "contributor" // the confirming user's own price
+	ReceiptPriceScopeStoreItem   ReceiptPriceScope = "store_item"  // the latest shared price for the store and item, or the user's own
 )
 
 // Receipt represents an uploaded receipt image
@@ -40,6 +58,9 @@ type Receipt struct {
 	ErrorMessage     *string       `json:"error_message,omitempty"`
 	ReceiptDate      *time.Time    `json:"receipt_date,omitempty"`
 	ReceiptTotal     *float64      `json:"receipt_total,omitempty"`
+	DateSource       *string       `json:"receipt_date_source,omitempty"`
+	OCRPreprocessing *string       `json:"ocr_preprocessing,omitempty"`
+	ThumbnailKey     *string       `json:"thumbnail_key,omitempty"`
 	UploadedAt       time.Time     `json:"uploaded_at"`
 	ProcessedAt      *time.Time    `json:"processed_at,omitempty"`
 	ConfirmedAt      *time.Time    `json:"confirmed_at,omitempty"`
@@ -54,6 +75,11 @@ type ReceiptWithItems struct {
 	Items     []ReceiptItemWithSuggestions `json:"items"`
 	StoreName *string                      `json:"store_name,omitempty"`
 	ImageURL  *string                      `json:"image_url,omitempty"`
+	// Presigned URL of the small preview image, when one was generated
+	ThumbnailURL *string `json:"thumbnail_url,omitempty"`
+	// Seconds the presigned URLs remain valid
+	ImageURLExpiresIn     *int `json:"image_url_expires_in,omitempty"`
+	ThumbnailURLExpiresIn *int `json:"thumbnail_url_expires_in,omitempty"`
 }
 
 // ReceiptItem represents a parsed line item from a receipt
@@ -72,6 +98,7 @@ type ReceiptItem struct {
 	IsConfirmed       bool        `json:"is_confirmed"`
 	CreatedItemID     *int        `json:"created_item_id,omitempty"`
 	LineNumber        *int        `json:"line_number,omitempty"`
+	OCRConfidence     *float64    `json:"ocr_confidence,omitempty"`
 	CreatedAt         time.Time   `json:"created_at"`
 	UpdatedAt         time.Time   `json:"updated_at"`
 }
@@ -81,6 +108,8 @@ type ReceiptItemWithSuggestions struct {
 	ReceiptItem
 	MatchedItemName *string          `json:"matched_item_name,omitempty"`
 	Suggestions     []ItemSuggestion `json:"suggestions,omitempty"`
+	// OCR confidence is below receipt_ocr_min_confidence
+	LowConfidence bool `json:"low_confidence"`
 }
 
 // ItemSuggestion represents a suggested item match
@@ -114,6 +143,7 @@ type CreateReceiptItemRequest struct {
 	MatchConfidence   *float64
 	MatchStatus       MatchStatus
 	LineNumber        int
+	OCRConfidence     *float64
 }
 
 // UpdateReceiptItemRequest is used when user confirms/updates an item
@@ -130,6 +160,8 @@ type UpdateReceiptItemRequest struct {
 type ConfirmReceiptRequest struct {
 	StoreID int                      `json:"store_id"`
 	Items   []ConfirmReceiptItemData `json:"items"`
+	// Add each confirmed catalog item to the user's inventory
+	AddToInventory bool `json:"add_to_inventory,omitempty"`
 }
 
 // ConfirmReceiptItemData represents a single item confirmation
@@ -174,6 +206,7 @@ type ParsedItem struct {
 	Price      float64
 	Quantity   int
 	LineNumber int
+	Barcode    string // GTIN-14 when the line prints a UPC
 }
 
 // ParsedReceipt represents the parsed result from receipt OCR
@@ -218,3 +251,86 @@ type StoreSpend struct {
 	TransactionCount int     `json:"transaction_count"`
 	Source           string  `json:"source"` // "receipt" or "list"
 }
+
+// RematchResult summarizes a re-run of item matching over unconfirmed receipt items
+type RematchResult struct {
+	ReceiptsChecked int `json:"receipts_checked"`
+	ItemsChecked    int `json:"items_checked"`
+	Improved        int `json:"improved"`
+}
+
+// ReceiptConflicts lists the unconfirmed lines of a receipt that need a decision before it is
+// confirmed. AutoMatchable counts lines already matched at or above MinConfidence.
+type ReceiptConflicts struct {
+	ReceiptID     int                          `json:"receipt_id"`
+	MinConfidence float64                      `json:"min_confidence"`
+	AutoMatchable int                          `json:"auto_matchable"`
+	NeedsReview   int                          `json:"needs_review"`
+	Resolved      int                          `json:"resolved"` // Confirmed or skipped lines
+	Items         []ReceiptItemWithSuggestions `json:"items"`
+}
+
+// StorageReconcileRequest controls a storage/receipts consistency check
+type StorageReconcileRequest struct {
+	DeleteOrphans bool `json:"delete_orphans"` // Remove objects that no receipt references
+	BatchSize     int  `json:"batch_size"`
+}
+
+// ReceiptObjectRef identifies a receipt by its storage key
+type ReceiptObjectRef struct {
+	ReceiptID int    `json:"receipt_id"`
+	UserID    int    `json:"user_id"`
+	S3Key     string `json:"s3_key"`
+}
+
+// StorageReconcileResult reports objects without receipts and receipts without objects.
+// Key lists are samples capped by the server; the counts cover the whole bucket.
+type StorageReconcileResult struct {
+	ObjectsScanned  int                `json:"objects_scanned"`
+	ReceiptsScanned int                `json:"receipts_scanned"`
+	OrphanedObjects int                `json:"orphaned_objects"`
+	OrphanedBytes   int64              `json:"orphaned_bytes"`
+	OrphanKeys      []string           `json:"orphan_keys"`
+	DeletedObjects  int                `json:"deleted_objects"`
+	MissingObjects  int                `json:"missing_objects"`
+	MissingReceipts []ReceiptObjectRef `json:"missing_receipts"`
+}
+
+// ReceiptOCRMetrics summarizes receipt scanning quality over scanned (non-manual) receipts
+type ReceiptOCRMetrics struct {
+	Since      time.Time `json:"since"`
+	Receipts   int       `json:"receipts"`
+	Processing int       `json:"processing"` // still pending or processing, excluded from the rates
+	Failed     int       `json:"failed"`
+	Confirmed  int       `json:"confirmed"`
+	// Share of processed receipts OCR read any text from
+	OCRSuccessRate float64 `json:"ocr_success_rate"`
+	// Share of receipts with OCR text that parsed without error
+	ParseSuccessRate float64 `json:"parse_success_rate"`
+
+	// Lines extracted per parsed receipt, and lines kept (not skipped) per confirmed receipt
+	AvgItemsExtracted float64 `json:"avg_items_extracted"`
+	AvgItemsConfirmed float64 `json:"avg_items_confirmed"`
+	// Share of lines on confirmed receipts that were kept rather than skipped
+	ItemConfirmRate float64 `json:"item_confirm_rate"`
+	// Share of lines on confirmed receipts that kept their automatic match
+	AutoMatchAccuracy float64 `json:"auto_match_accuracy"`
+
+	AvgOCRConfidence   *float64 `json:"avg_ocr_confidence,omitempty"`
+	LowConfidenceItems int      `json:"low_confidence_items"`
+
+	// Parsed receipts with a total, and those whose extracted line prices miss it by more than TotalTolerance
+	TotalTolerance     float64 `json:"total_tolerance"`
+	ReconciledReceipts int     `json:"reconciled_receipts"`
+	TotalMismatches    int     `json:"total_mismatches"`
+	TotalMismatchRate  float64 `json:"total_mismatch_rate"`
+}
+
+// OCRPreprocessingStat is the item match rate of receipts grouped by the preprocessing applied before OCR
+type OCRPreprocessingStat struct {
+	Preprocessing string  `json:"preprocessing"`
+	Receipts      int     `json:"receipts"`
+	Items         int     `json:"items"`
+	MatchedItems  int     `json:"matched_items"`
+	MatchRate     float64 `json:"match_rate"`
+}
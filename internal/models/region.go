@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 )
 
@@ -10,8 +11,13 @@ type Region struct {
 	Name      string    `json:"name"`
 	State     string    `json:"state"`
 	ZipCodes  []string  `json:"zip_codes"`
+	Country   string    `json:"country"`  // ISO 3166-1 alpha-2 code
+	Currency  string    `json:"currency"` // ISO 4217 code
+	Locale    string    `json:"locale"`   // BCP 47 tag used to format prices
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	CostOfLivingIndex *float64 `json:"cost_of_living_index,omitempty"` // Relative to a baseline of 100; nil when unset
 }
 
 // RegionWithStats includes aggregated statistics
@@ -22,11 +28,27 @@ type RegionWithStats struct {
 	PriceCount int `json:"price_count"`
 }
 
+// RegionContributor summarizes a user's shared contributions within a region
+type RegionContributor struct {
+	Rank              int     `json:"rank"`
+	UserID            *int    `json:"-"` // Used for masking only; the public leaderboard never exposes account IDs
+	Username          *string `json:"username,omitempty"`
+	ReputationPoints  int     `json:"reputation_points"`
+	SharedPriceCount  int     `json:"shared_price_count"`
+	StoresAdded       int     `json:"stores_added"`
+	ContributorHidden bool    `json:"-"`
+}
+
 // CreateRegionRequest is the request body for creating a region
 type CreateRegionRequest struct {
 	Name     string   `json:"name"`
 	State    string   `json:"state"`
 	ZipCodes []string `json:"zip_codes"`
+	Country  string   `json:"country,omitempty"`  // Defaults to US
+	Currency string   `json:"currency,omitempty"` // Defaults from the country
+	Locale   string   `json:"locale,omitempty"`   // Defaults from the country
+
+	CostOfLivingIndex *float64 `json:"cost_of_living_index,omitempty"`
 }
 
 // UpdateRegionRequest is the request body for updating a region
@@ -34,6 +56,11 @@ type UpdateRegionRequest struct {
 	Name     *string   `json:"name,omitempty"`
 	State    *string   `json:"state,omitempty"`
 	ZipCodes *[]string `json:"zip_codes,omitempty"`
+	Country  *string   `json:"country,omitempty"`
+	Currency *string   `json:"currency,omitempty"`
+	Locale   *string   `json:"locale,omitempty"`
+
+	CostOfLivingIndex *float64 `json:"cost_of_living_index,omitempty"`
 }
 
 // RegionListParams contains parameters for listing regions
@@ -43,3 +70,62 @@ type RegionListParams struct {
 	Search string
 	State  string
 }
+
+// RegionReferences counts rows pointing at a region; on deletion they are the rows reassigned
+type RegionReferences struct {
+	Stores    int `json:"stores"`
+	Users     int `json:"users"`
+	FeedItems int `json:"feed_items"`
+}
+
+// Total returns the number of referencing rows
+func (r RegionReferences) Total() int {
+	return r.Stores + r.Users + r.FeedItems
+}
+
+// DeleteRegionResult reports what deleting a region changed
+type DeleteRegionResult struct {
+	RegionID     int              `json:"region_id"`
+	ReassignedTo *int             `json:"reassigned_to,omitempty"` // Nil when references were cleared
+	Reassigned   RegionReferences `json:"reassigned"`
+}
+
+// RegionItemPrice summarizes an item's shared prices in one region. When normalization is
+// requested and the region has a cost-of-living index, the Normalized* fields restate the
+// prices at the baseline index of 100; Normalized reports whether that happened.
+type RegionItemPrice struct {
+	RegionID          int      `json:"region_id"`
+	RegionName        string   `json:"region_name"`
+	State             string   `json:"state"`
+	Currency          string   `json:"currency"`
+	CostOfLivingIndex *float64 `json:"cost_of_living_index,omitempty"`
+	StoreCount        int      `json:"store_count"`
+	MinPrice          float64  `json:"min_price"`
+	AvgPrice          float64  `json:"avg_price"`
+	MaxPrice          float64  `json:"max_price"`
+	Normalized        bool     `json:"normalized"`
+	NormalizedMin     *float64 `json:"normalized_min_price,omitempty"`
+	NormalizedAvg     *float64 `json:"normalized_avg_price,omitempty"`
+	NormalizedMax     *float64 `json:"normalized_max_price,omitempty"`
+	VerifiedOnly      bool     `json:"verified_only"` // Only verified prices were counted for this region
+}
+
+// CostOfLivingBaseline is the index value regional prices are normalized to
+const CostOfLivingBaseline = 100.0
+
+// Normalize restates the region's prices at the baseline cost of living. Regions without a
+// positive index keep raw prices only and stay flagged as not normalized.
+func (p *RegionItemPrice) Normalize() {
+	if p.CostOfLivingIndex == nil || *p.CostOfLivingIndex <= 0 {
+		return
+	}
+	factor := CostOfLivingBaseline / *p.CostOfLivingIndex
+	round := func(v float64) *float64 {
+		r := math.Round(v*factor*100) / 100
+		return &r
+	}
+	p.NormalizedMin = round(p.MinPrice)
+	p.NormalizedAvg = round(p.AvgPrice)
+	p.NormalizedMax = round(p.MaxPrice)
+	p.Normalized = true
+}
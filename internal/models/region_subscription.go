@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Region digest frequencies
+const (
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+// RegionSubscription is a user's emailed digest of price drops in a region
+type RegionSubscription struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	RegionID       int        `json:"region_id"`
+	RegionName     string     `json:"region_name"`
+	Frequency      string     `json:"frequency"`
+	Categories     []string   `json:"categories"` // Tag slugs; empty means every category
+	MinDropPercent float64    `json:"min_drop_percent"`
+	IsActive       bool       `json:"is_active"`
+	LastSentAt     *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Period returns how far apart digests for the subscription are sent
+func (s *RegionSubscription) Period() time.Duration {
+	if s.Frequency == DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// CreateRegionSubscriptionRequest is the request body for subscribing to a region digest
+type CreateRegionSubscriptionRequest struct {
+	RegionID       int      `json:"region_id"`
+	Frequency      string   `json:"frequency"`
+	Categories     []string `json:"categories"`
+	MinDropPercent float64  `json:"min_drop_percent"`
+}
+
+// UpdateRegionSubscriptionRequest is the request body for changing a region digest subscription
+type UpdateRegionSubscriptionRequest struct {
+	Frequency      *string   `json:"frequency,omitempty"`
+	Categories     *[]string `json:"categories,omitempty"`
+	MinDropPercent *float64  `json:"min_drop_percent,omitempty"`
+	IsActive       *bool     `json:"is_active,omitempty"`
+}
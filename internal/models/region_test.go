@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegionContributorOmitsUserID(t *testing.T) {
+	userID := 42
+	name := "shopper"
+	data, err := json.Marshal(&RegionContributor{Rank: 1, UserID: &userID, Username: &name})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), "user_id") || strings.Contains(string(data), "42") {
+		t.Errorf("contributor JSON exposes the user ID: %s", data)
+	}
+}
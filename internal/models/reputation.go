@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Reputation ledger reasons
+const (
+	ReputationReasonOpeningBalance   = "opening_balance"   // Points earned before the ledger existed
+	ReputationReasonVerification     = "verification"      // Verified someone else's price
+	ReputationReasonPriceVerified    = "price_verified"    // A submitted price was verified by another user
+	ReputationReasonStoreVerified    = "store_verified"    // A created store was verified
+	ReputationReasonReceiptConfirmed = "receipt_confirmed" // Confirmed a receipt
+)
+
+// ReputationEvent is one entry in a user's reputation ledger
+type ReputationEvent struct {
+	ID        int       `json:"id"`
+	Points    int       `json:"points"`
+	Reason    string    `json:"reason"`
+	SourceID  *int      `json:"source_id,omitempty"` // Price verification, store or receipt, depending on reason
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserReputation is a user's reputation total with a page of their ledger
+type UserReputation struct {
+	UserID int                `json:"user_id"`
+	Total  int                `json:"total"`
+	Events []*ReputationEvent `json:"events"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Search event kinds
+const (
+	SearchKindItem  = "item"
+	SearchKindStore = "store"
+)
+
+// TrendingSearch is a frequently searched query. Queries with few or no results point at data gaps.
+type TrendingSearch struct {
+	Query        string  `json:"query"`
+	Kind         string  `json:"kind"`
+	Searches     int     `json:"searches"`
+	AvgResults   float64 `json:"avg_results"`
+	ZeroResults  int     `json:"zero_results"` // Searches that found nothing
+	LastSearched string  `json:"last_searched"`
+}
+
+// TrendingSearchParams filters the trending searches listing
+type TrendingSearchParams struct {
+	RegionID    *int
+	Kind        string // Empty for both kinds
+	Days        int
+	MinSearches int // Queries searched fewer times are never shown
+	Limit       int
+}
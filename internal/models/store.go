@@ -31,6 +31,8 @@ type StoreWithStats struct {
 	RegionName       *string `json:"region_name,omitempty"`
 	PriceCount       int     `json:"price_count"`
 	ContributorCount int     `json:"contributor_count"` // Number of unique users who added prices
+	// How the store became verified: 'manual' or 'contributors'
+	VerifyBasis *string `json:"verification_basis,omitempty"`
 }
 
 // CreateStoreRequest is the request body for creating a store
@@ -64,6 +66,51 @@ type UpdateStoreRequest struct {
 	Verified      *bool    `json:"verified,omitempty"`
 }
 
+// Outcomes of a single row in a store import
+const (
+	StoreImportCreated   = "created"
+	StoreImportDuplicate = "duplicate" // Matches an existing store's address
+	StoreImportInvalid   = "invalid"   // Missing or malformed fields
+	StoreImportFailed    = "failed"
+)
+
+// StoreImportRow is one parsed row of a store import file
+type StoreImportRow struct {
+	Row     int // 1-based data row, excluding the header
+	Request CreateStoreRequest
+}
+
+// StoreImportRowResult reports what happened to one row of a store import
+type StoreImportRowResult struct {
+	Row      int    `json:"row"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	StoreID  *int   `json:"store_id,omitempty"` // New store, or the existing one for duplicates
+	RegionID *int   `json:"region_id,omitempty"`
+	Geocoded bool   `json:"geocoded"`
+	Error    string `json:"error,omitempty"`
+}
+
+// StoreImportInsert is the database outcome of inserting one imported store
+type StoreImportInsert struct {
+	StoreID   int
+	Duplicate bool
+	Err       error // The row failed and was rolled back
+}
+
+// StoreImportResult summarizes a store import
+type StoreImportResult struct {
+	Created       int                    `json:"created"`
+	Duplicates    int                    `json:"duplicates"`
+	Invalid       int                    `json:"invalid"`
+	Failed        int                    `json:"failed"`
+	Geocoded      int                    `json:"geocoded"`
+	CacheHits     int                    `json:"cache_hits"`
+	CircuitOpen   bool                   `json:"circuit_open"` // Geocoding stopped; later rows were imported without coordinates
+	StoppedReason string                 `json:"stopped_reason,omitempty"`
+	Rows          []StoreImportRowResult `json:"rows"`
+}
+
 // StoreListParams contains parameters for listing stores
 type StoreListParams struct {
 	Limit     int
@@ -83,3 +130,62 @@ type StoreStats struct {
 	PendingCount  int `json:"pending_count"`
 	TotalPrices   int `json:"total_prices"`
 }
+
+// StoresCarryingRequest is the request body for finding stores that carry a set of items
+type StoresCarryingRequest struct {
+	ItemIDs    []int    `json:"item_ids"`
+	RegionID   *int     `json:"region_id,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+	RadiusKm   *float64 `json:"radius_km,omitempty"`    // Requires coordinates
+	MaxAgeDays *int     `json:"max_age_days,omitempty"` // Ignore prices older than this
+	Limit      int      `json:"limit"`
+	Offset     int      `json:"offset"`
+}
+
+// StoreCoverage is a store ranked by how many of the requested items it prices
+type StoreCoverage struct {
+	StoreID        int      `json:"store_id"`
+	StoreName      string   `json:"store_name"`
+	StoreAddress   string   `json:"store_address"`
+	ItemsCovered   int      `json:"items_covered"`
+	ItemsRequested int      `json:"items_requested"`
+	Coverage       float64  `json:"coverage"`     // Fraction of requested items priced
+	BasketTotal    float64  `json:"basket_total"` // Sum of prices for covered items
+	MissingItemIDs []int    `json:"missing_item_ids,omitempty"`
+	DistanceKm     *float64 `json:"distance_km,omitempty"`
+	// DistanceKm converted to DistanceUnit and rounded for display
+	Distance     *float64     `json:"distance,omitempty"`
+	DistanceUnit DistanceUnit `json:"distance_unit,omitempty"`
+}
+
+// ItemPriceVolatility summarizes how often and how much an item's price changed at a store
+// within a window. Score is changes per week times the average change percent.
+type ItemPriceVolatility struct {
+	ItemID           int       `json:"item_id"`
+	ItemName         string    `json:"item_name"`
+	ItemBrand        *string   `json:"item_brand,omitempty"`
+	ChangeCount      int       `json:"change_count"`
+	ChangesPerWeek   float64   `json:"changes_per_week"`
+	AvgChange        float64   `json:"avg_change"`         // Average absolute change in currency units
+	AvgChangePercent float64   `json:"avg_change_percent"` // Average absolute change relative to the previous price
+	MaxChangePercent float64   `json:"max_change_percent"`
+	MinPrice         float64   `json:"min_price"`
+	MaxPrice         float64   `json:"max_price"`
+	LastPrice        float64   `json:"last_price"`
+	LastChangedAt    time.Time `json:"last_changed_at"`
+	Score            float64   `json:"score"`
+}
+
+// StoreQuality summarizes how trustworthy a store's shared prices are
+type StoreQuality struct {
+	StoreID          int     `json:"store_id"`
+	TotalPrices      int     `json:"total_prices"`
+	VerifiedPercent  float64 `json:"verified_percent"`
+	StalePercent     float64 `json:"stale_percent"`
+	AvgAgeDays       float64 `json:"avg_age_days"`
+	ContributorCount int     `json:"contributor_count"`
+	StaleAfterDays   int     `json:"stale_after_days"`
+	Score            float64 `json:"score"` // 0-100
+	Grade            string  `json:"grade"` // A-F, or N/A without prices
+}
@@ -0,0 +1,63 @@
+package models
+
+// Store data issue types, in cleanup priority order
+const (
+	StoreIssueMissingRegion      = "missing_region"      // Hidden from region views and stats
+	StoreIssueMissingCoordinates = "missing_coordinates" // Hidden from nearby search and route planning
+	StoreIssueRegionMismatch     = "region_mismatch"     // Region's state or zip codes do not cover the store address
+)
+
+// Suggested fixes for store data issues
+const (
+	StoreFixGeocode      = "geocode"       // POST /api/admin/stores/geocode-missing
+	StoreFixAssignRegion = "assign_region" // POST /api/admin/stores/assign-regions
+	StoreFixReview       = "review"        // No automatic fix; edit the store by hand
+)
+
+// Where a suggested region came from
+const (
+	RegionSuggestionZip          = "zip_code"
+	RegionSuggestionNearestStore = "nearest_store"
+)
+
+// StoreDataIssue is one entry in the store cleanup queue. A store with several
+// problems appears once per issue.
+type StoreDataIssue struct {
+	StoreID             int      `json:"store_id"`
+	StoreName           string   `json:"store_name"`
+	StreetAddress       string   `json:"street_address"`
+	City                string   `json:"city"`
+	State               string   `json:"state"`
+	ZipCode             string   `json:"zip_code"`
+	RegionID            *int     `json:"region_id,omitempty"`
+	Latitude            *float64 `json:"latitude,omitempty"`
+	Longitude           *float64 `json:"longitude,omitempty"`
+	PriceCount          int      `json:"price_count"`
+	Issue               string   `json:"issue"`
+	Priority            int      `json:"priority"` // 1 is most urgent
+	SuggestedFix        string   `json:"suggested_fix"`
+	SuggestedRegionID   *int     `json:"suggested_region_id,omitempty"`
+	SuggestedRegionName *string  `json:"suggested_region_name,omitempty"`
+	SuggestionSource    *string  `json:"suggestion_source,omitempty"`
+}
+
+// AssignStoreRegionsRequest limits a region assignment run to specific stores
+type AssignStoreRegionsRequest struct {
+	StoreIDs []int `json:"store_ids"` // Empty applies every suggestion
+	DryRun   bool  `json:"dry_run"`
+}
+
+// StoreRegionAssignment is a region change made (or proposed) for a store
+type StoreRegionAssignment struct {
+	StoreID      int    `json:"store_id"`
+	FromRegionID *int   `json:"from_region_id,omitempty"`
+	ToRegionID   int    `json:"to_region_id"`
+	Source       string `json:"source"`
+}
+
+// AssignStoreRegionsResult reports a region assignment run
+type AssignStoreRegionsResult struct {
+	DryRun      bool                    `json:"dry_run"`
+	Assigned    int                     `json:"assigned"`
+	Assignments []StoreRegionAssignment `json:"assignments"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "strings"
+
+// Store type taxonomy used for stores.store_type and user preferences
+const (
+	StoreTypeGrocery     = "grocery"
+	StoreTypeSupermarket = "supermarket"
+	StoreTypeWarehouse   = "warehouse"
+	StoreTypeSpecialty   = "specialty"
+	StoreTypeConvenience = "convenience"
+	StoreTypeDepartment  = "department"
+	StoreTypePharmacy    = "pharmacy"
+)
+
+// StoreTypes lists every valid store type
+var StoreTypes = []string{
+	StoreTypeGrocery,
+	StoreTypeSupermarket,
+	StoreTypeWarehouse,
+	StoreTypeSpecialty,
+	StoreTypeConvenience,
+	StoreTypeDepartment,
+	StoreTypePharmacy,
+}
+
+// IsValidStoreType checks if a store type is part of the taxonomy
+func IsValidStoreType(storeType string) bool {
+	for _, t := range StoreTypes {
+		if t == storeType {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeStoreTypes lowercases and deduplicates store types, returning the first invalid one if any
+func NormalizeStoreTypes(storeTypes []string) ([]string, string) {
+	seen := make(map[string]bool, len(storeTypes))
+	normalized := make([]string, 0, len(storeTypes))
+	for _, t := range storeTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !IsValidStoreType(t) {
+			return nil, t
+		}
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+	return normalized, ""
+}
@@ -33,6 +33,39 @@ type User struct {
 	Latitude      *float64 `json:"latitude,omitempty"`
 	Longitude     *float64 `json:"longitude,omitempty"`
 	GooglePlaceID *string  `json:"google_place_id,omitempty"`
+	// Privacy preferences
+	HideContributorName bool `json:"hide_contributor_name"` // Show as "community member" on public prices
+	// Discovery preferences
+	PreferredStoreTypes []string `json:"preferred_store_types"` // Default store types for nearby searches
+	// Display preferences
+	UnitSystem string `json:"unit_system"` // "metric" or "imperial"
+	// Contribution preferences, first chosen at registration
+	SharePricesByDefault bool `json:"share_prices_by_default"` // is_shared when a price omits it
+	DigestOptIn          bool `json:"digest_opt_in"`           // Region digests are only emailed when set; stored in notification_preferences
+	// Community preferences
+	LeaderboardOptOut bool `json:"leaderboard_opt_out"` // Left out of the savings leaderboard entirely
+}
+
+// AnonymousContributorName replaces a submitter's username when their identity is masked
+const AnonymousContributorName = "community member"
+
+// ContributorVisibility describes who is viewing contributed data and whether names are masked globally
+type ContributorVisibility struct {
+	ViewerID      int
+	ViewerIsAdmin bool
+	AnonymizeAll  bool // Global anonymize_contributors setting
+}
+
+// ShouldMask reports whether a submitter's username must be hidden from the viewer.
+// Admins and the submitter themselves always see the real name.
+func (v *ContributorVisibility) ShouldMask(submitterID *int, submitterHidden bool) bool {
+	if v == nil || v.ViewerIsAdmin {
+		return false
+	}
+	if submitterID != nil && *submitterID == v.ViewerID {
+		return false
+	}
+	return v.AnonymizeAll || submitterHidden
 }
 
 // UserPublic is the public-safe representation of a user
@@ -86,6 +119,9 @@ type RegisterRequest struct {
 	Latitude      *float64 `json:"latitude,omitempty"`
 	Longitude     *float64 `json:"longitude,omitempty"`
 	GooglePlaceID *string  `json:"google_place_id,omitempty"`
+	// Onboarding choices; unset values fall back to the onboarding_* settings
+	SharePricesByDefault *bool `json:"share_prices_by_default,omitempty"`
+	DigestOptIn          *bool `json:"digest_opt_in,omitempty"`
 	// Captcha token for Cloudflare Turnstile verification
 	CaptchaToken string `json:"captcha_token,omitempty"`
 }
@@ -115,6 +151,17 @@ type UpdateUserRequest struct {
 	Latitude      *float64 `json:"latitude,omitempty"`
 	Longitude     *float64 `json:"longitude,omitempty"`
 	GooglePlaceID *string  `json:"google_place_id,omitempty"`
+	// Privacy preferences
+	HideContributorName *bool `json:"hide_contributor_name,omitempty"`
+	// Discovery preferences; an empty list clears them
+	PreferredStoreTypes *[]string `json:"preferred_store_types,omitempty"`
+	// Display preferences
+	UnitSystem *string `json:"unit_system,omitempty"`
+	// Contribution preferences
+	SharePricesByDefault *bool `json:"share_prices_by_default,omitempty"`
+	DigestOptIn          *bool `json:"digest_opt_in,omitempty"`
+	// Community preferences
+	LeaderboardOptOut *bool `json:"leaderboard_opt_out,omitempty"`
 }
 
 // ChangePasswordRequest is the request body for changing password
@@ -151,6 +198,33 @@ type UserStats struct {
 	ListsCreated   int `json:"lists_created"`
 }
 
+// VerificationImpact summarizes how a user's price verifications held up against later price changes
+type VerificationImpact struct {
+	UserID           int       `json:"user_id"`
+	ReputationPoints int       `json:"reputation_points"`
+	Verifications    int       `json:"verifications"`
+	Confirmations    int       `json:"confirmations"` // voted "accurate"
+	Flags            int       `json:"flags"`         // voted "inaccurate"
+	Upheld           int       `json:"upheld"`        // later price history agreed with the vote
+	Corrected        int       `json:"corrected"`     // later price history contradicted the vote
+	Pending          int       `json:"pending"`       // too recent to judge
+	AccuracyRate     *float64  `json:"accuracy_rate,omitempty"`
+	WindowDays       int       `json:"window_days"`
+	ComputedAt       time.Time `json:"computed_at"`
+}
+
+// SavingsLeaderboardEntry ranks a user by what they saved on confirmed receipts compared with
+// the community average for the same items around the purchase date
+type SavingsLeaderboardEntry struct {
+	Rank              int     `json:"rank"`
+	UserID            *int    `json:"user_id,omitempty"`
+	Username          *string `json:"username,omitempty"`
+	EstimatedSavings  float64 `json:"estimated_savings"`
+	SavingsPercent    float64 `json:"savings_percent"` // Savings relative to the community total for the same items
+	ItemsCompared     int     `json:"items_compared"`
+	ContributorHidden bool    `json:"-"`
+}
+
 // AdminStats represents system-wide statistics
 type AdminStats struct {
 	TotalUsers     int `json:"total_users"`
@@ -161,3 +235,26 @@ type AdminStats struct {
 	TotalPrices    int `json:"total_prices"`
 	PricesToday    int `json:"prices_today"`
 }
+
+// UserRegionPref holds a user's saved store defaults for one region
+type UserRegionPref struct {
+	UserID            int       `json:"user_id"`
+	RegionID          int       `json:"region_id"`
+	RegionName        string    `json:"region_name"`
+	PreferredStoreIDs []int     `json:"preferred_store_ids"` // Stores used for shopping plans
+	CompareStoreIDs   []int     `json:"compare_store_ids"`   // Default stores for price comparisons
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UpdateUserRegionPrefRequest is the request body for saving region preferences
+type UpdateUserRegionPrefRequest struct {
+	PreferredStoreIDs []int `json:"preferred_store_ids"`
+	CompareStoreIDs   []int `json:"compare_store_ids"`
+}
+
+// UsernameCollision is a group of existing usernames that differ only by case
+type UsernameCollision struct {
+	Normalized string   `json:"normalized"`
+	UserIDs    []int    `json:"user_ids"`
+	Usernames  []string `json:"usernames"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// WhatsNewPrice is a price change shown in the what's-new digest
+type WhatsNewPrice struct {
+	StoreID       int       `json:"store_id"`
+	StoreName     string    `json:"store_name"`
+	ItemID        int       `json:"item_id"`
+	ItemName      string    `json:"item_name"`
+	Price         float64   `json:"price"`
+	PreviousPrice *float64  `json:"previous_price,omitempty"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// WhatsNewStore is a store added since the user's last visit
+type WhatsNewStore struct {
+	ID           int          `json:"id"`
+	Name         string       `json:"name"`
+	City         string       `json:"city"`
+	State        string       `json:"state"`
+	Distance     *float64     `json:"distance,omitempty"` // Set when the user has a location
+	DistanceUnit DistanceUnit `json:"distance_unit,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// WhatsNew is the consolidated home-screen digest of changes since the user last checked
+type WhatsNew struct {
+	Since           time.Time                `json:"since"`
+	SeenAt          time.Time                `json:"seen_at"`
+	FollowedPrices  []WhatsNewPrice          `json:"followed_prices"` // New prices on items from active shopping lists
+	RegionDrops     []WhatsNewPrice          `json:"region_drops"`
+	NewStores       []WhatsNewStore          `json:"new_stores"`
+	InventoryAlerts []*InventoryNotification `json:"inventory_alerts"`
+}
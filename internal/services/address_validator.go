@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// AddressValidator reverse-geocodes submitted coordinates and compares the result with the
+// submitted state and zip code, so mismatched locations can be flagged or rejected
+type AddressValidator struct {
+	db            *database.DB
+	maps          *GoogleMapsService
+	encryptionKey []byte
+}
+
+// NewAddressValidator creates a new address validator
+func NewAddressValidator(db *database.DB, cfg *config.Config) *AddressValidator {
+	return &AddressValidator{
+		db:            db,
+		maps:          NewGoogleMapsService(cfg.GoogleMapsAPIKey, 0),
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Check compares the coordinates with the supplied state and zip code. It returns nil when
+// validation is off, the coordinates are missing, or they cannot be geocoded; the check is
+// advisory and never fails a request on its own. Zip codes agree when their first
+// address_validation_zip_prefix digits match.
+func (v *AddressValidator) Check(ctx context.Context, lat, lng *float64, state, zipCode string) *models.AddressCheck {
+	mode := v.db.GetSettingString(ctx, "address_validation_mode", models.AddressValidationWarn, v.encryptionKey)
+	if mode != models.AddressValidationWarn && mode != models.AddressValidationBlock {
+		return nil
+	}
+	if lat == nil || lng == nil || (state == "" && zipCode == "") {
+		return nil
+	}
+
+	result, err := v.maps.ReverseGeocode(ctx, *lat, *lng)
+	if err != nil {
+		if err != ErrInvalidAPIKey {
+			log.Printf("Warning: Address validation could not reverse geocode %f,%f: %v", *lat, *lng, err)
+		}
+		return nil
+	}
+
+	check := &models.AddressCheck{
+		Mode:            mode,
+		GeocodedAddress: result.FormattedAddress,
+		Discrepancies:   []models.AddressDiscrepancy{},
+	}
+
+	geoState := result.Components.StateCode
+	if state != "" && geoState != "" && !strings.EqualFold(state, geoState) {
+		check.Discrepancies = append(check.Discrepancies, models.AddressDiscrepancy{
+			Field: "state", Supplied: state, Geocoded: geoState,
+		})
+	}
+
+	prefix := v.db.GetSettingInt(ctx, "address_validation_zip_prefix", 3, v.encryptionKey)
+	geoZip := result.Components.PostalCode
+	if zipCode != "" && geoZip != "" && !zipPrefixMatch(zipCode, geoZip, prefix) {
+		check.Discrepancies = append(check.Discrepancies, models.AddressDiscrepancy{
+			Field: "zip_code", Supplied: zipCode, Geocoded: geoZip,
+		})
+	}
+
+	return check
+}
+
+// zipPrefixMatch reports whether two postal codes share their first n characters, ignoring
+// case, spaces and ZIP+4 suffixes. n <= 0 disables the comparison.
+func zipPrefixMatch(a, b string, n int) bool {
+	if n <= 0 {
+		return true
+	}
+	normalize := func(z string) string {
+		z, _, _ = strings.Cut(strings.ToUpper(strings.ReplaceAll(z, " ", "")), "-")
+		return z
+	}
+	a, b = normalize(a), normalize(b)
+	if len(a) < n || len(b) < n {
+		return a == b
+	}
+	return a[:n] == b[:n]
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
+	"log"
 	"net/smtp"
 	"strings"
 
@@ -18,6 +20,21 @@ type EmailService struct {
 	encryptionKey []byte
 }
 
+// EmailCategory groups emails that may be sent with a distinct sender identity
+type EmailCategory string
+
+const (
+	EmailCategoryTransactional EmailCategory = "transactional"
+	EmailCategoryDigest        EmailCategory = "digest"
+)
+
+// EmailOptions overrides the sender identity for a single message. Empty fields keep the configured defaults.
+type EmailOptions struct {
+	FromName string
+	FromAddr string
+	ReplyTo  string
+}
+
 // NewEmailService creates a new email service instance
 func NewEmailService(db *database.DB, cfg *config.Config) *EmailService {
 	return &EmailService{
@@ -63,11 +80,21 @@ func (s *EmailService) SendEmail(to, subject, htmlBody, textBody string) error {
 		return fmt.Errorf("SMTP is not configured")
 	}
 
-	return s.sendMail(smtpCfg, []string{to}, subject, htmlBody, textBody)
+	return s.sendMail(smtpCfg, []string{to}, subject, htmlBody, textBody, s.CategoryOptions(ctx, EmailCategoryTransactional))
 }
 
 // SendEmailToMultiple sends an email to multiple recipients
 func (s *EmailService) SendEmailToMultiple(to []string, subject, htmlBody, textBody string) error {
+	return s.SendEmailWithOptions(to, subject, htmlBody, textBody, s.CategoryOptions(context.Background(), EmailCategoryTransactional))
+}
+
+// SendCategoryEmail sends an email using the sender identity configured for the category
+func (s *EmailService) SendCategoryEmail(category EmailCategory, to []string, subject, htmlBody, textBody string) error {
+	return s.SendEmailWithOptions(to, subject, htmlBody, textBody, s.CategoryOptions(context.Background(), category))
+}
+
+// SendEmailWithOptions sends an email with a per-message sender override
+func (s *EmailService) SendEmailWithOptions(to []string, subject, htmlBody, textBody string, opts *EmailOptions) error {
 	ctx := context.Background()
 	smtpCfg, err := s.getSMTPConfig(ctx)
 	if err != nil {
@@ -78,7 +105,17 @@ func (s *EmailService) SendEmailToMultiple(to []string, subject, htmlBody, textB
 		return fmt.Errorf("SMTP is not configured")
 	}
 
-	return s.sendMail(smtpCfg, to, subject, htmlBody, textBody)
+	return s.sendMail(smtpCfg, to, subject, htmlBody, textBody, opts)
+}
+
+// CategoryOptions returns the sender overrides configured for an email category
+func (s *EmailService) CategoryOptions(ctx context.Context, category EmailCategory) *EmailOptions {
+	fromName, fromAddr, replyTo := s.db.GetEmailCategorySender(ctx, string(category), s.encryptionKey)
+	return &EmailOptions{
+		FromName: fromName,
+		FromAddr: fromAddr,
+		ReplyTo:  replyTo,
+	}
 }
 
 // SendTestEmail sends a test email to verify SMTP configuration
@@ -117,9 +154,9 @@ func (s *EmailService) SendTestEmail(to string) error {
             <p>This is a test email from your PriceFeed application. If you're receiving this message, your email settings are configured properly.</p>
             <p><strong>SMTP Settings Used:</strong></p>
             <ul>
-                <li>Host: ` + smtpCfg.Host + `</li>
+                <li>Host: ` + html.EscapeString(smtpCfg.Host) + `</li>
                 <li>Port: ` + fmt.Sprintf("%d", smtpCfg.Port) + `</li>
-                <li>From: ` + smtpCfg.FromName + ` &lt;` + smtpCfg.FromAddr + `&gt;</li>
+                <li>From: ` + html.EscapeString(smtpCfg.FromName) + ` &lt;` + html.EscapeString(smtpCfg.FromAddr) + `&gt;</li>
             </ul>
             <p>You can now use email features like:</p>
             <ul>
@@ -155,7 +192,7 @@ You can now use email features like:
 
 This email was sent from PriceFeed Admin Panel`
 
-	return s.sendMail(smtpCfg, []string{to}, subject, htmlBody, textBody)
+	return s.sendMail(smtpCfg, []string{to}, subject, htmlBody, textBody, nil)
 }
 
 // SendWelcomeEmail sends a welcome email to a new user
@@ -181,7 +218,7 @@ func (s *EmailService) SendWelcomeEmail(to, username string) error {
             <h1 style="margin: 0;">Welcome to PriceFeed!</h1>
         </div>
         <div class="content">
-            <p>Hi ` + username + `,</p>
+            <p>Hi ` + html.EscapeString(username) + `,</p>
             <p>Thanks for joining PriceFeed! You're now part of a community-driven platform helping everyone find the best grocery prices.</p>
             <p>Here's what you can do:</p>
             <ul>
@@ -248,13 +285,13 @@ func (s *EmailService) SendEmailVerificationEmail(to, verifyToken string, verify
             <p>Thanks for signing up for PriceFeed! Please verify your email address to complete your registration.</p>
             <p>Click the button below to verify your email:</p>
             <p style="text-align: center;">
-                <a href="` + fullVerifyURL + `" class="btn">Verify Email</a>
+                <a href="` + html.EscapeString(fullVerifyURL) + `" class="btn">Verify Email</a>
             </p>
             <div class="info">
                 <strong>ℹ️ Note:</strong> This link will expire in 24 hours. If you didn't create an account, you can safely ignore this email.
             </div>
             <p>If the button doesn't work, copy and paste this link into your browser:</p>
-            <p style="word-break: break-all; color: #6b7280;">` + fullVerifyURL + `</p>
+            <p style="word-break: break-all; color: #6b7280;">` + html.EscapeString(fullVerifyURL) + `</p>
         </div>
         <div class="footer">
             <p>© PriceFeed - Community-driven grocery price comparison</p>
@@ -307,13 +344,13 @@ func (s *EmailService) SendPasswordResetEmail(to, resetToken string, resetURL st
             <p>You requested a password reset for your PriceFeed account.</p>
             <p>Click the button below to reset your password:</p>
             <p style="text-align: center;">
-                <a href="` + fullResetURL + `" class="btn">Reset Password</a>
+                <a href="` + html.EscapeString(fullResetURL) + `" class="btn">Reset Password</a>
             </p>
             <div class="warning">
                 <strong>⚠️ Important:</strong> This link will expire in 1 hour. If you didn't request this reset, please ignore this email.
             </div>
             <p>If the button doesn't work, copy and paste this link into your browser:</p>
-            <p style="word-break: break-all; color: #6b7280;">` + fullResetURL + `</p>
+            <p style="word-break: break-all; color: #6b7280;">` + html.EscapeString(fullResetURL) + `</p>
         </div>
         <div class="footer">
             <p>© PriceFeed - Community-driven grocery price comparison</p>
@@ -336,13 +373,40 @@ Important: This link will expire in 1 hour. If you didn't request this reset, pl
 	return s.SendEmail(to, subject, htmlBody, textBody)
 }
 
-// sendMail is the internal method that handles SMTP communication
-func (s *EmailService) sendMail(smtpCfg *database.SMTPConfig, to []string, subject, htmlBody, textBody string) error {
+// sendMail is the internal method that handles SMTP communication. It tries the primary
+// server first and, if configured, retries once on the fallback server.
+func (s *EmailService) sendMail(smtpCfg *database.SMTPConfig, to []string, subject, htmlBody, textBody string, opts *EmailOptions) error {
+	fromAddr, msg := buildMessage(smtpCfg, to, subject, htmlBody, textBody, opts)
+	return deliverWithFallback(smtpCfg, fromAddr, to, msg, s.deliver)
+}
+
+// buildMessage renders the MIME message, applying any per-message sender overrides, and
+// returns it with the From address used as the envelope sender
+func buildMessage(smtpCfg *database.SMTPConfig, to []string, subject, htmlBody, textBody string, opts *EmailOptions) (string, string) {
+	fromName, fromAddr, replyTo := smtpCfg.FromName, smtpCfg.FromAddr, smtpCfg.ReplyTo
+	if opts != nil {
+		if opts.FromName != "" {
+			fromName = opts.FromName
+		}
+		if opts.FromAddr != "" {
+			fromAddr = opts.FromAddr
+		}
+		if opts.ReplyTo != "" {
+			replyTo = opts.ReplyTo
+		}
+	}
+
+	// User-supplied values (e.g. list names in subjects) must not inject extra headers
+	fromName, replyTo, subject = headerSafe(fromName), headerSafe(replyTo), headerSafe(subject)
+
 	// Build the email headers and body
 	boundary := "boundary-pricefeed-email-12345"
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("From: %s <%s>\r\n", smtpCfg.FromName, smtpCfg.FromAddr))
+	msg.WriteString(fmt.Sprintf("From: %s <%s>\r\n", fromName, fromAddr))
+	if replyTo != "" {
+		msg.WriteString(fmt.Sprintf("Reply-To: %s\r\n", replyTo))
+	}
 	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
 	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
 	msg.WriteString("MIME-Version: 1.0\r\n")
@@ -367,6 +431,44 @@ func (s *EmailService) sendMail(smtpCfg *database.SMTPConfig, to []string, subje
 
 	msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
 
+	return fromAddr, msg.String()
+}
+
+// deliverWithFallback sends msg through the primary server and, when one is configured,
+// retries once through the fallback server
+func deliverWithFallback(smtpCfg *database.SMTPConfig, fromAddr string, to []string, msg string, deliver func(*database.SMTPConfig, string, []string, string) error) error {
+	err := deliver(smtpCfg, fromAddr, to, msg)
+	if err == nil {
+		return nil
+	}
+
+	if smtpCfg.Fallback == nil {
+		return err
+	}
+
+	log.Printf("Warning: Primary SMTP server %s:%d failed, trying fallback %s:%d: %v",
+		smtpCfg.Host, smtpCfg.Port, smtpCfg.Fallback.Host, smtpCfg.Fallback.Port, err)
+
+	if fallbackErr := deliver(smtpCfg.Fallback, fromAddr, to, msg); fallbackErr != nil {
+		log.Printf("Warning: Fallback SMTP server %s:%d failed: %v", smtpCfg.Fallback.Host, smtpCfg.Fallback.Port, fallbackErr)
+		return fmt.Errorf("primary SMTP failed: %v; fallback SMTP failed: %w", err, fallbackErr)
+	}
+
+	log.Printf("Email delivered via fallback SMTP server %s:%d", smtpCfg.Fallback.Host, smtpCfg.Fallback.Port)
+	return nil
+}
+
+// headerSafe strips line breaks so a value cannot terminate its header line
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// deliver sends a prepared message through a single SMTP server
+func (s *EmailService) deliver(server *database.SMTPConfig, fromAddr string, to []string, msg string) error {
+	// Envelope sender follows the (possibly overridden) From address
+	smtpCfg := *server
+	smtpCfg.FromAddr = fromAddr
+
 	// Connect to SMTP server
 	addr := fmt.Sprintf("%s:%d", smtpCfg.Host, smtpCfg.Port)
 
@@ -378,11 +480,11 @@ func (s *EmailService) sendMail(smtpCfg *database.SMTPConfig, to []string, subje
 
 	// For ports 465, use implicit TLS
 	if smtpCfg.Port == 465 {
-		return s.sendMailWithTLS(smtpCfg, addr, auth, to, msg.String())
+		return s.sendMailWithTLS(&smtpCfg, addr, auth, to, msg)
 	}
 
 	// For other ports (587, 25), use STARTTLS
-	return s.sendMailWithSTARTTLS(smtpCfg, addr, auth, to, msg.String())
+	return s.sendMailWithSTARTTLS(&smtpCfg, addr, auth, to, msg)
 }
 
 // sendMailWithTLS sends mail using implicit TLS (port 465)
@@ -505,7 +607,7 @@ func (s *EmailService) GetConfig() map[string]interface{} {
 		passwordMask = "••••••••"
 	}
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"host":       smtpCfg.Host,
 		"port":       smtpCfg.Port,
 		"user":       smtpCfg.User,
@@ -515,4 +617,11 @@ func (s *EmailService) GetConfig() map[string]interface{} {
 		"enabled":    smtpCfg.Enabled,
 		"configured": smtpCfg.Enabled && smtpCfg.Host != "" && smtpCfg.FromAddr != "",
 	}
+
+	if smtpCfg.Fallback != nil {
+		result["fallbackHost"] = smtpCfg.Fallback.Host
+		result["fallbackPort"] = smtpCfg.Fallback.Port
+	}
+
+	return result
 }
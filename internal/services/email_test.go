@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+func TestBuildMessageSenderOverrides(t *testing.T) {
+	cfg := &database.SMTPConfig{FromName: "PriceFeed", FromAddr: "noreply@example.com", ReplyTo: "help@example.com"}
+
+	tests := []struct {
+		name      string
+		opts      *EmailOptions
+		wantFrom  string
+		wantAddr  string
+		wantReply string
+	}{
+		{"defaults", nil, "From: PriceFeed <noreply@example.com>", "noreply@example.com", "Reply-To: help@example.com"},
+		{"empty overrides keep defaults", &EmailOptions{}, "From: PriceFeed <noreply@example.com>", "noreply@example.com", "Reply-To: help@example.com"},
+		{"category overrides", &EmailOptions{FromName: "Digest", FromAddr: "digest@example.com", ReplyTo: "digest-help@example.com"},
+			"From: Digest <digest@example.com>", "digest@example.com", "Reply-To: digest-help@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromAddr, msg := buildMessage(cfg, []string{"a@example.com"}, "Hello", "<p>hi</p>", "hi", tt.opts)
+			if fromAddr != tt.wantAddr {
+				t.Errorf("envelope sender = %q, want %q", fromAddr, tt.wantAddr)
+			}
+			if !strings.Contains(msg, tt.wantFrom+"\r\n") || !strings.Contains(msg, tt.wantReply+"\r\n") {
+				t.Errorf("message headers missing %q or %q:\n%s", tt.wantFrom, tt.wantReply, msg)
+			}
+		})
+	}
+}
+
+func TestBuildMessageStripsHeaderBreaks(t *testing.T) {
+	cfg := &database.SMTPConfig{FromName: "PriceFeed", FromAddr: "noreply@example.com"}
+	_, msg := buildMessage(cfg, []string{"a@example.com"}, "Weekly\r\nBcc: victim@example.com", "", "", nil)
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", msg)
+	}
+	if strings.Contains(msg, "Reply-To:") {
+		t.Errorf("empty reply-to was written:\n%s", msg)
+	}
+}
+
+func TestDeliverWithFallback(t *testing.T) {
+	primary := &database.SMTPConfig{Host: "primary"}
+	withFallback := &database.SMTPConfig{Host: "primary", Fallback: &database.SMTPConfig{Host: "fallback"}}
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		cfg      *database.SMTPConfig
+		failing  map[string]bool
+		wantHost []string
+		wantErr  bool
+	}{
+		{"primary succeeds", withFallback, nil, []string{"primary"}, false},
+		{"primary fails without fallback", primary, map[string]bool{"primary": true}, []string{"primary"}, true},
+		{"fallback succeeds", withFallback, map[string]bool{"primary": true}, []string{"primary", "fallback"}, false},
+		{"both fail", withFallback, map[string]bool{"primary": true, "fallback": true}, []string{"primary", "fallback"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tried []string
+			deliver := func(server *database.SMTPConfig, fromAddr string, to []string, msg string) error {
+				tried = append(tried, server.Host)
+				if fromAddr != "digest@example.com" {
+					t.Errorf("%s got envelope sender %q", server.Host, fromAddr)
+				}
+				if tt.failing[server.Host] {
+					return errDown
+				}
+				return nil
+			}
+
+			err := deliverWithFallback(tt.cfg, "digest@example.com", []string{"a@example.com"}, "msg", deliver)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errDown) {
+				t.Errorf("error %v does not wrap the delivery error", err)
+			}
+			if strings.Join(tried, ",") != strings.Join(tt.wantHost, ",") {
+				t.Errorf("tried %v, want %v", tried, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestHeaderSafe(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Weekly list", "Weekly list"},
+		{"Weekly\r\nBcc: victim@example.com", "Weekly  Bcc: victim@example.com"},
+		{"a\nb\rc", "a b c"},
+	}
+
+	for _, tt := range tests {
+		if got := headerSafe(tt.in); got != tt.want {
+			t.Errorf("headerSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -31,8 +31,9 @@ var (
 
 // GoogleMapsService provides methods to interact with Google Maps APIs
 type GoogleMapsService struct {
-	apiKey     string
-	httpClient *http.Client
+	apiKey       string
+	httpClient   *http.Client
+	dedupeRadius int // meters; 0 disables clustering of nearby results
 }
 
 // GeocodingResult represents the result of a geocoding operation
@@ -68,6 +69,9 @@ type PlaceResult struct {
 	UserRatingsTotal int      `json:"user_ratings_total,omitempty"`
 	OpenNow          *bool    `json:"open_now,omitempty"`
 	PriceLevel       *int     `json:"price_level,omitempty"`
+
+	// Duplicates holds near-duplicate places collapsed into this one by ClusterPlaces
+	Duplicates []*PlaceResult `json:"duplicates,omitempty"`
 }
 
 // PlaceDetails represents detailed information about a place
@@ -169,12 +173,14 @@ type placeDetailsResponse struct {
 }
 
 // NewGoogleMapsService creates a new GoogleMapsService instance
-func NewGoogleMapsService(apiKey string) *GoogleMapsService {
+// dedupeRadius is the distance in meters within which similarly named nearby places are collapsed
+func NewGoogleMapsService(apiKey string, dedupeRadius int) *GoogleMapsService {
 	return &GoogleMapsService{
 		apiKey: apiKey,
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		dedupeRadius: dedupeRadius,
 	}
 }
 
@@ -289,7 +295,52 @@ func (s *GoogleMapsService) NearbySearch(ctx context.Context, lat, lng float64,
 		placeType = "supermarket"
 	}
 
-	return s.nearbySearchSingleType(ctx, lat, lng, radius, placeType)
+	places, err := s.nearbySearchSingleType(ctx, lat, lng, radius, placeType)
+	if err != nil {
+		return nil, err
+	}
+
+	return ClusterPlaces(places, s.dedupeRadius), nil
+}
+
+// storeTypePlaceTypes maps the store type taxonomy to legacy Places API types
+var storeTypePlaceTypes = map[string][]string{
+	"grocery":     {"supermarket"},
+	"supermarket": {"supermarket"},
+	"warehouse":   {"department_store", "store"},
+	"specialty":   {"store"},
+	"convenience": {"convenience_store"},
+	"department":  {"department_store"},
+	"pharmacy":    {"pharmacy", "drugstore"},
+}
+
+// NearbySearchStoreTypes searches for places matching any of the given store types.
+// An empty list falls back to the default supermarket search.
+func (s *GoogleMapsService) NearbySearchStoreTypes(ctx context.Context, lat, lng float64, radius int, storeTypes []string) ([]*PlaceResult, error) {
+	if s.apiKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if radius <= 0 {
+		radius = defaultSearchRadius
+	}
+
+	seen := make(map[string]bool)
+	var placeTypes []string
+	for _, storeType := range storeTypes {
+		for _, pType := range storeTypePlaceTypes[storeType] {
+			if !seen[pType] {
+				seen[pType] = true
+				placeTypes = append(placeTypes, pType)
+			}
+		}
+	}
+
+	if len(placeTypes) == 0 {
+		return s.NearbySearch(ctx, lat, lng, radius, "")
+	}
+
+	return s.nearbySearchMultipleTypes(ctx, lat, lng, radius, placeTypes)
 }
 
 // nearbySearchMultipleTypes searches for multiple place types and deduplicates results
@@ -312,7 +363,8 @@ func (s *GoogleMapsService) nearbySearchMultipleTypes(ctx context.Context, lat,
 		}
 	}
 
-	return allPlaces, nil
+	// The same store can show up under different place IDs (e.g. a chain's gas station)
+	return ClusterPlaces(allPlaces, s.dedupeRadius), nil
 }
 
 // nearbySearchSingleType performs a nearby search for a single place type
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// InventoryNotifier generates expiry and low-stock notifications for users' inventories
+type InventoryNotifier struct {
+	db            *database.DB
+	email         *EmailService
+	encryptionKey []byte
+}
+
+// NewInventoryNotifier creates a new inventory notifier
+func NewInventoryNotifier(db *database.DB, cfg *config.Config, email *EmailService) *InventoryNotifier {
+	return &InventoryNotifier{
+		db:            db,
+		email:         email,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run generates notifications for every user with inventory alerts turned on and returns how
+// many were created
+func (n *InventoryNotifier) Run(ctx context.Context) (int, error) {
+	if !n.db.GetSettingBool(ctx, "inventory_notifications_enabled", true, n.encryptionKey) {
+		return 0, nil
+	}
+
+	windowDays := n.db.GetSettingInt(ctx, "inventory_expiry_window_days", 7, n.encryptionKey)
+	if windowDays < 1 {
+		windowDays = 1
+	}
+	sendEmail := n.db.GetSettingBool(ctx, "inventory_notification_email_enabled", false, n.encryptionKey)
+
+	userIDs, err := n.db.ListUsersWithInventory(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	allPrefs, err := n.db.ListNotificationPreferences(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, userID := range userIDs {
+		prefs, ok := allPrefs[userID]
+		if !ok || !prefs.InventoryAlerts {
+			continue
+		}
+
+		notifications, err := n.generateForUser(ctx, userID, windowDays)
+		if err != nil {
+			log.Printf("Warning: Failed to generate inventory notifications for user %d: %v", userID, err)
+			continue
+		}
+		created += len(notifications)
+
+		if sendEmail && prefs.InventoryEmail && len(notifications) > 0 {
+			if err := n.sendSummary(ctx, userID, notifications); err != nil {
+				log.Printf("Warning: Failed to send inventory summary to user %d: %v", userID, err)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// generateForUser creates notifications for newly expiring or low-stock items and clears resolved ones
+func (n *InventoryNotifier) generateForUser(ctx context.Context, userID, windowDays int) ([]*models.InventoryNotification, error) {
+	var created []*models.InventoryNotification
+
+	expiring, err := n.db.GetExpiringItems(ctx, userID, windowDays)
+	if err != nil {
+		return nil, err
+	}
+	expiringIDs := make([]int, 0, len(expiring))
+	for _, item := range expiring {
+		expiringIDs = append(expiringIDs, item.ID)
+		notification, err := n.db.CreateInventoryNotification(ctx, userID, item.ID, models.InventoryNotificationExpiring, expiryMessage(item))
+		if err != nil {
+			return nil, err
+		}
+		if notification != nil {
+			created = append(created, notification)
+		}
+	}
+	if err := n.db.ClearResolvedInventoryNotifications(ctx, userID, models.InventoryNotificationExpiring, expiringIDs); err != nil {
+		return nil, err
+	}
+
+	lowStock, err := n.db.GetLowStockItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lowStockIDs := make([]int, 0, len(lowStock))
+	for _, item := range lowStock {
+		lowStockIDs = append(lowStockIDs, item.ID)
+		message := fmt.Sprintf("%s is running low (%g left)", item.DisplayName, item.Quantity)
+		notification, err := n.db.CreateInventoryNotification(ctx, userID, item.ID, models.InventoryNotificationLowStock, message)
+		if err != nil {
+			return nil, err
+		}
+		if notification != nil {
+			created = append(created, notification)
+		}
+	}
+	if err := n.db.ClearResolvedInventoryNotifications(ctx, userID, models.InventoryNotificationLowStock, lowStockIDs); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// expiryMessage describes how soon an inventory item expires
+func expiryMessage(item *models.InventoryItemWithDetails) string {
+	if item.DaysUntilExpiry == nil {
+		return fmt.Sprintf("%s is expiring soon", item.DisplayName)
+	}
+	switch days := *item.DaysUntilExpiry; {
+	case days < 0:
+		return fmt.Sprintf("%s has expired", item.DisplayName)
+	case days == 0:
+		return fmt.Sprintf("%s expires today", item.DisplayName)
+	case days == 1:
+		return fmt.Sprintf("%s expires tomorrow", item.DisplayName)
+	default:
+		return fmt.Sprintf("%s expires in %d days", item.DisplayName, days)
+	}
+}
+
+// sendSummary emails the user a digest of their new inventory notifications
+func (n *InventoryNotifier) sendSummary(ctx context.Context, userID int, notifications []*models.InventoryNotification) error {
+	if !n.email.IsConfiguredWithContext(ctx) {
+		return nil
+	}
+
+	user, err := n.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var text, body strings.Builder
+	text.WriteString("Here is your daily pantry summary:\n\n")
+	body.WriteString("<p>Here is your daily pantry summary:</p><ul>")
+	for _, notification := range notifications {
+		text.WriteString("- " + notification.Message + "\n")
+		body.WriteString("<li>" + html.EscapeString(notification.Message) + "</li>")
+	}
+	body.WriteString("</ul>")
+
+	subject := fmt.Sprintf("%d pantry item(s) need your attention", len(notifications))
+	return n.email.SendCategoryEmail(EmailCategoryDigest, []string{user.Email}, subject, body.String(), text.String())
+}
+
+// Start runs the notifier immediately and then on every interval until ctx is cancelled
+func (n *InventoryNotifier) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		created, err := n.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Inventory notification run failed: %v", err)
+		} else if created > 0 {
+			log.Printf("Created %d inventory notification(s)", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -53,6 +53,17 @@ func (m *ItemMatcher) MatchReceiptItems(ctx context.Context, items []models.Pars
 			continue
 		}
 
+		// An exact barcode match beats any name similarity
+		if barcodeMatch := m.matchBarcode(ctx, item.Barcode); barcodeMatch != nil {
+			ranked := []models.MatchResult{*barcodeMatch}
+			for _, suggestion := range suggestions {
+				if suggestion.ItemID != barcodeMatch.ItemID {
+					ranked = append(ranked, suggestion)
+				}
+			}
+			suggestions = ranked
+		}
+
 		matched.Suggestions = suggestions
 
 		// Use the best match if confidence is high enough
@@ -66,6 +77,75 @@ func (m *ItemMatcher) MatchReceiptItems(ctx context.Context, items []models.Pars
 	return results, nil
 }
 
+// matchBarcode returns a full-confidence match for the public catalog item with the barcode, or nil
+func (m *ItemMatcher) matchBarcode(ctx context.Context, barcode string) *models.MatchResult {
+	if barcode == "" {
+		return nil
+	}
+
+	item, err := m.db.GetItemByBarcode(ctx, barcode, nil)
+	if err != nil || item.ArchivedAt != nil {
+		return nil
+	}
+
+	return &models.MatchResult{
+		ItemID:     item.ID,
+		Name:       item.Name,
+		Brand:      item.Brand,
+		Confidence: 1,
+		MatchType:  "barcode",
+	}
+}
+
+// RematchReceiptItems re-runs matching against the current catalog for unconfirmed items of
+// one receipt (or of all unconfirmed receipts when receiptID is nil, up to limit items).
+// A stored match is only replaced when the new one is more confident.
+func (m *ItemMatcher) RematchReceiptItems(ctx context.Context, receiptID *int, limit int) (*models.RematchResult, error) {
+	candidates, err := m.db.ListRematchCandidates(ctx, receiptID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]models.ParsedItem, len(candidates))
+	for i, item := range candidates {
+		name := item.RawText
+		if item.ExtractedName != nil && *item.ExtractedName != "" {
+			name = *item.ExtractedName
+		}
+		parsed[i] = models.ParsedItem{RawText: item.RawText, Name: name, Quantity: item.ExtractedQuantity, Barcode: ExtractBarcode(item.RawText)}
+	}
+
+	matched, err := m.MatchReceiptItems(ctx, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RematchResult{ItemsChecked: len(candidates)}
+	receipts := make(map[int]bool)
+	for i, item := range candidates {
+		receipts[item.ReceiptID] = true
+
+		best := matched[i].BestMatch
+		if best == nil {
+			continue
+		}
+		if item.MatchConfidence != nil && best.Confidence <= *item.MatchConfidence {
+			continue
+		}
+
+		updated, err := m.db.UpdateReceiptItemMatch(ctx, item.ID, best.ItemID, best.Confidence)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			result.Improved++
+		}
+	}
+	result.ReceiptsChecked = len(receipts)
+
+	return result, nil
+}
+
 // normalizeItemName cleans up an item name for better matching
 func (m *ItemMatcher) normalizeItemName(name string) string {
 	name = strings.ToLower(name)
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// weightCodePattern matches receipt size and weight codes such as "16OZ", "1.5LB" or "12CT"
+var weightCodePattern = regexp.MustCompile(`(?i)^\d+(\.\d+)?(oz|fz|floz|lb|lbs|g|kg|ml|l|ct|pk)$`)
+
+// productCodePattern matches bare PLU and UPC numbers printed next to item names
+var productCodePattern = regexp.MustCompile(`^\d{4,}$`)
+
+// NormalizeItemName turns raw receipt text such as "GV 2% MILK GAL" into a catalog name
+// ("Great Value 2% Milk Gallon"). Each word is looked up in replacements (lowercase keys;
+// an empty value drops the word), size and weight codes and product numbers are stripped,
+// and the remaining words are title-cased. Words containing digits are kept as printed.
+func NormalizeItemName(raw string, replacements map[string]string) string {
+	var words []string
+	for _, word := range strings.Fields(raw) {
+		if weightCodePattern.MatchString(word) || productCodePattern.MatchString(word) {
+			continue
+		}
+
+		if replacement, ok := replacements[strings.ToLower(word)]; ok {
+			if replacement != "" {
+				words = append(words, replacement)
+			}
+			continue
+		}
+
+		words = append(words, titleCaseWord(word))
+	}
+
+	name := strings.Join(words, " ")
+	if name == "" {
+		// Never reduce a name to nothing; fall back to the text as printed
+		return strings.Join(strings.Fields(raw), " ")
+	}
+	return name
+}
+
+// NormalizeNewItemName normalizes the name of an item being created from a receipt line, but
+// only while the name is still the receipt text as printed; a name the user typed is kept.
+func NormalizeNewItemName(name, rawText string, replacements map[string]string) string {
+	if strings.Join(strings.Fields(name), " ") != strings.Join(strings.Fields(rawText), " ") {
+		return name
+	}
+	return NormalizeItemName(name, replacements)
+}
+
+// titleCaseWord capitalizes the first letter of a word and lowercases the rest, leaving words
+// with digits (2%, 4x6) alone
+func titleCaseWord(word string) string {
+	if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+		return word
+	}
+
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,59 @@
+package services
+
+import "testing"
+
+func TestNormalizeItemName(t *testing.T) {
+	replacements := map[string]string{
+		"gv":  "Great Value",
+		"gal": "Gallon",
+		"ea":  "",
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"replacements and title case", "GV 2% MILK GAL", "Great Value 2% Milk Gallon"},
+		{"weight code stripped", "CHEDDAR CHEESE 16OZ", "Cheddar Cheese"},
+		{"decimal weight code stripped", "GROUND BEEF 1.5LB", "Ground Beef"},
+		{"product number stripped", "4011 BANANAS", "Bananas"},
+		{"empty replacement drops word", "AVOCADO EA", "Avocado"},
+		{"digits kept as printed", "PAPER TOWELS 6x2", "Paper Towels 6x2"},
+		{"whitespace collapsed", "  BREAD   WHITE ", "Bread White"},
+		{"never reduced to nothing", "4011  16OZ", "4011 16OZ"},
+		{"unicode", "ÉCLAIR CHOCOLAT", "Éclair Chocolat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeItemName(tt.raw, replacements); got != tt.want {
+				t.Errorf("NormalizeItemName(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeNewItemName(t *testing.T) {
+	replacements := map[string]string{"gv": "Great Value"}
+
+	tests := []struct {
+		name    string
+		item    string
+		rawText string
+		want    string
+	}{
+		{"receipt text normalized", "GV MILK", "GV MILK", "Great Value Milk"},
+		{"receipt text with extra spacing normalized", "GV  MILK ", "GV MILK", "Great Value Milk"},
+		{"edited name kept", "gv milk for baking", "GV MILK", "gv milk for baking"},
+		{"unknown receipt line kept", "GV MILK", "", "GV MILK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeNewItemName(tt.item, tt.rawText, replacements); got != tt.want {
+				t.Errorf("NormalizeNewItemName(%q, %q) = %q, want %q", tt.item, tt.rawText, got, tt.want)
+			}
+		})
+	}
+}
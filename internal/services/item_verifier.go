@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+// ItemVerifier periodically verifies items that show up on confirmed receipts from many users
+type ItemVerifier struct {
+	db            *database.DB
+	encryptionKey []byte
+}
+
+// NewItemVerifier creates a new item verifier
+func NewItemVerifier(db *database.DB, cfg *config.Config) *ItemVerifier {
+	return &ItemVerifier{
+		db:            db,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run performs a single verification pass using the current settings
+func (v *ItemVerifier) Run(ctx context.Context) (int, error) {
+	if !v.db.GetSettingBool(ctx, "item_auto_verify_enabled", true, v.encryptionKey) {
+		return 0, nil
+	}
+
+	minUsers := v.db.GetSettingInt(ctx, "item_auto_verify_min_users", 3, v.encryptionKey)
+	if minUsers < 2 {
+		// A single user must never be able to verify an item on their own
+		minUsers = 2
+	}
+
+	return v.db.AutoVerifyItemsFromReceipts(ctx, minUsers)
+}
+
+// Start runs the verifier immediately and then on every interval until ctx is cancelled
+func (v *ItemVerifier) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		verified, err := v.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Item auto-verification failed: %v", err)
+		} else if verified > 0 {
+			log.Printf("Auto-verified %d item(s) from receipt confirmations", verified)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
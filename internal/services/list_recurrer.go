@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+// ListRecurrer periodically regenerates completed recurring shopping lists
+type ListRecurrer struct {
+	db *database.DB
+}
+
+// NewListRecurrer creates a new list recurrer
+func NewListRecurrer(db *database.DB) *ListRecurrer {
+	return &ListRecurrer{db: db}
+}
+
+// Run creates a fresh active copy of every completed recurring list that is due and
+// moves the recurrence schedule onto the copy
+func (r *ListRecurrer) Run(ctx context.Context) (int, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	lists, err := r.db.ListDueRecurringLists(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, list := range lists {
+		copied, err := r.db.RegenerateRecurringList(ctx, list.ID, today)
+		if err != nil {
+			log.Printf("Warning: Failed to regenerate recurring list %d: %v", list.ID, err)
+			continue
+		}
+		if copied != nil {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// Start runs the recurrer immediately and then on every interval until ctx is cancelled
+func (r *ListRecurrer) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		created, err := r.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Recurring list regeneration failed: %v", err)
+		} else if created > 0 {
+			log.Printf("Regenerated %d recurring shopping list(s)", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// ListWatcher replans watched shopping lists when their prices change and emails users about savings
+type ListWatcher struct {
+	db            *database.DB
+	email         *EmailService
+	encryptionKey []byte
+}
+
+// NewListWatcher creates a new list watcher
+func NewListWatcher(db *database.DB, cfg *config.Config, email *EmailService) *ListWatcher {
+	return &ListWatcher{
+		db:            db,
+		email:         email,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run replans every watched list with changed prices and returns how many users were notified
+func (w *ListWatcher) Run(ctx context.Context) (int, error) {
+	if !w.db.GetSettingBool(ctx, "list_watch_enabled", true, w.encryptionKey) {
+		return 0, nil
+	}
+	minSavings := w.db.GetSettingFloat(ctx, "list_watch_min_savings", 1.00, w.encryptionKey)
+
+	watches, err := w.db.GetListWatchesWithPriceChanges(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	userIDs := make([]int, 0, len(watches))
+	for _, watch := range watches {
+		userIDs = append(userIDs, watch.UserID)
+	}
+	prefs, err := w.db.ListNotificationPreferences(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, watch := range watches {
+		plan, err := w.db.BuildShoppingPlan(ctx, watch.ListID, watch.UserID, nil, false, nil)
+		if err != nil {
+			// Empty or deleted lists: mark checked so they are not retried every run
+			if updateErr := w.db.UpdateListWatchCost(ctx, watch.ListID, watch.LastPlanCost, false); updateErr != nil {
+				log.Printf("Warning: Failed to update watch for list %d: %v", watch.ListID, updateErr)
+			}
+			continue
+		}
+
+		var newCost *float64
+		if plan.PlanTotal > 0 {
+			newCost = &plan.PlanTotal
+		}
+
+		sent := false
+		if newCost != nil && watch.LastPlanCost != nil && *watch.LastPlanCost-*newCost >= minSavings && wantsListWatchEmail(prefs, watch.UserID) {
+			if err := w.notify(ctx, watch, plan); err != nil {
+				log.Printf("Warning: Failed to send replan notification for list %d: %v", watch.ListID, err)
+			} else {
+				sent = true
+				notified++
+			}
+		}
+
+		if err := w.db.UpdateListWatchCost(ctx, watch.ListID, newCost, sent); err != nil {
+			log.Printf("Warning: Failed to update watch for list %d: %v", watch.ListID, err)
+		}
+	}
+
+	return notified, nil
+}
+
+// wantsListWatchEmail reports whether the list owner keeps list watch emails turned on
+func wantsListWatchEmail(prefs map[int]*models.NotificationPreferences, userID int) bool {
+	p, ok := prefs[userID]
+	return ok && p.ListWatchEmail
+}
+
+// notify emails the list owner about the cheaper plan
+func (w *ListWatcher) notify(ctx context.Context, watch *models.ListWatch, plan *models.ShoppingPlanResult) error {
+	if !w.email.IsConfiguredWithContext(ctx) {
+		return fmt.Errorf("email service is not configured")
+	}
+
+	user, err := w.db.GetUserByID(ctx, watch.UserID)
+	if err != nil {
+		return err
+	}
+	list, err := w.db.GetShoppingListByID(ctx, watch.ListID, watch.UserID)
+	if err != nil {
+		return err
+	}
+
+	previous := *watch.LastPlanCost
+	savings := previous - plan.PlanTotal
+
+	subject := fmt.Sprintf("Prices dropped on your list %q", list.Name)
+	textBody := fmt.Sprintf("Good news! The best plan for your shopping list \"%s\" now costs $%.2f, down from $%.2f (you save $%.2f).\n\nOpen PriceFeed to see the updated plan.",
+		list.Name, plan.PlanTotal, previous, savings)
+	htmlBody := fmt.Sprintf(`<p>Good news! The best plan for your shopping list <strong>%s</strong> now costs <strong>$%.2f</strong>, down from $%.2f.</p>
+<p>You save <strong>$%.2f</strong>. Open PriceFeed to see the updated plan.</p>`,
+		html.EscapeString(list.Name), plan.PlanTotal, previous, savings)
+
+	return w.email.SendCategoryEmail(EmailCategoryDigest, []string{user.Email}, subject, htmlBody, textBody)
+}
+
+// Start runs the watcher immediately and then on every interval until ctx is cancelled
+func (w *ListWatcher) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		notified, err := w.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: List watch replanning failed: %v", err)
+		} else if notified > 0 {
+			log.Printf("Sent %d list savings notification(s)", notified)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,7 +1,46 @@
 package services
 
+import (
+	"strings"
+)
+
 // OCRResult contains the OCR processing result
 type OCRResult struct {
 	Text       string
 	Confidence int
+	// Recognized text lines with their confidence, when the engine reports them
+	Lines []OCRLine
+}
+
+// OCRLine is a single recognized text line
+type OCRLine struct {
+	Text       string
+	Confidence float64 // 0-100
+}
+
+// LineConfidence returns the confidence of the recognized line that produced line, or nil
+// when the engine reported no line confidences or no line matches.
+func (r *OCRResult) LineConfidence(line string) *float64 {
+	want := normalizeOCRLine(line)
+	if want == "" {
+		return nil
+	}
+
+	// Parsed lines are cleaned versions of the OCR lines, so fall back to containment
+	var partial *float64
+	for i := range r.Lines {
+		got := normalizeOCRLine(r.Lines[i].Text)
+		if got == want {
+			return &r.Lines[i].Confidence
+		}
+		if partial == nil && got != "" && strings.Contains(got, want) {
+			partial = &r.Lines[i].Confidence
+		}
+	}
+	return partial
+}
+
+// normalizeOCRLine lowercases a line and collapses its whitespace for comparison
+func normalizeOCRLine(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
 }
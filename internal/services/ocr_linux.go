@@ -4,6 +4,8 @@ package services
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"os"
 	"path/filepath"
 
@@ -83,10 +85,25 @@ func (s *OCRService) ProcessImageFromPath(imagePath string) (*OCRResult, error)
 		return nil, fmt.Errorf("failed to extract text: %w", err)
 	}
 
-	return &OCRResult{
-		Text:       text,
-		Confidence: 0, // gosseract doesn't expose confidence directly in simple mode
-	}, nil
+	result := &OCRResult{Text: text}
+
+	// Per-line confidences let callers flag lines that were hard to read
+	boxes, err := s.client.GetBoundingBoxes(gosseract.RIL_TEXTLINE)
+	if err != nil {
+		log.Printf("Warning: Failed to get OCR line confidences: %v", err)
+		return result, nil
+	}
+
+	var total float64
+	for _, box := range boxes {
+		result.Lines = append(result.Lines, OCRLine{Text: box.Word, Confidence: box.Confidence})
+		total += box.Confidence
+	}
+	if len(boxes) > 0 {
+		result.Confidence = int(math.Round(total / float64(len(boxes))))
+	}
+
+	return result, nil
 }
 
 // Close releases OCR resources
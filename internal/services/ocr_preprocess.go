@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register JPEG decoding for receipt uploads
+	"image/png"
+	"math"
+	"strings"
+)
+
+// Labels recorded on a receipt when OCR preprocessing did not change the image
+const (
+	OCRPreprocessNone    = "none"    // Preprocessing disabled, or the image format could not be decoded
+	OCRPreprocessClean   = "clean"   // Fast path: the image already had enough contrast
+	OCRPreprocessHoldout = "holdout" // Would have been processed but was held out to measure the effect
+)
+
+// MaxImagePixels caps the width × height of an uploaded image. A small, highly compressed file
+// can declare enormous dimensions, and decoding it would allocate the full bitmap.
+const MaxImagePixels = 50_000_000
+
+// ErrImageTooLarge is returned for images with more than MaxImagePixels pixels
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// CheckImageDimensions reads only the image header and rejects images over MaxImagePixels.
+// Formats the standard library cannot read (e.g. WebP) are not checked.
+func CheckImageDimensions(imageBytes []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// Preprocessing steps recorded on a receipt
+const (
+	OCRStepGrayscale = "grayscale"
+	OCRStepContrast  = "contrast"
+	OCRStepDeskew    = "deskew"
+)
+
+// Tuning for the preprocessing pipeline
+const (
+	ocrHistogramSamples  = 500000 // Pixels sampled when measuring image quality
+	ocrContrastClipRatio = 0.01   // Fraction of darkest and brightest pixels ignored by the contrast stretch
+	ocrDeskewMaxWidth    = 800    // Width the image is scaled down to when estimating skew
+	ocrDeskewStep        = 0.25   // Degrees between candidate skew angles
+	ocrDeskewMinAngle    = 0.5    // Smaller estimated skew is left alone
+)
+
+// OCRPreprocessOptions selects which preprocessing steps run before OCR.
+// Contrast normalization and deskew work on the grayscale image, so enabling
+// either of them also converts the image to grayscale.
+type OCRPreprocessOptions struct {
+	Grayscale      bool
+	Contrast       bool
+	Deskew         bool
+	MaxSkewDegrees float64
+	// CleanContrast is the luminance spread (0-1) at or above which an image is
+	// considered clean and passed through untouched
+	CleanContrast float64
+	// Holdout passes images that need processing through untouched so their
+	// match rate can be compared with processed ones
+	Holdout bool
+}
+
+// OCRPreprocessResult is the image to run OCR on and what was done to it
+type OCRPreprocessResult struct {
+	Image  []byte
+	Steps  []string
+	Spread float64 // Luminance spread of the original image, 0-1
+}
+
+// Label returns the applied steps as stored on the receipt, e.g. "grayscale,contrast,deskew(-1.50)"
+func (r *OCRPreprocessResult) Label() string {
+	if len(r.Steps) == 0 {
+		return OCRPreprocessNone
+	}
+	return strings.Join(r.Steps, ",")
+}
+
+// PreprocessReceiptImage prepares a receipt photo for OCR. Images that already have
+// enough contrast are returned unchanged without decoding more than a sample of pixels;
+// others are converted to grayscale, contrast-stretched and optionally deskewed, and
+// re-encoded as PNG. Formats the standard library cannot decode (e.g. WebP) return an error
+// and should be passed to OCR as-is.
+func PreprocessReceiptImage(imageBytes []byte, opts OCRPreprocessOptions) (*OCRPreprocessResult, error) {
+	result := &OCRPreprocessResult{Image: imageBytes}
+	if !opts.Grayscale && !opts.Contrast && !opts.Deskew {
+		return result, nil
+	}
+
+	if err := CheckImageDimensions(imageBytes); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	lo, hi := luminanceRange(sampleLuminanceHistogram(img))
+	result.Spread = float64(hi-lo) / 255
+	if result.Spread >= opts.CleanContrast {
+		result.Steps = []string{OCRPreprocessClean}
+		return result, nil
+	}
+	if opts.Holdout {
+		result.Steps = []string{OCRPreprocessHoldout}
+		return result, nil
+	}
+
+	gray := toGray(img)
+	result.Steps = append(result.Steps, OCRStepGrayscale)
+
+	if opts.Contrast && hi > lo {
+		stretchContrast(gray, lo, hi)
+		result.Steps = append(result.Steps, OCRStepContrast)
+	}
+
+	if opts.Deskew && opts.MaxSkewDegrees > 0 {
+		if angle := estimateSkew(gray, opts.MaxSkewDegrees); math.Abs(angle) >= ocrDeskewMinAngle {
+			gray = rotateGray(gray, angle)
+			result.Steps = append(result.Steps, fmt.Sprintf("%s(%.2f)", OCRStepDeskew, angle))
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, gray); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	result.Image = buf.Bytes()
+
+	return result, nil
+}
+
+// sampleLuminanceHistogram builds a luminance histogram from an evenly spaced sample of pixels
+func sampleLuminanceHistogram(img image.Image) [256]int {
+	var hist [256]int
+	b := img.Bounds()
+	step := 1
+	if pixels := b.Dx() * b.Dy(); pixels > ocrHistogramSamples {
+		step = int(math.Ceil(math.Sqrt(float64(pixels) / ocrHistogramSamples)))
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			hist[luminanceAt(img, x, y)]++
+		}
+	}
+	return hist
+}
+
+// luminanceAt reads one pixel's luminance, using the Y plane directly for JPEGs
+func luminanceAt(img image.Image, x, y int) uint8 {
+	if ycc, ok := img.(*image.YCbCr); ok {
+		return ycc.Y[ycc.YOffset(x, y)]
+	}
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+}
+
+// luminanceRange returns the luminance levels below and above which the clip ratio of pixels fall
+func luminanceRange(hist [256]int) (lo, hi int) {
+	total := 0
+	for _, n := range hist {
+		total += n
+	}
+	clip := int(float64(total) * ocrContrastClipRatio)
+
+	seen := 0
+	for lo = 0; lo < 255; lo++ {
+		seen += hist[lo]
+		if seen > clip {
+			break
+		}
+	}
+	seen = 0
+	for hi = 255; hi > 0; hi-- {
+		seen += hist[hi]
+		if seen > clip {
+			break
+		}
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// toGray converts an image to 8-bit grayscale with its origin at 0,0
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		row := gray.Pix[y*gray.Stride : y*gray.Stride+b.Dx()]
+		for x := range row {
+			row[x] = luminanceAt(img, b.Min.X+x, b.Min.Y+y)
+		}
+	}
+	return gray
+}
+
+// stretchContrast maps the lo-hi luminance range onto the full 0-255 range in place
+func stretchContrast(gray *image.Gray, lo, hi int) {
+	var lut [256]uint8
+	for v := range lut {
+		scaled := (v - lo) * 255 / (hi - lo)
+		lut[v] = uint8(max(0, min(255, scaled)))
+	}
+	for i, v := range gray.Pix {
+		gray.Pix[i] = lut[v]
+	}
+}
+
+// estimateSkew finds the text line angle in degrees by projecting dark pixels of a
+// downscaled copy onto rows at each candidate angle; aligned text lines give the
+// sharpest row profile
+func estimateSkew(gray *image.Gray, maxDegrees float64) float64 {
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	scale := 1
+	if w > ocrDeskewMaxWidth {
+		scale = (w + ocrDeskewMaxWidth - 1) / ocrDeskewMaxWidth
+	}
+
+	// Dark pixels relative to the image center, from the downscaled grid
+	type point struct{ x, y float64 }
+	var dark []point
+	cx, cy := float64(w)/float64(2*scale), float64(h)/float64(2*scale)
+	for y := 0; y < h; y += scale {
+		for x := 0; x < w; x += scale {
+			if gray.Pix[y*gray.Stride+x] < 128 {
+				dark = append(dark, point{float64(x/scale) - cx, float64(y/scale) - cy})
+			}
+		}
+	}
+	if len(dark) == 0 {
+		return 0
+	}
+
+	diag := int(math.Hypot(cx, cy)) + 1
+	rows := make([]int, 2*diag+1)
+	best, bestScore := 0.0, -1.0
+	for angle := -maxDegrees; angle <= maxDegrees+1e-9; angle += ocrDeskewStep {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		for i := range rows {
+			rows[i] = 0
+		}
+		for _, p := range dark {
+			rows[int(math.Round(p.y*cos-p.x*sin))+diag]++
+		}
+		score := 0.0
+		for _, n := range rows {
+			score += float64(n) * float64(n)
+		}
+		if score > bestScore || (score == bestScore && math.Abs(angle) < math.Abs(best)) {
+			best, bestScore = angle, score
+		}
+	}
+	return best
+}
+
+// rotateGray rotates the image about its center so lines at the given angle become
+// horizontal, keeping the original size and filling uncovered corners with white
+func rotateGray(gray *image.Gray, degrees float64) *image.Gray {
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	cx, cy := float64(w-1)/2, float64(h-1)/2
+
+	for y := 0; y < h; y++ {
+		dy := float64(y) - cy
+		for x := 0; x < w; x++ {
+			dx := float64(x) - cx
+			sx := cx + dx*cos - dy*sin
+			sy := cy + dx*sin + dy*cos
+			out.Pix[y*out.Stride+x] = bilinearGray(gray, sx, sy)
+		}
+	}
+	return out
+}
+
+// bilinearGray samples the image at a fractional position, treating outside pixels as white
+func bilinearGray(gray *image.Gray, x, y float64) uint8 {
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	at := func(px, py int) float64 {
+		if px < 0 || py < 0 || px >= w || py >= h {
+			return 255
+		}
+		return float64(gray.Pix[py*gray.Stride+px])
+	}
+
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// pngHeader returns the signature and IHDR chunk of a PNG declaring the given size. It is
+// enough for image.DecodeConfig, which is all a dimension check should read.
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 0 // grayscale
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestCheckImageDimensions(t *testing.T) {
+	var small bytes.Buffer
+	if err := png.Encode(&small, image.NewGray(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		image  []byte
+		tooBig bool
+	}{
+		{"small image", small.Bytes(), false},
+		{"at the limit", pngHeader(10000, MaxImagePixels/10000), false},
+		{"declared huge", pngHeader(60000, 60000), true},
+		{"unknown format", []byte("RIFF....WEBP"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImageDimensions(tt.image)
+			if got := errors.Is(err, ErrImageTooLarge); got != tt.tooBig {
+				t.Errorf("CheckImageDimensions() = %v, want too large %v", err, tt.tooBig)
+			}
+		})
+	}
+}
+
+func TestPreprocessReceiptImageRejectsHugeImage(t *testing.T) {
+	_, err := PreprocessReceiptImage(pngHeader(60000, 60000), OCRPreprocessOptions{Grayscale: true})
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("PreprocessReceiptImage() = %v, want ErrImageTooLarge", err)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const earthRadiusMeters = 6371000.0
+
+// nameSimilarityThreshold is the minimum token overlap for two places to be considered the same store
+const nameSimilarityThreshold = 0.5
+
+// ClusterPlaces collapses near-duplicate places that sit within radiusMeters of each other
+// and have similar names. The best-known place in each cluster is returned with the others
+// attached as Duplicates. A radius of 0 or less returns the input unchanged.
+func ClusterPlaces(places []*PlaceResult, radiusMeters int) []*PlaceResult {
+	if radiusMeters <= 0 || len(places) < 2 {
+		return places
+	}
+
+	// Consider the most reviewed places first so they become cluster representatives
+	ordered := make([]*PlaceResult, len(places))
+	copy(ordered, places)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return placeRank(ordered[i]) > placeRank(ordered[j])
+	})
+
+	var clusters []*PlaceResult
+	for _, place := range ordered {
+		merged := false
+		for _, rep := range clusters {
+			if distanceMeters(rep.Latitude, rep.Longitude, place.Latitude, place.Longitude) > float64(radiusMeters) {
+				continue
+			}
+			if nameSimilarity(rep.Name, place.Name) < nameSimilarityThreshold {
+				continue
+			}
+			rep.Duplicates = append(rep.Duplicates, place)
+			merged = true
+			break
+		}
+		if !merged {
+			clusters = append(clusters, place)
+		}
+	}
+
+	// Preserve the original ordering of representatives
+	position := make(map[*PlaceResult]int, len(places))
+	for i, p := range places {
+		position[p] = i
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return position[clusters[i]] < position[clusters[j]]
+	})
+
+	return clusters
+}
+
+// placeRank scores a place for representative selection, preferring grocery stores over gas stations
+func placeRank(p *PlaceResult) int {
+	rank := p.UserRatingsTotal
+	for _, t := range p.Types {
+		if t == "gas_station" {
+			return rank - 1000000
+		}
+	}
+	return rank
+}
+
+// distanceMeters returns the great-circle distance between two coordinates
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// DistanceKm returns the great-circle distance between two coordinates in kilometers
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	return distanceMeters(lat1, lng1, lat2, lng2) / 1000
+}
+
+// nameSimilarity returns the share of tokens of the shorter name found in the longer one
+func nameSimilarity(a, b string) float64 {
+	tokensA := nameTokens(a)
+	tokensB := nameTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+	if len(tokensA) > len(tokensB) {
+		tokensA, tokensB = tokensB, tokensA
+	}
+
+	set := make(map[string]bool, len(tokensB))
+	for _, t := range tokensB {
+		set[t] = true
+	}
+
+	shared := 0
+	for _, t := range tokensA {
+		if set[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(tokensA))
+}
+
+// nameTokens lowercases a place name and splits it into words, dropping filler words
+func nameTokens(name string) []string {
+	stopWords := map[string]bool{
+		"the": true, "and": true, "of": true, "store": true, "market": true,
+		"gas": true, "station": true, "fuel": true, "inc": true,
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var tokens []string
+	for _, f := range fields {
+		if !stopWords[f] {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
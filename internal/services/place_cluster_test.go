@@ -0,0 +1,133 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestClusterPlaces(t *testing.T) {
+	// 0.0001 degrees of latitude is about 11 meters
+	place := func(id, name string, lat float64, reviews int, types ...string) *PlaceResult {
+		return &PlaceResult{PlaceID: id, Name: name, Latitude: lat, Longitude: -97.0, UserRatingsTotal: reviews, Types: types}
+	}
+
+	tests := []struct {
+		name       string
+		places     []*PlaceResult
+		radius     int
+		wantIDs    []string
+		wantMerged map[string][]string // representative -> duplicates
+	}{
+		{
+			name:       "similar names within radius",
+			places:     []*PlaceResult{place("a", "Kroger", 33.0, 10), place("b", "Kroger Marketplace", 33.0003, 500)},
+			radius:     75,
+			wantIDs:    []string{"b"},
+			wantMerged: map[string][]string{"b": {"a"}},
+		},
+		{
+			name:    "same name outside radius",
+			places:  []*PlaceResult{place("a", "Kroger", 33.0, 10), place("b", "Kroger", 33.01, 500)},
+			radius:  75,
+			wantIDs: []string{"a", "b"},
+		},
+		{
+			name:    "different names at the same coordinates",
+			places:  []*PlaceResult{place("a", "Kroger", 33.0, 10), place("b", "Walgreens", 33.0, 500)},
+			radius:  75,
+			wantIDs: []string{"a", "b"},
+		},
+		{
+			name:       "grocery store preferred over gas station",
+			places:     []*PlaceResult{place("a", "Kroger Fuel Center", 33.0, 900, "gas_station"), place("b", "Kroger", 33.0001, 5, "grocery_or_supermarket")},
+			radius:     75,
+			wantIDs:    []string{"b"},
+			wantMerged: map[string][]string{"b": {"a"}},
+		},
+		{
+			name:    "radius disabled",
+			places:  []*PlaceResult{place("a", "Kroger", 33.0, 10), place("b", "Kroger", 33.0, 500)},
+			radius:  0,
+			wantIDs: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClusterPlaces(tt.places, tt.radius)
+
+			var ids []string
+			for _, p := range got {
+				ids = append(ids, p.PlaceID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("ClusterPlaces() = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("ClusterPlaces() = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+
+			for _, p := range got {
+				var dupes []string
+				for _, d := range p.Duplicates {
+					dupes = append(dupes, d.PlaceID)
+				}
+				want := tt.wantMerged[p.PlaceID]
+				if len(dupes) != len(want) {
+					t.Errorf("%s duplicates = %v, want %v", p.PlaceID, dupes, want)
+					continue
+				}
+				for i := range dupes {
+					if dupes[i] != want[i] {
+						t.Errorf("%s duplicates = %v, want %v", p.PlaceID, dupes, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceRank(t *testing.T) {
+	tests := []struct {
+		name  string
+		place PlaceResult
+		want  int
+	}{
+		{"reviews", PlaceResult{UserRatingsTotal: 120, Types: []string{"supermarket"}}, 120},
+		{"no reviews", PlaceResult{}, 0},
+		{"gas station ranks below any store", PlaceResult{UserRatingsTotal: 5000, Types: []string{"store", "gas_station"}}, 5000 - 1000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := placeRank(&tt.place); got != tt.want {
+				t.Errorf("placeRank() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Kroger", "Kroger", 1},
+		{"Kroger", "Kroger Marketplace", 1},
+		{"The Kroger Store", "kroger", 1},
+		{"Kroger Fuel Center", "Kroger", 1},
+		{"Whole Foods Market", "Whole Earth", 0.5},
+		{"Kroger", "Walgreens", 0},
+		{"Gas Station", "Kroger", 0},
+		{"", "Kroger", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := nameSimilarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// presignSweepSize is the cache size above which expired URLs are swept on the next generation
+const presignSweepSize = 1024
+
+// ErrPresignRateLimited is returned when a user has generated too many presigned URLs recently
+// and no cached URL is still valid
+var ErrPresignRateLimited = errors.New("presigned url rate limit exceeded")
+
+// PresignedURL is a presigned download URL and when it stops working
+type PresignedURL struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// ExpiresIn returns the whole seconds the URL remains valid
+func (u *PresignedURL) ExpiresIn() int {
+	remaining := int(time.Until(u.ExpiresAt).Seconds())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// PresignOptions controls how long URLs are valid and how often they are regenerated
+type PresignOptions struct {
+	Validity      time.Duration // Lifetime of a newly generated URL
+	RefreshBefore time.Duration // Regenerate once less than this validity remains
+	MaxPerMinute  int           // Fresh URLs a user may generate per minute; 0 disables the limit
+}
+
+// PresignedURLCache reuses presigned URLs per object key for most of their validity and limits
+// how many fresh URLs each requesting user can generate
+type PresignedURLCache struct {
+	storage *StorageService
+
+	mu        sync.Mutex
+	urls      map[string]*PresignedURL
+	generated map[int][]time.Time // Per-user generation times within the last minute
+}
+
+// NewPresignedURLCache creates a presigned URL cache in front of the storage service
+func NewPresignedURLCache(storage *StorageService) *PresignedURLCache {
+	return &PresignedURLCache{
+		storage:   storage,
+		urls:      make(map[string]*PresignedURL),
+		generated: make(map[int][]time.Time),
+	}
+}
+
+// Get returns a presigned URL for key, reusing the cached one until it nears expiry. When the
+// user is over their generation limit, a cached URL that is still valid is returned instead of
+// a fresh one.
+func (p *PresignedURLCache) Get(ctx context.Context, userID int, key string, opts PresignOptions) (*PresignedURL, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	cached, ok := p.urls[key]
+	if ok && cached.ExpiresAt.Sub(now) > opts.RefreshBefore {
+		p.mu.Unlock()
+		return cached, nil
+	}
+
+	// Only limited requests are counted, so unlimited ones (e.g. list thumbnails) do not use
+	// up the user's allowance
+	if opts.MaxPerMinute > 0 {
+		recent := p.generated[userID][:0]
+		for _, t := range p.generated[userID] {
+			if now.Sub(t) < time.Minute {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) >= opts.MaxPerMinute {
+			p.generated[userID] = recent
+			p.mu.Unlock()
+			if ok && cached.ExpiresAt.After(now) {
+				return cached, nil
+			}
+			return nil, ErrPresignRateLimited
+		}
+		p.generated[userID] = append(recent, now)
+	}
+	p.mu.Unlock()
+
+	url, err := p.storage.GetPresignedURL(ctx, key, opts.Validity)
+	if err != nil {
+		return nil, err
+	}
+	fresh := &PresignedURL{URL: url, ExpiresAt: now.Add(opts.Validity)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.urls) >= presignSweepSize {
+		p.sweep(now)
+	}
+	p.urls[key] = fresh
+
+	return fresh, nil
+}
+
+// Forget drops the cached URL for key, e.g. after the object is deleted
+func (p *PresignedURLCache) Forget(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.urls, key)
+}
+
+// sweep removes expired URLs and idle generation counters. Callers must hold p.mu.
+func (p *PresignedURLCache) sweep(now time.Time) {
+	for key, u := range p.urls {
+		if !u.ExpiresAt.After(now) {
+			delete(p.urls, key)
+		}
+	}
+	for userID, times := range p.generated {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= time.Minute {
+			delete(p.generated, userID)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// PriceAlerter emails users whose price alerts are satisfied by newly stored prices
+type PriceAlerter struct {
+	db    *database.DB
+	email *EmailService
+}
+
+// NewPriceAlerter creates a new price alerter
+func NewPriceAlerter(db *database.DB, email *EmailService) *PriceAlerter {
+	return &PriceAlerter{
+		db:    db,
+		email: email,
+	}
+}
+
+// Notify checks the stored prices against price alerts in the background and emails each
+// alert owner once per matching price, unless they turned off price alert emails
+func (a *PriceAlerter) Notify(prices ...models.PriceSubmission) {
+	if len(prices) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		for _, p := range prices {
+			triggered, err := a.db.TriggerPriceAlerts(ctx, p)
+			if err != nil {
+				log.Printf("Warning: Failed to check price alerts for price %d: %v", p.PriceID, err)
+				continue
+			}
+			for _, t := range triggered {
+				if !t.EmailEnabled {
+					continue
+				}
+				if err := a.send(ctx, t); err != nil {
+					log.Printf("Warning: Failed to send price alert %d: %v", t.AlertID, err)
+				}
+			}
+		}
+	}()
+}
+
+// send emails the alert owner about the price that met their target
+func (a *PriceAlerter) send(ctx context.Context, t models.TriggeredPriceAlert) error {
+	if !a.email.IsConfiguredWithContext(ctx) {
+		return fmt.Errorf("email service is not configured")
+	}
+
+	subject := fmt.Sprintf("Price alert: %s is now $%.2f", t.ItemName, t.Price)
+	textBody := fmt.Sprintf("%s was just priced at $%.2f at %s, at or below your target of $%.2f.\n\nOpen PriceFeed to see the latest prices.",
+		t.ItemName, t.Price, t.StoreName, t.TargetPrice)
+	htmlBody := fmt.Sprintf(`<p><strong>%s</strong> was just priced at <strong>$%.2f</strong> at %s, at or below your target of $%.2f.</p>
+<p>Open PriceFeed to see the latest prices.</p>`,
+		html.EscapeString(t.ItemName), t.Price, html.EscapeString(t.StoreName), t.TargetPrice)
+
+	return a.email.SendEmail(t.Email, subject, htmlBody, textBody)
+}
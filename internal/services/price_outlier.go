@@ -0,0 +1,64 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// minOutlierSamples is the fewest reference prices needed before a price can be called an outlier
+const minOutlierSamples = 3
+
+// DetectPriceOutlier compares a price for an item of the given size and unit against reference
+// prices. With useUnitPrice set and a convertible size, references for other package sizes are
+// scaled to this item's size through their unit price, so a bulk pack is not judged against
+// singles. Otherwise only references for the same item are used, at face value. Returns nil
+// when the price is within factor of the median or there are too few references.
+func DetectPriceOutlier(itemID int, price float64, size *float64, unit *string, refs []models.ReferencePrice, factor float64, useUnitPrice bool) *models.PriceOutlier {
+	if factor <= 1 {
+		return nil
+	}
+
+	var baseSize float64
+	var dimension string
+	unitBased := false
+	if useUnitPrice && size != nil && unit != nil {
+		if b, dim, ok := ConvertToBaseUnit(*size, *unit); ok && b > 0 {
+			baseSize, dimension, unitBased = b, dim, true
+		}
+	}
+
+	var samples []float64
+	for _, r := range refs {
+		if unitBased && r.Size != nil && r.Unit != nil {
+			if perUnit, dim, ok := UnitPrice(r.Price, *r.Size, *r.Unit); ok && dim == dimension {
+				samples = append(samples, perUnit*baseSize)
+				continue
+			}
+		}
+		if r.ItemID == itemID {
+			samples = append(samples, r.Price)
+		}
+	}
+
+	if len(samples) < minOutlierSamples {
+		return nil
+	}
+
+	sort.Float64s(samples)
+	median := samples[len(samples)/2]
+	if len(samples)%2 == 0 {
+		median = (samples[len(samples)/2-1] + samples[len(samples)/2]) / 2
+	}
+	if median <= 0 || (price <= median*factor && price >= median/factor) {
+		return nil
+	}
+
+	return &models.PriceOutlier{
+		Price:    price,
+		Median:   median,
+		Factor:   factor,
+		Samples:  len(samples),
+		UnitBase: unitBased,
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+func TestDetectPriceOutlier(t *testing.T) {
+	size := func(v float64) *float64 { return &v }
+	unit := func(v string) *string { return &v }
+
+	const itemID, bulkID, otherID = 1, 2, 3
+	singles := []models.ReferencePrice{
+		{ItemID: itemID, Price: 1.00, Size: size(16), Unit: unit("oz")},
+		{ItemID: itemID, Price: 1.10, Size: size(16), Unit: unit("oz")},
+		{ItemID: itemID, Price: 0.90, Size: size(16), Unit: unit("oz")},
+	}
+	// Four-pound bulk packs priced at the same unit price as the singles
+	bulk := []models.ReferencePrice{
+		{ItemID: bulkID, Price: 4.00, Size: size(4), Unit: unit("lb")},
+		{ItemID: bulkID, Price: 4.00, Size: size(4), Unit: unit("lb")},
+		{ItemID: bulkID, Price: 4.00, Size: size(4), Unit: unit("lb")},
+	}
+
+	tests := []struct {
+		name         string
+		price        float64
+		size         *float64
+		unit         *string
+		refs         []models.ReferencePrice
+		useUnitPrice bool
+		wantOutlier  bool
+		wantMedian   float64
+		wantUnit     bool
+	}{
+		{"typical price", 1.05, size(16), unit("oz"), singles, true, false, 0, false},
+		{"far above median", 5.00, size(16), unit("oz"), singles, true, true, 1.00, true},
+		{"far below median", 0.20, size(16), unit("oz"), singles, true, true, 1.00, true},
+		{"bulk pack judged by unit price", 4.10, size(64), unit("oz"), singles, true, false, 0, false},
+		{"single judged against bulk unit prices", 1.05, size(16), unit("oz"), bulk, true, false, 0, false},
+		{"without unit prices other sizes are ignored", 1.05, size(16), unit("oz"), bulk, false, false, 0, false},
+		{"without a size only the same item counts", 5.00, nil, nil, append(append([]models.ReferencePrice{}, singles...), bulk...), true, true, 1.00, false},
+		{"other dimensions are ignored", 5.00, size(16), unit("oz"), []models.ReferencePrice{
+			{ItemID: otherID, Price: 5, Size: size(1), Unit: unit("gal")},
+			{ItemID: otherID, Price: 5, Size: size(1), Unit: unit("gal")},
+			{ItemID: otherID, Price: 5, Size: size(1), Unit: unit("gal")},
+		}, true, false, 0, false},
+		{"too few samples", 5.00, size(16), unit("oz"), singles[:2], true, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectPriceOutlier(itemID, tt.price, tt.size, tt.unit, tt.refs, 3, tt.useUnitPrice)
+			if (got != nil) != tt.wantOutlier {
+				t.Fatalf("DetectPriceOutlier() = %+v, want outlier %v", got, tt.wantOutlier)
+			}
+			if got == nil {
+				return
+			}
+			if got.Median != tt.wantMedian || got.UnitBase != tt.wantUnit {
+				t.Errorf("median %v unit-based %v, want %v %v", got.Median, got.UnitBase, tt.wantMedian, tt.wantUnit)
+			}
+		})
+	}
+
+	if got := DetectPriceOutlier(itemID, 100, size(16), unit("oz"), singles, 1, true); got != nil {
+		t.Errorf("factor 1 flagged %+v, want the check disabled", got)
+	}
+}
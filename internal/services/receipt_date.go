@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// EXIF tags holding capture timestamps
+const (
+	exifTagDateTime         = 0x0132
+	exifTagExifIFDPointer   = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+	exifTypeASCII           = 2
+	exifDateLayout          = "2006:01:02 15:04:05"
+)
+
+// monthFirstRegions lists the regions whose receipts print numeric dates month first (MM/DD)
+var monthFirstRegions = map[string]bool{
+	"US": true, "PR": true, "GU": true, "VI": true, "AS": true, "MP": true, "UM": true,
+	"FM": true, "MH": true, "PW": true, "PH": true,
+}
+
+// LocaleDayFirst reports whether receipts printed under a BCP 47 locale such as "en-GB" read
+// ambiguous numeric dates day first. ok is false when the locale has no region subtag.
+func LocaleDayFirst(locale string) (dayFirst, ok bool) {
+	subtags := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for i, subtag := range subtags {
+		// The first subtag is the language
+		if i > 0 && len(subtag) == 2 && isASCIILetters(subtag) {
+			return !monthFirstRegions[strings.ToUpper(subtag)], true
+		}
+	}
+	return false, false
+}
+
+// isASCIILetters reports whether s consists only of ASCII letters
+func isASCIILetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// InferReceiptDate picks the receipt date by precedence: the date printed on the receipt (OCR),
+// then the photo's EXIF capture time, then the upload time. Dates later than a day after upload
+// are treated as misreads and skipped.
+func InferReceiptDate(ocrDate, exifDate *time.Time, uploadedAt time.Time) (time.Time, string) {
+	latest := uploadedAt.Add(24 * time.Hour)
+
+	if ocrDate != nil && !ocrDate.IsZero() && ocrDate.Before(latest) {
+		return *ocrDate, models.ReceiptDateSourceOCR
+	}
+	if exifDate != nil && !exifDate.IsZero() && exifDate.Before(latest) {
+		return *exifDate, models.ReceiptDateSourceEXIF
+	}
+	return uploadedAt, models.ReceiptDateSourceUpload
+}
+
+// ExtractEXIFDate returns the capture timestamp from a JPEG's EXIF metadata, preferring
+// DateTimeOriginal over DateTime. Returns nil for non-JPEG images or when no date is present.
+func ExtractEXIFDate(image []byte) *time.Time {
+	tiff := findEXIFSegment(image)
+	if tiff == nil || len(tiff) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd0 := order.Uint32(tiff[4:8])
+	tags := readIFDTags(tiff, ifd0, order)
+
+	if ptr, ok := tags[exifTagExifIFDPointer]; ok {
+		exifTags := readIFDTags(tiff, order.Uint32(ptr), order)
+		if t := parseEXIFDate(tiff, exifTags[exifTagDateTimeOriginal], order); t != nil {
+			return t
+		}
+	}
+
+	return parseEXIFDate(tiff, tags[exifTagDateTime], order)
+}
+
+// findEXIFSegment walks JPEG markers and returns the TIFF payload of the APP1 Exif segment
+func findEXIFSegment(image []byte) []byte {
+	if len(image) < 4 || image[0] != 0xFF || image[1] != 0xD8 {
+		return nil
+	}
+
+	pos := 2
+	for pos+4 <= len(image) {
+		if image[pos] != 0xFF {
+			return nil
+		}
+		marker := image[pos+1]
+		// Start of scan: no metadata segments follow
+		if marker == 0xDA {
+			return nil
+		}
+
+		length := int(binary.BigEndian.Uint16(image[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(image) {
+			return nil
+		}
+
+		segment := image[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		pos = end
+	}
+	return nil
+}
+
+// readIFDTags returns the raw 4-byte value field of each entry in the IFD at offset
+func readIFDTags(tiff []byte, offset uint32, order binary.ByteOrder) map[uint16][]byte {
+	tags := make(map[uint16][]byte)
+	if int(offset)+2 > len(tiff) {
+		return tags
+	}
+
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry : entry+2])
+		tags[tag] = tiff[entry : entry+12]
+	}
+	return tags
+}
+
+// parseEXIFDate decodes an ASCII date entry in EXIF "YYYY:MM:DD HH:MM:SS" form
+func parseEXIFDate(tiff []byte, entry []byte, order binary.ByteOrder) *time.Time {
+	if len(entry) != 12 || order.Uint16(entry[2:4]) != exifTypeASCII {
+		return nil
+	}
+
+	count := order.Uint32(entry[4:8])
+	if count < uint32(len(exifDateLayout)) {
+		return nil
+	}
+
+	// Values longer than 4 bytes are stored at an offset into the TIFF data
+	offset := order.Uint32(entry[8:12])
+	if uint64(offset)+uint64(count) > uint64(len(tiff)) {
+		return nil
+	}
+
+	value := strings.TrimRight(string(tiff[offset:offset+count]), "\x00 ")
+	t, err := time.ParseInLocation(exifDateLayout, value, time.Local)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
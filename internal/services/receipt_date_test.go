@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocaleDayFirst(t *testing.T) {
+	tests := []struct {
+		locale   string
+		dayFirst bool
+		ok       bool
+	}{
+		{"en-US", false, true},
+		{"en-GB", true, true},
+		{"de-DE", true, true},
+		{"fil-PH", false, true},
+		{"en_us", false, true},
+		{"zh-Hant-TW", true, true},
+		{"es-419", false, false},
+		{"fr", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			dayFirst, ok := LocaleDayFirst(tt.locale)
+			if dayFirst != tt.dayFirst || ok != tt.ok {
+				t.Errorf("LocaleDayFirst(%q) = %v, %v, want %v, %v", tt.locale, dayFirst, ok, tt.dayFirst, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExtractDateOrder(t *testing.T) {
+	parser := NewReceiptParser()
+
+	tests := []struct {
+		name     string
+		text     string
+		dayFirst bool
+		want     time.Time
+	}{
+		{"ambiguous month first", "03/04/2024", false, time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)},
+		{"ambiguous day first", "03/04/2024", true, time.Date(2024, 4, 3, 0, 0, 0, 0, time.Local)},
+		{"only valid day first", "25/04/2024", false, time.Date(2024, 4, 25, 0, 0, 0, 0, time.Local)},
+		{"only valid month first", "04/25/2024", true, time.Date(2024, 4, 25, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parser.ExtractDate(tt.text, tt.dayFirst)
+			if got == nil || !got.Equal(tt.want) {
+				t.Errorf("ExtractDate(%q, %v) = %v, want %v", tt.text, tt.dayFirst, got, tt.want)
+			}
+		})
+	}
+}
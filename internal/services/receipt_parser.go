@@ -54,6 +54,19 @@ func NewReceiptParser() *ReceiptParser {
 	}
 }
 
+// barcodePattern matches a UPC/EAN printed between the item name and price
+var barcodePattern = regexp.MustCompile(`\s(\d{12,14})\s`)
+
+// ExtractBarcode returns the GTIN-14 form of a UPC printed on a receipt line, or "" if none
+func ExtractBarcode(line string) string {
+	m := barcodePattern.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	code, _ := models.NormalizeBarcode(m[1])
+	return code
+}
+
 // Parse parses OCR text and extracts receipt data
 func (p *ReceiptParser) Parse(ocrText string) (*models.ParsedReceipt, error) {
 	lines := strings.Split(ocrText, "\n")
@@ -62,7 +75,7 @@ func (p *ReceiptParser) Parse(ocrText string) (*models.ParsedReceipt, error) {
 	}
 
 	// Extract date
-	result.Date = p.extractDate(lines)
+	result.Date = p.extractDate(lines, false)
 
 	// Extract total
 	result.Total = p.extractTotal(lines)
@@ -140,6 +153,7 @@ func (p *ReceiptParser) parseLine(line string, lineNumber int) *models.ParsedIte
 				Price:      price,
 				Quantity:   quantity,
 				LineNumber: lineNumber,
+				Barcode:    ExtractBarcode(line),
 			}
 		}
 	}
@@ -186,8 +200,14 @@ func (p *ReceiptParser) cleanItemName(name string) string {
 	return strings.TrimSpace(name)
 }
 
+// ExtractDate extracts the printed date from receipt text. dayFirst selects DD/MM over MM/DD
+// for ambiguous dates; dates that only fit one order are read that way regardless.
+func (p *ReceiptParser) ExtractDate(ocrText string, dayFirst bool) *time.Time {
+	return p.extractDate(strings.Split(ocrText, "\n"), dayFirst)
+}
+
 // extractDate extracts a date from the receipt
-func (p *ReceiptParser) extractDate(lines []string) *time.Time {
+func (p *ReceiptParser) extractDate(lines []string, dayFirst bool) *time.Time {
 	for _, line := range lines {
 		for _, pattern := range p.datePatterns {
 			matches := pattern.FindStringSubmatch(line)
@@ -223,6 +243,11 @@ func (p *ReceiptParser) extractDate(lines []string) *time.Time {
 					year, _ = strconv.Atoi(matches[1])
 					month, _ = strconv.Atoi(matches[2])
 					day, _ = strconv.Atoi(matches[3])
+				} else if month > 12 && day <= 12 {
+					// Only valid as DD/MM
+					month, day = day, month
+				} else if dayFirst && day <= 12 {
+					month, day = day, month
 				}
 
 				// Validate date
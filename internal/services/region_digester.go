@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// RegionDigester emails region subscribers the price drops that match their preferences
+type RegionDigester struct {
+	db            *database.DB
+	email         *EmailService
+	encryptionKey []byte
+}
+
+// NewRegionDigester creates a new region digester
+func NewRegionDigester(db *database.DB, cfg *config.Config, email *EmailService) *RegionDigester {
+	return &RegionDigester{
+		db:            db,
+		email:         email,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run sends every due digest and returns how many emails were sent. A subscription whose
+// period has no matching drops is still marked as sent so the next digest starts fresh;
+// one whose email fails is retried on the next run.
+func (d *RegionDigester) Run(ctx context.Context) (int, error) {
+	if !d.db.GetSettingBool(ctx, "region_digest_enabled", true, d.encryptionKey) {
+		return 0, nil
+	}
+	if !d.email.IsConfiguredWithContext(ctx) {
+		return 0, nil
+	}
+	maxItems := d.db.GetSettingInt(ctx, "region_digest_max_items", 20, d.encryptionKey)
+
+	now := time.Now()
+	subs, err := d.db.ListDueRegionSubscriptions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, sub := range subs {
+		since := now.Add(-sub.Period())
+		if sub.LastSentAt != nil {
+			since = *sub.LastSentAt
+		}
+
+		drops, err := d.db.GetRegionDigestDrops(ctx, sub.RegionID, since, sub.Categories, sub.MinDropPercent, maxItems)
+		if err != nil {
+			log.Printf("Warning: Failed to build region digest for subscription %d: %v", sub.ID, err)
+			continue
+		}
+
+		if len(drops) > 0 {
+			if err := d.send(ctx, sub, drops); err != nil {
+				log.Printf("Warning: Failed to send region digest for subscription %d: %v", sub.ID, err)
+				continue
+			}
+			sent++
+		}
+
+		if err := d.db.MarkRegionSubscriptionSent(ctx, sub.ID, now); err != nil {
+			log.Printf("Warning: Failed to update region subscription %d: %v", sub.ID, err)
+		}
+	}
+
+	return sent, nil
+}
+
+// send emails one subscriber their region's price drops
+func (d *RegionDigester) send(ctx context.Context, sub *models.RegionSubscription, drops []models.WhatsNewPrice) error {
+	user, err := d.db.GetUserByID(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "Price drops in %s:\n\n", sub.RegionName)
+	fmt.Fprintf(&body, "<p>Price drops in <strong>%s</strong>:</p><ul>", html.EscapeString(sub.RegionName))
+	for _, drop := range drops {
+		percent := (*drop.PreviousPrice - drop.Price) / *drop.PreviousPrice * 100
+		fmt.Fprintf(&text, "- %s at %s: $%.2f (was $%.2f, -%.0f%%)\n",
+			drop.ItemName, drop.StoreName, drop.Price, *drop.PreviousPrice, percent)
+		fmt.Fprintf(&body, "<li>%s at %s: <strong>$%.2f</strong> (was $%.2f, -%.0f%%)</li>",
+			html.EscapeString(drop.ItemName), html.EscapeString(drop.StoreName), drop.Price, *drop.PreviousPrice, percent)
+	}
+	body.WriteString("</ul>")
+	text.WriteString("\nManage your digest subscriptions in PriceFeed.")
+	body.WriteString("<p>Manage your digest subscriptions in PriceFeed.</p>")
+
+	subject := fmt.Sprintf("%d price drop(s) in %s", len(drops), sub.RegionName)
+	return d.email.SendCategoryEmail(EmailCategoryDigest, []string{user.Email}, subject, body.String(), text.String())
+}
+
+// Start runs the digester immediately and then on every interval until ctx is cancelled
+func (d *RegionDigester) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		sent, err := d.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Region digest run failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("Sent %d region digest email(s)", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
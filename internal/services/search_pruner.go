@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+// SearchPruner periodically removes search events past the retention window
+type SearchPruner struct {
+	db            *database.DB
+	encryptionKey []byte
+}
+
+// NewSearchPruner creates a new search event pruner
+func NewSearchPruner(db *database.DB, cfg *config.Config) *SearchPruner {
+	return &SearchPruner{
+		db:            db,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run deletes search events older than search_events_retention_days
+func (p *SearchPruner) Run(ctx context.Context) (int, error) {
+	retentionDays := p.db.GetSettingInt(ctx, "search_events_retention_days", 30, p.encryptionKey)
+	if retentionDays < 1 {
+		retentionDays = 1
+	}
+
+	return p.db.PruneSearchEvents(ctx, retentionDays)
+}
+
+// Start runs the pruner immediately and then on every interval until ctx is cancelled
+func (p *SearchPruner) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		pruned, err := p.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Search event pruning failed: %v", err)
+		} else if pruned > 0 {
+			log.Printf("Pruned %d expired search event(s)", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
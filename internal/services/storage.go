@@ -131,6 +131,39 @@ func (s *StorageService) DeleteMultiple(ctx context.Context, keys []string) erro
 	return nil
 }
 
+// StoredObject describes an object found while listing the bucket
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// WalkObjects lists the objects under prefix and passes them to fn in batches of up to batchSize.
+// Listing stops at the first error returned by fn.
+func (s *StorageService) WalkObjects(ctx context.Context, prefix string, batchSize int, fn func([]StoredObject) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batch := make([]StoredObject, 0, batchSize)
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		batch = append(batch, StoredObject{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified})
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]StoredObject, 0, batchSize)
+		}
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
 // GetBucketName returns the bucket name
 func (s *StorageService) GetBucketName() string {
 	return s.bucketName
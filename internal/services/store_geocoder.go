@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+// geocodeBreakerThreshold is the number of consecutive failures that stops a backfill run
+const geocodeBreakerThreshold = 5
+
+// StoreGeocoder backfills coordinates for stores that have an address but no latitude/longitude
+type StoreGeocoder struct {
+	db            *database.DB
+	maps          *GoogleMapsService
+	encryptionKey []byte
+
+	mu      sync.Mutex
+	running bool
+	cache   map[string]*GeocodingResult
+}
+
+// GeocodeBackfillResult reports the outcome of a backfill run
+type GeocodeBackfillResult struct {
+	Processed     int                   `json:"processed"`
+	Succeeded     int                   `json:"succeeded"`
+	Failed        int                   `json:"failed"`
+	CacheHits     int                   `json:"cache_hits"`
+	Remaining     int                   `json:"remaining"`
+	CircuitOpen   bool                  `json:"circuit_open"`
+	StoppedReason string                `json:"stopped_reason,omitempty"`
+	Failures      []StoreGeocodeFailure `json:"failures,omitempty"`
+}
+
+// StoreGeocodeFailure describes a store that could not be geocoded
+type StoreGeocodeFailure struct {
+	StoreID int    `json:"store_id"`
+	Address string `json:"address"`
+	Error   string `json:"error"`
+}
+
+// ErrGeocodeInProgress is returned when a backfill is already running
+var ErrGeocodeInProgress = errors.New("geocode backfill already in progress")
+
+// NewStoreGeocoder creates a new store geocoder
+func NewStoreGeocoder(db *database.DB, maps *GoogleMapsService, cfg *config.Config) *StoreGeocoder {
+	return &StoreGeocoder{
+		db:            db,
+		maps:          maps,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+		cache:         make(map[string]*GeocodingResult),
+	}
+}
+
+// GeocodeMissing geocodes up to the configured batch size of stores lacking coordinates.
+// Requests are spaced by the configured delay, identical addresses are only looked up once,
+// and the run stops early when the API rejects requests or keeps failing.
+func (g *StoreGeocoder) GeocodeMissing(ctx context.Context) (*GeocodeBackfillResult, error) {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return nil, ErrGeocodeInProgress
+	}
+	g.running = true
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+	}()
+
+	batchSize := g.db.GetSettingInt(ctx, "geocode_backfill_batch_size", 50, g.encryptionKey)
+	delay := time.Duration(g.db.GetSettingInt(ctx, "geocode_backfill_delay_ms", 200, g.encryptionKey)) * time.Millisecond
+
+	stores, err := g.db.ListStoresMissingCoordinates(ctx, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GeocodeBackfillResult{}
+	consecutiveFailures := 0
+
+	for i, store := range stores {
+		address := fmt.Sprintf("%s, %s, %s %s", store.StreetAddress, store.City, store.State, store.ZipCode)
+		geo, cached := g.cached(address)
+
+		if cached {
+			result.CacheHits++
+		} else {
+			if i > 0 && delay > 0 {
+				select {
+				case <-ctx.Done():
+					result.StoppedReason = ctx.Err().Error()
+					return g.finish(ctx, result)
+				case <-time.After(delay):
+				}
+			}
+
+			geo, err = g.maps.Geocode(ctx, address)
+			if err != nil {
+				result.Processed++
+				result.Failed++
+				result.Failures = append(result.Failures, StoreGeocodeFailure{StoreID: store.ID, Address: address, Error: err.Error()})
+				consecutiveFailures++
+
+				// Quota, key and permission errors will not recover within this run
+				if errors.Is(err, ErrOverQueryLimit) || errors.Is(err, ErrInvalidAPIKey) || errors.Is(err, ErrRequestDenied) {
+					result.CircuitOpen = true
+					result.StoppedReason = err.Error()
+					break
+				}
+				// Other failures are about this address, so later runs try other stores first
+				if markErr := g.db.MarkStoreGeocodeAttempted(ctx, store.ID); markErr != nil {
+					log.Printf("Warning: failed to record geocode attempt for store %d: %v", store.ID, markErr)
+				}
+				if consecutiveFailures >= geocodeBreakerThreshold {
+					result.CircuitOpen = true
+					result.StoppedReason = fmt.Sprintf("%d consecutive failures", consecutiveFailures)
+					break
+				}
+				continue
+			}
+
+			g.remember(address, geo)
+		}
+
+		result.Processed++
+		if err := g.db.UpdateStoreCoordinates(ctx, store.ID, geo.Latitude, geo.Longitude, geo.PlaceID); err != nil {
+			result.Failed++
+			result.Failures = append(result.Failures, StoreGeocodeFailure{StoreID: store.ID, Address: address, Error: err.Error()})
+			continue
+		}
+
+		result.Succeeded++
+		consecutiveFailures = 0
+	}
+
+	return g.finish(ctx, result)
+}
+
+// cached returns a previous geocoding result for the address, if any
+func (g *StoreGeocoder) cached(address string) (*GeocodingResult, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	geo, ok := g.cache[geocodeCacheKey(address)]
+	return geo, ok
+}
+
+// remember caches a geocoding result for the address
+func (g *StoreGeocoder) remember(address string, geo *GeocodingResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cache[geocodeCacheKey(address)] = geo
+}
+
+// geocodeCacheKey normalizes an address for the geocoding cache
+func geocodeCacheKey(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// finish fills in the remaining count and logs the run summary
+func (g *StoreGeocoder) finish(ctx context.Context, result *GeocodeBackfillResult) (*GeocodeBackfillResult, error) {
+	remaining, err := g.db.CountStoresMissingCoordinates(ctx)
+	if err == nil {
+		result.Remaining = remaining
+	}
+
+	log.Printf("Store geocode backfill: %d succeeded, %d failed, %d remaining", result.Succeeded, result.Failed, result.Remaining)
+	if result.CircuitOpen {
+		log.Printf("Warning: Store geocode backfill stopped early: %s", result.StoppedReason)
+	}
+
+	return result, nil
+}
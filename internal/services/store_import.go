@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// ImportStores geocodes, assigns regions to and inserts imported store rows. Rows without
+// coordinates are geocoded with the same cache, request spacing and circuit breaker as the
+// backfill; once the breaker opens, remaining rows are imported without coordinates so the
+// backfill can fill them in later. Rows are inserted in batches of store_import_batch_size,
+// one transaction per batch, and duplicates of existing stores are reported rather than inserted.
+func (g *StoreGeocoder) ImportStores(ctx context.Context, rows []models.StoreImportRow, createdBy int) (*models.StoreImportResult, error) {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return nil, ErrGeocodeInProgress
+	}
+	g.running = true
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+	}()
+
+	batchSize := g.db.GetSettingInt(ctx, "store_import_batch_size", 100, g.encryptionKey)
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	delay := time.Duration(g.db.GetSettingInt(ctx, "geocode_backfill_delay_ms", 200, g.encryptionKey)) * time.Millisecond
+
+	result := &models.StoreImportResult{Rows: make([]models.StoreImportRowResult, 0, len(rows))}
+	consecutiveFailures := 0
+	lookups := 0
+
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+
+		reqs := make([]*models.CreateStoreRequest, 0, end-start)
+		rowResults := make([]models.StoreImportRowResult, 0, end-start)
+
+		for _, row := range rows[start:end] {
+			req := row.Request
+			rr := models.StoreImportRowResult{Row: row.Row, Name: req.Name}
+
+			if (req.Latitude == nil || req.Longitude == nil) && !result.CircuitOpen {
+				address := fmt.Sprintf("%s, %s, %s %s", req.StreetAddress, req.City, req.State, req.ZipCode)
+				geo, cached := g.cached(address)
+				if cached {
+					result.CacheHits++
+				} else {
+					if lookups > 0 && delay > 0 {
+						select {
+						case <-ctx.Done():
+							return nil, ctx.Err()
+						case <-time.After(delay):
+						}
+					}
+					lookups++
+
+					var err error
+					geo, err = g.maps.Geocode(ctx, address)
+					if err != nil {
+						geo = nil
+						rr.Error = "geocoding failed: " + err.Error()
+						consecutiveFailures++
+
+						// Quota, key and permission errors will not recover within this run
+						if errors.Is(err, ErrOverQueryLimit) || errors.Is(err, ErrInvalidAPIKey) || errors.Is(err, ErrRequestDenied) {
+							result.CircuitOpen = true
+							result.StoppedReason = err.Error()
+						} else if consecutiveFailures >= geocodeBreakerThreshold {
+							result.CircuitOpen = true
+							result.StoppedReason = fmt.Sprintf("%d consecutive failures", consecutiveFailures)
+						}
+					} else {
+						consecutiveFailures = 0
+						g.remember(address, geo)
+					}
+				}
+
+				if geo != nil {
+					req.Latitude = &geo.Latitude
+					req.Longitude = &geo.Longitude
+					rr.Geocoded = true
+					result.Geocoded++
+				}
+			}
+
+			if req.RegionID == nil {
+				regionID, err := g.db.FindRegionForLocation(ctx, req.State, req.ZipCode, req.Latitude, req.Longitude)
+				if err != nil {
+					return nil, err
+				}
+				req.RegionID = regionID
+			}
+			rr.RegionID = req.RegionID
+
+			reqs = append(reqs, &req)
+			rowResults = append(rowResults, rr)
+		}
+
+		inserted, err := g.db.ImportStores(ctx, reqs, &createdBy)
+		if err != nil {
+			// The batch could not be saved at all; report it and carry on with the next one
+			log.Printf("Warning: Store import batch at row %d failed: %v", rows[start].Row, err)
+			for _, rr := range rowResults {
+				rr.Status = models.StoreImportFailed
+				rr.Error = err.Error()
+				result.Failed++
+				result.Rows = append(result.Rows, rr)
+			}
+			continue
+		}
+
+		for i, rr := range rowResults {
+			if inserted[i].Err != nil {
+				rr.Status = models.StoreImportFailed
+				rr.Error = inserted[i].Err.Error()
+				result.Failed++
+				result.Rows = append(result.Rows, rr)
+				continue
+			}
+			if inserted[i].StoreID != 0 {
+				id := inserted[i].StoreID
+				rr.StoreID = &id
+			}
+			if inserted[i].Duplicate {
+				rr.Status = models.StoreImportDuplicate
+				result.Duplicates++
+			} else {
+				rr.Status = models.StoreImportCreated
+				result.Created++
+			}
+			result.Rows = append(result.Rows, rr)
+		}
+	}
+
+	log.Printf("Store import: %d created, %d duplicates, %d failed, %d geocoded", result.Created, result.Duplicates, result.Failed, result.Geocoded)
+	if result.CircuitOpen {
+		log.Printf("Warning: Store import stopped geocoding early: %s", result.StoppedReason)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+)
+
+// StoreVerifier periodically verifies public stores that many distinct users have priced
+type StoreVerifier struct {
+	db            *database.DB
+	encryptionKey []byte
+}
+
+// NewStoreVerifier creates a new store verifier
+func NewStoreVerifier(db *database.DB, cfg *config.Config) *StoreVerifier {
+	return &StoreVerifier{
+		db:            db,
+		encryptionKey: DeriveEncryptionKey(cfg.JWTSecret),
+	}
+}
+
+// Run performs a single verification pass using the current settings
+func (v *StoreVerifier) Run(ctx context.Context) (int, error) {
+	if !v.db.GetSettingBool(ctx, "store_auto_verify_enabled", true, v.encryptionKey) {
+		return 0, nil
+	}
+
+	minContributors := v.db.GetSettingInt(ctx, "store_auto_verify_min_contributors", 5, v.encryptionKey)
+	if minContributors < 2 {
+		// The store's creator alone must never be able to verify it
+		minContributors = 2
+	}
+
+	creatorPoints := v.db.GetSettingInt(ctx, "reputation_store_verified_points", 5, v.encryptionKey)
+
+	return v.db.AutoVerifyStoresFromContributors(ctx, minContributors, creatorPoints)
+}
+
+// Start runs the verifier immediately and then on every interval until ctx is cancelled
+func (v *StoreVerifier) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		verified, err := v.Run(runCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Store auto-verification failed: %v", err)
+		} else if verified > 0 {
+			log.Printf("Auto-verified %d store(s) from price contributors", verified)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
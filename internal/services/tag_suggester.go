@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/foxxcyber/price-feed/internal/config"
+	"github.com/foxxcyber/price-feed/internal/database"
+	"github.com/foxxcyber/price-feed/internal/models"
+)
+
+// keywordConfidence is the confidence assigned to tags found through the keyword mapping
+const keywordConfidence = 0.9
+
+// defaultTagKeywords maps words found in item names to tag slugs
+var defaultTagKeywords = map[string]string{
+	"milk": "dairy", "cheese": "dairy", "yogurt": "dairy", "butter": "dairy", "cream": "dairy",
+	"eggs": "eggs", "egg": "eggs",
+	"bread": "bakery", "bagel": "bakery", "bagels": "bakery", "muffin": "bakery", "tortilla": "bakery",
+	"apple": "produce", "apples": "produce", "banana": "produce", "bananas": "produce", "lettuce": "produce",
+	"tomato": "produce", "tomatoes": "produce", "onion": "produce", "onions": "produce", "potato": "produce",
+	"potatoes": "produce", "carrots": "produce",
+	"chicken": "meat", "beef": "meat", "pork": "meat", "turkey": "meat", "bacon": "meat", "sausage": "meat",
+	"salmon": "seafood", "shrimp": "seafood", "tuna": "seafood",
+	"frozen": "frozen", "pizza": "frozen",
+	"coffee": "beverages", "tea": "beverages", "juice": "beverages", "soda": "beverages", "water": "beverages",
+	"cereal": "breakfast", "oatmeal": "breakfast",
+	"pasta": "pantry", "rice": "pantry", "flour": "pantry", "sugar": "pantry", "beans": "pantry", "soup": "pantry",
+	"chips": "snacks", "crackers": "snacks", "cookies": "snacks",
+	"detergent": "household", "paper": "household", "towels": "household", "soap": "personal-care",
+	"shampoo": "personal-care", "toothpaste": "personal-care",
+	"diapers": "baby", "formula": "baby",
+	"gas": "fuel", "gasoline": "fuel", "diesel": "fuel",
+}
+
+// TagSuggester proposes tags for item names from a keyword mapping and the tags of similar items
+type TagSuggester struct {
+	db  *database.DB
+	cfg *config.Config
+}
+
+// NewTagSuggester creates a new tag suggester
+func NewTagSuggester(db *database.DB, cfg *config.Config) *TagSuggester {
+	return &TagSuggester{db: db, cfg: cfg}
+}
+
+// Suggest returns up to limit tag suggestions for an item name, most confident first.
+// Extra "keyword=slug" pairs can be configured with the tag_suggestion_keywords setting.
+func (t *TagSuggester) Suggest(ctx context.Context, name string, limit int) ([]models.TagSuggestion, error) {
+	encryptionKey := DeriveEncryptionKey(t.cfg.JWTSecret)
+
+	keywords := make(map[string]string, len(defaultTagKeywords))
+	for k, v := range defaultTagKeywords {
+		keywords[k] = v
+	}
+	for _, pair := range strings.Split(t.db.GetSettingString(ctx, "tag_suggestion_keywords", "", encryptionKey), ",") {
+		keyword, slug, ok := strings.Cut(pair, "=")
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		slug = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(slug), " ", "-"))
+		if ok && keyword != "" && slug != "" {
+			keywords[keyword] = slug
+		}
+	}
+
+	bySlug := make(map[string]*models.TagSuggestion)
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if slug, ok := keywords[word]; ok {
+			bySlug[slug] = &models.TagSuggestion{Slug: slug, Name: slug, Confidence: keywordConfidence, Source: "keyword"}
+		}
+	}
+
+	similar, err := t.db.GetSimilarItemTags(ctx, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range similar {
+		s.Confidence = math.Round(s.Confidence*100) / 100
+		if existing, ok := bySlug[s.Slug]; ok {
+			// Agreement between both sources raises confidence
+			existing.Name = s.Name
+			existing.Confidence = math.Min(1, existing.Confidence+s.Confidence/10)
+			continue
+		}
+		suggestion := s
+		bySlug[s.Slug] = &suggestion
+	}
+
+	suggestions := make([]models.TagSuggestion, 0, len(bySlug))
+	for _, s := range bySlug {
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].Slug < suggestions[j].Slug
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
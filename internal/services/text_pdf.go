@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page layout for RenderTextPDF: US Letter with monospaced 9pt text
+const (
+	pdfPageWidth   = 612
+	pdfPageHeight  = 792
+	pdfMargin      = 40
+	pdfFontSize    = 9
+	pdfLineHeight  = 11
+	pdfLinesOnPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// TextPDFMaxColumns is the number of characters that fit on one line of a RenderTextPDF page
+const TextPDFMaxColumns = (pdfPageWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6)
+
+// RenderTextPDF lays out plain text lines on as many pages as needed and returns a PDF
+// document. The header lines are repeated in bold at the top of every page. Text uses the
+// built-in Courier font, so callers can align columns with spaces; characters outside
+// printable ASCII are replaced with '?' and long lines are cut at TextPDFMaxColumns.
+func RenderTextPDF(header, lines []string) []byte {
+	perPage := pdfLinesOnPage - len(header) - 1
+	if perPage < 1 {
+		perPage = 1
+	}
+
+	var pages [][]string
+	for start := 0; start < len(lines) || start == 0; start += perPage {
+		end := min(start+perPage, len(lines))
+		pages = append(pages, lines[start:end])
+		if end == len(lines) {
+			break
+		}
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3 regular font, 4 bold font, then a page and content stream per page
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold >>",
+	)
+
+	for i, pageLines := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT\n")
+		fmt.Fprintf(&content, "%d TL\n", pdfLineHeight)
+		fmt.Fprintf(&content, "%d %d Td\n", pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+		fmt.Fprintf(&content, "/F2 %d Tf\n", pdfFontSize)
+		for _, line := range header {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		fmt.Fprintf(&content, "/F1 %d Tf\nT*\n", pdfFontSize)
+		for _, line := range pageLines {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		content.WriteString("ET\n")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfEscape makes a line safe for a PDF literal string
+func pdfEscape(line string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range line {
+		if n == TextPDFMaxColumns {
+			break
+		}
+		n++
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
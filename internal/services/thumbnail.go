@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// Output formats for receipt thumbnails
+const (
+	ThumbnailFormatJPEG = "jpeg"
+	ThumbnailFormatPNG  = "png"
+)
+
+// thumbnailSamples is how many source pixels per axis are averaged into one thumbnail pixel
+const thumbnailSamples = 4
+
+// ThumbnailOptions controls the size and encoding of a generated thumbnail
+type ThumbnailOptions struct {
+	MaxWidth  int
+	MaxHeight int
+	Format    string // ThumbnailFormatJPEG or ThumbnailFormatPNG
+	Quality   int    // JPEG quality, 1-100
+}
+
+// Thumbnail is an encoded preview image ready to upload
+type Thumbnail struct {
+	Image       []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// GenerateThumbnail scales an image down to fit within the configured bounds, keeping its
+// aspect ratio, and re-encodes it in the configured format. Images already small enough are
+// only re-encoded. Formats the standard library cannot decode (e.g. WebP) and images over
+// MaxImagePixels return an error.
+func GenerateThumbnail(imageBytes []byte, opts ThumbnailOptions) (*Thumbnail, error) {
+	if opts.MaxWidth < 1 || opts.MaxHeight < 1 {
+		return nil, fmt.Errorf("invalid thumbnail size %dx%d", opts.MaxWidth, opts.MaxHeight)
+	}
+
+	if err := CheckImageDimensions(imageBytes); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	scale := 1.0
+	if s := float64(opts.MaxWidth) / float64(width); s < scale {
+		scale = s
+	}
+	if s := float64(opts.MaxHeight) / float64(height); s < scale {
+		scale = s
+	}
+	dstW := max(1, int(float64(width)*scale))
+	dstH := max(1, int(float64(height)*scale))
+
+	dst := downscale(src, dstW, dstH)
+
+	thumb := &Thumbnail{Width: dstW, Height: dstH}
+	var buf bytes.Buffer
+	switch strings.ToLower(opts.Format) {
+	case ThumbnailFormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, dst); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		thumb.ContentType, thumb.Extension = "image/png", ".png"
+	case ThumbnailFormatJPEG, "jpg", "":
+		quality := opts.Quality
+		if quality < 1 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		thumb.ContentType, thumb.Extension = "image/jpeg", ".jpg"
+	default:
+		return nil, fmt.Errorf("unsupported thumbnail format %q", opts.Format)
+	}
+	thumb.Image = buf.Bytes()
+
+	return thumb, nil
+}
+
+// downscale resizes src to width x height by averaging a grid of samples from the source
+// area behind each destination pixel. Transparent areas are flattened onto white.
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xRatio := float64(bounds.Dx()) / float64(width)
+	yRatio := float64(bounds.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b, n uint32
+			for sy := 0; sy < thumbnailSamples; sy++ {
+				py := bounds.Min.Y + int((float64(y)+(float64(sy)+0.5)/thumbnailSamples)*yRatio)
+				for sx := 0; sx < thumbnailSamples; sx++ {
+					px := bounds.Min.X + int((float64(x)+(float64(sx)+0.5)/thumbnailSamples)*xRatio)
+					pr, pg, pb, pa := src.At(px, py).RGBA()
+					white := 0xffff - pa
+					r += (pr + white) >> 8
+					g += (pg + white) >> 8
+					b += (pb + white) >> 8
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestGenerateThumbnail(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, image.NewGray(image.Rect(0, 0, 400, 800))); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := GenerateThumbnail(src.Bytes(), ThumbnailOptions{MaxWidth: 100, MaxHeight: 100, Format: ThumbnailFormatPNG})
+	if err != nil {
+		t.Fatalf("GenerateThumbnail: %v", err)
+	}
+	if thumb.Width != 50 || thumb.Height != 100 {
+		t.Errorf("thumbnail is %dx%d, want 50x100", thumb.Width, thumb.Height)
+	}
+	if thumb.ContentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", thumb.ContentType)
+	}
+}
+
+func TestGenerateThumbnailRejectsHugeImage(t *testing.T) {
+	_, err := GenerateThumbnail(pngHeader(60000, 60000), ThumbnailOptions{MaxWidth: 100, MaxHeight: 100})
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("GenerateThumbnail() = %v, want ErrImageTooLarge", err)
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Errors converting an amount into packages
+var (
+	ErrUnknownUnit   = errors.New("unknown unit")
+	ErrUnitMismatch  = errors.New("unit does not match the package size unit")
+	ErrNoPackageSize = errors.New("item has no package size")
+	ErrInvalidAmount = errors.New("amount must be positive")
+)
+
+// Unit dimensions used when comparing package sizes
+const (
+	UnitDimensionWeight = "weight"
+	UnitDimensionVolume = "volume"
+	UnitDimensionCount  = "count"
+)
+
+// unitConversion maps a unit to its dimension and the factor converting it to the dimension's base unit
+type unitConversion struct {
+	dimension string
+	factor    float64
+}
+
+// Base units: ounces for weight, fluid ounces for volume, single items for count
+var unitBaseNames = map[string]string{
+	UnitDimensionWeight: "oz",
+	UnitDimensionVolume: "fl oz",
+	UnitDimensionCount:  "each",
+}
+
+var unitConversions = map[string]unitConversion{
+	// Weight
+	"oz":        {UnitDimensionWeight, 1},
+	"ounce":     {UnitDimensionWeight, 1},
+	"ounces":    {UnitDimensionWeight, 1},
+	"lb":        {UnitDimensionWeight, 16},
+	"lbs":       {UnitDimensionWeight, 16},
+	"pound":     {UnitDimensionWeight, 16},
+	"pounds":    {UnitDimensionWeight, 16},
+	"g":         {UnitDimensionWeight, 0.0352739619},
+	"gram":      {UnitDimensionWeight, 0.0352739619},
+	"grams":     {UnitDimensionWeight, 0.0352739619},
+	"kg":        {UnitDimensionWeight, 35.2739619},
+	"kilogram":  {UnitDimensionWeight, 35.2739619},
+	"kilograms": {UnitDimensionWeight, 35.2739619},
+
+	// Volume
+	"fl oz":  {UnitDimensionVolume, 1},
+	"floz":   {UnitDimensionVolume, 1},
+	"cup":    {UnitDimensionVolume, 8},
+	"cups":   {UnitDimensionVolume, 8},
+	"pt":     {UnitDimensionVolume, 16},
+	"pint":   {UnitDimensionVolume, 16},
+	"qt":     {UnitDimensionVolume, 32},
+	"quart":  {UnitDimensionVolume, 32},
+	"gal":    {UnitDimensionVolume, 128},
+	"gallon": {UnitDimensionVolume, 128},
+	"ml":     {UnitDimensionVolume, 0.0338140227},
+	"l":      {UnitDimensionVolume, 33.8140227},
+	"liter":  {UnitDimensionVolume, 33.8140227},
+	"litre":  {UnitDimensionVolume, 33.8140227},
+
+	// Count
+	"ct":    {UnitDimensionCount, 1},
+	"count": {UnitDimensionCount, 1},
+	"each":  {UnitDimensionCount, 1},
+	"ea":    {UnitDimensionCount, 1},
+	"pk":    {UnitDimensionCount, 1},
+	"pack":  {UnitDimensionCount, 1},
+	"dz":    {UnitDimensionCount, 12},
+	"dozen": {UnitDimensionCount, 12},
+}
+
+// ConvertToBaseUnit converts a size in the given unit to its dimension's base unit.
+// ok is false when the unit is unknown.
+func ConvertToBaseUnit(size float64, unit string) (baseSize float64, dimension string, ok bool) {
+	conv, ok := unitConversions[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		return 0, "", false
+	}
+	return size * conv.factor, conv.dimension, true
+}
+
+// BaseUnitName returns the display name of a dimension's base unit
+func BaseUnitName(dimension string) string {
+	return unitBaseNames[dimension]
+}
+
+// UnitPrice returns the price per base unit for a package of the given size and unit
+func UnitPrice(price, size float64, unit string) (perUnit float64, dimension string, ok bool) {
+	if size <= 0 {
+		return 0, "", false
+	}
+	baseSize, dimension, ok := ConvertToBaseUnit(size, unit)
+	if !ok || baseSize <= 0 {
+		return 0, "", false
+	}
+	return price / baseSize, dimension, true
+}
+
+// PackagesFor returns how many whole packages of the given size cover amount, where amount is
+// in unit and size in sizeUnit. Both units must share a dimension.
+func PackagesFor(amount float64, unit string, size float64, sizeUnit string) (int, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if size <= 0 || strings.TrimSpace(sizeUnit) == "" {
+		return 0, ErrNoPackageSize
+	}
+
+	amountBase, dimension, ok := ConvertToBaseUnit(amount, unit)
+	if !ok {
+		return 0, ErrUnknownUnit
+	}
+	sizeBase, sizeDimension, ok := ConvertToBaseUnit(size, sizeUnit)
+	if !ok || sizeBase <= 0 {
+		return 0, ErrNoPackageSize
+	}
+	if dimension != sizeDimension {
+		return 0, ErrUnitMismatch
+	}
+
+	// Tolerate float error so an exact multiple does not round up to an extra package
+	packages := int(math.Ceil(amountBase/sizeBase - 1e-9))
+	if packages < 1 {
+		packages = 1
+	}
+	return packages, nil
+}
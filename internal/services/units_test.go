@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPackagesFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		unit     string
+		size     float64
+		sizeUnit string
+		want     int
+		wantErr  error
+	}{
+		{"exact multiple", 32, "oz", 16, "oz", 2, nil},
+		{"rounds up", 17, "oz", 16, "oz", 2, nil},
+		{"converts units", 2, "lb", 16, "oz", 2, nil},
+		{"float error does not add a package", 3, "cup", 24, "fl oz", 1, nil},
+		{"small amount needs one package", 1, "oz", 5, "lb", 1, nil},
+		{"count units", 18, "each", 1, "dozen", 2, nil},
+		{"unit case and spacing ignored", 1, " GAL ", 64, "FL OZ", 2, nil},
+		{"zero amount", 0, "oz", 16, "oz", 0, ErrInvalidAmount},
+		{"negative amount", -1, "oz", 16, "oz", 0, ErrInvalidAmount},
+		{"no package size", 1, "oz", 0, "oz", 0, ErrNoPackageSize},
+		{"no package unit", 1, "oz", 16, "", 0, ErrNoPackageSize},
+		{"unknown package unit", 1, "oz", 16, "bag", 0, ErrNoPackageSize},
+		{"unknown amount unit", 1, "handful", 16, "oz", 0, ErrUnknownUnit},
+		{"different dimensions", 1, "gal", 16, "oz", 0, ErrUnitMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PackagesFor(tt.amount, tt.unit, tt.size, tt.sizeUnit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PackagesFor() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PackagesFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+-- Migration 013: Per-list shopping budget
+-- GetShoppingListByID and BuildShoppingPlan compare totals against this value
+
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS budget DECIMAL(10, 2);
@@ -0,0 +1,9 @@
+-- Migration 014: Contributor identity masking
+
+-- Per-user preference to appear as "community member" on shared prices
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_contributor_name BOOLEAN DEFAULT FALSE;
+
+-- Global switch to anonymize all contributors in public responses
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('anonymize_contributors', 'false', 'bool', 'general', 'Show all price submitters as "community member" to other users', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,15 @@
+-- Migration 015: Automatic item verification from receipt confirmations
+
+-- Distinct users who confirmed the item on a receipt (maintained by the verifier job)
+ALTER TABLE items ADD COLUMN IF NOT EXISTS receipt_user_count INT DEFAULT 0;
+
+-- How the item became verified: 'manual' or 'receipts'
+ALTER TABLE items ADD COLUMN IF NOT EXISTS verification_basis VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_items_confirmed_item ON receipt_items(confirmed_item_id)
+    WHERE is_confirmed = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('item_auto_verify_enabled', 'true', 'bool', 'general', 'Automatically verify items confirmed on receipts by multiple users', false),
+    ('item_auto_verify_min_users', '3', 'int', 'general', 'Distinct users whose receipts must confirm an item before it is verified', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,19 @@
+-- Migration 016: Price history tracking
+-- Previously only applied manually (migrations/009_price_history.sql)
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id SERIAL PRIMARY KEY,
+    store_id INT REFERENCES stores(id) ON DELETE CASCADE,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    previous_price DECIMAL(10, 2),
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_item_store ON price_history(item_id, store_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_item ON price_history(item_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_store ON price_history(store_id, recorded_at DESC);
+
+-- Lowest-ever lookups scan by item and price
+CREATE INDEX IF NOT EXISTS idx_price_history_item_price ON price_history(item_id, price);
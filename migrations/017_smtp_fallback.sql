@@ -0,0 +1,16 @@
+-- Migration 017: SMTP fallback server and per-category sender identity
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('smtp_reply_to', '', 'string', 'email', 'Default Reply-To address (blank for none)', false),
+    ('smtp_fallback_enabled', 'false', 'bool', 'email', 'Retry on a fallback SMTP server when the primary fails', false),
+    ('smtp_fallback_host', '', 'string', 'email', 'Fallback SMTP server hostname', false),
+    ('smtp_fallback_port', '587', 'int', 'email', 'Fallback SMTP server port', false),
+    ('smtp_fallback_user', '', 'string', 'email', 'Fallback SMTP authentication username', false),
+    ('smtp_fallback_password', '', 'encrypted', 'email', 'Fallback SMTP authentication password', true),
+    ('smtp_transactional_from_name', '', 'string', 'email', 'From name override for transactional emails', false),
+    ('smtp_transactional_from_addr', '', 'string', 'email', 'From address override for transactional emails', false),
+    ('smtp_transactional_reply_to', '', 'string', 'email', 'Reply-To override for transactional emails', false),
+    ('smtp_digest_from_name', '', 'string', 'email', 'From name override for digest emails', false),
+    ('smtp_digest_from_addr', '', 'string', 'email', 'From address override for digest emails', false),
+    ('smtp_digest_reply_to', '', 'string', 'email', 'Reply-To override for digest emails', false)
+ON CONFLICT (key) DO NOTHING;
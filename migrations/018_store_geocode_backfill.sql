@@ -0,0 +1,10 @@
+-- Migration 018: Store coordinate backfill via geocoding
+
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS google_place_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_stores_missing_coords ON stores(id) WHERE latitude IS NULL OR longitude IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('geocode_backfill_batch_size', '50', 'int', 'api', 'Maximum stores geocoded per backfill run', false),
+    ('geocode_backfill_delay_ms', '200', 'int', 'api', 'Delay between geocoding requests during backfill (milliseconds)', false)
+ON CONFLICT (key) DO NOTHING;
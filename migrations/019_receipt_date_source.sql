@@ -0,0 +1,9 @@
+-- Migration 019: Receipt date inference
+
+-- Where receipt_date came from: 'ocr', 'exif', 'upload' or 'manual'
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS receipt_date_source VARCHAR(20);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_date_inference_enabled', 'true', 'bool', 'general', 'Infer missing receipt dates from photo EXIF data or upload time', false),
+    ('receipt_date_day_first', 'false', 'bool', 'general', 'Read ambiguous receipt dates as DD/MM instead of MM/DD', false)
+ON CONFLICT (key) DO NOTHING;
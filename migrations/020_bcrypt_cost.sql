@@ -0,0 +1,5 @@
+-- Migration 020: Configurable bcrypt cost
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('bcrypt_cost', '10', 'int', 'auth', 'bcrypt cost for password hashes (10-16); existing hashes are upgraded on next login', false)
+ON CONFLICT (key) DO NOTHING;
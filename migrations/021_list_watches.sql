@@ -0,0 +1,18 @@
+-- Migration 021: Shopping list watches for automatic replanning
+
+CREATE TABLE IF NOT EXISTS list_watches (
+    list_id INT PRIMARY KEY REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_plan_cost DECIMAL(10, 2),
+    last_checked_at TIMESTAMP DEFAULT NOW(),
+    last_notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_watches_user ON list_watches(user_id);
+CREATE INDEX IF NOT EXISTS idx_store_prices_item_updated ON store_prices(item_id, updated_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('list_watch_enabled', 'true', 'bool', 'general', 'Replan watched shopping lists when their prices change', false),
+    ('list_watch_min_savings', '1.00', 'float', 'general', 'Minimum plan cost drop (in dollars) before notifying the list owner', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Migration 022: Preferred store types for nearby search defaults
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_store_types TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,20 @@
+-- Migration 023: Persistent inventory expiry and low-stock notifications
+
+CREATE TABLE IF NOT EXISTS inventory_notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inventory_item_id INT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    acknowledged_at TIMESTAMP,
+    UNIQUE (inventory_item_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_notifications_pending ON inventory_notifications(user_id) WHERE acknowledged_at IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('inventory_notifications_enabled', 'true', 'bool', 'general', 'Generate daily pantry expiry and low-stock notifications', false),
+    ('inventory_expiry_window_days', '7', 'int', 'general', 'Notify about pantry items expiring within this many days', false),
+    ('inventory_notification_email_enabled', 'false', 'bool', 'email', 'Email users a daily summary of new pantry notifications', false)
+ON CONFLICT (key) DO NOTHING;
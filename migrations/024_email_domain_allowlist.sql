@@ -0,0 +1,7 @@
+-- Migration 024: Email domain allow-list for user creation
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('email_domain_allowlist_enabled', 'false', 'bool', 'auth', 'Restrict admin-created accounts to approved email domains', false),
+    ('email_domain_allowlist', '', 'string', 'auth', 'Comma-separated allowed email domains; use *.example.com for subdomains', false),
+    ('email_domain_allowlist_register', 'false', 'bool', 'auth', 'Also enforce the email domain allow-list on self-registration', false)
+ON CONFLICT (key) DO NOTHING;
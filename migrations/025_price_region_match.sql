@@ -0,0 +1,5 @@
+-- Migration 025: Optional region restriction for shared price submissions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_require_region_match', 'false', 'bool', 'general', 'Only allow shared prices for stores in the submitter''s region', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Migration 026: Automatic store verification from distinct price contributors
+
+-- How the store became verified: 'manual' or 'contributors'
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS verification_basis VARCHAR(20);
+
+UPDATE stores SET verification_basis = 'manual' WHERE verified = true AND verification_basis IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_auto_verify_enabled', 'true', 'bool', 'general', 'Automatically verify public stores priced by many distinct users', false),
+    ('store_auto_verify_min_contributors', '5', 'int', 'general', 'Distinct price contributors required before a store is verified', false)
+ON CONFLICT (key) DO NOTHING;
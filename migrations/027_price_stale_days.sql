@@ -0,0 +1,5 @@
+-- Migration 027: Price staleness threshold for store data-quality metrics
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_stale_days', '30', 'int', 'general', 'Days without an update or verification before a price counts as stale', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Migration 028: Award verification reputation only once per user and price
+
+-- Set when the verifier was rewarded; survives toggling is_accurate back and forth
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS rewarded_at TIMESTAMP;
+
+UPDATE price_verifications SET rewarded_at = created_at WHERE is_accurate = true AND rewarded_at IS NULL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('reputation_verify_points', '1', 'int', 'general', 'Reputation awarded for the first accurate verification of a price', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Migration 029: Per-region store preferences for multi-region users
+
+CREATE TABLE IF NOT EXISTS user_region_prefs (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    region_id INT NOT NULL REFERENCES regions(id) ON DELETE CASCADE,
+    preferred_store_ids INT[] NOT NULL DEFAULT '{}',
+    compare_store_ids INT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, region_id)
+);
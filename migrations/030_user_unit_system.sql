@@ -0,0 +1,4 @@
+-- Migration 030: Distance display preference (distances are still computed in km)
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unit_system VARCHAR(10) NOT NULL DEFAULT 'metric'
+    CHECK (unit_system IN ('metric', 'imperial'));
@@ -0,0 +1,7 @@
+-- Migration 031: Case-insensitive username uniqueness
+-- The unique index on lower(username) is created at startup by EnsureUsernameIndex once no
+-- case-variant collisions exist; admins resolve them via /api/admin/users/username-collisions.
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('username_case_insensitive', 'true', 'bool', 'auth', 'Treat usernames differing only by case as the same username', false)
+ON CONFLICT (key) DO NOTHING;
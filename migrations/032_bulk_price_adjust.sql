@@ -0,0 +1,6 @@
+-- Migration 032: Bounds for bulk store price adjustments
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_bulk_max_change_percent', '50', 'float', 'general', 'Largest change (percent) a bulk price adjustment may apply to one price', false),
+    ('price_outlier_factor', '3', 'float', 'general', 'Bulk adjustments may not move a price beyond this multiple of the item''s median at other stores', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Migration 033: Automatic tag suggestions for items
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('tag_auto_apply_enabled', 'false', 'bool', 'general', 'Automatically add high-confidence suggested tags to new items', false),
+    ('tag_auto_apply_min_confidence', '0.8', 'float', 'general', 'Minimum confidence (0-1) for a suggested tag to be applied automatically', false),
+    ('tag_suggestion_keywords', '', 'string', 'general', 'Extra keyword=tag-slug pairs (comma separated) used for tag suggestions', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Migration 034: Region deletion with reassignment
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_delete_allow_unassign', 'false', 'bool', 'general', 'Allow force-deleting a referenced region without a reassignment target (clears region on its stores and users)', false)
+ON CONFLICT (key) DO NOTHING;
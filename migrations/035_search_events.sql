@@ -0,0 +1,19 @@
+-- Migration 035: Anonymized search events for trending searches
+-- Only the normalized query, kind and region are stored, never the user.
+
+CREATE TABLE IF NOT EXISTS search_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('item', 'store')),
+    query VARCHAR(100) NOT NULL,
+    region_id INT REFERENCES regions(id) ON DELETE SET NULL,
+    result_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_events_created ON search_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_search_events_region ON search_events(region_id, created_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('search_tracking_enabled', 'true', 'bool', 'general', 'Record anonymized item and store searches for trending searches', false),
+    ('search_events_retention_days', '30', 'int', 'general', 'Days to keep recorded search events', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration 036: Quantity-aware outlier check on price submission
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_outlier_check_enabled', 'false', 'bool', 'general', 'Ask submitters to confirm prices more than price_outlier_factor away from the typical price', false),
+    ('price_outlier_use_unit_price', 'true', 'bool', 'general', 'Compare outliers by unit price across package sizes when item sizes are known', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,8 @@
+-- Migration 037: Country, currency and locale per region
+-- Existing regions were all seeded from US data, so they take the US defaults
+
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS country_code VARCHAR(2) NOT NULL DEFAULT 'US';
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en-US';
+
+CREATE INDEX IF NOT EXISTS idx_regions_country ON regions(country_code);
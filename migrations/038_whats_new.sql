@@ -0,0 +1,11 @@
+-- Migration 038: Last-seen timestamp for the what's-new digest
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS whats_new_seen_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_stores_created_at ON stores(created_at);
+CREATE INDEX IF NOT EXISTS idx_price_history_recorded ON price_history(recorded_at);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('whats_new_first_visit_days', '7', 'int', 'general', 'Days of changes shown in the what''s-new digest before a user has opened it', false),
+    ('whats_new_store_radius_km', '15', 'int', 'general', 'Radius in km for new stores listed in the what''s-new digest', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,9 @@
+-- Migration 039: Keep prices from unverified accounts private until the email is verified
+
+ALTER TABLE store_prices ADD COLUMN IF NOT EXISTS share_on_verify BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_store_prices_share_on_verify ON store_prices(user_id) WHERE share_on_verify = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('private_prices_until_verified', 'false', 'bool', 'auth', 'Save prices from users with unverified email as private and share them once the email is verified', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Migration 040: Grace period for storage reconciliation
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('storage_reconcile_grace_minutes', '60', 'int', 'storage', 'Minutes an uploaded object may exist without a receipt before reconciliation treats it as orphaned', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration 041: Coordinate/address consistency checks for stores and user locations
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('address_validation_mode', 'warn', 'string', 'general', 'Reverse-geocode submitted coordinates and compare with the address: off, warn or block', false),
+    ('address_validation_zip_prefix', '3', 'int', 'general', 'Leading zip code digits that must match the geocoded zip (5 for exact, 0 to ignore zip codes)', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,25 @@
+-- Migration 042: Single-use tokens for external price imports
+
+CREATE TABLE IF NOT EXISTS import_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    scope VARCHAR(30) NOT NULL,
+    store_ids INTEGER[] NOT NULL DEFAULT '{}',
+    max_rows INTEGER NOT NULL,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    used_ip VARCHAR(45),
+    rows_imported INTEGER,
+    rows_rejected INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_tokens_created_at ON import_tokens(created_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('import_token_default_ttl_minutes', '60', 'int', 'general', 'Minutes an import token stays valid when no TTL is requested', false),
+    ('import_token_max_ttl_minutes', '1440', 'int', 'general', 'Longest TTL in minutes an admin can give an import token', false),
+    ('import_token_max_rows', '5000', 'int', 'general', 'Most prices a single import token can upload', false)
+ON CONFLICT (key) DO NOTHING;
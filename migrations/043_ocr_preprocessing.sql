@@ -0,0 +1,14 @@
+-- Migration 043: Image preprocessing before receipt OCR
+
+-- Steps applied before OCR, e.g. 'grayscale,contrast,deskew(1.50)', 'clean', 'holdout' or 'none'
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS ocr_preprocessing VARCHAR(100);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('ocr_preprocess_enabled', 'true', 'bool', 'general', 'Preprocess receipt images before OCR', false),
+    ('ocr_preprocess_grayscale', 'true', 'bool', 'general', 'Convert receipt images to grayscale before OCR (implied by contrast and deskew)', false),
+    ('ocr_preprocess_contrast', 'true', 'bool', 'general', 'Stretch receipt image contrast before OCR', false),
+    ('ocr_preprocess_deskew', 'false', 'bool', 'general', 'Straighten rotated receipt photos before OCR (slower)', false),
+    ('ocr_preprocess_max_skew_degrees', '5', 'float', 'general', 'Largest rotation in degrees that deskew corrects', false),
+    ('ocr_preprocess_clean_contrast', '0.8', 'float', 'general', 'Luminance spread (0-1) at which an image is clean enough to skip preprocessing', false),
+    ('ocr_preprocess_holdout_percent', '0', 'int', 'general', 'Percent of images needing preprocessing to leave untouched for match rate comparison', false)
+ON CONFLICT (key) DO NOTHING;
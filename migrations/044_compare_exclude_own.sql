@@ -0,0 +1,5 @@
+-- Migration 044: Option to rank the community best price without the user's own submissions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('compare_exclude_own_prices', 'false', 'bool', 'general', 'Compute the best price in comparisons without the requesting user''s own prices (shown separately); overridable per request with exclude_own', false)
+ON CONFLICT (key) DO NOTHING;
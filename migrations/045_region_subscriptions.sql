@@ -0,0 +1,22 @@
+-- Migration 045: Personalized region price-drop digests
+
+CREATE TABLE IF NOT EXISTS region_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    region_id INT NOT NULL REFERENCES regions(id) ON DELETE CASCADE,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly' CHECK (frequency IN ('daily', 'weekly')),
+    categories TEXT[] NOT NULL DEFAULT '{}', -- Tag slugs; empty means every category
+    min_drop_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, region_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_region_subscriptions_active ON region_subscriptions(last_sent_at) WHERE is_active = true;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_digest_enabled', 'true', 'bool', 'email', 'Email region price-drop digests to subscribers', false),
+    ('region_digest_max_items', '20', 'int', 'email', 'Most price drops listed in one region digest email', false)
+ON CONFLICT (key) DO NOTHING;
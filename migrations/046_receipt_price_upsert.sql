@@ -0,0 +1,8 @@
+-- Migration 046: Receipt confirmation updates store prices in place
+
+-- Lookup for the contributor's current price when a receipt line is confirmed
+CREATE INDEX IF NOT EXISTS idx_store_prices_store_item_user ON store_prices(store_id, item_id, user_id, updated_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_confirm_price_scope', 'contributor', 'string', 'general', 'Store price a confirmed receipt line updates: contributor (the user''s own price) or store_item (the latest price for the store and item)', false)
+ON CONFLICT (key) DO NOTHING;
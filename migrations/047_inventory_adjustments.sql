@@ -0,0 +1,17 @@
+-- Migration 047: Inventory quantity change log for consumption tracking
+
+CREATE TABLE IF NOT EXISTS inventory_adjustments (
+    id SERIAL PRIMARY KEY,
+    inventory_item_id INT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delta DECIMAL(10, 3) NOT NULL,
+    quantity_after DECIMAL(10, 3) NOT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual', -- manual, edit
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_adjustments_item ON inventory_adjustments(inventory_item_id, created_at DESC);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('inventory_restock_lead_days', '7', 'int', 'general', 'Suggest adding an inventory item to a list when its projected run-out is this many days away', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Migration 048: Cost-of-living index for cross-region price comparison
+
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS cost_of_living_index DECIMAL(6, 2) CHECK (cost_of_living_index > 0); -- 100 = baseline
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('region_price_normalize', 'false', 'bool', 'general', 'Normalize region price comparisons to the baseline cost of living by default; overridable per request with normalize', false)
+ON CONFLICT (key) DO NOTHING;
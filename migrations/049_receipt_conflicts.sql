@@ -0,0 +1,5 @@
+-- Migration 049: Confidence at which a receipt match needs no review
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_auto_match_confidence', '0.9', 'float', 'general', 'Receipt matches at or above this confidence are treated as auto-matched in the conflict view', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Migration 050: Price sharing and digest choices made at registration
+
+-- Existing users never chose to share by default, so they start opted out; new users get the
+-- column default (registration normally sets it explicitly)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS share_prices_by_default BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ALTER COLUMN share_prices_by_default SET DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Users who already subscribed to a region digest have opted in
+UPDATE users SET digest_opt_in = true
+WHERE id IN (SELECT user_id FROM region_subscriptions);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('onboarding_share_prices_default', 'true', 'bool', 'general', 'Price sharing default offered to new users at registration', false),
+    ('onboarding_digest_opt_in_default', 'false', 'bool', 'email', 'Region digest opt-in offered to new users at registration', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,23 @@
+-- Migration 051: Price alerts for items dropping below a target
+
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    store_id INT REFERENCES stores(id) ON DELETE CASCADE, -- NULL matches any store
+    target_price DECIMAL(10, 2) NOT NULL CHECK (target_price > 0),
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_item ON price_alerts(item_id);
+CREATE INDEX IF NOT EXISTS idx_price_alerts_user ON price_alerts(user_id);
+
+-- One row per alert fired by a store price at a given amount, so a submission never notifies twice
+CREATE TABLE IF NOT EXISTS price_alert_events (
+    alert_id INT NOT NULL REFERENCES price_alerts(id) ON DELETE CASCADE,
+    price_id INT NOT NULL REFERENCES store_prices(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (alert_id, price_id, price)
+);
@@ -0,0 +1,5 @@
+-- Migration 052: Whether store creators may change community stores they added
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_owner_edit_public', 'true', 'bool', 'general', 'Allow users to edit or delete public stores they created; when off only admins can change community stores', false)
+ON CONFLICT (key) DO NOTHING;
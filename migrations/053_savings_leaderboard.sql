@@ -0,0 +1,7 @@
+-- Migration 053: Savings leaderboard opt-out and benchmark window
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('savings_leaderboard_benchmark_days', '30', 'int', 'general', 'Days of community prices before a purchase averaged to estimate savings on the leaderboard', false)
+ON CONFLICT (key) DO NOTHING;
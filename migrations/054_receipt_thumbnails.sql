@@ -0,0 +1,11 @@
+-- Migration 054: Receipt thumbnails for the receipt list
+
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS thumbnail_key VARCHAR(255);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_thumbnail_enabled', 'true', 'bool', 'general', 'Generate a small preview image when a receipt is uploaded', false),
+    ('receipt_thumbnail_max_width', '320', 'int', 'general', 'Maximum receipt thumbnail width in pixels', false),
+    ('receipt_thumbnail_max_height', '480', 'int', 'general', 'Maximum receipt thumbnail height in pixels', false),
+    ('receipt_thumbnail_format', 'jpeg', 'string', 'general', 'Image format receipt thumbnails are converted to: jpeg or png', false),
+    ('receipt_thumbnail_quality', '75', 'int', 'general', 'JPEG quality (1-100) for receipt thumbnails', false)
+ON CONFLICT (key) DO NOTHING;
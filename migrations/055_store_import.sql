@@ -0,0 +1,5 @@
+-- Migration 055: Batch size for admin store imports
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('store_import_batch_size', '100', 'int', 'general', 'Imported stores inserted per transaction', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Migration 056: Archive items instead of deleting them so their price history survives
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
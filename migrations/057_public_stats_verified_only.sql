@@ -0,0 +1,7 @@
+-- Migration 057: Optionally limit public price statistics to verified prices
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('public_stats_verified_only', 'false', 'bool', 'general', 'Only count verified prices in public price statistics (histogram, region prices, price stats)', false),
+    ('public_stats_min_verifications', '1', 'int', 'general', 'Verifications a price needs before it counts toward public statistics', false),
+    ('public_stats_min_verified_prices', '3', 'int', 'general', 'Verified prices required before statistics stop falling back to all prices', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Migration 058: Product groups link the same product sold under different brands
+
+CREATE TABLE IF NOT EXISTS product_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS product_group_id INT REFERENCES product_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_items_product_group ON items(product_group_id) WHERE product_group_id IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Migration 059: Flag prices as wrong with a reason
+
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS flag_reason VARCHAR(20)
+    CHECK (flag_reason IN ('too_high', 'too_low', 'item_mismatch', 'out_of_stock', 'other'));
+ALTER TABLE price_verifications ADD COLUMN IF NOT EXISTS reason TEXT;
+
+-- Inaccurate verifications since the last accurate one; prices with enough flags drop out of
+-- comparisons and shopping plans until someone re-verifies them
+ALTER TABLE store_prices ADD COLUMN IF NOT EXISTS flag_count INT NOT NULL DEFAULT 0;
+
+UPDATE store_prices sp SET flag_count = (
+    SELECT COUNT(*) FROM price_verifications pv
+    WHERE pv.price_id = sp.id AND pv.is_accurate = false
+      AND pv.created_at > COALESCE((
+          SELECT MAX(a.created_at) FROM price_verifications a
+          WHERE a.price_id = sp.id AND a.is_accurate = true
+      ), '-infinity'::timestamp)
+)
+WHERE EXISTS (SELECT 1 FROM price_verifications pv WHERE pv.price_id = sp.id AND pv.is_accurate = false);
@@ -0,0 +1,42 @@
+-- Migration 060: Keep each item's lowest price on the item row
+-- Maintained by a trigger on store_prices so every write path stays consistent
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS best_price DECIMAL(10, 2);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS best_store_id INT REFERENCES stores(id) ON DELETE SET NULL;
+
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    UPDATE items SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        WHERE sp.item_id = p_item_id
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE id = p_item_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION store_prices_refresh_best_price() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_item_best_price(OLD.item_id);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.item_id IS DISTINCT FROM OLD.item_id) THEN
+        PERFORM refresh_item_best_price(NEW.item_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price
+    AFTER INSERT OR DELETE OR UPDATE OF price, store_id, item_id ON store_prices
+    FOR EACH ROW EXECUTE FUNCTION store_prices_refresh_best_price();
+
+-- Backfill
+SELECT refresh_item_best_price(id) FROM items WHERE EXISTS (SELECT 1 FROM store_prices sp WHERE sp.item_id = items.id);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('item_best_price_denormalized', 'true', 'bool', 'general', 'Read list estimates and spending from the stored best price instead of scanning every price', false)
+ON CONFLICT (key) DO NOTHING;
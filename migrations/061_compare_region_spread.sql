@@ -0,0 +1,6 @@
+-- Migration 061: Flag or restrict price comparisons across regions
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('compare_single_region_only', 'false', 'bool', 'general', 'Reject price comparisons whose stores are in different regions', false),
+    ('compare_max_store_spread_km', '80', 'float', 'general', 'Warn when compared stores are further apart than this many km (0 disables)', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,22 @@
+-- Migration 062: One place for each user's notification choices
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    region_digest_email BOOLEAN NOT NULL DEFAULT FALSE, -- Only record of the region digest opt-in (migration 078)
+    price_alert_email BOOLEAN NOT NULL DEFAULT TRUE,
+    list_watch_email BOOLEAN NOT NULL DEFAULT TRUE,
+    inventory_alerts BOOLEAN NOT NULL DEFAULT TRUE,
+    inventory_email BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Migration 078 drops users.digest_opt_in; skip the copy if it already ran
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'users' AND column_name = 'digest_opt_in') THEN
+        INSERT INTO notification_preferences (user_id, region_digest_email)
+        SELECT id, digest_opt_in FROM users
+        ON CONFLICT (user_id) DO NOTHING;
+    END IF;
+END $$;
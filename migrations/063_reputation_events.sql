@@ -0,0 +1,29 @@
+-- Migration 063: Reputation ledger and configurable award values
+
+CREATE TABLE IF NOT EXISTS reputation_events (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INT NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    source_id INT, -- Price verification, store or receipt the points were awarded for
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reputation_events_user ON reputation_events(user_id, created_at DESC);
+
+-- Each contribution is rewarded at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reputation_events_source
+    ON reputation_events(user_id, reason, source_id) WHERE source_id IS NOT NULL;
+
+-- Carry existing totals into the ledger so it sums to reputation_points
+INSERT INTO reputation_events (user_id, points, reason)
+SELECT u.id, u.reputation_points, 'opening_balance'
+FROM users u
+WHERE COALESCE(u.reputation_points, 0) <> 0
+  AND NOT EXISTS (SELECT 1 FROM reputation_events e WHERE e.user_id = u.id);
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('reputation_price_verified_points', '2', 'int', 'general', 'Reputation awarded to a price submitter each time another user verifies the price as accurate', false),
+    ('reputation_store_verified_points', '5', 'int', 'general', 'Reputation awarded to the creator of a store when it is verified', false),
+    ('reputation_receipt_confirmed_points', '3', 'int', 'general', 'Reputation awarded for confirming a receipt', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Migration 064: Presigned receipt image URL caching and generation limits
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_url_validity_minutes', '60', 'int', 'storage', 'Minutes a presigned receipt image URL stays valid', false),
+    ('receipt_url_refresh_minutes', '10', 'int', 'storage', 'Regenerate a cached receipt image URL once fewer than this many minutes of validity remain', false),
+    ('receipt_url_max_per_minute', '30', 'int', 'storage', 'Fresh presigned receipt image URLs one user can generate per minute (0 for no limit)', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Migration 065: Minimum drop for the price drops feed
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('price_drop_min_percent', '5', 'float', 'general', 'Smallest percent decrease listed in the price drops feed', false)
+ON CONFLICT (key) DO NOTHING;
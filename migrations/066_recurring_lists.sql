@@ -0,0 +1,10 @@
+-- Migration 066: Recurring shopping lists
+
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS recurrence VARCHAR(20) NOT NULL DEFAULT 'none'
+    CHECK (recurrence IN ('none', 'weekly', 'biweekly', 'monthly'));
+
+-- Date the next copy is due; only set while recurrence is not 'none'
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS next_occurrence DATE;
+
+CREATE INDEX IF NOT EXISTS idx_shopping_lists_recurring ON shopping_lists(next_occurrence)
+    WHERE recurrence <> 'none';
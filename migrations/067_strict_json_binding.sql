@@ -0,0 +1,5 @@
+-- Migration 067: Strict JSON request binding
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('strict_json_binding', 'false', 'bool', 'api', 'Reject write requests whose JSON body contains unknown fields', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Migration 068: Restock inventory from completed shopping lists
+
+-- Set the first time a completed list restocks inventory so that reopening and completing
+-- the list again does not add the same purchases twice
+ALTER TABLE shopping_lists ADD COLUMN IF NOT EXISTS inventory_restocked_at TIMESTAMP;
@@ -0,0 +1,55 @@
+-- Migration 069: Normalize names of items created from receipts
+
+-- Word rules applied to receipt text before it becomes a catalog item name. A receipt word
+-- equal to pattern (case-insensitive) is replaced; an empty replacement drops the word.
+CREATE TABLE IF NOT EXISTS item_name_rules (
+    id SERIAL PRIMARY KEY,
+    pattern VARCHAR(50) NOT NULL UNIQUE,
+    replacement VARCHAR(100) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO item_name_rules (pattern, replacement) VALUES
+    ('gv', 'Great Value'),
+    ('ks', 'Kirkland Signature'),
+    ('mm', 'Member''s Mark'),
+    ('org', 'Organic'),
+    ('whl', 'Whole'),
+    ('chkn', 'Chicken'),
+    ('brst', 'Breast'),
+    ('bnls', 'Boneless'),
+    ('sknls', 'Skinless'),
+    ('grnd', 'Ground'),
+    ('bf', 'Beef'),
+    ('mlk', 'Milk'),
+    ('chse', 'Cheese'),
+    ('shrd', 'Shredded'),
+    ('brd', 'Bread'),
+    ('wht', 'White'),
+    ('whe', 'Wheat'),
+    ('brn', 'Brown'),
+    ('grn', 'Green'),
+    ('frsh', 'Fresh'),
+    ('frzn', 'Frozen'),
+    ('veg', 'Vegetable'),
+    ('frt', 'Fruit'),
+    ('jce', 'Juice'),
+    ('flr', 'Flour'),
+    ('lrg', 'Large'),
+    ('med', 'Medium'),
+    ('sml', 'Small'),
+    ('gal', 'Gallon'),
+    ('pkg', 'Package'),
+    ('btl', 'Bottle'),
+    ('ea', ''),
+    ('@', '')
+ON CONFLICT (pattern) DO NOTHING;
+
+-- Receipt text an item was created from, kept for matching later receipts
+ALTER TABLE items ADD COLUMN IF NOT EXISTS raw_name TEXT;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_item_name_normalize', 'true', 'bool', 'general', 'Clean up names of items created from receipts using the item name rules', false)
+ON CONFLICT (key) DO NOTHING;
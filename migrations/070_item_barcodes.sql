@@ -0,0 +1,5 @@
+-- Migration 070: Item barcodes
+
+-- UPC/EAN barcode stored as a 14-digit GTIN (left-padded with zeros) so that UPC-A, EAN-13
+-- and the GTIN-14 printed on some receipts all find the same item
+ALTER TABLE items ADD COLUMN IF NOT EXISTS barcode VARCHAR(14) UNIQUE;
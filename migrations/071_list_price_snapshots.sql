@@ -0,0 +1,23 @@
+-- Migration 071: Shopping list price snapshots
+
+-- A saved copy of a list's estimated cost, so later snapshots can be compared against it
+CREATE TABLE IF NOT EXISTS list_price_snapshots (
+    id SERIAL PRIMARY KEY,
+    list_id INT NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    estimated_total DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_price_snapshots_list ON list_price_snapshots(list_id, created_at DESC);
+
+-- Each list item's quantity and best known price when the snapshot was taken
+CREATE TABLE IF NOT EXISTS list_price_snapshot_items (
+    snapshot_id INT NOT NULL REFERENCES list_price_snapshots(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    item_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    price DECIMAL(10,2),
+    store_name VARCHAR(200),
+    PRIMARY KEY (snapshot_id, item_id)
+);
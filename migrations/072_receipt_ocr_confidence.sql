@@ -0,0 +1,8 @@
+-- Migration 072: Per-line OCR confidence on receipt items
+
+-- Tesseract confidence (0-100) of the text line an item was parsed from
+ALTER TABLE receipt_items ADD COLUMN IF NOT EXISTS ocr_confidence REAL;
+
+INSERT INTO system_settings (key, value, value_type, category, description, is_sensitive) VALUES
+    ('receipt_ocr_min_confidence', '60', 'float', 'storage', 'OCR confidence (0-100) below which a receipt line is flagged for review', false)
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Migration 073: Scope item barcode uniqueness
+
+-- A barcode identifies one public item, and at most one private item per owner, so a user's
+-- private copy of a product does not block the public catalog entry (or another user's copy)
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_barcode_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_barcode_public ON items(barcode)
+    WHERE barcode IS NOT NULL AND is_private = false;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_barcode_private ON items(created_by, barcode)
+    WHERE barcode IS NOT NULL AND is_private = true;
@@ -0,0 +1,5 @@
+-- Migration 074: Remember failed store geocoding attempts
+
+-- The backfill retries stores that have never been attempted first, then the longest-ago
+-- failures, so stores that cannot be geocoded do not block the rest of the queue
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS geocode_attempted_at TIMESTAMP;
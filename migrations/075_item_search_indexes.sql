@@ -0,0 +1,9 @@
+-- Migration 075: Indexes for item search and receipt matching
+
+-- Item search narrows candidates with trigram and ILIKE matches on name, brand and receipt
+-- text, exact brand words and matching tags before scoring them
+CREATE INDEX IF NOT EXISTS idx_items_brand_trgm ON items USING gin(brand gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_items_brand_lower ON items(LOWER(brand));
+CREATE INDEX IF NOT EXISTS idx_items_raw_name_trgm ON items USING gin(raw_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_item_tags_tag ON item_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_tags_name_lower ON tags(LOWER(name));
@@ -0,0 +1,7 @@
+-- Migration 076: Read receipt dates in the order of the receipt's region
+
+-- The store's or uploader's region locale now decides day-first dates; the setting is the
+-- fallback for receipts with no known region
+UPDATE system_settings
+SET description = 'Read ambiguous receipt dates as DD/MM when neither the store nor the uploader has a region'
+WHERE key = 'receipt_date_day_first';
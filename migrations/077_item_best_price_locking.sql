@@ -0,0 +1,23 @@
+-- Migration 077: Serialize best price refreshes per item
+
+-- Two transactions writing prices for the same item each recomputed the best price from their
+-- own snapshot, so the later commit could store a value that missed the other's write. Locking
+-- the item row first makes the recompute wait, and it then reads the committed prices.
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    PERFORM 1 FROM items WHERE id = p_item_id FOR UPDATE;
+
+    UPDATE items SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        WHERE sp.item_id = p_item_id
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE id = p_item_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Repair any values that drifted before the lock was taken
+SELECT refresh_item_best_price(id) FROM items
+WHERE best_price IS DISTINCT FROM (SELECT MIN(sp.price) FROM store_prices sp WHERE sp.item_id = items.id);
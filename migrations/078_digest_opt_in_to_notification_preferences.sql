@@ -0,0 +1,14 @@
+-- Migration 078: notification_preferences is the only record of the region digest choice
+-- Copy users.digest_opt_in for anyone still without a preferences row, then drop the column.
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'users' AND column_name = 'digest_opt_in') THEN
+        INSERT INTO notification_preferences (user_id, region_digest_email)
+        SELECT id, digest_opt_in FROM users
+        ON CONFLICT (user_id) DO NOTHING;
+    END IF;
+END $$;
+
+ALTER TABLE users DROP COLUMN IF EXISTS digest_opt_in;
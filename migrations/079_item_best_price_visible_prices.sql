@@ -0,0 +1,93 @@
+-- Migration 079: Best price from community-visible prices, refreshed once per statement
+
+-- Only prices anyone could see count toward an item's best price: shared, not hidden by flags
+-- (models.PriceFlagHideThreshold), at a public store, for an item that is not archived. The
+-- affected items are locked in id order so writers touching several items cannot deadlock.
+CREATE OR REPLACE FUNCTION refresh_item_best_prices(p_item_ids INT[]) RETURNS VOID AS $$
+BEGIN
+    PERFORM 1 FROM items WHERE id = ANY(p_item_ids) ORDER BY id FOR UPDATE;
+
+    UPDATE items i SET (best_price, best_store_id) = (
+        SELECT sp.price, sp.store_id
+        FROM store_prices sp
+        JOIN stores s ON s.id = sp.store_id
+        WHERE sp.item_id = i.id
+          AND i.archived_at IS NULL
+          AND sp.is_shared = true
+          AND sp.flag_count < 3
+          AND COALESCE(s.is_private, false) = false
+        ORDER BY sp.price ASC, sp.updated_at DESC
+        LIMIT 1
+    )
+    WHERE i.id = ANY(p_item_ids);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_item_best_price(p_item_id INT) RETURNS VOID AS $$
+BEGIN
+    PERFORM refresh_item_best_prices(ARRAY[p_item_id]);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Refresh every item a statement touched in one call instead of once per row
+CREATE OR REPLACE FUNCTION store_prices_refresh_best_prices() RETURNS TRIGGER AS $$
+DECLARE
+    changed INT[];
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        SELECT array_agg(DISTINCT item_id) INTO changed FROM new_prices;
+    ELSIF TG_OP = 'DELETE' THEN
+        SELECT array_agg(DISTINCT item_id) INTO changed FROM old_prices;
+    ELSE
+        SELECT array_agg(item_id) INTO changed
+        FROM (SELECT item_id FROM old_prices UNION SELECT item_id FROM new_prices) ids;
+    END IF;
+
+    IF changed IS NOT NULL THEN
+        PERFORM refresh_item_best_prices(changed);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sharing and flag changes matter now too, so updates fire on any column
+DROP TRIGGER IF EXISTS trg_store_prices_best_price ON store_prices;
+DROP FUNCTION IF EXISTS store_prices_refresh_best_price();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_insert ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_insert
+    AFTER INSERT ON store_prices
+    REFERENCING NEW TABLE AS new_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_update ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_update
+    AFTER UPDATE ON store_prices
+    REFERENCING OLD TABLE AS old_prices NEW TABLE AS new_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+DROP TRIGGER IF EXISTS trg_store_prices_best_price_delete ON store_prices;
+CREATE TRIGGER trg_store_prices_best_price_delete
+    AFTER DELETE ON store_prices
+    REFERENCING OLD TABLE AS old_prices
+    FOR EACH STATEMENT EXECUTE FUNCTION store_prices_refresh_best_prices();
+
+-- Archiving or restoring an item clears or restores its best price
+CREATE OR REPLACE FUNCTION items_refresh_best_price_on_archive() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_item_best_prices(ARRAY[NEW.id]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_items_best_price_archive ON items;
+CREATE TRIGGER trg_items_best_price_archive
+    AFTER UPDATE OF archived_at ON items
+    FOR EACH ROW WHEN (OLD.archived_at IS DISTINCT FROM NEW.archived_at)
+    EXECUTE FUNCTION items_refresh_best_price_on_archive();
+
+-- Recompute every stored best price under the new rules
+SELECT refresh_item_best_prices(ARRAY(
+    SELECT id FROM items
+    WHERE best_price IS NOT NULL OR EXISTS (SELECT 1 FROM store_prices sp WHERE sp.item_id = items.id)
+));
@@ -234,6 +234,13 @@ const userApi = {
     return api.get(`/users/${id}/stats`);
   },
 
+  /**
+   * Get how a user's price verifications held up over time
+   */
+  getVerificationImpact(id) {
+    return api.get(`/users/${id}/verification-impact`);
+  },
+
   /**
    * Change user password
    */
@@ -386,6 +393,19 @@ const itemsApi = {
     return api.get(`/items/${id}`);
   },
 
+  /**
+   * Get the lowest price ever recorded for an item
+   * @param {number} id - Item ID
+   * @param {Object} params - Optional { store_id, region_id }
+   */
+  getLowestEver(id, params = {}) {
+    const query = new URLSearchParams();
+    if (params.store_id) query.set('store_id', params.store_id);
+    if (params.region_id) query.set('region_id', params.region_id);
+    const queryStr = query.toString();
+    return api.get(`/items/${id}/lowest-ever${queryStr ? '?' + queryStr : ''}`);
+  },
+
   /**
    * Get item statistics
    */
@@ -640,8 +660,9 @@ const compareApi = {
    * Get price comparison matrix
    * @param {number[]} storeIds - Array of store IDs to compare
    * @param {number[]} itemIds - Array of item IDs to compare (optional)
+   * @param {Object} coords - Optional { latitude, longitude } to include store distances
    */
-  getComparison(storeIds, itemIds = null) {
+  getComparison(storeIds, itemIds = null, coords = null) {
     const query = new URLSearchParams();
     if (storeIds && storeIds.length > 0) {
       query.set('store_ids', storeIds.join(','));
@@ -649,6 +670,10 @@ const compareApi = {
     if (itemIds && itemIds.length > 0) {
       query.set('item_ids', itemIds.join(','));
     }
+    if (coords && coords.latitude != null && coords.longitude != null) {
+      query.set('lat', coords.latitude);
+      query.set('lng', coords.longitude);
+    }
     const queryStr = query.toString();
     return api.get(`/compare${queryStr ? '?' + queryStr : ''}`);
   },
@@ -841,8 +866,9 @@ const regionsApi = {
   /**
    * Delete a region (admin)
    */
-  delete(id) {
-    return api.delete(`/admin/regions/${id}`);
+  delete(id, reassignTo = null) {
+    const query = reassignTo ? `?force=true&reassign_to=${encodeURIComponent(reassignTo)}` : '';
+    return api.delete(`/admin/regions/${id}${query}`);
   },
 };
 